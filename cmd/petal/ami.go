@@ -16,13 +16,21 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/schollz/progressbar/v3"
+	"github.com/scttfrdmn/petal/internal/config"
 	"github.com/scttfrdmn/petal/pkg/ami"
+	"github.com/scttfrdmn/petal/pkg/provisioner"
+	"github.com/scttfrdmn/petal/pkg/software"
+	"github.com/scttfrdmn/petal/pkg/state"
 	"github.com/scttfrdmn/petal/pkg/template"
 	"github.com/spf13/cobra"
 )
@@ -38,6 +46,50 @@ var (
 	amiSkipCleanup  bool
 	amiDetach       bool
 	amiWatch        bool
+	amiAllowConcur  bool
+	amiOutputMeta   string
+	amiFromCluster  string
+	amiFromRunning  string
+	amiStopHeadNode bool
+	amiSpackLock    string
+	amiSpackConfig  []string
+	amiLicenseFiles []string
+	amiValidateOnly bool
+	amiSkipLmod     bool
+	amiMaxWallClock time.Duration
+	amiPollInterval time.Duration
+	amiWatchPoll    time.Duration
+	amiTags         map[string]string
+	amiTmplTags     bool
+	amiWebhook      string
+	amiMilestones   []int
+	amiPreInstall   string
+	amiEncrypt      bool
+	amiKMSKeyID     string
+	amiRootVolume   int
+	amiSpot         bool
+	amiMaxSpotPrice string
+	amiBuildType    string
+	amiBaseAMIArch  string
+	amiInstanceID   string
+	amiRegion       string
+	amiToRegion     string
+	amiAccounts     []string
+	amiPublic       bool
+	amiRevoke       bool
+	amiForceCancel  bool
+	amiOrphaned     bool
+	amiSeedDirs     []string
+	amiSkipRegistry bool
+	amiReuse        bool
+	buildsStatus    string
+	buildsSince     string
+	buildsSort      string
+	gcOlderThan     string
+	matrixDir       string
+	matrixArchs     []string
+	matrixParallel  int
+	matrixSuffix    string
 )
 
 // amiCmd represents the ami command group
@@ -49,6 +101,16 @@ var amiCmd = &cobra.Command{
 Custom AMIs dramatically reduce cluster creation time by pre-installing
 all software during the AMI build process (30-90 minutes once), allowing
 clusters to boot in 2-3 minutes instead of waiting hours for software installation.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Opt-in pruning of old build states (ami.auto_cleanup_builds in config)
+		cfg, err := config.Load()
+		if err != nil {
+			return
+		}
+		if err := ami.CleanupOnStartup(cfg); err != nil && verbose {
+			fmt.Printf("⚠️  Warning: failed to clean up old build states: %v\n", err)
+		}
+	},
 }
 
 // buildAMICmd builds a custom AMI from a template
@@ -65,17 +127,168 @@ This command:
 
 The process typically takes 30-90 minutes depending on the number of packages.
 
+With --from-running-cluster, nothing is installed: the head node's root volume
+is imaged as it is. The AMI is a backup of that head node, keeping its Slurm
+configuration and identity, so pctl will not create clusters from it.
+
 Example:
-  pctl ami build -t bioinformatics.yaml --name bio-cluster-v1 --subnet-id subnet-xxx --key-name my-key`,
+  pctl ami build -t bioinformatics.yaml --name bio-cluster-v1 --subnet-id subnet-xxx --key-name my-key
+
+  # Rebuild from the seed an existing cluster was created with
+  pctl ami build --from-cluster my-cluster --name bio-cluster-v2 --subnet-id subnet-xxx
+
+  # Reproduce an exact software environment from a concretized spack.lock
+  pctl ami build --seed bio.yaml --from-spack-lock spack.lock --name bio-cluster-v3 --subnet-id subnet-xxx
+
+  # Use site Spack settings, e.g. system compilers and MPI as externals
+  pctl ami build --seed bio.yaml --spack-config packages.yaml --spack-config config.yaml --name bio-cluster-v4 --subnet-id subnet-xxx
+
+  # Stage a license file for a commercial compiler; :sensitive keeps it out of the AMI
+  pctl ami build --seed intel.yaml --license-file ./intel.lic:/opt/intel/licenses/intel.lic:sensitive --name intel-v1 --subnet-id subnet-xxx
+
+  # Configure site repositories and proxies before Spack runs
+  pctl ami build --seed bio.yaml --pre-install-script s3://my-bucket/site-setup.sh --name bio-cluster-v9 --subnet-id subnet-xxx
+
+  # Encrypt the AMI's snapshots with a customer-managed KMS key
+  pctl ami build --seed bio.yaml --encrypt --kms-key-id alias/ami-snapshots --name bio-cluster-v10 --subnet-id subnet-xxx
+
+  # Give large Spack stacks a 100 GiB root volume
+  pctl ami build --seed bio.yaml --root-volume-size 100 --name bio-cluster-v11 --subnet-id subnet-xxx
+
+  # Build on a spot instance, paying at most $0.30/hour
+  pctl ami build --seed bio.yaml --spot --max-spot-price 0.30 --name bio-cluster-v12 --subnet-id subnet-xxx
+
+  # Compile a large stack on 48 cores instead of the default 16
+  pctl ami build --seed bio.yaml --build-instance-type c6a.12xlarge --name bio-cluster-v13 --subnet-id subnet-xxx
+
+  # Build on an instance family pctl does not recognize as Graviton
+  pctl ami build --seed bio-arm.yaml --build-instance-type a1.4xlarge --base-ami-arch arm64 --name bio-arm-v1 --subnet-id subnet-xxx
+
+  # Spack-only AMI without Lmod, for Spack environments or containers
+  pctl ami build --seed bio.yaml --skip-lmod --name bio-spack-v1 --subnet-id subnet-xxx
+
+  # Tag the AMI for cost tracking; the seed's metadata section is added too
+  pctl ami build --seed bio.yaml --name bio-cluster-v7 --subnet-id subnet-xxx --tags project=genomics,owner=alice
+
+  # Post to a chat webhook at 25/50/75% installed and when the build finishes
+  pctl ami build --seed bio.yaml --name bio-cluster-v8 --subnet-id subnet-xxx --notify-webhook https://hooks.example.com/pctl --notify-on-progress
+
+  # Keep the stopped build instance to create more AMIs from it (experimental)
+  pctl ami build --seed bio.yaml --name bio-cluster-v14 --subnet-id subnet-xxx --reuse-instance
+
+  # Give up, terminate the instance, and remove any unfinished AMI after 10 hours
+  pctl ami build --seed bio.yaml --name bio-cluster-v6 --subnet-id subnet-xxx --max-wall-clock 10h
+
+  # Check the subnet, key pair, base AMI, architecture, and vCPU quota without building
+  pctl ami build --seed bio.yaml --name bio-cluster-v5 --subnet-id subnet-xxx --key-name my-key --validate-only
+
+  # Back up the head node of a running cluster, keeping changes made on it by hand
+  pctl ami build --from-running-cluster my-cluster --name my-cluster-head-v1
+
+  # Stop the head node first for a consistent filesystem, then start it again
+  pctl stop my-cluster
+  pctl ami build --from-running-cluster my-cluster --name my-cluster-head-v2 --stop-head-node`,
 	RunE: runBuildAMI,
 }
 
+// buildMatrixCmd builds AMIs for several seeds and architectures
+var buildMatrixCmd = &cobra.Command{
+	Use:   "build-matrix",
+	Short: "Build AMIs for every seed in a directory and architecture",
+	Long: `Build an AMI for each seed in a directory and each requested architecture.
+
+Each combination is built like 'pctl ami build', with its own build state,
+and named <seed>-<arch>-<suffix>. An AMI only boots on clusters whose head
+node shares its architecture, so combinations whose seed has a head node of
+another architecture are skipped, as are seeds without software packages.
+
+Up to --parallelism builds run at once. A failed build does not stop the
+others; a summary table of every combination is printed at the end, and the
+command fails if any build failed.
+
+Examples:
+  pctl ami build-matrix --templates ./seeds/ --arch x86_64,arm64 --subnet-id subnet-xxx
+
+  # Run four builds at a time and name the AMIs <seed>-<arch>-v2
+  pctl ami build-matrix --templates ./seeds/ --parallelism 4 --name-suffix v2 --subnet-id subnet-xxx
+
+  # Start every build and exit, then follow them with 'pctl ami list-builds'
+  pctl ami build-matrix --templates ./seeds/ --subnet-id subnet-xxx --detach`,
+	RunE: runBuildMatrix,
+}
+
+// snapshotAMICmd creates an AMI from a kept build instance
+var snapshotAMICmd = &cobra.Command{
+	Use:   "snapshot INSTANCE_ID",
+	Short: "Create an AMI from a build instance kept with --reuse-instance",
+	Long: `Create an AMI from a stopped build instance kept by 'pctl ami build
+--reuse-instance', without launching an instance or reinstalling software.
+This is experimental.
+
+The AMI gets the same template and fingerprint tags as the original build,
+plus any --tags. The instance is terminated afterwards unless
+--reuse-instance is given again.
+
+Examples:
+  # Create another AMI and keep the instance for more
+  pctl ami snapshot i-0123456789abcdef0 --name bio-cluster-v2 --reuse-instance
+
+  # Create a final AMI and terminate the instance
+  pctl ami snapshot i-0123456789abcdef0 --name bio-cluster-v3 --tags stage=prod`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotAMI,
+}
+
+// attachAMICmd resumes a build from its instance
+var attachAMICmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Resume an AMI build from its build instance",
+	Long: `Resume monitoring an AMI build whose local build record was lost.
+
+The build is reconstructed from the tags on the build instance (build ID,
+template name, fingerprint, and progress). If the instance is still
+installing software, attach waits for it to finish; it then creates the AMI
+and terminates the instance, as ami build would have.
+
+Example:
+  pctl ami attach --instance-id i-0123456789abcdef0 --name bio-cluster-v1 --region us-west-2`,
+	RunE: runAttachAMI,
+}
+
 // listAMIsCmd lists all custom AMIs
 var listAMIsCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all custom AMIs",
-	Long:  `List all pctl-managed custom AMIs in the current region.`,
-	RunE:  runListAMIs,
+	Long: `List all pctl-managed custom AMIs in the current region.
+
+With --orphaned, only AMIs that cannot be traced back to a known seed or
+cluster are shown. An AMI is referenced when a cluster uses it, when its
+template name or fingerprint matches a seed recorded in cluster state or
+found in a --seed-dir directory, or when its template name matches a
+template in a configured registry. Use 'pctl ami prune' to delete them.
+
+Examples:
+  pctl ami list
+
+  # AMIs with no matching seed or cluster
+  pctl ami list --orphaned --seed-dir ./seeds`,
+	RunE: runListAMIs,
+}
+
+// pruneAMIsCmd deletes orphaned AMIs
+var pruneAMIsCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete AMIs without a matching seed or cluster",
+	Long: `Delete the AMIs, and their snapshots, that 'pctl ami list --orphaned'
+shows: pctl-managed AMIs not used by any cluster and not matching any seed in
+cluster state, a --seed-dir directory, or a configured registry.
+
+The AMIs are listed and deletion must be confirmed. If a registry cannot be
+listed, nothing is deleted, since AMIs of its templates would look orphaned;
+use --skip-registry to check against local seeds only.`,
+	Example: `  # Delete AMIs whose seeds are gone
+  pctl ami prune --seed-dir ./seeds`,
+	RunE: runPruneAMIs,
 }
 
 // deleteAMICmd deletes a custom AMI
@@ -90,6 +303,46 @@ Example:
 	RunE: runDeleteAMI,
 }
 
+// copyAMICmd copies a custom AMI to another region
+var copyAMICmd = &cobra.Command{
+	Use:   "copy [ami-id]",
+	Short: "Copy a custom AMI to another region",
+	Long: `Copy a custom AMI to another region so clusters there can use it.
+
+The copy keeps the AMI's name, description, and tags, so pctl recognizes it
+in the destination region. By default the command waits until the copy is
+available, which can take a while for large AMIs.
+
+Examples:
+  pctl ami copy ami-1234567890abcdef --to-region eu-west-1
+
+  # Start the copy and return immediately
+  pctl ami copy ami-1234567890abcdef --to-region eu-west-1 --detach`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCopyAMI,
+}
+
+// shareAMICmd shares a custom AMI with other AWS accounts
+var shareAMICmd = &cobra.Command{
+	Use:   "share [ami-id]",
+	Short: "Share a custom AMI with other AWS accounts",
+	Long: `Share a custom AMI with other AWS accounts.
+
+Sharing with an account grants it permission to launch the AMI and to create
+volumes from the AMI's EBS snapshots, which it needs to launch the AMI.
+
+Examples:
+  pctl ami share ami-1234567890abcdef --account 123456789012 --account 210987654321
+
+  # Stop sharing with an account
+  pctl ami share ami-1234567890abcdef --account 123456789012 --revoke
+
+  # Let any AWS account launch the AMI (asks for confirmation)
+  pctl ami share ami-1234567890abcdef --public`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShareAMI,
+}
+
 // statusBuildCmd checks the status of an AMI build
 var statusBuildCmd = &cobra.Command{
 	Use:   "status [build-id]",
@@ -102,10 +355,38 @@ This command shows:
 - AMI ID (if complete)
 - Error message (if failed)
 
+With --output json, the build state is printed as JSON for scripts, and pctl
+exits non-zero if the build failed.
+
 Example:
-  pctl ami status 550e8400-e29b-41d4-a716-446655440000`,
+  pctl ami status 550e8400-e29b-41d4-a716-446655440000
+
+  # Build state as JSON
+  pctl ami status 550e8400-e29b-41d4-a716-446655440000 --output json`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runStatusBuild,
+	Annotations: map[string]string{jsonOutputAnnotation: "true"},
+}
+
+// cancelBuildCmd stops an in-progress AMI build
+var cancelBuildCmd = &cobra.Command{
+	Use:   "cancel [build-id]",
+	Short: "Cancel an in-progress AMI build",
+	Long: `Cancel an in-progress AMI build, such as one started with --detach.
+
+The build instance is terminated and the build is marked failed. Cancelling a
+build that has already finished does nothing.
+
+A build that is already creating its AMI is only cancelled with --force; the
+partially created AMI and its snapshots are then deleted.
+
+Examples:
+  pctl ami cancel 550e8400-e29b-41d4-a716-446655440000
+
+  # Cancel a build that has started creating its AMI
+  pctl ami cancel 550e8400-e29b-41d4-a716-446655440000 --force`,
 	Args: cobra.ExactArgs(1),
-	RunE: runStatusBuild,
+	RunE: runCancelBuild,
 }
 
 // listBuildsCmd lists all AMI builds
@@ -114,21 +395,53 @@ var listBuildsCmd = &cobra.Command{
 	Short: "List all AMI builds",
 	Long: `List all AMI builds (in-progress, completed, and failed).
 
-Example:
-  pctl ami list-builds`,
+Examples:
+  pctl ami list-builds
+
+  # Failed builds from the last day
+  pctl ami list-builds --status failed --since 24h
+
+  # Longest builds first
+  pctl ami list-builds --sort duration`,
 	RunE: runListBuilds,
 }
 
+// gcBuildsCmd removes old build states
+var gcBuildsCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove old AMI build records",
+	Long: `Remove completed and failed AMI build records older than the retention
+period. In-progress builds are never removed.
+
+The default retention is ami.build_retention_days from the config file (30 days).
+Set ami.auto_cleanup_builds: true to prune automatically when running ami commands.
+
+Examples:
+  pctl ami gc
+  pctl ami gc --older-than 7d`,
+	RunE: runGCBuilds,
+}
+
 func init() {
 	rootCmd.AddCommand(amiCmd)
 	amiCmd.AddCommand(buildAMICmd)
+	amiCmd.AddCommand(buildMatrixCmd)
+	amiCmd.AddCommand(attachAMICmd)
+	amiCmd.AddCommand(snapshotAMICmd)
 	amiCmd.AddCommand(listAMIsCmd)
+	amiCmd.AddCommand(pruneAMIsCmd)
 	amiCmd.AddCommand(deleteAMICmd)
+	amiCmd.AddCommand(copyAMICmd)
+	amiCmd.AddCommand(shareAMICmd)
 	amiCmd.AddCommand(statusBuildCmd)
+	amiCmd.AddCommand(cancelBuildCmd)
 	amiCmd.AddCommand(listBuildsCmd)
+	amiCmd.AddCommand(gcBuildsCmd)
+
+	cancelBuildCmd.Flags().BoolVar(&amiForceCancel, "force", false, "cancel a build that is already creating its AMI, deleting the partial AMI")
 
 	// Build AMI flags
-	buildAMICmd.Flags().StringVar(&amiSeedFile, "seed", "", "seed file (required)")
+	buildAMICmd.Flags().StringVar(&amiSeedFile, "seed", "", "seed file (required unless --from-cluster or --from-running-cluster is set)")
 	buildAMICmd.Flags().StringVarP(&amiTemplateFile, "template", "t", "", "DEPRECATED: use --seed instead")
 	buildAMICmd.Flags().StringVar(&amiName, "name", "", "AMI name (required)")
 	buildAMICmd.Flags().StringVar(&amiDescription, "description", "", "AMI description")
@@ -137,13 +450,355 @@ func init() {
 	buildAMICmd.Flags().IntVar(&amiTimeout, "timeout", 480, "timeout in minutes for software installation (default: 8 hours)")
 	buildAMICmd.Flags().BoolVar(&amiSkipCleanup, "no-cleanup", false, "skip automatic cleanup before AMI creation (not recommended)")
 	buildAMICmd.Flags().BoolVar(&amiDetach, "detach", false, "start build and exit immediately (build continues in AWS)")
+	buildAMICmd.Flags().BoolVar(&amiAllowConcur, "allow-concurrent", false, "allow a build while another build of the same configuration is in progress")
+	buildAMICmd.Flags().StringVar(&amiOutputMeta, "output-metadata", "", "write build results as JSON to this file")
+	buildAMICmd.Flags().StringVar(&amiFromCluster, "from-cluster", "", "use the seed an existing cluster was created from")
+	buildAMICmd.Flags().StringVar(&amiFromRunning, "from-running-cluster", "", "back up the head node of a running cluster as an AMI instead of building from a seed (not usable with --custom-ami)")
+	buildAMICmd.Flags().BoolVar(&amiStopHeadNode, "stop-head-node", false, "stop the head node while snapshotting it, then start it again; the compute fleet must be stopped (with --from-running-cluster)")
+	buildAMICmd.Flags().StringVar(&amiSpackLock, "from-spack-lock", "", "install exact package versions from a spack.lock instead of the seed's package specs; overrides build.spack_lock")
+	buildAMICmd.Flags().StringArrayVar(&amiSpackConfig, "spack-config", nil, "Spack config file (config.yaml, packages.yaml, ...) to install in Spack's site scope (repeatable); overrides build.spack_config")
+	buildAMICmd.Flags().BoolVar(&amiValidateOnly, "validate-only", false, "check build prerequisites and report each check without launching anything")
+	buildAMICmd.Flags().StringToStringVar(&amiTags, "tags", nil, "additional AMI tags (key=value,...)")
+	buildAMICmd.Flags().BoolVar(&amiTmplTags, "tags-from-template", true, "tag the AMI with the seed's metadata and package count")
+	buildAMICmd.Flags().StringVar(&amiWebhook, "notify-webhook", "", "URL to POST a JSON notification to when the build completes or fails")
+	buildAMICmd.Flags().IntSliceVar(&amiMilestones, "notify-on-progress", nil, "also notify the webhook when installation reaches these percentages")
+	buildAMICmd.Flags().Lookup("notify-on-progress").NoOptDefVal = "25,50,75"
+	buildAMICmd.Flags().DurationVar(&amiPollInterval, "progress-interval", ami.DefaultPollInterval, "how often to check installation progress (minimum 10s)")
+	buildAMICmd.Flags().DurationVar(&amiMaxWallClock, "max-wall-clock", 0, "maximum time for the whole build, after which it is cleaned up and marked failed (e.g., 10h; default no limit)")
+	buildAMICmd.Flags().BoolVar(&amiSkipLmod, "skip-lmod", false, "build a Spack-only AMI without Lmod (for Spack environments or containers)")
+	buildAMICmd.Flags().StringArrayVar(&amiLicenseFiles, "license-file", nil, "license file to stage on the build instance as src:dest, or src:dest:sensitive to remove it before the AMI is created (repeatable)")
+	buildAMICmd.Flags().BoolVar(&amiEncrypt, "encrypt", false, "encrypt the AMI's EBS snapshots, failing the build if they end up unencrypted")
+	buildAMICmd.Flags().StringVar(&amiKMSKeyID, "kms-key-id", "", "KMS key ID, alias, or ARN to encrypt snapshots with (with --encrypt; default: the account's default EBS key)")
+	buildAMICmd.Flags().BoolVar(&amiSpot, "spot", false, "launch the build instance as a spot instance (cheaper, but the build fails if EC2 reclaims it)")
+	buildAMICmd.Flags().StringVar(&amiMaxSpotPrice, "max-spot-price", "", "maximum hourly spot price in USD (with --spot; default: the on-demand price)")
+	buildAMICmd.Flags().StringVar(&amiBuildType, "build-instance-type", "", "instance type to build on; must match the seed's architecture (default: c6a.4xlarge, or c7g.4xlarge for arm64 seeds)")
+	buildAMICmd.Flags().StringVar(&amiBaseAMIArch, "base-ami-arch", "", "base AMI architecture, x86_64 or arm64, checked against the instance types with EC2 (default: guessed from the build instance type)")
+	buildAMICmd.Flags().IntVar(&amiRootVolume, "root-volume-size", 0, "root volume size in GiB for the build instance and the AMI, on gp3 (default: the base AMI's size)")
+	buildAMICmd.Flags().BoolVar(&amiReuse, "reuse-instance", false, "keep the build instance stopped after the AMI is created, for 'pctl ami snapshot' (experimental)")
+	buildAMICmd.Flags().StringVar(&amiPreInstall, "pre-install-script", "", "sh or bash script (local path or s3:// URI) to run before any software is installed; overrides build.pre_install_script")
 
-	buildAMICmd.MarkFlagRequired("template")
 	buildAMICmd.MarkFlagRequired("name")
-	buildAMICmd.MarkFlagRequired("subnet-id")
+
+	// Build matrix flags
+	buildMatrixCmd.Flags().StringVar(&matrixDir, "templates", "", "directory of seed files to build (required)")
+	buildMatrixCmd.Flags().StringSliceVar(&matrixArchs, "arch", []string{template.ArchitectureX86_64, template.ArchitectureARM64}, "architectures to build for (x86_64, arm64)")
+	buildMatrixCmd.Flags().IntVar(&matrixParallel, "parallelism", 2, "maximum number of builds running at once")
+	buildMatrixCmd.Flags().StringVar(&matrixSuffix, "name-suffix", "", "suffix for AMI names, which are <seed>-<arch>-<suffix> (default: the current date and time)")
+	buildMatrixCmd.Flags().StringVar(&amiSubnetID, "subnet-id", "", "subnet ID for the build instances (required)")
+	buildMatrixCmd.Flags().StringVar(&amiKeyName, "key-name", "", "EC2 key pair name for SSH access (optional)")
+	buildMatrixCmd.Flags().IntVar(&amiTimeout, "timeout", 480, "timeout in minutes for each build's software installation (default: 8 hours)")
+	buildMatrixCmd.Flags().BoolVar(&amiDetach, "detach", false, "start every build and exit (builds continue in AWS)")
+	buildMatrixCmd.Flags().StringToStringVar(&amiTags, "tags", nil, "additional AMI tags (key=value,...)")
+	buildMatrixCmd.Flags().BoolVar(&amiSpot, "spot", false, "launch the build instances as spot instances")
+	buildMatrixCmd.MarkFlagRequired("templates")
+	buildMatrixCmd.MarkFlagRequired("subnet-id")
+
+	// Snapshot flags
+	snapshotAMICmd.Flags().StringVar(&amiName, "name", "", "AMI name (required)")
+	snapshotAMICmd.Flags().StringVar(&amiDescription, "description", "", "AMI description")
+	snapshotAMICmd.Flags().StringVar(&amiRegion, "region", "", "AWS region of the build instance (default from config)")
+	snapshotAMICmd.Flags().StringToStringVar(&amiTags, "tags", nil, "additional tags for the AMI, its snapshots, and the build instance's volumes (key=value,...)")
+	snapshotAMICmd.Flags().BoolVar(&amiReuse, "reuse-instance", false, "keep the build instance stopped for more snapshots instead of terminating it")
+	snapshotAMICmd.MarkFlagRequired("name")
+
+	// Attach flags
+	attachAMICmd.Flags().StringVar(&amiInstanceID, "instance-id", "", "build instance ID (required)")
+	attachAMICmd.Flags().StringVar(&amiName, "name", "", "AMI name (required)")
+	attachAMICmd.Flags().StringVar(&amiDescription, "description", "", "AMI description")
+	attachAMICmd.Flags().StringVar(&amiRegion, "region", "", "AWS region of the build instance (default from config)")
+	attachAMICmd.Flags().IntVar(&amiTimeout, "timeout", 480, "timeout in minutes for the remaining software installation")
+	attachAMICmd.Flags().DurationVar(&amiPollInterval, "progress-interval", ami.DefaultPollInterval, "how often to check installation progress (minimum 10s)")
+	attachAMICmd.MarkFlagRequired("instance-id")
+	attachAMICmd.MarkFlagRequired("name")
+
+	// Copy flags
+	copyAMICmd.Flags().StringVar(&amiToRegion, "to-region", "", "region to copy the AMI to (required)")
+	copyAMICmd.Flags().StringVar(&amiRegion, "region", "", "region of the source AMI (default from config)")
+	copyAMICmd.Flags().BoolVar(&amiDetach, "detach", false, "start the copy and return without waiting for it to become available")
+	copyAMICmd.MarkFlagRequired("to-region")
+
+	// Share flags
+	shareAMICmd.Flags().StringArrayVar(&amiAccounts, "account", nil, "AWS account ID to share the AMI with (repeatable)")
+	shareAMICmd.Flags().BoolVar(&amiPublic, "public", false, "let every AWS account launch the AMI")
+	shareAMICmd.Flags().BoolVar(&amiRevoke, "revoke", false, "remove the permissions instead of granting them")
+	shareAMICmd.Flags().StringVar(&amiRegion, "region", "", "region of the AMI (default from config)")
 
 	// Status command flags
+	listAMIsCmd.Flags().BoolVar(&amiOrphaned, "orphaned", false, "only show AMIs with no matching seed or cluster")
+	listAMIsCmd.Flags().StringSliceVar(&amiSeedDirs, "seed-dir", nil, "directory of seeds to treat as known (with --orphaned, repeatable)")
+	listAMIsCmd.Flags().BoolVar(&amiSkipRegistry, "skip-registry", false, "do not treat registry templates as known (with --orphaned)")
+
+	pruneAMIsCmd.Flags().StringSliceVar(&amiSeedDirs, "seed-dir", nil, "directory of seeds to treat as known (repeatable)")
+	pruneAMIsCmd.Flags().BoolVar(&amiSkipRegistry, "skip-registry", false, "do not treat registry templates as known")
+
 	statusBuildCmd.Flags().BoolVarP(&amiWatch, "watch", "w", false, "continuously watch build progress until complete")
+	statusBuildCmd.Flags().DurationVar(&amiWatchPoll, "progress-interval", ami.MinPollInterval, "how often to refresh progress with --watch (minimum 10s)")
+
+	// List builds flags
+	listBuildsCmd.Flags().StringVar(&buildsStatus, "status", "", "only show builds with this status (launching, installing, creating, complete, failed)")
+	listBuildsCmd.Flags().StringVar(&buildsSince, "since", "", "only show builds started within this age (e.g., 24h, 7d)")
+	listBuildsCmd.Flags().StringVar(&buildsSort, "sort", "", "sort builds by 'started' (newest first) or 'duration' (longest first)")
+
+	// GC flags
+	gcBuildsCmd.Flags().StringVar(&gcOlderThan, "older-than", "", "remove finished builds older than this age (e.g., 7d; default from config)")
+}
+
+// amiNamePattern matches names EC2 accepts for AMIs.
+var amiNamePattern = regexp.MustCompile(`^[a-zA-Z0-9()\[\] ./\-'@_]{3,128}$`)
+
+// amiBuildFlags holds the values of the ami build flags that shape the
+// build options.
+type amiBuildFlags struct {
+	Name             string
+	Description      string
+	SubnetID         string
+	KeyName          string
+	TimeoutMinutes   int
+	SkipCleanup      bool
+	Detach           bool
+	AllowConcurrent  bool
+	SpackLock        string
+	SpackConfigFiles []string
+	LicenseFiles     []string
+	SkipLmod         bool
+	MaxWallClock     time.Duration
+	PollInterval     time.Duration
+	Tags             map[string]string
+	TagsFromTemplate bool
+	NotifyWebhook    string
+	Milestones       []int
+	PreInstallScript string
+	EncryptSnapshots bool
+	KMSKeyID         string
+	RootVolumeSizeGB int
+	UseSpot          bool
+	MaxSpotPrice     string
+	InstanceType     string
+	Architecture     string
+	ReuseInstance    bool
+}
+
+// currentAMIBuildFlags returns the ami build flags as parsed by cobra.
+func currentAMIBuildFlags() amiBuildFlags {
+	return amiBuildFlags{
+		Name:             amiName,
+		Description:      amiDescription,
+		SubnetID:         amiSubnetID,
+		KeyName:          amiKeyName,
+		TimeoutMinutes:   amiTimeout,
+		SkipCleanup:      amiSkipCleanup,
+		Detach:           amiDetach,
+		AllowConcurrent:  amiAllowConcur,
+		SpackLock:        amiSpackLock,
+		SpackConfigFiles: amiSpackConfig,
+		LicenseFiles:     amiLicenseFiles,
+		SkipLmod:         amiSkipLmod,
+		MaxWallClock:     amiMaxWallClock,
+		PollInterval:     amiPollInterval,
+		Tags:             amiTags,
+		TagsFromTemplate: amiTmplTags,
+		NotifyWebhook:    amiWebhook,
+		Milestones:       amiMilestones,
+		PreInstallScript: amiPreInstall,
+		EncryptSnapshots: amiEncrypt,
+		KMSKeyID:         amiKMSKeyID,
+		RootVolumeSizeGB: amiRootVolume,
+		UseSpot:          amiSpot,
+		MaxSpotPrice:     amiMaxSpotPrice,
+		InstanceType:     amiBuildType,
+		Architecture:     amiBaseAMIArch,
+		ReuseInstance:    amiReuse,
+	}
+}
+
+// buildOptionsFromFlags assembles and validates build options for tmpl,
+// starting from ami.DefaultBuildOptions.
+func buildOptionsFromFlags(tmpl *template.Template, flags amiBuildFlags) (*ami.BuildOptions, error) {
+	if flags.Name == "" {
+		return nil, fmt.Errorf("--name is required")
+	}
+	if !amiNamePattern.MatchString(flags.Name) {
+		return nil, fmt.Errorf("invalid AMI name %q: must be 3-128 characters of letters, numbers, spaces, and ()[]./-'@_", flags.Name)
+	}
+	if flags.SubnetID == "" {
+		return nil, fmt.Errorf("--subnet-id is required")
+	}
+	if flags.TimeoutMinutes <= 0 {
+		return nil, fmt.Errorf("--timeout must be a positive number of minutes, got %d", flags.TimeoutMinutes)
+	}
+	if flags.MaxWallClock < 0 {
+		return nil, fmt.Errorf("--max-wall-clock must not be negative, got %v", flags.MaxWallClock)
+	}
+	if flags.SkipLmod && tmpl.Software.GetModuleSystem() == template.ModuleSystemLmod && len(tmpl.Software.DefaultModules) > 0 {
+		return nil, fmt.Errorf("--skip-lmod cannot be used with software.default_modules, which are loaded through Lmod")
+	}
+	if err := validateProgressInterval(flags.PollInterval); err != nil {
+		return nil, err
+	}
+	for key, value := range flags.Tags {
+		if err := template.ValidateTag(key, value); err != nil {
+			return nil, fmt.Errorf("invalid --tags: %w", err)
+		}
+	}
+	if flags.NotifyWebhook != "" {
+		if err := ami.ValidateWebhookURL(flags.NotifyWebhook); err != nil {
+			return nil, fmt.Errorf("invalid --notify-webhook: %w", err)
+		}
+		if flags.Detach {
+			return nil, fmt.Errorf("--notify-webhook cannot be used with --detach; notifications are only sent while pctl is watching the build")
+		}
+	}
+	if len(flags.Milestones) > 0 {
+		if flags.NotifyWebhook == "" {
+			return nil, fmt.Errorf("--notify-on-progress requires --notify-webhook")
+		}
+		if err := ami.ValidateProgressMilestones(flags.Milestones); err != nil {
+			return nil, fmt.Errorf("invalid --notify-on-progress: %w", err)
+		}
+	}
+
+	if flags.PreInstallScript != "" {
+		if _, err := software.LoadPreInstallScript(flags.PreInstallScript); err != nil {
+			return nil, fmt.Errorf("invalid --pre-install-script: %w", err)
+		}
+	}
+
+	if flags.KMSKeyID != "" {
+		if !flags.EncryptSnapshots {
+			return nil, fmt.Errorf("--kms-key-id requires --encrypt")
+		}
+		if err := ami.ValidateKMSKeyID(flags.KMSKeyID); err != nil {
+			return nil, fmt.Errorf("invalid --kms-key-id: %w", err)
+		}
+	}
+
+	if err := ami.ValidateRootVolumeSize(flags.RootVolumeSizeGB); err != nil {
+		return nil, fmt.Errorf("invalid --root-volume-size: %w", err)
+	}
+
+	if flags.MaxSpotPrice != "" {
+		if !flags.UseSpot {
+			return nil, fmt.Errorf("--max-spot-price requires --spot")
+		}
+		if err := ami.ValidateSpotPrice(flags.MaxSpotPrice); err != nil {
+			return nil, fmt.Errorf("invalid --max-spot-price: %w", err)
+		}
+	}
+
+	if flags.ReuseInstance {
+		if flags.Detach {
+			return nil, fmt.Errorf("--reuse-instance cannot be used with --detach")
+		}
+		if flags.UseSpot {
+			return nil, fmt.Errorf("--reuse-instance cannot be used with --spot; spot build instances cannot be stopped and kept")
+		}
+	}
+
+	if flags.Architecture != "" {
+		if err := ami.ValidateArchitecture(flags.Architecture); err != nil {
+			return nil, fmt.Errorf("invalid --base-ami-arch: %w", err)
+		}
+	}
+	if flags.InstanceType != "" {
+		if !strings.Contains(flags.InstanceType, ".") {
+			return nil, fmt.Errorf("invalid --build-instance-type %q: expected an EC2 instance type such as c6a.8xlarge", flags.InstanceType)
+		}
+		// With --base-ami-arch, the build checks the types with EC2 instead
+		if flags.Architecture == "" {
+			if err := ami.ValidateBuildArchitecture(tmpl, flags.InstanceType); err != nil {
+				return nil, fmt.Errorf("invalid --build-instance-type: %w", err)
+			}
+		}
+	}
+
+	opts := ami.DefaultBuildOptions()
+	opts.Name = flags.Name
+	opts.Description = flags.Description
+	if opts.Description == "" {
+		if flags.SpackLock != "" || tmpl.Build.SpackLock != "" {
+			opts.Description = fmt.Sprintf("pctl AMI for %s template from spack.lock", tmpl.Cluster.Name)
+		} else {
+			opts.Description = fmt.Sprintf("pctl AMI for %s template with %d packages",
+				tmpl.Cluster.Name, len(tmpl.Software.SpackPackages))
+		}
+	}
+	opts.InstanceType = flags.InstanceType
+	opts.Architecture = flags.Architecture
+	if opts.InstanceType == "" {
+		arch := flags.Architecture
+		if arch == "" {
+			arch = tmpl.Architecture()
+		}
+		opts.InstanceType = ami.DefaultBuildInstanceType(arch)
+	}
+	opts.SubnetID = flags.SubnetID
+	opts.KeyName = flags.KeyName
+	opts.WaitTimeout = time.Duration(flags.TimeoutMinutes) * time.Minute
+	opts.SkipCleanup = flags.SkipCleanup
+	opts.Detach = flags.Detach
+	opts.AllowConcurrent = flags.AllowConcurrent
+	opts.SpackLock = flags.SpackLock
+	opts.SpackConfigFiles = flags.SpackConfigFiles
+	opts.LicenseFiles = flags.LicenseFiles
+	opts.SkipLmod = flags.SkipLmod
+	opts.MaxWallClock = flags.MaxWallClock
+	if flags.PollInterval != 0 {
+		opts.PollInterval = flags.PollInterval
+	}
+	opts.Tags = template.MergeTags(opts.Tags, flags.Tags)
+	opts.TagsFromTemplate = flags.TagsFromTemplate
+	opts.NotifyWebhook = flags.NotifyWebhook
+	opts.ProgressMilestones = flags.Milestones
+	opts.PreInstallScript = flags.PreInstallScript
+	opts.EncryptSnapshots = flags.EncryptSnapshots
+	opts.KMSKeyID = flags.KMSKeyID
+	opts.RootVolumeSizeGB = flags.RootVolumeSizeGB
+	opts.UseSpot = flags.UseSpot
+	opts.MaxSpotPrice = flags.MaxSpotPrice
+	opts.ReuseInstance = flags.ReuseInstance
+
+	return opts, nil
+}
+
+// validateProgressInterval rejects polling intervals shorter than
+// ami.MinPollInterval. Zero selects the default.
+func validateProgressInterval(interval time.Duration) error {
+	if interval != 0 && interval < ami.MinPollInterval {
+		return fmt.Errorf("--progress-interval must be at least %v, got %v", ami.MinPollInterval, interval)
+	}
+	return nil
+}
+
+// runAMIPreflight runs the build preflight checks and reports each one
+// without launching anything.
+func runAMIPreflight(ctx context.Context, tmpl *template.Template, opts *ami.BuildOptions) error {
+	builder, err := ami.NewBuilder(ctx, tmpl.Cluster.Region)
+	if err != nil {
+		return fmt.Errorf("failed to create AMI builder: %w", err)
+	}
+
+	fmt.Printf("🔍 Checking build prerequisites in %s...\n\n", tmpl.Cluster.Region)
+	result := builder.NewPreflight().Run(ctx, tmpl, opts)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, check := range result.Checks {
+		icon := "✅"
+		switch check.Status {
+		case ami.PreflightFailed:
+			icon = "❌"
+		case ami.PreflightSkipped:
+			icon = "⏭️ "
+		}
+		fmt.Fprintf(w, "   %s %s\t%s\n", icon, check.Name, check.Message)
+	}
+	w.Flush()
+	fmt.Println()
+
+	if !result.Passed() {
+		return fmt.Errorf("preflight checks failed")
+	}
+	fmt.Printf("✅ All preflight checks passed. Run without --validate-only to build.\n")
+	return nil
 }
 
 func runBuildAMI(cmd *cobra.Command, args []string) error {
@@ -159,8 +814,32 @@ func runBuildAMI(cmd *cobra.Command, args []string) error {
 		seedFile = amiTemplateFile
 	}
 
+	if amiFromRunning != "" {
+		if seedFile != "" || amiFromCluster != "" {
+			return fmt.Errorf("cannot use --from-running-cluster with --seed or --from-cluster")
+		}
+		return runBuildAMIFromRunningCluster(ctx, amiFromRunning)
+	}
+	if amiStopHeadNode {
+		return fmt.Errorf("--stop-head-node requires --from-running-cluster")
+	}
+
+	if amiFromCluster != "" {
+		if seedFile != "" {
+			return fmt.Errorf("cannot use --from-cluster with --seed")
+		}
+		stateMgr, err := state.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create state manager: %w", err)
+		}
+		seedFile, err = stateMgr.TemplatePath(amiFromCluster)
+		if err != nil {
+			return fmt.Errorf("failed to resolve seed from cluster: %w", err)
+		}
+	}
+
 	if seedFile == "" {
-		return fmt.Errorf("--seed is required for AMI building")
+		return fmt.Errorf("--seed or --from-cluster is required for AMI building")
 	}
 
 	// Load and validate seed
@@ -170,72 +849,385 @@ func runBuildAMI(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load template: %w", err)
 	}
 
-	if err := tmpl.Validate(); err != nil {
-		return fmt.Errorf("template validation failed: %w", err)
+	opts, err := buildOptionsFromFlags(tmpl, currentAMIBuildFlags())
+	if err != nil {
+		return err
+	}
+
+	if amiValidateOnly {
+		return runAMIPreflight(ctx, tmpl, opts)
+	}
+
+	if err := tmpl.Validate(); err != nil {
+		return fmt.Errorf("template validation failed: %w", err)
+	}
+
+	spackLock := opts.SpackLock
+	if spackLock == "" {
+		spackLock = tmpl.Build.SpackLock
+	}
+	if len(tmpl.Software.SpackPackages) == 0 && spackLock == "" {
+		return fmt.Errorf("template has no software packages - AMI building only makes sense for templates with software")
+	}
+	if spackLock != "" && len(tmpl.Software.SpackPackages) > 0 {
+		fmt.Printf("⚠️  Installing from %s; the seed's spack_packages are ignored\n", spackLock)
+	}
+
+	fmt.Printf("✅ Template validated\n\n")
+
+	// Create AMI builder
+	builder, err := ami.NewBuilder(ctx, tmpl.Cluster.Region)
+	if err != nil {
+		return fmt.Errorf("failed to create AMI builder: %w", err)
+	}
+
+	// Show cleanup status
+	if opts.SkipCleanup {
+		fmt.Printf("⚠️  Cleanup disabled - AMI will be larger and may contain sensitive data\n\n")
+	} else {
+		fmt.Printf("✅ Cleanup enabled - AMI will be optimized for size and security\n\n")
+	}
+
+	// Show module system status
+	if tmpl.Software.GetModuleSystem() == template.ModuleSystemLmod && (opts.SkipLmod || !tmpl.Software.ShouldInstallLmod()) {
+		fmt.Printf("⏭️  Lmod disabled - packages will not be available through 'module load'\n\n")
+	}
+
+	// Show detach status
+	if opts.Detach {
+		fmt.Printf("🚀 Detach mode enabled - build will start and CLI will exit\n\n")
+	}
+
+	// Build AMI
+	metadata, err := builder.BuildAMI(ctx, tmpl, opts)
+	if err != nil {
+		return fmt.Errorf("AMI build failed: %w", err)
+	}
+
+	if amiOutputMeta != "" {
+		if err := ami.WriteMetadataFile(amiOutputMeta, metadata); err != nil {
+			return err
+		}
+		fmt.Printf("📝 Build metadata written to %s\n\n", amiOutputMeta)
+	}
+
+	// If detached, the build details were already printed by BuildAMI
+	if opts.Detach {
+		return nil
+	}
+
+	fmt.Printf("✅ AMI build successful!\n\n")
+	fmt.Printf("AMI Details:\n")
+	fmt.Printf("  ID:          %s\n", metadata.AMIID)
+	fmt.Printf("  Name:        %s\n", metadata.Name)
+	fmt.Printf("  Region:      %s\n", metadata.Region)
+	fmt.Printf("  Template:    %s\n", metadata.TemplateName)
+	fmt.Printf("  Packages:    %d\n", len(metadata.SpackPackages))
+	if metadata.SpackLockHash != "" {
+		fmt.Printf("  Spack lock:  sha256:%s\n", metadata.SpackLockHash)
+	}
+	fmt.Println()
+
+	fmt.Printf("Next steps:\n")
+	fmt.Printf("  1. Test the AMI:\n")
+	fmt.Printf("     petal create --seed %s --key-name <key> --custom-ami %s\n\n", seedFile, metadata.AMIID)
+	fmt.Printf("  2. Share the AMI with other AWS accounts if needed:\n")
+	fmt.Printf("     pctl ami share %s --account 123456789012\n\n", metadata.AMIID)
+
+	return nil
+}
+
+// runBuildAMIFromRunningCluster creates an AMI from the head node of a
+// running cluster. Unlike a seed build, nothing is installed: the AMI holds
+// whatever is on the head node now.
+func runBuildAMIFromRunningCluster(ctx context.Context, clusterName string) error {
+	if !amiNamePattern.MatchString(amiName) {
+		return fmt.Errorf("invalid AMI name %q: must be 3-128 characters of letters, numbers, spaces, and ()[]./-'@_", amiName)
+	}
+	for key, value := range amiTags {
+		if err := template.ValidateTag(key, value); err != nil {
+			return fmt.Errorf("invalid --tags: %w", err)
+		}
+	}
+
+	prov, err := provisioner.NewProvisioner()
+	if err != nil {
+		return fmt.Errorf("failed to create provisioner: %w", err)
+	}
+
+	fmt.Printf("🔍 Resolving the head node of %s...\n", clusterName)
+	instanceID, status, err := prov.GetHeadNodeInstanceID(ctx, clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve head node: %w", err)
+	}
+	if !status.Ready() {
+		return fmt.Errorf("cluster %s is %s; only a running cluster's head node can be snapshotted", clusterName, status.Status)
+	}
+	// Compute nodes depend on the head node's shared filesystems and
+	// slurmctld, so it is only stopped under a stopped fleet
+	if amiStopHeadNode && status.SchedulerState != provisioner.FleetStatusStopped {
+		return fmt.Errorf("the compute fleet of %s is %s; stop it before stopping the head node:\n  pctl stop %s", clusterName, status.SchedulerState, clusterName)
+	}
+	fmt.Printf("   Head node: %s\n\n", instanceID)
+
+	opts := ami.DefaultBuildOptions()
+	opts.Name = amiName
+	opts.Description = amiDescription
+	if opts.Description == "" {
+		opts.Description = fmt.Sprintf("pctl AMI from the head node of cluster %s", clusterName)
+	}
+	opts.Tags = template.MergeTags(opts.Tags, amiTags)
+
+	if !amiStopHeadNode {
+		fmt.Printf("⚠️  The head node keeps running; use --stop-head-node for a consistent filesystem\n\n")
+	}
+
+	builder, err := ami.NewBuilder(ctx, status.Region)
+	if err != nil {
+		return fmt.Errorf("failed to create AMI builder: %w", err)
+	}
+
+	metadata, err := builder.SnapshotHeadNode(ctx, clusterName, instanceID, amiStopHeadNode, opts)
+	if err != nil {
+		return fmt.Errorf("AMI snapshot failed: %w", err)
+	}
+
+	if amiOutputMeta != "" {
+		if err := ami.WriteMetadataFile(amiOutputMeta, metadata); err != nil {
+			return err
+		}
+		fmt.Printf("📝 Build metadata written to %s\n\n", amiOutputMeta)
+	}
+
+	fmt.Printf("✅ AMI snapshot successful!\n\n")
+	fmt.Printf("AMI Details:\n")
+	fmt.Printf("  ID:          %s\n", metadata.AMIID)
+	fmt.Printf("  Name:        %s\n", metadata.Name)
+	fmt.Printf("  Region:      %s\n", metadata.Region)
+	fmt.Printf("  Cluster:     %s\n", clusterName)
+	fmt.Println()
+	fmt.Printf("The AMI keeps the head node's Slurm configuration, munge key, SSH host\n")
+	fmt.Printf("keys, and cloud-init state, so it is a backup of this head node: pctl\n")
+	fmt.Printf("will not create clusters from it with --custom-ami.\n")
+
+	return nil
+}
+
+// matrixAMIName returns the AMI name of a build matrix cell.
+func matrixAMIName(cell ami.MatrixCell, suffix string) string {
+	return fmt.Sprintf("%s-%s-%s", cell.TemplateName(), cell.Architecture, suffix)
+}
+
+// matrixBuildOptions returns the build options for each buildable cell,
+// keyed by AMI name. Cells whose options are invalid are marked with the
+// error so the rest of the matrix still builds.
+func matrixBuildOptions(cells []ami.MatrixCell, flags amiBuildFlags, suffix string) map[string]*ami.BuildOptions {
+	options := make(map[string]*ami.BuildOptions)
+	for i := range cells {
+		cell := &cells[i]
+		if cell.Err != nil || cell.SkipReason != "" {
+			continue
+		}
+
+		cellFlags := flags
+		cellFlags.Name = matrixAMIName(*cell, suffix)
+		cellFlags.InstanceType = ami.DefaultBuildInstanceType(cell.Architecture)
+		opts, err := buildOptionsFromFlags(cell.Template, cellFlags)
+		if err != nil {
+			cell.Err = err
+			continue
+		}
+		options[cellFlags.Name] = opts
+	}
+	return options
+}
+
+func runBuildMatrix(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if matrixParallel < 1 {
+		return fmt.Errorf("--parallelism must be at least 1, got %d", matrixParallel)
+	}
+	suffix := matrixSuffix
+	if suffix == "" {
+		suffix = time.Now().Format("20060102-1504")
+	}
+
+	paths, err := ami.FindMatrixTemplates(matrixDir)
+	if err != nil {
+		return err
+	}
+	cells, err := ami.ExpandMatrix(paths, matrixArchs)
+	if err != nil {
+		return fmt.Errorf("invalid --arch: %w", err)
+	}
+	options := matrixBuildOptions(cells, currentAMIBuildFlags(), suffix)
+
+	fmt.Printf("🧱 Building %d AMI(s) from %d seed(s), %d at a time\n\n", len(options), len(paths), matrixParallel)
+	build := func(ctx context.Context, cell ami.MatrixCell) (*ami.AMIMetadata, error) {
+		name := matrixAMIName(cell, suffix)
+		fmt.Printf("▶️  Starting %s\n", name)
+
+		builder, err := ami.NewBuilder(ctx, cell.Template.Cluster.Region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AMI builder: %w", err)
+		}
+		metadata, err := builder.BuildAMI(ctx, cell.Template, options[name])
+		if err != nil {
+			fmt.Printf("❌ %s failed: %v\n", name, err)
+			return nil, err
+		}
+		fmt.Printf("✅ %s finished\n", name)
+		return metadata, nil
+	}
+	results := ami.RunMatrix(ctx, cells, matrixParallel, build)
+
+	fmt.Printf("\nBuild matrix results:\n\n")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "SEED\tARCH\tAMI NAME\tSTATUS\tDURATION\tDETAILS\n")
+	fmt.Fprintf(w, "────\t────\t────────\t──────\t────────\t───────\n")
+	for _, result := range results {
+		name, arch, duration := "-", result.Cell.Architecture, "-"
+		if arch == "" {
+			arch = "-"
+		} else {
+			name = matrixAMIName(result.Cell, suffix)
+		}
+		if result.Duration > 0 {
+			duration = formatDuration(result.Duration)
+		}
+
+		var details string
+		switch {
+		case result.Cell.SkipReason != "":
+			details = result.Cell.SkipReason
+		case result.Err != nil:
+			details = strings.SplitN(result.Err.Error(), "\n", 2)[0]
+		case result.Metadata.AMIID != "":
+			details = result.Metadata.AMIID
+		default:
+			details = "build " + result.Metadata.BuildID
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			result.Cell.TemplateName(), arch, name, result.Status(), duration, details)
+	}
+	w.Flush()
+
+	summary := ami.SummarizeMatrix(results)
+	fmt.Printf("\nSucceeded: %d, failed: %d, skipped: %d\n", summary.Succeeded, summary.Failed, summary.Skipped)
+	if amiDetach && summary.Succeeded > 0 {
+		fmt.Printf("Follow the detached builds with 'pctl ami list-builds'.\n")
+	}
+
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d AMI build(s) failed", summary.Failed, len(results)-summary.Skipped)
+	}
+	return nil
+}
+
+func runAttachAMI(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if !amiNamePattern.MatchString(amiName) {
+		return fmt.Errorf("invalid AMI name %q: must be 3-128 characters of letters, numbers, spaces, and ()[]./-'@_", amiName)
+	}
+	if amiTimeout <= 0 {
+		return fmt.Errorf("--timeout must be a positive number of minutes, got %d", amiTimeout)
+	}
+	if err := validateProgressInterval(amiPollInterval); err != nil {
+		return err
+	}
+
+	region := amiRegion
+	if region == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		region = cfg.Defaults.Region
+	}
+
+	opts := ami.DefaultBuildOptions()
+	opts.Name = amiName
+	opts.Description = amiDescription
+	if opts.Description == "" {
+		opts.Description = fmt.Sprintf("pctl AMI recovered from build instance %s", amiInstanceID)
+	}
+	opts.WaitTimeout = time.Duration(amiTimeout) * time.Minute
+	if amiPollInterval != 0 {
+		opts.PollInterval = amiPollInterval
+	}
+
+	builder, err := ami.NewBuilder(ctx, region)
+	if err != nil {
+		return fmt.Errorf("failed to create AMI builder: %w", err)
+	}
+
+	metadata, err := builder.AttachBuild(ctx, amiInstanceID, opts)
+	if err != nil {
+		return fmt.Errorf("AMI build failed: %w", err)
+	}
+
+	fmt.Printf("✅ AMI build successful!\n\n")
+	fmt.Printf("AMI Details:\n")
+	fmt.Printf("  ID:          %s\n", metadata.AMIID)
+	fmt.Printf("  Name:        %s\n", metadata.Name)
+	fmt.Printf("  Region:      %s\n", metadata.Region)
+	fmt.Printf("  Template:    %s\n", metadata.TemplateName)
+	fmt.Println()
+
+	return nil
+}
+
+func runSnapshotAMI(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	instanceID := args[0]
+
+	if !amiNamePattern.MatchString(amiName) {
+		return fmt.Errorf("invalid AMI name %q: must be 3-128 characters of letters, numbers, spaces, and ()[]./-'@_", amiName)
 	}
-
-	if len(tmpl.Software.SpackPackages) == 0 {
-		return fmt.Errorf("template has no software packages - AMI building only makes sense for templates with software")
+	for key, value := range amiTags {
+		if err := template.ValidateTag(key, value); err != nil {
+			return fmt.Errorf("invalid --tags: %w", err)
+		}
 	}
 
-	fmt.Printf("✅ Template validated\n\n")
-
-	// Create AMI builder
-	builder, err := ami.NewBuilder(ctx, tmpl.Cluster.Region)
-	if err != nil {
-		return fmt.Errorf("failed to create AMI builder: %w", err)
+	region := amiRegion
+	if region == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		region = cfg.Defaults.Region
 	}
 
-	// Prepare build options
 	opts := ami.DefaultBuildOptions()
 	opts.Name = amiName
 	opts.Description = amiDescription
 	if opts.Description == "" {
-		opts.Description = fmt.Sprintf("pctl AMI for %s template with %d packages",
-			tmpl.Cluster.Name, len(tmpl.Software.SpackPackages))
-	}
-	opts.SubnetID = amiSubnetID
-	opts.KeyName = amiKeyName
-	opts.WaitTimeout = time.Duration(amiTimeout) * time.Minute
-	opts.SkipCleanup = amiSkipCleanup
-	opts.Detach = amiDetach
-
-	// Show cleanup status
-	if amiSkipCleanup {
-		fmt.Printf("⚠️  Cleanup disabled - AMI will be larger and may contain sensitive data\n\n")
-	} else {
-		fmt.Printf("✅ Cleanup enabled - AMI will be optimized for size and security\n\n")
-	}
-
-	// Show detach status
-	if amiDetach {
-		fmt.Printf("🚀 Detach mode enabled - build will start and CLI will exit\n\n")
+		opts.Description = fmt.Sprintf("pctl AMI from build instance %s", instanceID)
 	}
+	opts.Tags = template.MergeTags(opts.Tags, amiTags)
+	opts.ReuseInstance = amiReuse
 
-	// Build AMI
-	metadata, err := builder.BuildAMI(ctx, tmpl, opts)
+	builder, err := ami.NewBuilder(ctx, region)
 	if err != nil {
-		return fmt.Errorf("AMI build failed: %w", err)
+		return fmt.Errorf("failed to create AMI builder: %w", err)
 	}
 
-	// If detached, the build details were already printed by BuildAMI
-	if amiDetach {
-		return nil
+	metadata, err := builder.SnapshotInstance(ctx, instanceID, opts)
+	if err != nil {
+		return fmt.Errorf("AMI snapshot failed: %w", err)
 	}
 
-	fmt.Printf("✅ AMI build successful!\n\n")
+	fmt.Printf("✅ AMI snapshot successful!\n\n")
 	fmt.Printf("AMI Details:\n")
 	fmt.Printf("  ID:          %s\n", metadata.AMIID)
 	fmt.Printf("  Name:        %s\n", metadata.Name)
 	fmt.Printf("  Region:      %s\n", metadata.Region)
 	fmt.Printf("  Template:    %s\n", metadata.TemplateName)
-	fmt.Printf("  Packages:    %d\n\n", len(metadata.SpackPackages))
-
-	fmt.Printf("Next steps:\n")
-	fmt.Printf("  1. Test the AMI:\n")
-	fmt.Printf("     petal create --seed %s --key-name <key> --custom-ami %s\n\n", seedFile, metadata.AMIID)
-	fmt.Printf("  2. Share the AMI with other AWS accounts if needed:\n")
-	fmt.Printf("     aws ec2 modify-image-attribute --image-id %s --launch-permission \"Add=[{UserId=123456789012}]\"\n\n", metadata.AMIID)
+	fmt.Println()
 
 	return nil
 }
@@ -258,6 +1250,18 @@ func runListAMIs(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list AMIs: %w", err)
 	}
 
+	if amiOrphaned && len(amis) > 0 {
+		known, err := loadKnownTemplates(amiSeedDirs, !amiSkipRegistry)
+		if err != nil {
+			return err
+		}
+		amis = ami.FindOrphanedAMIs(amis, known)
+		if len(amis) == 0 {
+			fmt.Println("No orphaned AMIs found.")
+			return nil
+		}
+	}
+
 	if len(amis) == 0 {
 		fmt.Println("No custom AMIs found.")
 		fmt.Println("\nBuild your first AMI with:")
@@ -277,12 +1281,144 @@ func runListAMIs(cmd *cobra.Command, args []string) error {
 
 	w.Flush()
 
+	if amiOrphaned {
+		fmt.Printf("\nTotal: %d orphaned AMI(s)\n\n", len(amis))
+		fmt.Printf("Use 'pctl ami prune' to delete them, or 'pctl ami delete <ami-id>' to remove one.\n")
+		return nil
+	}
+
 	fmt.Printf("\nTotal: %d AMI(s)\n\n", len(amis))
 	fmt.Printf("Use 'pctl create -t template.yaml --custom-ami <ami-id>' to create a cluster with a custom AMI.\n")
 
 	return nil
 }
 
+// runPruneAMIs deletes the AMIs that no known seed or cluster references.
+func runPruneAMIs(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// Determine region (use from config or default)
+	region := "us-east-1" // TODO: Get from config
+
+	manager, err := ami.NewManager(ctx, region)
+	if err != nil {
+		return fmt.Errorf("failed to create AMI manager: %w", err)
+	}
+
+	amis, err := manager.ListAMIs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list AMIs: %w", err)
+	}
+	known, err := loadKnownTemplates(amiSeedDirs, !amiSkipRegistry)
+	if err != nil {
+		return err
+	}
+	orphaned := ami.FindOrphanedAMIs(amis, known)
+	if len(orphaned) == 0 {
+		fmt.Println("No orphaned AMIs found.")
+		return nil
+	}
+
+	fmt.Printf("⚠️  About to delete %d orphaned AMI(s) and their snapshots:\n\n", len(orphaned))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "AMI ID\tNAME\tTEMPLATE\n")
+	for _, amiMeta := range orphaned {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", amiMeta.AMIID, amiMeta.Name, amiMeta.TemplateName)
+	}
+	w.Flush()
+	fmt.Printf("\nType 'yes' to confirm deletion: ")
+
+	var confirmation string
+	fmt.Scanln(&confirmation)
+
+	if confirmation != "yes" {
+		fmt.Println("\n❌ Deletion cancelled.")
+		return nil
+	}
+
+	fmt.Println()
+	failed := 0
+	for _, amiMeta := range orphaned {
+		if err := manager.DeleteAMI(ctx, amiMeta.AMIID); err != nil {
+			fmt.Printf("❌ Failed to delete %s: %v\n", amiMeta.AMIID, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ Deleted %s (%s)\n", amiMeta.AMIID, amiMeta.Name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d of %d orphaned AMIs", failed, len(orphaned))
+	}
+
+	return nil
+}
+
+// loadKnownTemplates collects the seeds and AMIs referenced by local cluster
+// state, plus any seeds found in seedDirs and, with includeRegistry, the
+// templates of every configured registry. It fails if a registry cannot be
+// listed, since its templates' AMIs would otherwise look orphaned.
+func loadKnownTemplates(seedDirs []string, includeRegistry bool) (*ami.KnownTemplates, error) {
+	known := ami.NewKnownTemplates()
+
+	stateMgr, err := state.NewManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state manager: %w", err)
+	}
+	clusters, err := stateMgr.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	for _, cluster := range clusters {
+		known.AddAMI(cluster.CustomAMI)
+		if cluster.TemplatePath != "" {
+			addKnownSeed(known, cluster.TemplatePath)
+		}
+	}
+
+	for _, dir := range seedDirs {
+		var paths []string
+		for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan seed directory %s: %w", dir, err)
+			}
+			paths = append(paths, matches...)
+		}
+		for _, path := range paths {
+			addKnownSeed(known, path)
+		}
+	}
+
+	if includeRegistry {
+		manager, err := createRegistryManager()
+		if err != nil {
+			return nil, err
+		}
+		result := manager.ListAll(context.Background())
+		if len(result.Failures) > 0 {
+			printRegistryFailures(result.Failures)
+			return nil, fmt.Errorf("failed to list registry templates; use --skip-registry to check against local seeds only")
+		}
+		for _, entry := range result.Templates {
+			known.AddName(entry.Name)
+		}
+	}
+
+	return known, nil
+}
+
+// addKnownSeed records the seed at path, skipping files that are not seeds.
+func addKnownSeed(known *ami.KnownTemplates, path string) {
+	tmpl, err := template.Load(path)
+	if err != nil || tmpl.Cluster.Name == "" {
+		if verbose {
+			fmt.Printf("⚠️  Skipping %s: not a readable seed\n", path)
+		}
+		return
+	}
+	known.AddTemplate(tmpl)
+}
+
 func runDeleteAMI(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 	amiID := args[0]
@@ -329,9 +1465,153 @@ func runDeleteAMI(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runCopyAMI(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	amiID := args[0]
+
+	region := amiRegion
+	if region == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		region = cfg.Defaults.Region
+	}
+	if err := validateCopyRegions(region, amiToRegion); err != nil {
+		return err
+	}
+
+	manager, err := ami.NewManager(ctx, region)
+	if err != nil {
+		return fmt.Errorf("failed to create AMI manager: %w", err)
+	}
+
+	fmt.Printf("📦 Copying AMI %s from %s to %s...\n", amiID, region, amiToRegion)
+
+	newAMI, err := manager.StartCopyAMI(ctx, amiID, amiToRegion)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("   New AMI ID: %s\n", newAMI)
+
+	if amiDetach {
+		fmt.Printf("\n✅ Copy started. Check it with:\n")
+		fmt.Printf("   aws ec2 describe-images --region %s --image-ids %s\n", amiToRegion, newAMI)
+		return nil
+	}
+
+	fmt.Printf("   Waiting for the copy to become available...\n")
+	if err := manager.WaitForCopy(ctx, newAMI, amiToRegion); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✅ AMI copied successfully\n")
+	fmt.Printf("   AMI ID: %s\n", newAMI)
+	fmt.Printf("   Region: %s\n", amiToRegion)
+
+	return nil
+}
+
+func runShareAMI(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	amiID := args[0]
+
+	if err := validateShareFlags(amiAccounts, amiPublic); err != nil {
+		return err
+	}
+
+	region := amiRegion
+	if region == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		region = cfg.Defaults.Region
+	}
+
+	manager, err := ami.NewManager(ctx, region)
+	if err != nil {
+		return fmt.Errorf("failed to create AMI manager: %w", err)
+	}
+
+	if amiPublic {
+		if !amiRevoke {
+			fmt.Printf("⚠️  About to make AMI %s public.\n", amiID)
+			fmt.Printf("Any AWS account will be able to launch it, including any software,\n")
+			fmt.Printf("licenses, or data installed in it.\n")
+			fmt.Printf("Type 'yes' to confirm: ")
+
+			var confirmation string
+			fmt.Scanln(&confirmation)
+			if confirmation != "yes" {
+				fmt.Println("\n❌ Sharing cancelled.")
+				return nil
+			}
+		}
+
+		if err := manager.SetAMIPublic(ctx, amiID, !amiRevoke); err != nil {
+			return err
+		}
+		if amiRevoke {
+			fmt.Printf("✅ AMI %s is no longer public\n", amiID)
+		} else {
+			fmt.Printf("✅ AMI %s is now public\n", amiID)
+		}
+		return nil
+	}
+
+	if amiRevoke {
+		if err := manager.UnshareAMI(ctx, amiID, amiAccounts); err != nil {
+			return err
+		}
+		fmt.Printf("✅ AMI %s is no longer shared with %s\n", amiID, strings.Join(amiAccounts, ", "))
+		return nil
+	}
+
+	if err := manager.ShareAMI(ctx, amiID, amiAccounts); err != nil {
+		return err
+	}
+	fmt.Printf("✅ AMI %s and its snapshots are shared with %s\n", amiID, strings.Join(amiAccounts, ", "))
+
+	return nil
+}
+
+// validateShareFlags checks that ami share was given either account IDs or
+// --public.
+func validateShareFlags(accounts []string, public bool) error {
+	if public && len(accounts) > 0 {
+		return fmt.Errorf("--public cannot be combined with --account")
+	}
+	if !public && len(accounts) == 0 {
+		return fmt.Errorf("--account or --public is required")
+	}
+	for _, id := range accounts {
+		if err := ami.ValidateAccountID(id); err != nil {
+			return fmt.Errorf("invalid --account: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateCopyRegions checks that an AMI can be copied from source to dest.
+func validateCopyRegions(source, dest string) error {
+	validRegions := template.NewValidator().ValidRegions
+	if !validRegions[dest] {
+		return fmt.Errorf("--to-region '%s' is not a valid AWS region", dest)
+	}
+	if source == dest {
+		return fmt.Errorf("--to-region must differ from the source region %s", source)
+	}
+	return nil
+}
+
 func runStatusBuild(cmd *cobra.Command, args []string) error {
 	buildID := args[0]
 
+	if amiWatch && outputFormat == outputJSON {
+		return fmt.Errorf("--watch cannot be used with --output json")
+	}
+
 	stateManager, err := ami.NewStateManager()
 	if err != nil {
 		return fmt.Errorf("failed to create state manager: %w", err)
@@ -342,6 +1622,21 @@ func runStatusBuild(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load build state: %w", err)
 	}
 
+	// A finished detached build no longer needs its fingerprint lock
+	if err := stateManager.ReleaseBuildLock(state); err != nil && verbose {
+		fmt.Printf("⚠️  Warning: %v\n", err)
+	}
+
+	if outputFormat == outputJSON {
+		if err := printJSON(state); err != nil {
+			return err
+		}
+		if state.Status == ami.BuildStatusFailed {
+			return fmt.Errorf("build %s failed", state.BuildID)
+		}
+		return nil
+	}
+
 	// Display build status
 	fmt.Printf("Build Status\n")
 	fmt.Printf("============\n\n")
@@ -378,18 +1673,43 @@ func runStatusBuild(cmd *cobra.Command, args []string) error {
 	// Show error if failed
 	if state.Status == ami.BuildStatusFailed && state.ErrorMessage != "" {
 		fmt.Printf("\n❌ Error:    %s\n", state.ErrorMessage)
+		if state.FailureCategory != "" {
+			fmt.Printf("   Category: %s\n", state.FailureCategory)
+		}
 	}
 
 	// Watch mode
+	if err := validateProgressInterval(amiWatchPoll); err != nil {
+		return err
+	}
+	if amiWatchPoll == 0 {
+		amiWatchPoll = ami.MinPollInterval
+	}
 	if amiWatch && state.Status != ami.BuildStatusComplete && state.Status != ami.BuildStatusFailed {
 		fmt.Printf("\n⏳ Watching build progress (press Ctrl+C to exit)...\n\n")
-		return watchBuild(stateManager, buildID)
+		return watchBuild(stateManager, buildID, amiWatchPoll)
 	}
 
 	return nil
 }
 
 func runListBuilds(cmd *cobra.Command, args []string) error {
+	var filter ami.BuildFilter
+	if buildsStatus != "" {
+		status, err := ami.ParseBuildStatus(buildsStatus)
+		if err != nil {
+			return err
+		}
+		filter.Status = status
+	}
+	if buildsSince != "" {
+		age, err := ami.ParseAge(buildsSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		filter.Since = time.Now().Add(-age)
+	}
+
 	stateManager, err := ami.NewStateManager()
 	if err != nil {
 		return fmt.Errorf("failed to create state manager: %w", err)
@@ -400,6 +1720,19 @@ func runListBuilds(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list builds: %w", err)
 	}
 
+	filtered := filter.Status != "" || !filter.Since.IsZero()
+	states = ami.FilterStates(states, filter)
+	if buildsSort != "" {
+		if err := ami.SortStates(states, buildsSort); err != nil {
+			return err
+		}
+	}
+
+	if len(states) == 0 && filtered {
+		fmt.Println("No builds match the given filters.")
+		return nil
+	}
+
 	if len(states) == 0 {
 		fmt.Println("No builds found.")
 		fmt.Println("\nStart a build with:")
@@ -413,12 +1746,7 @@ func runListBuilds(cmd *cobra.Command, args []string) error {
 	fmt.Fprintf(w, "────────\t──────\t────────\t────────\t───────\t────────\n")
 
 	for _, state := range states {
-		var duration string
-		if state.EndTime != nil {
-			duration = formatDuration(state.EndTime.Sub(state.StartTime))
-		} else {
-			duration = formatDuration(time.Since(state.StartTime))
-		}
+		duration := formatDuration(state.Duration())
 
 		// Truncate build ID for display
 		shortID := state.BuildID
@@ -443,6 +1771,86 @@ func runListBuilds(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runCancelBuild(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	buildID := args[0]
+
+	stateManager, err := ami.NewStateManager()
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+
+	state, err := stateManager.LoadState(buildID)
+	if err != nil {
+		return fmt.Errorf("failed to load build state: %w", err)
+	}
+
+	builder, err := ami.NewBuilder(ctx, state.Region)
+	if err != nil {
+		return fmt.Errorf("failed to create AMI builder: %w", err)
+	}
+
+	fmt.Printf("🛑 Cancelling build %s...\n", buildID)
+	cancelled, err := builder.CancelBuild(ctx, buildID, amiForceCancel)
+	if errors.Is(err, ami.ErrBuildCreating) {
+		return fmt.Errorf("%w; use --force to cancel it and delete the partial AMI", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to cancel build: %w", err)
+	}
+
+	if !cancelled {
+		fmt.Printf("ℹ️  Build %s already finished (%s); nothing to cancel\n", buildID, state.Status)
+		return nil
+	}
+
+	fmt.Printf("✅ Build cancelled\n")
+	if state.InstanceID != "" {
+		fmt.Printf("   Terminated instance %s\n", state.InstanceID)
+	}
+	return nil
+}
+
+func runGCBuilds(cmd *cobra.Command, args []string) error {
+	var retention time.Duration
+	if gcOlderThan != "" {
+		age, err := ami.ParseAge(gcOlderThan)
+		if err != nil {
+			return fmt.Errorf("invalid --older-than: %w", err)
+		}
+		retention = age
+	} else {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		retention = cfg.BuildRetention()
+	}
+
+	stateManager, err := ami.NewStateManager()
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+
+	before, err := stateManager.ListStates()
+	if err != nil {
+		return fmt.Errorf("failed to list builds: %w", err)
+	}
+
+	if err := stateManager.CleanupOldStates(retention); err != nil {
+		return fmt.Errorf("failed to clean up builds: %w", err)
+	}
+
+	after, err := stateManager.ListStates()
+	if err != nil {
+		return fmt.Errorf("failed to list builds: %w", err)
+	}
+
+	fmt.Printf("🧹 Removed %d build record(s) older than %s\n", len(before)-len(after), formatDuration(retention))
+
+	return nil
+}
+
 func formatStatus(status ami.BuildStatus) string {
 	switch status {
 	case ami.BuildStatusLaunching:
@@ -489,8 +1897,10 @@ func formatRelativeTime(t time.Time) string {
 	return "just now"
 }
 
-func watchBuild(stateManager *ami.StateManager, buildID string) error {
-	ticker := time.NewTicker(10 * time.Second)
+// watchBuild follows a build's saved state, refreshing every interval, until
+// it completes or fails.
+func watchBuild(stateManager *ami.StateManager, buildID string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	lastProgress := -1
@@ -0,0 +1,394 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scttfrdmn/petal/pkg/ami"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func amiTestTemplate() *template.Template {
+	return &template.Template{
+		Cluster:  template.ClusterConfig{Name: "bio", Region: "us-east-1"},
+		Compute:  template.ComputeConfig{HeadNode: "t3.xlarge"},
+		Software: template.SoftwareConfig{SpackPackages: []string{"samtools@1.17", "bwa@0.7.17"}},
+	}
+}
+
+func validAMIBuildFlags() amiBuildFlags {
+	return amiBuildFlags{
+		Name:           "bio-cluster-v1",
+		SubnetID:       "subnet-123",
+		TimeoutMinutes: 480,
+	}
+}
+
+func TestBuildOptionsFromFlagsDefaults(t *testing.T) {
+	opts, err := buildOptionsFromFlags(amiTestTemplate(), validAMIBuildFlags())
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+
+	if opts.Name != "bio-cluster-v1" || opts.SubnetID != "subnet-123" {
+		t.Errorf("Name, SubnetID = %q, %q", opts.Name, opts.SubnetID)
+	}
+	if opts.Description != "pctl AMI for bio template with 2 packages" {
+		t.Errorf("Description = %q", opts.Description)
+	}
+	if opts.WaitTimeout != 8*time.Hour {
+		t.Errorf("WaitTimeout = %v, want 8h", opts.WaitTimeout)
+	}
+	if opts.PollInterval != 30*time.Second {
+		t.Errorf("PollInterval = %v, want the default 30s", opts.PollInterval)
+	}
+	if opts.InstanceType != "c6a.4xlarge" {
+		t.Errorf("InstanceType = %q, want the default c6a.4xlarge", opts.InstanceType)
+	}
+	if opts.Tags["ManagedBy"] != "pctl" {
+		t.Errorf("Tags = %v, want default ManagedBy tag", opts.Tags)
+	}
+	if opts.SkipCleanup || opts.Detach || opts.AllowConcurrent || opts.KeyName != "" {
+		t.Errorf("unexpected non-default options: %+v", opts)
+	}
+}
+
+func TestBuildOptionsFromFlagsOverrides(t *testing.T) {
+	flags := amiBuildFlags{
+		Name:             "bio-cluster-v2",
+		Description:      "Bio AMI",
+		SubnetID:         "subnet-456",
+		KeyName:          "my-key",
+		TimeoutMinutes:   90,
+		SkipCleanup:      true,
+		Detach:           true,
+		AllowConcurrent:  true,
+		SkipLmod:         true,
+		MaxWallClock:     10 * time.Hour,
+		PollInterval:     time.Minute,
+		Tags:             map[string]string{"project": "genomics"},
+		TagsFromTemplate: true,
+		SpackLock:        "spack.lock",
+		SpackConfigFiles: []string{"packages.yaml"},
+		LicenseFiles:     []string{"intel.lic:/opt/intel/licenses/intel.lic"},
+	}
+
+	opts, err := buildOptionsFromFlags(amiTestTemplate(), flags)
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+
+	if opts.Description != "Bio AMI" || opts.KeyName != "my-key" || opts.WaitTimeout != 90*time.Minute {
+		t.Errorf("Description, KeyName, WaitTimeout = %q, %q, %v", opts.Description, opts.KeyName, opts.WaitTimeout)
+	}
+	if !opts.SkipCleanup || !opts.Detach || !opts.AllowConcurrent || !opts.SkipLmod {
+		t.Errorf("boolean flags not applied: %+v", opts)
+	}
+	if opts.Tags["project"] != "genomics" || opts.Tags["ManagedBy"] != "pctl" || !opts.TagsFromTemplate {
+		t.Errorf("Tags, TagsFromTemplate = %v, %v, want project tag with defaults and template tags on", opts.Tags, opts.TagsFromTemplate)
+	}
+	if opts.MaxWallClock != 10*time.Hour || opts.PollInterval != time.Minute {
+		t.Errorf("MaxWallClock, PollInterval = %v, %v, want 10h, 1m", opts.MaxWallClock, opts.PollInterval)
+	}
+	if opts.SpackLock != "spack.lock" ||
+		!reflect.DeepEqual(opts.SpackConfigFiles, flags.SpackConfigFiles) ||
+		!reflect.DeepEqual(opts.LicenseFiles, flags.LicenseFiles) {
+		t.Errorf("file options not applied: %+v", opts)
+	}
+}
+
+func TestBuildOptionsFromFlagsNotify(t *testing.T) {
+	flags := validAMIBuildFlags()
+	flags.NotifyWebhook = "https://hooks.example.com/pctl"
+	flags.Milestones = []int{25, 50, 75}
+
+	opts, err := buildOptionsFromFlags(amiTestTemplate(), flags)
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+	if opts.NotifyWebhook != flags.NotifyWebhook || !reflect.DeepEqual(opts.ProgressMilestones, flags.Milestones) {
+		t.Errorf("NotifyWebhook, ProgressMilestones = %q, %v", opts.NotifyWebhook, opts.ProgressMilestones)
+	}
+}
+
+func TestBuildOptionsFromFlagsPreInstallScript(t *testing.T) {
+	flags := validAMIBuildFlags()
+	flags.PreInstallScript = "s3://my-bucket/site-setup.sh"
+
+	opts, err := buildOptionsFromFlags(amiTestTemplate(), flags)
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+	if opts.PreInstallScript != flags.PreInstallScript {
+		t.Errorf("PreInstallScript = %q, want %q", opts.PreInstallScript, flags.PreInstallScript)
+	}
+}
+
+func TestBuildOptionsFromFlagsEncrypt(t *testing.T) {
+	flags := validAMIBuildFlags()
+	flags.EncryptSnapshots = true
+	flags.KMSKeyID = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+	opts, err := buildOptionsFromFlags(amiTestTemplate(), flags)
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+	if !opts.EncryptSnapshots || opts.KMSKeyID != flags.KMSKeyID {
+		t.Errorf("EncryptSnapshots, KMSKeyID = %v, %q", opts.EncryptSnapshots, opts.KMSKeyID)
+	}
+}
+
+func TestBuildOptionsFromFlagsRootVolumeSize(t *testing.T) {
+	flags := validAMIBuildFlags()
+	flags.RootVolumeSizeGB = 100
+
+	opts, err := buildOptionsFromFlags(amiTestTemplate(), flags)
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+	if opts.RootVolumeSizeGB != 100 {
+		t.Errorf("RootVolumeSizeGB = %d, want 100", opts.RootVolumeSizeGB)
+	}
+}
+
+func TestBuildOptionsFromFlagsSpot(t *testing.T) {
+	flags := validAMIBuildFlags()
+	flags.UseSpot = true
+	flags.MaxSpotPrice = "0.30"
+
+	opts, err := buildOptionsFromFlags(amiTestTemplate(), flags)
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+	if !opts.UseSpot || opts.MaxSpotPrice != "0.30" {
+		t.Errorf("UseSpot, MaxSpotPrice = %v, %q", opts.UseSpot, opts.MaxSpotPrice)
+	}
+}
+
+func TestBuildOptionsFromFlagsBuildInstanceType(t *testing.T) {
+	flags := validAMIBuildFlags()
+	flags.InstanceType = "c6a.12xlarge"
+
+	opts, err := buildOptionsFromFlags(amiTestTemplate(), flags)
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+	if opts.InstanceType != "c6a.12xlarge" {
+		t.Errorf("InstanceType = %q, want c6a.12xlarge", opts.InstanceType)
+	}
+
+	// arm64 seeds default to a Graviton build instance
+	armTemplate := amiTestTemplate()
+	armTemplate.Compute.HeadNode = "c7g.xlarge"
+	opts, err = buildOptionsFromFlags(armTemplate, validAMIBuildFlags())
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+	if opts.InstanceType != "c7g.4xlarge" {
+		t.Errorf("InstanceType = %q, want the arm64 default c7g.4xlarge", opts.InstanceType)
+	}
+}
+
+func TestBuildOptionsFromFlagsBaseAMIArch(t *testing.T) {
+	// The override skips the instance family guess, which does not know a1
+	flags := validAMIBuildFlags()
+	flags.InstanceType = "a1.4xlarge"
+	flags.Architecture = "arm64"
+
+	opts, err := buildOptionsFromFlags(amiTestTemplate(), flags)
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+	if opts.InstanceType != "a1.4xlarge" || opts.Architecture != "arm64" {
+		t.Errorf("InstanceType, Architecture = %q, %q", opts.InstanceType, opts.Architecture)
+	}
+
+	// Without a build instance type, the default follows the override
+	flags = validAMIBuildFlags()
+	flags.Architecture = "arm64"
+	opts, err = buildOptionsFromFlags(amiTestTemplate(), flags)
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+	if opts.InstanceType != "c7g.4xlarge" {
+		t.Errorf("InstanceType = %q, want the arm64 default c7g.4xlarge", opts.InstanceType)
+	}
+}
+
+func TestBuildOptionsFromFlagsSpackLockDescription(t *testing.T) {
+	flags := validAMIBuildFlags()
+	flags.SpackLock = "spack.lock"
+
+	opts, err := buildOptionsFromFlags(amiTestTemplate(), flags)
+	if err != nil {
+		t.Fatalf("buildOptionsFromFlags() error = %v", err)
+	}
+	if opts.Description != "pctl AMI for bio template from spack.lock" {
+		t.Errorf("Description = %q", opts.Description)
+	}
+}
+
+func TestBuildOptionsFromFlagsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*amiBuildFlags)
+		wantErr string
+	}{
+		{"empty name", func(f *amiBuildFlags) { f.Name = "" }, "--name is required"},
+		{"short name", func(f *amiBuildFlags) { f.Name = "ab" }, "invalid AMI name"},
+		{"name with invalid characters", func(f *amiBuildFlags) { f.Name = "bio:v1" }, "invalid AMI name"},
+		{"name too long", func(f *amiBuildFlags) { f.Name = strings.Repeat("a", 129) }, "invalid AMI name"},
+		{"empty subnet", func(f *amiBuildFlags) { f.SubnetID = "" }, "--subnet-id is required"},
+		{"zero timeout", func(f *amiBuildFlags) { f.TimeoutMinutes = 0 }, "--timeout must be a positive"},
+		{"negative timeout", func(f *amiBuildFlags) { f.TimeoutMinutes = -5 }, "--timeout must be a positive"},
+		{"negative max wall clock", func(f *amiBuildFlags) { f.MaxWallClock = -time.Hour }, "--max-wall-clock must not be negative"},
+		{"progress interval too short", func(f *amiBuildFlags) { f.PollInterval = 5 * time.Second }, "--progress-interval must be at least 10s"},
+		{"reserved tag prefix", func(f *amiBuildFlags) { f.Tags = map[string]string{"aws:owner": "x"} }, "invalid --tags"},
+		{"webhook not a URL", func(f *amiBuildFlags) { f.NotifyWebhook = "hooks.example.com" }, "invalid --notify-webhook"},
+		{"webhook with detach", func(f *amiBuildFlags) { f.NotifyWebhook = "https://hooks.example.com"; f.Detach = true }, "cannot be used with --detach"},
+		{"milestones without webhook", func(f *amiBuildFlags) { f.Milestones = []int{50} }, "--notify-on-progress requires --notify-webhook"},
+		{"milestone out of range", func(f *amiBuildFlags) {
+			f.NotifyWebhook = "https://hooks.example.com"
+			f.Milestones = []int{50, 100}
+		}, "invalid --notify-on-progress"},
+		{"pre-install script not a shell script", func(f *amiBuildFlags) { f.PreInstallScript = "s3://my-bucket/setup.py" }, "invalid --pre-install-script"},
+		{"kms key without encrypt", func(f *amiBuildFlags) { f.KMSKeyID = "alias/ami-snapshots" }, "--kms-key-id requires --encrypt"},
+		{"invalid kms key", func(f *amiBuildFlags) { f.EncryptSnapshots = true; f.KMSKeyID = "my key" }, "invalid --kms-key-id"},
+		{"negative root volume size", func(f *amiBuildFlags) { f.RootVolumeSizeGB = -1 }, "invalid --root-volume-size"},
+		{"root volume too large", func(f *amiBuildFlags) { f.RootVolumeSizeGB = 20000 }, "invalid --root-volume-size"},
+		{"spot price without spot", func(f *amiBuildFlags) { f.MaxSpotPrice = "0.30" }, "--max-spot-price requires --spot"},
+		{"invalid spot price", func(f *amiBuildFlags) { f.UseSpot = true; f.MaxSpotPrice = "$0.30" }, "invalid --max-spot-price"},
+		{"reuse instance with detach", func(f *amiBuildFlags) { f.ReuseInstance = true; f.Detach = true }, "--reuse-instance cannot be used with --detach"},
+		{"reuse instance with spot", func(f *amiBuildFlags) { f.ReuseInstance = true; f.UseSpot = true }, "--reuse-instance cannot be used with --spot"},
+		{"build instance type not a type", func(f *amiBuildFlags) { f.InstanceType = "xlarge" }, "invalid --build-instance-type"},
+		{"build instance type wrong architecture", func(f *amiBuildFlags) { f.InstanceType = "c7g.8xlarge" }, "invalid --build-instance-type"},
+		{"invalid base AMI architecture", func(f *amiBuildFlags) { f.Architecture = "aarch64" }, "invalid --base-ami-arch"},
+		{"pre-install script missing", func(f *amiBuildFlags) { f.PreInstallScript = "/nonexistent/setup.sh" }, "invalid --pre-install-script"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := validAMIBuildFlags()
+			tt.modify(&flags)
+			_, err := buildOptionsFromFlags(amiTestTemplate(), flags)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("buildOptionsFromFlags() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	flags := validAMIBuildFlags()
+	flags.SkipLmod = true
+	withModules := amiTestTemplate()
+	withModules.Software.DefaultModules = []string{withModules.Software.SpackPackages[0]}
+	if _, err := buildOptionsFromFlags(withModules, flags); err == nil || !strings.Contains(err.Error(), "--skip-lmod cannot be used with software.default_modules") {
+		t.Errorf("buildOptionsFromFlags() error = %v, want --skip-lmod rejected with default modules", err)
+	}
+
+	flags = validAMIBuildFlags()
+	flags.Name = "bio cluster (v1) [test] ./-'@_"
+	if _, err := buildOptionsFromFlags(amiTestTemplate(), flags); err != nil {
+		t.Errorf("buildOptionsFromFlags() rejected a valid name: %v", err)
+	}
+}
+
+func TestValidateCopyRegions(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		dest    string
+		wantErr string
+	}{
+		{name: "valid", source: "us-east-1", dest: "eu-west-1"},
+		{name: "same region", source: "us-east-1", dest: "us-east-1", wantErr: "must differ from the source region"},
+		{name: "unknown region", source: "us-east-1", dest: "eu-west-9", wantErr: "is not a valid AWS region"},
+		{name: "empty region", source: "us-east-1", dest: "", wantErr: "is not a valid AWS region"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCopyRegions(tt.source, tt.dest)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateCopyRegions() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateCopyRegions() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateShareFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts []string
+		public   bool
+		wantErr  string
+	}{
+		{name: "accounts", accounts: []string{"123456789012", "210987654321"}},
+		{name: "public", public: true},
+		{name: "neither", wantErr: "--account or --public is required"},
+		{name: "both", accounts: []string{"123456789012"}, public: true, wantErr: "cannot be combined"},
+		{name: "invalid account", accounts: []string{"1234"}, wantErr: "invalid --account"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateShareFlags(tt.accounts, tt.public)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateShareFlags() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateShareFlags() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatrixBuildOptions(t *testing.T) {
+	armTmpl := amiTestTemplate()
+	armTmpl.Compute.HeadNode = "c7g.xlarge"
+	cells := []ami.MatrixCell{
+		{TemplatePath: "seeds/bio.yaml", Template: amiTestTemplate(), Architecture: "x86_64"},
+		{TemplatePath: "seeds/bio.yaml", Template: amiTestTemplate(), Architecture: "arm64", SkipReason: "head node t3.xlarge is x86_64"},
+		{TemplatePath: "seeds/bio-arm.yaml", Template: armTmpl, Architecture: "arm64"},
+		{TemplatePath: "seeds/" + strings.Repeat("x", 130) + ".yaml", Template: amiTestTemplate(), Architecture: "x86_64"},
+	}
+
+	options := matrixBuildOptions(cells, validAMIBuildFlags(), "v2")
+
+	if len(options) != 2 {
+		t.Fatalf("Expected options for the 2 valid buildable cells, got %d", len(options))
+	}
+	if opts := options["bio-x86_64-v2"]; opts == nil || opts.InstanceType != "c6a.4xlarge" || opts.SubnetID != "subnet-123" {
+		t.Errorf("Unexpected x86_64 options: %+v", opts)
+	}
+	if opts := options["bio-arm-arm64-v2"]; opts == nil || opts.InstanceType != "c7g.4xlarge" {
+		t.Errorf("Unexpected arm64 options: %+v", opts)
+	}
+	if cells[3].Err == nil || !strings.Contains(cells[3].Err.Error(), "invalid AMI name") {
+		t.Errorf("Expected the cell with an overlong name to carry the error, got %v", cells[3].Err)
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scttfrdmn/petal/pkg/benchmark"
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/scttfrdmn/petal/pkg/template"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchmarkSuite   string
+	benchmarkQueue   string
+	benchmarkCRName  string
+	benchmarkNodes   int
+	benchmarkTimeout time.Duration
+)
+
+// benchmarkPollInterval is how often the job is checked while it runs.
+const benchmarkPollInterval = 30 * time.Second
+
+// benchmarkDir is the head node directory benchmark jobs run from.
+const benchmarkDir = "~/pctl-benchmark"
+
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark CLUSTER_NAME",
+	Short: "Run a standard benchmark job on a cluster",
+	Long: `Run a standard benchmark job on a cluster and record the result.
+
+The benchmark is submitted to Slurm over SSH and pctl waits for it to finish.
+The result is reported with the instance type and estimated on-demand cost,
+and saved to ~/.pctl/benchmarks/ for comparing configurations.
+
+Suites:
+  hpl     High-Performance Linpack (GFLOPS). Requires hpl in the seed's
+          software.spack_packages.
+  stream  STREAM memory bandwidth (MB/s) on a single node.`,
+	Example: `  # Measure memory bandwidth of a compute node
+  pctl benchmark my-cluster --suite stream
+
+  # Run HPL across 4 nodes of the mpi queue
+  pctl benchmark my-cluster --suite hpl --queue mpi --nodes 4
+
+  # Target one compute resource of a mixed queue
+  pctl benchmark my-cluster --suite stream --queue mixed --compute-resource-name large`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBenchmark,
+}
+
+func init() {
+	rootCmd.AddCommand(benchmarkCmd)
+	benchmarkCmd.Flags().StringVar(&benchmarkSuite, "suite", "", "benchmark suite to run (hpl, stream)")
+	benchmarkCmd.Flags().StringVar(&benchmarkQueue, "queue", "", "queue to run on (default: first queue in the seed)")
+	benchmarkCmd.Flags().StringVar(&benchmarkCRName, "compute-resource-name", "", "compute resource within the queue to run on (default: first)")
+	benchmarkCmd.Flags().IntVar(&benchmarkNodes, "nodes", 1, "number of nodes to run on")
+	benchmarkCmd.Flags().DurationVar(&benchmarkTimeout, "timeout", 2*time.Hour, "maximum time to wait for the job")
+	benchmarkCmd.Flags().StringVarP(&sshKeyPath, "key", "i", "", "Path to SSH private key (overrides cluster default)")
+	benchmarkCmd.Flags().StringVarP(&sshUser, "user", "u", "ec2-user", "SSH username")
+	benchmarkCmd.MarkFlagRequired("suite")
+}
+
+func runBenchmark(cmd *cobra.Command, args []string) error {
+	clusterName := args[0]
+
+	suite, err := benchmark.ParseSuite(benchmarkSuite)
+	if err != nil {
+		return err
+	}
+	if benchmarkNodes < 1 {
+		return fmt.Errorf("--nodes must be at least 1")
+	}
+	if suite == benchmark.SuiteSTREAM && benchmarkNodes != 1 {
+		return fmt.Errorf("the stream suite runs on a single node")
+	}
+
+	stateMgr, err := state.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	clusterState, err := stateMgr.Load(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to load cluster state: %w", err)
+	}
+
+	queue, resource, instanceType, err := benchmarkQueueInstanceType(stateMgr, clusterName, benchmarkQueue, benchmarkCRName)
+	if err != nil {
+		return err
+	}
+
+	target, err := resolveSSHTarget(clusterName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📊 Running %s benchmark on %s\n", suite, clusterName)
+	if queue != "" {
+		fmt.Printf("   Queue: %s\n", queue)
+	}
+	if resource != "" {
+		fmt.Printf("   Compute resource: %s\n", resource)
+	}
+	if instanceType != "" {
+		fmt.Printf("   Instance type: %s\n", instanceType)
+	}
+	fmt.Printf("   Nodes: %d\n\n", benchmarkNodes)
+
+	// Write the job script on the head node and submit it
+	submit := []string{"mkdir -p " + benchmarkDir, "cd " + benchmarkDir}
+	if setup := suite.SetupCommand(); setup != "" {
+		submit = append(submit, setup)
+	}
+	scriptName := fmt.Sprintf("pctl-%s.sbatch", suite)
+	sbatch := fmt.Sprintf("sbatch --parsable --nodes=%d", benchmarkNodes)
+	if queue != "" {
+		sbatch += " --partition=" + queue
+	}
+	if benchmarkCRName != "" {
+		// ParallelCluster tags each node with its instance type as a Slurm feature
+		sbatch += " --constraint=" + instanceType
+	}
+	submit = append(submit, "cat > "+scriptName, sbatch+" "+scriptName)
+
+	output, err := runRemote(target, suite.JobScript(), strings.Join(submit, " && "))
+	if err != nil {
+		return fmt.Errorf("failed to submit benchmark job: %w", err)
+	}
+	jobID, err := benchmark.ParseJobID(output)
+	if err != nil {
+		return fmt.Errorf("failed to submit benchmark job: %w", err)
+	}
+	fmt.Printf("✅ Submitted job %s\n", jobID)
+
+	if err := waitForJob(target, jobID, benchmarkTimeout); err != nil {
+		return err
+	}
+
+	// Read and parse the job output
+	output, err = runRemote(target, "", fmt.Sprintf("cat %s/pctl-benchmark-%s.out", benchmarkDir, jobID))
+	if err != nil {
+		return fmt.Errorf("failed to read job output: %w", err)
+	}
+
+	exitCode, elapsed, err := benchmark.ParseJobStatus(output)
+	if err != nil {
+		return fmt.Errorf("benchmark job %s did not complete: %w", jobID, err)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("benchmark job %s failed with exit code %d:\n\n%s", jobID, exitCode, lastLines(output, 20))
+	}
+
+	measurement, err := benchmark.ParseOutput(suite, output)
+	if err != nil {
+		return fmt.Errorf("failed to parse benchmark output: %w", err)
+	}
+
+	result := &benchmark.Result{
+		Suite:           suite,
+		Cluster:         clusterName,
+		Region:          clusterState.Region,
+		Queue:           queue,
+		ComputeResource: resource,
+		InstanceType:    instanceType,
+		Nodes:           benchmarkNodes,
+		JobID:           jobID,
+		Value:           measurement.Value,
+		Unit:            measurement.Unit,
+		Details:         measurement.Details,
+		ElapsedSeconds:  int(elapsed.Seconds()),
+		Timestamp:       time.Now(),
+	}
+
+	if instanceType != "" {
+		price, err := benchmark.HourlyPrice(context.Background(), clusterState.Region, instanceType)
+		if err != nil {
+			fmt.Printf("⚠️  Warning: Could not look up instance price: %v\n", err)
+		} else {
+			result.SetHourlyPrice(price)
+		}
+	}
+
+	store, err := benchmark.NewStore()
+	if err != nil {
+		return fmt.Errorf("failed to create benchmark store: %w", err)
+	}
+	path, err := store.Save(result)
+	if err != nil {
+		return fmt.Errorf("failed to save benchmark result: %w", err)
+	}
+
+	fmt.Printf("\n✅ Benchmark complete\n\n")
+	fmt.Printf("Suite:         %s\n", result.Suite)
+	if result.InstanceType != "" {
+		fmt.Printf("Instance type: %s x %d\n", result.InstanceType, result.Nodes)
+	}
+	fmt.Printf("Result:        %.2f %s\n", result.Value, result.Unit)
+	for _, kernel := range []string{"copy", "scale", "add", "triad"} {
+		if rate, ok := result.Details[kernel]; ok {
+			fmt.Printf("  %-6s       %.1f MB/s\n", kernel+":", rate)
+		}
+	}
+	fmt.Printf("Run time:      %s\n", elapsed)
+	if result.HourlyPrice > 0 {
+		fmt.Printf("Cost:          $%.2f ($%.4f/hr per node)\n", result.Cost, result.HourlyPrice)
+	}
+	fmt.Printf("Saved:         %s\n", path)
+
+	return nil
+}
+
+// benchmarkQueueInstanceType resolves the queue, compute resource, and
+// instance type to run on from the cluster's seed. The seed is optional
+// unless a compute resource is requested: without it the job runs on the
+// requested (or default) partition and the instance type is unknown.
+func benchmarkQueueInstanceType(stateMgr *state.Manager, clusterName, queueName, resourceName string) (queue, resource, instanceType string, err error) {
+	seedFile, err := stateMgr.TemplatePath(clusterName)
+	var tmpl *template.Template
+	if err == nil {
+		tmpl, err = template.Load(seedFile)
+	}
+	if err != nil {
+		if resourceName != "" {
+			return "", "", "", fmt.Errorf("failed to load seed to resolve compute resource %s: %w", resourceName, err)
+		}
+		fmt.Printf("⚠️  Warning: %v; instance type and cost will not be reported\n", err)
+		return queueName, "", "", nil
+	}
+
+	for _, q := range tmpl.Compute.Queues {
+		if queueName != "" && q.Name != queueName {
+			continue
+		}
+
+		if len(q.ComputeResources) == 0 {
+			if resourceName != "" {
+				return "", "", "", fmt.Errorf("queue %s has no named compute resources", q.Name)
+			}
+			if len(q.InstanceTypes) == 0 {
+				return q.Name, "", "", nil
+			}
+			return q.Name, "", q.InstanceTypes[0], nil
+		}
+
+		if resourceName == "" {
+			r := q.ComputeResources[0]
+			if len(q.ComputeResources) > 1 {
+				fmt.Printf("⚠️  Warning: queue %s has several compute resources; reporting %s (use --compute-resource-name to target one)\n", q.Name, r.Name)
+			}
+			return q.Name, r.Name, r.InstanceTypes[0], nil
+		}
+
+		for _, r := range q.ComputeResources {
+			if r.Name != resourceName {
+				continue
+			}
+			if len(r.InstanceTypes) != 1 {
+				return "", "", "", fmt.Errorf("compute resource %s has several instance types and cannot be targeted", r.Name)
+			}
+			return q.Name, r.Name, r.InstanceTypes[0], nil
+		}
+		return "", "", "", fmt.Errorf("compute resource %s not found in queue %s", resourceName, q.Name)
+	}
+
+	if resourceName != "" {
+		return "", "", "", fmt.Errorf("queue %s not found in seed", queueName)
+	}
+	fmt.Printf("⚠️  Warning: queue %s not found in seed; instance type and cost will not be reported\n", queueName)
+	return queueName, "", "", nil
+}
+
+// waitForJob polls Slurm until the job leaves the queue.
+func waitForJob(target *sshTarget, jobID string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	lastState := ""
+
+	for {
+		// squeue forgets finished jobs, so a job it no longer knows is done
+		output, err := runRemote(target, "", fmt.Sprintf("squeue -h -j %s -o %%T", jobID))
+		jobState, err := benchmark.ParseJobState(output, err)
+		if err != nil {
+			return fmt.Errorf("failed to check job status: %w", err)
+		}
+
+		if jobState == "" || jobState == "COMPLETED" {
+			return nil
+		}
+		if jobState != lastState {
+			fmt.Printf("⏳ Job %s: %s\n", jobID, jobState)
+			lastState = jobState
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for job %s (still %s)\n\nTo cancel it, run on the head node:\n  scancel %s", timeout, jobID, jobState, jobID)
+		}
+		time.Sleep(benchmarkPollInterval)
+	}
+}
+
+// runRemote runs a command in a login shell on the head node, so the Slurm
+// and module environment is available, and returns its standard output.
+func runRemote(target *sshTarget, stdin, command string) (string, error) {
+	sshCmd := target.command("bash", "-lc", shellQuote(command))
+
+	var stdout, stderr bytes.Buffer
+	sshCmd.Stdin = strings.NewReader(stdin)
+	sshCmd.Stdout = &stdout
+	sshCmd.Stderr = &stderr
+
+	if err := sshCmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// shellQuote quotes s as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// lastLines returns the last n lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
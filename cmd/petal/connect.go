@@ -0,0 +1,105 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/scttfrdmn/petal/pkg/provisioner"
+	"github.com/spf13/cobra"
+)
+
+var connectCmd = &cobra.Command{
+	Use:   "connect CLUSTER_NAME",
+	Short: "Connect to cluster head node via SSM Session Manager",
+	Long: `Connect to the cluster head node using AWS Systems Manager Session Manager.
+
+Session Manager needs no open SSH port, public IP, or SSH key, which makes it
+suitable for clusters in private subnets. It requires the AWS CLI and the
+Session Manager plugin to be installed locally.
+
+If Session Manager is not available, pctl falls back to SSH.`,
+	Example: `  # Connect via Session Manager
+  pctl connect my-cluster
+
+  # Key and user are used only if falling back to SSH
+  pctl connect my-cluster --key ~/.ssh/my-key.pem --user ubuntu`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConnect,
+}
+
+func init() {
+	rootCmd.AddCommand(connectCmd)
+	connectCmd.Flags().StringVarP(&sshKeyPath, "key", "i", "", "Path to SSH private key for SSH fallback")
+	connectCmd.Flags().StringVarP(&sshUser, "user", "u", "ec2-user", "SSH username for SSH fallback")
+}
+
+func runConnect(cmd *cobra.Command, args []string) error {
+	clusterName := args[0]
+
+	if err := checkSSMAvailable(); err != nil {
+		fmt.Printf("⚠️  Session Manager not available: %v\n", err)
+		fmt.Printf("   Falling back to SSH\n\n")
+		return runSSH(cmd, args)
+	}
+
+	prov, err := provisioner.NewProvisioner()
+	if err != nil {
+		return fmt.Errorf("failed to create provisioner: %w", err)
+	}
+
+	ctx := context.Background()
+	instanceID, status, err := prov.GetHeadNodeInstanceID(ctx, clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve head node: %w", err)
+	}
+
+	if !status.Ready() {
+		return fmt.Errorf("cluster is not ready for connection (status: %s)\n\nRun 'pctl status %s' to check cluster state", status.Status, clusterName)
+	}
+
+	fmt.Printf("🔗 Connecting to %s via Session Manager...\n", clusterName)
+	fmt.Printf("   Instance: %s\n", instanceID)
+	fmt.Printf("   Region:   %s\n\n", status.Region)
+
+	ssmCmd := exec.Command("aws", "ssm", "start-session",
+		"--target", instanceID,
+		"--region", status.Region,
+	)
+
+	ssmCmd.Stdin = os.Stdin
+	ssmCmd.Stdout = os.Stdout
+	ssmCmd.Stderr = os.Stderr
+
+	if err := ssmCmd.Run(); err != nil {
+		return fmt.Errorf("session manager connection failed: %w", err)
+	}
+
+	return nil
+}
+
+// checkSSMAvailable verifies the AWS CLI and Session Manager plugin are installed.
+func checkSSMAvailable() error {
+	if _, err := exec.LookPath("aws"); err != nil {
+		return fmt.Errorf("AWS CLI not found in PATH")
+	}
+	if _, err := exec.LookPath("session-manager-plugin"); err != nil {
+		return fmt.Errorf("session-manager-plugin not found in PATH (see https://docs.aws.amazon.com/systems-manager/latest/userguide/session-manager-working-with-install-plugin.html)")
+	}
+	return nil
+}
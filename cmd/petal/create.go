@@ -15,34 +15,64 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/scttfrdmn/petal/internal/config"
 	"github.com/scttfrdmn/petal/pkg/ami"
+	"github.com/scttfrdmn/petal/pkg/capture"
+	"github.com/scttfrdmn/petal/pkg/network"
 	"github.com/scttfrdmn/petal/pkg/provisioner"
+	"github.com/scttfrdmn/petal/pkg/software"
+	"github.com/scttfrdmn/petal/pkg/state"
 	"github.com/scttfrdmn/petal/pkg/template"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
 )
 
 var (
-	createSeed      string
-	createTemplate  string // Deprecated, use createSeed
-	createName      string
-	createRegion    string
-	createKeyName   string
-	createSubnetID  string
-	createCustomAMI string
-	createWait      bool
-	rebuildAMI      bool
-	dryRun          bool
-	forceBootstrap  bool
+	createSeed           string
+	createTemplate       string // Deprecated, use createSeed
+	createName           string
+	createRegion         string
+	createKeyName        string
+	createSubnetID       string
+	createCustomAMI      string
+	createWait           bool
+	rebuildAMI           bool
+	forceBootstrap       bool
+	createTags           map[string]string
+	createDNSDomain      string
+	createDNSServers     []string
+	createExportCFN      string
+	createDefaultVPC     bool
+	createPollEvery      time.Duration
+	createMonitorTimeout time.Duration
+	createHealth         bool
+	createScaleZero      bool
+	createSSHCIDRs       []string
+	createAZCount        int
+	createEnableNAT      bool
+	createVPCID          string
+	createNoS3EP         bool
+	createOutputConfig   string
+	createEFAGDR         bool
+	createCapacityGroups map[string]string
 )
 
 var createCmd = &cobra.Command{
-	Use:     "create",
-	Aliases: []string{"bloom", "grow"},
-	Short:   "Create a new HPC cluster",
+	Use:         "create",
+	Annotations: map[string]string{dryRunAnnotation: "true"},
+	Aliases:     []string{"bloom", "grow"},
+	Short:       "Create a new HPC cluster",
 	Long: `Create a new AWS ParallelCluster from a template.
 
 This command:
@@ -54,14 +84,33 @@ This command:
 
 The cluster name can be specified with --name, or will use the name from the template.
 
+Without --seed, on a terminal, pctl builds a starter seed: a small on-demand
+cluster with the Spack packages you pick interactively, as with
+'pctl template packages --interactive'. The seed is saved as NAME.yaml in the
+current directory so the cluster can be recreated or updated from it.
+
 If --subnet-id is not provided, pctl will automatically create a VPC with public
-and private subnets, internet gateway, route tables, and security groups.`,
+and private subnets, internet gateway, route tables, and security groups. With
+--use-default-vpc, pctl instead uses a public subnet in the account's default VPC.
+
+With --dry-run, the whole flow runs against fake AWS and pcluster responses:
+each action (network, bootstrap upload, pcluster config) is printed instead
+of performed, and no credentials are needed. The ParallelCluster
+configuration pctl would submit is printed at the end, or written to a file
+with --output-config. Resources the dry run would create or look up appear as
+placeholder IDs containing "dryrun".`,
 	Example: `  # Create a cluster with automatic VPC/networking
   pctl create -t bioinformatics.yaml --key-name my-key
 
   # Create using existing VPC/subnet
   pctl create -t bioinformatics.yaml --key-name my-key --subnet-id subnet-abc123
 
+  # Use the account's default VPC (quickest first run)
+  pctl create -t bioinformatics.yaml --key-name my-key --use-default-vpc
+
+  # Pick packages for a starter seed, saved as demo.yaml
+  pctl create --name demo --key-name my-key
+
   # Create with custom name
   pctl create -t my-cluster.yaml --name production-cluster --key-name my-key
 
@@ -71,11 +120,44 @@ and private subnets, internet gateway, route tables, and security groups.`,
   # Create with custom AMI
   pctl create -t my-cluster.yaml --key-name my-key --custom-ami ami-0123456789
 
-  # Dry run to see what would be created (no AWS credentials needed)
-  pctl create -t my-cluster.yaml --dry-run
+  # Rehearse creation end to end (no AWS credentials needed)
+  pctl create -t my-cluster.yaml --key-name my-key --dry-run
+
+  # Save the ParallelCluster configuration a dry run generates
+  pctl create -t my-cluster.yaml --key-name my-key --dry-run --output-config cluster-config.yaml
 
   # Create and wait for completion
-  pctl create -t my-cluster.yaml --key-name my-key --wait`,
+  pctl create -t my-cluster.yaml --key-name my-key --wait
+
+  # Confirm Slurm is accepting jobs before reporting the cluster ready
+  pctl create -t my-cluster.yaml --key-name my-key --health-check
+
+  # Only allow SSH from the campus network
+  pctl create -t my-cluster.yaml --key-name my-key --ssh-cidr 203.0.113.0/24
+
+  # Spread compute subnets across three Availability Zones
+  pctl create -t my-cluster.yaml --key-name my-key --az-count 3
+
+  # Create the cluster's subnets and security group in a shared VPC
+  pctl create -t my-cluster.yaml --key-name my-key --vpc-id vpc-0abc123
+
+  # Launch compute nodes in private subnets with outbound access via NAT
+  pctl create -t my-cluster.yaml --key-name my-key --enable-nat
+
+  # Launch every compute node on demand, ignoring the seed's min_count
+  pctl create -t my-cluster.yaml --key-name my-key --scale-to-zero
+
+  # Enable EFA with GPUDirect RDMA on the seed's p4d/p5 queues
+  pctl create -t gpu-cluster.yaml --key-name my-key --compute-efa-gdr
+
+  # Launch the gpu queue into a shared On-Demand Capacity Reservation group
+  pctl create -t gpu-cluster.yaml --key-name my-key --compute-on-demand-capacity gpu=arn:aws:resource-groups:us-east-1:123456789012:group/gpu-reservations
+
+  # Add cost allocation tags to the cluster resources
+  pctl create -t my-cluster.yaml --key-name my-key --tags project=genomics,cost-center=1234
+
+  # Keep the deployed CloudFormation template for change review
+  pctl create -t my-cluster.yaml --key-name my-key --export-cfn stack.json`,
 	RunE: runCreate,
 }
 
@@ -87,10 +169,26 @@ func init() {
 	createCmd.Flags().StringVarP(&createKeyName, "key-name", "k", "", "EC2 key pair name for SSH access (required)")
 	createCmd.Flags().StringVarP(&createSubnetID, "subnet-id", "s", "", "subnet ID (optional, auto-creates VPC if not provided)")
 	createCmd.Flags().StringVar(&createCustomAMI, "custom-ami", "", "custom AMI ID to use")
-	createCmd.Flags().BoolVar(&createWait, "wait", false, "wait for cluster creation to complete")
+	createCmd.Flags().BoolVar(&createWait, "wait", false, "wait for cluster creation to complete (up to --monitor-timeout)")
 	createCmd.Flags().BoolVar(&rebuildAMI, "rebuild-ami", false, "force rebuild of AMI even if cached version exists")
-	createCmd.Flags().BoolVar(&dryRun, "dry-run", false, "validate and show plan without creating")
 	createCmd.Flags().BoolVar(&forceBootstrap, "force-bootstrap", false, "bypass AMI requirement and use bootstrap scripts (not recommended for production)")
+	createCmd.Flags().StringToStringVar(&createTags, "tags", nil, "additional tags for cluster resources (key=value,...)")
+	createCmd.Flags().StringVar(&createDNSDomain, "dns-domain", "", "DNS search domain for the created VPC (overrides seed)")
+	createCmd.Flags().StringSliceVar(&createDNSServers, "dns-servers", nil, "DNS server IPs for the created VPC (overrides seed)")
+	createCmd.Flags().StringSliceVar(&createSSHCIDRs, "ssh-cidr", nil, "CIDRs allowed to SSH to a pctl-created VPC (default: this machine's public IP)")
+	createCmd.Flags().IntVar(&createAZCount, "az-count", 2, "number of Availability Zones to spread a pctl-created VPC's subnets across")
+	createCmd.Flags().StringVar(&createVPCID, "vpc-id", "", "existing VPC to create the cluster's subnets and security group in, instead of a new VPC")
+	createCmd.Flags().BoolVar(&createNoS3EP, "no-s3-endpoint", false, "skip the S3 gateway endpoint a pctl-created VPC gets when the seed mounts S3 buckets")
+	createCmd.Flags().BoolVar(&createEnableNAT, "enable-nat", false, "give a pctl-created VPC's private subnets a NAT gateway and launch compute nodes there (NAT gateways are billed hourly)")
+	createCmd.Flags().BoolVar(&createDefaultVPC, "use-default-vpc", false, "use a public subnet in the account's default VPC instead of creating a VPC")
+	createCmd.Flags().BoolVar(&createHealth, "health-check", false, "after creation, SSH to the head node and confirm Slurm responds and every queue's partition is up")
+	createCmd.Flags().BoolVar(&createEFAGDR, "compute-efa-gdr", false, "enable EFA with GPUDirect RDMA on every queue whose instance types all support it (p4d, p5)")
+	createCmd.Flags().StringToStringVar(&createCapacityGroups, "compute-on-demand-capacity", nil, "launch a queue's nodes into an On-Demand Capacity Reservation group (queue=resource-group-arn,...)")
+	createCmd.Flags().BoolVar(&createScaleZero, "scale-to-zero", false, "set every queue's min_count and static_count to 0 so no compute nodes run while idle")
+	createCmd.Flags().DurationVar(&createPollEvery, "progress-interval", 0, "how often to check creation progress (minimum 10s; default 10-15s depending on the phase)")
+	createCmd.Flags().DurationVar(&createMonitorTimeout, "monitor-timeout", provisioner.DefaultMonitorTimeout, "how long to follow creation before leaving the cluster to finish in the background")
+	createCmd.Flags().StringVar(&createExportCFN, "export-cfn", "", "after creating the cluster, write its CloudFormation stack template to this file (.json or .yaml)")
+	createCmd.Flags().StringVar(&createOutputConfig, "output-config", "", "with --dry-run, write the generated ParallelCluster configuration to this file instead of stdout")
 	rootCmd.AddCommand(createCmd)
 }
 
@@ -105,10 +203,57 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		seedFile = createTemplate
 	}
 
-	if seedFile == "" {
+	if seedFile == "" && !term.IsTerminal(int(os.Stdin.Fd())) {
 		return fmt.Errorf("--seed is required for cluster creation")
 	}
 
+	if createDefaultVPC && createSubnetID != "" {
+		return fmt.Errorf("cannot use both --use-default-vpc and --subnet-id")
+	}
+	if createOutputConfig != "" && !dryRun {
+		return fmt.Errorf("--output-config requires --dry-run")
+	}
+	if err := validateProgressInterval(createPollEvery); err != nil {
+		return err
+	}
+	if createMonitorTimeout <= 0 {
+		return fmt.Errorf("--monitor-timeout must be positive")
+	}
+	if err := network.ValidateSSHCIDRs(createSSHCIDRs); err != nil {
+		return err
+	}
+	if len(createSSHCIDRs) > 0 && (createSubnetID != "" || createDefaultVPC) {
+		return fmt.Errorf("--ssh-cidr only applies to a pctl-created VPC or --vpc-id; SSH access to an existing subnet is set by its security groups")
+	}
+	if createAZCount < 1 || createAZCount > network.MaxAZCount {
+		return fmt.Errorf("--az-count must be between 1 and %d", network.MaxAZCount)
+	}
+	if cmd.Flags().Changed("az-count") && (createSubnetID != "" || createDefaultVPC) {
+		return fmt.Errorf("--az-count only applies to a pctl-created VPC; it cannot be used with --subnet-id or --use-default-vpc")
+	}
+	if createEnableNAT && (createSubnetID != "" || createDefaultVPC) {
+		return fmt.Errorf("--enable-nat only applies to a pctl-created VPC; it cannot be used with --subnet-id or --use-default-vpc")
+	}
+	if createVPCID != "" {
+		if !strings.HasPrefix(createVPCID, "vpc-") {
+			return fmt.Errorf("invalid --vpc-id %q: expected a VPC ID such as vpc-0abc123", createVPCID)
+		}
+		if createSubnetID != "" || createDefaultVPC {
+			return fmt.Errorf("--vpc-id cannot be used with --subnet-id or --use-default-vpc")
+		}
+		if createEnableNAT {
+			return fmt.Errorf("--enable-nat cannot be used with --vpc-id; a shared VPC's outbound routing is managed by its owner")
+		}
+	}
+
+	if seedFile == "" {
+		var err error
+		seedFile, err = writeStarterSeed(os.Stdin, os.Stdout, createName, createRegion)
+		if err != nil {
+			return err
+		}
+	}
+
 	if verbose {
 		fmt.Printf("Loading seed: %s\n", seedFile)
 	}
@@ -119,9 +264,50 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load template: %w", err)
 	}
 
+	// Override the region before validation, which checks region-specific
+	// settings such as capacity reservation ARNs against it
+	if createRegion != "" {
+		tmpl.Cluster.Region = createRegion
+	}
+
+	// Override DNS settings if provided
+	if createDNSDomain != "" {
+		tmpl.Network.DomainName = createDNSDomain
+	}
+	if len(createDNSServers) > 0 {
+		tmpl.Network.DNSServers = createDNSServers
+	}
+
+	if createEFAGDR {
+		queues := tmpl.EnableGPUDirectRDMA()
+		if len(queues) == 0 {
+			return fmt.Errorf("--compute-efa-gdr: no queue uses only instance types that support GPUDirect RDMA (p4d, p4de, p5, p5e, p5en)")
+		}
+		fmt.Printf("Enabled EFA with GPUDirect RDMA on queue(s): %s\n", strings.Join(queues, ", "))
+	}
+
+	for queueName, groupARN := range createCapacityGroups {
+		if err := tmpl.SetCapacityReservationGroup(queueName, groupARN); err != nil {
+			return fmt.Errorf("--compute-on-demand-capacity: %w", err)
+		}
+	}
+
+	if createScaleZero {
+		if removed := tmpl.ScaleToZero(); removed > 0 && verbose {
+			fmt.Printf("Scaled %d always-on compute node(s) to zero\n", removed)
+		}
+	}
+
 	if err := tmpl.Validate(); err != nil {
 		return fmt.Errorf("template validation failed: %w", err)
 	}
+	printTemplateWarnings(tmpl)
+
+	for key, value := range createTags {
+		if err := template.ValidateTag(key, value); err != nil {
+			return fmt.Errorf("invalid --tags: %w", err)
+		}
+	}
 
 	// Override cluster name if provided
 	clusterName := tmpl.Cluster.Name
@@ -133,7 +319,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	}
 
 	if dryRun {
-		fmt.Printf("\n🔍 Dry run mode - no resources will be created\n\n")
+		fmt.Printf("\n🔍 Dry run mode - AWS calls are simulated and no resources will be created\n\n")
 	}
 
 	fmt.Printf("Cluster Configuration:\n")
@@ -142,8 +328,51 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Head Node: %s\n", tmpl.Compute.HeadNode)
 	fmt.Printf("\nCompute Queues:\n")
 	for _, queue := range tmpl.Compute.Queues {
-		fmt.Printf("  - %s: %v (min: %d, max: %d)\n",
-			queue.Name, queue.InstanceTypes, queue.MinCount, queue.MaxCount)
+		if len(queue.ComputeResources) > 0 {
+			fmt.Printf("  - %s: (static: %d, dynamic: %d, max: %d)\n",
+				queue.Name, queue.StaticNodes(), queue.DynamicNodes(), queue.MaxNodes())
+			for _, resource := range queue.ComputeResources {
+				fmt.Printf("      %s: %v (static: %d, max: %d, %s)\n",
+					resource.Name, resource.InstanceTypes, resource.StaticCount, resource.MaxCount, resource.GetCapacityType())
+			}
+		} else {
+			fmt.Printf("  - %s: %v (static: %d, dynamic: %d, max: %d)\n",
+				queue.Name, queue.InstanceTypes, queue.StaticNodes(), queue.DynamicNodes(), queue.MaxCount)
+		}
+		if queue.PlacementGroup {
+			fmt.Printf("    Placement group: cluster\n")
+		}
+		if queue.Image != nil {
+			fmt.Printf("    AMI: %s\n", queue.Image.CustomAMI)
+		}
+		if queue.EFAEnabled() {
+			efa := "enabled"
+			if queue.GPUDirectRDMA() {
+				efa += ", GPUDirect RDMA"
+			}
+			fmt.Printf("    EFA: %s\n", efa)
+			if dryRun {
+				printEFARequirements(queue)
+			}
+		}
+		if queue.CapacityReservation != nil {
+			fmt.Printf("    Capacity reservation group: %s\n", queue.CapacityReservation.ResourceGroupARN)
+		}
+	}
+	if tmpl.Compute.ScaledownIdleTime > 0 {
+		fmt.Printf("  Scaledown idle time: %d minutes\n", tmpl.Compute.ScaledownIdleTime)
+	}
+	switch {
+	case tmpl.Scheduler.LocalAccounting():
+		fmt.Printf("  Slurm accounting: slurmdbd with a local database on the head node\n")
+	case tmpl.Scheduler.ExternalAccounting():
+		fmt.Printf("  Slurm accounting: slurmdbd with the external database %s\n", tmpl.Scheduler.Accounting.URI)
+	}
+	if obs := tmpl.Observability; obs.IsSet() {
+		fmt.Printf("\nMonitoring:\n")
+		fmt.Printf("  CloudWatch dashboard: %s\n", enabledString(obs.DashboardEnabled()))
+		fmt.Printf("  CloudWatch alarms: %s\n", enabledString(obs.AlarmsEnabled()))
+		fmt.Printf("  Log retention: %d days\n", obs.GetLogRetentionDays())
 	}
 
 	if len(tmpl.Software.SpackPackages) > 0 {
@@ -151,6 +380,10 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		for _, pkg := range tmpl.Software.SpackPackages {
 			fmt.Printf("  - %s\n", pkg)
 		}
+		fmt.Printf("  Module System: %s\n", tmpl.Software.GetModuleSystem())
+		if len(tmpl.Software.DefaultModules) > 0 {
+			fmt.Printf("  Default Modules: %s\n", strings.Join(tmpl.Software.DefaultModules, " "))
+		}
 	}
 
 	if len(tmpl.Users) > 0 {
@@ -167,29 +400,119 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if dryRun {
-		fmt.Printf("\n✅ Template validation passed - ready to create\n")
-		fmt.Printf("\nTo create this cluster, run without --dry-run\n")
-		return nil
+	if len(tmpl.IAM.AdditionalPolicies) > 0 || len(tmpl.IAM.S3Access) > 0 {
+		fmt.Printf("\nIAM:\n")
+		for _, policy := range tmpl.IAM.AdditionalPolicies {
+			fmt.Printf("  - Policy: %s\n", policy)
+		}
+		for _, access := range tmpl.IAM.S3Access {
+			mode := "read-only"
+			if access.EnableWrite {
+				mode = "read-write"
+			}
+			fmt.Printf("  - S3: %s (%s)\n", access.Bucket, mode)
+		}
+	}
+
+	if tmpl.Network.DomainName != "" || len(tmpl.Network.DNSServers) > 0 {
+		fmt.Printf("\nNetwork DNS:\n")
+		if tmpl.Network.DomainName != "" {
+			fmt.Printf("  Domain: %s\n", tmpl.Network.DomainName)
+		}
+		if len(tmpl.Network.DNSServers) > 0 {
+			fmt.Printf("  Servers: %s\n", strings.Join(tmpl.Network.DNSServers, ", "))
+		}
+	}
+
+	if tmpl.Network.Proxy != "" {
+		// Redact any credentials in the proxy URL
+		proxy := tmpl.Network.Proxy
+		if u, err := url.Parse(proxy); err == nil {
+			proxy = u.Redacted()
+		}
+		fmt.Printf("\nProxy: %s\n", proxy)
+		noProxy := append(append([]string{}, software.DefaultNoProxy...), tmpl.Network.NoProxy...)
+		fmt.Printf("  Bypassed: %s, VPC CIDR\n", strings.Join(noProxy, ", "))
+	}
+
+	if len(tmpl.Network.IngressRules) > 0 {
+		fmt.Printf("\nHead Node Ingress:\n")
+		for _, rule := range tmpl.Network.IngressRules {
+			fmt.Printf("  - %s/%s from %s", rule.Port, rule.GetProtocol(), rule.CIDR)
+			if rule.Description != "" {
+				fmt.Printf(" (%s)", rule.Description)
+			}
+			fmt.Println()
+		}
+	}
+
+	if len(tmpl.Cluster.Tags) > 0 || len(createTags) > 0 {
+		fmt.Printf("\nTags:\n")
+		for key, value := range tmpl.Cluster.Tags {
+			if _, overridden := createTags[key]; !overridden {
+				fmt.Printf("  - %s=%s\n", key, value)
+			}
+		}
+		for key, value := range createTags {
+			fmt.Printf("  - %s=%s\n", key, value)
+		}
+	}
+	if len(tmpl.Compute.HeadNodeTags) > 0 {
+		fmt.Printf("\nHead Node Tags:\n")
+		for key, value := range tmpl.Compute.HeadNodeTags {
+			fmt.Printf("  - %s=%s\n", key, value)
+		}
+	}
+	for _, queue := range tmpl.Compute.Queues {
+		if len(queue.Tags) > 0 {
+			fmt.Printf("\nQueue %s Tags:\n", queue.Name)
+			for key, value := range queue.Tags {
+				fmt.Printf("  - %s=%s\n", key, value)
+			}
+		}
+	}
+
+	if createExportCFN != "" && dryRun {
+		return fmt.Errorf("--export-cfn cannot be used with --dry-run; the template is read from the cluster's stack once it is created")
 	}
 
 	// Validate required flags
 	if createKeyName == "" {
-		return fmt.Errorf("--key-name is required for SSH access to the cluster")
+		if !dryRun {
+			return fmt.Errorf("--key-name is required for SSH access to the cluster")
+		}
+		createKeyName = dryRunKeyName
+		fmt.Printf("\n⚠️  No --key-name given; the dry run uses the placeholder %s\n", dryRunKeyName)
+	}
+
+	if createDefaultVPC {
+		if dryRun {
+			fmt.Printf("🔍 [dry run] Would look up a public subnet in the default VPC\n")
+			createSubnetID = dryRunDefaultSubnet
+		} else if err := useDefaultVPCSubnet(tmpl); err != nil {
+			return err
+		}
 	}
 
 	// subnet-id is now optional - will auto-create VPC if not provided
 	if createSubnetID != "" {
 		fmt.Printf("📍 Using existing subnet: %s\n", createSubnetID)
+		if tmpl.Network.DomainName != "" || len(tmpl.Network.DNSServers) > 0 {
+			fmt.Printf("⚠️  DNS settings only apply to pctl-created VPCs and will be ignored\n")
+		}
+		if len(tmpl.Network.IngressRules) > 0 {
+			fmt.Printf("⚠️  Ingress rules only apply to pctl-created VPCs and will be ignored\n")
+		}
+	} else if createVPCID != "" {
+		fmt.Printf("📍 Will create subnets and a security group in existing VPC: %s\n", createVPCID)
+		if tmpl.Network.DomainName != "" || len(tmpl.Network.DNSServers) > 0 {
+			fmt.Printf("⚠️  DNS settings only apply to pctl-created VPCs and will be ignored\n")
+		}
 	} else {
 		fmt.Printf("📍 Will auto-create VPC and networking\n")
 	}
 
-	// Override region in template if provided (needed for AMI lookup)
 	region := tmpl.Cluster.Region
-	if createRegion != "" {
-		region = createRegion
-	}
 
 	// AMI lookup/building logic
 	if createCustomAMI == "" && len(tmpl.Software.SpackPackages) > 0 {
@@ -202,18 +525,21 @@ func runCreate(cmd *cobra.Command, args []string) error {
 			fmt.Printf("Fingerprint hash: %s\n", fingerprint.Hash)
 		}
 
-		// Create AMI manager
-		ctx := context.Background()
-		amiManager, err := ami.NewManager(ctx, region)
-		if err != nil {
-			return fmt.Errorf("failed to create AMI manager: %w", err)
-		}
-
 		// Check for existing AMI (skip cache if rebuild flag is set)
 		var amiID string
-		if rebuildAMI {
+		if dryRun {
+			fmt.Printf("🔍 [dry run] Would look up a cached AMI tagged with fingerprint %s in %s\n", fingerprint.Hash, region)
+			fmt.Printf("   Continuing as if none were found, with bootstrap scripts\n")
+			forceBootstrap = true
+		} else if rebuildAMI {
 			fmt.Printf("⚙️  Skipping cache lookup (--rebuild-ami flag set)\n")
 		} else {
+			ctx := context.Background()
+			amiManager, err := ami.NewManager(ctx, region)
+			if err != nil {
+				return fmt.Errorf("failed to create AMI manager: %w", err)
+			}
+
 			amiID, err = amiManager.FindAMIByFingerprint(ctx, fingerprint)
 			if err != nil {
 				return fmt.Errorf("failed to lookup AMI: %w", err)
@@ -227,7 +553,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		}
 
 		// Build new AMI if not found or rebuild requested
-		if amiID == "" {
+		if amiID == "" && !dryRun {
 			// Generate AMI name from fingerprint
 			amiName := fmt.Sprintf("pctl-%s", fingerprint.String())
 
@@ -261,18 +587,41 @@ func runCreate(cmd *cobra.Command, args []string) error {
 
 	// Create provisioner
 	fmt.Printf("\n🚀 Creating cluster: %s\n\n", clusterName)
-	prov, err := provisioner.NewProvisioner()
+	var prov *provisioner.Provisioner
+	var plan *provisioner.DryRunPlan
+	if dryRun {
+		prov, plan, err = provisioner.NewDryRunProvisioner(os.Stdout)
+		if err == nil {
+			defer plan.Close()
+		}
+	} else {
+		prov, err = provisioner.NewProvisioner()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create provisioner: %w", err)
 	}
 
 	// Prepare create options
 	opts := &provisioner.CreateOptions{
-		TemplatePath: seedFile,
-		KeyName:      createKeyName,
-		SubnetID:     createSubnetID,
-		CustomAMI:    createCustomAMI,
-		DryRun:       false,
+		TemplatePath:   seedFile,
+		KeyName:        createKeyName,
+		SubnetID:       createSubnetID,
+		CustomAMI:      createCustomAMI,
+		Tags:           createTags,
+		DryRun:         dryRun,
+		PollInterval:   createPollEvery,
+		SSHCIDRs:       createSSHCIDRs,
+		AZCount:        createAZCount,
+		EnableNAT:      createEnableNAT,
+		VpcID:          createVPCID,
+		NoS3Endpoint:   createNoS3EP,
+		MonitorTimeout: createMonitorTimeout,
+		Overrides: &state.TemplateOverrides{
+			Region:                    createRegion,
+			ScaleToZero:               createScaleZero,
+			EFAGDR:                    createEFAGDR,
+			CapacityReservationGroups: createCapacityGroups,
+		},
 	}
 
 	// Override cluster name in template if provided
@@ -280,24 +629,30 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		tmpl.Cluster.Name = createName
 	}
 
-	// Override region in template if provided
-	if createRegion != "" {
-		tmpl.Cluster.Region = createRegion
-	}
-
-	// Create cluster
+	// Create cluster; monitoring is bounded by --monitor-timeout alone, so
+	// --wait must not impose a shorter deadline of its own
 	ctx := context.Background()
-	if createWait {
-		// Set a reasonable timeout for cluster creation (30 minutes)
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, 30*time.Minute)
-		defer cancel()
-	}
 
 	if err := prov.CreateCluster(ctx, tmpl, opts); err != nil {
 		return fmt.Errorf("failed to create cluster: %w", err)
 	}
 
+	if dryRun {
+		return printDryRunSummary(clusterName, plan, createOutputConfig)
+	}
+
+	if createExportCFN != "" {
+		if err := exportCloudFormation(prov, clusterName, tmpl.Cluster.Region); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+	}
+
+	if createHealth {
+		if err := checkClusterHealth(clusterName, tmpl); err != nil {
+			return err
+		}
+	}
+
 	fmt.Printf("\n✅ Cluster created successfully!\n\n")
 	fmt.Printf("Cluster: %s\n", clusterName)
 	fmt.Printf("Region: %s\n", tmpl.Cluster.Region)
@@ -313,3 +668,170 @@ func runCreate(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// writeStarterSeed builds a starter seed from the packages picked on in,
+// prompting for a cluster name if name is empty, and saves it as NAME.yaml.
+// It returns the path of the saved seed.
+func writeStarterSeed(in io.Reader, out io.Writer, name, region string) (string, error) {
+	reader := bufio.NewReader(in)
+	fmt.Fprintf(out, "🌱 No --seed given; building a starter seed\n")
+	if name == "" {
+		fmt.Fprintf(out, "Cluster name: ")
+		var err error
+		name, err = readLine(reader)
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			return "", fmt.Errorf("a cluster name is required to build a starter seed")
+		}
+	}
+	if region == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return "", fmt.Errorf("failed to load config: %w", err)
+		}
+		region = cfg.Defaults.Region
+	}
+
+	packages, err := pickPackages(reader, out, capture.NewModuleDatabase())
+	if err != nil {
+		return "", err
+	}
+
+	tmpl := starterSeed(name, region, packages)
+	if err := tmpl.Validate(); err != nil {
+		return "", fmt.Errorf("starter seed is invalid: %w", err)
+	}
+	path := name + ".yaml"
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return "", fmt.Errorf("%s already exists; create the cluster from it with --seed %s", path, path)
+		}
+		return "", fmt.Errorf("failed to create seed file: %w", err)
+	}
+	defer file.Close()
+	enc := yaml.NewEncoder(file)
+	enc.SetIndent(2)
+	if err := enc.Encode(tmpl); err != nil {
+		return "", fmt.Errorf("failed to write seed file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to write seed file: %w", err)
+	}
+
+	fmt.Fprintf(out, "\n✅ Saved seed to %s\n\n", path)
+	return path, nil
+}
+
+// starterSeed returns a small on-demand cluster with the given packages.
+func starterSeed(name, region string, packages []string) *template.Template {
+	return &template.Template{
+		APIVersion: template.CurrentAPIVersion,
+		Cluster:    template.ClusterConfig{Name: name, Region: region},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.medium",
+			Queues: []template.Queue{
+				{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MinCount: 0, MaxCount: 10},
+			},
+		},
+		Software: template.SoftwareConfig{SpackPackages: packages},
+	}
+}
+
+// exportCloudFormation writes the CloudFormation template of the cluster's
+// stack to --export-cfn.
+func exportCloudFormation(prov *provisioner.Provisioner, clusterName, region string) error {
+	body, err := prov.ExportCloudFormation(context.Background(), clusterName, region)
+	if err != nil {
+		return fmt.Errorf("failed to export CloudFormation template: %w", err)
+	}
+
+	out, err := provisioner.FormatExportedTemplate(body, createExportCFN)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(createExportCFN, out, 0644); err != nil {
+		return fmt.Errorf("failed to write CloudFormation template: %w", err)
+	}
+
+	fmt.Printf("📝 CloudFormation template written to %s\n", createExportCFN)
+	return nil
+}
+
+// useDefaultVPCSubnet sets --subnet-id to a public subnet in the account's
+// default VPC for the seed's region.
+func useDefaultVPCSubnet(tmpl *template.Template) error {
+	region := tmpl.Cluster.Region
+
+	ctx := context.Background()
+	netMgr, err := network.NewManager(ctx, region)
+	if err != nil {
+		return fmt.Errorf("failed to create network manager: %w", err)
+	}
+
+	subnet, err := netMgr.FindDefaultSubnet(ctx)
+	if errors.Is(err, network.ErrNoDefaultVPC) {
+		return fmt.Errorf("%w\n\nCreate one with 'aws ec2 create-default-vpc --region %s', pass --subnet-id,\nor omit --use-default-vpc to let pctl create a VPC", err, region)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find default VPC subnet: %w", err)
+	}
+
+	fmt.Printf("📍 Default VPC %s: using public subnet %s (%s)\n", subnet.VpcID, subnet.SubnetID, subnet.AvailabilityZone)
+	createSubnetID = subnet.SubnetID
+	return nil
+}
+
+// enabledString renders a toggle for the configuration summary.
+func enabledString(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+// printEFARequirements lists what an EFA queue needs to get the networking
+// it asks for, marking the ones the seed does not meet.
+func printEFARequirements(queue template.Queue) {
+	fmt.Printf("    EFA requirements:\n")
+	// Validation has already rejected instance types without EFA
+	fmt.Printf("      ✓ EFA-capable instance types: %s\n", strings.Join(queue.AllInstanceTypes(), ", "))
+	if queue.PlacementGroup {
+		fmt.Printf("      ✓ Cluster placement group in a single Availability Zone\n")
+	} else {
+		fmt.Printf("      ✗ Cluster placement group in a single Availability Zone (set placement_group: true)\n")
+	}
+	fmt.Printf("      ✓ EFA security group rules and driver (added by ParallelCluster)\n")
+	if queue.GPUDirectRDMA() {
+		fmt.Printf("      ✓ GPUDirect RDMA-capable GPUs (NVIDIA driver from the ParallelCluster AMI)\n")
+	}
+}
+
+// Placeholders for inputs a dry run would otherwise look up or require.
+const (
+	dryRunKeyName       = "dry-run-key"
+	dryRunDefaultSubnet = "subnet-dryrun-default"
+)
+
+// printDryRunSummary prints the number of actions a dry run simulated and
+// the ParallelCluster config it generated, or writes the config to
+// configPath if set.
+func printDryRunSummary(clusterName string, plan *provisioner.DryRunPlan, configPath string) error {
+	if plan.Config != "" {
+		if configPath != "" {
+			if err := os.WriteFile(configPath, []byte(plan.Config), 0644); err != nil {
+				return fmt.Errorf("failed to write ParallelCluster configuration: %w", err)
+			}
+			fmt.Printf("\n📝 ParallelCluster configuration written to %s\n", configPath)
+		} else {
+			fmt.Printf("\nParallelCluster configuration:\n\n%s\n", plan.Config)
+		}
+		fmt.Printf("   IDs containing \"dryrun\" are placeholders for resources the real run looks up or creates\n")
+	}
+
+	fmt.Printf("\n✅ Dry run complete for cluster %s: %d action(s) simulated, nothing was created\n", clusterName, len(plan.Steps))
+	fmt.Printf("\nTo create this cluster, run without --dry-run\n")
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func TestWriteStarterSeed(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// Name prompt, one search with one selection, then an empty search
+	input := strings.NewReader("demo\nopenmpi\n1\n\n")
+	path, err := writeStarterSeed(input, io.Discard, "", "us-west-2")
+	if err != nil {
+		t.Fatalf("writeStarterSeed() failed: %v", err)
+	}
+	if path != "demo.yaml" {
+		t.Errorf("Expected demo.yaml, got %s", path)
+	}
+
+	tmpl, err := template.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load starter seed: %v", err)
+	}
+	if err := tmpl.Validate(); err != nil {
+		t.Errorf("Starter seed should be valid: %v", err)
+	}
+	if tmpl.Cluster.Name != "demo" || tmpl.Cluster.Region != "us-west-2" {
+		t.Errorf("Expected cluster demo in us-west-2, got %s in %s", tmpl.Cluster.Name, tmpl.Cluster.Region)
+	}
+	if len(tmpl.Software.SpackPackages) != 1 || !strings.HasPrefix(tmpl.Software.SpackPackages[0], "openmpi") {
+		t.Errorf("Expected the picked openmpi package, got %v", tmpl.Software.SpackPackages)
+	}
+
+	// An existing seed is never overwritten
+	_, err = writeStarterSeed(strings.NewReader("\n"), io.Discard, "demo", "us-west-2")
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an error for an existing seed, got %v", err)
+	}
+	reloaded, err := template.Load(path)
+	if err != nil || !reflect.DeepEqual(reloaded, tmpl) {
+		t.Errorf("Existing seed should be unchanged")
+	}
+}
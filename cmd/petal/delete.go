@@ -26,8 +26,10 @@ import (
 )
 
 var (
-	deleteForce     bool
-	deleteLocalOnly bool
+	deleteForce       bool
+	deleteLocalOnly   bool
+	deleteWait        bool
+	deleteForceDelete bool
 )
 
 var deleteCmd = &cobra.Command{
@@ -42,6 +44,10 @@ This is a destructive operation that will:
 - Delete associated networking resources (if created by pctl)
 - Remove cluster state from pctl
 
+Without --wait, networking, placement groups, and cluster state are kept
+until the cluster stack is gone; clean them up afterwards with
+'pctl network prune'.
+
 Data in S3 buckets will NOT be deleted.`,
 	Example: `  # Delete a cluster (with confirmation)
   pctl delete my-cluster
@@ -50,7 +56,13 @@ Data in S3 buckets will NOT be deleted.`,
   pctl delete my-cluster --force
   pctl delete my-cluster -f
   pctl delete my-cluster --yes
-  pctl delete my-cluster -y`,
+  pctl delete my-cluster -y
+
+  # Wait for the cluster stack to be deleted before removing networking
+  pctl delete my-cluster --wait
+
+  # Recover a cluster stuck in DELETE_FAILED
+  pctl delete my-cluster --force-delete`,
 	Args: cobra.ExactArgs(1),
 	RunE: runDelete,
 }
@@ -59,6 +71,8 @@ func init() {
 	deleteCmd.Flags().BoolVarP(&deleteForce, "force", "f", false, "skip confirmation prompt")
 	deleteCmd.Flags().BoolVarP(&deleteForce, "yes", "y", false, "skip confirmation prompt (alias for --force)")
 	deleteCmd.Flags().BoolVar(&deleteLocalOnly, "local-only", false, "only delete local state (cluster already deleted from AWS)")
+	deleteCmd.Flags().BoolVar(&deleteWait, "wait", false, "wait for the cluster stack to be deleted before cleaning up networking and state")
+	deleteCmd.Flags().BoolVar(&deleteForceDelete, "force-delete", false, "on DELETE_FAILED, delete failed resources directly and retry, retaining any that cannot be removed (implies --wait)")
 	rootCmd.AddCommand(deleteCmd)
 }
 
@@ -155,7 +169,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	fmt.Printf("⏳ This may take 5-10 minutes...\n\n")
 
 	ctx := context.Background()
-	if err := prov.DeleteCluster(ctx, clusterName); err != nil {
+	if err := prov.DeleteCluster(ctx, clusterName, &provisioner.DeleteOptions{Wait: deleteWait, ForceDelete: deleteForceDelete}); err != nil {
 		return fmt.Errorf("failed to delete cluster: %w", err)
 	}
 
@@ -0,0 +1,130 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scttfrdmn/petal/pkg/provisioner"
+	"github.com/spf13/cobra"
+)
+
+var fleetForce bool
+
+var stopCmd = &cobra.Command{
+	Use:   "stop CLUSTER_NAME",
+	Short: "Stop a cluster's compute fleet",
+	Long: `Stop a cluster's compute fleet to save money while it is idle.
+
+All compute nodes are terminated; the head node, shared storage, and queued
+jobs are kept, and the cluster can be started again with 'pctl start'.
+
+Before stopping, pctl checks over SSH for running jobs. If any are running,
+or the check fails, the fleet is only stopped with --force, since stopping
+terminates the nodes they run on.`,
+	Example: `  # Stop compute nodes overnight
+  pctl stop my-cluster
+
+  # Stop even if jobs are running or cannot be checked
+  pctl stop my-cluster --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStop,
+}
+
+var startCmd = &cobra.Command{
+	Use:   "start CLUSTER_NAME",
+	Short: "Start a cluster's stopped compute fleet",
+	Long: `Start a cluster's compute fleet after 'pctl stop'.
+
+Static nodes are launched again and queued jobs resume scheduling; dynamic
+nodes launch on demand as before.`,
+	Example: `  # Resume a stopped cluster
+  pctl start my-cluster`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStart,
+}
+
+func init() {
+	stopCmd.Flags().BoolVarP(&fleetForce, "force", "f", false, "stop the fleet even if jobs are running or the running jobs check fails")
+	stopCmd.Flags().StringVarP(&sshKeyPath, "key", "i", "", "Path to SSH private key for the running jobs check (overrides cluster default)")
+	stopCmd.Flags().StringVarP(&sshUser, "user", "u", "ec2-user", "SSH username for the running jobs check")
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(startCmd)
+}
+
+func runStop(cmd *cobra.Command, args []string) error {
+	clusterName := args[0]
+
+	prov, err := provisioner.NewProvisioner()
+	if err != nil {
+		return fmt.Errorf("failed to create provisioner: %w", err)
+	}
+
+	running, err := countRunningJobs(clusterName)
+	switch {
+	case err != nil:
+		fmt.Printf("⚠️  Could not check for running jobs: %v\n\n", err)
+		if !fleetForce {
+			return fmt.Errorf("could not confirm that no jobs are running; use --force to stop anyway")
+		}
+	case running > 0:
+		fmt.Printf("⚠️  %d job(s) are running on %s; stopping the compute fleet terminates their nodes.\n\n", running, clusterName)
+		if !fleetForce {
+			return fmt.Errorf("%d job(s) are running; wait for them to finish or use --force to stop anyway", running)
+		}
+	}
+
+	fmt.Printf("⏸️  Stopping compute fleet of %s...\n", clusterName)
+	if err := prov.SetComputeFleet(context.Background(), clusterName, false); err != nil {
+		return fmt.Errorf("failed to stop cluster: %w", err)
+	}
+
+	fmt.Printf("✅ Compute fleet stopped. The head node is still running.\n")
+	fmt.Printf("\nStart it again with: pctl start %s\n", clusterName)
+	return nil
+}
+
+func runStart(cmd *cobra.Command, args []string) error {
+	clusterName := args[0]
+
+	prov, err := provisioner.NewProvisioner()
+	if err != nil {
+		return fmt.Errorf("failed to create provisioner: %w", err)
+	}
+
+	fmt.Printf("▶️  Starting compute fleet of %s...\n", clusterName)
+	if err := prov.SetComputeFleet(context.Background(), clusterName, true); err != nil {
+		return fmt.Errorf("failed to start cluster: %w", err)
+	}
+
+	fmt.Printf("✅ Compute fleet running.\n")
+	return nil
+}
+
+// countRunningJobs returns the number of running Slurm jobs on the cluster,
+// queried over SSH on the head node.
+func countRunningJobs(clusterName string) (int, error) {
+	target, err := resolveSSHTarget(clusterName)
+	if err != nil {
+		return 0, err
+	}
+
+	output, err := runRemote(target, "", provisioner.RunningJobsCommand)
+	if err != nil {
+		return 0, fmt.Errorf("squeue failed: %w", err)
+	}
+	return provisioner.ParseJobCount(output)
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scttfrdmn/petal/pkg/provisioner"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// healthCheckAttempts and healthCheckRetryDelay give Slurm a few minutes to
+// start accepting jobs after the cluster reports CREATE_COMPLETE.
+const (
+	healthCheckAttempts   = 10
+	healthCheckRetryDelay = 30 * time.Second
+)
+
+// checkClusterHealth connects to the head node and confirms that Slurm
+// responds and that a partition is up for each queue in the seed, retrying
+// while the scheduler starts. It reports each check and returns an error if
+// any still fail.
+func checkClusterHealth(clusterName string, tmpl *template.Template) error {
+	fmt.Printf("\n🔍 Checking Slurm on the head node...\n")
+
+	target, err := resolveSSHTarget(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to reach head node for health check: %w", err)
+	}
+
+	var queues []string
+	for _, queue := range tmpl.Compute.Queues {
+		queues = append(queues, queue.Name)
+	}
+
+	var results []provisioner.HealthCheckResult
+	for attempt := 1; attempt <= healthCheckAttempts; attempt++ {
+		sinfoOutput, sinfoErr := runRemote(target, "", fmt.Sprintf("sinfo --noheader --format='%s'", provisioner.SinfoFormat))
+		_, squeueErr := runRemote(target, "", "squeue --noheader")
+
+		results = provisioner.SlurmHealthChecks(sinfoOutput, sinfoErr, squeueErr, queues)
+		if provisioner.HealthChecksPassed(results) || attempt == healthCheckAttempts {
+			break
+		}
+		fmt.Printf("   ⏳ Slurm is not ready yet, retrying in %v (%d/%d)...\n", healthCheckRetryDelay, attempt, healthCheckAttempts)
+		time.Sleep(healthCheckRetryDelay)
+	}
+
+	for _, result := range results {
+		icon := "✅"
+		if !result.Passed {
+			icon = "❌"
+		}
+		fmt.Printf("   %s %s: %s\n", icon, result.Name, result.Detail)
+	}
+
+	if !provisioner.HealthChecksPassed(results) {
+		return fmt.Errorf("cluster %s failed its health check; Slurm is not ready to accept jobs", clusterName)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scttfrdmn/petal/pkg/provisioner"
+	"github.com/spf13/cobra"
+)
+
+var networkCmd = &cobra.Command{
+	Use:   "network",
+	Short: "Manage pctl-created network resources",
+	Long:  `Manage VPCs and networking resources created by pctl for clusters.`,
+}
+
+var networkPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete network resources left behind by deleted clusters",
+	Long: `Delete VPCs, networking resources, and placement groups left behind when
+a cluster was deleted but they could not be removed (for example, because
+network interfaces or instances were still in use), or when it was deleted
+without --wait and its stack has since finished deleting.
+
+Clusters in this state are shown as DELETE_FAILED_NETWORK,
+DELETE_FAILED_PLACEMENT_GROUPS, or DELETE_PENDING by 'pctl list'.`,
+	Example: `  # Retry cleanup of leftover cluster networks
+  pctl network prune`,
+	RunE: runNetworkPrune,
+}
+
+func init() {
+	networkCmd.AddCommand(networkPruneCmd)
+	rootCmd.AddCommand(networkCmd)
+}
+
+func runNetworkPrune(cmd *cobra.Command, args []string) error {
+	prov, err := provisioner.NewProvisioner()
+	if err != nil {
+		return fmt.Errorf("failed to create provisioner: %w", err)
+	}
+
+	fmt.Printf("🧹 Pruning leftover cluster networks...\n")
+
+	ctx := context.Background()
+	pruned, err := prov.PruneNetworks(ctx)
+	for _, name := range pruned {
+		fmt.Printf("✅ Leftover resources deleted for cluster '%s'\n", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(pruned) == 0 {
+		fmt.Printf("No leftover networks found.\n")
+	}
+
+	return nil
+}
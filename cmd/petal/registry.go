@@ -15,16 +15,25 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/scttfrdmn/petal/internal/config"
 	"github.com/scttfrdmn/petal/pkg/registry"
+	"github.com/scttfrdmn/petal/pkg/template"
 	"github.com/spf13/cobra"
 )
 
 var (
-	registryURL string
+	registryURL     string
+	publishTemplate string
+	publishToken    string
+	registryRefresh bool
 )
 
 // registryCmd represents the registry command
@@ -34,7 +43,23 @@ var registryCmd = &cobra.Command{
 	Long: `Manage the template registry for discovering and sharing cluster templates.
 
 The registry provides a curated collection of templates for common HPC workloads
-including bioinformatics, machine learning, computational chemistry, and more.`,
+including bioinformatics, machine learning, computational chemistry, and more.
+
+Registries added with 'pctl registry add' are queried along with the default
+registry unless --registry picks one. To use a private GitHub repository as a
+registry, set GITHUB_TOKEN to a token that can read it, or give the source its
+own token in registry.sources in ~/.petal/config.yaml.
+
+  registry:
+    sources:
+      - name: lab
+        url: github.com/mylab/pctl-seeds
+        token: ghp_...  # used for this source instead of GITHUB_TOKEN
+
+The registry index is cached in ~/.petal/registry-cache for registry.cache_ttl
+(default 1h) from ~/.petal/config.yaml, or not at all if it is 0; with
+preferences.auto_update_registry set to false it is kept until you pass
+--refresh to list or search.`,
 }
 
 // registryListCmd lists templates in the registry
@@ -61,42 +86,189 @@ Searches template names, titles, descriptions, and tags.`,
 
 // registryPullCmd downloads a template
 var registryPullCmd = &cobra.Command{
-	Use:   "pull [template-name] [destination]",
+	Use:   "pull [template-name[@version]] [destination]",
 	Short: "Download a template",
 	Long: `Download a template from the registry to your local filesystem.
 
+Without a version, the highest released version is pulled.
+
 Example:
-  pctl registry pull bioinformatics ./my-cluster.yaml`,
+  pctl registry pull bioinformatics ./my-cluster.yaml
+
+  # Pin an exact version
+  pctl registry pull bioinformatics@1.2.0 ./my-cluster.yaml`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: runRegistryPull,
 }
 
+// registryAddCmd adds a registry source to the config file
+var registryAddCmd = &cobra.Command{
+	Use:   "add <name> <url>",
+	Short: "Add a registry source",
+	Long: `Add a template registry to registry.sources in ~/.petal/config.yaml.
+
+Templates from every configured source are listed, searched, and pulled along
+with the default registry, unless --registry picks a single registry.`,
+	Example: `  pctl registry add lab github.com/mylab/pctl-seeds`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runRegistryAdd,
+}
+
+// registryRemoveCmd removes a registry source from the config file
+var registryRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a registry source",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRegistryRemove,
+}
+
+// registrySourcesCmd lists the configured registry sources
+var registrySourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "List registry sources",
+	Long: `List the registry sources in ~/.petal/config.yaml and whether each
+one's index can be fetched right now.`,
+	Args: cobra.NoArgs,
+	RunE: runRegistrySources,
+}
+
+// registryPublishCmd publishes a template to a registry
+var registryPublishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Publish a template to a registry",
+	Long: `Validate a template and publish it to a GitHub registry.
+
+The index entry is computed from the template: its name (the file name unless
+metadata.name is set), title (metadata.title or the file's first comment line),
+description, author, version, tags (comma-separated metadata.tags), and the
+SHA-256 of the file. The name and version become paths in the registry, so
+they must be lowercase letters, digits, '.', '_', or '-'.
+
+Without a token, the entry is printed for you to merge into the registry's
+index.json alongside the template file. With a token, the template and the
+updated index.json are committed to the registry through the GitHub API. The
+token is --token, the token of the matching registry source in
+~/.petal/config.yaml, or GITHUB_TOKEN.`,
+	Example: `  # Print the index entry to merge by hand
+  pctl registry publish -t genomics.yaml --registry myorg/pctl-templates
+
+  # Commit the template and index directly
+  pctl registry publish -t genomics.yaml --registry myorg/pctl-templates --token ghp_...`,
+	RunE: runRegistryPublish,
+}
+
 func init() {
 	rootCmd.AddCommand(registryCmd)
 	registryCmd.AddCommand(registryListCmd)
 	registryCmd.AddCommand(registrySearchCmd)
 	registryCmd.AddCommand(registryPullCmd)
+	registryCmd.AddCommand(registryPublishCmd)
+	registryCmd.AddCommand(registryAddCmd)
+	registryCmd.AddCommand(registryRemoveCmd)
+	registryCmd.AddCommand(registrySourcesCmd)
+
+	registryListCmd.Flags().BoolVar(&registryRefresh, "refresh", false, "fetch a fresh registry index instead of using the cache")
+	registrySearchCmd.Flags().BoolVar(&registryRefresh, "refresh", false, "fetch a fresh registry index instead of using the cache")
+	registryPullCmd.Flags().BoolVar(&registryRefresh, "refresh", false, "fetch a fresh registry index instead of using the cache")
+
+	registryPublishCmd.Flags().StringVarP(&publishTemplate, "template", "t", "", "path to template file (required)")
+	registryPublishCmd.Flags().StringVar(&publishToken, "token", "", "GitHub token to commit the template and index directly")
+	registryPublishCmd.MarkFlagRequired("template")
 
 	// Add registry URL flag
 	registryCmd.PersistentFlags().StringVarP(&registryURL, "registry", "r", registry.DefaultRegistry,
 		"registry URL (GitHub repository)")
 }
 
+// createRegistryManager returns a manager for the --registry registry. When
+// --registry is not given, the sources in the config file are queried too.
 func createRegistryManager() (*registry.Manager, error) {
 	manager := registry.NewManager()
 
-	// Parse registry URL and create GitHub registry
-	owner, repo, err := registry.ParseGitHubURL(registryURL)
+	cfg, err := config.Load()
 	if err != nil {
-		return nil, fmt.Errorf("invalid registry URL: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	githubReg := registry.NewGitHubRegistry(owner, repo)
+	githubReg, err := newGitHubRegistry(registryURL, os.Getenv("GITHUB_TOKEN"), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry URL: %w", err)
+	}
 	manager.AddRegistry(githubReg)
 
+	if registryCmd.PersistentFlags().Changed("registry") {
+		return manager, nil
+	}
+	added := map[string]bool{githubReg.String(): true}
+	for _, source := range cfg.Registry.Sources {
+		sourceReg, err := newGitHubRegistry(source.URL, source.GitHubToken(), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL for registry source %s: %w", source.Name, err)
+		}
+		if !added[sourceReg.String()] {
+			manager.AddRegistry(sourceReg)
+			added[sourceReg.String()] = true
+		}
+	}
+
 	return manager, nil
 }
 
+// newGitHubRegistry returns the GitHub registry at url, reading its index
+// through the cache. A token lets pctl read private registries.
+func newGitHubRegistry(url, token string, cfg *config.Config) (*registry.GitHubRegistry, error) {
+	owner, repo, err := registry.ParseGitHubURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	githubReg := registry.NewGitHubRegistry(owner, repo)
+	githubReg.Token = token
+
+	// Cache the index so repeated lists and searches skip the network
+	cacheDir, err := config.GetRegistryCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	githubReg.CacheDir = cacheDir
+	githubReg.CacheTTL = cfg.RegistryCacheTTL()
+	githubReg.Refresh = registryRefresh
+
+	return githubReg, nil
+}
+
+// registryToken returns the GitHub token for the registry at url: the token
+// of the configured source with that URL, or GITHUB_TOKEN.
+func registryToken(cfg *config.Config, url string) string {
+	for _, source := range cfg.Registry.Sources {
+		if sameRegistry(source.URL, url) {
+			return source.GitHubToken()
+		}
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// sameRegistry reports whether two registry URLs name the same repository.
+func sameRegistry(a, b string) bool {
+	ownerA, repoA, errA := registry.ParseGitHubURL(a)
+	ownerB, repoB, errB := registry.ParseGitHubURL(b)
+	return errA == nil && errB == nil && strings.EqualFold(ownerA, ownerB) && strings.EqualFold(repoA, repoB)
+}
+
+// printRegistryFailures warns about registries missing from partial results.
+func printRegistryFailures(failures []registry.RegistryFailure) {
+	for _, failure := range failures {
+		if failure.TimedOut {
+			fmt.Printf("⚠️  Registry %s timed out; its templates are not shown\n", failure.Registry)
+		} else {
+			fmt.Printf("⚠️  Registry %s failed: %v\n", failure.Registry, failure.Err)
+		}
+	}
+	if len(failures) > 0 {
+		fmt.Println()
+	}
+}
+
 func runRegistryList(cmd *cobra.Command, args []string) error {
 	manager, err := createRegistryManager()
 	if err != nil {
@@ -105,10 +277,12 @@ func runRegistryList(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Fetching templates from registry...\n\n")
 
-	templates, err := manager.List()
-	if err != nil {
-		return fmt.Errorf("failed to list templates: %w", err)
+	result := manager.ListAll(context.Background())
+	printRegistryFailures(result.Failures)
+	if len(result.Templates) == 0 && len(result.Failures) > 0 {
+		return fmt.Errorf("failed to list templates: %w", result.Failures[0].Err)
 	}
+	templates := result.Templates
 
 	if len(templates) == 0 {
 		fmt.Println("No templates found in registry.")
@@ -144,10 +318,12 @@ func runRegistrySearch(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Searching for '%s'...\n\n", query)
 
-	templates, err := manager.Search(query)
-	if err != nil {
-		return fmt.Errorf("failed to search templates: %w", err)
+	result := manager.SearchAll(context.Background(), query)
+	printRegistryFailures(result.Failures)
+	if len(result.Templates) == 0 && len(result.Failures) > 0 {
+		return fmt.Errorf("failed to search templates: %w", result.Failures[0].Err)
 	}
+	templates := result.Templates
 
 	if len(templates) == 0 {
 		fmt.Printf("No templates found matching '%s'.\n", query)
@@ -173,7 +349,8 @@ func runRegistrySearch(cmd *cobra.Command, args []string) error {
 
 func runRegistryPull(cmd *cobra.Command, args []string) error {
 	templateName := args[0]
-	destination := templateName + ".yaml"
+	name, _ := registry.ParseTemplateRef(templateName)
+	destination := name + ".yaml"
 	if len(args) > 1 {
 		destination = args[1]
 	}
@@ -196,3 +373,125 @@ func runRegistryPull(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runRegistryAdd(cmd *cobra.Command, args []string) error {
+	name, url := args[0], args[1]
+
+	if _, _, err := registry.ParseGitHubURL(url); err != nil {
+		return fmt.Errorf("invalid registry URL: %w", err)
+	}
+
+	if err := config.AddRegistrySource(config.RegistrySource{Name: name, URL: url}); err != nil {
+		return fmt.Errorf("failed to add registry source: %w", err)
+	}
+
+	fmt.Printf("✅ Added registry source %s (%s)\n", name, url)
+	return nil
+}
+
+func runRegistryRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	if err := config.RemoveRegistrySource(name); err != nil {
+		return fmt.Errorf("failed to remove registry source: %w", err)
+	}
+
+	fmt.Printf("✅ Removed registry source %s\n", name)
+	return nil
+}
+
+func runRegistrySources(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if len(cfg.Registry.Sources) == 0 {
+		fmt.Println("No registry sources configured.")
+		fmt.Printf("\nAdd one with 'pctl registry add <name> <url>'.\n")
+		return nil
+	}
+
+	// Check each index with a fresh fetch, which also refreshes the cache
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAME\tURL\tINDEX\n")
+	fmt.Fprintf(w, "────\t───\t─────\n")
+	for _, source := range cfg.Registry.Sources {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", source.Name, source.URL, sourceReachability(source, cfg))
+	}
+	w.Flush()
+
+	return nil
+}
+
+// sourceReachability describes whether a source's index can be fetched.
+func sourceReachability(source config.RegistrySource, cfg *config.Config) string {
+	githubReg, err := newGitHubRegistry(source.URL, source.GitHubToken(), cfg)
+	if err != nil {
+		return fmt.Sprintf("❌ invalid URL: %v", err)
+	}
+	githubReg.Refresh = true
+
+	templates, err := githubReg.List()
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	return fmt.Sprintf("✅ reachable (%d templates)", len(templates))
+}
+
+func runRegistryPublish(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	token := publishToken
+	if token == "" {
+		token = registryToken(cfg, registryURL)
+	}
+	githubReg, err := newGitHubRegistry(registryURL, token, cfg)
+	if err != nil {
+		return fmt.Errorf("invalid registry URL: %w", err)
+	}
+
+	content, err := os.ReadFile(publishTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+	tmpl, err := template.Load(publishTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to load template: %w", err)
+	}
+	if err := tmpl.Validate(); err != nil {
+		fmt.Printf("❌ Template validation failed:\n\n%v\n", err)
+		return fmt.Errorf("validation failed")
+	}
+
+	entry, err := registry.NewIndexEntry(tmpl, publishTemplate, content, githubReg.String(), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if token == "" {
+		entryJSON, err := json.MarshalIndent(entry, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal index entry: %w", err)
+		}
+		fmt.Printf("✅ Template is valid. To publish it to %s:\n\n", githubReg)
+		fmt.Printf("1. Add %s as %s/%s\n", publishTemplate, githubReg.BasePath, entry.Path)
+		fmt.Printf("2. Add this entry to %s/index.json (replacing any entry named %q):\n\n", githubReg.BasePath, entry.Name)
+		fmt.Println(string(entryJSON))
+		fmt.Printf("\nOr rerun with --token or GITHUB_TOKEN set to commit both files directly.\n")
+		return nil
+	}
+
+	fmt.Printf("Publishing template '%s' to %s...\n", entry.Name, githubReg)
+	if err := githubReg.Publish(entry, content, token); err != nil {
+		return fmt.Errorf("failed to publish template: %w", err)
+	}
+
+	fmt.Printf("✅ Published %s/%s and updated %s/index.json\n", githubReg.BasePath, entry.Path, githubReg.BasePath)
+	fmt.Printf("\nOthers can now use it with:\n")
+	fmt.Printf("  pctl registry pull %s --registry %s/%s\n", entry.Name, githubReg.Owner, githubReg.Repo)
+	return nil
+}
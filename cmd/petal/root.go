@@ -15,6 +15,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/scttfrdmn/petal/internal/config"
@@ -22,8 +23,22 @@ import (
 )
 
 var (
-	cfgFile string
-	verbose bool
+	cfgFile      string
+	verbose      bool
+	dryRun       bool
+	outputFormat string
+)
+
+// dryRunAnnotation marks commands that support --dry-run.
+const dryRunAnnotation = "supportsDryRun"
+
+// jsonOutputAnnotation marks commands that support --output json.
+const jsonOutputAnnotation = "supportsJSONOutput"
+
+// Output formats accepted by --output.
+const (
+	outputText = "text"
+	outputJSON = "json"
 )
 
 var rootCmd = &cobra.Command{
@@ -39,6 +54,21 @@ data pre-configured.
 For more information, visit: https://github.com/scttfrdmn/petal`,
 	SilenceUsage:  true,
 	SilenceErrors: false,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if dryRun && cmd.Annotations[dryRunAnnotation] != "true" {
+			return fmt.Errorf("--dry-run is not supported by '%s'", cmd.CommandPath())
+		}
+		switch outputFormat {
+		case outputText:
+		case outputJSON:
+			if cmd.Annotations[jsonOutputAnnotation] != "true" {
+				return fmt.Errorf("--output json is not supported by '%s'", cmd.CommandPath())
+			}
+		default:
+			return fmt.Errorf("invalid output format: %s (must be text or json)", outputFormat)
+		}
+		return nil
+	},
 }
 
 func init() {
@@ -49,4 +79,19 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.petal/config.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "rehearse the command with simulated AWS calls, creating nothing (supported by create)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", outputText, "output format: text or json (json is supported by status, validate, version, and ami status)")
+
+	// Commands with their own pre-run hooks (e.g. ami) still get the checks above
+	cobra.EnableTraverseRunHooks = true
+}
+
+// printJSON writes v to stdout as indented JSON, for --output json.
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
 }
@@ -32,7 +32,7 @@ var (
 
 var sshCmd = &cobra.Command{
 	Use:     "ssh CLUSTER_NAME",
-	Aliases: []string{"stem", "connect"},
+	Aliases: []string{"stem"},
 	Short:   "SSH into cluster head node",
 	Long: `Connect to the cluster head node via SSH.
 
@@ -64,27 +64,63 @@ func runSSH(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Connecting to cluster: %s\n\n", clusterName)
 	}
 
+	target, err := resolveSSHTarget(clusterName)
+	if err != nil {
+		return err
+	}
+
+	// Print connection info
+	fmt.Printf("🔗 Connecting to %s...\n", clusterName)
+	fmt.Printf("   Host: %s\n", target.host)
+	fmt.Printf("   User: %s\n", target.user)
+	fmt.Printf("   Key:  %s\n\n", target.keyPath)
+
+	sshCmd := target.command()
+
+	// Connect stdin/stdout/stderr to allow interactive session
+	sshCmd.Stdin = os.Stdin
+	sshCmd.Stdout = os.Stdout
+	sshCmd.Stderr = os.Stderr
+
+	// Execute SSH command
+	if err := sshCmd.Run(); err != nil {
+		return fmt.Errorf("SSH connection failed: %w", err)
+	}
+
+	return nil
+}
+
+// sshTarget is a cluster head node reachable over SSH.
+type sshTarget struct {
+	host    string
+	user    string
+	keyPath string
+}
+
+// resolveSSHTarget finds the head node address and SSH key for a cluster,
+// using the --key and --user flags when set.
+func resolveSSHTarget(clusterName string) (*sshTarget, error) {
 	// Create provisioner
 	prov, err := provisioner.NewProvisioner()
 	if err != nil {
-		return fmt.Errorf("failed to create provisioner: %w", err)
+		return nil, fmt.Errorf("failed to create provisioner: %w", err)
 	}
 
 	// Get cluster status to retrieve head node IP
 	ctx := context.Background()
 	status, err := prov.GetClusterStatus(ctx, clusterName)
 	if err != nil {
-		return fmt.Errorf("failed to get cluster status: %w", err)
+		return nil, fmt.Errorf("failed to get cluster status: %w", err)
 	}
 
 	// Check if cluster is ready
-	if status.Status != "CREATE_COMPLETE" {
-		return fmt.Errorf("cluster is not ready for SSH (status: %s)\n\nRun 'pctl status %s' to check cluster state", status.Status, clusterName)
+	if !status.Ready() {
+		return nil, fmt.Errorf("cluster is not ready for SSH (status: %s)\n\nRun 'pctl status %s' to check cluster state", status.Status, clusterName)
 	}
 
 	// Check if head node IP is available
 	if status.HeadNodeIP == "" {
-		return fmt.Errorf("head node IP address not available yet\n\nTry again in a few moments")
+		return nil, fmt.Errorf("head node IP address not available yet\n\nTry again in a few moments")
 	}
 
 	// Determine key path
@@ -95,58 +131,55 @@ func runSSH(cmd *cobra.Command, args []string) error {
 		if err == nil {
 			state, err := stateMgr.Load(clusterName)
 			if err == nil && state.KeyName != "" {
-				// Check common key locations
-				homeDir, _ := os.UserHomeDir()
-				possiblePaths := []string{
-					filepath.Join(homeDir, ".ssh", state.KeyName+".pem"),
-					filepath.Join(homeDir, ".ssh", state.KeyName),
-					filepath.Join(homeDir, ".ssh", "id_rsa"),
-				}
-
-				for _, path := range possiblePaths {
-					if _, err := os.Stat(path); err == nil {
-						keyPath = path
-						break
-					}
-				}
+				keyPath = findSSHKey(state.KeyName)
 			}
 		}
 
 		// If still no key found, provide helpful error
 		if keyPath == "" {
-			return fmt.Errorf("SSH key path not found\n\nPlease specify the key path with:\n  pctl ssh %s --key ~/.ssh/<key>.pem\n\nOr use the full SSH command:\n  ssh -i ~/.ssh/<key>.pem %s@%s",
+			return nil, fmt.Errorf("SSH key path not found\n\nPlease specify the key path with:\n  pctl ssh %s --key ~/.ssh/<key>.pem\n\nOr use the full SSH command:\n  ssh -i ~/.ssh/<key>.pem %s@%s",
 				clusterName, sshUser, status.HeadNodeIP)
 		}
 	}
 
 	// Verify key exists
 	if _, err := os.Stat(keyPath); os.IsNotExist(err) {
-		return fmt.Errorf("SSH key not found: %s\n\nPlease provide the correct key path with --key flag", keyPath)
+		return nil, fmt.Errorf("SSH key not found: %s\n\nPlease provide the correct key path with --key flag", keyPath)
 	}
 
-	// Print connection info
-	fmt.Printf("🔗 Connecting to %s...\n", clusterName)
-	fmt.Printf("   Host: %s\n", status.HeadNodeIP)
-	fmt.Printf("   User: %s\n", sshUser)
-	fmt.Printf("   Key:  %s\n\n", keyPath)
-
-	// Build SSH command
-	sshCmd := exec.Command("ssh",
-		"-i", keyPath,
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		fmt.Sprintf("%s@%s", sshUser, status.HeadNodeIP),
-	)
+	return &sshTarget{
+		host:    status.HeadNodeIP,
+		user:    sshUser,
+		keyPath: keyPath,
+	}, nil
+}
 
-	// Connect stdin/stdout/stderr to allow interactive session
-	sshCmd.Stdin = os.Stdin
-	sshCmd.Stdout = os.Stdout
-	sshCmd.Stderr = os.Stderr
+// findSSHKey returns the private key for an EC2 key pair from the common
+// locations under ~/.ssh, or "" if none exists.
+func findSSHKey(keyName string) string {
+	homeDir, _ := os.UserHomeDir()
+	possiblePaths := []string{
+		filepath.Join(homeDir, ".ssh", keyName+".pem"),
+		filepath.Join(homeDir, ".ssh", keyName),
+		filepath.Join(homeDir, ".ssh", "id_rsa"),
+	}
 
-	// Execute SSH command
-	if err := sshCmd.Run(); err != nil {
-		return fmt.Errorf("SSH connection failed: %w", err)
+	for _, path := range possiblePaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
 	}
+	return ""
+}
 
-	return nil
+// command builds an SSH command to the head node. With no remote command it
+// opens an interactive session.
+func (t *sshTarget) command(remote ...string) *exec.Cmd {
+	args := []string{
+		"-i", t.keyPath,
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+		fmt.Sprintf("%s@%s", t.user, t.host),
+	}
+	return exec.Command("ssh", append(args, remote...)...)
 }
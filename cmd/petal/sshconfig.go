@@ -0,0 +1,266 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scttfrdmn/petal/internal/config"
+	"github.com/scttfrdmn/petal/pkg/provisioner"
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sshConfigWrite bool
+	sshConfigAll   bool
+)
+
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config [CLUSTER_NAME]",
+	Short: "Print an SSH config entry for a cluster head node",
+	Long: `Print a Host block for ~/.ssh/config so that 'ssh CLUSTER_NAME' connects
+to the cluster's head node.
+
+The head node address, key pair, and user come from the cluster's local state;
+if the address has not been recorded yet it is looked up once and saved.
+With --write the blocks are added to ~/.ssh/config, replacing any block pctl
+wrote earlier for the same cluster.
+
+Head node host keys are accepted on first connection and recorded in
+~/.petal/known_hosts, so later connections are verified. If a recreated
+cluster reuses an address, remove its old key with:
+  ssh-keygen -R ADDRESS -f ~/.petal/known_hosts`,
+	Example: `  # Print the entry for one cluster
+  pctl ssh-config my-cluster
+
+  # Add entries for every cluster to ~/.ssh/config
+  pctl ssh-config --all --write
+
+  # Then connect with plain ssh, scp, or rsync
+  ssh my-cluster`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSSHConfig,
+}
+
+func init() {
+	rootCmd.AddCommand(sshConfigCmd)
+	sshConfigCmd.Flags().BoolVar(&sshConfigWrite, "write", false, "add the entries to ~/.ssh/config instead of printing them")
+	sshConfigCmd.Flags().BoolVar(&sshConfigAll, "all", false, "include every cluster in local state")
+	sshConfigCmd.Flags().StringVarP(&sshKeyPath, "key", "i", "", "Path to SSH private key (overrides cluster default)")
+	sshConfigCmd.Flags().StringVarP(&sshUser, "user", "u", "ec2-user", "SSH username")
+}
+
+func runSSHConfig(cmd *cobra.Command, args []string) error {
+	if sshConfigAll == (len(args) == 1) {
+		return fmt.Errorf("specify either a cluster name or --all")
+	}
+
+	prov, err := provisioner.NewProvisioner()
+	if err != nil {
+		return fmt.Errorf("failed to create provisioner: %w", err)
+	}
+	stateMgr, err := prov.GetStateManager()
+	if err != nil {
+		return fmt.Errorf("failed to get state manager: %w", err)
+	}
+
+	var clusters []*state.ClusterState
+	if sshConfigAll {
+		clusters, err = prov.ListClusters()
+		if err != nil {
+			return fmt.Errorf("failed to list clusters: %w", err)
+		}
+	} else {
+		clusterState, err := stateMgr.Load(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to load cluster state: %w", err)
+		}
+		clusters = []*state.ClusterState{clusterState}
+	}
+
+	ctx := context.Background()
+	blocks := map[string]string{}
+	var names []string
+	for _, clusterState := range clusters {
+		block, err := clusterSSHConfig(ctx, prov, stateMgr, clusterState)
+		if err != nil {
+			if !sshConfigAll {
+				return err
+			}
+			// Keep stdout clean for redirecting into a config file
+			fmt.Fprintf(os.Stderr, "⚠️  Skipping %s: %v\n", clusterState.Name, err)
+			continue
+		}
+		blocks[clusterState.Name] = block
+		names = append(names, clusterState.Name)
+	}
+
+	if len(names) == 0 {
+		return fmt.Errorf("no clusters with a known head node address")
+	}
+
+	if !sshConfigWrite {
+		for i, name := range names {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Print(blocks[name])
+		}
+		return nil
+	}
+
+	path, err := userSSHConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := writeSSHConfig(path, names, blocks); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Wrote %d SSH host(s) to %s\n", len(names), path)
+	for _, name := range names {
+		fmt.Printf("   ssh %s\n", name)
+	}
+	return nil
+}
+
+// clusterSSHConfig builds the SSH config block for a cluster, looking up and
+// saving the head node address if its state has none yet.
+func clusterSSHConfig(ctx context.Context, prov *provisioner.Provisioner, stateMgr *state.Manager, clusterState *state.ClusterState) (string, error) {
+	if clusterState.HeadNodeIP == "" && clusterState.HeadNodePrivateIP == "" {
+		status, err := prov.GetClusterStatus(ctx, clusterState.Name)
+		if err != nil {
+			return "", fmt.Errorf("failed to get cluster status: %w", err)
+		}
+		if status.HeadNodeIP == "" && status.HeadNodePrivateIP == "" {
+			return "", fmt.Errorf("head node address not available (status: %s)", status.Status)
+		}
+		clusterState.HeadNodeIP = status.HeadNodeIP
+		clusterState.HeadNodePrivateIP = status.HeadNodePrivateIP
+		if err := stateMgr.Save(clusterState); err != nil {
+			return "", fmt.Errorf("failed to save cluster state: %w", err)
+		}
+	}
+
+	keyPath := sshKeyPath
+	if keyPath == "" && clusterState.KeyName != "" {
+		keyPath = findSSHKey(clusterState.KeyName)
+	}
+	knownHosts, err := pctlKnownHostsPath()
+	if err != nil {
+		return "", err
+	}
+	return sshConfigBlock(clusterState, sshUser, keyPath, knownHosts)
+}
+
+// pctlKnownHostsPath returns the known_hosts file for cluster head nodes,
+// ~/.petal/known_hosts, creating its directory if needed. It is kept apart
+// from ~/.ssh/known_hosts since head node addresses are reused by later
+// clusters with new host keys.
+func pctlKnownHostsPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", configDir, err)
+	}
+	return filepath.Join(configDir, "known_hosts"), nil
+}
+
+// sshConfigBlock returns a Host block for a cluster's head node, preferring
+// its public address. A new head node's host key is accepted on first use and
+// recorded in knownHosts, then verified on later connections. The block is
+// delimited by comments so it can be replaced when written again.
+func sshConfigBlock(clusterState *state.ClusterState, user, keyPath, knownHosts string) (string, error) {
+	host := clusterState.HeadNodeIP
+	if host == "" {
+		host = clusterState.HeadNodePrivateIP
+	}
+	if host == "" {
+		return "", fmt.Errorf("cluster %s has no recorded head node address", clusterState.Name)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", sshConfigBegin(clusterState.Name))
+	fmt.Fprintf(&b, "Host %s\n", clusterState.Name)
+	fmt.Fprintf(&b, "    HostName %s\n", host)
+	fmt.Fprintf(&b, "    User %s\n", user)
+	if keyPath != "" {
+		fmt.Fprintf(&b, "    IdentityFile %s\n", keyPath)
+		fmt.Fprintf(&b, "    IdentitiesOnly yes\n")
+	}
+	fmt.Fprintf(&b, "    StrictHostKeyChecking accept-new\n")
+	fmt.Fprintf(&b, "    UserKnownHostsFile %s\n", knownHosts)
+	fmt.Fprintf(&b, "%s\n", sshConfigEnd(clusterState.Name))
+	return b.String(), nil
+}
+
+func sshConfigBegin(name string) string { return "# BEGIN pctl " + name }
+func sshConfigEnd(name string) string   { return "# END pctl " + name }
+
+// mergeSSHConfig returns config with the block for the named cluster
+// replacing any earlier pctl block for it, or appended if there is none.
+func mergeSSHConfig(config, name, block string) string {
+	begin, end := sshConfigBegin(name)+"\n", sshConfigEnd(name)+"\n"
+	if start := strings.Index(config, begin); start >= 0 {
+		if stop := strings.Index(config[start:], end); stop >= 0 {
+			return config[:start] + block + config[start+stop+len(end):]
+		}
+	}
+
+	if config == "" {
+		return block
+	}
+	if !strings.HasSuffix(config, "\n") {
+		config += "\n"
+	}
+	return config + "\n" + block
+}
+
+// userSSHConfigPath returns the path of the user's ~/.ssh/config.
+func userSSHConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".ssh", "config"), nil
+}
+
+// writeSSHConfig merges the named clusters' blocks into the SSH config file
+// at path, creating it if needed.
+func writeSSHConfig(path string, names []string, blocks map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	config := string(data)
+	for _, name := range names {
+		config = mergeSSHConfig(config, name, blocks[name])
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/state"
+)
+
+func TestSSHConfigBlock(t *testing.T) {
+	clusterState := &state.ClusterState{
+		Name:              "bio",
+		HeadNodeIP:        "203.0.113.10",
+		HeadNodePrivateIP: "10.0.1.5",
+		KeyName:           "lab",
+	}
+
+	block, err := sshConfigBlock(clusterState, "ec2-user", "/home/me/.ssh/lab.pem", "/home/me/.petal/known_hosts")
+	if err != nil {
+		t.Fatalf("sshConfigBlock() error = %v", err)
+	}
+	want := `# BEGIN pctl bio
+Host bio
+    HostName 203.0.113.10
+    User ec2-user
+    IdentityFile /home/me/.ssh/lab.pem
+    IdentitiesOnly yes
+    StrictHostKeyChecking accept-new
+    UserKnownHostsFile /home/me/.petal/known_hosts
+# END pctl bio
+`
+	if block != want {
+		t.Errorf("sshConfigBlock() =\n%s\nwant\n%s", block, want)
+	}
+}
+
+func TestSSHConfigBlockPrivateAddressNoKey(t *testing.T) {
+	block, err := sshConfigBlock(&state.ClusterState{Name: "private", HeadNodePrivateIP: "10.0.1.5"}, "ubuntu", "", "/home/me/.petal/known_hosts")
+	if err != nil {
+		t.Fatalf("sshConfigBlock() error = %v", err)
+	}
+	if !strings.Contains(block, "HostName 10.0.1.5\n") || !strings.Contains(block, "User ubuntu\n") {
+		t.Errorf("unexpected block:\n%s", block)
+	}
+	if strings.Contains(block, "IdentityFile") {
+		t.Errorf("block should not set IdentityFile without a key:\n%s", block)
+	}
+
+	if _, err := sshConfigBlock(&state.ClusterState{Name: "pending"}, "ec2-user", "", "/home/me/.petal/known_hosts"); err == nil {
+		t.Error("sshConfigBlock() expected error without a head node address")
+	}
+}
+
+func TestMergeSSHConfig(t *testing.T) {
+	block := func(name, ip string) string {
+		b, err := sshConfigBlock(&state.ClusterState{Name: name, HeadNodeIP: ip}, "ec2-user", "", "/home/me/.petal/known_hosts")
+		if err != nil {
+			t.Fatalf("sshConfigBlock() error = %v", err)
+		}
+		return b
+	}
+
+	existing := "Host github.com\n    User git"
+	config := mergeSSHConfig(existing, "bio", block("bio", "203.0.113.10"))
+	config = mergeSSHConfig(config, "chem", block("chem", "203.0.113.20"))
+	if !strings.HasPrefix(config, "Host github.com\n    User git\n\n# BEGIN pctl bio\n") {
+		t.Errorf("existing entries should be kept ahead of appended blocks:\n%s", config)
+	}
+
+	// Writing a cluster again replaces its block in place
+	updated := mergeSSHConfig(config, "bio", block("bio", "198.51.100.7"))
+	if strings.Contains(updated, "203.0.113.10") || !strings.Contains(updated, "HostName 198.51.100.7") {
+		t.Errorf("bio block was not replaced:\n%s", updated)
+	}
+	if strings.Count(updated, "Host bio\n") != 1 || !strings.Contains(updated, "HostName 203.0.113.20") {
+		t.Errorf("unexpected config after replacing bio:\n%s", updated)
+	}
+	if strings.Index(updated, "Host bio") > strings.Index(updated, "Host chem") {
+		t.Error("replaced block should stay in its original position")
+	}
+
+	if got := mergeSSHConfig("", "bio", block("bio", "203.0.113.10")); got != block("bio", "203.0.113.10") {
+		t.Errorf("mergeSSHConfig() into empty config = %q", got)
+	}
+}
+
+func TestWriteSSHConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ssh", "config")
+	blocks := map[string]string{}
+	for _, name := range []string{"bio", "chem"} {
+		b, err := sshConfigBlock(&state.ClusterState{Name: name, HeadNodeIP: "203.0.113.10"}, "ec2-user", "", "/home/me/.petal/known_hosts")
+		if err != nil {
+			t.Fatalf("sshConfigBlock() error = %v", err)
+		}
+		blocks[name] = b
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := writeSSHConfig(path, []string{"bio", "chem"}, blocks); err != nil {
+			t.Fatalf("writeSSHConfig() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	if strings.Count(string(data), "# BEGIN pctl") != 2 {
+		t.Errorf("writing twice should not duplicate entries:\n%s", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("config mode = %v, want 0600", info.Mode().Perm())
+	}
+}
@@ -17,6 +17,9 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/scttfrdmn/petal/pkg/provisioner"
 	"github.com/spf13/cobra"
@@ -34,24 +37,80 @@ Shows:
 - Compute node counts and status
 - ParallelCluster version
 - Software installation status
-- Error messages (if any)`,
+- Error messages (if any)
+
+With --slurm, pctl also connects to the head node over SSH and reports each
+partition's allocated and idle nodes and the number of pending jobs. If the
+head node cannot be reached, the rest of the status is still shown.
+
+With --output json, the status is printed as JSON for scripts, and pctl exits
+non-zero if the cluster is in a FAILED state.
+
+With --watch, pctl resumes following a cluster that is still being created,
+showing CloudFormation events and the cluster configuration phase just as
+pctl create does. Use it after pctl create timed out or was interrupted. If
+the cluster has already finished creating, its status is shown immediately.`,
 	Example: `  # Get cluster status
   pctl status my-cluster
 
+  # Include Slurm node utilization and pending jobs
+  pctl status my-cluster --slurm
+
   # Get status with verbose output
-  pctl status my-cluster --verbose`,
-	Args: cobra.ExactArgs(1),
-	RunE: runStatus,
+  pctl status my-cluster --verbose
+
+  # Get status as JSON
+  pctl status my-cluster --output json
+
+  # Resume following a cluster that is still being created
+  pctl status my-cluster --watch`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runStatus,
+	Annotations: map[string]string{jsonOutputAnnotation: "true"},
+}
+
+// clusterStatusOutput is the status --output json document.
+type clusterStatusOutput struct {
+	*provisioner.ClusterStatus
+	// Slurm is set with --slurm when the head node could be queried
+	Slurm *provisioner.SlurmUtilization `json:"slurm,omitempty"`
+	// SlurmError explains why --slurm utilization is missing
+	SlurmError string `json:"slurm_error,omitempty"`
 }
 
+var (
+	statusSlurm        bool
+	statusWatch        bool
+	statusPollEvery    time.Duration
+	statusWatchTimeout time.Duration
+)
+
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusSlurm, "slurm", false, "SSH to the head node and report Slurm partition utilization and pending jobs")
+	statusCmd.Flags().StringVarP(&sshKeyPath, "key", "i", "", "Path to SSH private key for --slurm (overrides cluster default)")
+	statusCmd.Flags().StringVarP(&sshUser, "user", "u", "ec2-user", "SSH username for --slurm")
+	statusCmd.Flags().BoolVarP(&statusWatch, "watch", "w", false, "follow creation progress of a cluster that is still being created")
+	statusCmd.Flags().DurationVar(&statusPollEvery, "progress-interval", 0, "how often to check creation progress with --watch (minimum 10s)")
+	statusCmd.Flags().DurationVar(&statusWatchTimeout, "monitor-timeout", provisioner.DefaultMonitorTimeout, "how long to follow creation with --watch")
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
 	clusterName := args[0]
 
-	if verbose {
+	if statusWatch {
+		if outputFormat == outputJSON {
+			return fmt.Errorf("--watch cannot be used with --output json")
+		}
+		if err := validateProgressInterval(statusPollEvery); err != nil {
+			return err
+		}
+		if statusWatchTimeout <= 0 {
+			return fmt.Errorf("--monitor-timeout must be positive")
+		}
+	}
+
+	if verbose && outputFormat != outputJSON {
 		fmt.Printf("Checking status for cluster: %s\n\n", clusterName)
 	}
 
@@ -61,13 +120,24 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create provisioner: %w", err)
 	}
 
-	// Get cluster status
 	ctx := context.Background()
+	if statusWatch {
+		if err := prov.WatchClusterCreation(ctx, clusterName, statusPollEvery, statusWatchTimeout); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
+	// Get cluster status
 	status, err := prov.GetClusterStatus(ctx, clusterName)
 	if err != nil {
 		return fmt.Errorf("failed to get cluster status: %w", err)
 	}
 
+	if outputFormat == outputJSON {
+		return printClusterStatusJSON(clusterName, status)
+	}
+
 	// Print status header
 	statusEmoji := getStatusEmoji(status.Status)
 	fmt.Printf("📊 Cluster Status: %s\n\n", clusterName)
@@ -93,14 +163,24 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  State:  %s\n", status.SchedulerState)
 	}
 
+	if statusSlurm {
+		printSlurmUtilization(clusterName, status)
+	}
+
 	// Print next steps based on status
 	fmt.Printf("\nActions:\n")
 	switch status.Status {
 	case "CREATE_IN_PROGRESS":
 		fmt.Printf("  ⏳ Cluster is being created. Check again in a few minutes.\n")
 		fmt.Printf("  💡 Monitor progress: pctl status %s\n", clusterName)
-	case "CREATE_COMPLETE":
-		fmt.Printf("  ✅ Cluster is ready to use!\n")
+	case "CREATE_COMPLETE", "UPDATE_COMPLETE":
+		if status.SchedulerState == provisioner.FleetStatusStopped {
+			fmt.Printf("  ⏸️  Compute fleet is stopped; queued jobs will not run.\n")
+			fmt.Printf("  ▶️  Start compute fleet: pctl start %s\n", clusterName)
+		} else {
+			fmt.Printf("  ✅ Cluster is ready to use!\n")
+			fmt.Printf("  ⏸️  Stop compute fleet while idle: pctl stop %s\n", clusterName)
+		}
 		if status.HeadNodeIP != "" {
 			fmt.Printf("  🔗 SSH to head node: ssh -i ~/.ssh/<key>.pem ec2-user@%s\n", status.HeadNodeIP)
 		}
@@ -123,3 +203,87 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// printClusterStatusJSON prints status as JSON. It returns an error for a
+// failed cluster so scripts can branch on the exit code.
+func printClusterStatusJSON(clusterName string, status *provisioner.ClusterStatus) error {
+	output := clusterStatusOutput{ClusterStatus: status}
+	if statusSlurm {
+		if !status.Ready() {
+			output.SlurmError = fmt.Sprintf("unavailable until the cluster is ready (status: %s)", status.Status)
+		} else if utilization, err := querySlurmUtilization(clusterName); err != nil {
+			output.SlurmError = err.Error()
+		} else {
+			output.Slurm = utilization
+		}
+	}
+
+	if err := printJSON(output); err != nil {
+		return err
+	}
+	if status.Failed() {
+		return fmt.Errorf("cluster %s is %s", clusterName, status.Status)
+	}
+	return nil
+}
+
+// printSlurmUtilization reports Slurm partition utilization and pending jobs
+// from the head node. Failures are reported as warnings, since the rest of
+// the status is still useful.
+func printSlurmUtilization(clusterName string, status *provisioner.ClusterStatus) {
+	fmt.Printf("\nSlurm Utilization:\n")
+	if !status.Ready() {
+		fmt.Printf("  ⚠️  Unavailable until the cluster is ready (status: %s)\n", status.Status)
+		return
+	}
+
+	utilization, err := querySlurmUtilization(clusterName)
+	if err != nil {
+		fmt.Printf("  ⚠️  Unavailable: %v\n", err)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  PARTITION\tSTATE\tALLOCATED\tIDLE\tOTHER\tTOTAL\tUSED\n")
+	for _, p := range utilization.Partitions {
+		name := p.Name
+		if p.Default {
+			name += "*"
+		}
+		state := "up"
+		if !p.Available {
+			state = "down"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%d\t%d\t%d\t%d\t%.0f%%\n", name, state, p.Allocated, p.Idle, p.Other, p.Total, p.Percent())
+	}
+	w.Flush()
+	fmt.Printf("  Pending jobs: %d\n", utilization.PendingJobs)
+}
+
+// querySlurmUtilization runs sinfo and squeue on the cluster's head node.
+func querySlurmUtilization(clusterName string) (*provisioner.SlurmUtilization, error) {
+	target, err := resolveSSHTarget(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	sinfoOutput, err := runRemote(target, "", provisioner.SinfoSummaryCommand)
+	if err != nil {
+		return nil, fmt.Errorf("sinfo failed: %w", err)
+	}
+	partitions, err := provisioner.ParseSinfoSummary(sinfoOutput)
+	if err != nil {
+		return nil, err
+	}
+
+	squeueOutput, err := runRemote(target, "", provisioner.PendingJobsCommand)
+	if err != nil {
+		return nil, fmt.Errorf("squeue failed: %w", err)
+	}
+	pending, err := provisioner.ParseJobCount(squeueOutput)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provisioner.SlurmUtilization{Partitions: partitions, PendingJobs: pending}, nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/scttfrdmn/petal/pkg/capture"
+	"github.com/scttfrdmn/petal/pkg/template"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	convertTemplate string
+	convertTo       string
+	convertOutput   string
+	convertInPlace  bool
+	packagesPicker  bool
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Work with cluster templates",
+	Long:  `Commands for working with pctl cluster templates.`,
+}
+
+var templateConvertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Upgrade a template to a newer apiVersion",
+	Long: `Upgrade a template to a newer schema version.
+
+Loads the template, applies each registered migration from its apiVersion
+(v1 if unset) up to the target version, validates the result, and writes the
+upgraded YAML. Comments and key order are preserved.
+
+Without --output-file or --in-place, the upgraded template is written to stdout.`,
+	Example: `  # Upgrade to the current version and write a new file
+  pctl template convert -t old.yaml --output-file new.yaml
+
+  # Upgrade to a specific version in place
+  pctl template convert -t cluster.yaml --to v2 --in-place`,
+	RunE: runTemplateConvert,
+}
+
+var templatePackagesCmd = &cobra.Command{
+	Use:   "packages [query]",
+	Short: "Search for Spack packages to use in a template",
+	Long: `Search the package index for Spack packages by module or package name.
+
+Matching is fuzzy: the query's letters must appear in order, so "qesp"
+finds quantum-espresso. The index is built from pctl's module database.
+
+With --interactive, search repeatedly and pick packages by number. The
+selected packages are printed as a software.spack_packages block to paste
+into a template.`,
+	Example: `  # Find MPI implementations
+  pctl template packages mpi
+
+  # Pick packages interactively
+  pctl template packages --interactive`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTemplatePackages,
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateConvertCmd)
+	templateCmd.AddCommand(templatePackagesCmd)
+
+	templatePackagesCmd.Flags().BoolVarP(&packagesPicker, "interactive", "i", false, "interactively search and select packages")
+
+	templateConvertCmd.Flags().StringVarP(&convertTemplate, "template", "t", "", "path to template file (required)")
+	templateConvertCmd.Flags().StringVar(&convertTo, "to", template.CurrentAPIVersion, "target apiVersion")
+	templateConvertCmd.Flags().StringVarP(&convertOutput, "output-file", "o", "", "write the upgraded template to this file")
+	templateConvertCmd.Flags().BoolVar(&convertInPlace, "in-place", false, "overwrite the input template")
+	templateConvertCmd.MarkFlagRequired("template")
+}
+
+func runTemplateConvert(cmd *cobra.Command, args []string) error {
+	if convertInPlace && convertOutput != "" {
+		return fmt.Errorf("--in-place and --output-file cannot be used together")
+	}
+
+	data, err := os.ReadFile(convertTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to read template file: %w", err)
+	}
+
+	out, applied, err := template.Migrate(data, convertTo)
+	if err != nil {
+		return fmt.Errorf("failed to convert template: %w", err)
+	}
+
+	// Make sure the upgraded template is still valid before writing it
+	var tmpl template.Template
+	if err := yaml.Unmarshal(out, &tmpl); err != nil {
+		return fmt.Errorf("failed to parse converted template: %w", err)
+	}
+	tmpl.ResolveSkeletons(filepath.Dir(convertTemplate))
+	if err := tmpl.Validate(); err != nil {
+		return fmt.Errorf("converted template is invalid: %w", err)
+	}
+
+	outputPath := convertOutput
+	if convertInPlace {
+		outputPath = convertTemplate
+	}
+
+	if outputPath == "" {
+		fmt.Print(string(out))
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, out, 0644); err != nil {
+		return fmt.Errorf("failed to write template: %w", err)
+	}
+
+	if len(applied) == 0 {
+		fmt.Printf("✅ Template is already at %s\n", convertTo)
+	} else {
+		for _, m := range applied {
+			fmt.Printf("   %s → %s: %s\n", m.From, m.To, m.Description)
+		}
+		fmt.Printf("✅ Template converted to %s\n", convertTo)
+	}
+	fmt.Printf("   Written to: %s\n", outputPath)
+
+	return nil
+}
+
+func runTemplatePackages(cmd *cobra.Command, args []string) error {
+	db := capture.NewModuleDatabase()
+
+	if packagesPicker {
+		packages, err := pickPackages(os.Stdin, os.Stdout, db)
+		if err != nil {
+			return err
+		}
+		if len(packages) == 0 {
+			fmt.Println("No packages selected.")
+			return nil
+		}
+
+		fmt.Printf("\n✅ Selected %d package(s). Add to your template:\n\n", len(packages))
+		fmt.Printf("software:\n  spack_packages:\n")
+		for _, spec := range packages {
+			fmt.Printf("    - %s\n", spec)
+		}
+		return nil
+	}
+
+	query := ""
+	if len(args) > 0 {
+		query = args[0]
+	}
+	matches := db.Search(query)
+	if len(matches) == 0 {
+		fmt.Printf("No packages match '%s'.\n", query)
+		return nil
+	}
+	for _, match := range matches {
+		fmt.Printf("  %-36s (module: %s)\n", match.SpackPackage, match.OnPremName)
+	}
+	return nil
+}
+
+// pickPackages runs the interactive package picker, reading searches and
+// selections from in until an empty search. It returns the selected specs in
+// selection order, without duplicates.
+func pickPackages(in io.Reader, out io.Writer, db *capture.ModuleDatabase) ([]string, error) {
+	reader := bufio.NewReader(in)
+	selected := make(map[string]bool)
+	var packages []string
+
+	for {
+		fmt.Fprintf(out, "\nSearch packages (empty to finish): ")
+		query, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if query == "" {
+			return packages, nil
+		}
+
+		matches := db.Search(query)
+		if len(matches) == 0 {
+			fmt.Fprintf(out, "  No packages match '%s'\n", query)
+			continue
+		}
+		for i, match := range matches {
+			marker := " "
+			if selected[match.SpackPackage] {
+				marker = "✓"
+			}
+			fmt.Fprintf(out, "  %s %2d) %s\n", marker, i+1, match.SpackPackage)
+		}
+
+		fmt.Fprintf(out, "Select (e.g. 1,3 or 2-4; empty to search again): ")
+		input, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		indexes, err := parseSelection(input, len(matches))
+		if err != nil {
+			fmt.Fprintf(out, "  ❌ %v\n", err)
+			continue
+		}
+
+		for _, i := range indexes {
+			spec := matches[i].SpackPackage
+			if selected[spec] {
+				continue
+			}
+			if !template.ValidSpackSpec(spec) {
+				fmt.Fprintf(out, "  ⚠️  Skipping '%s': not a valid package spec\n", spec)
+				continue
+			}
+			selected[spec] = true
+			packages = append(packages, spec)
+			fmt.Fprintf(out, "  ✓ %s\n", spec)
+		}
+	}
+}
+
+// readLine reads a trimmed line, treating end of input as an empty line.
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// parseSelection parses 1-based choices like "1,3 5-7" into 0-based indexes.
+func parseSelection(input string, count int) ([]int, error) {
+	var indexes []int
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to := field, field
+		if parts := strings.SplitN(field, "-", 2); len(parts) == 2 {
+			from, to = parts[0], parts[1]
+		}
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection '%s'", field)
+		}
+		end, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection '%s'", field)
+		}
+		if start < 1 || end > count || start > end {
+			return nil, fmt.Errorf("selection '%s' is out of range 1-%d", field, count)
+		}
+		for i := start; i <= end; i++ {
+			indexes = append(indexes, i-1)
+		}
+	}
+	return indexes, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scttfrdmn/petal/pkg/provisioner"
+	"github.com/scttfrdmn/petal/pkg/template"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateSeed      string
+	updateTemplate  string // Deprecated, use updateSeed
+	updateName      string
+	updateStopFleet bool
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Apply seed changes to a running cluster",
+	Long: `Apply seed changes to a running cluster with pcluster update-cluster.
+
+The ParallelCluster configuration is regenerated from the seed, keeping the
+network, key pair, AMI, bootstrap script, and tags the cluster was created
+with, which ParallelCluster does not allow to change. Queue, instance type,
+scaling, and scheduler changes are applied in place. Software, users, and S3
+mounts are set up when nodes first boot, so changing them needs a new AMI or
+cluster.
+
+Flags given to pctl create that change the seed, such as --region,
+--scale-to-zero, --compute-efa-gdr, and --compute-on-demand-capacity, are
+re-applied, as are the SSH CIDRs the head node was restricted to.
+
+Some changes, such as replacing a queue's instance types, require the compute
+fleet to be stopped. pctl reports when that is the case; with --stop-fleet it
+stops the fleet, updates the cluster, and starts the fleet again. Stopping the
+fleet terminates running compute nodes and their jobs.`,
+	Example: `  # Apply seed changes to a cluster
+  pctl update --seed my-cluster.yaml
+
+  # Update a cluster created with --name
+  pctl update --seed my-cluster.yaml --name production-cluster
+
+  # Stop the compute fleet if the update requires it
+  pctl update --seed my-cluster.yaml --stop-fleet`,
+	RunE: runUpdate,
+}
+
+func init() {
+	updateCmd.Flags().StringVar(&updateSeed, "seed", "", "path to seed file (required)")
+	updateCmd.Flags().StringVarP(&updateTemplate, "template", "t", "", "DEPRECATED: use --seed instead")
+	updateCmd.Flags().StringVarP(&updateName, "name", "n", "", "cluster name (overrides seed)")
+	updateCmd.Flags().BoolVar(&updateStopFleet, "stop-fleet", false, "stop the compute fleet if the update requires it, then start it again (terminates running jobs)")
+	rootCmd.AddCommand(updateCmd)
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	seedFile := updateSeed
+	if updateTemplate != "" {
+		if updateSeed != "" {
+			return fmt.Errorf("cannot use both --seed and --template flags")
+		}
+		fmt.Printf("⚠️  Warning: --template is deprecated, use --seed instead\n\n")
+		seedFile = updateTemplate
+	}
+
+	if seedFile == "" {
+		return fmt.Errorf("--seed is required for cluster updates")
+	}
+
+	tmpl, err := template.Load(seedFile)
+	if err != nil {
+		return fmt.Errorf("failed to load template: %w", err)
+	}
+	if err := tmpl.Validate(); err != nil {
+		return fmt.Errorf("template validation failed: %w", err)
+	}
+	printTemplateWarnings(tmpl)
+
+	if updateName != "" {
+		tmpl.Cluster.Name = updateName
+	}
+
+	prov, err := provisioner.NewProvisioner()
+	if err != nil {
+		return fmt.Errorf("failed to create provisioner: %w", err)
+	}
+
+	stateMgr, err := prov.GetStateManager()
+	if err != nil {
+		return fmt.Errorf("failed to get state manager: %w", err)
+	}
+	clusterState, err := stateMgr.Load(tmpl.Cluster.Name)
+	if err != nil {
+		return fmt.Errorf("cluster '%s' not found. Use 'pctl list' to see managed clusters", tmpl.Cluster.Name)
+	}
+
+	fmt.Printf("🔄 Updating cluster: %s (%s)\n\n", tmpl.Cluster.Name, clusterState.Region)
+
+	opts := &provisioner.UpdateOptions{
+		TemplatePath: seedFile,
+		StopFleet:    updateStopFleet,
+	}
+	if err := prov.UpdateCluster(context.Background(), tmpl, opts); err != nil {
+		return fmt.Errorf("failed to update cluster: %w", err)
+	}
+
+	fmt.Printf("\n✅ Cluster '%s' updated successfully.\n", tmpl.Cluster.Name)
+	fmt.Printf("\nCheck status: pctl status %s\n", tmpl.Cluster.Name)
+
+	return nil
+}
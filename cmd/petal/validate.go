@@ -15,8 +15,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/scttfrdmn/petal/pkg/benchmark"
 	"github.com/scttfrdmn/petal/pkg/template"
 	"github.com/spf13/cobra"
 )
@@ -36,13 +39,30 @@ This command checks the template for:
 - Semantic validation (valid regions, instance types, naming conventions)
 - Best practices (UID/GID ranges, resource limits)
 
+Queues that keep nodes running 24/7 (min_count or static_count above 0) are
+reported as warnings with an estimated idle cost; they do not fail validation.
+
+With --output json, every error and warning is printed as a JSON object with
+the template field it concerns, its message, and its severity.
+
 The command returns exit code 0 if the template is valid, non-zero otherwise.`,
 	Example: `  # Validate a template
   pctl validate -t my-cluster.yaml
 
   # Validate with verbose output
-  pctl validate -t my-cluster.yaml --verbose`,
-	RunE: runValidate,
+  pctl validate -t my-cluster.yaml --verbose
+
+  # Report errors and warnings per field as JSON
+  pctl validate -t my-cluster.yaml --output json`,
+	RunE:        runValidate,
+	Annotations: map[string]string{jsonOutputAnnotation: "true"},
+}
+
+// validateOutput is the validate --output json document.
+type validateOutput struct {
+	Template string `json:"template"`
+	Valid    bool   `json:"valid"`
+	*template.ValidationReport
 }
 
 func init() {
@@ -52,7 +72,7 @@ func init() {
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
-	if verbose {
+	if verbose && outputFormat != outputJSON {
 		fmt.Printf("Validating template: %s\n", validateTemplate)
 	}
 
@@ -62,6 +82,22 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load template: %w", err)
 	}
 
+	if outputFormat == outputJSON {
+		ctx, cancel := context.WithTimeout(context.Background(), priceLookupTimeout)
+		defer cancel()
+
+		validator := template.NewValidator()
+		validator.Price = idleCostPricer(ctx)
+		report := validator.ValidateDetailed(tmpl)
+		if err := printJSON(validateOutput{Template: validateTemplate, Valid: report.Valid(), ValidationReport: report}); err != nil {
+			return err
+		}
+		if !report.Valid() {
+			return fmt.Errorf("validation failed")
+		}
+		return nil
+	}
+
 	if verbose {
 		fmt.Printf("Template loaded successfully\n")
 		fmt.Printf("  Cluster: %s\n", tmpl.Cluster.Name)
@@ -87,5 +123,49 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("✅ Template is valid!\n")
+	printTemplateWarnings(tmpl)
 	return nil
 }
+
+// priceLookupTimeout bounds the on-demand price lookups behind idle cost
+// warnings, so validation stays quick without AWS credentials.
+const priceLookupTimeout = 10 * time.Second
+
+// printTemplateWarnings prints the template's non-fatal warnings, pricing
+// always-on queues from the AWS Price List API when it is reachable.
+func printTemplateWarnings(tmpl *template.Template) {
+	ctx, cancel := context.WithTimeout(context.Background(), priceLookupTimeout)
+	defer cancel()
+
+	warnings := template.NewValidator().Warnings(tmpl, idleCostPricer(ctx))
+	if len(warnings) == 0 {
+		return
+	}
+	fmt.Println()
+	for _, warning := range warnings {
+		fmt.Printf("⚠️  Warning: %s\n", warning)
+	}
+}
+
+// idleCostPricer returns a PriceFunc that looks up and caches on-demand
+// prices for idle cost warnings, or nil on dry runs.
+func idleCostPricer(ctx context.Context) template.PriceFunc {
+	// Dry runs make no AWS calls, so idle costs go unpriced
+	if dryRun {
+		return nil
+	}
+
+	prices := map[string]float64{}
+	price := func(region, instanceType string) (float64, error) {
+		if hourly, ok := prices[instanceType]; ok {
+			return hourly, nil
+		}
+		hourly, err := benchmark.HourlyPrice(ctx, region, instanceType)
+		if err != nil {
+			return 0, err
+		}
+		prices[instanceType] = hourly
+		return hourly, nil
+	}
+	return price
+}
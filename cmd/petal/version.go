@@ -15,44 +15,31 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/scttfrdmn/petal/internal/version"
 	"github.com/spf13/cobra"
 )
 
-var (
-	versionOutput string
-)
-
 var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Print version information",
-	Long:  "Print detailed version information including build time and git commit.",
-	RunE:  runVersion,
+	Use:         "version",
+	Annotations: map[string]string{jsonOutputAnnotation: "true"},
+	Short:       "Print version information",
+	Long:        "Print detailed version information including build time and git commit.",
+	RunE:        runVersion,
 }
 
 func init() {
-	versionCmd.Flags().StringVarP(&versionOutput, "output", "o", "text", "output format (text|json)")
 	rootCmd.AddCommand(versionCmd)
 }
 
 func runVersion(cmd *cobra.Command, args []string) error {
 	info := version.Get()
 
-	switch versionOutput {
-	case "json":
-		data, err := json.MarshalIndent(info, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal version info: %w", err)
-		}
-		fmt.Println(string(data))
-	case "text":
-		fmt.Println(info.String())
-	default:
-		return fmt.Errorf("invalid output format: %s (must be text or json)", versionOutput)
+	// The global --output flag selects the format
+	if outputFormat == outputJSON {
+		return printJSON(info)
 	}
-
+	fmt.Println(info.String())
 	return nil
 }
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -32,6 +33,8 @@ type Config struct {
 
 	Registry struct {
 		Sources []RegistrySource `mapstructure:"sources"`
+		// CacheTTL is how long a cached registry index is used
+		CacheTTL time.Duration `mapstructure:"cache_ttl"`
 	} `mapstructure:"registry"`
 
 	ParallelCluster struct {
@@ -44,12 +47,53 @@ type Config struct {
 		ValidateBeforeCreate bool `mapstructure:"validate_before_create"`
 		ConfirmDestructive   bool `mapstructure:"confirm_destructive"`
 	} `mapstructure:"preferences"`
+
+	AMI struct {
+		AutoCleanupBuilds  bool `mapstructure:"auto_cleanup_builds"`
+		BuildRetentionDays int  `mapstructure:"build_retention_days"`
+	} `mapstructure:"ami"`
+}
+
+// BuildRetention returns how long finished AMI build states are kept.
+func (c *Config) BuildRetention() time.Duration {
+	return time.Duration(c.AMI.BuildRetentionDays) * 24 * time.Hour
+}
+
+// DefaultParallelClusterVersion is the ParallelCluster version used when the
+// config does not set one.
+const DefaultParallelClusterVersion = "3.14.0"
+
+// RegistryCacheNeverExpires is the registry cache TTL with
+// auto_update_registry off. It is negative, which the registry reads as
+// keeping the cached index until it is refreshed on request.
+const RegistryCacheNeverExpires time.Duration = -1
+
+// RegistryCacheTTL returns how long a cached registry index is used before
+// it is fetched again. A cache_ttl of zero disables the cache; with
+// auto_update_registry off, the cached index never expires and is only
+// fetched on request.
+func (c *Config) RegistryCacheTTL() time.Duration {
+	if !c.Preferences.AutoUpdateRegistry {
+		return RegistryCacheNeverExpires
+	}
+	return c.Registry.CacheTTL
 }
 
 // RegistrySource represents a template registry source.
 type RegistrySource struct {
-	Name string `mapstructure:"name"`
-	URL  string `mapstructure:"url"`
+	Name string `mapstructure:"name" yaml:"name"`
+	URL  string `mapstructure:"url" yaml:"url"`
+	// Token reads the source if it is a private repository
+	Token string `mapstructure:"token" yaml:"token,omitempty"`
+}
+
+// GitHubToken returns the token used to read the source: its own token, or
+// GITHUB_TOKEN if it has none.
+func (s RegistrySource) GitHubToken() string {
+	if s.Token != "" {
+		return s.Token
+	}
+	return os.Getenv("GITHUB_TOKEN")
 }
 
 // Load loads the configuration from the default locations.
@@ -70,11 +114,14 @@ func Load() (*Config, error) {
 
 	// Set defaults
 	v.SetDefault("defaults.region", "us-east-1")
-	v.SetDefault("parallelcluster.version", "3.14.0")
+	v.SetDefault("parallelcluster.version", DefaultParallelClusterVersion)
 	v.SetDefault("parallelcluster.install_method", "pipx")
 	v.SetDefault("preferences.auto_update_registry", true)
+	v.SetDefault("registry.cache_ttl", "1h")
 	v.SetDefault("preferences.validate_before_create", true)
 	v.SetDefault("preferences.confirm_destructive", true)
+	v.SetDefault("ami.auto_cleanup_builds", false)
+	v.SetDefault("ami.build_retention_days", 30)
 
 	// Read config file
 	if err := v.ReadInConfig(); err != nil {
@@ -120,6 +167,15 @@ func GetStateDir() (string, error) {
 	return filepath.Join(configDir, "state"), nil
 }
 
+// GetRegistryCacheDir returns the directory registry indexes are cached in.
+func GetRegistryCacheDir() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "registry-cache"), nil
+}
+
 // EnsureConfigDir ensures the configuration directory exists.
 func EnsureConfigDir() error {
 	configDir, err := GetConfigDir()
@@ -18,6 +18,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestGetConfigDir(t *testing.T) {
@@ -181,6 +182,10 @@ func TestLoadDefaults(t *testing.T) {
 		t.Error("Default auto_update_registry should be true")
 	}
 
+	if cfg.RegistryCacheTTL() != time.Hour {
+		t.Errorf("Default registry cache TTL = %v, want 1h", cfg.RegistryCacheTTL())
+	}
+
 	if !cfg.Preferences.ValidateBeforeCreate {
 		t.Error("Default validate_before_create should be true")
 	}
@@ -188,6 +193,14 @@ func TestLoadDefaults(t *testing.T) {
 	if !cfg.Preferences.ConfirmDestructive {
 		t.Error("Default confirm_destructive should be true")
 	}
+
+	if cfg.AMI.AutoCleanupBuilds {
+		t.Error("Default auto_cleanup_builds should be false")
+	}
+
+	if cfg.BuildRetention() != 30*24*time.Hour {
+		t.Errorf("Default build retention = %v, want 30 days", cfg.BuildRetention())
+	}
 }
 
 func TestLoadWithConfigFile(t *testing.T) {
@@ -223,10 +236,16 @@ preferences:
   validate_before_create: false
   confirm_destructive: false
 
+ami:
+  auto_cleanup_builds: true
+  build_retention_days: 7
+
 registry:
+  cache_ttl: 30m
   sources:
     - name: official
       url: https://github.com/example/templates
+      token: ghp_official
 `
 	configFile := filepath.Join(configDir, "config.yaml")
 	err = os.WriteFile(configFile, []byte(configContent), 0644)
@@ -261,6 +280,14 @@ registry:
 		t.Error("Loaded auto_update_registry should be false")
 	}
 
+	if cfg.Registry.CacheTTL != 30*time.Minute {
+		t.Errorf("Loaded registry cache_ttl = %v, want 30m", cfg.Registry.CacheTTL)
+	}
+
+	if cfg.RegistryCacheTTL() != RegistryCacheNeverExpires {
+		t.Errorf("Registry cache TTL = %v, want RegistryCacheNeverExpires with auto_update_registry off", cfg.RegistryCacheTTL())
+	}
+
 	if cfg.Preferences.ValidateBeforeCreate {
 		t.Error("Loaded validate_before_create should be false")
 	}
@@ -269,6 +296,14 @@ registry:
 		t.Error("Loaded confirm_destructive should be false")
 	}
 
+	if !cfg.AMI.AutoCleanupBuilds {
+		t.Error("Loaded auto_cleanup_builds should be true")
+	}
+
+	if cfg.BuildRetention() != 7*24*time.Hour {
+		t.Errorf("Loaded build retention = %v, want 7 days", cfg.BuildRetention())
+	}
+
 	// Check registry sources
 	if len(cfg.Registry.Sources) != 1 {
 		t.Fatalf("Expected 1 registry source, got %d", len(cfg.Registry.Sources))
@@ -281,6 +316,21 @@ registry:
 	if cfg.Registry.Sources[0].URL != "https://github.com/example/templates" {
 		t.Errorf("Registry source URL = %s, want https://github.com/example/templates", cfg.Registry.Sources[0].URL)
 	}
+
+	if cfg.Registry.Sources[0].Token != "ghp_official" {
+		t.Errorf("Registry source token = %s, want ghp_official", cfg.Registry.Sources[0].Token)
+	}
+}
+
+func TestRegistrySourceGitHubToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_env")
+
+	if got := (RegistrySource{Name: "lab", Token: "ghp_lab"}).GitHubToken(); got != "ghp_lab" {
+		t.Errorf("GitHubToken() = %s, want the source's token ghp_lab", got)
+	}
+	if got := (RegistrySource{Name: "dept"}).GitHubToken(); got != "ghp_env" {
+		t.Errorf("GitHubToken() = %s, want GITHUB_TOKEN ghp_env", got)
+	}
 }
 
 func TestRegistrySourceStruct(t *testing.T) {
@@ -0,0 +1,177 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrSourceNotFound is returned when removing a registry source that is not
+// configured.
+var ErrSourceNotFound = errors.New("registry source not found")
+
+// GetConfigFile returns the path of the configuration file.
+func GetConfigFile() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.yaml"), nil
+}
+
+// AddRegistrySource adds a registry source to the configuration file. Names
+// must be unique.
+func AddRegistrySource(source RegistrySource) error {
+	return updateRegistrySources(func(sources []RegistrySource) ([]RegistrySource, error) {
+		for _, existing := range sources {
+			if existing.Name == source.Name {
+				return nil, fmt.Errorf("registry source %q already exists (%s)", source.Name, existing.URL)
+			}
+		}
+		return append(sources, source), nil
+	})
+}
+
+// RemoveRegistrySource removes the named registry source from the
+// configuration file.
+func RemoveRegistrySource(name string) error {
+	return updateRegistrySources(func(sources []RegistrySource) ([]RegistrySource, error) {
+		for i, existing := range sources {
+			if existing.Name == name {
+				return append(sources[:i], sources[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrSourceNotFound, name)
+	})
+}
+
+// updateRegistrySources rewrites registry.sources in the configuration file,
+// leaving the rest of the file, including comments, as it was. The file is
+// replaced atomically so a failed write never leaves it truncated.
+func updateRegistrySources(update func([]RegistrySource) ([]RegistrySource, error)) error {
+	configFile, err := GetConfigFile()
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	data, err := os.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to parse config: %s is not a mapping", configFile)
+	}
+
+	registry, err := mappingValue(root, "registry")
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var sources []RegistrySource
+	sourcesNode := lookupValue(registry, "sources")
+	if sourcesNode != nil {
+		if err := sourcesNode.Decode(&sources); err != nil {
+			return fmt.Errorf("failed to parse registry sources: %w", err)
+		}
+	} else {
+		sourcesNode = &yaml.Node{}
+		registry.Content = append(registry.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "sources"}, sourcesNode)
+	}
+
+	sources, err = update(sources)
+	if err != nil {
+		return err
+	}
+
+	if err := sourcesNode.Encode(sources); err != nil {
+		return fmt.Errorf("failed to encode registry sources: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if err := EnsureConfigDir(); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(configFile), ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), configFile); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
+
+// lookupValue returns the value node for key in a mapping, or nil if the key
+// is missing.
+func lookupValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the mapping under key, adding an empty one if the key
+// is missing or has no value.
+func mappingValue(mapping *yaml.Node, key string) (*yaml.Node, error) {
+	value := lookupValue(mapping, key)
+	if value == nil {
+		value = &yaml.Node{Kind: yaml.MappingNode}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+		return value, nil
+	}
+	if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+		*value = yaml.Node{Kind: yaml.MappingNode}
+	}
+	if value.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a mapping", key)
+	}
+	return value, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddRemoveRegistrySource(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configFile := filepath.Join(home, ".petal", "config.yaml")
+
+	// Other settings and comments must survive edits to the sources
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	original := `# Site defaults
+defaults:
+  region: us-west-2 # closest region
+registry:
+  cache_ttl: 30m
+`
+	if err := os.WriteFile(configFile, []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := AddRegistrySource(RegistrySource{Name: "lab", URL: "github.com/lab/seeds"}); err != nil {
+		t.Fatalf("AddRegistrySource() error = %v", err)
+	}
+	if err := AddRegistrySource(RegistrySource{Name: "dept", URL: "dept/seeds"}); err != nil {
+		t.Fatalf("AddRegistrySource() error = %v", err)
+	}
+	err := AddRegistrySource(RegistrySource{Name: "lab", URL: "github.com/other/seeds"})
+	if err == nil || !strings.Contains(err.Error(), `"lab" already exists`) {
+		t.Errorf("AddRegistrySource() duplicate error = %v, want already exists", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []RegistrySource{{Name: "lab", URL: "github.com/lab/seeds"}, {Name: "dept", URL: "dept/seeds"}}
+	if len(cfg.Registry.Sources) != 2 || cfg.Registry.Sources[0] != want[0] || cfg.Registry.Sources[1] != want[1] {
+		t.Errorf("Sources = %v, want %v", cfg.Registry.Sources, want)
+	}
+	if cfg.Defaults.Region != "us-west-2" || cfg.Registry.CacheTTL.String() != "30m0s" {
+		t.Errorf("Other settings changed: region %s, cache_ttl %v", cfg.Defaults.Region, cfg.Registry.CacheTTL)
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if !strings.Contains(string(data), "# Site defaults") || !strings.Contains(string(data), "# closest region") {
+		t.Errorf("Comments were not preserved:\n%s", data)
+	}
+
+	if err := RemoveRegistrySource("lab"); err != nil {
+		t.Fatalf("RemoveRegistrySource() error = %v", err)
+	}
+	if err := RemoveRegistrySource("lab"); !errors.Is(err, ErrSourceNotFound) {
+		t.Errorf("RemoveRegistrySource() missing error = %v, want ErrSourceNotFound", err)
+	}
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Registry.Sources) != 1 || cfg.Registry.Sources[0] != want[1] {
+		t.Errorf("Sources after remove = %v, want [%v]", cfg.Registry.Sources, want[1])
+	}
+}
+
+func TestAddRegistrySourceWithoutConfigFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := AddRegistrySource(RegistrySource{Name: "lab", URL: "lab/seeds"}); err != nil {
+		t.Fatalf("AddRegistrySource() error = %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Registry.Sources) != 1 || cfg.Registry.Sources[0].Name != "lab" {
+		t.Errorf("Sources = %v, want the lab source", cfg.Registry.Sources)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/google/uuid"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// Build instance tags. Besides these, build instances carry the template's
+// fingerprint tags (pctl:fingerprint, ...) so a build can be recovered from
+// the instance alone if its local state is lost.
+const (
+	// TagBuildID is the build ID of the build the instance belongs to
+	TagBuildID = "pctl:build-id"
+	// tagProgress is updated by the build script as installation proceeds
+	tagProgress = "pctl-progress"
+)
+
+// buildInstanceTags returns the tags for a build instance.
+func buildInstanceTags(tmpl *template.Template, buildState *BuildState) []types.Tag {
+	tags := []types.Tag{
+		{Key: aws.String("Name"), Value: aws.String("pctl-ami-builder")},
+		{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
+		{Key: aws.String("Purpose"), Value: aws.String("AMI-Build")},
+		{Key: aws.String(TagBuildID), Value: aws.String(buildState.BuildID)},
+		{Key: aws.String(TagTemplateName), Value: aws.String(tmpl.Cluster.Name)},
+	}
+	if buildState.SpackLockHash != "" {
+		tags = append(tags, types.Tag{Key: aws.String(TagSpackLockHash), Value: aws.String(buildState.SpackLockHash)})
+	}
+	return append(tags, sortedTags(tmpl.ComputeFingerprint().Tags())...)
+}
+
+// buildInstanceTagSpecifications tags a build instance and its volumes alike
+// with the build's tags and opts.Tags, so cost tools attribute the build's
+// storage as well as its compute.
+func buildInstanceTagSpecifications(tmpl *template.Template, buildState *BuildState, opts *BuildOptions) []types.TagSpecification {
+	tags := withUserTags(buildInstanceTags(tmpl, buildState), opts.Tags)
+	return tagSpecifications(tags, types.ResourceTypeInstance, types.ResourceTypeVolume)
+}
+
+// sortedTags converts a tag map to EC2 tags ordered by key.
+func sortedTags(m map[string]string) []types.Tag {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tags := make([]types.Tag, 0, len(keys))
+	for _, key := range keys {
+		tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(m[key])})
+	}
+	return tags
+}
+
+// buildStateFromInstanceTags reconstructs the state of a build from the tags
+// on its instance. Builds launched before instances carried a build ID get a
+// new one.
+func buildStateFromInstanceTags(instanceID, amiName, region string, tags map[string]string, launchTime time.Time) *BuildState {
+	state := &BuildState{
+		BuildID:         tags[TagBuildID],
+		InstanceID:      instanceID,
+		Status:          BuildStatusInstalling,
+		ProgressMessage: tags[tagProgress],
+		Progress:        extractProgressPercentage(tags[tagProgress]),
+		StartTime:       launchTime,
+		TemplateName:    tags[TagTemplateName],
+		AMIName:         amiName,
+		Region:          region,
+		Fingerprint:     tags["pctl:fingerprint"],
+		SpackLockHash:   tags[TagSpackLockHash],
+	}
+	if state.BuildID == "" {
+		state.BuildID = uuid.New().String()
+	}
+	if state.TemplateName == "" {
+		state.TemplateName = "unknown"
+	}
+	if count, err := strconv.Atoi(tags["pctl:package-count"]); err == nil {
+		state.PackageCount = count
+	}
+	return state
+}
+
+// fingerprintTagsFromInstance returns the fingerprint tags a build instance
+// carries, to be copied onto the AMI.
+func fingerprintTagsFromInstance(tags map[string]string) map[string]string {
+	fingerprintTags := make(map[string]string)
+	for key, value := range tags {
+		if strings.HasPrefix(key, "pctl:") && key != TagBuildID && key != TagReusable {
+			fingerprintTags[key] = value
+		}
+	}
+	return fingerprintTags
+}
+
+// AttachBuild resumes a build whose local state was lost, reconstructing it
+// from the build instance's tags. It waits for software installation if the
+// instance is still running, creates the AMI named opts.Name, and terminates
+// the instance.
+func (b *Builder) AttachBuild(ctx context.Context, instanceID string, opts *BuildOptions) (*AMIMetadata, error) {
+	result, err := b.ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instance %s: %w", instanceID, err)
+	}
+	if len(result.Reservations) == 0 || len(result.Reservations[0].Instances) == 0 {
+		return nil, fmt.Errorf("instance %s not found", instanceID)
+	}
+	instance := result.Reservations[0].Instances[0]
+
+	tags := make(map[string]string)
+	for _, tag := range instance.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	if tags["ManagedBy"] != "pctl" || tags["Purpose"] != "AMI-Build" {
+		return nil, fmt.Errorf("instance %s is not a pctl AMI build instance", instanceID)
+	}
+
+	buildState := buildStateFromInstanceTags(instanceID, opts.Name, b.region, tags, aws.ToTime(instance.LaunchTime))
+	if instance.State == nil {
+		return nil, fmt.Errorf("instance %s has no state", instanceID)
+	}
+	if spotInterrupted(instance) {
+		return nil, fmt.Errorf("instance %s: %w", instanceID, ErrSpotInterrupted)
+	}
+	switch instance.State.Name {
+	case types.InstanceStateNameRunning:
+	case types.InstanceStateNameStopping, types.InstanceStateNameStopped:
+		// Installation already finished and the instance is being imaged
+		buildState.Status = BuildStatusCreating
+	default:
+		return nil, fmt.Errorf("instance %s is %s; only running or stopped build instances can be attached", instanceID, instance.State.Name)
+	}
+	buildState.BaseAMI = aws.ToString(instance.ImageId)
+	buildState.ParallelClusterVersion = b.getParallelClusterVersion(ctx, buildState.BaseAMI)
+
+	// SSH verification needs the instance's key pair
+	attachOpts := *opts
+	if attachOpts.KeyName == "" {
+		attachOpts.KeyName = aws.ToString(instance.KeyName)
+	}
+	attachOpts.UseSpot = isSpotInstance(instance)
+
+	if err := b.stateManager.SaveState(buildState); err != nil {
+		return nil, fmt.Errorf("failed to save build state: %w", err)
+	}
+
+	fmt.Printf("🔗 Attached to build instance %s\n", instanceID)
+	fmt.Printf("   Build ID: %s\n", buildState.BuildID)
+	fmt.Printf("   Template: %s\n", buildState.TemplateName)
+	if buildState.ProgressMessage != "" {
+		fmt.Printf("   Progress: %s\n", buildState.ProgressMessage)
+	}
+	if buildState.Fingerprint == "" {
+		fmt.Printf("   ⚠️  Instance has no fingerprint tags; the AMI will not be matched to seeds automatically\n")
+	}
+	fmt.Println()
+
+	defer func() {
+		if buildState.Status != BuildStatusComplete {
+			b.stateManager.MarkFailed(buildState.BuildID, "Build did not complete successfully")
+		}
+	}()
+	defer func() {
+		fmt.Printf("🧹 Cleaning up temporary instance...\n")
+		b.terminateInstance(ctx, instanceID)
+	}()
+
+	if buildState.Status != BuildStatusCreating {
+		fmt.Printf("3️⃣  Waiting for software installation to finish...\n")
+		b.stateManager.SaveState(buildState)
+	}
+	amiID, err := b.finishBuild(ctx, buildState, &attachOpts, buildState.TemplateName, fingerprintTagsFromInstance(tags))
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("🎉 AMI build complete!\n")
+	fmt.Printf("   Build ID: %s\n", buildState.BuildID)
+	fmt.Printf("   AMI ID: %s\n", amiID)
+	fmt.Printf("   Region: %s\n\n", b.region)
+
+	return &AMIMetadata{
+		AMIID:                  amiID,
+		Name:                   opts.Name,
+		Description:            opts.Description,
+		Region:                 b.region,
+		CreatedAt:              time.Now(),
+		TemplateName:           buildState.TemplateName,
+		Fingerprint:            buildState.Fingerprint,
+		SpackLockHash:          buildState.SpackLockHash,
+		Tags:                   opts.Tags,
+		BaseAMI:                buildState.BaseAMI,
+		ParallelClusterVersion: buildState.ParallelClusterVersion,
+		BuildID:                buildState.BuildID,
+		Status:                 BuildStatusComplete,
+		DurationSeconds:        int64(time.Since(buildState.StartTime).Seconds()),
+	}, nil
+}
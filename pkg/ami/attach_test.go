@@ -0,0 +1,134 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func TestBuildStateFromInstanceTags(t *testing.T) {
+	launchTime := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tags := map[string]string{
+		"Name":               "pctl-ami-builder",
+		"ManagedBy":          "pctl",
+		"Purpose":            "AMI-Build",
+		"pctl:build-id":      "550e8400-e29b-41d4-a716-446655440000",
+		"TemplateName":       "bioinformatics",
+		"SpackLockHash":      "abc123",
+		"pctl:fingerprint":   "f00dfeed",
+		"pctl:package-count": "12",
+		"pctl-progress":      "42% - Installing samtools",
+		"pctl:base-os":       "amazonlinux2023",
+	}
+
+	state := buildStateFromInstanceTags("i-0123", "bio-v1", "us-west-2", tags, launchTime)
+
+	want := &BuildState{
+		BuildID:         "550e8400-e29b-41d4-a716-446655440000",
+		InstanceID:      "i-0123",
+		Status:          BuildStatusInstalling,
+		Progress:        42,
+		ProgressMessage: "42% - Installing samtools",
+		StartTime:       launchTime,
+		TemplateName:    "bioinformatics",
+		AMIName:         "bio-v1",
+		Region:          "us-west-2",
+		PackageCount:    12,
+		Fingerprint:     "f00dfeed",
+		SpackLockHash:   "abc123",
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("buildStateFromInstanceTags() = %+v, want %+v", state, want)
+	}
+}
+
+func TestBuildStateFromInstanceTagsMinimal(t *testing.T) {
+	// Instances launched before build tags were added carry only these
+	tags := map[string]string{"ManagedBy": "pctl", "Purpose": "AMI-Build"}
+
+	state := buildStateFromInstanceTags("i-0123", "bio-v1", "us-east-1", tags, time.Now())
+	if state.BuildID == "" {
+		t.Error("Expected a new build ID")
+	}
+	if state.TemplateName != "unknown" || state.Progress != 0 || state.Fingerprint != "" {
+		t.Errorf("unexpected state: %+v", state)
+	}
+}
+
+func TestBuildInstanceTagsRoundTrip(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster:  template.ClusterConfig{Name: "bioinformatics", Region: "us-east-1"},
+		Software: template.SoftwareConfig{SpackPackages: []string{"samtools@1.17", "bwa@0.7.17"}},
+	}
+	original := &BuildState{BuildID: "build-1", SpackLockHash: "abc123"}
+
+	tags := make(map[string]string)
+	for _, tag := range buildInstanceTags(tmpl, original) {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	state := buildStateFromInstanceTags("i-0123", "bio-v1", "us-east-1", tags, time.Now())
+	if state.BuildID != "build-1" || state.TemplateName != "bioinformatics" || state.SpackLockHash != "abc123" {
+		t.Errorf("unexpected state: %+v", state)
+	}
+
+	fingerprint := tmpl.ComputeFingerprint()
+	if state.Fingerprint != fingerprint.Hash || state.PackageCount != 2 {
+		t.Errorf("Fingerprint, PackageCount = %s, %d, want %s, 2", state.Fingerprint, state.PackageCount, fingerprint.Hash)
+	}
+
+	// The AMI gets the same fingerprint tags a direct build would give it
+	if got := fingerprintTagsFromInstance(tags); !reflect.DeepEqual(got, fingerprint.Tags()) {
+		t.Errorf("fingerprintTagsFromInstance() = %v, want %v", got, fingerprint.Tags())
+	}
+}
+
+func TestBuildInstanceTagSpecifications(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster:  template.ClusterConfig{Name: "bioinformatics", Region: "us-east-1"},
+		Software: template.SoftwareConfig{SpackPackages: []string{"samtools@1.17"}},
+	}
+	state := &BuildState{BuildID: "build-1"}
+	opts := &BuildOptions{Tags: map[string]string{"CostCenter": "genomics", "Name": "ignored"}}
+
+	specs := buildInstanceTagSpecifications(tmpl, state, opts)
+
+	if len(specs) != 2 || specs[0].ResourceType != types.ResourceTypeInstance || specs[1].ResourceType != types.ResourceTypeVolume {
+		t.Fatalf("Expected instance and volume tag specifications, got %+v", specs)
+	}
+	for _, spec := range specs {
+		tags := make(map[string]string)
+		for _, tag := range spec.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		for key, want := range map[string]string{
+			"Name":          "pctl-ami-builder",
+			"ManagedBy":     "pctl",
+			TagBuildID:      "build-1",
+			TagTemplateName: "bioinformatics",
+			"CostCenter":    "genomics",
+		} {
+			if tags[key] != want {
+				t.Errorf("%s tag %s = %q, want %q", spec.ResourceType, key, tags[key], want)
+			}
+		}
+	}
+}
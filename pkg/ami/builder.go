@@ -19,6 +19,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -32,6 +33,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/smithy-go"
 	"github.com/schollz/progressbar/v3"
 	"github.com/scttfrdmn/petal/pkg/software"
 	"github.com/scttfrdmn/petal/pkg/template"
@@ -40,27 +43,60 @@ import (
 // AMIMetadata contains information about a built AMI.
 type AMIMetadata struct {
 	// AMIID is the AMI ID
-	AMIID string
+	AMIID string `json:"ami_id,omitempty"`
 	// Name is the AMI name
-	Name string
+	Name string `json:"name"`
 	// Description is the AMI description
-	Description string
+	Description string `json:"description,omitempty"`
 	// Region is the AWS region
-	Region string
+	Region string `json:"region"`
 	// CreatedAt is when the AMI was created
-	CreatedAt time.Time
+	CreatedAt time.Time `json:"created_at"`
 	// TemplateName is the source template name
-	TemplateName string
+	TemplateName string `json:"template_name"`
+	// Fingerprint is the template fingerprint hash
+	Fingerprint string `json:"fingerprint,omitempty"`
 	// SpackPackages lists installed Spack packages
-	SpackPackages []string
+	SpackPackages []string `json:"spack_packages"`
+	// SpackLockHash is the SHA-256 of the spack.lock the AMI was built from
+	SpackLockHash string `json:"spack_lock_hash,omitempty"`
 	// Tags are AMI tags
-	Tags map[string]string
+	Tags map[string]string `json:"tags,omitempty"`
+	// BaseAMI is the AMI the build started from
+	BaseAMI string `json:"base_ami,omitempty"`
+	// ParallelClusterVersion is the ParallelCluster version of the base AMI
+	ParallelClusterVersion string `json:"parallelcluster_version,omitempty"`
+	// BuildID is the build that produced the AMI
+	BuildID string `json:"build_id,omitempty"`
+	// Status is the build status when the metadata was produced
+	Status BuildStatus `json:"status,omitempty"`
+	// DurationSeconds is how long the build took
+	DurationSeconds int64 `json:"duration_seconds,omitempty"`
 }
 
+// WriteMetadataFile writes AMI metadata to a file as JSON.
+func WriteMetadataFile(path string, metadata *AMIMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write metadata file: %w", err)
+	}
+
+	return nil
+}
+
+// maxUserDataSize is the EC2 limit on instance user data, before base64 encoding.
+const maxUserDataSize = 16 * 1024
+
 // Builder builds custom AMIs with pre-installed software.
 type Builder struct {
+	awsConfig    aws.Config
 	ec2Client    *ec2.Client
 	iamClient    *iam.Client
+	ssmClient    *ssm.Client
 	region       string
 	stateManager *StateManager
 }
@@ -78,8 +114,10 @@ func NewBuilder(ctx context.Context, region string) (*Builder, error) {
 	}
 
 	return &Builder{
+		awsConfig:    cfg,
 		ec2Client:    ec2.NewFromConfig(cfg),
 		iamClient:    iam.NewFromConfig(cfg),
+		ssmClient:    ssm.NewFromConfig(cfg),
 		region:       region,
 		stateManager: stateManager,
 	}, nil
@@ -87,31 +125,177 @@ func NewBuilder(ctx context.Context, region string) (*Builder, error) {
 
 // BuildAMI creates a custom AMI from a template.
 func (b *Builder) BuildAMI(ctx context.Context, tmpl *template.Template, opts *BuildOptions) (*AMIMetadata, error) {
+	tmpl = applySkipLmod(tmpl, opts)
+
+	// An explicit architecture replaces the guess from instance families, so
+	// it is checked against what EC2 reports instead
+	if opts.Architecture != "" {
+		if err := checkInstanceArchitectures(ctx, b.ec2Client, opts.Architecture, opts.InstanceType, tmpl.Compute.HeadNode); err != nil {
+			return nil, err
+		}
+	} else if err := ValidateBuildArchitecture(tmpl, opts.InstanceType); err != nil {
+		return nil, err
+	}
+
+	// Only a stopped instance can be kept and imaged again
+	if opts.ReuseInstance && (opts.Detach || opts.UseSpot) {
+		return nil, fmt.Errorf("reusing the build instance is not supported for detached or spot builds")
+	}
+
+	// The wall-clock limit covers every step, including AMI availability
+	if opts.MaxWallClock > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxWallClock)
+		defer cancel()
+	}
+
+	// A detached build is aborted by a signal until it is handed off to AWS;
+	// detached is set at the handoff, after which nothing is cleaned up
+	var detached bool
+	var handoff *detachHandoff
+	if opts.Detach {
+		ctx, handoff = startDetachHandoff(ctx)
+		defer handoff.Stop()
+	}
+
+	// The lockfile, Spack config files, and pre-install script are part of
+	// the fingerprint, so an AMI built with --from-spack-lock, --spack-config,
+	// or --pre-install-script is only reused by seeds naming identical content
+	if opts.SpackLock != "" || len(opts.SpackConfigFiles) > 0 || opts.PreInstallScript != "" {
+		overridden := *tmpl
+		if opts.SpackLock != "" {
+			overridden.Build.SpackLock = opts.SpackLock
+		}
+		if len(opts.SpackConfigFiles) > 0 {
+			overridden.Build.SpackConfig = opts.SpackConfigFiles
+		}
+		if opts.PreInstallScript != "" {
+			overridden.Build.PreInstallScript = opts.PreInstallScript
+		}
+		tmpl = &overridden
+	}
+
+	// Load the lockfile up front so an invalid one fails before any state is created
+	var spackLock *software.SpackLock
+	packages := tmpl.Software.SpackPackages
+	if tmpl.Build.SpackLock != "" {
+		var err error
+		spackLock, err = software.LoadSpackLock(tmpl.Build.SpackLock)
+		if err != nil {
+			return nil, err
+		}
+		packages = spackLock.Roots
+	}
+	spackConfig, err := loadSpackConfigFiles(tmpl.Build.SpackConfig)
+	if err != nil {
+		return nil, err
+	}
+	licenseFiles, err := loadLicenseFiles(opts.LicenseFiles)
+	if err != nil {
+		return nil, err
+	}
+	preInstall, err := loadPreInstallScript(tmpl, opts)
+	if err != nil {
+		return nil, err
+	}
+	opts = applyTemplateTags(tmpl, len(packages), opts)
+
 	// Create build state
 	buildState := b.stateManager.NewBuildState(
 		tmpl.Cluster.Name,
 		opts.Name,
 		b.region,
-		len(tmpl.Software.SpackPackages),
+		len(packages),
 	)
 
+	buildState.Fingerprint = tmpl.ComputeFingerprint().Hash
+	if spackLock != nil {
+		buildState.SpackLockHash = spackLock.Hash
+	}
+
 	if err := b.stateManager.SaveState(buildState); err != nil {
 		return nil, fmt.Errorf("failed to save initial build state: %w", err)
 	}
 
+	// Refuse to build the same configuration twice at once
+	if !opts.AllowConcurrent {
+		lock, err := b.stateManager.AcquireBuildLock(buildState.Fingerprint, buildState.BuildID, func(holder *BuildState) bool {
+			return b.buildInstanceGone(ctx, holder)
+		})
+		if err != nil {
+			b.stateManager.DeleteState(buildState.BuildID)
+			return nil, err
+		}
+		// A detached build keeps the lock after pctl exits; it goes stale
+		// once the build finishes and 'pctl ami status' releases it
+		defer func() {
+			if !detached {
+				lock.Release()
+			}
+		}()
+	}
+
 	fmt.Printf("🚀 Starting AMI build process...\n")
 	fmt.Printf("   Build ID: %s\n\n", buildState.BuildID)
 
+	// Runs last, once the build's final status is recorded
+	defer b.notifyFinished(ctx, buildState.BuildID, opts)
+
 	// Ensure cleanup on failure
 	defer func() {
-		if buildState.Status != BuildStatusComplete {
-			b.stateManager.MarkFailed(buildState.BuildID, "Build did not complete successfully")
+		if buildState.Status == BuildStatusComplete || detached {
+			return
 		}
+		if cause := context.Cause(ctx); errors.Is(cause, errBuildAborted) {
+			b.stateManager.MarkFailedWithCategory(buildState.BuildID, FailureCategoryCancelled, cause.Error())
+			return
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && opts.MaxWallClock > 0 {
+			fmt.Printf("\n⏰ Build exceeded its maximum wall-clock time of %v\n", opts.MaxWallClock)
+			b.stateManager.MarkFailedWithCategory(buildState.BuildID, FailureCategoryDeadline,
+				fmt.Sprintf("Build exceeded maximum wall-clock time of %v", opts.MaxWallClock))
+			return
+		}
+		b.stateManager.MarkFailed(buildState.BuildID, "Build did not complete successfully")
 	}()
 
 	// Step 1: Launch temporary instance
 	fmt.Printf("1️⃣  Launching temporary build instance...\n")
-	instanceID, err := b.launchBuildInstance(ctx, tmpl, opts)
+	baseAMI, err := b.resolveBaseAMI(ctx, tmpl, opts)
+	if err != nil {
+		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Failed to resolve base AMI: %v", err))
+		return nil, err
+	}
+	buildState.BaseAMI = baseAMI
+	buildState.ParallelClusterVersion = b.getParallelClusterVersion(ctx, baseAMI)
+
+	staged, err := b.stageLicenseFiles(ctx, buildState.BuildID, licenseFiles)
+	defer func() {
+		// Detached builds may still be downloading them
+		if !detached {
+			b.deleteStagedFiles(ctx, staged)
+		}
+	}()
+	if err != nil {
+		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Failed to stage license files: %v", err))
+		return nil, err
+	}
+
+	lockURI, err := b.stageSpackLock(ctx, buildState.BuildID, spackLock)
+	if lockURI != "" {
+		staged = append(staged, lockURI)
+	}
+	if err != nil {
+		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Failed to stage spack lockfile: %v", err))
+		return nil, err
+	}
+
+	if err := b.presignPreInstallScript(ctx, preInstall); err != nil {
+		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Failed to stage pre-install script: %v", err))
+		return nil, err
+	}
+
+	instanceID, err := b.launchBuildInstance(ctx, tmpl, opts, buildState, spackLock, spackConfig, licenseFiles, preInstall)
 	if err != nil {
 		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Failed to launch instance: %v", err))
 		return nil, fmt.Errorf("failed to launch build instance: %w", err)
@@ -120,10 +304,20 @@ func (b *Builder) BuildAMI(ctx context.Context, tmpl *template.Template, opts *B
 	b.stateManager.SaveState(buildState)
 	fmt.Printf("   ✅ Instance launched: %s\n\n", instanceID)
 
-	// Ensure cleanup
+	// Ensure cleanup, including an AMI left unfinished by the deadline
+	var partialAMI string
+	var keepInstance bool
 	defer func() {
+		if detached || keepInstance {
+			return
+		}
 		fmt.Printf("🧹 Cleaning up temporary instance...\n")
-		b.terminateInstance(ctx, instanceID)
+		if partialAMI != "" {
+			fmt.Printf("🧹 Deregistering unfinished AMI %s...\n", partialAMI)
+		}
+		if err := cleanupBuildResources(ctx, b.ec2Client, instanceID, partialAMI); err != nil {
+			fmt.Printf("⚠️  Warning: %v\n", err)
+		}
 	}()
 
 	// Step 2: Wait for instance to be ready
@@ -134,8 +328,15 @@ func (b *Builder) BuildAMI(ctx context.Context, tmpl *template.Template, opts *B
 	}
 	fmt.Printf("   ✅ Instance is ready\n\n")
 
-	// If detached mode, return here with the build ID
+	// Handoff boundary: a detached build returns here and the instance keeps
+	// running in AWS. A signal that already aborted the build wins, and the
+	// instance is cleaned up as for any other failure.
 	if opts.Detach {
+		if !handoff.HandOff() {
+			return nil, context.Cause(ctx)
+		}
+		detached = true
+
 		buildState.Status = BuildStatusInstalling
 		b.stateManager.SaveState(buildState)
 
@@ -147,6 +348,17 @@ func (b *Builder) BuildAMI(ctx context.Context, tmpl *template.Template, opts *B
 		fmt.Printf("  pctl ami status %s\n\n", buildState.BuildID)
 		fmt.Printf("Or watch progress continuously:\n")
 		fmt.Printf("  pctl ami status %s --watch\n\n", buildState.BuildID)
+		fmt.Printf("Stop it with:\n")
+		fmt.Printf("  pctl ami cancel %s\n\n", buildState.BuildID)
+		fmt.Printf("If the local build record is lost, resume with:\n")
+		fmt.Printf("  pctl ami attach --instance-id %s --name %s\n\n", instanceID, opts.Name)
+		if len(staged) > 0 {
+			fmt.Printf("Staged build files stay in S3 until you delete them:\n")
+			for _, s3URI := range staged {
+				fmt.Printf("  aws s3 rm %s\n", s3URI)
+			}
+			fmt.Println()
+		}
 
 		// Return partial metadata (AMI not created yet)
 		return &AMIMetadata{
@@ -155,8 +367,12 @@ func (b *Builder) BuildAMI(ctx context.Context, tmpl *template.Template, opts *B
 			Region:        b.region,
 			CreatedAt:     time.Now(),
 			TemplateName:  tmpl.Cluster.Name,
-			SpackPackages: tmpl.Software.SpackPackages,
+			Fingerprint:   buildState.Fingerprint,
+			SpackPackages: packages,
+			SpackLockHash: buildState.SpackLockHash,
 			Tags:          opts.Tags,
+			BuildID:       buildState.BuildID,
+			Status:        buildState.Status,
 		}, nil
 	}
 
@@ -164,55 +380,43 @@ func (b *Builder) BuildAMI(ctx context.Context, tmpl *template.Template, opts *B
 	buildState.Status = BuildStatusInstalling
 	b.stateManager.SaveState(buildState)
 	fmt.Printf("3️⃣  Installing software (this may take 30-90 minutes)...\n")
-	fmt.Printf("   📦 Installing %d Spack packages\n", len(tmpl.Software.SpackPackages))
-	if err := b.waitForSoftwareInstallation(ctx, instanceID, buildState.BuildID, opts); err != nil {
-		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Software installation failed: %v", err))
-		return nil, fmt.Errorf("software installation failed: %w", err)
-	}
-	fmt.Printf("   ✅ Software installation complete\n\n")
-
-	// Step 4: Stop the instance
-	fmt.Printf("4️⃣  Stopping instance for AMI creation...\n")
-	if err := b.stopInstance(ctx, instanceID); err != nil {
-		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Failed to stop instance: %v", err))
-		return nil, fmt.Errorf("failed to stop instance: %w", err)
+	if spackLock != nil {
+		fmt.Printf("   📦 Installing %d Spack root specs from spack.lock\n", len(packages))
+	} else {
+		fmt.Printf("   📦 Installing %d Spack packages\n", len(packages))
 	}
-	fmt.Printf("   ✅ Instance stopped\n\n")
-
-	// Step 5: Create AMI
-	buildState.Status = BuildStatusCreating
-	b.stateManager.SaveState(buildState)
-	fmt.Printf("5️⃣  Creating AMI...\n")
-	amiID, err := b.createAMI(ctx, instanceID, tmpl, opts)
+	amiID, err := b.finishBuild(ctx, buildState, opts, tmpl.Cluster.Name, tmpl.ComputeFingerprint().Tags())
 	if err != nil {
-		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Failed to create AMI: %v", err))
-		return nil, fmt.Errorf("failed to create AMI: %w", err)
-	}
-	fmt.Printf("   ✅ AMI created: %s\n\n", amiID)
-
-	// Step 6: Wait for AMI to be available
-	fmt.Printf("6️⃣  Waiting for AMI to be available...\n")
-	if err := b.waitForAMIAvailable(ctx, amiID); err != nil {
-		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("AMI failed to become available: %v", err))
-		return nil, fmt.Errorf("AMI failed to become available: %w", err)
+		if amiID != "" && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			partialAMI = amiID
+		}
+		return nil, err
 	}
-	fmt.Printf("   ✅ AMI is available\n\n")
 
-	// Mark build as complete
-	if err := b.stateManager.MarkComplete(buildState.BuildID, amiID); err != nil {
-		// Log error but don't fail the build
-		fmt.Printf("⚠️  Warning: Failed to update build state: %v\n", err)
+	if opts.ReuseInstance {
+		if err := markInstanceReusable(ctx, b.ec2Client, instanceID, buildState); err != nil {
+			fmt.Printf("⚠️  Warning: %v; terminating it instead\n", err)
+		} else {
+			keepInstance = true
+		}
 	}
 
 	metadata := &AMIMetadata{
-		AMIID:         amiID,
-		Name:          opts.Name,
-		Description:   opts.Description,
-		Region:        b.region,
-		CreatedAt:     time.Now(),
-		TemplateName:  tmpl.Cluster.Name,
-		SpackPackages: tmpl.Software.SpackPackages,
-		Tags:          opts.Tags,
+		AMIID:                  amiID,
+		Name:                   opts.Name,
+		Description:            opts.Description,
+		Region:                 b.region,
+		CreatedAt:              time.Now(),
+		TemplateName:           tmpl.Cluster.Name,
+		Fingerprint:            buildState.Fingerprint,
+		SpackPackages:          packages,
+		SpackLockHash:          buildState.SpackLockHash,
+		Tags:                   opts.Tags,
+		BaseAMI:                buildState.BaseAMI,
+		ParallelClusterVersion: buildState.ParallelClusterVersion,
+		BuildID:                buildState.BuildID,
+		Status:                 BuildStatusComplete,
+		DurationSeconds:        int64(time.Since(buildState.StartTime).Seconds()),
 	}
 
 	fmt.Printf("🎉 AMI build complete!\n")
@@ -221,6 +425,9 @@ func (b *Builder) BuildAMI(ctx context.Context, tmpl *template.Template, opts *B
 	fmt.Printf("   Region: %s\n", b.region)
 	fmt.Printf("\nYou can now use this AMI with:\n")
 	fmt.Printf("  pctl create -t template.yaml --key-name <key> --custom-ami %s\n\n", amiID)
+	if keepInstance {
+		printReusableInstance(instanceID)
+	}
 
 	return metadata, nil
 }
@@ -231,7 +438,8 @@ type BuildOptions struct {
 	Name string
 	// Description is the AMI description
 	Description string
-	// InstanceType for the build instance (default: t3.xlarge)
+	// InstanceType for the build instance (default: c6a.4xlarge). Its
+	// architecture determines the architecture of the base AMI.
 	InstanceType string
 	// SubnetID for the build instance
 	SubnetID string
@@ -239,51 +447,242 @@ type BuildOptions struct {
 	KeyName string
 	// BaseAMI is the base ParallelCluster AMI (auto-detected if not specified)
 	BaseAMI string
+	// Architecture overrides the architecture guessed from InstanceType when
+	// resolving the base AMI (x86_64 or arm64). It is checked against the
+	// architectures EC2 reports for the build and head node instance types.
+	Architecture string
 	// Tags are additional tags for the AMI
 	Tags map[string]string
 	// WaitTimeout is the maximum time to wait for software installation
 	WaitTimeout time.Duration
+	// PollInterval is how often installation progress is checked
+	// (DefaultPollInterval if zero)
+	PollInterval time.Duration
 	// SkipCleanup disables automatic cleanup before AMI creation
 	SkipCleanup bool
 	// CustomCleanupScript runs in addition to default cleanup
 	CustomCleanupScript string
 	// Detach starts the build and returns immediately (build continues in AWS)
 	Detach bool
+	// AllowConcurrent skips the build lock that prevents concurrent builds of the same fingerprint
+	AllowConcurrent bool
+	// SpackLock is the path to a spack.lock to install from instead of
+	// concretizing the template's package specs
+	SpackLock string
+	// SpackConfigFiles are paths to Spack configuration files (config.yaml,
+	// packages.yaml, ...) written to Spack's site scope before installing,
+	// replacing the template's build.spack_config
+	SpackConfigFiles []string
+	// LicenseFiles are src:dest[:sensitive] license files staged on the build
+	// instance before installing; sensitive files are removed before the AMI
+	// is created
+	LicenseFiles []string
+	// MaxWallClock bounds the whole build, from launch to AMI availability;
+	// the instance and any unfinished AMI are cleaned up if it is exceeded
+	// (0 means no limit)
+	MaxWallClock time.Duration
+	// SkipLmod builds a Spack-only AMI without Lmod, as if the template set
+	// software.install_lmod: false
+	SkipLmod bool
+	// TagsFromTemplate adds the template's metadata and package count to the
+	// AMI tags
+	TagsFromTemplate bool
+	// NotifyWebhook receives a JSON POST when the build completes or fails
+	NotifyWebhook string
+	// ProgressMilestones are the installation percentages at which
+	// NotifyWebhook also receives a progress update
+	ProgressMilestones []int
+	// PreInstallScript is an S3 URI, local path, or inline script run before
+	// any software is installed; it overrides build.pre_install_script
+	PreInstallScript string
+	// EncryptSnapshots encrypts the build instance's volumes so the AMI's
+	// snapshots are encrypted, and fails the build if they are not
+	EncryptSnapshots bool
+	// KMSKeyID is the KMS key used with EncryptSnapshots (default: the
+	// account's default EBS key)
+	KMSKeyID string
+	// RootVolumeSizeGB resizes the build instance's root volume, and so the
+	// AMI's, to this many GiB on gp3 (0 keeps the base AMI's size)
+	RootVolumeSizeGB int
+	// UseSpot launches the build instance as a one-time spot instance
+	UseSpot bool
+	// MaxSpotPrice caps the hourly spot price in USD (default: the
+	// on-demand price)
+	MaxSpotPrice string
+	// ReuseInstance keeps the build instance stopped after the AMI is
+	// created, tagged so SnapshotInstance can create more AMIs from it
+	// (experimental; not supported with Detach or UseSpot)
+	ReuseInstance bool
+}
+
+// applyTemplateTags returns opts with the template's metadata and the
+// package count added to its tags if opts.TagsFromTemplate is set. Tags
+// already in opts, such as those from --tags, take precedence. The caller's
+// options are not modified.
+func applyTemplateTags(tmpl *template.Template, packageCount int, opts *BuildOptions) *BuildOptions {
+	if !opts.TagsFromTemplate {
+		return opts
+	}
+
+	tags := template.MergeTags(tmpl.Metadata, map[string]string{TagPackageCount: strconv.Itoa(packageCount)})
+	tagged := *opts
+	tagged.Tags = template.MergeTags(tags, opts.Tags)
+	return &tagged
+}
+
+// applySkipLmod returns tmpl with Lmod disabled if opts.SkipLmod is set. The
+// caller's template is not modified.
+func applySkipLmod(tmpl *template.Template, opts *BuildOptions) *template.Template {
+	if !opts.SkipLmod || !tmpl.Software.ShouldInstallLmod() {
+		return tmpl
+	}
+
+	skipped := *tmpl
+	installLmod := false
+	skipped.Software.InstallLmod = &installLmod
+	return &skipped
+}
+
+// loadSpackConfigFiles loads Spack configuration files, allowing at most one
+// file per configuration section.
+func loadSpackConfigFiles(paths []string) ([]*software.SpackConfigFile, error) {
+	var files []*software.SpackConfigFile
+	sections := make(map[string]string)
+	for _, path := range paths {
+		file, err := software.LoadSpackConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := sections[file.Section]; ok {
+			return nil, fmt.Errorf("spack config files %s and %s both set the '%s' section", prev, path, file.Section)
+		}
+		sections[file.Section] = path
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// DefaultBuildInstanceType returns the default build instance type for an
+// architecture: 16 vCPUs, compute-optimized for fast Spack builds.
+func DefaultBuildInstanceType(architecture string) string {
+	if architecture == template.ArchitectureARM64 {
+		return "c7g.4xlarge"
+	}
+	return "c6a.4xlarge"
+}
+
+// ValidateBuildArchitecture checks that the build instance type has the same
+// architecture as the template's head node, since the AMI is built for the
+// instance it is built on.
+func ValidateBuildArchitecture(tmpl *template.Template, instanceType string) error {
+	if tmpl.Compute.HeadNode == "" {
+		return nil
+	}
+	buildArch := template.InstanceArchitecture(instanceType)
+	headNodeArch := tmpl.Architecture()
+	if buildArch != headNodeArch {
+		return fmt.Errorf("build instance type %s is %s but head node %s is %s; the AMI would not boot on the cluster (use --build-instance-type with a %s type such as %s, or change compute.head_node)",
+			instanceType, buildArch, tmpl.Compute.HeadNode, headNodeArch, headNodeArch, DefaultBuildInstanceType(headNodeArch))
+	}
+	return nil
+}
+
+// ValidateArchitecture checks that arch is an architecture AMIs can be built for.
+func ValidateArchitecture(arch string) error {
+	if arch != template.ArchitectureX86_64 && arch != template.ArchitectureARM64 {
+		return fmt.Errorf("invalid architecture %q: must be %s or %s", arch, template.ArchitectureX86_64, template.ArchitectureARM64)
+	}
+	return nil
+}
+
+// buildArchitecture returns the architecture of the base AMI for a build:
+// the explicit override, or the architecture guessed from the build
+// instance type.
+func buildArchitecture(opts *BuildOptions) string {
+	if opts.Architecture != "" {
+		return opts.Architecture
+	}
+	return template.InstanceArchitecture(opts.InstanceType)
+}
+
+// describeInstanceTypesAPI is the subset of the EC2 API used to look up
+// instance type architectures.
+type describeInstanceTypesAPI interface {
+	DescribeInstanceTypes(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error)
+}
+
+// checkInstanceArchitectures checks that each instance type supports arch,
+// as reported by EC2 rather than guessed from the instance family.
+func checkInstanceArchitectures(ctx context.Context, client describeInstanceTypesAPI, arch string, instanceTypes ...string) error {
+	for _, instanceType := range instanceTypes {
+		if instanceType == "" {
+			continue
+		}
+		result, err := client.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{
+			InstanceTypes: []types.InstanceType{types.InstanceType(instanceType)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe instance type %s: %w", instanceType, err)
+		}
+		if len(result.InstanceTypes) == 0 || result.InstanceTypes[0].ProcessorInfo == nil {
+			return fmt.Errorf("instance type %s is not available in this region", instanceType)
+		}
+
+		var supported []string
+		for _, a := range result.InstanceTypes[0].ProcessorInfo.SupportedArchitectures {
+			supported = append(supported, string(a))
+		}
+		if !containsString(supported, arch) {
+			return fmt.Errorf("architecture %s does not match instance type %s, which supports %s", arch, instanceType, strings.Join(supported, ", "))
+		}
+	}
+	return nil
 }
 
 // DefaultBuildOptions returns default build options.
 func DefaultBuildOptions() *BuildOptions {
 	return &BuildOptions{
-		InstanceType: "c6a.4xlarge", // 16 vCPUs, compute-optimized for fast Spack builds
-		WaitTimeout:  4 * time.Hour, // 4 hours - generous timeout for Spack builds
+		InstanceType:     DefaultBuildInstanceType(template.ArchitectureX86_64),
+		WaitTimeout:      4 * time.Hour, // 4 hours - generous timeout for Spack builds
+		PollInterval:     DefaultPollInterval,
+		TagsFromTemplate: true,
 		Tags: map[string]string{
 			"ManagedBy": "pctl",
 		},
 	}
 }
 
-func (b *Builder) launchBuildInstance(ctx context.Context, tmpl *template.Template, opts *BuildOptions) (string, error) {
-	// Determine architecture from the instance type
-	// Use the template's head_node instance type, or fall back to opts.InstanceType
-	instanceType := opts.InstanceType
-	if tmpl.Compute.HeadNode != "" {
-		instanceType = tmpl.Compute.HeadNode
+// resolveBaseAMI returns the base AMI for a build, auto-detecting the official
+// ParallelCluster AMI for the template's OS and architecture if none was specified.
+func (b *Builder) resolveBaseAMI(ctx context.Context, tmpl *template.Template, opts *BuildOptions) (string, error) {
+	if opts.BaseAMI != "" {
+		return opts.BaseAMI, nil
 	}
-	architecture := getInstanceTypeArchitecture(instanceType)
 
-	// Get base AMI if not specified
-	baseAMI := opts.BaseAMI
-	if baseAMI == "" {
-		var err error
-		baseAMI, err = b.getLatestParallelClusterAMI(ctx, architecture)
-		if err != nil {
-			return "", fmt.Errorf("failed to get base AMI for architecture %s: %w", architecture, err)
-		}
-		fmt.Printf("   Using base AMI %s (%s architecture)\n", baseAMI, architecture)
+	// The base AMI must boot on the build instance, so its architecture
+	// comes from the instance type that is actually launched
+	architecture := buildArchitecture(opts)
+	osName := tmpl.Cluster.GetOS()
+
+	baseAMI, err := b.findParallelClusterAMI(ctx, osName, architecture)
+	if err != nil {
+		return "", fmt.Errorf("failed to get base AMI for %s (%s): %w", osName, architecture, err)
 	}
+	fmt.Printf("   Using base AMI %s (%s, %s architecture)\n", baseAMI, osName, architecture)
+
+	return baseAMI, nil
+}
 
+func (b *Builder) launchBuildInstance(ctx context.Context, tmpl *template.Template, opts *BuildOptions, buildState *BuildState, spackLock *software.SpackLock, spackConfig []*software.SpackConfigFile, licenseFiles []*software.LicenseFile, preInstall *software.PreInstallScript) (string, error) {
 	// Generate user data script for software installation
 	manager := software.NewManager()
+	if spackLock != nil {
+		manager.SetSpackLock(spackLock)
+	}
+	manager.SetSpackConfigFiles(spackConfig)
+	manager.SetLicenseFiles(licenseFiles)
+	manager.SetPreInstallScript(preInstall)
+	manager.SetVerifyCommands(tmpl.Build.Verify)
 	userData := manager.GenerateBootstrapScript(tmpl, false, false) // Software only, no users/S3
 
 	// Append cleanup script unless skipped
@@ -295,6 +694,10 @@ func (b *Builder) launchBuildInstance(ctx context.Context, tmpl *template.Templa
 		userData += GenerateCleanupScript(opts.CustomCleanupScript)
 	}
 
+	if len(userData) > maxUserDataSize {
+		return "", fmt.Errorf("build script is %d bytes, exceeding the EC2 user data limit of %d bytes", len(userData), maxUserDataSize)
+	}
+
 	// Base64 encode user data
 	userDataEncoded := base64.StdEncoding.EncodeToString([]byte(userData))
 
@@ -307,7 +710,7 @@ func (b *Builder) launchBuildInstance(ctx context.Context, tmpl *template.Templa
 
 	// Launch instance
 	runInput := &ec2.RunInstancesInput{
-		ImageId:      aws.String(baseAMI),
+		ImageId:      aws.String(buildState.BaseAMI),
 		InstanceType: types.InstanceType(opts.InstanceType),
 		MinCount:     aws.Int32(1),
 		MaxCount:     aws.Int32(1),
@@ -315,16 +718,7 @@ func (b *Builder) launchBuildInstance(ctx context.Context, tmpl *template.Templa
 		IamInstanceProfile: &types.IamInstanceProfileSpecification{
 			Arn: aws.String(instanceProfileArn),
 		},
-		TagSpecifications: []types.TagSpecification{
-			{
-				ResourceType: types.ResourceTypeInstance,
-				Tags: []types.Tag{
-					{Key: aws.String("Name"), Value: aws.String("pctl-ami-builder")},
-					{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
-					{Key: aws.String("Purpose"), Value: aws.String("AMI-Build")},
-				},
-			},
-		},
+		TagSpecifications: buildInstanceTagSpecifications(tmpl, buildState, opts),
 		NetworkInterfaces: []types.InstanceNetworkInterfaceSpecification{
 			{
 				DeviceIndex:              aws.Int32(0),
@@ -340,8 +734,31 @@ func (b *Builder) launchBuildInstance(ctx context.Context, tmpl *template.Templa
 		runInput.KeyName = aws.String(opts.KeyName)
 	}
 
-	runResult, err := b.ec2Client.RunInstances(ctx, runInput)
+	if opts.UseSpot {
+		runInput.InstanceMarketOptions = spotMarketOptions(opts.MaxSpotPrice)
+	}
+
+	// Snapshots inherit the encryption and size of the volumes they are taken from
+	if opts.EncryptSnapshots || opts.RootVolumeSizeGB > 0 {
+		baseImage, err := b.describeImage(ctx, buildState.BaseAMI)
+		if err != nil {
+			return "", err
+		}
+		var mappings []types.BlockDeviceMapping
+		if opts.EncryptSnapshots {
+			mappings = encryptedBlockDeviceMappings(baseImage, opts.KMSKeyID)
+		}
+		if opts.RootVolumeSizeGB > 0 {
+			if mappings, err = withRootVolumeSize(mappings, baseImage, opts.RootVolumeSizeGB); err != nil {
+				return "", err
+			}
+		}
+		runInput.BlockDeviceMappings = mappings
+	}
 
+	// The build ID makes a retried launch return the same instance
+	runInput.ClientToken = aws.String(buildState.BuildID)
+	runResult, err := runInstancesWithRetry(ctx, b.ec2Client, runInput)
 	if err != nil {
 		return "", err
 	}
@@ -353,6 +770,84 @@ func (b *Builder) launchBuildInstance(ctx context.Context, tmpl *template.Templa
 	return *runResult.Instances[0].InstanceId, nil
 }
 
+// finishBuild takes a build whose instance is installing software through to
+// an available AMI: it waits for installation to finish, stops the instance,
+// and creates the AMI tagged with templateName and fingerprintTags. Builds
+// already in BuildStatusCreating skip the installation wait. It returns the
+// new AMI ID, which is also returned with the error if the AMI was registered
+// but never became available.
+func (b *Builder) finishBuild(ctx context.Context, buildState *BuildState, opts *BuildOptions, templateName string, fingerprintTags map[string]string) (string, error) {
+	instanceID := buildState.InstanceID
+
+	if buildState.Status != BuildStatusCreating {
+		if err := b.waitForSoftwareInstallation(ctx, instanceID, buildState.BuildID, opts); err != nil {
+			if errors.Is(err, ErrSpotInterrupted) {
+				b.stateManager.MarkFailedWithCategory(buildState.BuildID, FailureCategorySpotInterrupted, err.Error())
+				return "", err
+			}
+			b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Software installation failed: %v", err))
+			return "", fmt.Errorf("software installation failed: %w", err)
+		}
+		fmt.Printf("   ✅ Software installation complete\n\n")
+	}
+
+	// Step 4: Stop the instance. One-time spot instances cannot be stopped;
+	// CreateImage reboots them instead for a consistent snapshot.
+	if opts.UseSpot {
+		fmt.Printf("4️⃣  Spot instance will be rebooted during AMI creation\n\n")
+	} else {
+		fmt.Printf("4️⃣  Stopping instance for AMI creation...\n")
+		if err := b.stopInstance(ctx, instanceID); err != nil {
+			b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Failed to stop instance: %v", err))
+			return "", fmt.Errorf("failed to stop instance: %w", err)
+		}
+		fmt.Printf("   ✅ Instance stopped\n\n")
+	}
+
+	// Step 5: Create AMI
+	buildState.Status = BuildStatusCreating
+	b.stateManager.SaveState(buildState)
+	fmt.Printf("5️⃣  Creating AMI...\n")
+	amiID, err := b.createAMI(ctx, instanceID, templateName, fingerprintTags, opts, buildState)
+	if err != nil {
+		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Failed to create AMI: %v", err))
+		return "", fmt.Errorf("failed to create AMI: %w", err)
+	}
+	// Record the AMI now so a cancelled build can clean it up
+	buildState.AMIID = amiID
+	b.stateManager.SaveState(buildState)
+	fmt.Printf("   ✅ AMI created: %s\n\n", amiID)
+
+	// Step 6: Wait for AMI to be available
+	fmt.Printf("6️⃣  Waiting for AMI to be available...\n")
+	if err := b.waitForAMIAvailable(ctx, amiID); err != nil {
+		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("AMI failed to become available: %v", err))
+		return amiID, fmt.Errorf("AMI failed to become available: %w", err)
+	}
+	fmt.Printf("   ✅ AMI is available\n\n")
+
+	if opts.EncryptSnapshots {
+		if err := b.verifySnapshotsEncrypted(ctx, amiID); err != nil {
+			b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("Snapshot encryption check failed: %v", err))
+			// Do not leave an unencrypted AMI behind for someone to use
+			if delErr := (&Manager{builder: b}).DeleteAMI(ctx, amiID); delErr != nil {
+				fmt.Printf("⚠️  Warning: failed to delete unencrypted AMI %s: %v\n", amiID, delErr)
+			}
+			return "", err
+		}
+		fmt.Printf("   🔒 AMI snapshots are encrypted\n\n")
+	}
+
+	// Mark build as complete
+	if err := b.stateManager.MarkComplete(buildState.BuildID, amiID); err != nil {
+		// Log error but don't fail the build
+		fmt.Printf("⚠️  Warning: Failed to update build state: %v\n", err)
+	}
+	buildState.Status = BuildStatusComplete
+
+	return amiID, nil
+}
+
 func (b *Builder) waitForInstanceReady(ctx context.Context, instanceID string) error {
 	waiter := ec2.NewInstanceRunningWaiter(b.ec2Client)
 	return waiter.Wait(ctx, &ec2.DescribeInstancesInput{
@@ -360,8 +855,22 @@ func (b *Builder) waitForInstanceReady(ctx context.Context, instanceID string) e
 	}, 5*time.Minute)
 }
 
+// DefaultPollInterval is how often build progress is checked by default.
+const DefaultPollInterval = 30 * time.Second
+
+// MinPollInterval is the shortest supported progress polling interval; faster
+// polling risks EC2 API throttling.
+const MinPollInterval = 10 * time.Second
+
+// newPollTicker creates the ticker that paces progress polling.
+var newPollTicker = time.NewTicker
+
 func (b *Builder) waitForSoftwareInstallation(ctx context.Context, instanceID, buildID string, opts *BuildOptions) error {
-	ticker := time.NewTicker(30 * time.Second)
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := newPollTicker(interval)
 	defer ticker.Stop()
 
 	timeout := time.After(opts.WaitTimeout)
@@ -396,6 +905,10 @@ func (b *Builder) waitForSoftwareInstallation(ctx context.Context, instanceID, b
 		case <-ticker.C:
 			// Poll console output for progress markers
 			progress, err := b.getConsoleProgress(ctx, instanceID)
+			if errors.Is(err, ErrSpotInterrupted) {
+				fmt.Println()
+				return err
+			}
 			if err != nil {
 				// If we can't get console output, just show elapsed time
 				elapsed := time.Since(startTime)
@@ -407,6 +920,12 @@ func (b *Builder) waitForSoftwareInstallation(ctx context.Context, instanceID, b
 			if progress != "" && progress != lastProgress {
 				lastProgress = progress
 
+				// A failed verification command ends the bootstrap early
+				if err := verifyFailure(progress); err != nil {
+					fmt.Println()
+					return err
+				}
+
 				// Extract progress percentage and update state
 				progressInt := extractProgressPercentage(progress)
 				if progressInt > lastProgressInt {
@@ -427,6 +946,7 @@ func (b *Builder) waitForSoftwareInstallation(ctx context.Context, instanceID, b
 					}
 
 					b.stateManager.UpdateProgress(buildID, progressInt, progress)
+					b.notifyMilestones(ctx, buildID, opts)
 					lastProgressInt = progressInt
 				}
 
@@ -453,6 +973,19 @@ func (b *Builder) waitForSoftwareInstallation(ctx context.Context, instanceID, b
 	}
 }
 
+// ErrVerifyFailed is returned when a build.verify command fails on the
+// build instance.
+var ErrVerifyFailed = errors.New("build verification failed")
+
+// verifyFailure returns an ErrVerifyFailed error describing the failed
+// command if a progress message reports one, or nil.
+func verifyFailure(progress string) error {
+	if _, detail, failed := strings.Cut(progress, software.VerifyFailedMarker+": "); failed {
+		return fmt.Errorf("%w: %s", ErrVerifyFailed, detail)
+	}
+	return nil
+}
+
 // extractProgressPercentage extracts the percentage from a progress message.
 func extractProgressPercentage(message string) int {
 	// Look for patterns like "(42%)" or "42%"
@@ -471,10 +1004,22 @@ func extractProgressPercentage(message string) int {
 	return 0
 }
 
-// getConsoleProgress retrieves progress from EC2 instance tags (primary) with console output fallback.
+// getConsoleProgress retrieves progress from the bootstrap log over SSM
+// (primary), then EC2 instance tags, with console output as the last fallback.
 func (b *Builder) getConsoleProgress(ctx context.Context, instanceID string) (string, error) {
-	// Primary: Try to get progress from instance tag (more reliable, no caching issues)
+	// The tag lookup also detects spot interruptions, so it always runs
 	tagProgress, tagErr := b.getTagProgress(ctx, instanceID)
+	if errors.Is(tagErr, ErrSpotInterrupted) {
+		return "", tagErr
+	}
+
+	// Primary: read the bootstrap log over SSM, which sees every marker as it
+	// is written (unavailable until the SSM agent registers, or without one)
+	if ssmProgress, err := readSSMProgress(ctx, b, instanceID); err == nil && ssmProgress != "" {
+		return ssmProgress, nil
+	}
+
+	// Then the instance tag (not updated when the AWS CLI is missing)
 	if tagErr == nil && tagProgress != "" {
 		return tagProgress, nil
 	}
@@ -506,10 +1051,13 @@ func (b *Builder) getTagProgress(ctx context.Context, instanceID string) (string
 	}
 
 	instance := result.Reservations[0].Instances[0]
+	if spotInterrupted(instance) {
+		return "", ErrSpotInterrupted
+	}
 
 	// Find the pctl-progress tag
 	for _, tag := range instance.Tags {
-		if tag.Key != nil && *tag.Key == "pctl-progress" && tag.Value != nil {
+		if tag.Key != nil && *tag.Key == tagProgress && tag.Value != nil {
 			return *tag.Value, nil
 		}
 	}
@@ -537,22 +1085,32 @@ func (b *Builder) getConsoleProgressFromOutput(ctx context.Context, instanceID s
 		return "", err
 	}
 
-	consoleOutput := string(decodedBytes)
-
 	// Find the last PCTL_PROGRESS marker
-	lines := strings.Split(consoleOutput, "\n")
-	var lastProgress string
-	for _, line := range lines {
-		if strings.Contains(line, "PCTL_PROGRESS:") {
-			// Extract the progress message
-			parts := strings.SplitN(line, "PCTL_PROGRESS:", 2)
-			if len(parts) == 2 {
-				lastProgress = strings.TrimSpace(parts[1])
-			}
-		}
+	return lastProgressMarker(string(decodedBytes)), nil
+}
+
+// buildInstanceGone reports whether a build's instance has been terminated or
+// no longer exists. Instances in other regions, or that cannot be described,
+// are assumed to still exist.
+func (b *Builder) buildInstanceGone(ctx context.Context, state *BuildState) bool {
+	if state.Region != b.region {
+		return false
+	}
+
+	result, err := b.ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{state.InstanceID},
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		return errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidInstanceID.NotFound"
 	}
 
-	return lastProgress, nil
+	if len(result.Reservations) == 0 || len(result.Reservations[0].Instances) == 0 {
+		return true
+	}
+	instanceState := result.Reservations[0].Instances[0].State
+	return instanceState != nil &&
+		(instanceState.Name == types.InstanceStateNameTerminated || instanceState.Name == types.InstanceStateNameShuttingDown)
 }
 
 // getInstancePublicIP retrieves the public IP address of an EC2 instance.
@@ -609,7 +1167,7 @@ func (b *Builder) verifyCloudInitComplete(ctx context.Context, instanceID, keyNa
 }
 
 func (b *Builder) stopInstance(ctx context.Context, instanceID string) error {
-	_, err := b.ec2Client.StopInstances(ctx, &ec2.StopInstancesInput{
+	err := stopInstancesWithRetry(ctx, b.ec2Client, &ec2.StopInstancesInput{
 		InstanceIds: []string{instanceID},
 	})
 	if err != nil {
@@ -622,23 +1180,8 @@ func (b *Builder) stopInstance(ctx context.Context, instanceID string) error {
 	}, 5*time.Minute)
 }
 
-func (b *Builder) createAMI(ctx context.Context, instanceID string, tmpl *template.Template, opts *BuildOptions) (string, error) {
-	result, err := b.ec2Client.CreateImage(ctx, &ec2.CreateImageInput{
-		InstanceId:  aws.String(instanceID),
-		Name:        aws.String(opts.Name),
-		Description: aws.String(opts.Description),
-		TagSpecifications: []types.TagSpecification{
-			{
-				ResourceType: types.ResourceTypeImage,
-				Tags: []types.Tag{
-					{Key: aws.String("Name"), Value: aws.String(opts.Name)},
-					{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
-					{Key: aws.String("TemplateName"), Value: aws.String(tmpl.Cluster.Name)},
-				},
-			},
-		},
-	})
-
+func (b *Builder) createAMI(ctx context.Context, instanceID, templateName string, fingerprintTags map[string]string, opts *BuildOptions, buildState *BuildState) (string, error) {
+	result, err := createImageWithRetry(ctx, b.ec2Client, createImageInput(instanceID, templateName, fingerprintTags, opts, buildState))
 	if err != nil {
 		return "", err
 	}
@@ -646,6 +1189,65 @@ func (b *Builder) createAMI(ctx context.Context, instanceID string, tmpl *templa
 	return *result.ImageId, nil
 }
 
+// createImageInput builds the CreateImage request for a build, tagging the
+// AMI and its snapshots with the AMI's provenance, fingerprint, and
+// opts.Tags. Tags pctl sets itself take precedence over opts.Tags with the
+// same key.
+func createImageInput(instanceID, templateName string, fingerprintTags map[string]string, opts *BuildOptions, buildState *BuildState) *ec2.CreateImageInput {
+	tags := []types.Tag{
+		{Key: aws.String("Name"), Value: aws.String(opts.Name)},
+		{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
+	}
+	if templateName != "" {
+		tags = append(tags, types.Tag{Key: aws.String(TagTemplateName), Value: aws.String(templateName)})
+	}
+	// Record the base AMI and its ParallelCluster version so clusters
+	// created from this AMI can be checked against the pcluster CLI
+	if buildState.BaseAMI != "" {
+		tags = append(tags, types.Tag{Key: aws.String(TagBaseAMI), Value: aws.String(buildState.BaseAMI)})
+	}
+	if buildState.ParallelClusterVersion != "" {
+		tags = append(tags, types.Tag{Key: aws.String(TagParallelClusterVersion), Value: aws.String(buildState.ParallelClusterVersion)})
+	}
+	if buildState.SpackLockHash != "" {
+		tags = append(tags, types.Tag{Key: aws.String(TagSpackLockHash), Value: aws.String(buildState.SpackLockHash)})
+	}
+	// Fingerprint tags let create reuse this AMI for matching templates
+	tags = append(tags, sortedTags(fingerprintTags)...)
+
+	return &ec2.CreateImageInput{
+		InstanceId:        aws.String(instanceID),
+		Name:              aws.String(opts.Name),
+		Description:       aws.String(opts.Description),
+		TagSpecifications: tagSpecifications(withUserTags(tags, opts.Tags), types.ResourceTypeImage, types.ResourceTypeSnapshot),
+	}
+}
+
+// withUserTags appends user tags, such as cost allocation tags, to the tags
+// pctl sets, skipping any that would override a pctl tag.
+func withUserTags(tags []types.Tag, userTags map[string]string) []types.Tag {
+	reserved := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		reserved[aws.ToString(tag.Key)] = true
+	}
+	for _, tag := range sortedTags(userTags) {
+		if !reserved[aws.ToString(tag.Key)] {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// tagSpecifications applies the same tags to each resource type, so every
+// resource a request creates is tagged alike.
+func tagSpecifications(tags []types.Tag, resourceTypes ...types.ResourceType) []types.TagSpecification {
+	specs := make([]types.TagSpecification, len(resourceTypes))
+	for i, resourceType := range resourceTypes {
+		specs[i] = types.TagSpecification{ResourceType: resourceType, Tags: tags}
+	}
+	return specs
+}
+
 func (b *Builder) waitForAMIAvailable(ctx context.Context, amiID string) error {
 	// Continuously poll for AMI status and snapshot progress
 	// This unified approach checks for snapshot ID on each iteration rather than
@@ -765,15 +1367,45 @@ func (b *Builder) terminateInstance(ctx context.Context, instanceID string) erro
 	return err
 }
 
-func (b *Builder) getLatestParallelClusterAMI(ctx context.Context, architecture string) (string, error) {
-	// Query for AWS ParallelCluster AMIs with matching architecture
-	// This is a simplified version - in production, query AWS Systems Manager Parameter Store
+// parallelClusterAMIOS maps a ParallelCluster OS name to the token used in
+// official ParallelCluster AMI names.
+var parallelClusterAMIOS = map[string]string{
+	"alinux2":    "amzn2",
+	"alinux2023": "amzn2023",
+	"ubuntu2004": "ubuntu-2004-lts",
+	"ubuntu2204": "ubuntu-2204-lts",
+	"ubuntu2404": "ubuntu-2404-lts",
+	"rhel8":      "rhel8",
+	"rhel9":      "rhel9",
+	"rocky8":     "rocky8",
+	"rocky9":     "rocky9",
+}
+
+// parallelClusterAMINamePattern returns the AMI name filter for official
+// ParallelCluster AMIs of the given release and OS.
+func parallelClusterAMINamePattern(version, osName string) (string, error) {
+	token, ok := parallelClusterAMIOS[osName]
+	if !ok {
+		return "", fmt.Errorf("unsupported OS: %s", osName)
+	}
+	return fmt.Sprintf("aws-parallelcluster-%s-%s-hvm-*", version, token), nil
+}
+
+// getLatestParallelClusterAMI returns the newest Amazon-owned AMI whose name
+// matches the ParallelCluster pattern for the release, OS, and architecture.
+func (b *Builder) getLatestParallelClusterAMI(ctx context.Context, version, osName, architecture string) (string, error) {
+	namePattern, err := parallelClusterAMINamePattern(version, osName)
+	if err != nil {
+		return "", err
+	}
+
+	// Query for AWS ParallelCluster AMIs with matching OS and architecture
 	result, err := b.ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
 		Owners: []string{"amazon"},
 		Filters: []types.Filter{
 			{
 				Name:   aws.String("name"),
-				Values: []string{"aws-parallelcluster-*-amzn2-*"},
+				Values: []string{namePattern},
 			},
 			{
 				Name:   aws.String("state"),
@@ -791,7 +1423,7 @@ func (b *Builder) getLatestParallelClusterAMI(ctx context.Context, architecture
 	}
 
 	if len(result.Images) == 0 {
-		return "", fmt.Errorf("no ParallelCluster AMIs found")
+		return "", fmt.Errorf("no ParallelCluster %s AMIs found for %s (%s)", version, osName, architecture)
 	}
 
 	// Return the most recent AMI
@@ -807,51 +1439,6 @@ func (b *Builder) getLatestParallelClusterAMI(ctx context.Context, architecture
 	return *latest.ImageId, nil
 }
 
-// getInstanceTypeArchitecture determines the CPU architecture for an instance type.
-// This allows AMI building to work correctly from any host (e.g., Mac ARM building x86 AMIs).
-func getInstanceTypeArchitecture(instanceType string) string {
-	// Extract instance family (e.g., "t3", "c5", "m6g")
-	// ARM instance families end with 'g' or 'gd' (Graviton)
-	// Examples: t4g, m6g, c6gd, r6gd
-
-	// Common ARM (Graviton) prefixes
-	armFamilies := map[string]bool{
-		"t4g":    true,
-		"m6g":    true,
-		"m6gd":   true,
-		"m7g":    true,
-		"m7gd":   true,
-		"c6g":    true,
-		"c6gd":   true,
-		"c6gn":   true,
-		"c7g":    true,
-		"c7gd":   true,
-		"c7gn":   true,
-		"r6g":    true,
-		"r6gd":   true,
-		"r7g":    true,
-		"r7gd":   true,
-		"x2gd":   true,
-		"im4gn":  true,
-		"is4gen": true,
-	}
-
-	// Extract family from instance type (e.g., "t3.large" -> "t3")
-	parts := strings.Split(instanceType, ".")
-	if len(parts) < 2 {
-		// Default to x86_64 if we can't parse
-		return "x86_64"
-	}
-
-	family := parts[0]
-	if armFamilies[family] {
-		return "arm64"
-	}
-
-	// Default to x86_64 for all other instance types
-	return "x86_64"
-}
-
 // ensureIAMInstanceProfile ensures the IAM role and instance profile exist for AMI builder instances.
 // Returns the instance profile ARN if successful.
 func (b *Builder) ensureIAMInstanceProfile(ctx context.Context) (string, error) {
@@ -932,6 +1519,17 @@ func (b *Builder) ensureIAMInstanceProfile(ctx context.Context) (string, error)
 		fmt.Printf("   IAM role created successfully\n")
 	}
 
+	// SSM lets the builder read the bootstrap log for progress. Attaching is
+	// idempotent, so roles created before this policy was added pick it up;
+	// without it, progress falls back to tags and console output.
+	_, err = b.iamClient.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+		RoleName:  aws.String(roleName),
+		PolicyArn: aws.String(ssmManagedInstancePolicyARN),
+	})
+	if err != nil {
+		fmt.Printf("   ⚠️  Could not attach SSM policy to %s, progress will use tags and console output: %v\n", roleName, err)
+	}
+
 	// Check if instance profile exists
 	getProfileOutput, err := b.iamClient.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{
 		InstanceProfileName: aws.String(profileName),
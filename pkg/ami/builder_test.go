@@ -0,0 +1,380 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func TestWriteMetadataFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build.json")
+
+	metadata := &AMIMetadata{
+		AMIID:           "ami-0123456789abcdef0",
+		Name:            "bio-cluster-v1",
+		Region:          "us-east-1",
+		CreatedAt:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		TemplateName:    "bioinformatics",
+		Fingerprint:     "abc123",
+		SpackPackages:   []string{"gcc@11.3.0", "samtools@1.17"},
+		BuildID:         "550e8400-e29b-41d4-a716-446655440000",
+		Status:          BuildStatusComplete,
+		DurationSeconds: 3725,
+	}
+
+	if err := WriteMetadataFile(path, metadata); err != nil {
+		t.Fatalf("WriteMetadataFile() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read metadata file: %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("metadata file is not valid JSON: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"ami_id":           "ami-0123456789abcdef0",
+		"name":             "bio-cluster-v1",
+		"region":           "us-east-1",
+		"created_at":       "2025-01-02T03:04:05Z",
+		"template_name":    "bioinformatics",
+		"fingerprint":      "abc123",
+		"spack_packages":   []interface{}{"gcc@11.3.0", "samtools@1.17"},
+		"build_id":         "550e8400-e29b-41d4-a716-446655440000",
+		"status":           "complete",
+		"duration_seconds": float64(3725),
+	}
+
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("metadata JSON mismatch\ngot:  %v\nwant: %v", parsed, expected)
+	}
+}
+
+func TestWriteMetadataFileDetached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build.json")
+
+	// Detached builds only know the build ID
+	metadata := &AMIMetadata{
+		Name:          "bio-cluster-v1",
+		Region:        "us-east-1",
+		TemplateName:  "bioinformatics",
+		SpackPackages: []string{"gcc@11.3.0"},
+		BuildID:       "550e8400-e29b-41d4-a716-446655440000",
+		Status:        BuildStatusInstalling,
+	}
+
+	if err := WriteMetadataFile(path, metadata); err != nil {
+		t.Fatalf("WriteMetadataFile() failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("metadata file is not valid JSON: %v", err)
+	}
+
+	if parsed["build_id"] != metadata.BuildID {
+		t.Errorf("build_id = %v, want %s", parsed["build_id"], metadata.BuildID)
+	}
+	if parsed["status"] != "installing" {
+		t.Errorf("status = %v, want installing", parsed["status"])
+	}
+	if _, ok := parsed["ami_id"]; ok {
+		t.Error("ami_id should be omitted before the AMI exists")
+	}
+}
+
+func TestParallelClusterAMINamePattern(t *testing.T) {
+	tests := []struct {
+		os   string
+		want string
+	}{
+		{"alinux2023", "aws-parallelcluster-3.14.0-amzn2023-hvm-*"},
+		{"alinux2", "aws-parallelcluster-3.14.0-amzn2-hvm-*"},
+		{"ubuntu2204", "aws-parallelcluster-3.14.0-ubuntu-2204-lts-hvm-*"},
+		{"rocky9", "aws-parallelcluster-3.14.0-rocky9-hvm-*"},
+	}
+	for _, tt := range tests {
+		got, err := parallelClusterAMINamePattern("3.14.0", tt.os)
+		if err != nil {
+			t.Fatalf("parallelClusterAMINamePattern(%q) error = %v", tt.os, err)
+		}
+		if got != tt.want {
+			t.Errorf("parallelClusterAMINamePattern(%q) = %q, want %q", tt.os, got, tt.want)
+		}
+	}
+
+	if _, err := parallelClusterAMINamePattern("3.14.0", "centos7"); err == nil {
+		t.Error("expected error for unsupported OS")
+	}
+}
+
+func TestLoadSpackConfigFilesDuplicateSection(t *testing.T) {
+	dir := t.TempDir()
+	site := filepath.Join(dir, "site")
+	if err := os.Mkdir(site, 0755); err != nil {
+		t.Fatal(err)
+	}
+	first := filepath.Join(dir, "packages.yaml")
+	second := filepath.Join(site, "packages.yaml")
+	for _, path := range []string{first, second} {
+		if err := os.WriteFile(path, []byte("packages: {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := loadSpackConfigFiles([]string{first})
+	if err != nil || len(files) != 1 {
+		t.Fatalf("loadSpackConfigFiles() = %v, %v; want one file", files, err)
+	}
+
+	if _, err := loadSpackConfigFiles([]string{first, second}); err == nil || !strings.Contains(err.Error(), "both set the 'packages' section") {
+		t.Errorf("loadSpackConfigFiles() error = %v, want duplicate section error", err)
+	}
+}
+
+func TestValidateBuildArchitecture(t *testing.T) {
+	tmpl := &template.Template{Compute: template.ComputeConfig{HeadNode: "c7g.xlarge"}}
+
+	if err := ValidateBuildArchitecture(tmpl, "c7g.8xlarge"); err != nil {
+		t.Errorf("ValidateBuildArchitecture(arm64, arm64) error = %v", err)
+	}
+
+	err := ValidateBuildArchitecture(tmpl, "c6a.4xlarge")
+	if err == nil {
+		t.Fatal("ValidateBuildArchitecture(arm64 head node, x86_64 build) = nil, want error")
+	}
+	for _, want := range []string{"c6a.4xlarge is x86_64", "c7g.xlarge is arm64", "--build-instance-type", "c7g.4xlarge"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
+
+func TestBuildAMIRejectsArchitectureMismatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	stateManager, err := NewStateManager()
+	if err != nil {
+		t.Fatalf("NewStateManager() error = %v", err)
+	}
+	b := &Builder{region: "us-east-1", stateManager: stateManager}
+
+	tmpl := &template.Template{
+		Cluster:  template.ClusterConfig{Name: "bio", Region: "us-east-1"},
+		Compute:  template.ComputeConfig{HeadNode: "t3.xlarge"},
+		Software: template.SoftwareConfig{SpackPackages: []string{"samtools@1.17"}},
+	}
+	opts := DefaultBuildOptions()
+	opts.InstanceType = "c7g.4xlarge"
+
+	if _, err := b.BuildAMI(context.Background(), tmpl, opts); err == nil || !strings.Contains(err.Error(), "is arm64 but head node t3.xlarge is x86_64") {
+		t.Errorf("BuildAMI() error = %v, want the architecture mismatch", err)
+	}
+	builds, err := stateManager.ListStates()
+	if err != nil {
+		t.Fatalf("ListStates() error = %v", err)
+	}
+	if len(builds) != 0 {
+		t.Errorf("BuildAMI() recorded %d build(s) before rejecting the mismatch", len(builds))
+	}
+}
+
+func TestApplySkipLmod(t *testing.T) {
+	tmpl := &template.Template{
+		Software: template.SoftwareConfig{SpackPackages: []string{"gcc@11.3.0"}},
+	}
+
+	if got := applySkipLmod(tmpl, &BuildOptions{}); got != tmpl {
+		t.Error("Template should be unchanged without SkipLmod")
+	}
+
+	got := applySkipLmod(tmpl, &BuildOptions{SkipLmod: true})
+	if got.Software.ShouldInstallLmod() {
+		t.Error("SkipLmod should disable Lmod")
+	}
+	if !tmpl.Software.ShouldInstallLmod() {
+		t.Error("SkipLmod should not modify the caller's template")
+	}
+	if got.ComputeFingerprint().Hash == tmpl.ComputeFingerprint().Hash {
+		t.Error("Skipping Lmod should change the fingerprint")
+	}
+}
+
+func TestWaitForSoftwareInstallationPollInterval(t *testing.T) {
+	original := newPollTicker
+	defer func() { newPollTicker = original }()
+
+	var got time.Duration
+	newPollTicker = func(d time.Duration) *time.Ticker {
+		got = d
+		return time.NewTicker(d)
+	}
+
+	// A canceled context returns before the first poll
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b := &Builder{}
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     time.Duration
+	}{
+		{"configured", 45 * time.Second, 45 * time.Second},
+		{"default", 0, DefaultPollInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &BuildOptions{WaitTimeout: time.Hour, PollInterval: tt.interval}
+			if err := b.waitForSoftwareInstallation(ctx, "i-123", "build-1", opts); err != context.Canceled {
+				t.Fatalf("waitForSoftwareInstallation() error = %v, want context.Canceled", err)
+			}
+			if got != tt.want {
+				t.Errorf("poll interval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if DefaultBuildOptions().PollInterval != DefaultPollInterval {
+		t.Errorf("DefaultBuildOptions().PollInterval = %v, want %v", DefaultBuildOptions().PollInterval, DefaultPollInterval)
+	}
+}
+
+func TestCreateImageInputTemplateTags(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster:  template.ClusterConfig{Name: "bio"},
+		Metadata: map[string]string{"owner": "alice", "project": "genomics", "Name": "from-metadata"},
+	}
+	opts := DefaultBuildOptions()
+	opts.Name = "bio-v1"
+	opts.Description = "Bio AMI"
+	opts.Tags["project"] = "override"
+
+	tagged := applyTemplateTags(tmpl, 3, opts)
+	if _, ok := opts.Tags["owner"]; ok {
+		t.Error("applyTemplateTags() modified the caller's tags")
+	}
+
+	buildState := &BuildState{ParallelClusterVersion: "3.14.0"}
+	input := createImageInput("i-123", "bio", map[string]string{"pctl:fingerprint": "abc123"}, tagged, buildState)
+	if aws.ToString(input.InstanceId) != "i-123" || aws.ToString(input.Name) != "bio-v1" || aws.ToString(input.Description) != "Bio AMI" {
+		t.Errorf("unexpected CreateImage input: %+v", input)
+	}
+
+	got := make(map[string]string)
+	for _, tag := range input.TagSpecifications[0].Tags {
+		key := aws.ToString(tag.Key)
+		if _, dup := got[key]; dup {
+			t.Errorf("duplicate tag %q", key)
+		}
+		got[key] = aws.ToString(tag.Value)
+	}
+
+	want := map[string]string{
+		"Name":                    "bio-v1",
+		"ManagedBy":               "pctl",
+		TagTemplateName:           "bio",
+		TagParallelClusterVersion: "3.14.0",
+		TagPackageCount:           "3",
+		"pctl:fingerprint":        "abc123",
+		"owner":                   "alice",
+		"project":                 "override",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AMI tags = %v, want %v", got, want)
+	}
+
+	// Snapshots are billed separately, so they carry the AMI's tags too
+	if len(input.TagSpecifications) != 2 || input.TagSpecifications[0].ResourceType != types.ResourceTypeImage ||
+		input.TagSpecifications[1].ResourceType != types.ResourceTypeSnapshot {
+		t.Fatalf("Expected image and snapshot tag specifications, got %+v", input.TagSpecifications)
+	}
+	if !reflect.DeepEqual(input.TagSpecifications[1].Tags, input.TagSpecifications[0].Tags) {
+		t.Errorf("Snapshot tags = %v, want the AMI's tags", input.TagSpecifications[1].Tags)
+	}
+}
+
+func TestApplyTemplateTagsDisabled(t *testing.T) {
+	tmpl := &template.Template{Metadata: map[string]string{"owner": "alice"}}
+	opts := &BuildOptions{Tags: map[string]string{"ManagedBy": "pctl"}}
+
+	if got := applyTemplateTags(tmpl, 3, opts); got != opts {
+		t.Errorf("applyTemplateTags() without TagsFromTemplate should return opts unchanged, got tags %v", got.Tags)
+	}
+}
+
+func TestVerifyFailure(t *testing.T) {
+	if err := verifyFailure("85% - Integrating Spack with Lmod"); err != nil {
+		t.Errorf("verifyFailure() = %v for normal progress", err)
+	}
+
+	err := verifyFailure("97% - PCTL_VERIFY_FAILED: check 2 of 3 failed: gmx --version")
+	if !errors.Is(err, ErrVerifyFailed) {
+		t.Fatalf("verifyFailure() = %v, want ErrVerifyFailed", err)
+	}
+	if err.Error() != "build verification failed: check 2 of 3 failed: gmx --version" {
+		t.Errorf("verifyFailure() = %q", err)
+	}
+}
+
+func TestCheckInstanceArchitectures(t *testing.T) {
+	fake := &fakePreflightEC2{typeInfo: []types.InstanceTypeInfo{
+		instanceTypeInfo("a1.4xlarge", 16, types.ArchitectureTypeArm64),
+		instanceTypeInfo("c6a.4xlarge", 16, types.ArchitectureTypeX8664),
+	}}
+	ctx := context.Background()
+
+	if err := checkInstanceArchitectures(ctx, fake, "arm64", "a1.4xlarge", ""); err != nil {
+		t.Errorf("checkInstanceArchitectures(arm64, a1.4xlarge) error = %v", err)
+	}
+
+	err := checkInstanceArchitectures(ctx, fake, "arm64", "a1.4xlarge", "c6a.4xlarge")
+	if err == nil || !strings.Contains(err.Error(), "arm64 does not match instance type c6a.4xlarge, which supports x86_64") {
+		t.Errorf("checkInstanceArchitectures(arm64, c6a.4xlarge) error = %v, want a mismatch", err)
+	}
+
+	if err := checkInstanceArchitectures(ctx, fake, "x86_64", "c99.huge"); err == nil {
+		t.Error("checkInstanceArchitectures() accepted an unknown instance type")
+	}
+}
+
+func TestValidateArchitecture(t *testing.T) {
+	for _, arch := range []string{"x86_64", "arm64"} {
+		if err := ValidateArchitecture(arch); err != nil {
+			t.Errorf("ValidateArchitecture(%q) error = %v", arch, err)
+		}
+	}
+	for _, arch := range []string{"", "amd64", "aarch64", "ARM64"} {
+		if err := ValidateArchitecture(arch); err == nil {
+			t.Errorf("ValidateArchitecture(%q) = nil, want error", arch)
+		}
+	}
+}
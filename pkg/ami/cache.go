@@ -187,39 +187,16 @@ func (m *Manager) FindAMIByFingerprint(ctx context.Context, fingerprint *templat
 	}
 
 	// Query AWS for AMI with matching fingerprint tag
-	result, err := m.builder.ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
-		Owners: []string{"self"},
-		Filters: []types.Filter{
-			{
-				Name:   aws.String("tag:pctl:fingerprint"),
-				Values: []string{fingerprint.Hash},
-			},
-			{
-				Name:   aws.String("tag:ManagedBy"),
-				Values: []string{"pctl"},
-			},
-		},
-	})
-
+	mostRecent, err := findFingerprintedImage(ctx, m.builder.ec2Client, fingerprint)
 	if err != nil {
-		return "", fmt.Errorf("failed to query AMIs: %w", err)
+		return "", err
 	}
 
 	// No matching AMI found
-	if len(result.Images) == 0 {
+	if mostRecent == nil {
 		return "", nil
 	}
 
-	// Use the most recent AMI if multiple exist
-	mostRecent := result.Images[0]
-	for _, img := range result.Images {
-		if img.CreationDate != nil && mostRecent.CreationDate != nil {
-			if *img.CreationDate > *mostRecent.CreationDate {
-				mostRecent = img
-			}
-		}
-	}
-
 	amiID := *mostRecent.ImageId
 
 	// Add to cache
@@ -235,6 +212,73 @@ func (m *Manager) FindAMIByFingerprint(ctx context.Context, fingerprint *templat
 	return amiID, nil
 }
 
+// describeImagesAPI is the subset of the EC2 API used to look up AMIs by tag.
+type describeImagesAPI interface {
+	DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error)
+}
+
+// findFingerprintedImage returns the most recent pctl AMI tagged with the
+// fingerprint's hash. AMIs built before fingerprint version 2 carry the
+// version 1 hash instead, so when nothing matches it retries with that hash
+// and keeps only images of the fingerprint's architecture, which version 1
+// did not record. It returns nil if no AMI matches.
+func findFingerprintedImage(ctx context.Context, client describeImagesAPI, fingerprint *template.AMIFingerprint) (*types.Image, error) {
+	images, err := describeFingerprintedImages(ctx, client, fingerprint.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(images) == 0 && fingerprint.Version >= 2 {
+		legacy, err := describeFingerprintedImages(ctx, client, fingerprint.LegacyHash())
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range legacy {
+			if fingerprint.Architecture == "" || string(img.Architecture) == fingerprint.Architecture {
+				images = append(images, img)
+			}
+		}
+	}
+
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	// Use the most recent AMI if multiple exist
+	mostRecent := images[0]
+	for _, img := range images {
+		if img.CreationDate != nil && mostRecent.CreationDate != nil {
+			if *img.CreationDate > *mostRecent.CreationDate {
+				mostRecent = img
+			}
+		}
+	}
+
+	return &mostRecent, nil
+}
+
+// describeFingerprintedImages lists the caller's pctl AMIs tagged with hash.
+func describeFingerprintedImages(ctx context.Context, client describeImagesAPI, hash string) ([]types.Image, error) {
+	result, err := client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		Owners: []string{"self"},
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("tag:pctl:fingerprint"),
+				Values: []string{hash},
+			},
+			{
+				Name:   aws.String("tag:ManagedBy"),
+				Values: []string{"pctl"},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query AMIs: %w", err)
+	}
+
+	return result.Images, nil
+}
+
 // amiExists checks if an AMI exists in AWS.
 func (m *Manager) amiExists(ctx context.Context, amiID string) bool {
 	result, err := m.builder.ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
@@ -0,0 +1,95 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// fakeImagesEC2 serves DescribeImages from images keyed by fingerprint tag.
+type fakeImagesEC2 struct {
+	images  map[string][]types.Image
+	queried []string
+}
+
+func (f *fakeImagesEC2) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	var hash string
+	for _, filter := range params.Filters {
+		if aws.ToString(filter.Name) == "tag:pctl:fingerprint" {
+			hash = filter.Values[0]
+		}
+	}
+	f.queried = append(f.queried, hash)
+	return &ec2.DescribeImagesOutput{Images: f.images[hash]}, nil
+}
+
+func TestFindFingerprintedImageFallsBackToV1Hash(t *testing.T) {
+	tmpl := &template.Template{}
+	tmpl.Compute.HeadNode = "c5.xlarge"
+	tmpl.Software.SpackPackages = []string{"gcc@11.3.0"}
+	fingerprint := tmpl.ComputeFingerprint()
+
+	legacyHash := fingerprint.LegacyHash()
+	if legacyHash == fingerprint.Hash {
+		t.Fatal("LegacyHash() should differ from the version 2 hash")
+	}
+
+	client := &fakeImagesEC2{images: map[string][]types.Image{
+		legacyHash: {
+			{ImageId: aws.String("ami-v1-old"), Architecture: types.ArchitectureValuesX8664, CreationDate: aws.String("2024-01-01T00:00:00Z")},
+			{ImageId: aws.String("ami-v1-new"), Architecture: types.ArchitectureValuesX8664, CreationDate: aws.String("2024-06-01T00:00:00Z")},
+			{ImageId: aws.String("ami-v1-arm"), Architecture: types.ArchitectureValuesArm64, CreationDate: aws.String("2024-09-01T00:00:00Z")},
+		},
+	}}
+
+	img, err := findFingerprintedImage(context.Background(), client, fingerprint)
+	if err != nil {
+		t.Fatalf("findFingerprintedImage() error = %v", err)
+	}
+	if img == nil || aws.ToString(img.ImageId) != "ami-v1-new" {
+		t.Fatalf("findFingerprintedImage() = %v, want ami-v1-new", img)
+	}
+	if len(client.queried) != 2 || client.queried[0] != fingerprint.Hash || client.queried[1] != legacyHash {
+		t.Errorf("queried hashes = %v, want [v2, v1]", client.queried)
+	}
+}
+
+func TestFindFingerprintedImagePrefersV2Hash(t *testing.T) {
+	tmpl := &template.Template{}
+	tmpl.Compute.HeadNode = "c5.xlarge"
+	fingerprint := tmpl.ComputeFingerprint()
+
+	client := &fakeImagesEC2{images: map[string][]types.Image{
+		fingerprint.Hash:         {{ImageId: aws.String("ami-v2")}},
+		fingerprint.LegacyHash(): {{ImageId: aws.String("ami-v1"), Architecture: types.ArchitectureValuesX8664}},
+	}}
+
+	img, err := findFingerprintedImage(context.Background(), client, fingerprint)
+	if err != nil {
+		t.Fatalf("findFingerprintedImage() error = %v", err)
+	}
+	if img == nil || aws.ToString(img.ImageId) != "ami-v2" {
+		t.Fatalf("findFingerprintedImage() = %v, want ami-v2", img)
+	}
+	if len(client.queried) != 1 {
+		t.Errorf("queried hashes = %v, want only the v2 hash", client.queried)
+	}
+}
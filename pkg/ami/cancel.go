@@ -0,0 +1,104 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// cancelledMessage is the error recorded for a cancelled build.
+const cancelledMessage = "cancelled by user"
+
+// ErrBuildCreating is returned when cancelling a build that is already
+// creating its AMI without force.
+var ErrBuildCreating = errors.New("build is already creating its AMI")
+
+// CancelBuild stops an in-progress build: it terminates the build instance,
+// deregisters any AMI the build has started creating along with its
+// snapshots, and marks the build failed as cancelled by the user. It returns
+// false without changing anything if the build has already finished. A build
+// in BuildStatusCreating is only cancelled with force, since its AMI may be
+// nearly ready; otherwise ErrBuildCreating is returned.
+func (b *Builder) CancelBuild(ctx context.Context, buildID string, force bool) (bool, error) {
+	return cancelBuild(ctx, b.ec2Client, b.stateManager, buildID, force)
+}
+
+func cancelBuild(ctx context.Context, client buildCleanupAPI, sm *StateManager, buildID string, force bool) (bool, error) {
+	state, err := sm.LoadState(buildID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load build state: %w", err)
+	}
+
+	switch state.Status {
+	case BuildStatusComplete, BuildStatusFailed:
+		return false, nil
+	case BuildStatusCreating:
+		if !force {
+			return false, ErrBuildCreating
+		}
+	}
+
+	var partialAMI string
+	if state.Status == BuildStatusCreating {
+		partialAMI = state.AMIID
+		// Builds from before the AMI ID was recorded early, or stopped
+		// between CreateImage and saving state, are found by name
+		if partialAMI == "" {
+			if partialAMI, err = findPendingAMI(ctx, client, state.AMIName); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// State is only updated once cleanup succeeds, so a failed cancel can be retried
+	if err := cleanupBuildResources(ctx, client, state.InstanceID, partialAMI); err != nil {
+		return false, err
+	}
+
+	if err := sm.MarkFailedWithCategory(buildID, FailureCategoryCancelled, cancelledMessage); err != nil {
+		return true, fmt.Errorf("failed to update build state: %w", err)
+	}
+	return true, nil
+}
+
+// findPendingAMI returns the ID of the account's pending AMI with the given
+// name, or "" if there is none. AMI names are unique per account and region,
+// so this is the AMI a build in BuildStatusCreating registered.
+func findPendingAMI(ctx context.Context, client buildCleanupAPI, name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+
+	result, err := client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		Owners: []string{"self"},
+		Filters: []types.Filter{
+			{Name: aws.String("name"), Values: []string{name}},
+			{Name: aws.String("state"), Values: []string{string(types.ImageStatePending)}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to find AMI %q: %w", name, err)
+	}
+	if len(result.Images) == 0 {
+		return "", nil
+	}
+	return aws.ToString(result.Images[0].ImageId), nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newCancelTestState(t *testing.T, status BuildStatus, amiID string) (*StateManager, *BuildState) {
+	t.Helper()
+	sm := &StateManager{stateDir: t.TempDir()}
+	state := sm.NewBuildState("bio", "bio-v1", "us-east-1", 3)
+	state.InstanceID = "i-123"
+	state.Status = status
+	state.AMIID = amiID
+	if err := sm.SaveState(state); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	return sm, state
+}
+
+func TestCancelBuildInstalling(t *testing.T) {
+	sm, state := newCancelTestState(t, BuildStatusInstalling, "")
+	client := &fakeCleanupEC2{}
+
+	cancelled, err := cancelBuild(context.Background(), client, sm, state.BuildID, false)
+	if err != nil || !cancelled {
+		t.Fatalf("cancelBuild() = %v, %v, want cancelled", cancelled, err)
+	}
+	if want := []string{"terminate i-123"}; !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+
+	got, err := sm.LoadState(state.BuildID)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if got.Status != BuildStatusFailed || got.ErrorMessage != "cancelled by user" || got.FailureCategory != FailureCategoryCancelled || got.EndTime == nil {
+		t.Errorf("state after cancel = %+v", got)
+	}
+}
+
+func TestCancelBuildFinishedIsNoOp(t *testing.T) {
+	for _, status := range []BuildStatus{BuildStatusComplete, BuildStatusFailed} {
+		sm, state := newCancelTestState(t, status, "ami-done")
+		client := &fakeCleanupEC2{}
+
+		cancelled, err := cancelBuild(context.Background(), client, sm, state.BuildID, true)
+		if err != nil || cancelled {
+			t.Errorf("%s: cancelBuild() = %v, %v, want no-op", status, cancelled, err)
+		}
+		if len(client.calls) != 0 {
+			t.Errorf("%s: unexpected calls %v", status, client.calls)
+		}
+		if got, _ := sm.LoadState(state.BuildID); got.Status != status {
+			t.Errorf("%s: status changed to %s", status, got.Status)
+		}
+	}
+}
+
+func TestCancelBuildCreatingRequiresForce(t *testing.T) {
+	sm, state := newCancelTestState(t, BuildStatusCreating, "ami-456")
+	client := &fakeCleanupEC2{snapshots: []string{"snap-1"}}
+
+	if _, err := cancelBuild(context.Background(), client, sm, state.BuildID, false); !errors.Is(err, ErrBuildCreating) {
+		t.Fatalf("cancelBuild() error = %v, want ErrBuildCreating", err)
+	}
+	if len(client.calls) != 0 {
+		t.Fatalf("unexpected calls without --force: %v", client.calls)
+	}
+
+	cancelled, err := cancelBuild(context.Background(), client, sm, state.BuildID, true)
+	if err != nil || !cancelled {
+		t.Fatalf("cancelBuild(force) = %v, %v, want cancelled", cancelled, err)
+	}
+	want := []string{"terminate i-123", "describe ami-456", "deregister ami-456", "delete snap-1"}
+	if !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+}
+
+func TestCancelBuildCreatingFindsPendingAMI(t *testing.T) {
+	sm, state := newCancelTestState(t, BuildStatusCreating, "")
+	client := &fakeCleanupEC2{pendingAMI: "ami-789"}
+
+	if _, err := cancelBuild(context.Background(), client, sm, state.BuildID, true); err != nil {
+		t.Fatalf("cancelBuild() error = %v", err)
+	}
+	want := []string{"find bio-v1", "terminate i-123", "describe ami-789", "deregister ami-789"}
+	if !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+}
+
+func TestCancelBuildCleanupFailureKeepsState(t *testing.T) {
+	sm, state := newCancelTestState(t, BuildStatusInstalling, "")
+	client := &fakeCleanupEC2{terminateErr: errors.New("boom")}
+
+	if _, err := cancelBuild(context.Background(), client, sm, state.BuildID, false); err == nil {
+		t.Fatal("cancelBuild() error = nil, want terminate failure")
+	}
+	if got, _ := sm.LoadState(state.BuildID); got.Status != BuildStatusInstalling {
+		t.Errorf("status = %s, want build left installing so cancel can be retried", got.Status)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// TagCopiedFrom records the region and ID of the AMI a copy was made from,
+// e.g. "us-east-1/ami-0123456789abcdef0".
+const TagCopiedFrom = "CopiedFrom"
+
+// copyWaitTimeout bounds how long CopyAMI waits for the copy to become
+// available; copies of large AMIs between distant regions can take a while.
+const copyWaitTimeout = 90 * time.Minute
+
+// CopyAMI copies a pctl AMI to destRegion, waits until the copy is
+// available, and returns its ID.
+func (m *Manager) CopyAMI(ctx context.Context, sourceAMI, destRegion string) (string, error) {
+	amiID, err := m.StartCopyAMI(ctx, sourceAMI, destRegion)
+	if err != nil {
+		return "", err
+	}
+	if err := m.WaitForCopy(ctx, amiID, destRegion); err != nil {
+		return amiID, err
+	}
+	return amiID, nil
+}
+
+// StartCopyAMI starts copying a pctl AMI to destRegion and returns the ID of
+// the copy without waiting for it to become available. The copy keeps the
+// source AMI's name, description, and tags.
+func (m *Manager) StartCopyAMI(ctx context.Context, sourceAMI, destRegion string) (string, error) {
+	if destRegion == m.builder.region {
+		return "", fmt.Errorf("AMI %s is already in %s", sourceAMI, destRegion)
+	}
+
+	result, err := m.builder.ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{sourceAMI},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe AMI: %w", err)
+	}
+	if len(result.Images) == 0 {
+		return "", fmt.Errorf("AMI %s not found", sourceAMI)
+	}
+	img := result.Images[0]
+	if img.State != types.ImageStateAvailable {
+		return "", fmt.Errorf("AMI %s is %s; only available AMIs can be copied", sourceAMI, img.State)
+	}
+
+	copied, err := m.regionClient(destRegion).CopyImage(ctx, &ec2.CopyImageInput{
+		Name:          img.Name,
+		Description:   img.Description,
+		SourceImageId: aws.String(sourceAMI),
+		SourceRegion:  aws.String(m.builder.region),
+		TagSpecifications: tagSpecifications(copiedImageTags(img, m.builder.region),
+			types.ResourceTypeImage, types.ResourceTypeSnapshot),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to copy AMI to %s: %w", destRegion, err)
+	}
+
+	return aws.ToString(copied.ImageId), nil
+}
+
+// WaitForCopy waits for an AMI copy in region to become available.
+func (m *Manager) WaitForCopy(ctx context.Context, amiID, region string) error {
+	waiter := ec2.NewImageAvailableWaiter(m.regionClient(region))
+	err := waiter.Wait(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{amiID},
+	}, copyWaitTimeout)
+	if err != nil {
+		return fmt.Errorf("failed waiting for AMI %s in %s: %w", amiID, region, err)
+	}
+	return nil
+}
+
+// regionClient returns an EC2 client for region using the manager's
+// credentials.
+func (m *Manager) regionClient(region string) *ec2.Client {
+	return ec2.NewFromConfig(m.builder.awsConfig, func(o *ec2.Options) {
+		o.Region = region
+	})
+}
+
+// copiedImageTags returns the tags for a copy of img: the source AMI's tags,
+// including ManagedBy and TemplateName so pctl still recognizes the copy,
+// plus TagCopiedFrom. Tags with the reserved aws: prefix are dropped.
+func copiedImageTags(img types.Image, sourceRegion string) []types.Tag {
+	var tags []types.Tag
+	for _, tag := range img.Tags {
+		key := aws.ToString(tag.Key)
+		if key == "" || key == TagCopiedFrom || strings.HasPrefix(key, "aws:") {
+			continue
+		}
+		tags = append(tags, types.Tag{Key: tag.Key, Value: tag.Value})
+	}
+	tags = append(tags, types.Tag{
+		Key:   aws.String(TagCopiedFrom),
+		Value: aws.String(sourceRegion + "/" + aws.ToString(img.ImageId)),
+	})
+	return tags
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestCopiedImageTags(t *testing.T) {
+	img := types.Image{
+		ImageId: aws.String("ami-0123456789abcdef0"),
+		Tags: []types.Tag{
+			{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
+			{Key: aws.String(TagTemplateName), Value: aws.String("bioinformatics")},
+			{Key: aws.String("project"), Value: aws.String("genomics")},
+			{Key: aws.String("aws:cloudformation:stack-name"), Value: aws.String("stack")},
+			{Key: aws.String(TagCopiedFrom), Value: aws.String("us-west-2/ami-0fedcba9876543210")},
+		},
+	}
+
+	got := make(map[string]string)
+	for _, tag := range copiedImageTags(img, "us-east-1") {
+		got[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	want := map[string]string{
+		"ManagedBy":     "pctl",
+		TagTemplateName: "bioinformatics",
+		"project":       "genomics",
+		TagCopiedFrom:   "us-east-1/ami-0123456789abcdef0",
+	}
+	if len(got) != len(want) {
+		t.Errorf("copiedImageTags() = %v, want %v", got, want)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("tag %s = %q, want %q", key, got[key], value)
+		}
+	}
+}
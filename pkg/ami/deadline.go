@@ -0,0 +1,78 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// buildCleanupAPI is the subset of the EC2 API used to clean up after a build.
+type buildCleanupAPI interface {
+	TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error)
+	DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error)
+	DeregisterImage(ctx context.Context, params *ec2.DeregisterImageInput, optFns ...func(*ec2.Options)) (*ec2.DeregisterImageOutput, error)
+	DeleteSnapshot(ctx context.Context, params *ec2.DeleteSnapshotInput, optFns ...func(*ec2.Options)) (*ec2.DeleteSnapshotOutput, error)
+}
+
+// cleanupBuildResources terminates a build instance and, if partialAMI is
+// set, deregisters that AMI and deletes its snapshots. It runs even if ctx
+// has been canceled or has passed its deadline, since that is usually why the
+// build is being cleaned up.
+func cleanupBuildResources(ctx context.Context, client buildCleanupAPI, instanceID, partialAMI string) error {
+	ctx = context.WithoutCancel(ctx)
+	var errs []error
+
+	if instanceID != "" {
+		if _, err := client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+			InstanceIds: []string{instanceID},
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to terminate instance %s: %w", instanceID, err))
+		}
+	}
+
+	if partialAMI != "" {
+		// Snapshots may not be listed yet; collect whatever exists
+		var snapshotIDs []string
+		if result, err := client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+			ImageIds: []string{partialAMI},
+		}); err == nil && len(result.Images) > 0 {
+			for _, bdm := range result.Images[0].BlockDeviceMappings {
+				if bdm.Ebs != nil && bdm.Ebs.SnapshotId != nil {
+					snapshotIDs = append(snapshotIDs, *bdm.Ebs.SnapshotId)
+				}
+			}
+		}
+
+		if _, err := client.DeregisterImage(ctx, &ec2.DeregisterImageInput{
+			ImageId: aws.String(partialAMI),
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to deregister AMI %s: %w", partialAMI, err))
+		}
+		for _, snapshotID := range snapshotIDs {
+			if _, err := client.DeleteSnapshot(ctx, &ec2.DeleteSnapshotInput{
+				SnapshotId: aws.String(snapshotID),
+			}); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete snapshot %s: %w", snapshotID, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// fakeCleanupEC2 records cleanup calls and fails them if made with a
+// context that is already done.
+type fakeCleanupEC2 struct {
+	calls        []string
+	snapshots    []string
+	terminateErr error
+	// pendingAMI is returned by name lookups of pending AMIs
+	pendingAMI string
+}
+
+func (f *fakeCleanupEC2) record(ctx context.Context, call string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	f.calls = append(f.calls, call)
+	return nil
+}
+
+func (f *fakeCleanupEC2) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, _ ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
+	if err := f.record(ctx, "terminate "+params.InstanceIds[0]); err != nil {
+		return nil, err
+	}
+	return &ec2.TerminateInstancesOutput{}, f.terminateErr
+}
+
+func (f *fakeCleanupEC2) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, _ ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	if len(params.ImageIds) == 0 {
+		if err := f.record(ctx, "find "+params.Filters[0].Values[0]); err != nil {
+			return nil, err
+		}
+		if f.pendingAMI == "" {
+			return &ec2.DescribeImagesOutput{}, nil
+		}
+		return &ec2.DescribeImagesOutput{Images: []types.Image{{ImageId: aws.String(f.pendingAMI)}}}, nil
+	}
+	if err := f.record(ctx, "describe "+params.ImageIds[0]); err != nil {
+		return nil, err
+	}
+	var mappings []types.BlockDeviceMapping
+	for _, id := range f.snapshots {
+		mappings = append(mappings, types.BlockDeviceMapping{Ebs: &types.EbsBlockDevice{SnapshotId: aws.String(id)}})
+	}
+	return &ec2.DescribeImagesOutput{Images: []types.Image{{BlockDeviceMappings: mappings}}}, nil
+}
+
+func (f *fakeCleanupEC2) DeregisterImage(ctx context.Context, params *ec2.DeregisterImageInput, _ ...func(*ec2.Options)) (*ec2.DeregisterImageOutput, error) {
+	if err := f.record(ctx, "deregister "+aws.ToString(params.ImageId)); err != nil {
+		return nil, err
+	}
+	return &ec2.DeregisterImageOutput{}, nil
+}
+
+func (f *fakeCleanupEC2) DeleteSnapshot(ctx context.Context, params *ec2.DeleteSnapshotInput, _ ...func(*ec2.Options)) (*ec2.DeleteSnapshotOutput, error) {
+	if err := f.record(ctx, "delete "+aws.ToString(params.SnapshotId)); err != nil {
+		return nil, err
+	}
+	return &ec2.DeleteSnapshotOutput{}, nil
+}
+
+func TestCleanupBuildResourcesAfterDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Fatalf("ctx.Err() = %v, want deadline exceeded", ctx.Err())
+	}
+
+	client := &fakeCleanupEC2{snapshots: []string{"snap-1", "snap-2"}}
+	if err := cleanupBuildResources(ctx, client, "i-123", "ami-456"); err != nil {
+		t.Fatalf("cleanupBuildResources() error = %v", err)
+	}
+
+	want := []string{"terminate i-123", "describe ami-456", "deregister ami-456", "delete snap-1", "delete snap-2"}
+	if !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+}
+
+func TestCleanupBuildResourcesInstanceOnly(t *testing.T) {
+	client := &fakeCleanupEC2{}
+	if err := cleanupBuildResources(context.Background(), client, "i-123", ""); err != nil {
+		t.Fatalf("cleanupBuildResources() error = %v", err)
+	}
+	if want := []string{"terminate i-123"}; !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+}
+
+func TestCleanupBuildResourcesContinuesAfterError(t *testing.T) {
+	client := &fakeCleanupEC2{terminateErr: errors.New("boom")}
+	err := cleanupBuildResources(context.Background(), client, "i-123", "ami-456")
+	if err == nil {
+		t.Fatal("cleanupBuildResources() error = nil, want terminate failure")
+	}
+	if want := []string{"terminate i-123", "describe ami-456", "deregister ami-456"}; !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+}
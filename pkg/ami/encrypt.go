@@ -0,0 +1,98 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// kmsKeyIDPattern matches the ways EC2 accepts a KMS key: a key ID, a
+// multi-Region key ID, an alias, or a key or alias ARN.
+var kmsKeyIDPattern = regexp.MustCompile(`^([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|mrk-[0-9a-f]{32}|alias/[a-zA-Z0-9/_-]+|arn:aws[a-z-]*:kms:[a-z0-9-]+:\d{12}:(key/[a-zA-Z0-9-]+|alias/[a-zA-Z0-9/_-]+))$`)
+
+// ValidateKMSKeyID checks that id names a KMS key in a form EC2 accepts.
+func ValidateKMSKeyID(id string) error {
+	if !kmsKeyIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid KMS key %q: must be a key ID, alias/<name>, or key or alias ARN", id)
+	}
+	return nil
+}
+
+// encryptedBlockDeviceMappings overrides the EBS volumes of a base AMI so
+// the build instance's volumes, and therefore the AMI's snapshots, are
+// encrypted. An empty kmsKeyID uses the account's default EBS key.
+func encryptedBlockDeviceMappings(img types.Image, kmsKeyID string) []types.BlockDeviceMapping {
+	var mappings []types.BlockDeviceMapping
+	for _, bdm := range img.BlockDeviceMappings {
+		if bdm.Ebs == nil {
+			continue
+		}
+		ebs := &types.EbsBlockDevice{Encrypted: aws.Bool(true)}
+		if kmsKeyID != "" {
+			ebs.KmsKeyId = aws.String(kmsKeyID)
+		}
+		mappings = append(mappings, types.BlockDeviceMapping{
+			DeviceName: bdm.DeviceName,
+			Ebs:        ebs,
+		})
+	}
+	return mappings
+}
+
+// unencryptedSnapshots returns the IDs of img's EBS snapshots that are not
+// encrypted.
+func unencryptedSnapshots(img types.Image) []string {
+	var unencrypted []string
+	for _, bdm := range img.BlockDeviceMappings {
+		if bdm.Ebs != nil && !aws.ToBool(bdm.Ebs.Encrypted) {
+			unencrypted = append(unencrypted, aws.ToString(bdm.Ebs.SnapshotId))
+		}
+	}
+	return unencrypted
+}
+
+// describeImage returns the image with the given ID.
+func (b *Builder) describeImage(ctx context.Context, amiID string) (types.Image, error) {
+	result, err := b.ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{amiID},
+	})
+	if err != nil {
+		return types.Image{}, fmt.Errorf("failed to describe AMI: %w", err)
+	}
+	if len(result.Images) == 0 {
+		return types.Image{}, fmt.Errorf("AMI %s not found", amiID)
+	}
+	return result.Images[0], nil
+}
+
+// verifySnapshotsEncrypted returns an error if any of the AMI's snapshots
+// is unencrypted.
+func (b *Builder) verifySnapshotsEncrypted(ctx context.Context, amiID string) error {
+	img, err := b.describeImage(ctx, amiID)
+	if err != nil {
+		return err
+	}
+	if unencrypted := unencryptedSnapshots(img); len(unencrypted) > 0 {
+		return fmt.Errorf("AMI %s has unencrypted snapshots: %s", amiID, strings.Join(unencrypted, ", "))
+	}
+	return nil
+}
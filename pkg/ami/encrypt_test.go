@@ -0,0 +1,87 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestValidateKMSKeyID(t *testing.T) {
+	valid := []string{
+		"1234abcd-12ab-34cd-56ef-1234567890ab",
+		"mrk-1234abcd12ab34cd56ef1234567890ab",
+		"alias/ami-snapshots",
+		"arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		"arn:aws-us-gov:kms:us-gov-west-1:123456789012:alias/ami-snapshots",
+	}
+	for _, id := range valid {
+		if err := ValidateKMSKeyID(id); err != nil {
+			t.Errorf("ValidateKMSKeyID(%q) error = %v", id, err)
+		}
+	}
+
+	invalid := []string{"", "ami-snapshots", "alias/", "1234abcd", "arn:aws:iam::123456789012:role/r", "alias/my key"}
+	for _, id := range invalid {
+		if err := ValidateKMSKeyID(id); err == nil {
+			t.Errorf("ValidateKMSKeyID(%q) expected error", id)
+		}
+	}
+}
+
+func TestEncryptedBlockDeviceMappings(t *testing.T) {
+	base := types.Image{
+		BlockDeviceMappings: []types.BlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &types.EbsBlockDevice{SnapshotId: aws.String("snap-root"), VolumeSize: aws.Int32(40)}},
+			{DeviceName: aws.String("/dev/sdb"), VirtualName: aws.String("ephemeral0")},
+		},
+	}
+
+	mappings := encryptedBlockDeviceMappings(base, "alias/ami-snapshots")
+	if len(mappings) != 1 {
+		t.Fatalf("expected 1 mapping for the EBS root volume, got %d", len(mappings))
+	}
+	root := mappings[0]
+	if aws.ToString(root.DeviceName) != "/dev/xvda" || !aws.ToBool(root.Ebs.Encrypted) || aws.ToString(root.Ebs.KmsKeyId) != "alias/ami-snapshots" {
+		t.Errorf("root mapping = %s encrypted=%v key=%q", aws.ToString(root.DeviceName), aws.ToBool(root.Ebs.Encrypted), aws.ToString(root.Ebs.KmsKeyId))
+	}
+	// The snapshot and size are inherited from the base AMI
+	if root.Ebs.SnapshotId != nil || root.Ebs.VolumeSize != nil {
+		t.Error("mapping should only override encryption")
+	}
+
+	if defaultKey := encryptedBlockDeviceMappings(base, ""); defaultKey[0].Ebs.KmsKeyId != nil {
+		t.Error("no KMS key should be set when using the default key")
+	}
+}
+
+func TestUnencryptedSnapshots(t *testing.T) {
+	img := types.Image{
+		BlockDeviceMappings: []types.BlockDeviceMapping{
+			{Ebs: &types.EbsBlockDevice{SnapshotId: aws.String("snap-root"), Encrypted: aws.Bool(true)}},
+			{Ebs: &types.EbsBlockDevice{SnapshotId: aws.String("snap-data"), Encrypted: aws.Bool(false)}},
+			{Ebs: &types.EbsBlockDevice{SnapshotId: aws.String("snap-scratch")}},
+			{VirtualName: aws.String("ephemeral0")},
+		},
+	}
+
+	want := []string{"snap-data", "snap-scratch"}
+	if got := unencryptedSnapshots(img); !reflect.DeepEqual(got, want) {
+		t.Errorf("unencryptedSnapshots() = %v, want %v", got, want)
+	}
+}
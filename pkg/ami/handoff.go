@@ -0,0 +1,102 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// errBuildAborted is the cancellation cause of a detached build that was
+// signaled before it was handed off.
+var errBuildAborted = errors.New("build aborted")
+
+// detachHandoff guards the synchronous part of a detached build. Until
+// HandOff is called, SIGTERM or an interrupt cancels the build's context, so
+// the build's deferred cleanup terminates the instance and marks the build
+// failed. After HandOff the instance is meant to keep running in AWS, so
+// signals are ignored until Stop.
+type detachHandoff struct {
+	signals chan os.Signal
+	cancel  context.CancelCauseFunc
+	done    chan struct{}
+
+	mu        sync.Mutex
+	handedOff bool
+	aborted   bool
+}
+
+// startDetachHandoff begins intercepting SIGTERM and interrupts for a
+// detached build.
+func startDetachHandoff(ctx context.Context) (context.Context, *detachHandoff) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	return watchDetachHandoff(ctx, signals)
+}
+
+// watchDetachHandoff returns a context that is canceled with errBuildAborted
+// if a signal arrives on signals before the handoff.
+func watchDetachHandoff(ctx context.Context, signals chan os.Signal) (context.Context, *detachHandoff) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	h := &detachHandoff{
+		signals: signals,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				h.mu.Lock()
+				if !h.handedOff && !h.aborted {
+					h.aborted = true
+					fmt.Printf("\n🛑 Received %v before the build was handed off, aborting...\n", sig)
+					cancel(fmt.Errorf("%w: received %v before the detached build was handed off", errBuildAborted, sig))
+				}
+				h.mu.Unlock()
+			case <-h.done:
+				return
+			}
+		}
+	}()
+
+	return ctx, h
+}
+
+// HandOff marks the point after which the build runs on its own in AWS. It
+// returns false if the build was already aborted, in which case the caller
+// must clean up as for any other failure.
+func (h *detachHandoff) HandOff() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.aborted {
+		return false
+	}
+	h.handedOff = true
+	return true
+}
+
+// Stop stops intercepting signals and releases the build's context.
+func (h *detachHandoff) Stop() {
+	signal.Stop(h.signals)
+	close(h.done)
+	h.cancel(nil)
+}
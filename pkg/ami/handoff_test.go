@@ -0,0 +1,95 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDetachHandoffSignalBeforeHandoff(t *testing.T) {
+	signals := make(chan os.Signal, 1)
+	ctx, handoff := watchDetachHandoff(context.Background(), signals)
+	defer handoff.Stop()
+
+	signals <- syscall.SIGTERM
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGTERM before the handoff did not cancel the build")
+	}
+	if cause := context.Cause(ctx); !errors.Is(cause, errBuildAborted) {
+		t.Errorf("context.Cause() = %v, want errBuildAborted", cause)
+	}
+	if handoff.HandOff() {
+		t.Error("HandOff() = true after the build was aborted, want false")
+	}
+}
+
+func TestDetachHandoffSignalRemovesStagedFiles(t *testing.T) {
+	signals := make(chan os.Signal, 1)
+	ctx, handoff := watchDetachHandoff(context.Background(), signals)
+	defer handoff.Stop()
+
+	signals <- os.Interrupt
+	<-ctx.Done()
+	if handoff.HandOff() {
+		t.Fatal("HandOff() = true after the build was aborted, want false")
+	}
+
+	// The aborted build cleans up with its canceled context; staged license
+	// files must still be deleted from S3
+	client := &fakeStagedFileS3{}
+	staged := []string{"s3://bucket/ami-builds/b1/license-0-intel.lic"}
+	removeStagedFiles(ctx, client, staged)
+	if len(client.deleted) != 1 || client.deleted[0] != staged[0] {
+		t.Errorf("deleted = %v, want %v", client.deleted, staged)
+	}
+}
+
+func TestDetachHandoffSignalAfterHandoff(t *testing.T) {
+	signals := make(chan os.Signal)
+	ctx, handoff := watchDetachHandoff(context.Background(), signals)
+	defer handoff.Stop()
+
+	if !handoff.HandOff() {
+		t.Fatal("HandOff() = false, want true")
+	}
+
+	// The unbuffered send returns once the watcher has received the signal;
+	// the second send waits until the first has been handled
+	signals <- syscall.SIGTERM
+	signals <- os.Interrupt
+	if err := ctx.Err(); err != nil {
+		t.Errorf("signal after the handoff canceled the build: %v", err)
+	}
+}
+
+func TestDetachHandoffStop(t *testing.T) {
+	ctx, handoff := watchDetachHandoff(context.Background(), make(chan os.Signal, 1))
+	handoff.HandOff()
+	handoff.Stop()
+
+	if ctx.Err() == nil {
+		t.Error("Stop() did not release the build's context")
+	}
+	if errors.Is(context.Cause(ctx), errBuildAborted) {
+		t.Error("Stop() after the handoff should not look like an abort")
+	}
+}
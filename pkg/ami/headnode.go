@@ -0,0 +1,218 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/google/uuid"
+)
+
+// TagSourceCluster is the cluster whose head node an AMI was snapshotted from.
+const TagSourceCluster = "pctl:source-cluster"
+
+// ParallelCluster tags identifying a cluster's instances.
+const (
+	tagPClusterName     = "parallelcluster:cluster-name"
+	tagPClusterNodeType = "parallelcluster:node-type"
+	tagPClusterVersion  = "parallelcluster:version"
+)
+
+// headNode is a cluster's head node instance as described by EC2.
+type headNode struct {
+	instance types.Instance
+	tags     map[string]string
+}
+
+// stopped reports whether the head node is stopped.
+func (h *headNode) stopped() bool {
+	return h.instance.State != nil && h.instance.State.Name == types.InstanceStateNameStopped
+}
+
+// describeHeadNode checks that instanceID is the head node of clusterName,
+// running or stopped, so an AMI is never taken from the wrong instance.
+func describeHeadNode(ctx context.Context, client snapshotAPI, clusterName, instanceID string) (*headNode, error) {
+	result, err := client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instance %s: %w", instanceID, err)
+	}
+	if len(result.Reservations) == 0 || len(result.Reservations[0].Instances) == 0 {
+		return nil, fmt.Errorf("instance %s not found", instanceID)
+	}
+	instance := result.Reservations[0].Instances[0]
+
+	tags := make(map[string]string)
+	for _, tag := range instance.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	if tags[tagPClusterName] != clusterName || tags[tagPClusterNodeType] != "HeadNode" {
+		return nil, fmt.Errorf("instance %s is not the head node of cluster %s", instanceID, clusterName)
+	}
+
+	state := "unknown"
+	if instance.State != nil {
+		state = string(instance.State.Name)
+	}
+	if state != string(types.InstanceStateNameRunning) && state != string(types.InstanceStateNameStopped) {
+		return nil, fmt.Errorf("head node %s is %s; it must be running or stopped to be snapshotted", instanceID, state)
+	}
+
+	return &headNode{instance: instance, tags: tags}, nil
+}
+
+// createHeadNodeImage starts creating an AMI named opts.Name from a cluster's
+// head node. A running head node is imaged without a reboot, so the cluster
+// stays up and the AMI is crash-consistent. The returned state is a build of
+// its own with the new AMI's ID.
+//
+// The head node is imaged as it is, since cleaning it would break the live
+// cluster, so the AMI keeps the cluster's configuration and identity. It is
+// tagged only with its source cluster, not as built from a template, and
+// pctl refuses to create clusters from it. Only the root volume is imaged;
+// the cluster's shared and /home volumes are left out.
+func createHeadNodeImage(ctx context.Context, client buildInstanceAPI, node *headNode, clusterName, region string, opts *BuildOptions) (*BuildState, error) {
+	instanceID := aws.ToString(node.instance.InstanceId)
+	buildState := &BuildState{
+		BuildID:                uuid.New().String(),
+		InstanceID:             instanceID,
+		Status:                 BuildStatusCreating,
+		StartTime:              time.Now(),
+		AMIName:                opts.Name,
+		Region:                 region,
+		BaseAMI:                aws.ToString(node.instance.ImageId),
+		ParallelClusterVersion: node.tags[tagPClusterVersion],
+	}
+
+	input := createImageInput(instanceID, "", map[string]string{TagSourceCluster: clusterName}, opts, buildState)
+	input.NoReboot = aws.Bool(!node.stopped())
+	input.BlockDeviceMappings = rootVolumeOnly(node.instance)
+	image, err := createImageWithRetry(ctx, client, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AMI from head node %s: %w", instanceID, err)
+	}
+	buildState.AMIID = aws.ToString(image.ImageId)
+
+	return buildState, nil
+}
+
+// rootVolumeOnly returns block device mappings that exclude every volume
+// attached to instance except its root volume.
+func rootVolumeOnly(instance types.Instance) []types.BlockDeviceMapping {
+	rootDevice := aws.ToString(instance.RootDeviceName)
+	var mappings []types.BlockDeviceMapping
+	for _, mapping := range instance.BlockDeviceMappings {
+		device := aws.ToString(mapping.DeviceName)
+		if device == "" || device == rootDevice {
+			continue
+		}
+		mappings = append(mappings, types.BlockDeviceMapping{
+			DeviceName: aws.String(device),
+			NoDevice:   aws.String(""),
+		})
+	}
+	return mappings
+}
+
+// SnapshotHeadNode creates an AMI named opts.Name from the head node of a
+// running cluster, capturing changes made on it by hand. With stop, the head
+// node is stopped first for a consistent filesystem and started again once
+// the AMI's snapshots are initiated, whether or not that succeeded.
+func (b *Builder) SnapshotHeadNode(ctx context.Context, clusterName, instanceID string, stop bool, opts *BuildOptions) (*AMIMetadata, error) {
+	node, err := describeHeadNode(ctx, b.ec2Client, clusterName, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The snapshots are point-in-time once CreateImage returns, so a head
+	// node stopped here can be started again before the AMI is available
+	restart := func() {}
+	if stop && !node.stopped() {
+		fmt.Printf("⏸️  Stopping head node %s...\n", instanceID)
+		if err := b.stopInstance(ctx, instanceID); err != nil {
+			return nil, fmt.Errorf("failed to stop head node %s: %w", instanceID, err)
+		}
+		node.instance.State = &types.InstanceState{Name: types.InstanceStateNameStopped}
+		fmt.Printf("   ✅ Head node stopped\n\n")
+
+		restart = func() {
+			fmt.Printf("▶️  Starting head node %s...\n", instanceID)
+			if err := b.startInstance(ctx, instanceID); err != nil {
+				fmt.Printf("⚠️  Warning: failed to start head node %s: %v\n", instanceID, err)
+				fmt.Printf("   Start it with: aws ec2 start-instances --instance-ids %s --region %s\n\n", instanceID, b.region)
+				return
+			}
+			fmt.Printf("   ✅ Head node running\n\n")
+		}
+	}
+
+	fmt.Printf("📸 Creating AMI %s from the head node of %s (%s)...\n", opts.Name, clusterName, instanceID)
+	buildState, err := createHeadNodeImage(ctx, b.ec2Client, node, clusterName, b.region, opts)
+	restart()
+	if err != nil {
+		return nil, err
+	}
+	if err := b.stateManager.SaveState(buildState); err != nil {
+		return nil, fmt.Errorf("failed to save build state: %w", err)
+	}
+	fmt.Printf("   Build ID: %s\n", buildState.BuildID)
+	fmt.Printf("   ✅ AMI created: %s\n\n", buildState.AMIID)
+
+	fmt.Printf("⏳ Waiting for AMI to be available...\n")
+	if err := b.waitForAMIAvailable(ctx, buildState.AMIID); err != nil {
+		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("AMI failed to become available: %v", err))
+		return nil, fmt.Errorf("AMI failed to become available: %w", err)
+	}
+	fmt.Printf("   ✅ AMI is available\n\n")
+
+	if err := b.stateManager.MarkComplete(buildState.BuildID, buildState.AMIID); err != nil {
+		fmt.Printf("⚠️  Warning: Failed to update build state: %v\n", err)
+	}
+
+	return &AMIMetadata{
+		AMIID:                  buildState.AMIID,
+		Name:                   opts.Name,
+		Description:            opts.Description,
+		Region:                 b.region,
+		CreatedAt:              time.Now(),
+		Tags:                   opts.Tags,
+		BaseAMI:                buildState.BaseAMI,
+		ParallelClusterVersion: buildState.ParallelClusterVersion,
+		BuildID:                buildState.BuildID,
+		Status:                 BuildStatusComplete,
+		DurationSeconds:        int64(time.Since(buildState.StartTime).Seconds()),
+	}, nil
+}
+
+// startInstance starts a stopped instance and waits for it to run.
+func (b *Builder) startInstance(ctx context.Context, instanceID string) error {
+	_, err := b.ec2Client.StartInstances(ctx, &ec2.StartInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return err
+	}
+
+	waiter := ec2.NewInstanceRunningWaiter(b.ec2Client)
+	return waiter.Wait(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	}, 10*time.Minute)
+}
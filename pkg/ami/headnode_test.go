@@ -0,0 +1,161 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// clusterHeadNode returns the running head node of cluster "genomics" as
+// tagged by ParallelCluster.
+func clusterHeadNode() types.Instance {
+	tags := map[string]string{
+		"Name":                         "HeadNode",
+		"parallelcluster:cluster-name": "genomics",
+		"parallelcluster:node-type":    "HeadNode",
+		"parallelcluster:version":      "3.14.0",
+	}
+	return types.Instance{
+		InstanceId: aws.String("i-head"),
+		ImageId:    aws.String("ami-pcluster"),
+		State:      &types.InstanceState{Name: types.InstanceStateNameRunning},
+		Tags:       sortedTags(tags),
+		// The root volume, the shared EBS volume, and the persistent /home volume
+		RootDeviceName: aws.String("/dev/xvda"),
+		BlockDeviceMappings: []types.InstanceBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda")},
+			{DeviceName: aws.String("/dev/sdb")},
+			{DeviceName: aws.String("/dev/sdc")},
+		},
+	}
+}
+
+func TestDescribeHeadNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterName string
+		modify      func(instance *types.Instance)
+		wantErr     string
+	}{
+		{"running head node", "genomics", func(instance *types.Instance) {}, ""},
+		{"stopped head node", "genomics", func(instance *types.Instance) {
+			instance.State = &types.InstanceState{Name: types.InstanceStateNameStopped}
+		}, ""},
+		{"another cluster", "proteomics", func(instance *types.Instance) {}, "is not the head node of cluster proteomics"},
+		{"compute node", "genomics", func(instance *types.Instance) {
+			instance.Tags = sortedTags(map[string]string{
+				"parallelcluster:cluster-name": "genomics",
+				"parallelcluster:node-type":    "Compute",
+			})
+		}, "is not the head node of cluster genomics"},
+		{"stopping", "genomics", func(instance *types.Instance) {
+			instance.State = &types.InstanceState{Name: types.InstanceStateNameStopping}
+		}, "is stopping; it must be running or stopped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := clusterHeadNode()
+			tt.modify(&instance)
+			client := &fakeSnapshotEC2{instance: instance}
+
+			node, err := describeHeadNode(context.Background(), client, tt.clusterName, "i-head")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("describeHeadNode() error = %v", err)
+				}
+				if aws.ToString(node.instance.InstanceId) != "i-head" {
+					t.Errorf("describeHeadNode() instance = %s, want i-head", aws.ToString(node.instance.InstanceId))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("describeHeadNode() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateHeadNodeImage(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      types.InstanceStateName
+		wantReboot bool
+	}{
+		// A running head node must not be rebooted under the cluster
+		{"running", types.InstanceStateNameRunning, false},
+		{"stopped", types.InstanceStateNameStopped, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := clusterHeadNode()
+			instance.State = &types.InstanceState{Name: tt.state}
+			client := &fakeSnapshotEC2{instance: instance}
+			node, err := describeHeadNode(context.Background(), client, "genomics", "i-head")
+			if err != nil {
+				t.Fatalf("describeHeadNode() error = %v", err)
+			}
+
+			opts := &BuildOptions{Name: "genomics-head-v1", Tags: map[string]string{"stage": "test"}}
+			state, err := createHeadNodeImage(context.Background(), client, node, "genomics", "us-east-1", opts)
+			if err != nil {
+				t.Fatalf("createHeadNodeImage() error = %v", err)
+			}
+
+			if state.AMIID != "ami-built" || state.InstanceID != "i-head" || state.Status != BuildStatusCreating || state.BuildID == "" {
+				t.Errorf("unexpected state: %+v", state)
+			}
+			if aws.ToString(client.image.InstanceId) != "i-head" || aws.ToString(client.image.Name) != "genomics-head-v1" {
+				t.Errorf("CreateImage called with instance %s, name %s", aws.ToString(client.image.InstanceId), aws.ToString(client.image.Name))
+			}
+			if noReboot := aws.ToBool(client.image.NoReboot); noReboot == tt.wantReboot {
+				t.Errorf("CreateImage NoReboot = %v, want %v", noReboot, !tt.wantReboot)
+			}
+			var excluded []string
+			for _, mapping := range client.image.BlockDeviceMappings {
+				if mapping.NoDevice == nil {
+					t.Errorf("block device %s is imaged, want only the root volume", aws.ToString(mapping.DeviceName))
+				}
+				excluded = append(excluded, aws.ToString(mapping.DeviceName))
+			}
+			if want := []string{"/dev/sdb", "/dev/sdc"}; !reflect.DeepEqual(excluded, want) {
+				t.Errorf("excluded block devices = %v, want %v", excluded, want)
+			}
+
+			tags := imageTags(client.image)
+			for key, want := range map[string]string{
+				TagSourceCluster:          "genomics",
+				TagBaseAMI:                "ami-pcluster",
+				TagParallelClusterVersion: "3.14.0",
+				"stage":                   "test",
+			} {
+				if tags[key] != want {
+					t.Errorf("AMI tag %s = %q, want %q", key, tags[key], want)
+				}
+			}
+			// A head node snapshot is not built from a template
+			if name, ok := tags[TagTemplateName]; ok || state.TemplateName != "" {
+				t.Errorf("head node AMI recorded template %q (state %q)", name, state.TemplateName)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/scttfrdmn/petal/pkg/bootstrap"
+	"github.com/scttfrdmn/petal/pkg/software"
+)
+
+// licenseURLExpiry is how long the build instance can download staged license
+// files; they are fetched at the start of the build.
+const licenseURLExpiry = time.Hour
+
+// loadLicenseFiles loads src:dest[:sensitive] license file specifications,
+// allowing at most one file per destination.
+func loadLicenseFiles(specs []string) ([]*software.LicenseFile, error) {
+	var files []*software.LicenseFile
+	destinations := make(map[string]string)
+	for _, spec := range specs {
+		file, err := software.LoadLicenseFile(spec)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := destinations[file.Destination]; ok {
+			return nil, fmt.Errorf("license files %s and %s both go to %s", prev, file.Source, file.Destination)
+		}
+		destinations[file.Destination] = file.Source
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// stageLicenseFiles uploads license files too large to embed in the build
+// script to S3 and points them at presigned download URLs. It returns the S3
+// URIs of the uploaded objects so they can be deleted after the build.
+func (b *Builder) stageLicenseFiles(ctx context.Context, buildID string, files []*software.LicenseFile) ([]string, error) {
+	var s3Manager *bootstrap.S3Manager
+	var uploaded []string
+	for i, file := range files {
+		if file.Inline() {
+			continue
+		}
+
+		if s3Manager == nil {
+			var err error
+			s3Manager, err = bootstrap.NewS3Manager(ctx, b.region)
+			if err != nil {
+				return uploaded, fmt.Errorf("failed to create S3 manager: %w", err)
+			}
+		}
+
+		name := fmt.Sprintf("license-%d-%s", i, path.Base(file.Destination))
+		s3URI, err := s3Manager.UploadBuildFile(ctx, buildID, name, file.Content)
+		if err != nil {
+			return uploaded, fmt.Errorf("failed to upload license file %s: %w", file.Source, err)
+		}
+		uploaded = append(uploaded, s3URI)
+
+		url, err := s3Manager.PresignDownloadURL(ctx, s3URI, licenseURLExpiry)
+		if err != nil {
+			return uploaded, fmt.Errorf("failed to stage license file %s: %w", file.Source, err)
+		}
+		file.URL = url
+		fmt.Printf("   Staged license file %s via %s\n", file.Source, s3URI)
+	}
+	return uploaded, nil
+}
+
+// stagedFileAPI is the S3 operation used to delete staged build files.
+type stagedFileAPI interface {
+	DeleteBootstrapScript(ctx context.Context, s3URI string) error
+}
+
+// deleteStagedFiles removes license files and lockfiles staged in S3 for
+// the build.
+func (b *Builder) deleteStagedFiles(ctx context.Context, s3URIs []string) {
+	if len(s3URIs) == 0 {
+		return
+	}
+
+	s3Manager, err := bootstrap.NewS3Manager(context.WithoutCancel(ctx), b.region)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to delete staged build files: %v\n", err)
+		return
+	}
+	removeStagedFiles(ctx, s3Manager, s3URIs)
+}
+
+// removeStagedFiles deletes staged build files. It runs even if ctx has been
+// canceled or has passed its deadline, so sensitive license files are not
+// left in S3 by a build that was interrupted or ran out of time.
+func removeStagedFiles(ctx context.Context, client stagedFileAPI, s3URIs []string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, s3URI := range s3URIs {
+		if err := client.DeleteBootstrapScript(ctx, s3URI); err != nil {
+			fmt.Printf("⚠️  Warning: Failed to delete staged build file %s: %v\n", s3URI, err)
+		}
+	}
+}
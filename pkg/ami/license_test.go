@@ -0,0 +1,84 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeStagedFileS3 records deletions and fails them if made with a context
+// that is already done.
+type fakeStagedFileS3 struct {
+	deleted []string
+}
+
+func (f *fakeStagedFileS3) DeleteBootstrapScript(ctx context.Context, s3URI string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	f.deleted = append(f.deleted, s3URI)
+	return nil
+}
+
+func TestRemoveStagedFilesAfterDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Fatalf("ctx.Err() = %v, want deadline exceeded", ctx.Err())
+	}
+
+	client := &fakeStagedFileS3{}
+	staged := []string{"s3://bucket/ami-builds/b1/license-0-intel.lic", "s3://bucket/ami-builds/b1/spack.lock"}
+	removeStagedFiles(ctx, client, staged)
+	if !reflect.DeepEqual(client.deleted, staged) {
+		t.Errorf("deleted = %v, want %v", client.deleted, staged)
+	}
+}
+
+func TestLoadLicenseFilesDuplicateDestination(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.lic", "b.lic"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("license\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := loadLicenseFiles([]string{
+		filepath.Join(dir, "a.lic") + ":/opt/licenses/a.lic",
+		filepath.Join(dir, "b.lic") + ":/opt/licenses/b.lic:sensitive",
+	})
+	if err != nil {
+		t.Fatalf("loadLicenseFiles() error = %v", err)
+	}
+	if len(files) != 2 || !files[1].Sensitive {
+		t.Errorf("loadLicenseFiles() = %+v", files)
+	}
+
+	_, err = loadLicenseFiles([]string{
+		filepath.Join(dir, "a.lic") + ":/opt/licenses/license.lic",
+		filepath.Join(dir, "b.lic") + ":/opt/licenses/license.lic",
+	})
+	if err == nil || !strings.Contains(err.Error(), "both go to /opt/licenses/license.lic") {
+		t.Errorf("loadLicenseFiles() error = %v, want duplicate destination error", err)
+	}
+}
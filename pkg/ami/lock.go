@@ -0,0 +1,228 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxBuildLockAge is how long a build may hold its lock before the lock is
+// treated as stale, well beyond the 4 hour software installation timeout.
+const maxBuildLockAge = 24 * time.Hour
+
+// lockTakeoverTimeout is how long a takeover marker may exist before it is
+// assumed to be left behind by a crashed pctl and removed.
+const lockTakeoverTimeout = time.Minute
+
+// lockTakeoverRetryDelay is the wait before retrying while another build
+// takes over a stale lock.
+const lockTakeoverRetryDelay = 50 * time.Millisecond
+
+// lockAttempts bounds how often AcquireBuildLock retries while the lock
+// changes hands.
+const lockAttempts = 20
+
+// BuildInProgressError is returned when another build of the same
+// software configuration already holds the build lock.
+type BuildInProgressError struct {
+	// Fingerprint is the fingerprint hash of the contended configuration
+	Fingerprint string
+	// BuildID is the ID of the build holding the lock
+	BuildID string
+}
+
+// Error implements the error interface.
+func (e *BuildInProgressError) Error() string {
+	return fmt.Sprintf("another build of this configuration is already in progress (build ID: %s)\n\n"+
+		"Check its progress with:\n  pctl ami status %s\n\n"+
+		"To build anyway, re-run with --allow-concurrent", e.BuildID, e.BuildID)
+}
+
+// BuildLock is an advisory lock held for the duration of an AMI build.
+type BuildLock struct {
+	path    string
+	buildID string
+}
+
+// AcquireBuildLock acquires the advisory lock for a fingerprint on behalf of a build.
+// If another build holds the lock and is still in progress, a *BuildInProgressError
+// is returned. Locks left behind by builds that have finished, whose state no
+// longer exists, that started more than maxBuildLockAge ago, or whose build
+// instance instanceGone reports as gone are treated as stale and taken over.
+// instanceGone may be nil when the instance cannot be checked.
+func (sm *StateManager) AcquireBuildLock(fingerprint, buildID string, instanceGone func(*BuildState) bool) (*BuildLock, error) {
+	lockPath := filepath.Join(sm.stateDir, fmt.Sprintf("%s.lock", fingerprint))
+
+	for attempt := 0; attempt < lockAttempts; attempt++ {
+		err := createLockFile(lockPath, buildID)
+		if err == nil {
+			return &BuildLock{path: lockPath, buildID: buildID}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create build lock: %w", err)
+		}
+
+		// Lock exists - check whether its holder is still building
+		data, err := os.ReadFile(lockPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // Released between our create and read
+			}
+			return nil, fmt.Errorf("failed to read build lock: %w", err)
+		}
+
+		holder := strings.TrimSpace(string(data))
+		if sm.isLockHolderActive(holder, instanceGone) {
+			return nil, &BuildInProgressError{Fingerprint: fingerprint, BuildID: holder}
+		}
+
+		// Stale lock, remove and retry
+		if err := removeStaleLock(lockPath, holder); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("failed to acquire build lock for %s", fingerprint)
+}
+
+// createLockFile creates the lock file holding buildID, failing with an
+// os.IsExist error if it already exists. The holder is written to a temporary
+// file first and linked into place, so other builds never read a lock
+// without its holder.
+func createLockFile(lockPath, buildID string) error {
+	tmpPath := fmt.Sprintf("%s.%s.tmp", lockPath, buildID)
+	if err := os.WriteFile(tmpPath, []byte(buildID), 0644); err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	return os.Link(tmpPath, lockPath)
+}
+
+// removeStaleLock removes a lock still held by the stale build holder. Only the
+// build that creates the takeover marker may remove the lock, and it re-reads
+// the lock first, so a lock another build has just taken over is never removed.
+func removeStaleLock(lockPath, holder string) error {
+	marker := lockPath + ".takeover"
+	f, err := os.OpenFile(marker, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to create build lock takeover marker: %w", err)
+		}
+		// Another build is taking over, unless its pctl crashed doing so
+		if info, statErr := os.Stat(marker); statErr == nil && time.Since(info.ModTime()) > lockTakeoverTimeout {
+			os.Remove(marker)
+			return nil
+		}
+		time.Sleep(lockTakeoverRetryDelay)
+		return nil
+	}
+	f.Close()
+	defer os.Remove(marker)
+
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read build lock: %w", err)
+	}
+	if strings.TrimSpace(string(data)) != holder {
+		return nil // Taken over already; the caller checks the new holder
+	}
+
+	if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale build lock: %w", err)
+	}
+	return nil
+}
+
+// Release releases the build lock. It is safe to call on a nil lock and
+// does nothing if the lock has since been taken over by another build.
+func (l *BuildLock) Release() error {
+	if l == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read build lock: %w", err)
+	}
+
+	if strings.TrimSpace(string(data)) != l.buildID {
+		return nil // Not ours anymore
+	}
+
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release build lock: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseBuildLock releases the lock a finished build still holds, e.g. a
+// detached build whose pctl process exited before it completed. It does
+// nothing while the build is in progress or if another build holds the lock.
+func (sm *StateManager) ReleaseBuildLock(state *BuildState) error {
+	if state.Fingerprint == "" || sm.isBuildActive(state.BuildID) {
+		return nil
+	}
+	lock := &BuildLock{
+		path:    filepath.Join(sm.stateDir, fmt.Sprintf("%s.lock", state.Fingerprint)),
+		buildID: state.BuildID,
+	}
+	return lock.Release()
+}
+
+// isBuildActive reports whether a build exists and has not finished.
+func (sm *StateManager) isBuildActive(buildID string) bool {
+	if buildID == "" {
+		return false
+	}
+
+	state, err := sm.LoadState(buildID)
+	if err != nil {
+		return false
+	}
+
+	return state.Status != BuildStatusComplete && state.Status != BuildStatusFailed
+}
+
+// isLockHolderActive reports whether the build holding a lock is still
+// running: it has not finished, started within maxBuildLockAge, and its build
+// instance, once launched, is not reported gone by instanceGone.
+func (sm *StateManager) isLockHolderActive(buildID string, instanceGone func(*BuildState) bool) bool {
+	if !sm.isBuildActive(buildID) {
+		return false
+	}
+
+	state, err := sm.LoadState(buildID)
+	if err != nil {
+		return false
+	}
+	if time.Since(state.StartTime) > maxBuildLockAge {
+		return false
+	}
+	if state.InstanceID != "" && instanceGone != nil && instanceGone(state) {
+		return false
+	}
+	return true
+}
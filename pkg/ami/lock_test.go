@@ -0,0 +1,287 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquireBuildLockConcurrent(t *testing.T) {
+	tmpHome := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", originalHome)
+
+	sm, _ := NewStateManager()
+
+	const fingerprint = "abc123"
+	const builders = 8
+
+	// Each builder has an in-progress state, as BuildAMI would
+	states := make([]*BuildState, builders)
+	for i := range states {
+		states[i] = sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+		states[i].Fingerprint = fingerprint
+		if err := sm.SaveState(states[i]); err != nil {
+			t.Fatalf("SaveState() failed: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var acquired []*BuildLock
+	var refused []*BuildInProgressError
+
+	for _, state := range states {
+		wg.Add(1)
+		go func(buildID string) {
+			defer wg.Done()
+			lock, err := sm.AcquireBuildLock(fingerprint, buildID, nil)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				acquired = append(acquired, lock)
+				return
+			}
+			var inProgress *BuildInProgressError
+			if errors.As(err, &inProgress) {
+				refused = append(refused, inProgress)
+				return
+			}
+			t.Errorf("unexpected error: %v", err)
+		}(state.BuildID)
+	}
+	wg.Wait()
+
+	if len(acquired) != 1 {
+		t.Fatalf("Expected exactly 1 build to acquire the lock, got %d", len(acquired))
+	}
+	if len(refused) != builders-1 {
+		t.Errorf("Expected %d builds to be refused, got %d", builders-1, len(refused))
+	}
+
+	for _, r := range refused {
+		if r.BuildID != acquired[0].buildID {
+			t.Errorf("Refusal should point to holder %s, got %s", acquired[0].buildID, r.BuildID)
+		}
+	}
+}
+
+func TestBuildLockRelease(t *testing.T) {
+	tmpHome := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", originalHome)
+
+	sm, _ := NewStateManager()
+
+	first := sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+	second := sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+	sm.SaveState(first)
+	sm.SaveState(second)
+
+	lock, err := sm.AcquireBuildLock("abc123", first.BuildID, nil)
+	if err != nil {
+		t.Fatalf("AcquireBuildLock() failed: %v", err)
+	}
+
+	if _, err := sm.AcquireBuildLock("abc123", second.BuildID, nil); err == nil {
+		t.Fatal("Expected second build to be refused while lock is held")
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() failed: %v", err)
+	}
+
+	lock, err = sm.AcquireBuildLock("abc123", second.BuildID, nil)
+	if err != nil {
+		t.Fatalf("Expected lock to be available after release, got: %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireBuildLockStale(t *testing.T) {
+	tmpHome := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", originalHome)
+
+	sm, _ := NewStateManager()
+
+	crashed := sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+	sm.SaveState(crashed)
+
+	if _, err := sm.AcquireBuildLock("abc123", crashed.BuildID, nil); err != nil {
+		t.Fatalf("AcquireBuildLock() failed: %v", err)
+	}
+
+	// Holder finished without releasing the lock
+	sm.MarkFailed(crashed.BuildID, "crashed")
+
+	next := sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+	sm.SaveState(next)
+
+	lock, err := sm.AcquireBuildLock("abc123", next.BuildID, nil)
+	if err != nil {
+		t.Fatalf("Expected stale lock to be taken over, got: %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireBuildLockStaleConcurrent(t *testing.T) {
+	tmpHome := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", originalHome)
+
+	sm, _ := NewStateManager()
+
+	crashed := sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+	sm.SaveState(crashed)
+	if _, err := sm.AcquireBuildLock("abc123", crashed.BuildID, nil); err != nil {
+		t.Fatalf("AcquireBuildLock() failed: %v", err)
+	}
+	sm.MarkFailed(crashed.BuildID, "crashed")
+
+	// Builders racing to take over the stale lock must not each remove the
+	// lock another just took
+	const builders = 8
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	acquired := 0
+	for i := 0; i < builders; i++ {
+		state := sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+		sm.SaveState(state)
+		wg.Add(1)
+		go func(buildID string) {
+			defer wg.Done()
+			_, err := sm.AcquireBuildLock("abc123", buildID, nil)
+			mu.Lock()
+			defer mu.Unlock()
+			var inProgress *BuildInProgressError
+			switch {
+			case err == nil:
+				acquired++
+			case !errors.As(err, &inProgress):
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(state.BuildID)
+	}
+	wg.Wait()
+
+	if acquired != 1 {
+		t.Errorf("Expected exactly 1 build to take over the stale lock, got %d", acquired)
+	}
+}
+
+func TestAcquireBuildLockAbandonedBuild(t *testing.T) {
+	tmpHome := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", originalHome)
+
+	sm, _ := NewStateManager()
+
+	tests := []struct {
+		name         string
+		instanceID   string
+		age          time.Duration
+		instanceGone bool
+		wantStale    bool
+	}{
+		{name: "running build", instanceID: "i-0123456789abcdef0", age: time.Hour, wantStale: false},
+		{name: "instance not launched yet", age: time.Minute, instanceGone: true, wantStale: false},
+		{name: "instance terminated", instanceID: "i-0123456789abcdef0", age: time.Hour, instanceGone: true, wantStale: true},
+		{name: "past maximum age", instanceID: "i-0123456789abcdef0", age: maxBuildLockAge + time.Hour, wantStale: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The holder is still in progress, as after a crash of its pctl
+			holder := sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+			holder.InstanceID = tt.instanceID
+			holder.StartTime = time.Now().Add(-tt.age)
+			sm.SaveState(holder)
+			instanceGone := func(*BuildState) bool { return tt.instanceGone }
+
+			holderLock, err := sm.AcquireBuildLock("abc123", holder.BuildID, instanceGone)
+			if err != nil {
+				t.Fatalf("AcquireBuildLock() failed: %v", err)
+			}
+			defer holderLock.Release()
+
+			next := sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+			sm.SaveState(next)
+			lock, err := sm.AcquireBuildLock("abc123", next.BuildID, instanceGone)
+			if tt.wantStale {
+				if err != nil {
+					t.Fatalf("Expected the abandoned lock to be taken over, got: %v", err)
+				}
+				lock.Release()
+				return
+			}
+			var inProgress *BuildInProgressError
+			if !errors.As(err, &inProgress) {
+				t.Fatalf("Expected BuildInProgressError, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestReleaseBuildLock(t *testing.T) {
+	tmpHome := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", originalHome)
+
+	sm, _ := NewStateManager()
+
+	// A detached build keeps its lock after pctl exits
+	detached := sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+	detached.Fingerprint = "abc123"
+	sm.SaveState(detached)
+	if _, err := sm.AcquireBuildLock("abc123", detached.BuildID, nil); err != nil {
+		t.Fatalf("AcquireBuildLock() failed: %v", err)
+	}
+	lockPath := filepath.Join(sm.stateDir, "abc123.lock")
+
+	if err := sm.ReleaseBuildLock(detached); err != nil {
+		t.Fatalf("ReleaseBuildLock() failed: %v", err)
+	}
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Fatalf("Lock of an in-progress build should be kept, got: %v", err)
+	}
+
+	next := sm.NewBuildState("test-template", "test-ami", "us-east-1", 1)
+	sm.SaveState(next)
+	if _, err := sm.AcquireBuildLock("abc123", next.BuildID, nil); err == nil {
+		t.Fatal("Expected a second build to be refused while the detached build runs")
+	}
+
+	sm.MarkComplete(detached.BuildID, "ami-0123456789abcdef0")
+	finished, _ := sm.LoadState(detached.BuildID)
+	if err := sm.ReleaseBuildLock(finished); err != nil {
+		t.Fatalf("ReleaseBuildLock() failed: %v", err)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("Lock of a finished build should be released, got: %v", err)
+	}
+}
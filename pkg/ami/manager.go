@@ -75,6 +75,12 @@ func (m *Manager) ListAMIs(ctx context.Context) ([]*AMIMetadata, error) {
 				switch *tag.Key {
 				case "TemplateName":
 					metadata.TemplateName = *tag.Value
+				case TagBaseAMI:
+					metadata.BaseAMI = *tag.Value
+				case TagParallelClusterVersion:
+					metadata.ParallelClusterVersion = *tag.Value
+				case "pctl:fingerprint":
+					metadata.Fingerprint = *tag.Value
 				}
 			}
 		}
@@ -116,6 +122,12 @@ func (m *Manager) GetAMI(ctx context.Context, amiID string) (*AMIMetadata, error
 			switch *tag.Key {
 			case "TemplateName":
 				metadata.TemplateName = *tag.Value
+			case TagBaseAMI:
+				metadata.BaseAMI = *tag.Value
+			case TagParallelClusterVersion:
+				metadata.ParallelClusterVersion = *tag.Value
+			case "pctl:fingerprint":
+				metadata.Fingerprint = *tag.Value
 			}
 		}
 	}
@@ -139,12 +151,7 @@ func (m *Manager) DeleteAMI(ctx context.Context, amiID string) error {
 	}
 
 	// Collect snapshot IDs
-	var snapshotIDs []string
-	for _, bdm := range result.Images[0].BlockDeviceMappings {
-		if bdm.Ebs != nil && bdm.Ebs.SnapshotId != nil {
-			snapshotIDs = append(snapshotIDs, *bdm.Ebs.SnapshotId)
-		}
-	}
+	snapshotIDs := imageSnapshotIDs(result.Images[0])
 
 	// Deregister AMI
 	_, err = m.builder.ec2Client.DeregisterImage(ctx, &ec2.DeregisterImageInput{
@@ -0,0 +1,212 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// MatrixCell is one seed and architecture combination of a build matrix.
+type MatrixCell struct {
+	// TemplatePath is the seed file
+	TemplatePath string
+	// Template is the loaded seed, nil if it could not be loaded
+	Template *template.Template
+	// Architecture is the architecture the AMI is built for
+	Architecture string
+	// SkipReason is why the cell is not built, if it is skipped
+	SkipReason string
+	// Err is why the seed cannot be built at all
+	Err error
+}
+
+// TemplateName returns the seed file name without its extension.
+func (c MatrixCell) TemplateName() string {
+	base := filepath.Base(c.TemplatePath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// MatrixResult is the outcome of one matrix cell.
+type MatrixResult struct {
+	Cell MatrixCell
+	// Metadata is the build's metadata, nil if the cell was skipped or the
+	// build failed
+	Metadata *AMIMetadata
+	// Err is why the build failed
+	Err error
+	// Duration is how long the build ran
+	Duration time.Duration
+}
+
+// Status returns "skipped", "failed", or the status of the finished build.
+func (r MatrixResult) Status() string {
+	switch {
+	case r.Cell.SkipReason != "":
+		return "skipped"
+	case r.Err != nil:
+		return "failed"
+	case r.Metadata != nil && r.Metadata.Status != "":
+		return string(r.Metadata.Status)
+	}
+	return string(BuildStatusComplete)
+}
+
+// MatrixSummary counts matrix results by outcome.
+type MatrixSummary struct {
+	Succeeded int
+	Failed    int
+	Skipped   int
+}
+
+// MatrixBuildFunc builds the AMI for one matrix cell, normally by calling
+// Builder.BuildAMI.
+type MatrixBuildFunc func(ctx context.Context, cell MatrixCell) (*AMIMetadata, error)
+
+// FindMatrixTemplates returns the seed files in dir, sorted by name.
+func FindMatrixTemplates(dir string) ([]string, error) {
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan seed directory %s: %w", dir, err)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no seed files (*.yaml, *.yml, *.json) found in %s", dir)
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// ExpandMatrix loads each seed and pairs it with each architecture. An AMI
+// only boots on clusters whose head node shares its architecture, so cells
+// for other architectures are skipped, as are seeds without software. Seeds
+// that fail to load or validate yield a single cell carrying the error, so
+// one broken seed does not stop the rest of the matrix.
+func ExpandMatrix(templatePaths, architectures []string) ([]MatrixCell, error) {
+	if len(architectures) == 0 {
+		return nil, fmt.Errorf("at least one architecture is required")
+	}
+	seen := make(map[string]bool)
+	var archs []string
+	for _, arch := range architectures {
+		if err := ValidateArchitecture(arch); err != nil {
+			return nil, err
+		}
+		if !seen[arch] {
+			seen[arch] = true
+			archs = append(archs, arch)
+		}
+	}
+
+	var cells []MatrixCell
+	for _, path := range templatePaths {
+		tmpl, err := template.Load(path)
+		if err == nil {
+			err = tmpl.Validate()
+		}
+		if err != nil {
+			cells = append(cells, MatrixCell{TemplatePath: path, Err: err})
+			continue
+		}
+
+		for _, arch := range archs {
+			cell := MatrixCell{TemplatePath: path, Template: tmpl, Architecture: arch}
+			switch {
+			case len(tmpl.Software.SpackPackages) == 0:
+				cell.SkipReason = "seed has no software packages"
+			case tmpl.Compute.HeadNode != "" && tmpl.Architecture() != arch:
+				cell.SkipReason = fmt.Sprintf("head node %s is %s", tmpl.Compute.HeadNode, tmpl.Architecture())
+			}
+			cells = append(cells, cell)
+		}
+	}
+
+	return cells, nil
+}
+
+// RunMatrix builds the cells with at most parallelism builds at a time and
+// returns their results in cell order. A failed build does not stop the
+// others; cells not yet started when ctx is cancelled fail with its error.
+func RunMatrix(ctx context.Context, cells []MatrixCell, parallelism int, build MatrixBuildFunc) []MatrixResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	results := make([]MatrixResult, len(cells))
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, cell := range cells {
+		results[i].Cell = cell
+		if cell.SkipReason != "" {
+			continue
+		}
+		if cell.Err != nil {
+			results[i].Err = cell.Err
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+			if err := ctx.Err(); err != nil {
+				<-slots
+				results[i].Err = err
+				continue
+			}
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *MatrixResult) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			start := time.Now()
+			result.Metadata, result.Err = build(ctx, result.Cell)
+			result.Duration = time.Since(start)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results
+}
+
+// SummarizeMatrix counts the results by outcome.
+func SummarizeMatrix(results []MatrixResult) MatrixSummary {
+	var summary MatrixSummary
+	for _, result := range results {
+		switch result.Status() {
+		case "skipped":
+			summary.Skipped++
+		case "failed":
+			summary.Failed++
+		default:
+			summary.Succeeded++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeMatrixSeed writes a seed with the given head node and packages.
+func writeMatrixSeed(t *testing.T, dir, file, headNode string, packages ...string) string {
+	t.Helper()
+
+	seed := "cluster:\n  name: " + strings.TrimSuffix(file, filepath.Ext(file)) + "\n  region: us-east-1\n" +
+		"compute:\n  head_node: " + headNode + "\n  queues:\n    - name: compute\n      instance_types: [" + headNode + "]\n      max_count: 2\n"
+	if len(packages) > 0 {
+		seed += "software:\n  spack_packages: [" + strings.Join(packages, ", ") + "]\n"
+	}
+
+	path := filepath.Join(dir, file)
+	if err := os.WriteFile(path, []byte(seed), 0644); err != nil {
+		t.Fatalf("failed to write seed: %v", err)
+	}
+	return path
+}
+
+// cellKeys describes cells as seed/arch, with the skip reason or error.
+func cellKeys(cells []MatrixCell) []string {
+	var keys []string
+	for _, cell := range cells {
+		key := cell.TemplateName() + "/" + cell.Architecture
+		switch {
+		case cell.Err != nil:
+			key += " error"
+		case cell.SkipReason != "":
+			key += " skip: " + cell.SkipReason
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestFindMatrixTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeMatrixSeed(t, dir, "b.yml", "t3.medium", "gcc")
+	writeMatrixSeed(t, dir, "a.yaml", "t3.medium", "gcc")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("seeds\n"), 0644)
+
+	paths, err := FindMatrixTemplates(dir)
+	if err != nil {
+		t.Fatalf("FindMatrixTemplates() failed: %v", err)
+	}
+	expected := []string{filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yml")}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+
+	if _, err := FindMatrixTemplates(t.TempDir()); err == nil {
+		t.Error("Expected an error for a directory without seeds")
+	}
+}
+
+func TestExpandMatrix(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{
+		writeMatrixSeed(t, dir, "bio.yaml", "c6a.xlarge", "samtools"),
+		writeMatrixSeed(t, dir, "bio-arm.yaml", "c7g.xlarge", "samtools"),
+		writeMatrixSeed(t, dir, "plain.yaml", "c6a.xlarge"),
+		writeMatrixSeed(t, dir, "broken.yaml", "", "samtools"),
+	}
+
+	cells, err := ExpandMatrix(paths, []string{"x86_64", "arm64", "x86_64"})
+	if err != nil {
+		t.Fatalf("ExpandMatrix() failed: %v", err)
+	}
+
+	expected := []string{
+		"bio/x86_64",
+		"bio/arm64 skip: head node c6a.xlarge is x86_64",
+		"bio-arm/x86_64 skip: head node c7g.xlarge is arm64",
+		"bio-arm/arm64",
+		"plain/x86_64 skip: seed has no software packages",
+		"plain/arm64 skip: seed has no software packages",
+		"broken/ error",
+	}
+	if got := cellKeys(cells); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected cells:\n  %s\ngot:\n  %s", strings.Join(expected, "\n  "), strings.Join(got, "\n  "))
+	}
+
+	if _, err := ExpandMatrix(paths, []string{"ppc64le"}); err == nil {
+		t.Error("Expected an error for an unsupported architecture")
+	}
+	if _, err := ExpandMatrix(paths, nil); err == nil {
+		t.Error("Expected an error without architectures")
+	}
+}
+
+func TestRunMatrixAggregatesPartialFailures(t *testing.T) {
+	cells := []MatrixCell{
+		{TemplatePath: "bio.yaml", Architecture: "x86_64"},
+		{TemplatePath: "bio.yaml", Architecture: "arm64", SkipReason: "head node c6a.xlarge is x86_64"},
+		{TemplatePath: "chem.yaml", Architecture: "x86_64"},
+		{TemplatePath: "broken.yaml", Err: errors.New("invalid seed")},
+		{TemplatePath: "ml.yaml", Architecture: "arm64"},
+	}
+
+	var mu sync.Mutex
+	var built []string
+	build := func(ctx context.Context, cell MatrixCell) (*AMIMetadata, error) {
+		mu.Lock()
+		built = append(built, cell.TemplateName()+"/"+cell.Architecture)
+		mu.Unlock()
+		if cell.TemplateName() == "chem" {
+			return nil, errors.New("spack install failed")
+		}
+		return &AMIMetadata{AMIID: "ami-" + cell.TemplateName(), Status: BuildStatusComplete}, nil
+	}
+
+	results := RunMatrix(context.Background(), cells, 2, build)
+	if len(results) != len(cells) {
+		t.Fatalf("Expected %d results, got %d", len(cells), len(results))
+	}
+
+	var statuses []string
+	for i, result := range results {
+		if result.Cell.TemplatePath != cells[i].TemplatePath {
+			t.Errorf("Expected result %d for %s, got %s", i, cells[i].TemplatePath, result.Cell.TemplatePath)
+		}
+		statuses = append(statuses, result.Status())
+	}
+	expected := []string{"complete", "skipped", "failed", "failed", "complete"}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("Expected statuses %v, got %v", expected, statuses)
+	}
+	if len(built) != 3 {
+		t.Errorf("Expected only the 3 buildable cells to be built, got %v", built)
+	}
+	if results[4].Metadata == nil || results[4].Metadata.AMIID != "ami-ml" {
+		t.Errorf("Expected the build after a failure to still run, got %+v", results[4])
+	}
+
+	summary := SummarizeMatrix(results)
+	if summary != (MatrixSummary{Succeeded: 2, Failed: 2, Skipped: 1}) {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
+
+func TestRunMatrixBoundsParallelism(t *testing.T) {
+	var cells []MatrixCell
+	for i := 0; i < 8; i++ {
+		cells = append(cells, MatrixCell{TemplatePath: "seed.yaml", Architecture: "x86_64"})
+	}
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	build := func(ctx context.Context, cell MatrixCell) (*AMIMetadata, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return &AMIMetadata{}, nil
+	}
+
+	RunMatrix(context.Background(), cells, 3, build)
+	if peak != 3 {
+		t.Errorf("Expected at most 3 concurrent builds (and reaching 3), got %d", peak)
+	}
+}
+
+func TestRunMatrixCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cells := []MatrixCell{
+		{TemplatePath: "first.yaml", Architecture: "x86_64"},
+		{TemplatePath: "second.yaml", Architecture: "x86_64"},
+	}
+
+	build := func(ctx context.Context, cell MatrixCell) (*AMIMetadata, error) {
+		cancel()
+		return nil, ctx.Err()
+	}
+
+	results := RunMatrix(ctx, cells, 1, build)
+	for i, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("Expected result %d to be cancelled, got %v", i, result.Err)
+		}
+	}
+}
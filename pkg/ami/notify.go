@@ -0,0 +1,195 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Build notification events.
+const (
+	// NotifyEventProgress reports that installation passed a milestone
+	NotifyEventProgress = "progress"
+	// NotifyEventComplete reports that the AMI is available
+	NotifyEventComplete = "complete"
+	// NotifyEventFailed reports that the build failed
+	NotifyEventFailed = "failed"
+)
+
+// BuildNotification is the JSON body POSTed to the build webhook.
+type BuildNotification struct {
+	Event     string    `json:"event"`
+	BuildID   string    `json:"build_id"`
+	AMIName   string    `json:"ami_name"`
+	Template  string    `json:"template"`
+	Region    string    `json:"region"`
+	Progress  int       `json:"progress"`
+	Message   string    `json:"message,omitempty"`
+	AMIID     string    `json:"ami_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// newBuildNotification describes the current state of a build.
+func newBuildNotification(event string, state *BuildState) *BuildNotification {
+	return &BuildNotification{
+		Event:     event,
+		BuildID:   state.BuildID,
+		AMIName:   state.AMIName,
+		Template:  state.TemplateName,
+		Region:    state.Region,
+		Progress:  state.Progress,
+		Message:   state.ProgressMessage,
+		AMIID:     state.AMIID,
+		Error:     state.ErrorMessage,
+		Timestamp: time.Now(),
+	}
+}
+
+// ValidateWebhookURL checks that a notification webhook is an http or https
+// URL.
+func ValidateWebhookURL(webhook string) error {
+	u, err := url.Parse(webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: expected an http:// or https:// URL", webhook)
+	}
+	return nil
+}
+
+// ValidateProgressMilestones checks that each milestone is a percentage
+// between 1 and 99; completion is always notified.
+func ValidateProgressMilestones(milestones []int) error {
+	for _, milestone := range milestones {
+		if milestone < 1 || milestone > 99 {
+			return fmt.Errorf("invalid progress milestone %d: must be between 1 and 99", milestone)
+		}
+	}
+	return nil
+}
+
+// dueMilestones returns the milestones at or below progress that have not
+// fired yet, in ascending order.
+func dueMilestones(milestones, fired []int, progress int) []int {
+	done := make(map[int]bool, len(fired))
+	for _, milestone := range fired {
+		done[milestone] = true
+	}
+
+	var due []int
+	for _, milestone := range milestones {
+		if milestone <= progress && !done[milestone] {
+			done[milestone] = true
+			due = append(due, milestone)
+		}
+	}
+	sort.Ints(due)
+	return due
+}
+
+// postNotification POSTs a build notification to webhook.
+func postNotification(ctx context.Context, webhook string, notification *BuildNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notifyMilestones POSTs a progress notification for each milestone the
+// build has reached since the last call, recording them in the build state
+// so each fires only once.
+func (b *Builder) notifyMilestones(ctx context.Context, buildID string, opts *BuildOptions) {
+	if opts.NotifyWebhook == "" || len(opts.ProgressMilestones) == 0 {
+		return
+	}
+
+	state, err := b.stateManager.LoadState(buildID)
+	if err != nil {
+		return
+	}
+	due := dueMilestones(opts.ProgressMilestones, state.MilestonesNotified, state.Progress)
+	if len(due) == 0 {
+		return
+	}
+
+	for _, milestone := range due {
+		notification := newBuildNotification(NotifyEventProgress, state)
+		notification.Progress = milestone
+		if err := postNotification(ctx, opts.NotifyWebhook, notification); err != nil {
+			fmt.Printf("\n⚠️  Warning: failed to send %d%% progress notification: %v\n", milestone, err)
+		}
+	}
+
+	// Record milestones even if sending failed so a broken webhook is not
+	// retried on every poll
+	if err := b.stateManager.RecordMilestones(buildID, due); err != nil {
+		fmt.Printf("\n⚠️  Warning: failed to record progress notifications: %v\n", err)
+	}
+}
+
+// notifyFinished POSTs a complete or failed notification for a build that
+// has finished. Builds still in progress, such as detached ones, are
+// skipped.
+func (b *Builder) notifyFinished(ctx context.Context, buildID string, opts *BuildOptions) {
+	if opts.NotifyWebhook == "" {
+		return
+	}
+
+	state, err := b.stateManager.LoadState(buildID)
+	if err != nil {
+		return
+	}
+
+	var event string
+	switch state.Status {
+	case BuildStatusComplete:
+		event = NotifyEventComplete
+	case BuildStatusFailed:
+		event = NotifyEventFailed
+	default:
+		return
+	}
+
+	// Still notify when the build was cancelled or hit its deadline
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 15*time.Second)
+	defer cancel()
+	if err := postNotification(ctx, opts.NotifyWebhook, newBuildNotification(event, state)); err != nil {
+		fmt.Printf("⚠️  Warning: failed to send build notification: %v\n", err)
+	}
+}
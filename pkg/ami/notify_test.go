@@ -0,0 +1,180 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// webhookRecorder is a test webhook that records the notifications it
+// receives.
+type webhookRecorder struct {
+	mu            sync.Mutex
+	notifications []BuildNotification
+}
+
+func newWebhookRecorder(t *testing.T) (*webhookRecorder, string) {
+	t.Helper()
+	rec := &webhookRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n BuildNotification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("invalid notification body: %v", err)
+		}
+		rec.mu.Lock()
+		rec.notifications = append(rec.notifications, n)
+		rec.mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return rec, server.URL
+}
+
+func (r *webhookRecorder) progress() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var progress []int
+	for _, n := range r.notifications {
+		progress = append(progress, n.Progress)
+	}
+	return progress
+}
+
+func TestDueMilestones(t *testing.T) {
+	tests := []struct {
+		name       string
+		milestones []int
+		fired      []int
+		progress   int
+		want       []int
+	}{
+		{"below first", []int{25, 50, 75}, nil, 10, nil},
+		{"exactly at milestone", []int{25, 50, 75}, nil, 25, []int{25}},
+		{"already fired", []int{25, 50, 75}, []int{25}, 40, nil},
+		{"jump past several", []int{25, 50, 75}, []int{25}, 80, []int{50, 75}},
+		{"unsorted and duplicated", []int{75, 25, 25}, nil, 90, []int{25, 75}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dueMilestones(tt.milestones, tt.fired, tt.progress); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dueMilestones() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyMilestonesFireOnce(t *testing.T) {
+	rec, webhook := newWebhookRecorder(t)
+	sm := &StateManager{stateDir: t.TempDir()}
+	b := &Builder{stateManager: sm}
+
+	state := sm.NewBuildState("bio", "bio-v1", "us-east-1", 3)
+	if err := sm.SaveState(state); err != nil {
+		t.Fatalf("SaveState() failed: %v", err)
+	}
+	opts := &BuildOptions{NotifyWebhook: webhook, ProgressMilestones: []int{25, 50, 75}}
+
+	for _, progress := range []int{10, 30, 30, 45, 80, 95} {
+		sm.UpdateProgress(state.BuildID, progress, "Installing")
+		b.notifyMilestones(context.Background(), state.BuildID, opts)
+	}
+
+	if got, want := rec.progress(), []int{25, 50, 75}; !reflect.DeepEqual(got, want) {
+		t.Errorf("notified milestones = %v, want %v", got, want)
+	}
+	for _, n := range rec.notifications {
+		if n.Event != NotifyEventProgress || n.BuildID != state.BuildID || n.AMIName != "bio-v1" {
+			t.Errorf("unexpected notification: %+v", n)
+		}
+	}
+
+	saved, err := sm.LoadState(state.BuildID)
+	if err != nil {
+		t.Fatalf("LoadState() failed: %v", err)
+	}
+	if !reflect.DeepEqual(saved.MilestonesNotified, []int{25, 50, 75}) {
+		t.Errorf("MilestonesNotified = %v, want [25 50 75]", saved.MilestonesNotified)
+	}
+}
+
+func TestNotifyMilestonesWithoutWebhook(t *testing.T) {
+	sm := &StateManager{stateDir: t.TempDir()}
+	b := &Builder{stateManager: sm}
+
+	state := sm.NewBuildState("bio", "bio-v1", "us-east-1", 3)
+	state.Progress = 60
+	sm.SaveState(state)
+
+	b.notifyMilestones(context.Background(), state.BuildID, &BuildOptions{ProgressMilestones: []int{50}})
+
+	saved, _ := sm.LoadState(state.BuildID)
+	if len(saved.MilestonesNotified) != 0 {
+		t.Errorf("Expected no milestones recorded without a webhook, got %v", saved.MilestonesNotified)
+	}
+}
+
+func TestNotifyFinished(t *testing.T) {
+	rec, webhook := newWebhookRecorder(t)
+	sm := &StateManager{stateDir: t.TempDir()}
+	b := &Builder{stateManager: sm}
+	opts := &BuildOptions{NotifyWebhook: webhook}
+
+	// Builds still running, e.g. detached ones, are not reported
+	running := sm.NewBuildState("bio", "bio-v1", "us-east-1", 3)
+	running.Status = BuildStatusInstalling
+	sm.SaveState(running)
+	b.notifyFinished(context.Background(), running.BuildID, opts)
+
+	complete := sm.NewBuildState("bio", "bio-v2", "us-east-1", 3)
+	sm.SaveState(complete)
+	sm.MarkComplete(complete.BuildID, "ami-12345")
+	b.notifyFinished(context.Background(), complete.BuildID, opts)
+
+	failed := sm.NewBuildState("bio", "bio-v3", "us-east-1", 3)
+	sm.SaveState(failed)
+	sm.MarkFailed(failed.BuildID, "instance failed")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // Failure notifications are still sent after cancellation
+	b.notifyFinished(ctx, failed.BuildID, opts)
+
+	if len(rec.notifications) != 2 {
+		t.Fatalf("Expected 2 notifications, got %+v", rec.notifications)
+	}
+	if n := rec.notifications[0]; n.Event != NotifyEventComplete || n.AMIID != "ami-12345" || n.Progress != 100 {
+		t.Errorf("unexpected complete notification: %+v", n)
+	}
+	if n := rec.notifications[1]; n.Event != NotifyEventFailed || n.Error != "instance failed" {
+		t.Errorf("unexpected failed notification: %+v", n)
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	for _, webhook := range []string{"https://hooks.example.com/pctl", "http://localhost:8080"} {
+		if err := ValidateWebhookURL(webhook); err != nil {
+			t.Errorf("ValidateWebhookURL(%q) unexpected error: %v", webhook, err)
+		}
+	}
+	for _, webhook := range []string{"hooks.example.com", "ftp://example.com", "https://"} {
+		if err := ValidateWebhookURL(webhook); err == nil {
+			t.Errorf("ValidateWebhookURL(%q) expected error", webhook)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// KnownTemplates is the set of templates and clusters that pctl-managed AMIs
+// can be traced back to. An AMI matching none of them is orphaned.
+type KnownTemplates struct {
+	names        map[string]bool
+	fingerprints map[string]bool
+	amiIDs       map[string]bool
+}
+
+// NewKnownTemplates creates an empty set of known templates.
+func NewKnownTemplates() *KnownTemplates {
+	return &KnownTemplates{
+		names:        make(map[string]bool),
+		fingerprints: make(map[string]bool),
+		amiIDs:       make(map[string]bool),
+	}
+}
+
+// AddTemplate records a template by its cluster name and software fingerprint.
+func (k *KnownTemplates) AddTemplate(tmpl *template.Template) {
+	if tmpl.Cluster.Name != "" {
+		k.names[tmpl.Cluster.Name] = true
+	}
+	k.fingerprints[tmpl.ComputeFingerprint().Hash] = true
+}
+
+// AddName records a template known only by name, such as a registry entry.
+func (k *KnownTemplates) AddName(name string) {
+	if name != "" {
+		k.names[name] = true
+	}
+}
+
+// AddAMI records an AMI that is in use, such as a cluster's custom AMI.
+func (k *KnownTemplates) AddAMI(amiID string) {
+	if amiID != "" {
+		k.amiIDs[amiID] = true
+	}
+}
+
+// References reports whether an AMI is used by a known cluster or matches a
+// known template by name or fingerprint.
+func (k *KnownTemplates) References(meta *AMIMetadata) bool {
+	if k.amiIDs[meta.AMIID] {
+		return true
+	}
+	if meta.TemplateName != "" && k.names[meta.TemplateName] {
+		return true
+	}
+	return meta.Fingerprint != "" && k.fingerprints[meta.Fingerprint]
+}
+
+// FindOrphanedAMIs returns the AMIs not referenced by any known template or cluster.
+func FindOrphanedAMIs(amis []*AMIMetadata, known *KnownTemplates) []*AMIMetadata {
+	var orphaned []*AMIMetadata
+	for _, meta := range amis {
+		if !known.References(meta) {
+			orphaned = append(orphaned, meta)
+		}
+	}
+	return orphaned
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func TestFindOrphanedAMIs(t *testing.T) {
+	bio := &template.Template{
+		Cluster:  template.ClusterConfig{Name: "bio"},
+		Software: template.SoftwareConfig{SpackPackages: []string{"samtools@1.17"}},
+	}
+	renamed := &template.Template{
+		Cluster:  template.ClusterConfig{Name: "chem-v2"},
+		Software: template.SoftwareConfig{SpackPackages: []string{"gromacs@2023.1"}},
+	}
+
+	known := NewKnownTemplates()
+	known.AddTemplate(bio)
+	known.AddTemplate(renamed)
+	known.AddAMI("ami-in-use")
+	known.AddName("rna-seq")
+
+	amis := []*AMIMetadata{
+		{AMIID: "ami-by-name", TemplateName: "bio"},
+		{AMIID: "ami-by-registry-name", TemplateName: "rna-seq"},
+		{AMIID: "ami-by-fingerprint", TemplateName: "chem", Fingerprint: renamed.ComputeFingerprint().Hash},
+		{AMIID: "ami-in-use", TemplateName: "deleted"},
+		{AMIID: "ami-orphan", TemplateName: "deleted", Fingerprint: "0123abcd"},
+		{AMIID: "ami-untagged"},
+	}
+
+	orphaned := FindOrphanedAMIs(amis, known)
+
+	var got []string
+	for _, meta := range orphaned {
+		got = append(got, meta.AMIID)
+	}
+	want := []string{"ami-orphan", "ami-untagged"}
+	if len(got) != len(want) {
+		t.Fatalf("FindOrphanedAMIs() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("FindOrphanedAMIs()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFindOrphanedAMIsNoKnownTemplates(t *testing.T) {
+	amis := []*AMIMetadata{
+		{AMIID: "ami-1", TemplateName: "bio"},
+		{AMIID: "ami-2", TemplateName: "chem"},
+	}
+
+	orphaned := FindOrphanedAMIs(amis, NewKnownTemplates())
+	if len(orphaned) != len(amis) {
+		t.Errorf("FindOrphanedAMIs() returned %d AMIs, want %d", len(orphaned), len(amis))
+	}
+}
@@ -0,0 +1,466 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/scttfrdmn/petal/pkg/software"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// PreflightStatus is the outcome of a single preflight check.
+type PreflightStatus string
+
+const (
+	// PreflightPassed means the prerequisite is in place
+	PreflightPassed PreflightStatus = "pass"
+	// PreflightFailed means the build would fail
+	PreflightFailed PreflightStatus = "fail"
+	// PreflightSkipped means the check does not apply or could not be run
+	PreflightSkipped PreflightStatus = "skip"
+)
+
+// PreflightCheck is the result of checking one build prerequisite.
+type PreflightCheck struct {
+	// Name is the prerequisite checked (e.g., "Subnet")
+	Name string
+	// Status is the outcome
+	Status PreflightStatus
+	// Message explains the outcome
+	Message string
+}
+
+// PreflightResult holds the results of all preflight checks, in order.
+type PreflightResult struct {
+	Checks []PreflightCheck
+}
+
+// Passed reports whether no check failed.
+func (r *PreflightResult) Passed() bool {
+	for _, check := range r.Checks {
+		if check.Status == PreflightFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// preflightEC2API is the subset of the EC2 API used by preflight checks.
+type preflightEC2API interface {
+	DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+	DescribeRouteTables(ctx context.Context, params *ec2.DescribeRouteTablesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRouteTablesOutput, error)
+	DescribeKeyPairs(ctx context.Context, params *ec2.DescribeKeyPairsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeKeyPairsOutput, error)
+	DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error)
+	DescribeInstanceTypes(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error)
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+}
+
+// Preflight checks that everything an AMI build needs is in place, without
+// launching anything.
+type Preflight struct {
+	ec2Client preflightEC2API
+	// latestBaseAMI finds the official ParallelCluster AMI for an OS and architecture
+	latestBaseAMI func(ctx context.Context, osName, architecture string) (string, error)
+	// serviceQuota returns the value of a Service Quotas quota. It is nil
+	// until the Service Quotas client is a dependency, which skips the vCPU
+	// quota check
+	serviceQuota func(ctx context.Context, serviceCode, quotaCode string) (float64, error)
+}
+
+// NewPreflight creates preflight checks that use the builder's AWS clients.
+func (b *Builder) NewPreflight() *Preflight {
+	return &Preflight{
+		ec2Client:     b.ec2Client,
+		latestBaseAMI: b.findParallelClusterAMI,
+	}
+}
+
+// Run checks the template and build inputs, subnet, key pair, base AMI,
+// architecture, and vCPU quota for a build of tmpl with opts. Checks that
+// depend on an earlier failed check are skipped.
+func (p *Preflight) Run(ctx context.Context, tmpl *template.Template, opts *BuildOptions) *PreflightResult {
+	result := &PreflightResult{}
+
+	result.Checks = append(result.Checks, checkBuildInputs(tmpl, opts))
+	result.Checks = append(result.Checks, p.checkSubnet(ctx, opts.SubnetID))
+	result.Checks = append(result.Checks, p.checkKeyPair(ctx, opts.KeyName))
+
+	baseAMICheck, amiArch := p.checkBaseAMI(ctx, tmpl, opts)
+	result.Checks = append(result.Checks, baseAMICheck)
+
+	instanceType, instanceCheck := p.describeBuildInstanceType(ctx, opts.InstanceType)
+	if instanceCheck != nil {
+		result.Checks = append(result.Checks, *instanceCheck)
+		return result
+	}
+	result.Checks = append(result.Checks, checkArchitecture(tmpl, opts.InstanceType, instanceType, amiArch))
+	result.Checks = append(result.Checks, p.checkVCPUQuota(ctx, opts.InstanceType, instanceType))
+
+	return result
+}
+
+// checkBuildInputs validates the template and loads the lockfile, Spack
+// configuration, and license files the build would use.
+func checkBuildInputs(tmpl *template.Template, opts *BuildOptions) PreflightCheck {
+	check := PreflightCheck{Name: "Template"}
+
+	if err := tmpl.Validate(); err != nil {
+		check.Status = PreflightFailed
+		check.Message = err.Error()
+		return check
+	}
+	if len(tmpl.Software.SpackPackages) == 0 && opts.SpackLock == "" {
+		check.Status = PreflightFailed
+		check.Message = "template has no software packages"
+		return check
+	}
+	if opts.SpackLock != "" {
+		if _, err := software.LoadSpackLock(opts.SpackLock); err != nil {
+			check.Status = PreflightFailed
+			check.Message = err.Error()
+			return check
+		}
+	}
+	if _, err := loadSpackConfigFiles(opts.SpackConfigFiles); err != nil {
+		check.Status = PreflightFailed
+		check.Message = err.Error()
+		return check
+	}
+	if _, err := loadLicenseFiles(opts.LicenseFiles); err != nil {
+		check.Status = PreflightFailed
+		check.Message = err.Error()
+		return check
+	}
+
+	check.Status = PreflightPassed
+	check.Message = fmt.Sprintf("%s is valid", tmpl.Cluster.Name)
+	return check
+}
+
+// checkSubnet checks that the subnet exists and routes to the internet, which
+// the build instance needs to download Spack and packages.
+func (p *Preflight) checkSubnet(ctx context.Context, subnetID string) PreflightCheck {
+	check := PreflightCheck{Name: "Subnet"}
+	if subnetID == "" {
+		check.Status = PreflightFailed
+		check.Message = "no subnet specified"
+		return check
+	}
+
+	subnets, err := p.ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		SubnetIds: []string{subnetID},
+	})
+	if err != nil || len(subnets.Subnets) == 0 {
+		check.Status = PreflightFailed
+		check.Message = fmt.Sprintf("subnet %s not found", subnetID)
+		if err != nil {
+			check.Message = fmt.Sprintf("failed to describe subnet %s: %v", subnetID, err)
+		}
+		return check
+	}
+	subnet := subnets.Subnets[0]
+	vpcID := aws.ToString(subnet.VpcId)
+
+	// Subnets without an explicit association use the VPC's main route table
+	routeTables, err := p.ec2Client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []types.Filter{
+			{Name: aws.String("association.subnet-id"), Values: []string{subnetID}},
+		},
+	})
+	if err == nil && len(routeTables.RouteTables) == 0 {
+		routeTables, err = p.ec2Client.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+			Filters: []types.Filter{
+				{Name: aws.String("vpc-id"), Values: []string{vpcID}},
+				{Name: aws.String("association.main"), Values: []string{"true"}},
+			},
+		})
+	}
+	if err != nil {
+		check.Status = PreflightFailed
+		check.Message = fmt.Sprintf("failed to describe route tables for subnet %s: %v", subnetID, err)
+		return check
+	}
+
+	for _, table := range routeTables.RouteTables {
+		if target := internetRouteTarget(table.Routes); target != "" {
+			check.Status = PreflightPassed
+			check.Message = fmt.Sprintf("%s (%s, %s) routes to the internet via %s",
+				subnetID, vpcID, aws.ToString(subnet.AvailabilityZone), target)
+			return check
+		}
+	}
+
+	check.Status = PreflightFailed
+	check.Message = fmt.Sprintf("%s has no active 0.0.0.0/0 route to an internet or NAT gateway; the build instance cannot download software", subnetID)
+	return check
+}
+
+// internetRouteTarget returns the gateway of an active default route to an
+// internet or NAT gateway, or an empty string if there is none.
+func internetRouteTarget(routes []types.Route) string {
+	for _, route := range routes {
+		if aws.ToString(route.DestinationCidrBlock) != "0.0.0.0/0" || route.State == types.RouteStateBlackhole {
+			continue
+		}
+		if gateway := aws.ToString(route.GatewayId); strings.HasPrefix(gateway, "igw-") {
+			return gateway
+		}
+		if nat := aws.ToString(route.NatGatewayId); nat != "" {
+			return nat
+		}
+	}
+	return ""
+}
+
+// checkKeyPair checks that the SSH key pair exists, if one was given.
+func (p *Preflight) checkKeyPair(ctx context.Context, keyName string) PreflightCheck {
+	check := PreflightCheck{Name: "Key pair"}
+	if keyName == "" {
+		check.Status = PreflightSkipped
+		check.Message = "no key pair specified (SSH verification of the build is disabled)"
+		return check
+	}
+
+	result, err := p.ec2Client.DescribeKeyPairs(ctx, &ec2.DescribeKeyPairsInput{
+		KeyNames: []string{keyName},
+	})
+	if err != nil || len(result.KeyPairs) == 0 {
+		check.Status = PreflightFailed
+		check.Message = fmt.Sprintf("key pair %s not found", keyName)
+		return check
+	}
+
+	check.Status = PreflightPassed
+	check.Message = fmt.Sprintf("%s exists", keyName)
+	return check
+}
+
+// checkBaseAMI resolves the base AMI as the build would and returns its
+// architecture, or an empty string if it could not be resolved.
+func (p *Preflight) checkBaseAMI(ctx context.Context, tmpl *template.Template, opts *BuildOptions) (PreflightCheck, string) {
+	check := PreflightCheck{Name: "Base AMI"}
+
+	if opts.BaseAMI == "" {
+		architecture := buildArchitecture(opts)
+		osName := tmpl.Cluster.GetOS()
+
+		amiID, err := p.latestBaseAMI(ctx, osName, architecture)
+		if err != nil {
+			check.Status = PreflightFailed
+			check.Message = fmt.Sprintf("no ParallelCluster AMI found for %s (%s): %v", osName, architecture, err)
+			return check, ""
+		}
+		check.Status = PreflightPassed
+		check.Message = fmt.Sprintf("%s (latest ParallelCluster AMI for %s, %s)", amiID, osName, architecture)
+		return check, architecture
+	}
+
+	result, err := p.ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{opts.BaseAMI},
+	})
+	if err != nil || len(result.Images) == 0 {
+		check.Status = PreflightFailed
+		check.Message = fmt.Sprintf("base AMI %s not found", opts.BaseAMI)
+		return check, ""
+	}
+	image := result.Images[0]
+	if image.State != types.ImageStateAvailable {
+		check.Status = PreflightFailed
+		check.Message = fmt.Sprintf("base AMI %s is %s, not available", opts.BaseAMI, image.State)
+		return check, ""
+	}
+
+	check.Status = PreflightPassed
+	check.Message = fmt.Sprintf("%s (%s)", opts.BaseAMI, image.Architecture)
+	return check, string(image.Architecture)
+}
+
+// describeBuildInstanceType looks up the build instance type. It returns a
+// failed check if the type does not exist.
+func (p *Preflight) describeBuildInstanceType(ctx context.Context, instanceType string) (*types.InstanceTypeInfo, *PreflightCheck) {
+	result, err := p.ec2Client.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []types.InstanceType{types.InstanceType(instanceType)},
+	})
+	if err != nil || len(result.InstanceTypes) == 0 {
+		return nil, &PreflightCheck{
+			Name:    "Build instance",
+			Status:  PreflightFailed,
+			Message: fmt.Sprintf("instance type %s is not available in this region", instanceType),
+		}
+	}
+	return &result.InstanceTypes[0], nil
+}
+
+// checkArchitecture checks that the build instance can boot the base AMI and
+// that the AMI matches the architecture of the template's instances.
+func checkArchitecture(tmpl *template.Template, instanceType string, info *types.InstanceTypeInfo, amiArch string) PreflightCheck {
+	check := PreflightCheck{Name: "Architecture"}
+	if amiArch == "" {
+		check.Status = PreflightSkipped
+		check.Message = "base AMI could not be resolved"
+		return check
+	}
+
+	var supported []string
+	if info.ProcessorInfo != nil {
+		for _, arch := range info.ProcessorInfo.SupportedArchitectures {
+			supported = append(supported, string(arch))
+		}
+	}
+	if !containsString(supported, amiArch) {
+		check.Status = PreflightFailed
+		check.Message = fmt.Sprintf("build instance type %s (%s) cannot boot the %s base AMI",
+			instanceType, strings.Join(supported, ", "), amiArch)
+		return check
+	}
+
+	if templateArch := tmpl.Architecture(); templateArch != amiArch {
+		check.Status = PreflightFailed
+		check.Message = fmt.Sprintf("base AMI is %s but the template's instances are %s", amiArch, templateArch)
+		return check
+	}
+
+	check.Status = PreflightPassed
+	check.Message = fmt.Sprintf("%s build instance, base AMI, and template instances", amiArch)
+	return check
+}
+
+// checkVCPUQuota checks that the On-Demand vCPU quota covering the build
+// instance type has room for it on top of running instances.
+func (p *Preflight) checkVCPUQuota(ctx context.Context, instanceType string, info *types.InstanceTypeInfo) PreflightCheck {
+	check := PreflightCheck{Name: "vCPU quota"}
+
+	class := onDemandQuotaClassFor(instanceType)
+	if p.serviceQuota == nil {
+		check.Status = PreflightSkipped
+		check.Message = fmt.Sprintf("the %s quota is not read; check it at https://console.aws.amazon.com/servicequotas/", class.name)
+		return check
+	}
+	quota, err := p.serviceQuota(ctx, "ec2", class.code)
+	if err != nil {
+		check.Status = PreflightSkipped
+		check.Message = fmt.Sprintf("could not read the %s quota: %v", class.name, err)
+		return check
+	}
+
+	used, err := p.runningOnDemandVCPUs(ctx, class)
+	if err != nil {
+		check.Status = PreflightSkipped
+		check.Message = fmt.Sprintf("could not count running instances: %v", err)
+		return check
+	}
+
+	var needed int32
+	if info.VCpuInfo != nil {
+		needed = aws.ToInt32(info.VCpuInfo.DefaultVCpus)
+	}
+	available := int(quota) - used
+	if int(needed) > available {
+		check.Status = PreflightFailed
+		check.Message = fmt.Sprintf("%s needs %d vCPUs but only %d of %d %s are free; request an increase at https://console.aws.amazon.com/servicequotas/",
+			instanceType, needed, max(available, 0), int(quota), class.name)
+		return check
+	}
+
+	check.Status = PreflightPassed
+	check.Message = fmt.Sprintf("%s needs %d vCPUs; %d of %d %s are free",
+		instanceType, needed, available, int(quota), class.name)
+	return check
+}
+
+// runningOnDemandVCPUs counts the vCPUs of pending and running On-Demand
+// instances that count against the same quota class.
+func (p *Preflight) runningOnDemandVCPUs(ctx context.Context, class onDemandQuotaClass) (int, error) {
+	paginator := ec2.NewDescribeInstancesPaginator(p.ec2Client, &ec2.DescribeInstancesInput{
+		Filters: []types.Filter{
+			{Name: aws.String("instance-state-name"), Values: []string{"pending", "running"}},
+		},
+	})
+
+	used := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.InstanceLifecycle == types.InstanceLifecycleTypeSpot {
+					continue
+				}
+				if onDemandQuotaClassFor(string(instance.InstanceType)).code != class.code {
+					continue
+				}
+				if instance.CpuOptions != nil {
+					used += int(aws.ToInt32(instance.CpuOptions.CoreCount) * aws.ToInt32(instance.CpuOptions.ThreadsPerCore))
+				}
+			}
+		}
+	}
+	return used, nil
+}
+
+// onDemandQuotaClass is a Running On-Demand instances vCPU quota.
+type onDemandQuotaClass struct {
+	code string
+	name string
+}
+
+// onDemandQuotaClasses maps instance family prefixes to their quota, longest
+// prefixes first. Families not listed use the Standard quota.
+var onDemandQuotaClasses = []struct {
+	prefix string
+	class  onDemandQuotaClass
+}{
+	{"hpc", onDemandQuotaClass{"L-F7808C92", "Running On-Demand HPC instances vCPUs"}},
+	{"inf", onDemandQuotaClass{"L-1945791B", "Running On-Demand Inf instances vCPUs"}},
+	{"trn", onDemandQuotaClass{"L-2C3B7624", "Running On-Demand Trn instances vCPUs"}},
+	{"dl", onDemandQuotaClass{"L-6E869C2A", "Running On-Demand DL instances vCPUs"}},
+	{"vt", onDemandQuotaClass{"L-DB2E81BA", "Running On-Demand G and VT instances vCPUs"}},
+	{"u-", onDemandQuotaClass{"L-43DA4232", "Running On-Demand High Memory instances vCPUs"}},
+	{"f", onDemandQuotaClass{"L-74FC7D96", "Running On-Demand F instances vCPUs"}},
+	{"g", onDemandQuotaClass{"L-DB2E81BA", "Running On-Demand G and VT instances vCPUs"}},
+	{"p", onDemandQuotaClass{"L-417A185B", "Running On-Demand P instances vCPUs"}},
+	{"x", onDemandQuotaClass{"L-7295265B", "Running On-Demand X instances vCPUs"}},
+}
+
+// standardQuotaClass covers A, C, D, H, I, M, R, T, and Z instances.
+var standardQuotaClass = onDemandQuotaClass{"L-1216C47A", "Running On-Demand Standard instances vCPUs"}
+
+// onDemandQuotaClassFor returns the vCPU quota an instance type counts against.
+func onDemandQuotaClassFor(instanceType string) onDemandQuotaClass {
+	for _, entry := range onDemandQuotaClasses {
+		if strings.HasPrefix(instanceType, entry.prefix) {
+			return entry.class
+		}
+	}
+	return standardQuotaClass
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
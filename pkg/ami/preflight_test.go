@@ -0,0 +1,469 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// fakePreflightEC2 answers preflight lookups from fixed data.
+type fakePreflightEC2 struct {
+	subnets     []types.Subnet
+	routeTables map[string][]types.RouteTable // keyed by "subnet" or "main"
+	keyPairs    []string
+	images      []types.Image
+	typeInfo    []types.InstanceTypeInfo
+	instances   []types.Instance
+}
+
+func (f *fakePreflightEC2) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	for _, subnet := range f.subnets {
+		if aws.ToString(subnet.SubnetId) == params.SubnetIds[0] {
+			return &ec2.DescribeSubnetsOutput{Subnets: []types.Subnet{subnet}}, nil
+		}
+	}
+	return nil, fmt.Errorf("InvalidSubnetID.NotFound")
+}
+
+func (f *fakePreflightEC2) DescribeRouteTables(ctx context.Context, params *ec2.DescribeRouteTablesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRouteTablesOutput, error) {
+	key := "subnet"
+	if aws.ToString(params.Filters[0].Name) == "vpc-id" {
+		key = "main"
+	}
+	return &ec2.DescribeRouteTablesOutput{RouteTables: f.routeTables[key]}, nil
+}
+
+func (f *fakePreflightEC2) DescribeKeyPairs(ctx context.Context, params *ec2.DescribeKeyPairsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeKeyPairsOutput, error) {
+	for _, name := range f.keyPairs {
+		if name == params.KeyNames[0] {
+			return &ec2.DescribeKeyPairsOutput{KeyPairs: []types.KeyPairInfo{{KeyName: aws.String(name)}}}, nil
+		}
+	}
+	return nil, fmt.Errorf("InvalidKeyPair.NotFound")
+}
+
+func (f *fakePreflightEC2) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	for _, image := range f.images {
+		if aws.ToString(image.ImageId) == params.ImageIds[0] {
+			return &ec2.DescribeImagesOutput{Images: []types.Image{image}}, nil
+		}
+	}
+	return nil, fmt.Errorf("InvalidAMIID.NotFound")
+}
+
+func (f *fakePreflightEC2) DescribeInstanceTypes(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
+	for _, info := range f.typeInfo {
+		if info.InstanceType == params.InstanceTypes[0] {
+			return &ec2.DescribeInstanceTypesOutput{InstanceTypes: []types.InstanceTypeInfo{info}}, nil
+		}
+	}
+	return nil, fmt.Errorf("InvalidInstanceType")
+}
+
+func (f *fakePreflightEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return &ec2.DescribeInstancesOutput{Reservations: []types.Reservation{{Instances: f.instances}}}, nil
+}
+
+func instanceTypeInfo(instanceType string, vcpus int32, arch types.ArchitectureType) types.InstanceTypeInfo {
+	return types.InstanceTypeInfo{
+		InstanceType:  types.InstanceType(instanceType),
+		VCpuInfo:      &types.VCpuInfo{DefaultVCpus: aws.Int32(vcpus)},
+		ProcessorInfo: &types.ProcessorInfo{SupportedArchitectures: []types.ArchitectureType{arch}},
+	}
+}
+
+func runningInstance(instanceType string, cores int32) types.Instance {
+	return types.Instance{
+		InstanceType: types.InstanceType(instanceType),
+		CpuOptions:   &types.CpuOptions{CoreCount: aws.Int32(cores), ThreadsPerCore: aws.Int32(2)},
+	}
+}
+
+// newFakePreflight returns a preflight for an account where every check passes.
+func newFakePreflight() (*Preflight, *fakePreflightEC2) {
+	fake := &fakePreflightEC2{
+		subnets: []types.Subnet{{
+			SubnetId:         aws.String("subnet-public"),
+			VpcId:            aws.String("vpc-1"),
+			AvailabilityZone: aws.String("us-east-1a"),
+		}},
+		routeTables: map[string][]types.RouteTable{
+			"subnet": {{Routes: []types.Route{
+				{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local")},
+				{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-1"), State: types.RouteStateActive},
+			}}},
+		},
+		keyPairs: []string{"my-key"},
+		images: []types.Image{{
+			ImageId:      aws.String("ami-custom"),
+			State:        types.ImageStateAvailable,
+			Architecture: types.ArchitectureValuesX8664,
+		}},
+		typeInfo: []types.InstanceTypeInfo{
+			instanceTypeInfo("c6a.4xlarge", 16, types.ArchitectureTypeX8664),
+			instanceTypeInfo("c7g.4xlarge", 16, types.ArchitectureTypeArm64),
+		},
+		instances: []types.Instance{runningInstance("m5.2xlarge", 4)},
+	}
+	p := &Preflight{
+		ec2Client: fake,
+		latestBaseAMI: func(ctx context.Context, osName, architecture string) (string, error) {
+			return "ami-pcluster-" + architecture, nil
+		},
+		serviceQuota: func(ctx context.Context, serviceCode, quotaCode string) (float64, error) {
+			return 64, nil
+		},
+	}
+	return p, fake
+}
+
+func preflightTemplate() *template.Template {
+	return &template.Template{
+		Cluster: template.ClusterConfig{Name: "bio", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues:   []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 4}},
+		},
+		Software: template.SoftwareConfig{SpackPackages: []string{"samtools@1.17"}},
+	}
+}
+
+func preflightOptions() *BuildOptions {
+	opts := DefaultBuildOptions()
+	opts.Name = "bio-v1"
+	opts.SubnetID = "subnet-public"
+	opts.KeyName = "my-key"
+	return opts
+}
+
+// checkByName returns the named check from a preflight result.
+func checkByName(t *testing.T, result *PreflightResult, name string) PreflightCheck {
+	t.Helper()
+	for _, check := range result.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("no %q check in %+v", name, result.Checks)
+	return PreflightCheck{}
+}
+
+func TestPreflightAllPass(t *testing.T) {
+	p, _ := newFakePreflight()
+	result := p.Run(context.Background(), preflightTemplate(), preflightOptions())
+
+	if !result.Passed() {
+		t.Fatalf("Passed() = false, checks: %+v", result.Checks)
+	}
+	wantNames := []string{"Template", "Subnet", "Key pair", "Base AMI", "Architecture", "vCPU quota"}
+	if len(result.Checks) != len(wantNames) {
+		t.Fatalf("got %d checks, want %d: %+v", len(result.Checks), len(wantNames), result.Checks)
+	}
+	for i, check := range result.Checks {
+		if check.Name != wantNames[i] || check.Status != PreflightPassed {
+			t.Errorf("check %d = %+v, want %s passed", i, check, wantNames[i])
+		}
+	}
+	if msg := checkByName(t, result, "vCPU quota").Message; !strings.Contains(msg, "56 of 64") {
+		t.Errorf("quota message %q should report 56 of 64 vCPUs free", msg)
+	}
+}
+
+func TestPreflightTemplate(t *testing.T) {
+	p, _ := newFakePreflight()
+
+	tmpl := preflightTemplate()
+	tmpl.Software.SpackPackages = nil
+	result := p.Run(context.Background(), tmpl, preflightOptions())
+	if check := checkByName(t, result, "Template"); check.Status != PreflightFailed || !strings.Contains(check.Message, "no software packages") {
+		t.Errorf("Template check = %+v, want failure for no packages", check)
+	}
+
+	opts := preflightOptions()
+	opts.LicenseFiles = []string{"missing.lic:/opt/licenses/missing.lic"}
+	result = p.Run(context.Background(), preflightTemplate(), opts)
+	if check := checkByName(t, result, "Template"); check.Status != PreflightFailed {
+		t.Errorf("Template check = %+v, want failure for a missing license file", check)
+	}
+	if result.Passed() {
+		t.Error("Passed() should be false when a check fails")
+	}
+}
+
+func TestPreflightSubnet(t *testing.T) {
+	tests := []struct {
+		name        string
+		subnetID    string
+		routeTables map[string][]types.RouteTable
+		wantStatus  PreflightStatus
+		wantMessage string
+	}{
+		{
+			name:        "missing subnet",
+			subnetID:    "subnet-missing",
+			wantStatus:  PreflightFailed,
+			wantMessage: "failed to describe subnet subnet-missing",
+		},
+		{
+			name:     "main route table with NAT gateway",
+			subnetID: "subnet-public",
+			routeTables: map[string][]types.RouteTable{
+				"main": {{Routes: []types.Route{{DestinationCidrBlock: aws.String("0.0.0.0/0"), NatGatewayId: aws.String("nat-1")}}}},
+			},
+			wantStatus:  PreflightPassed,
+			wantMessage: "via nat-1",
+		},
+		{
+			name:     "no default route",
+			subnetID: "subnet-public",
+			routeTables: map[string][]types.RouteTable{
+				"subnet": {{Routes: []types.Route{{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local")}}}},
+			},
+			wantStatus:  PreflightFailed,
+			wantMessage: "no active 0.0.0.0/0 route",
+		},
+		{
+			name:     "blackholed internet gateway",
+			subnetID: "subnet-public",
+			routeTables: map[string][]types.RouteTable{
+				"subnet": {{Routes: []types.Route{{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-1"), State: types.RouteStateBlackhole}}}},
+			},
+			wantStatus:  PreflightFailed,
+			wantMessage: "no active 0.0.0.0/0 route",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, fake := newFakePreflight()
+			if tt.routeTables != nil {
+				fake.routeTables = tt.routeTables
+			}
+			check := p.checkSubnet(context.Background(), tt.subnetID)
+			if check.Status != tt.wantStatus || !strings.Contains(check.Message, tt.wantMessage) {
+				t.Errorf("checkSubnet() = %+v, want %s containing %q", check, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestPreflightKeyPair(t *testing.T) {
+	p, _ := newFakePreflight()
+
+	if check := p.checkKeyPair(context.Background(), "my-key"); check.Status != PreflightPassed {
+		t.Errorf("checkKeyPair(my-key) = %+v, want passed", check)
+	}
+	if check := p.checkKeyPair(context.Background(), "other-key"); check.Status != PreflightFailed {
+		t.Errorf("checkKeyPair(other-key) = %+v, want failed", check)
+	}
+	if check := p.checkKeyPair(context.Background(), ""); check.Status != PreflightSkipped {
+		t.Errorf("checkKeyPair(\"\") = %+v, want skipped", check)
+	}
+}
+
+func TestPreflightBaseAMI(t *testing.T) {
+	p, fake := newFakePreflight()
+	tmpl := preflightTemplate()
+
+	opts := preflightOptions()
+	check, arch := p.checkBaseAMI(context.Background(), tmpl, opts)
+	if check.Status != PreflightPassed || arch != "x86_64" || !strings.Contains(check.Message, "ami-pcluster-x86_64") {
+		t.Errorf("checkBaseAMI() = %+v, %q, want auto-detected x86_64 AMI", check, arch)
+	}
+
+	opts.BaseAMI = "ami-custom"
+	if check, arch := p.checkBaseAMI(context.Background(), tmpl, opts); check.Status != PreflightPassed || arch != "x86_64" {
+		t.Errorf("checkBaseAMI(ami-custom) = %+v, %q, want passed x86_64", check, arch)
+	}
+
+	fake.images[0].State = types.ImageStatePending
+	if check, _ := p.checkBaseAMI(context.Background(), tmpl, opts); check.Status != PreflightFailed {
+		t.Errorf("checkBaseAMI(pending) = %+v, want failed", check)
+	}
+
+	opts.BaseAMI = "ami-missing"
+	if check, arch := p.checkBaseAMI(context.Background(), tmpl, opts); check.Status != PreflightFailed || arch != "" {
+		t.Errorf("checkBaseAMI(ami-missing) = %+v, %q, want failed", check, arch)
+	}
+
+	p.latestBaseAMI = func(ctx context.Context, osName, architecture string) (string, error) {
+		return "", fmt.Errorf("no ParallelCluster AMIs found")
+	}
+	opts.BaseAMI = ""
+	if check, _ := p.checkBaseAMI(context.Background(), tmpl, opts); check.Status != PreflightFailed {
+		t.Errorf("checkBaseAMI() = %+v, want failed when no AMI resolves", check)
+	}
+}
+
+func TestPreflightArchitecture(t *testing.T) {
+	x86 := instanceTypeInfo("c6a.4xlarge", 16, types.ArchitectureTypeX8664)
+	arm := instanceTypeInfo("c7g.4xlarge", 16, types.ArchitectureTypeArm64)
+
+	armTemplate := preflightTemplate()
+	armTemplate.Compute.HeadNode = "c7g.xlarge"
+	armTemplate.Compute.Queues[0].InstanceTypes = []string{"c7g.16xlarge"}
+
+	tests := []struct {
+		name         string
+		tmpl         *template.Template
+		instanceType string
+		info         *types.InstanceTypeInfo
+		amiArch      string
+		wantStatus   PreflightStatus
+	}{
+		{"x86 throughout", preflightTemplate(), "c6a.4xlarge", &x86, "x86_64", PreflightPassed},
+		{"arm throughout", armTemplate, "c7g.4xlarge", &arm, "arm64", PreflightPassed},
+		{"x86 build instance for arm AMI", armTemplate, "c6a.4xlarge", &x86, "arm64", PreflightFailed},
+		{"AMI does not match template", preflightTemplate(), "c7g.4xlarge", &arm, "arm64", PreflightFailed},
+		{"unresolved AMI", preflightTemplate(), "c6a.4xlarge", &x86, "", PreflightSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := checkArchitecture(tt.tmpl, tt.instanceType, tt.info, tt.amiArch)
+			if check.Status != tt.wantStatus {
+				t.Errorf("checkArchitecture() = %+v, want %s", check, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPreflightBuildInstanceType(t *testing.T) {
+	p, _ := newFakePreflight()
+	opts := preflightOptions()
+	opts.InstanceType = "c99.huge"
+
+	result := p.Run(context.Background(), preflightTemplate(), opts)
+	if check := checkByName(t, result, "Build instance"); check.Status != PreflightFailed {
+		t.Errorf("Build instance check = %+v, want failed", check)
+	}
+}
+
+func TestPreflightBaseAMIFollowsBuildInstanceType(t *testing.T) {
+	p, _ := newFakePreflight()
+
+	// An x86 build instance for an arm64 template resolves an x86 base AMI,
+	// which the architecture check then rejects
+	armTemplate := preflightTemplate()
+	armTemplate.Compute.HeadNode = "c7g.xlarge"
+	result := p.Run(context.Background(), armTemplate, preflightOptions())
+	if msg := checkByName(t, result, "Base AMI").Message; !strings.Contains(msg, "ami-pcluster-x86_64") {
+		t.Errorf("Base AMI message %q should use the x86_64 AMI of the build instance", msg)
+	}
+	if check := checkByName(t, result, "Architecture"); check.Status != PreflightFailed {
+		t.Errorf("Architecture check = %+v, want failed", check)
+	}
+
+	opts := preflightOptions()
+	opts.InstanceType = DefaultBuildInstanceType(template.ArchitectureARM64)
+	result = p.Run(context.Background(), armTemplate, opts)
+	if msg := checkByName(t, result, "Base AMI").Message; !strings.Contains(msg, "ami-pcluster-arm64") {
+		t.Errorf("Base AMI message %q should use the arm64 AMI of the build instance", msg)
+	}
+	if check := checkByName(t, result, "Architecture"); check.Status != PreflightPassed {
+		t.Errorf("Architecture check = %+v, want passed", check)
+	}
+}
+
+func TestPreflightBaseAMIArchitectureOverride(t *testing.T) {
+	p, fake := newFakePreflight()
+	fake.typeInfo = append(fake.typeInfo, instanceTypeInfo("a1.4xlarge", 16, types.ArchitectureTypeArm64))
+
+	armTemplate := preflightTemplate()
+	armTemplate.Compute.HeadNode = "c7g.xlarge"
+	opts := preflightOptions()
+	opts.InstanceType = "a1.4xlarge"
+	opts.Architecture = template.ArchitectureARM64
+
+	// The family guess says x86_64; the override wins
+	result := p.Run(context.Background(), armTemplate, opts)
+	if msg := checkByName(t, result, "Base AMI").Message; !strings.Contains(msg, "ami-pcluster-arm64") {
+		t.Errorf("Base AMI message %q should use the arm64 AMI", msg)
+	}
+	if check := checkByName(t, result, "Architecture"); check.Status != PreflightPassed {
+		t.Errorf("Architecture check = %+v, want passed", check)
+	}
+}
+
+func TestPreflightVCPUQuota(t *testing.T) {
+	info := instanceTypeInfo("c6a.4xlarge", 16, types.ArchitectureTypeX8664)
+
+	p, fake := newFakePreflight()
+	// 50 standard vCPUs in use; P and Spot instances do not count
+	spot := runningInstance("m5.24xlarge", 48)
+	spot.InstanceLifecycle = types.InstanceLifecycleTypeSpot
+	fake.instances = []types.Instance{
+		runningInstance("m5.6xlarge", 12),
+		runningInstance("r5.8xlarge", 13),
+		runningInstance("p4d.24xlarge", 48),
+		spot,
+	}
+
+	var quotaCode string
+	p.serviceQuota = func(ctx context.Context, serviceCode, code string) (float64, error) {
+		quotaCode = code
+		return 64, nil
+	}
+	if check := p.checkVCPUQuota(context.Background(), "c6a.4xlarge", &info); check.Status != PreflightFailed || !strings.Contains(check.Message, "only 14 of 64") {
+		t.Errorf("checkVCPUQuota() = %+v, want failure with 14 of 64 free", check)
+	}
+	if quotaCode != standardQuotaClass.code {
+		t.Errorf("quota code = %s, want standard %s", quotaCode, standardQuotaClass.code)
+	}
+
+	fake.instances = fake.instances[1:]
+	if check := p.checkVCPUQuota(context.Background(), "c6a.4xlarge", &info); check.Status != PreflightPassed {
+		t.Errorf("checkVCPUQuota() = %+v, want passed", check)
+	}
+
+	p.serviceQuota = func(ctx context.Context, serviceCode, code string) (float64, error) {
+		return 0, fmt.Errorf("AccessDeniedException")
+	}
+	if check := p.checkVCPUQuota(context.Background(), "c6a.4xlarge", &info); check.Status != PreflightSkipped {
+		t.Errorf("checkVCPUQuota() = %+v, want skipped when the quota cannot be read", check)
+	}
+
+	p.serviceQuota = nil
+	if check := p.checkVCPUQuota(context.Background(), "c6a.4xlarge", &info); check.Status != PreflightSkipped || !strings.Contains(check.Message, "Standard instances") {
+		t.Errorf("checkVCPUQuota() = %+v, want skipped without a quota reader", check)
+	}
+}
+
+func TestOnDemandQuotaClassFor(t *testing.T) {
+	tests := map[string]string{
+		"c6a.4xlarge":     "L-1216C47A",
+		"t3.micro":        "L-1216C47A",
+		"hpc7g.16xlarge":  "L-F7808C92",
+		"g5.xlarge":       "L-DB2E81BA",
+		"p4d.24xlarge":    "L-417A185B",
+		"inf2.xlarge":     "L-1945791B",
+		"trn1.32xlarge":   "L-2C3B7624",
+		"x2idn.16xlarge":  "L-7295265B",
+		"u-6tb1.56xlarge": "L-43DA4232",
+	}
+	for instanceType, want := range tests {
+		if got := onDemandQuotaClassFor(instanceType).code; got != want {
+			t.Errorf("onDemandQuotaClassFor(%s) = %s, want %s", instanceType, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scttfrdmn/petal/pkg/bootstrap"
+	"github.com/scttfrdmn/petal/pkg/software"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// loadPreInstallScript loads the pre-install script from the build options,
+// falling back to the template's build.pre_install_script. It returns nil
+// when neither is set.
+func loadPreInstallScript(tmpl *template.Template, opts *BuildOptions) (*software.PreInstallScript, error) {
+	value := opts.PreInstallScript
+	if value == "" {
+		value = tmpl.Build.PreInstallScript
+	}
+	if value == "" {
+		return nil, nil
+	}
+	return software.LoadPreInstallScript(value)
+}
+
+// presignPreInstallScript points an S3 pre-install script at a presigned
+// download URL, so the build instance needs no access to the bucket.
+func (b *Builder) presignPreInstallScript(ctx context.Context, script *software.PreInstallScript) error {
+	if script == nil || !script.InS3() {
+		return nil
+	}
+
+	s3Manager, err := bootstrap.NewS3Manager(ctx, b.region)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 manager: %w", err)
+	}
+	url, err := s3Manager.PresignDownloadURL(ctx, script.Source, licenseURLExpiry)
+	if err != nil {
+		return fmt.Errorf("failed to presign pre-install script %s: %w", script.Source, err)
+	}
+	script.URL = url
+	return nil
+}
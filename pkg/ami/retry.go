@@ -0,0 +1,109 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go"
+)
+
+// transientRetryDelays is the wait before each retry of a transient EC2
+// error, for five attempts in all.
+var transientRetryDelays = []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second}
+
+// transientErrorCodes are EC2 error codes for throttling, service hiccups,
+// and eventual consistency, which usually succeed when retried.
+var transientErrorCodes = map[string]bool{
+	"RequestLimitExceeded":       true,
+	"Throttling":                 true,
+	"ThrottlingException":        true,
+	"InternalError":              true,
+	"ServiceUnavailable":         true,
+	"Unavailable":                true,
+	"InvalidInstanceID.NotFound": true, // a just-launched instance may not be visible yet
+}
+
+// isTransientError reports whether err is an EC2 error worth retrying.
+func isTransientError(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && transientErrorCodes[apiErr.ErrorCode()]
+}
+
+// retryTransient calls fn, retrying transient errors after each of
+// transientRetryDelays. Other errors are returned immediately.
+func retryTransient(ctx context.Context, operation string, fn func() error) error {
+	err := fn()
+	for attempt, delay := range transientRetryDelays {
+		if err == nil || !isTransientError(err) {
+			return err
+		}
+
+		fmt.Printf("   ⚠️  %s failed (attempt %d/%d): %v\n", operation, attempt+1, len(transientRetryDelays)+1, err)
+		fmt.Printf("   ⏳ Retrying in %s...\n", delay)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		err = fn()
+	}
+	return err
+}
+
+// buildInstanceAPI is the subset of the EC2 API whose build calls are
+// retried on transient errors.
+type buildInstanceAPI interface {
+	RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error)
+	StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error)
+	CreateImage(ctx context.Context, params *ec2.CreateImageInput, optFns ...func(*ec2.Options)) (*ec2.CreateImageOutput, error)
+}
+
+// runInstancesWithRetry launches instances, retrying transient errors. The
+// input should carry a ClientToken so a retry cannot launch a second instance.
+func runInstancesWithRetry(ctx context.Context, client buildInstanceAPI, input *ec2.RunInstancesInput) (*ec2.RunInstancesOutput, error) {
+	var result *ec2.RunInstancesOutput
+	err := retryTransient(ctx, "RunInstances", func() error {
+		var err error
+		result, err = client.RunInstances(ctx, input)
+		return err
+	})
+	return result, err
+}
+
+// stopInstancesWithRetry stops instances, retrying transient errors.
+func stopInstancesWithRetry(ctx context.Context, client buildInstanceAPI, input *ec2.StopInstancesInput) error {
+	return retryTransient(ctx, "StopInstances", func() error {
+		_, err := client.StopInstances(ctx, input)
+		return err
+	})
+}
+
+// createImageWithRetry creates an AMI, retrying transient errors.
+func createImageWithRetry(ctx context.Context, client buildInstanceAPI, input *ec2.CreateImageInput) (*ec2.CreateImageOutput, error) {
+	var result *ec2.CreateImageOutput
+	err := retryTransient(ctx, "CreateImage", func() error {
+		var err error
+		result, err = client.CreateImage(ctx, input)
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeBuildInstanceEC2 fails each call with the next of errs, then succeeds.
+type fakeBuildInstanceEC2 struct {
+	errs  []error
+	calls int
+}
+
+func (f *fakeBuildInstanceEC2) next() error {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return f.errs[f.calls-1]
+	}
+	return nil
+}
+
+func (f *fakeBuildInstanceEC2) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+	if err := f.next(); err != nil {
+		return nil, err
+	}
+	return &ec2.RunInstancesOutput{Instances: []types.Instance{{InstanceId: aws.String("i-build")}}}, nil
+}
+
+func (f *fakeBuildInstanceEC2) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	if err := f.next(); err != nil {
+		return nil, err
+	}
+	return &ec2.StopInstancesOutput{}, nil
+}
+
+func (f *fakeBuildInstanceEC2) CreateImage(ctx context.Context, params *ec2.CreateImageInput, optFns ...func(*ec2.Options)) (*ec2.CreateImageOutput, error) {
+	if err := f.next(); err != nil {
+		return nil, err
+	}
+	return &ec2.CreateImageOutput{ImageId: aws.String("ami-built")}, nil
+}
+
+func ec2Error(code string) error {
+	return &smithy.GenericAPIError{Code: code, Message: code}
+}
+
+// withoutRetryDelays makes retries immediate for the duration of a test.
+func withoutRetryDelays(t *testing.T) {
+	t.Helper()
+	saved := transientRetryDelays
+	transientRetryDelays = []time.Duration{0, 0, 0, 0}
+	t.Cleanup(func() { transientRetryDelays = saved })
+}
+
+func TestRetryTransientThrottling(t *testing.T) {
+	withoutRetryDelays(t)
+	ctx := context.Background()
+	throttled := []error{ec2Error("RequestLimitExceeded"), ec2Error("RequestLimitExceeded")}
+
+	fake := &fakeBuildInstanceEC2{errs: throttled}
+	run, err := runInstancesWithRetry(ctx, fake, &ec2.RunInstancesInput{})
+	if err != nil || aws.ToString(run.Instances[0].InstanceId) != "i-build" {
+		t.Errorf("runInstancesWithRetry() = %v, %v", run, err)
+	}
+	if fake.calls != 3 {
+		t.Errorf("RunInstances called %d times, want 3", fake.calls)
+	}
+
+	fake = &fakeBuildInstanceEC2{errs: throttled}
+	if err := stopInstancesWithRetry(ctx, fake, &ec2.StopInstancesInput{}); err != nil || fake.calls != 3 {
+		t.Errorf("stopInstancesWithRetry() error = %v after %d calls, want success on the third", err, fake.calls)
+	}
+
+	fake = &fakeBuildInstanceEC2{errs: []error{ec2Error("InvalidInstanceID.NotFound"), ec2Error("Unavailable")}}
+	image, err := createImageWithRetry(ctx, fake, &ec2.CreateImageInput{})
+	if err != nil || aws.ToString(image.ImageId) != "ami-built" || fake.calls != 3 {
+		t.Errorf("createImageWithRetry() = %v, %v after %d calls, want success on the third", image, err, fake.calls)
+	}
+}
+
+func TestRetryTransientFailsFast(t *testing.T) {
+	withoutRetryDelays(t)
+
+	fake := &fakeBuildInstanceEC2{errs: []error{ec2Error("InvalidSubnetID.NotFound")}}
+	_, err := runInstancesWithRetry(context.Background(), fake, &ec2.RunInstancesInput{})
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "InvalidSubnetID.NotFound" {
+		t.Errorf("runInstancesWithRetry() error = %v, want InvalidSubnetID.NotFound", err)
+	}
+	if fake.calls != 1 {
+		t.Errorf("RunInstances called %d times, want 1", fake.calls)
+	}
+}
+
+func TestRetryTransientGivesUp(t *testing.T) {
+	withoutRetryDelays(t)
+
+	errs := make([]error, 10)
+	for i := range errs {
+		errs[i] = ec2Error("RequestLimitExceeded")
+	}
+	fake := &fakeBuildInstanceEC2{errs: errs}
+	if err := stopInstancesWithRetry(context.Background(), fake, &ec2.StopInstancesInput{}); err == nil {
+		t.Error("stopInstancesWithRetry() = nil, want the last throttling error")
+	}
+	if want := len(transientRetryDelays) + 1; fake.calls != want {
+		t.Errorf("StopInstances called %d times, want %d", fake.calls, want)
+	}
+}
+
+func TestRetryTransientCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fake := &fakeBuildInstanceEC2{errs: []error{ec2Error("RequestLimitExceeded")}}
+	if _, err := createImageWithRetry(ctx, fake, &ec2.CreateImageInput{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("createImageWithRetry() error = %v, want context.Canceled", err)
+	}
+	if fake.calls != 1 {
+		t.Errorf("CreateImage called %d times, want 1", fake.calls)
+	}
+}
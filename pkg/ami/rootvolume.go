@@ -0,0 +1,81 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// MaxRootVolumeSizeGB is the largest gp3 volume EC2 supports, in GiB.
+const MaxRootVolumeSizeGB = 16384
+
+// defaultRootDeviceName is the root device of Amazon Linux AMIs, used when a
+// base AMI does not report its own.
+const defaultRootDeviceName = "/dev/xvda"
+
+// ValidateRootVolumeSize checks that sizeGB is a gp3 volume size EC2 accepts.
+// Zero keeps the base AMI's root volume size.
+func ValidateRootVolumeSize(sizeGB int) error {
+	if sizeGB < 0 || sizeGB > MaxRootVolumeSizeGB {
+		return fmt.Errorf("root volume size must be between 1 and %d GiB, got %d", MaxRootVolumeSizeGB, sizeGB)
+	}
+	return nil
+}
+
+// withRootVolumeSize returns mappings with the base AMI's root volume resized
+// to sizeGB on gp3, updating the root device's existing mapping if there is
+// one. The AMI created from the build instance keeps the larger root volume,
+// so clusters launched from it get it too. EC2 cannot shrink a volume below
+// its snapshot, so a size smaller than the base AMI's root volume is an error.
+func withRootVolumeSize(mappings []types.BlockDeviceMapping, img types.Image, sizeGB int) ([]types.BlockDeviceMapping, error) {
+	rootDevice := aws.ToString(img.RootDeviceName)
+	if rootDevice == "" {
+		rootDevice = defaultRootDeviceName
+	}
+
+	for _, bdm := range img.BlockDeviceMappings {
+		if aws.ToString(bdm.DeviceName) == rootDevice && bdm.Ebs != nil {
+			if baseSize := aws.ToInt32(bdm.Ebs.VolumeSize); int32(sizeGB) < baseSize {
+				return nil, fmt.Errorf("root volume size %d GiB is smaller than the base AMI's %d GiB root volume", sizeGB, baseSize)
+			}
+		}
+	}
+
+	resized := append([]types.BlockDeviceMapping(nil), mappings...)
+	for i, bdm := range resized {
+		if aws.ToString(bdm.DeviceName) != rootDevice {
+			continue
+		}
+		ebs := types.EbsBlockDevice{}
+		if bdm.Ebs != nil {
+			ebs = *bdm.Ebs
+		}
+		ebs.VolumeSize = aws.Int32(int32(sizeGB))
+		ebs.VolumeType = types.VolumeTypeGp3
+		resized[i].Ebs = &ebs
+		return resized, nil
+	}
+
+	return append(resized, types.BlockDeviceMapping{
+		DeviceName: aws.String(rootDevice),
+		Ebs: &types.EbsBlockDevice{
+			VolumeSize: aws.Int32(int32(sizeGB)),
+			VolumeType: types.VolumeTypeGp3,
+		},
+	}), nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestValidateRootVolumeSize(t *testing.T) {
+	for _, size := range []int{0, 8, 100, MaxRootVolumeSizeGB} {
+		if err := ValidateRootVolumeSize(size); err != nil {
+			t.Errorf("ValidateRootVolumeSize(%d) unexpected error = %v", size, err)
+		}
+	}
+	for _, size := range []int{-1, MaxRootVolumeSizeGB + 1} {
+		if err := ValidateRootVolumeSize(size); err == nil {
+			t.Errorf("ValidateRootVolumeSize(%d) expected error", size)
+		}
+	}
+}
+
+func TestWithRootVolumeSize(t *testing.T) {
+	base := types.Image{
+		RootDeviceName: aws.String("/dev/sda1"),
+		BlockDeviceMappings: []types.BlockDeviceMapping{
+			{DeviceName: aws.String("/dev/sda1"), Ebs: &types.EbsBlockDevice{SnapshotId: aws.String("snap-root"), VolumeSize: aws.Int32(40)}},
+			{DeviceName: aws.String("/dev/sdb"), Ebs: &types.EbsBlockDevice{SnapshotId: aws.String("snap-data"), VolumeSize: aws.Int32(10)}},
+		},
+	}
+
+	t.Run("adds root mapping", func(t *testing.T) {
+		mappings, err := withRootVolumeSize(nil, base, 100)
+		if err != nil {
+			t.Fatalf("withRootVolumeSize() error = %v", err)
+		}
+		if len(mappings) != 1 {
+			t.Fatalf("expected 1 mapping, got %d", len(mappings))
+		}
+		root := mappings[0]
+		if aws.ToString(root.DeviceName) != "/dev/sda1" || aws.ToInt32(root.Ebs.VolumeSize) != 100 || root.Ebs.VolumeType != types.VolumeTypeGp3 {
+			t.Errorf("root mapping = %s size=%d type=%s", aws.ToString(root.DeviceName), aws.ToInt32(root.Ebs.VolumeSize), root.Ebs.VolumeType)
+		}
+	})
+
+	t.Run("keeps encryption", func(t *testing.T) {
+		encrypted := encryptedBlockDeviceMappings(base, "alias/ami-snapshots")
+		mappings, err := withRootVolumeSize(encrypted, base, 100)
+		if err != nil {
+			t.Fatalf("withRootVolumeSize() error = %v", err)
+		}
+		if len(mappings) != 2 {
+			t.Fatalf("expected 2 mappings, got %d", len(mappings))
+		}
+		root, data := mappings[0], mappings[1]
+		if aws.ToInt32(root.Ebs.VolumeSize) != 100 || !aws.ToBool(root.Ebs.Encrypted) || aws.ToString(root.Ebs.KmsKeyId) != "alias/ami-snapshots" {
+			t.Errorf("root mapping size=%d encrypted=%v key=%q", aws.ToInt32(root.Ebs.VolumeSize), aws.ToBool(root.Ebs.Encrypted), aws.ToString(root.Ebs.KmsKeyId))
+		}
+		if data.Ebs.VolumeSize != nil {
+			t.Error("only the root volume should be resized")
+		}
+		if encrypted[0].Ebs.VolumeSize != nil {
+			t.Error("withRootVolumeSize should not modify the mappings passed in")
+		}
+	})
+
+	t.Run("default root device", func(t *testing.T) {
+		mappings, err := withRootVolumeSize(nil, types.Image{}, 50)
+		if err != nil {
+			t.Fatalf("withRootVolumeSize() error = %v", err)
+		}
+		if aws.ToString(mappings[0].DeviceName) != "/dev/xvda" {
+			t.Errorf("DeviceName = %s, want /dev/xvda", aws.ToString(mappings[0].DeviceName))
+		}
+	})
+
+	t.Run("smaller than base", func(t *testing.T) {
+		_, err := withRootVolumeSize(nil, base, 20)
+		if err == nil || !strings.Contains(err.Error(), "smaller than the base AMI's 40 GiB") {
+			t.Errorf("withRootVolumeSize() error = %v, want smaller than base error", err)
+		}
+	})
+}
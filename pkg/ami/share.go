@@ -0,0 +1,139 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// accountIDPattern matches 12-digit AWS account IDs.
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// ValidateAccountID checks that id is a 12-digit AWS account ID.
+func ValidateAccountID(id string) error {
+	if !accountIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid AWS account ID %q: must be 12 digits", id)
+	}
+	return nil
+}
+
+// ShareAMI grants accountIDs permission to launch an AMI and to create
+// volumes from its EBS snapshots, without which the AMI cannot be launched
+// in those accounts.
+func (m *Manager) ShareAMI(ctx context.Context, amiID string, accountIDs []string) error {
+	return m.modifyAccountSharing(ctx, amiID, accountIDs, false)
+}
+
+// UnshareAMI revokes the permissions ShareAMI granted to accountIDs.
+func (m *Manager) UnshareAMI(ctx context.Context, amiID string, accountIDs []string) error {
+	return m.modifyAccountSharing(ctx, amiID, accountIDs, true)
+}
+
+// SetAMIPublic makes an AMI launchable by every AWS account, or reverts
+// that. Its snapshots stay private: launching a public AMI does not need
+// them, and public snapshots would expose the AMI's data.
+func (m *Manager) SetAMIPublic(ctx context.Context, amiID string, public bool) error {
+	permissions := []types.LaunchPermission{{Group: types.PermissionGroupAll}}
+	_, err := m.builder.ec2Client.ModifyImageAttribute(ctx, &ec2.ModifyImageAttributeInput{
+		ImageId:          aws.String(amiID),
+		LaunchPermission: launchPermissionModifications(permissions, !public),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to modify launch permissions of AMI %s: %w", amiID, err)
+	}
+	return nil
+}
+
+// modifyAccountSharing adds or, with revoke, removes the launch permissions
+// of an AMI and the create-volume permissions of its snapshots for
+// accountIDs.
+func (m *Manager) modifyAccountSharing(ctx context.Context, amiID string, accountIDs []string, revoke bool) error {
+	for _, id := range accountIDs {
+		if err := ValidateAccountID(id); err != nil {
+			return err
+		}
+	}
+
+	result, err := m.builder.ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{amiID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe AMI: %w", err)
+	}
+	if len(result.Images) == 0 {
+		return fmt.Errorf("AMI %s not found", amiID)
+	}
+
+	var launch []types.LaunchPermission
+	var volume []types.CreateVolumePermission
+	for _, id := range accountIDs {
+		launch = append(launch, types.LaunchPermission{UserId: aws.String(id)})
+		volume = append(volume, types.CreateVolumePermission{UserId: aws.String(id)})
+	}
+
+	_, err = m.builder.ec2Client.ModifyImageAttribute(ctx, &ec2.ModifyImageAttributeInput{
+		ImageId:          aws.String(amiID),
+		LaunchPermission: launchPermissionModifications(launch, revoke),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to modify launch permissions of AMI %s: %w", amiID, err)
+	}
+
+	for _, snapshotID := range imageSnapshotIDs(result.Images[0]) {
+		_, err := m.builder.ec2Client.ModifySnapshotAttribute(ctx, &ec2.ModifySnapshotAttributeInput{
+			SnapshotId:             aws.String(snapshotID),
+			Attribute:              types.SnapshotAttributeNameCreateVolumePermission,
+			CreateVolumePermission: volumePermissionModifications(volume, revoke),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to modify permissions of snapshot %s: %w", snapshotID, err)
+		}
+	}
+
+	return nil
+}
+
+// launchPermissionModifications adds permissions, or removes them with revoke.
+func launchPermissionModifications(permissions []types.LaunchPermission, revoke bool) *types.LaunchPermissionModifications {
+	if revoke {
+		return &types.LaunchPermissionModifications{Remove: permissions}
+	}
+	return &types.LaunchPermissionModifications{Add: permissions}
+}
+
+// volumePermissionModifications adds permissions, or removes them with revoke.
+func volumePermissionModifications(permissions []types.CreateVolumePermission, revoke bool) *types.CreateVolumePermissionModifications {
+	if revoke {
+		return &types.CreateVolumePermissionModifications{Remove: permissions}
+	}
+	return &types.CreateVolumePermissionModifications{Add: permissions}
+}
+
+// imageSnapshotIDs returns the IDs of the EBS snapshots backing img.
+func imageSnapshotIDs(img types.Image) []string {
+	var snapshotIDs []string
+	for _, bdm := range img.BlockDeviceMappings {
+		if bdm.Ebs != nil && bdm.Ebs.SnapshotId != nil {
+			snapshotIDs = append(snapshotIDs, *bdm.Ebs.SnapshotId)
+		}
+	}
+	return snapshotIDs
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestValidateAccountID(t *testing.T) {
+	for _, id := range []string{"123456789012", "000000000001"} {
+		if err := ValidateAccountID(id); err != nil {
+			t.Errorf("ValidateAccountID(%q) error = %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "12345678901", "1234567890123", "12345678901a", "arn:aws:iam::123456789012:root"} {
+		if err := ValidateAccountID(id); err == nil {
+			t.Errorf("ValidateAccountID(%q) expected error", id)
+		}
+	}
+}
+
+func TestImageSnapshotIDs(t *testing.T) {
+	img := types.Image{
+		BlockDeviceMappings: []types.BlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &types.EbsBlockDevice{SnapshotId: aws.String("snap-root")}},
+			{DeviceName: aws.String("/dev/sdb"), VirtualName: aws.String("ephemeral0")},
+			{DeviceName: aws.String("/dev/sdc"), Ebs: &types.EbsBlockDevice{SnapshotId: aws.String("snap-data")}},
+		},
+	}
+
+	want := []string{"snap-root", "snap-data"}
+	if got := imageSnapshotIDs(img); !reflect.DeepEqual(got, want) {
+		t.Errorf("imageSnapshotIDs() = %v, want %v", got, want)
+	}
+}
+
+func TestPermissionModifications(t *testing.T) {
+	launch := []types.LaunchPermission{{UserId: aws.String("123456789012")}}
+	if mods := launchPermissionModifications(launch, false); len(mods.Add) != 1 || len(mods.Remove) != 0 {
+		t.Errorf("share should add launch permissions, got %+v", mods)
+	}
+	if mods := launchPermissionModifications(launch, true); len(mods.Add) != 0 || len(mods.Remove) != 1 {
+		t.Errorf("revoke should remove launch permissions, got %+v", mods)
+	}
+
+	volume := []types.CreateVolumePermission{{UserId: aws.String("123456789012")}}
+	if mods := volumePermissionModifications(volume, false); len(mods.Add) != 1 || len(mods.Remove) != 0 {
+		t.Errorf("share should add volume permissions, got %+v", mods)
+	}
+	if mods := volumePermissionModifications(volume, true); len(mods.Add) != 0 || len(mods.Remove) != 1 {
+		t.Errorf("revoke should remove volume permissions, got %+v", mods)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/google/uuid"
+)
+
+// TagReusable marks a build instance that was kept stopped after a
+// successful build with ReuseInstance, so more AMIs can be created from it.
+const TagReusable = "pctl:reusable"
+
+// createTagsAPI is the EC2 call used to mark a build instance reusable.
+type createTagsAPI interface {
+	CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error)
+}
+
+// markInstanceReusable tags a stopped build instance as reusable. The base
+// AMI's ParallelCluster version is recorded on the instance too, since
+// snapshots of it are made without the build's state.
+func markInstanceReusable(ctx context.Context, client createTagsAPI, instanceID string, buildState *BuildState) error {
+	tags := []types.Tag{{Key: aws.String(TagReusable), Value: aws.String("true")}}
+	if buildState.ParallelClusterVersion != "" {
+		tags = append(tags, types.Tag{Key: aws.String(TagParallelClusterVersion), Value: aws.String(buildState.ParallelClusterVersion)})
+	}
+
+	_, err := client.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{instanceID},
+		Tags:      tags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag build instance %s as reusable: %w", instanceID, err)
+	}
+	return nil
+}
+
+// snapshotAPI is the EC2 calls used to create an AMI from a kept build instance.
+type snapshotAPI interface {
+	buildInstanceAPI
+	createTagsAPI
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+}
+
+// createSnapshotImage checks that instanceID is a stopped, reusable pctl
+// build instance and starts creating an AMI named opts.Name from it. Each
+// snapshot is a build of its own: the returned state has a new build ID and
+// the new AMI's ID.
+func createSnapshotImage(ctx context.Context, client snapshotAPI, instanceID, region string, opts *BuildOptions) (*BuildState, error) {
+	result, err := client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instance %s: %w", instanceID, err)
+	}
+	if len(result.Reservations) == 0 || len(result.Reservations[0].Instances) == 0 {
+		return nil, fmt.Errorf("instance %s not found", instanceID)
+	}
+	instance := result.Reservations[0].Instances[0]
+
+	tags := make(map[string]string)
+	for _, tag := range instance.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	if tags["ManagedBy"] != "pctl" || tags["Purpose"] != "AMI-Build" {
+		return nil, fmt.Errorf("instance %s is not a pctl AMI build instance", instanceID)
+	}
+	if tags[TagReusable] != "true" {
+		return nil, fmt.Errorf("instance %s was not kept for reuse; build with 'pctl ami build --reuse-instance' to snapshot a build instance", instanceID)
+	}
+	if instance.State == nil || instance.State.Name != types.InstanceStateNameStopped {
+		state := "unknown"
+		if instance.State != nil {
+			state = string(instance.State.Name)
+		}
+		return nil, fmt.Errorf("instance %s is %s; only stopped build instances can be snapshotted", instanceID, state)
+	}
+
+	buildState := buildStateFromInstanceTags(instanceID, opts.Name, region, tags, time.Now())
+	buildState.BuildID = uuid.New().String()
+	buildState.Status = BuildStatusCreating
+	buildState.BaseAMI = aws.ToString(instance.ImageId)
+	buildState.ParallelClusterVersion = tags[TagParallelClusterVersion]
+
+	// The instance's volumes were tagged at launch, before this snapshot's
+	// tags were known; tag them too so cost tools attribute the storage
+	// billed while the instance is kept
+	if err := tagInstanceVolumes(ctx, client, instance, tags, opts.Tags); err != nil {
+		return nil, fmt.Errorf("failed to tag volumes of instance %s: %w", instanceID, err)
+	}
+
+	image, err := createImageWithRetry(ctx, client, createImageInput(instanceID, buildState.TemplateName, fingerprintTagsFromInstance(tags), opts, buildState))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AMI from instance %s: %w", instanceID, err)
+	}
+	buildState.AMIID = aws.ToString(image.ImageId)
+
+	return buildState, nil
+}
+
+// tagInstanceVolumes applies userTags to the EBS volumes of a build instance,
+// skipping any that would override a tag pctl set on the instance.
+func tagInstanceVolumes(ctx context.Context, client createTagsAPI, instance types.Instance, instanceTags, userTags map[string]string) error {
+	var volumeIDs []string
+	for _, mapping := range instance.BlockDeviceMappings {
+		if mapping.Ebs != nil && mapping.Ebs.VolumeId != nil {
+			volumeIDs = append(volumeIDs, aws.ToString(mapping.Ebs.VolumeId))
+		}
+	}
+	if len(volumeIDs) == 0 || len(userTags) == 0 {
+		return nil
+	}
+
+	var pctlTags []types.Tag
+	for _, tag := range sortedTags(instanceTags) {
+		if isPctlInstanceTag(aws.ToString(tag.Key)) {
+			pctlTags = append(pctlTags, tag)
+		}
+	}
+	tags := withUserTags(pctlTags, userTags)[len(pctlTags):]
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err := client.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: volumeIDs,
+		Tags:      tags,
+	})
+	return err
+}
+
+// isPctlInstanceTag reports whether key is one of the tags pctl sets on a
+// build instance, as opposed to a user tag.
+func isPctlInstanceTag(key string) bool {
+	switch key {
+	case "Name", "ManagedBy", "Purpose", TagTemplateName, TagSpackLockHash, TagParallelClusterVersion:
+		return true
+	}
+	return strings.HasPrefix(key, "pctl")
+}
+
+// SnapshotInstance creates an AMI named opts.Name from a build instance kept
+// stopped by a build with ReuseInstance, without reinstalling any software.
+// The instance is terminated afterwards unless opts.ReuseInstance is set
+// again, so further AMIs can be created from it.
+func (b *Builder) SnapshotInstance(ctx context.Context, instanceID string, opts *BuildOptions) (*AMIMetadata, error) {
+	fmt.Printf("📸 Creating AMI %s from build instance %s...\n", opts.Name, instanceID)
+	buildState, err := createSnapshotImage(ctx, b.ec2Client, instanceID, b.region, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.stateManager.SaveState(buildState); err != nil {
+		return nil, fmt.Errorf("failed to save build state: %w", err)
+	}
+	fmt.Printf("   Build ID: %s\n", buildState.BuildID)
+	fmt.Printf("   ✅ AMI created: %s\n\n", buildState.AMIID)
+
+	fmt.Printf("⏳ Waiting for AMI to be available...\n")
+	if err := b.waitForAMIAvailable(ctx, buildState.AMIID); err != nil {
+		b.stateManager.MarkFailed(buildState.BuildID, fmt.Sprintf("AMI failed to become available: %v", err))
+		return nil, fmt.Errorf("AMI failed to become available: %w", err)
+	}
+	fmt.Printf("   ✅ AMI is available\n\n")
+
+	if err := b.stateManager.MarkComplete(buildState.BuildID, buildState.AMIID); err != nil {
+		fmt.Printf("⚠️  Warning: Failed to update build state: %v\n", err)
+	}
+
+	if opts.ReuseInstance {
+		printReusableInstance(instanceID)
+	} else {
+		fmt.Printf("🧹 Terminating build instance %s...\n\n", instanceID)
+		if err := b.terminateInstance(ctx, instanceID); err != nil {
+			fmt.Printf("⚠️  Warning: failed to terminate build instance %s: %v\n\n", instanceID, err)
+		}
+	}
+
+	return &AMIMetadata{
+		AMIID:                  buildState.AMIID,
+		Name:                   opts.Name,
+		Description:            opts.Description,
+		Region:                 b.region,
+		CreatedAt:              time.Now(),
+		TemplateName:           buildState.TemplateName,
+		Fingerprint:            buildState.Fingerprint,
+		SpackLockHash:          buildState.SpackLockHash,
+		Tags:                   opts.Tags,
+		BaseAMI:                buildState.BaseAMI,
+		ParallelClusterVersion: buildState.ParallelClusterVersion,
+		BuildID:                buildState.BuildID,
+		Status:                 BuildStatusComplete,
+		DurationSeconds:        int64(time.Since(buildState.StartTime).Seconds()),
+	}, nil
+}
+
+// printReusableInstance explains how to use, and get rid of, a build
+// instance kept for reuse.
+func printReusableInstance(instanceID string) {
+	fmt.Printf("♻️  Build instance %s is stopped and kept for reuse (its EBS volumes are still billed)\n", instanceID)
+	fmt.Printf("   Create another AMI from it with:\n")
+	fmt.Printf("     pctl ami snapshot %s --name <ami-name> --reuse-instance\n", instanceID)
+	fmt.Printf("   Create a final AMI and terminate it with:\n")
+	fmt.Printf("     pctl ami snapshot %s --name <ami-name>\n\n", instanceID)
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// fakeSnapshotEC2 describes a single build instance and records the AMI
+// created from it.
+type fakeSnapshotEC2 struct {
+	fakeBuildInstanceEC2
+	instance types.Instance
+	image    *ec2.CreateImageInput
+	tagged   *ec2.CreateTagsInput
+}
+
+func (f *fakeSnapshotEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return &ec2.DescribeInstancesOutput{Reservations: []types.Reservation{{Instances: []types.Instance{f.instance}}}}, nil
+}
+
+func (f *fakeSnapshotEC2) CreateImage(ctx context.Context, params *ec2.CreateImageInput, optFns ...func(*ec2.Options)) (*ec2.CreateImageOutput, error) {
+	f.image = params
+	return f.fakeBuildInstanceEC2.CreateImage(ctx, params, optFns...)
+}
+
+func (f *fakeSnapshotEC2) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	f.tagged = params
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+// reusableBuildInstance returns a stopped build instance kept by a build
+// with ReuseInstance.
+func reusableBuildInstance() types.Instance {
+	tags := map[string]string{
+		"Name":                   "pctl-ami-builder",
+		"ManagedBy":              "pctl",
+		"Purpose":                "AMI-Build",
+		"pctl:build-id":          "build-1",
+		"TemplateName":           "bioinformatics",
+		"pctl:fingerprint":       "f00dfeed",
+		"pctl:package-count":     "12",
+		"pctl-progress":          "100% - Installation complete",
+		"pctl:reusable":          "true",
+		"ParallelClusterVersion": "3.14.0",
+	}
+	return types.Instance{
+		InstanceId: aws.String("i-0123"),
+		ImageId:    aws.String("ami-base"),
+		State:      &types.InstanceState{Name: types.InstanceStateNameStopped},
+		Tags:       sortedTags(tags),
+		BlockDeviceMappings: []types.InstanceBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-root")}},
+		},
+	}
+}
+
+func imageTags(input *ec2.CreateImageInput) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range input.TagSpecifications[0].Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags
+}
+
+func TestCreateSnapshotImage(t *testing.T) {
+	client := &fakeSnapshotEC2{instance: reusableBuildInstance()}
+	opts := &BuildOptions{Name: "bio-cluster-v2", Description: "second cut", Tags: map[string]string{"stage": "test", "ManagedBy": "me"}}
+
+	state, err := createSnapshotImage(context.Background(), client, "i-0123", "us-east-1", opts)
+	if err != nil {
+		t.Fatalf("createSnapshotImage() error = %v", err)
+	}
+
+	if state.AMIID != "ami-built" || state.Status != BuildStatusCreating || state.AMIName != "bio-cluster-v2" {
+		t.Errorf("unexpected state: %+v", state)
+	}
+	// Every snapshot is its own build
+	if state.BuildID == "" || state.BuildID == "build-1" {
+		t.Errorf("Expected a new build ID, got %q", state.BuildID)
+	}
+
+	if aws.ToString(client.image.InstanceId) != "i-0123" || aws.ToString(client.image.Name) != "bio-cluster-v2" {
+		t.Errorf("CreateImage called with instance %s, name %s", aws.ToString(client.image.InstanceId), aws.ToString(client.image.Name))
+	}
+
+	tags := imageTags(client.image)
+	for key, want := range map[string]string{
+		"TemplateName":           "bioinformatics",
+		"pctl:fingerprint":       "f00dfeed",
+		"BaseAMI":                "ami-base",
+		"ParallelClusterVersion": "3.14.0",
+		"stage":                  "test",
+	} {
+		if tags[key] != want {
+			t.Errorf("AMI tag %s = %q, want %q", key, tags[key], want)
+		}
+	}
+	for _, key := range []string{TagReusable, TagBuildID} {
+		if _, ok := tags[key]; ok {
+			t.Errorf("AMI should not carry the instance's %s tag", key)
+		}
+	}
+
+	// The kept instance's volumes get the snapshot's tags, but pctl's own
+	// tags are not overridden
+	if client.tagged == nil || strings.Join(client.tagged.Resources, ",") != "vol-root" {
+		t.Fatalf("Expected the instance's volumes to be tagged, got %+v", client.tagged)
+	}
+	volumeTags := make(map[string]string)
+	for _, tag := range client.tagged.Tags {
+		volumeTags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	if !reflect.DeepEqual(volumeTags, map[string]string{"stage": "test"}) {
+		t.Errorf("Expected volume tags {stage: test}, got %v", volumeTags)
+	}
+}
+
+func TestCreateSnapshotImageRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(instance *types.Instance)
+		wantErr string
+	}{
+		{"not a build instance", func(instance *types.Instance) {
+			instance.Tags = []types.Tag{{Key: aws.String("Name"), Value: aws.String("web")}}
+		}, "not a pctl AMI build instance"},
+		{"not kept for reuse", func(instance *types.Instance) {
+			var tags []types.Tag
+			for _, tag := range instance.Tags {
+				if aws.ToString(tag.Key) != TagReusable {
+					tags = append(tags, tag)
+				}
+			}
+			instance.Tags = tags
+		}, "was not kept for reuse"},
+		{"running", func(instance *types.Instance) {
+			instance.State = &types.InstanceState{Name: types.InstanceStateNameRunning}
+		}, "is running; only stopped build instances"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := reusableBuildInstance()
+			tt.modify(&instance)
+			client := &fakeSnapshotEC2{instance: instance}
+
+			_, err := createSnapshotImage(context.Background(), client, "i-0123", "us-east-1", &BuildOptions{Name: "bio-cluster-v2"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("createSnapshotImage() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if client.image != nil {
+				t.Error("No AMI should be created")
+			}
+		})
+	}
+}
+
+func TestMarkInstanceReusable(t *testing.T) {
+	client := &fakeSnapshotEC2{}
+	state := &BuildState{BuildID: "build-1", ParallelClusterVersion: "3.14.0"}
+
+	if err := markInstanceReusable(context.Background(), client, "i-0123", state); err != nil {
+		t.Fatalf("markInstanceReusable() error = %v", err)
+	}
+
+	if len(client.tagged.Resources) != 1 || client.tagged.Resources[0] != "i-0123" {
+		t.Errorf("Tagged resources = %v, want [i-0123]", client.tagged.Resources)
+	}
+	tags := make(map[string]string)
+	for _, tag := range client.tagged.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	if tags[TagReusable] != "true" || tags[TagParallelClusterVersion] != "3.14.0" {
+		t.Errorf("Instance tags = %v", tags)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scttfrdmn/petal/pkg/bootstrap"
+	"github.com/scttfrdmn/petal/pkg/software"
+)
+
+// spackLockURLExpiry is how long the build instance can download a staged
+// lockfile; it is fetched once Spack itself is installed.
+const spackLockURLExpiry = 2 * time.Hour
+
+// stageSpackLock uploads a Spack lockfile to S3 and points it at a presigned
+// download URL, since lockfiles are usually too large for EC2 user data. It
+// returns the S3 URI of the uploaded object so it can be deleted after the
+// build.
+func (b *Builder) stageSpackLock(ctx context.Context, buildID string, lock *software.SpackLock) (string, error) {
+	if lock == nil {
+		return "", nil
+	}
+
+	s3Manager, err := bootstrap.NewS3Manager(ctx, b.region)
+	if err != nil {
+		return "", fmt.Errorf("failed to create S3 manager: %w", err)
+	}
+	s3URI, err := s3Manager.UploadBuildFile(ctx, buildID, "spack.lock", lock.Content)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload spack lockfile: %w", err)
+	}
+	url, err := s3Manager.PresignDownloadURL(ctx, s3URI, spackLockURLExpiry)
+	if err != nil {
+		return s3URI, fmt.Errorf("failed to stage spack lockfile: %w", err)
+	}
+	lock.URL = url
+	fmt.Printf("   Staged spack.lock via %s\n", s3URI)
+	return s3URI, nil
+}
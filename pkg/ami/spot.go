@@ -0,0 +1,78 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// ErrSpotInterrupted is returned when EC2 reclaims a spot build instance
+// before the build finishes.
+var ErrSpotInterrupted = errors.New("spot interrupted: EC2 reclaimed the build instance; retry the build or use on-demand")
+
+// spotPricePattern matches a price in USD per hour, such as 0.25.
+var spotPricePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// ValidateSpotPrice checks that price is a positive hourly price in USD.
+func ValidateSpotPrice(price string) error {
+	if !spotPricePattern.MatchString(price) {
+		return fmt.Errorf("invalid spot price %q: must be a price in USD per hour, e.g. 0.25", price)
+	}
+	if value, _ := strconv.ParseFloat(price, 64); value <= 0 {
+		return fmt.Errorf("invalid spot price %q: must be greater than 0", price)
+	}
+	return nil
+}
+
+// spotMarketOptions requests a one-time spot instance that terminates when
+// interrupted. An empty maxPrice caps the price at the on-demand price.
+func spotMarketOptions(maxPrice string) *types.InstanceMarketOptionsRequest {
+	spot := &types.SpotMarketOptions{
+		SpotInstanceType:             types.SpotInstanceTypeOneTime,
+		InstanceInterruptionBehavior: types.InstanceInterruptionBehaviorTerminate,
+	}
+	if maxPrice != "" {
+		spot.MaxPrice = aws.String(maxPrice)
+	}
+	return &types.InstanceMarketOptionsRequest{
+		MarketType:  types.MarketTypeSpot,
+		SpotOptions: spot,
+	}
+}
+
+// spotInterrupted reports whether EC2 stopped or terminated the instance to
+// reclaim its spot capacity.
+func spotInterrupted(instance types.Instance) bool {
+	if instance.StateReason == nil {
+		return false
+	}
+	switch aws.ToString(instance.StateReason.Code) {
+	case "Server.SpotInstanceTermination", "Server.SpotInstanceShutdown":
+		return true
+	}
+	return false
+}
+
+// isSpotInstance reports whether the instance was launched as spot. One-time
+// spot instances cannot be stopped.
+func isSpotInstance(instance types.Instance) bool {
+	return instance.InstanceLifecycle == types.InstanceLifecycleTypeSpot
+}
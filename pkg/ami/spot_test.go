@@ -0,0 +1,82 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestValidateSpotPrice(t *testing.T) {
+	for _, price := range []string{"0.30", "1", "12.5"} {
+		if err := ValidateSpotPrice(price); err != nil {
+			t.Errorf("ValidateSpotPrice(%q) unexpected error = %v", price, err)
+		}
+	}
+	for _, price := range []string{"", "0", "0.00", "-1", "$0.30", "0.3.1", "cheap"} {
+		if err := ValidateSpotPrice(price); err == nil {
+			t.Errorf("ValidateSpotPrice(%q) expected error", price)
+		}
+	}
+}
+
+func TestSpotMarketOptions(t *testing.T) {
+	opts := spotMarketOptions("0.30")
+	if opts.MarketType != types.MarketTypeSpot {
+		t.Errorf("MarketType = %s, want spot", opts.MarketType)
+	}
+	spot := opts.SpotOptions
+	if spot.SpotInstanceType != types.SpotInstanceTypeOneTime || spot.InstanceInterruptionBehavior != types.InstanceInterruptionBehaviorTerminate {
+		t.Errorf("SpotOptions = %s, %s, want one-time, terminate", spot.SpotInstanceType, spot.InstanceInterruptionBehavior)
+	}
+	if aws.ToString(spot.MaxPrice) != "0.30" {
+		t.Errorf("MaxPrice = %q, want 0.30", aws.ToString(spot.MaxPrice))
+	}
+
+	if spotMarketOptions("").SpotOptions.MaxPrice != nil {
+		t.Error("MaxPrice should be unset to default to the on-demand price")
+	}
+}
+
+func TestSpotInterrupted(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason *types.StateReason
+		want   bool
+	}{
+		{"running", nil, false},
+		{"spot termination", &types.StateReason{Code: aws.String("Server.SpotInstanceTermination")}, true},
+		{"spot shutdown", &types.StateReason{Code: aws.String("Server.SpotInstanceShutdown")}, true},
+		{"user stop", &types.StateReason{Code: aws.String("Client.UserInitiatedShutdown")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spotInterrupted(types.Instance{StateReason: tt.reason}); got != tt.want {
+				t.Errorf("spotInterrupted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSpotInstance(t *testing.T) {
+	if !isSpotInstance(types.Instance{InstanceLifecycle: types.InstanceLifecycleTypeSpot}) {
+		t.Error("spot lifecycle should be reported as spot")
+	}
+	if isSpotInstance(types.Instance{}) {
+		t.Error("on-demand instance should not be reported as spot")
+	}
+}
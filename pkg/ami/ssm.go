@@ -0,0 +1,99 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/scttfrdmn/petal/internal/config"
+)
+
+// errParameterNotFound is returned when an SSM parameter does not exist.
+var errParameterNotFound = errors.New("parameter not found")
+
+// parallelClusterAMIParameter returns the public SSM parameter holding the
+// official ParallelCluster AMI ID for a version, OS, and architecture, e.g.
+// "/aws/service/parallelcluster/3.14.0/ami/alinux2/x86_64".
+func parallelClusterAMIParameter(version, osName, architecture string) (string, error) {
+	if _, ok := parallelClusterAMIOS[osName]; !ok {
+		return "", fmt.Errorf("unsupported OS: %s", osName)
+	}
+	return fmt.Sprintf("/aws/service/parallelcluster/%s/ami/%s/%s", version, osName, architecture), nil
+}
+
+// getSSMParameter returns the value of an SSM parameter, or
+// errParameterNotFound if it does not exist.
+func (b *Builder) getSSMParameter(ctx context.Context, name string) (string, error) {
+	result, err := b.ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
+		Name: aws.String(name),
+	})
+	var notFound *ssmtypes.ParameterNotFound
+	if errors.As(err, &notFound) {
+		return "", errParameterNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if result.Parameter == nil {
+		return "", errParameterNotFound
+	}
+	return aws.ToString(result.Parameter.Value), nil
+}
+
+// configuredParallelClusterVersion returns the ParallelCluster version from
+// the pctl config.
+func configuredParallelClusterVersion() string {
+	cfg, err := config.Load()
+	if err != nil || cfg.ParallelCluster.Version == "" {
+		return config.DefaultParallelClusterVersion
+	}
+	return cfg.ParallelCluster.Version
+}
+
+// findParallelClusterAMI returns the official ParallelCluster AMI for the
+// configured ParallelCluster version, OS, and architecture.
+func (b *Builder) findParallelClusterAMI(ctx context.Context, osName, architecture string) (string, error) {
+	return resolveParallelClusterAMI(ctx, configuredParallelClusterVersion(), osName, architecture, b.getSSMParameter, b.getLatestParallelClusterAMI)
+}
+
+// resolveParallelClusterAMI reads the AMI ID for version from SSM Parameter
+// Store, which pins builds to the official image for that release. When the
+// parameter cannot be read, because it does not exist or SSM access is
+// denied, it falls back to the newest AMI named for the same release.
+func resolveParallelClusterAMI(ctx context.Context, version, osName, architecture string,
+	getParameter func(ctx context.Context, name string) (string, error),
+	latestByName func(ctx context.Context, version, osName, architecture string) (string, error)) (string, error) {
+	name, err := parallelClusterAMIParameter(version, osName, architecture)
+	if err != nil {
+		return "", err
+	}
+
+	amiID, err := getParameter(ctx, name)
+	if err == nil && amiID != "" {
+		return amiID, nil
+	}
+	switch {
+	case err == nil || errors.Is(err, errParameterNotFound):
+		fmt.Printf("   ⚠️  SSM parameter %s not found, searching ParallelCluster %s AMIs by name\n", name, version)
+	default:
+		fmt.Printf("   ⚠️  Could not read SSM parameter %s (%v), searching ParallelCluster %s AMIs by name\n", name, err, version)
+	}
+	return latestByName(ctx, version, osName, architecture)
+}
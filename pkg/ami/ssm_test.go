@@ -0,0 +1,80 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParallelClusterAMIParameter(t *testing.T) {
+	name, err := parallelClusterAMIParameter("3.14.0", "alinux2", "arm64")
+	if err != nil {
+		t.Fatalf("parallelClusterAMIParameter() error = %v", err)
+	}
+	if want := "/aws/service/parallelcluster/3.14.0/ami/alinux2/arm64"; name != want {
+		t.Errorf("parallelClusterAMIParameter() = %s, want %s", name, want)
+	}
+
+	if _, err := parallelClusterAMIParameter("3.14.0", "windows", "x86_64"); err == nil {
+		t.Error("parallelClusterAMIParameter() expected error for unsupported OS")
+	}
+}
+
+func TestResolveParallelClusterAMI(t *testing.T) {
+	ctx := context.Background()
+	var gotVersion string
+	latestByName := func(ctx context.Context, version, osName, architecture string) (string, error) {
+		gotVersion = version
+		return "ami-byname", nil
+	}
+
+	tests := []struct {
+		name     string
+		paramID  string
+		paramErr error
+		want     string
+		wantErr  bool
+	}{
+		{name: "parameter found", paramID: "ami-official", want: "ami-official"},
+		{name: "parameter missing falls back to name", paramErr: errParameterNotFound, want: "ami-byname"},
+		{name: "access denied falls back to name", paramErr: errors.New("AccessDeniedException"), want: "ami-byname"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotName string
+			gotVersion = ""
+			getParameter := func(ctx context.Context, name string) (string, error) {
+				gotName = name
+				return tt.paramID, tt.paramErr
+			}
+
+			got, err := resolveParallelClusterAMI(ctx, "3.14.0", "ubuntu2204", "x86_64", getParameter, latestByName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveParallelClusterAMI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveParallelClusterAMI() = %s, want %s", got, tt.want)
+			}
+			if tt.want == "ami-byname" && gotVersion != "3.14.0" {
+				t.Errorf("name search used version %q, want 3.14.0", gotVersion)
+			}
+			if want := "/aws/service/parallelcluster/3.14.0/ami/ubuntu2204/x86_64"; gotName != want {
+				t.Errorf("looked up parameter %s, want %s", gotName, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/scttfrdmn/petal/pkg/software"
+)
+
+// ssmManagedInstancePolicyARN is the AWS managed policy that lets the SSM
+// agent on build instances register and run commands.
+const ssmManagedInstancePolicyARN = "arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore"
+
+// ssmProgressCommand prints the last progress marker from the bootstrap log.
+var ssmProgressCommand = fmt.Sprintf("grep -h 'PCTL_PROGRESS:' %s 2>/dev/null | tail -n 1", software.BootstrapLogPath)
+
+// ssmCommandTimeout bounds how long one progress poll waits for its command.
+const ssmCommandTimeout = 15 * time.Second
+
+// ssmInvocationPollInterval is how often a pending command is checked.
+var ssmInvocationPollInterval = time.Second
+
+// errSSMUnavailable is returned when an instance is not registered with SSM,
+// because its SSM agent is missing, not yet running, or lacks a role.
+var errSSMUnavailable = errors.New("instance is not managed by SSM")
+
+// ssmCommandAPI runs shell commands on instances through SSM Run Command.
+type ssmCommandAPI interface {
+	// sendSSMCommand starts a shell command and returns its command ID
+	sendSSMCommand(ctx context.Context, instanceID, command string) (string, error)
+	// getSSMCommandInvocation returns a command's status and standard output
+	getSSMCommandInvocation(ctx context.Context, commandID, instanceID string) (status, output string, err error)
+}
+
+// readSSMProgress returns the latest progress marker written by the bootstrap
+// script, read from the build instance through SSM Run Command. Unlike the
+// console output, the log is read directly, so every marker is seen as soon
+// as it is written. Returns "" if no marker has been written yet.
+func readSSMProgress(ctx context.Context, api ssmCommandAPI, instanceID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, ssmCommandTimeout)
+	defer cancel()
+
+	commandID, err := api.sendSSMCommand(ctx, instanceID, ssmProgressCommand)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		status, output, err := api.getSSMCommandInvocation(ctx, commandID, instanceID)
+		if err != nil {
+			return "", err
+		}
+		switch status {
+		case "Success":
+			return lastProgressMarker(output), nil
+		case "Pending", "InProgress", "Delayed", "":
+		default:
+			return "", fmt.Errorf("progress command %s: %s", commandID, status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("progress command %s did not finish: %w", commandID, ctx.Err())
+		case <-time.After(ssmInvocationPollInterval):
+		}
+	}
+}
+
+// lastProgressMarker returns the message of the last PCTL_PROGRESS marker in
+// output, or "" if there is none.
+func lastProgressMarker(output string) string {
+	var lastProgress string
+	for _, line := range strings.Split(output, "\n") {
+		if _, message, ok := strings.Cut(line, "PCTL_PROGRESS:"); ok {
+			lastProgress = strings.TrimSpace(message)
+		}
+	}
+	return lastProgress
+}
+
+// sendSSMCommand starts a shell command on an instance with the
+// AWS-RunShellScript document.
+func (b *Builder) sendSSMCommand(ctx context.Context, instanceID, command string) (string, error) {
+	result, err := b.ssmClient.SendCommand(ctx, &ssm.SendCommandInput{
+		InstanceIds:  []string{instanceID},
+		DocumentName: aws.String("AWS-RunShellScript"),
+		Parameters: map[string][]string{
+			"commands": {command},
+		},
+		TimeoutSeconds: aws.Int32(30),
+		Comment:        aws.String("pctl build progress"),
+	})
+	var invalidInstance *ssmtypes.InvalidInstanceId
+	if errors.As(err, &invalidInstance) {
+		return "", errSSMUnavailable
+	}
+	if err != nil {
+		return "", err
+	}
+	if result.Command == nil {
+		return "", fmt.Errorf("SSM returned no command for instance %s", instanceID)
+	}
+	return aws.ToString(result.Command.CommandId), nil
+}
+
+// getSSMCommandInvocation returns the status and standard output of a
+// command on an instance. An invocation that SSM has not recorded yet is
+// reported as pending.
+func (b *Builder) getSSMCommandInvocation(ctx context.Context, commandID, instanceID string) (string, string, error) {
+	result, err := b.ssmClient.GetCommandInvocation(ctx, &ssm.GetCommandInvocationInput{
+		CommandId:  aws.String(commandID),
+		InstanceId: aws.String(instanceID),
+	})
+	var notRecorded *ssmtypes.InvocationDoesNotExist
+	if errors.As(err, &notRecorded) {
+		return "Pending", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return string(result.Status), aws.ToString(result.StandardOutputContent), nil
+}
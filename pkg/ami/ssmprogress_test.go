@@ -0,0 +1,115 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSSMCommands returns queued invocation statuses for a progress command.
+type fakeSSMCommands struct {
+	sendErr  error
+	statuses []string
+	output   string
+	command  string
+	polls    int
+}
+
+func (f *fakeSSMCommands) sendSSMCommand(ctx context.Context, instanceID, command string) (string, error) {
+	f.command = command
+	if f.sendErr != nil {
+		return "", f.sendErr
+	}
+	return "cmd-1", nil
+}
+
+func (f *fakeSSMCommands) getSSMCommandInvocation(ctx context.Context, commandID, instanceID string) (string, string, error) {
+	status := f.statuses[f.polls]
+	f.polls++
+	if status != "Success" {
+		return status, "", nil
+	}
+	return status, f.output, nil
+}
+
+func TestReadSSMProgress(t *testing.T) {
+	ssmInvocationPollInterval = time.Millisecond
+	defer func() { ssmInvocationPollInterval = time.Second }()
+
+	tests := []struct {
+		name    string
+		fake    *fakeSSMCommands
+		want    string
+		wantErr error
+	}{
+		{
+			name: "marker after pending",
+			fake: &fakeSSMCommands{
+				statuses: []string{"Pending", "InProgress", "Success"},
+				output:   "<13>Jun 1 pctl-bootstrap: PCTL_PROGRESS: Installing gcc@13 (1/4 packages, 35%)\n",
+			},
+			want: "Installing gcc@13 (1/4 packages, 35%)",
+		},
+		{
+			name: "no marker yet",
+			fake: &fakeSSMCommands{statuses: []string{"Success"}},
+			want: "",
+		},
+		{
+			name:    "instance not managed",
+			fake:    &fakeSSMCommands{sendErr: errSSMUnavailable},
+			wantErr: errSSMUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readSSMProgress(context.Background(), tt.fake, "i-123")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("readSSMProgress() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readSSMProgress() = %q, want %q", got, tt.want)
+			}
+			if !strings.Contains(tt.fake.command, "/var/log/pctl-bootstrap.log") {
+				t.Errorf("command %q should read the bootstrap log", tt.fake.command)
+			}
+		})
+	}
+}
+
+func TestReadSSMProgressFailedCommand(t *testing.T) {
+	fake := &fakeSSMCommands{statuses: []string{"Failed"}}
+	if _, err := readSSMProgress(context.Background(), fake, "i-123"); err == nil {
+		t.Error("readSSMProgress() expected error for a failed command")
+	}
+}
+
+func TestLastProgressMarker(t *testing.T) {
+	output := "Starting pctl bootstrap\n" +
+		"PCTL_PROGRESS: Bootstrap started (0%)\n" +
+		"spack install output\n" +
+		"PCTL_PROGRESS: Finalizing installation (95%)\n" +
+		"sync\n"
+	if got := lastProgressMarker(output); got != "Finalizing installation (95%)" {
+		t.Errorf("lastProgressMarker() = %q", got)
+	}
+	if got := lastProgressMarker("no markers\n"); got != "" {
+		t.Errorf("lastProgressMarker() = %q, want empty", got)
+	}
+}
@@ -19,9 +19,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/scttfrdmn/petal/internal/config"
 )
 
 // BuildStatus represents the status of an AMI build.
@@ -40,6 +44,18 @@ const (
 	BuildStatusFailed BuildStatus = "failed"
 )
 
+// FailureCategoryDeadline marks builds that ran past their maximum
+// wall-clock time.
+const FailureCategoryDeadline = "deadline"
+
+// FailureCategoryCancelled marks builds stopped with 'pctl ami cancel', or
+// by a signal before a detached build was handed off.
+const FailureCategoryCancelled = "cancelled"
+
+// FailureCategorySpotInterrupted marks spot builds whose instance EC2
+// reclaimed.
+const FailureCategorySpotInterrupted = "spot-interrupted"
+
 // BuildState tracks the state of an AMI build.
 type BuildState struct {
 	// BuildID is a unique identifier for this build
@@ -66,8 +82,21 @@ type BuildState struct {
 	Region string `json:"region"`
 	// PackageCount is the number of packages being installed
 	PackageCount int `json:"package_count"`
+	// Fingerprint is the template fingerprint hash being built
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// BaseAMI is the AMI the build instance was launched from
+	BaseAMI string `json:"base_ami,omitempty"`
+	// ParallelClusterVersion is the ParallelCluster version of the base AMI
+	ParallelClusterVersion string `json:"parallelcluster_version,omitempty"`
+	// SpackLockHash is the SHA-256 of the spack.lock being installed, if any
+	SpackLockHash string `json:"spack_lock_hash,omitempty"`
 	// ErrorMessage is populated if the build fails
 	ErrorMessage string `json:"error_message,omitempty"`
+	// FailureCategory classifies why a failed build failed (e.g., deadline)
+	FailureCategory string `json:"failure_category,omitempty"`
+	// MilestonesNotified are the progress milestones already sent to the
+	// notification webhook
+	MilestonesNotified []int `json:"milestones_notified,omitempty"`
 }
 
 // StateManager manages AMI build state persistence.
@@ -208,6 +237,21 @@ func (sm *StateManager) CleanupOldStates(maxAge time.Duration) error {
 	return nil
 }
 
+// CleanupOnStartup prunes finished build states older than the configured
+// retention when automatic cleanup is enabled. In-progress builds are kept.
+func CleanupOnStartup(cfg *config.Config) error {
+	if !cfg.AMI.AutoCleanupBuilds || cfg.BuildRetention() <= 0 {
+		return nil
+	}
+
+	sm, err := NewStateManager()
+	if err != nil {
+		return err
+	}
+
+	return sm.CleanupOldStates(cfg.BuildRetention())
+}
+
 // UpdateProgress updates the progress for a build state.
 func (sm *StateManager) UpdateProgress(buildID string, progress int, message string) error {
 	state, err := sm.LoadState(buildID)
@@ -221,6 +265,18 @@ func (sm *StateManager) UpdateProgress(buildID string, progress int, message str
 	return sm.SaveState(state)
 }
 
+// RecordMilestones records progress milestones as notified.
+func (sm *StateManager) RecordMilestones(buildID string, milestones []int) error {
+	state, err := sm.LoadState(buildID)
+	if err != nil {
+		return err
+	}
+
+	state.MilestonesNotified = append(state.MilestonesNotified, milestones...)
+
+	return sm.SaveState(state)
+}
+
 // MarkComplete marks a build as complete.
 func (sm *StateManager) MarkComplete(buildID, amiID string) error {
 	state, err := sm.LoadState(buildID)
@@ -239,6 +295,11 @@ func (sm *StateManager) MarkComplete(buildID, amiID string) error {
 
 // MarkFailed marks a build as failed.
 func (sm *StateManager) MarkFailed(buildID string, errorMsg string) error {
+	return sm.MarkFailedWithCategory(buildID, "", errorMsg)
+}
+
+// MarkFailedWithCategory marks a build as failed for a known reason.
+func (sm *StateManager) MarkFailedWithCategory(buildID, category, errorMsg string) error {
 	state, err := sm.LoadState(buildID)
 	if err != nil {
 		return err
@@ -247,7 +308,91 @@ func (sm *StateManager) MarkFailed(buildID string, errorMsg string) error {
 	now := time.Now()
 	state.Status = BuildStatusFailed
 	state.ErrorMessage = errorMsg
+	state.FailureCategory = category
 	state.EndTime = &now
 
 	return sm.SaveState(state)
 }
+
+// BuildFilter selects build states by status and start time.
+type BuildFilter struct {
+	// Status matches builds with this status (empty matches all)
+	Status BuildStatus
+	// Since matches builds started at or after this time (zero matches all)
+	Since time.Time
+}
+
+// Sort orders for build states.
+const (
+	// SortByStarted orders builds by start time, newest first
+	SortByStarted = "started"
+	// SortByDuration orders builds by duration, longest first
+	SortByDuration = "duration"
+)
+
+// ParseBuildStatus parses a build status name.
+func ParseBuildStatus(s string) (BuildStatus, error) {
+	switch status := BuildStatus(strings.ToLower(s)); status {
+	case BuildStatusLaunching, BuildStatusInstalling, BuildStatusCreating, BuildStatusComplete, BuildStatusFailed:
+		return status, nil
+	}
+	return "", fmt.Errorf("invalid build status %q (must be one of: launching, installing, creating, complete, failed)", s)
+}
+
+// ParseAge parses an age such as "24h", "90m", or "7d".
+// It accepts any time.ParseDuration format plus a "d" suffix for days.
+func ParseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (examples: 24h, 90m, 7d)", s)
+	}
+	return d, nil
+}
+
+// Duration returns how long the build ran, or has been running if not finished.
+func (s *BuildState) Duration() time.Duration {
+	if s.EndTime != nil {
+		return s.EndTime.Sub(s.StartTime)
+	}
+	return time.Since(s.StartTime)
+}
+
+// FilterStates returns the build states matching the filter.
+func FilterStates(states []*BuildState, filter BuildFilter) []*BuildState {
+	var matched []*BuildState
+	for _, state := range states {
+		if filter.Status != "" && state.Status != filter.Status {
+			continue
+		}
+		if !filter.Since.IsZero() && state.StartTime.Before(filter.Since) {
+			continue
+		}
+		matched = append(matched, state)
+	}
+	return matched
+}
+
+// SortStates sorts build states in place by SortByStarted or SortByDuration.
+func SortStates(states []*BuildState, by string) error {
+	switch by {
+	case SortByStarted:
+		sort.SliceStable(states, func(i, j int) bool {
+			return states[i].StartTime.After(states[j].StartTime)
+		})
+	case SortByDuration:
+		sort.SliceStable(states, func(i, j int) bool {
+			return states[i].Duration() > states[j].Duration()
+		})
+	default:
+		return fmt.Errorf("invalid sort order %q (must be %s or %s)", by, SortByStarted, SortByDuration)
+	}
+	return nil
+}
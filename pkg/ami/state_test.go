@@ -19,6 +19,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/scttfrdmn/petal/internal/config"
 )
 
 func TestBuildStatus(t *testing.T) {
@@ -360,6 +362,32 @@ func TestMarkFailed(t *testing.T) {
 	}
 }
 
+func TestMarkFailedWithCategory(t *testing.T) {
+	tmpHome := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", originalHome)
+
+	sm, _ := NewStateManager()
+	state := sm.NewBuildState("test-template", "test-ami", "us-east-1", 5)
+	sm.SaveState(state)
+
+	if err := sm.MarkFailedWithCategory(state.BuildID, FailureCategoryDeadline, "Build exceeded maximum wall-clock time of 1h0m0s"); err != nil {
+		t.Fatalf("MarkFailedWithCategory() failed: %v", err)
+	}
+
+	failedState, err := sm.LoadState(state.BuildID)
+	if err != nil {
+		t.Fatalf("LoadState() failed: %v", err)
+	}
+	if failedState.Status != BuildStatusFailed {
+		t.Errorf("Expected status failed, got %s", failedState.Status)
+	}
+	if failedState.FailureCategory != FailureCategoryDeadline {
+		t.Errorf("Expected failure category %q, got %q", FailureCategoryDeadline, failedState.FailureCategory)
+	}
+}
+
 func TestCleanupOldStates(t *testing.T) {
 	tmpHome := t.TempDir()
 	originalHome := os.Getenv("HOME")
@@ -535,3 +563,228 @@ func TestBuildStateJSONSerialization(t *testing.T) {
 		t.Error("EndTime mismatch")
 	}
 }
+
+// testBuildStates returns build states with fixed times relative to now.
+func testBuildStates(now time.Time) []*BuildState {
+	ended := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+	return []*BuildState{
+		// Started 2 days ago, ran 1 hour
+		{BuildID: "old-complete", Status: BuildStatusComplete, StartTime: now.Add(-48 * time.Hour), EndTime: ended(47 * time.Hour)},
+		// Started 3 hours ago, ran 2 hours
+		{BuildID: "recent-failed", Status: BuildStatusFailed, StartTime: now.Add(-3 * time.Hour), EndTime: ended(1 * time.Hour)},
+		// Started 30 minutes ago, ran 10 minutes
+		{BuildID: "new-failed", Status: BuildStatusFailed, StartTime: now.Add(-30 * time.Minute), EndTime: ended(20 * time.Minute)},
+		// Started 5 days ago, ran 30 minutes
+		{BuildID: "ancient-failed", Status: BuildStatusFailed, StartTime: now.Add(-120 * time.Hour), EndTime: ended(119*time.Hour + 30*time.Minute)},
+		// Started 45 minutes ago, still running
+		{BuildID: "installing", Status: BuildStatusInstalling, StartTime: now.Add(-45 * time.Minute)},
+	}
+}
+
+func buildIDs(states []*BuildState) []string {
+	ids := make([]string, len(states))
+	for i, s := range states {
+		ids[i] = s.BuildID
+	}
+	return ids
+}
+
+func TestFilterAndSortStates(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		filter BuildFilter
+		sort   string
+		want   []string
+	}{
+		{
+			name: "no filter keeps order",
+			want: []string{"old-complete", "recent-failed", "new-failed", "ancient-failed", "installing"},
+		},
+		{
+			name:   "status failed",
+			filter: BuildFilter{Status: BuildStatusFailed},
+			want:   []string{"recent-failed", "new-failed", "ancient-failed"},
+		},
+		{
+			name:   "status complete",
+			filter: BuildFilter{Status: BuildStatusComplete},
+			want:   []string{"old-complete"},
+		},
+		{
+			name:   "status installing",
+			filter: BuildFilter{Status: BuildStatusInstalling},
+			want:   []string{"installing"},
+		},
+		{
+			name:   "since 24h",
+			filter: BuildFilter{Since: now.Add(-24 * time.Hour)},
+			want:   []string{"recent-failed", "new-failed", "installing"},
+		},
+		{
+			name:   "status and since",
+			filter: BuildFilter{Status: BuildStatusFailed, Since: now.Add(-24 * time.Hour)},
+			want:   []string{"recent-failed", "new-failed"},
+		},
+		{
+			name: "sort started",
+			sort: SortByStarted,
+			want: []string{"new-failed", "installing", "recent-failed", "old-complete", "ancient-failed"},
+		},
+		{
+			name: "sort duration",
+			sort: SortByDuration,
+			want: []string{"recent-failed", "old-complete", "installing", "ancient-failed", "new-failed"},
+		},
+		{
+			name:   "status with sort started",
+			filter: BuildFilter{Status: BuildStatusFailed},
+			sort:   SortByStarted,
+			want:   []string{"new-failed", "recent-failed", "ancient-failed"},
+		},
+		{
+			name:   "since with sort duration",
+			filter: BuildFilter{Since: now.Add(-24 * time.Hour)},
+			sort:   SortByDuration,
+			want:   []string{"recent-failed", "installing", "new-failed"},
+		},
+		{
+			name:   "no matches",
+			filter: BuildFilter{Status: BuildStatusCreating},
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states := FilterStates(testBuildStates(now), tt.filter)
+			if tt.sort != "" {
+				if err := SortStates(states, tt.sort); err != nil {
+					t.Fatalf("SortStates() failed: %v", err)
+				}
+			}
+
+			got := buildIDs(states)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSortStatesInvalid(t *testing.T) {
+	if err := SortStates(nil, "name"); err == nil {
+		t.Error("Expected error for invalid sort order")
+	}
+}
+
+func TestParseBuildStatus(t *testing.T) {
+	for _, s := range []string{"launching", "installing", "creating", "complete", "FAILED"} {
+		if _, err := ParseBuildStatus(s); err != nil {
+			t.Errorf("ParseBuildStatus(%q) unexpected error: %v", s, err)
+		}
+	}
+	if _, err := ParseBuildStatus("done"); err == nil {
+		t.Error("Expected error for invalid status")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"24h", 24 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"xd", 0, true},
+		{"-1h", 0, true},
+		{"yesterday", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAge(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAge(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAge(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCleanupOnStartup(t *testing.T) {
+	tmpHome := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", originalHome)
+
+	sm, _ := NewStateManager()
+
+	longAgo := time.Now().Add(-40 * 24 * time.Hour)
+	recently := time.Now().Add(-2 * 24 * time.Hour)
+
+	oldComplete := sm.NewBuildState("t", "old-complete", "us-east-1", 1)
+	oldComplete.Status = BuildStatusComplete
+	oldComplete.StartTime = longAgo
+	oldComplete.EndTime = &longAgo
+
+	oldFailed := sm.NewBuildState("t", "old-failed", "us-east-1", 1)
+	oldFailed.Status = BuildStatusFailed
+	oldFailed.StartTime = longAgo
+	oldFailed.EndTime = &longAgo
+
+	recentComplete := sm.NewBuildState("t", "recent-complete", "us-east-1", 1)
+	recentComplete.Status = BuildStatusComplete
+	recentComplete.EndTime = &recently
+
+	// Started long ago but still running
+	oldInstalling := sm.NewBuildState("t", "old-installing", "us-east-1", 1)
+	oldInstalling.Status = BuildStatusInstalling
+	oldInstalling.StartTime = longAgo
+
+	for _, s := range []*BuildState{oldComplete, oldFailed, recentComplete, oldInstalling} {
+		if err := sm.SaveState(s); err != nil {
+			t.Fatalf("SaveState() failed: %v", err)
+		}
+	}
+
+	cfg := &config.Config{}
+	cfg.AMI.BuildRetentionDays = 30
+
+	// Disabled by default: nothing is pruned
+	if err := CleanupOnStartup(cfg); err != nil {
+		t.Fatalf("CleanupOnStartup() failed: %v", err)
+	}
+	if states, _ := sm.ListStates(); len(states) != 4 {
+		t.Fatalf("Expected no states pruned when disabled, got %d remaining", len(states))
+	}
+
+	cfg.AMI.AutoCleanupBuilds = true
+	if err := CleanupOnStartup(cfg); err != nil {
+		t.Fatalf("CleanupOnStartup() failed: %v", err)
+	}
+
+	for _, pruned := range []string{oldComplete.BuildID, oldFailed.BuildID} {
+		if _, err := sm.LoadState(pruned); err == nil {
+			t.Errorf("Expected old finished build %s to be pruned", pruned)
+		}
+	}
+	for _, kept := range []string{recentComplete.BuildID, oldInstalling.BuildID} {
+		if _, err := sm.LoadState(kept); err != nil {
+			t.Errorf("Expected build %s to survive cleanup: %v", kept, err)
+		}
+	}
+}
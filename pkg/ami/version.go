@@ -0,0 +1,92 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// AMI tags recording where a pctl-built AMI came from.
+const (
+	// TagBaseAMI is the AMI the build instance was launched from
+	TagBaseAMI = "BaseAMI"
+	// TagParallelClusterVersion is the ParallelCluster version of the base AMI
+	TagParallelClusterVersion = "ParallelClusterVersion"
+	// TagSpackLockHash is the SHA-256 of the spack.lock the AMI was built from
+	TagSpackLockHash = "SpackLockHash"
+	// TagTemplateName is the name of the template the AMI was built from
+	TagTemplateName = "TemplateName"
+	// TagPackageCount is the number of Spack packages installed in the AMI
+	TagPackageCount = "PackageCount"
+)
+
+// pclusterAMINamePattern matches official ParallelCluster AMI names,
+// e.g. "aws-parallelcluster-3.14.0-amzn2-hvm-x86_64-202501010000".
+var pclusterAMINamePattern = regexp.MustCompile(`aws-parallelcluster-(\d+\.\d+\.\d+)`)
+
+// ParseParallelClusterVersion extracts the ParallelCluster version from an
+// official ParallelCluster AMI name. Returns "" if the name has no version.
+func ParseParallelClusterVersion(amiName string) string {
+	match := pclusterAMINamePattern.FindStringSubmatch(amiName)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// VersionsCompatible reports whether an AMI built for amiVersion can be used
+// with the pcluster CLI at cliVersion. ParallelCluster AMIs are tied to a
+// minor release, so the major and minor components must match.
+func VersionsCompatible(amiVersion, cliVersion string) bool {
+	return minorVersion(amiVersion) == minorVersion(cliVersion)
+}
+
+// minorVersion returns the "major.minor" prefix of a version string.
+func minorVersion(version string) string {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// getParallelClusterVersion determines the ParallelCluster version of a base
+// AMI, either from its pctl version tag (when building on a pctl AMI) or from
+// its official name. Returns "" if the version cannot be determined.
+func (b *Builder) getParallelClusterVersion(ctx context.Context, amiID string) string {
+	result, err := b.ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{amiID},
+	})
+	if err != nil || len(result.Images) == 0 {
+		fmt.Printf("   ⚠️  Could not determine ParallelCluster version of base AMI %s\n", amiID)
+		return ""
+	}
+
+	img := result.Images[0]
+	for _, tag := range img.Tags {
+		if tag.Key != nil && tag.Value != nil && *tag.Key == TagParallelClusterVersion {
+			return *tag.Value
+		}
+	}
+	if img.Name != nil {
+		return ParseParallelClusterVersion(*img.Name)
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ami
+
+import "testing"
+
+func TestParseParallelClusterVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		amiName string
+		want    string
+	}{
+		{"x86 amzn2", "aws-parallelcluster-3.14.0-amzn2-hvm-x86_64-202501010000", "3.14.0"},
+		{"arm amzn2", "aws-parallelcluster-3.9.1-amzn2-hvm-arm64-202403150000", "3.9.1"},
+		{"pctl ami", "my-bio-ami-20250101", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseParallelClusterVersion(tt.amiName); got != tt.want {
+				t.Errorf("ParseParallelClusterVersion(%q) = %q, want %q", tt.amiName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionsCompatible(t *testing.T) {
+	tests := []struct {
+		amiVersion string
+		cliVersion string
+		want       bool
+	}{
+		{"3.14.0", "3.14.0", true},
+		{"3.14.0", "3.14.1", true},
+		{"3.13.2", "3.14.0", false},
+		{"2.11.0", "3.11.0", false},
+		{"v3.14.0", "3.14.0", true},
+	}
+
+	for _, tt := range tests {
+		if got := VersionsCompatible(tt.amiVersion, tt.cliVersion); got != tt.want {
+			t.Errorf("VersionsCompatible(%q, %q) = %v, want %v", tt.amiVersion, tt.cliVersion, got, tt.want)
+		}
+	}
+}
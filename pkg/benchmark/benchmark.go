@@ -0,0 +1,321 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package benchmark provides standard benchmark jobs for comparing cluster configurations.
+package benchmark
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Suite is a standard benchmark that can be run on a cluster.
+type Suite string
+
+// Supported benchmark suites.
+const (
+	// SuiteHPL is High-Performance Linpack, reporting floating-point throughput
+	SuiteHPL Suite = "hpl"
+	// SuiteSTREAM is the STREAM memory bandwidth benchmark
+	SuiteSTREAM Suite = "stream"
+)
+
+// Suites lists the supported benchmark suites.
+var Suites = []Suite{SuiteHPL, SuiteSTREAM}
+
+// Markers written by the job scripts so results can be read without Slurm accounting.
+const (
+	markerExitCode       = "PCTL_EXIT_CODE="
+	markerElapsedSeconds = "PCTL_ELAPSED_SECONDS="
+)
+
+// ParseSuite parses a benchmark suite name.
+func ParseSuite(s string) (Suite, error) {
+	for _, suite := range Suites {
+		if string(suite) == strings.ToLower(s) {
+			return suite, nil
+		}
+	}
+	return "", fmt.Errorf("unknown benchmark suite %q (supported: hpl, stream)", s)
+}
+
+// SetupCommand returns the shell command run on the head node before the job
+// is submitted, or an empty string if no setup is needed.
+func (s Suite) SetupCommand() string {
+	switch s {
+	case SuiteSTREAM:
+		// Compute nodes may not have internet access, so fetch the source on the head node
+		return "test -f stream.c || curl -fsSL -o stream.c https://www.cs.virginia.edu/stream/FTP/Code/stream.c"
+	default:
+		return ""
+	}
+}
+
+// JobScript returns the Slurm batch script for the suite. Node count and
+// partition are passed to sbatch on the command line.
+func (s Suite) JobScript() string {
+	switch s {
+	case SuiteHPL:
+		return hplJobScript
+	case SuiteSTREAM:
+		return streamJobScript
+	default:
+		return ""
+	}
+}
+
+const hplJobScript = `#!/bin/bash -l
+#SBATCH --job-name=pctl-hpl
+#SBATCH --output=pctl-benchmark-%j.out
+#SBATCH --exclusive
+
+start=$(date +%s)
+
+# HPL is expected to be installed with Spack (software.spack_packages: [hpl])
+if command -v module >/dev/null 2>&1; then
+  module load hpl >/dev/null 2>&1 || true
+fi
+if ! command -v xhpl >/dev/null 2>&1; then
+  echo "ERROR: xhpl not found; add hpl to software.spack_packages in the seed"
+  echo "PCTL_EXIT_CODE=127"
+  exit 127
+fi
+
+workdir="$SLURM_SUBMIT_DIR/hpl-$SLURM_JOB_ID"
+mkdir -p "$workdir" && cd "$workdir"
+
+tasks=$((SLURM_JOB_NUM_NODES * $(nproc)))
+mem_kb=$(awk '/MemTotal/ {print $2}' /proc/meminfo)
+
+# Size the problem to ~80% of total memory, rounded down to the block size
+nb=192
+n=$(awk -v m="$mem_kb" -v nodes="$SLURM_JOB_NUM_NODES" -v nb="$nb" 'BEGIN { n = sqrt(0.8 * m * 1024 * nodes / 8); print int(n / nb) * nb }')
+
+# Near-square process grid with P <= Q
+p=$(awk -v t="$tasks" 'BEGIN { for (p = int(sqrt(t)); p > 1; p--) if (t % p == 0) break; print p }')
+q=$((tasks / p))
+
+cat > HPL.dat <<HPLDAT
+HPLinpack benchmark input file
+Generated by pctl benchmark
+HPL.out      output file name (if any)
+6            device out (6=stdout,7=stderr,file)
+1            # of problems sizes (N)
+$n           Ns
+1            # of NBs
+$nb          NBs
+0            PMAP process mapping (0=Row-,1=Column-major)
+1            # of process grids (P x Q)
+$p           Ps
+$q           Qs
+16.0         threshold
+1            # of panel fact
+2            PFACTs (0=left, 1=Crout, 2=Right)
+1            # of recursive stopping criterium
+4            NBMINs (>= 1)
+1            # of panels in recursion
+2            NDIVs
+1            # of recursive panel fact.
+1            RFACTs (0=left, 1=Crout, 2=Right)
+1            # of broadcast
+1            BCASTs (0=1rg,1=1rM,2=2rg,3=2rM,4=Lng,5=LnM)
+1            # of lookahead depth
+1            DEPTHs (>=0)
+2            SWAP (0=bin-exch,1=long,2=mix)
+64           swapping threshold
+0            L1 in (0=transposed,1=no-transposed) form
+0            U  in (0=transposed,1=no-transposed) form
+1            Equilibration (0=no,1=yes)
+8            memory alignment in double (> 0)
+HPLDAT
+
+mpirun -np "$tasks" xhpl
+rc=$?
+
+echo "PCTL_ELAPSED_SECONDS=$(( $(date +%s) - start ))"
+echo "PCTL_EXIT_CODE=$rc"
+`
+
+const streamJobScript = `#!/bin/bash -l
+#SBATCH --job-name=pctl-stream
+#SBATCH --output=pctl-benchmark-%j.out
+#SBATCH --nodes=1
+#SBATCH --exclusive
+
+start=$(date +%s)
+
+workdir="$SLURM_SUBMIT_DIR/stream-$SLURM_JOB_ID"
+mkdir -p "$workdir" && cd "$workdir"
+
+# Arrays are sized well beyond the last-level cache of current instance types
+gcc -O3 -march=native -fopenmp -mcmodel=medium \
+  -DSTREAM_ARRAY_SIZE=80000000 -DNTIMES=20 \
+  "$SLURM_SUBMIT_DIR/stream.c" -o stream &&
+  OMP_NUM_THREADS=$(nproc) OMP_PROC_BIND=spread ./stream
+rc=$?
+
+echo "PCTL_ELAPSED_SECONDS=$(( $(date +%s) - start ))"
+echo "PCTL_EXIT_CODE=$rc"
+`
+
+// Measurement is the headline figure from a benchmark run.
+type Measurement struct {
+	// Value is the headline result (GFLOPS for HPL, Triad MB/s for STREAM)
+	Value float64
+	// Unit is the unit of Value
+	Unit string
+	// Details holds secondary figures, such as the individual STREAM kernels
+	Details map[string]float64
+}
+
+// ParseOutput extracts the measurement from a suite's job output.
+func ParseOutput(suite Suite, output string) (*Measurement, error) {
+	switch suite {
+	case SuiteHPL:
+		return ParseHPL(output)
+	case SuiteSTREAM:
+		return ParseSTREAM(output)
+	default:
+		return nil, fmt.Errorf("unknown benchmark suite %q", suite)
+	}
+}
+
+// ParseHPL extracts the best GFLOPS figure from HPL output. Result lines look like:
+//
+//	T/V                N    NB     P     Q               Time                 Gflops
+//	WR11C2R4       81408   192     4     8             492.04             7.3221e+02
+func ParseHPL(output string) (*Measurement, error) {
+	var best float64
+	found := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.Contains(line, "FAILED") && strings.Contains(line, "||Ax-b||") {
+			return nil, fmt.Errorf("HPL residual check failed: %s", line)
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 7 || !(strings.HasPrefix(fields[0], "WR") || strings.HasPrefix(fields[0], "WC")) {
+			continue
+		}
+
+		gflops, err := strconv.ParseFloat(fields[6], 64)
+		if err != nil {
+			continue
+		}
+		if !found || gflops > best {
+			best = gflops
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no HPL result found in output")
+	}
+
+	return &Measurement{Value: best, Unit: "GFLOPS"}, nil
+}
+
+// ParseSTREAM extracts the best rates from STREAM output. Result lines look like:
+//
+//	Function    Best Rate MB/s  Avg time     Min time     Max time
+//	Triad:          45612.3     0.052913     0.052616     0.053412
+func ParseSTREAM(output string) (*Measurement, error) {
+	details := make(map[string]float64)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "Copy:", "Scale:", "Add:", "Triad:":
+			rate, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse STREAM %s rate %q: %w", strings.TrimSuffix(fields[0], ":"), fields[1], err)
+			}
+			details[strings.ToLower(strings.TrimSuffix(fields[0], ":"))] = rate
+		}
+	}
+
+	triad, ok := details["triad"]
+	if !ok {
+		return nil, fmt.Errorf("no STREAM Triad result found in output")
+	}
+
+	return &Measurement{Value: triad, Unit: "MB/s", Details: details}, nil
+}
+
+// ParseJobStatus reads the exit code and run time the job script appends to
+// its output. It fails if the job has not written them, e.g. because it was
+// killed.
+func ParseJobStatus(output string) (exitCode int, elapsed time.Duration, err error) {
+	exitCode = -1
+	elapsedSeconds := -1
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, markerExitCode):
+			exitCode, err = strconv.Atoi(strings.TrimPrefix(line, markerExitCode))
+		case strings.HasPrefix(line, markerElapsedSeconds):
+			elapsedSeconds, err = strconv.Atoi(strings.TrimPrefix(line, markerElapsedSeconds))
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse job status line %q: %w", line, err)
+		}
+	}
+
+	if exitCode < 0 || elapsedSeconds < 0 {
+		return 0, 0, fmt.Errorf("job output has no completion status (job may have been cancelled or timed out)")
+	}
+
+	return exitCode, time.Duration(elapsedSeconds) * time.Second, nil
+}
+
+// ParseJobState reads a job's state from 'squeue -h -j ID -o %T' and the
+// error it failed with, if any. squeue forgets a job some time after it
+// finishes and then fails with "Invalid job id specified"; that, like an
+// empty answer, means the job has left the queue and "" is returned. Any
+// other squeue failure, such as slurmctld not responding, is returned.
+func ParseJobState(output string, squeueErr error) (string, error) {
+	if squeueErr != nil {
+		if strings.Contains(squeueErr.Error(), "Invalid job id") {
+			return "", nil
+		}
+		return "", squeueErr
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// ParseJobID parses the job ID printed by 'sbatch --parsable', which may be
+// followed by ';<cluster>' on federated setups.
+func ParseJobID(output string) (string, error) {
+	id := strings.TrimSpace(output)
+	if i := strings.Index(id, ";"); i >= 0 {
+		id = id[:i]
+	}
+	if _, err := strconv.Atoi(id); err != nil {
+		return "", fmt.Errorf("unexpected sbatch output: %q", strings.TrimSpace(output))
+	}
+	return id, nil
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleHPLOutput = `================================================================================
+HPLinpack 2.3  --  High-Performance Linpack benchmark  --   December 2, 2018
+================================================================================
+
+T/V                N    NB     P     Q               Time                 Gflops
+--------------------------------------------------------------------------------
+WR11C2R4       81408   192     4     8             492.04             7.3221e+02
+HPL_pdgesv() start time Thu Oct 16 10:12:01 2025
+
+HPL_pdgesv() end time   Thu Oct 16 10:20:13 2025
+
+--------------------------------------------------------------------------------
+||Ax-b||_oo/(eps*(||A||_oo*||x||_oo+||b||_oo)*N)=   3.41226051e-03 ...... PASSED
+================================================================================
+
+Finished      1 tests with the following results:
+              1 tests completed and passed residual checks,
+              0 tests completed and failed residual checks,
+              0 tests skipped because of illegal input values.
+--------------------------------------------------------------------------------
+
+End of Tests.
+================================================================================
+PCTL_ELAPSED_SECONDS=501
+PCTL_EXIT_CODE=0
+`
+
+const sampleSTREAMOutput = `-------------------------------------------------------------
+STREAM version $Revision: 5.10 $
+-------------------------------------------------------------
+Array size = 80000000 (elements), Offset = 0 (elements)
+Number of Threads counted = 36
+-------------------------------------------------------------
+Function    Best Rate MB/s  Avg time     Min time     Max time
+Copy:           41870.5     0.031002     0.030570     0.031734
+Scale:          41540.8     0.031183     0.030813     0.031622
+Add:            45218.7     0.042829     0.042460     0.043310
+Triad:          45612.3     0.052913     0.042094     0.053412
+-------------------------------------------------------------
+Solution Validates: avg error less than 1.000000e-13 on all three arrays
+-------------------------------------------------------------
+PCTL_ELAPSED_SECONDS=12
+PCTL_EXIT_CODE=0
+`
+
+func TestParseSuite(t *testing.T) {
+	for _, name := range []string{"hpl", "HPL", "stream"} {
+		if _, err := ParseSuite(name); err != nil {
+			t.Errorf("ParseSuite(%q) unexpected error: %v", name, err)
+		}
+	}
+
+	if _, err := ParseSuite("linpack"); err == nil {
+		t.Error("Expected error for unknown suite")
+	}
+}
+
+func TestParseHPL(t *testing.T) {
+	m, err := ParseHPL(sampleHPLOutput)
+	if err != nil {
+		t.Fatalf("ParseHPL() failed: %v", err)
+	}
+
+	if m.Value != 732.21 {
+		t.Errorf("Expected 732.21 GFLOPS, got %v", m.Value)
+	}
+	if m.Unit != "GFLOPS" {
+		t.Errorf("Expected unit GFLOPS, got %s", m.Unit)
+	}
+}
+
+func TestParseHPLBestOfSeveral(t *testing.T) {
+	output := `WR11C2R4       40704   192     4     8             70.12             6.4101e+02
+WR11C2R4       81408   192     4     8             492.04             7.3221e+02
+WC11C2R4       81408   256     4     8             510.30             7.0602e+02
+`
+	m, err := ParseHPL(output)
+	if err != nil {
+		t.Fatalf("ParseHPL() failed: %v", err)
+	}
+	if m.Value != 732.21 {
+		t.Errorf("Expected best result 732.21, got %v", m.Value)
+	}
+}
+
+func TestParseHPLFailedResidual(t *testing.T) {
+	output := strings.Replace(sampleHPLOutput, "...... PASSED", "...... FAILED", 1)
+	if _, err := ParseHPL(output); err == nil || !strings.Contains(err.Error(), "residual check failed") {
+		t.Errorf("Expected residual check error, got %v", err)
+	}
+}
+
+func TestParseHPLNoResult(t *testing.T) {
+	if _, err := ParseHPL("ERROR: xhpl not found\nPCTL_EXIT_CODE=127\n"); err == nil {
+		t.Error("Expected error when output has no result")
+	}
+}
+
+func TestParseSTREAM(t *testing.T) {
+	m, err := ParseSTREAM(sampleSTREAMOutput)
+	if err != nil {
+		t.Fatalf("ParseSTREAM() failed: %v", err)
+	}
+
+	if m.Value != 45612.3 {
+		t.Errorf("Expected Triad 45612.3 MB/s, got %v", m.Value)
+	}
+	if m.Unit != "MB/s" {
+		t.Errorf("Expected unit MB/s, got %s", m.Unit)
+	}
+
+	expected := map[string]float64{"copy": 41870.5, "scale": 41540.8, "add": 45218.7, "triad": 45612.3}
+	for kernel, rate := range expected {
+		if m.Details[kernel] != rate {
+			t.Errorf("Expected %s rate %v, got %v", kernel, rate, m.Details[kernel])
+		}
+	}
+}
+
+func TestParseSTREAMNoTriad(t *testing.T) {
+	if _, err := ParseSTREAM("Copy:           41870.5     0.031002     0.030570     0.031734\n"); err == nil {
+		t.Error("Expected error when Triad result is missing")
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	m, err := ParseOutput(SuiteSTREAM, sampleSTREAMOutput)
+	if err != nil {
+		t.Fatalf("ParseOutput() failed: %v", err)
+	}
+	if m.Unit != "MB/s" {
+		t.Errorf("Expected STREAM measurement, got unit %s", m.Unit)
+	}
+
+	if _, err := ParseOutput(Suite("unknown"), sampleSTREAMOutput); err == nil {
+		t.Error("Expected error for unknown suite")
+	}
+}
+
+func TestParseJobStatus(t *testing.T) {
+	exitCode, elapsed, err := ParseJobStatus(sampleHPLOutput)
+	if err != nil {
+		t.Fatalf("ParseJobStatus() failed: %v", err)
+	}
+	if exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", exitCode)
+	}
+	if elapsed != 501*time.Second {
+		t.Errorf("Expected elapsed 501s, got %s", elapsed)
+	}
+
+	exitCode, _, err = ParseJobStatus("ERROR: xhpl not found\nPCTL_ELAPSED_SECONDS=0\nPCTL_EXIT_CODE=127\n")
+	if err != nil {
+		t.Fatalf("ParseJobStatus() failed: %v", err)
+	}
+	if exitCode != 127 {
+		t.Errorf("Expected exit code 127, got %d", exitCode)
+	}
+
+	if _, _, err := ParseJobStatus("slurmstepd: *** JOB 12 CANCELLED ***\n"); err == nil {
+		t.Error("Expected error when job output has no completion status")
+	}
+}
+
+func TestParseJobID(t *testing.T) {
+	tests := []struct {
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{"42\n", "42", false},
+		{"42;cluster\n", "42", false},
+		{"sbatch: error: invalid partition specified: gpu\n", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseJobID(tt.output)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseJobID(%q) error = %v, wantErr %v", tt.output, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseJobID(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestParseJobState(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		squeueErr error
+		want      string
+		wantErr   bool
+	}{
+		{"running", "RUNNING\n", nil, "RUNNING", false},
+		{"left the queue", "", nil, "", false},
+		{"forgotten", "", errors.New("exit status 1: slurm_load_jobs error: Invalid job id specified"), "", false},
+		{"controller down", "", errors.New("exit status 1: slurm_load_jobs error: Unable to contact slurm controller (connect failure)"), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJobState(tt.output, tt.squeueErr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseJobState() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseJobState() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOnDemandPrice(t *testing.T) {
+	item := `{
+  "product": {"attributes": {"instanceType": "c5n.18xlarge", "regionCode": "us-east-1"}},
+  "terms": {
+    "OnDemand": {
+      "ABC.JRTCKXETXF": {
+        "priceDimensions": {
+          "ABC.JRTCKXETXF.6YS6EN2CT7": {"unit": "Hrs", "pricePerUnit": {"USD": "3.8880000000"}}
+        }
+      }
+    }
+  }
+}`
+
+	price, err := parseOnDemandPrice(item)
+	if err != nil {
+		t.Fatalf("parseOnDemandPrice() failed: %v", err)
+	}
+	if price != 3.888 {
+		t.Errorf("Expected price 3.888, got %v", price)
+	}
+
+	if _, err := parseOnDemandPrice(`{"terms": {"OnDemand": {}}}`); err == nil {
+		t.Error("Expected error when item has no on-demand price")
+	}
+}
+
+func TestResultSetHourlyPrice(t *testing.T) {
+	result := &Result{Nodes: 4, ElapsedSeconds: 1800}
+	result.SetHourlyPrice(2.0)
+
+	if result.Cost != 4.0 {
+		t.Errorf("Expected cost 4.0 (4 nodes x 0.5h x $2), got %v", result.Cost)
+	}
+}
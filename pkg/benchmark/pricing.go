@@ -0,0 +1,103 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+)
+
+// pricingRegion is the region hosting the AWS Price List API.
+const pricingRegion = "us-east-1"
+
+// HourlyPrice looks up the on-demand Linux price of an instance type in a region.
+func HourlyPrice(ctx context.Context, region, instanceType string) (float64, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(pricingRegion))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	filter := func(field, value string) types.Filter {
+		return types.Filter{Type: types.FilterTypeTermMatch, Field: aws.String(field), Value: aws.String(value)}
+	}
+
+	output, err := pricing.NewFromConfig(cfg).GetProducts(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonEC2"),
+		Filters: []types.Filter{
+			filter("instanceType", instanceType),
+			filter("regionCode", region),
+			filter("operatingSystem", "Linux"),
+			filter("tenancy", "Shared"),
+			filter("preInstalledSw", "NA"),
+			filter("capacitystatus", "Used"),
+		},
+		MaxResults: aws.Int32(1),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get price list: %w", err)
+	}
+
+	if len(output.PriceList) == 0 {
+		return 0, fmt.Errorf("no on-demand price found for %s in %s", instanceType, region)
+	}
+
+	return parseOnDemandPrice(output.PriceList[0])
+}
+
+// priceListItem is the part of a Price List API product used for on-demand pricing.
+type priceListItem struct {
+	Terms struct {
+		OnDemand map[string]struct {
+			PriceDimensions map[string]struct {
+				Unit         string            `json:"unit"`
+				PricePerUnit map[string]string `json:"pricePerUnit"`
+			} `json:"priceDimensions"`
+		} `json:"OnDemand"`
+	} `json:"terms"`
+}
+
+// parseOnDemandPrice extracts the hourly USD price from a Price List API product.
+func parseOnDemandPrice(item string) (float64, error) {
+	var product priceListItem
+	if err := json.Unmarshal([]byte(item), &product); err != nil {
+		return 0, fmt.Errorf("failed to parse price list item: %w", err)
+	}
+
+	for _, term := range product.Terms.OnDemand {
+		for _, dimension := range term.PriceDimensions {
+			if dimension.Unit != "Hrs" {
+				continue
+			}
+			usd, ok := dimension.PricePerUnit["USD"]
+			if !ok {
+				continue
+			}
+			price, err := strconv.ParseFloat(usd, 64)
+			if err != nil {
+				return 0, fmt.Errorf("failed to parse price %q: %w", usd, err)
+			}
+			return price, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no hourly on-demand price in price list item")
+}
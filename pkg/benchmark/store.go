@@ -0,0 +1,124 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Result is a recorded benchmark run.
+type Result struct {
+	Suite   Suite  `json:"suite"`
+	Cluster string `json:"cluster"`
+	Region  string `json:"region"`
+	Queue   string `json:"queue,omitempty"`
+	// ComputeResource is the compute resource within the queue, if named
+	ComputeResource string `json:"compute_resource,omitempty"`
+	InstanceType    string `json:"instance_type,omitempty"`
+	Nodes           int    `json:"nodes"`
+	JobID           string `json:"job_id"`
+	// Value is the headline result in Unit
+	Value   float64            `json:"value"`
+	Unit    string             `json:"unit"`
+	Details map[string]float64 `json:"details,omitempty"`
+	// ElapsedSeconds is the job run time, excluding time spent queued
+	ElapsedSeconds int `json:"elapsed_seconds"`
+	// HourlyPrice is the on-demand price per node in USD, if known
+	HourlyPrice float64 `json:"hourly_price,omitempty"`
+	// Cost is the estimated on-demand cost of the run in USD
+	Cost      float64   `json:"cost,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SetHourlyPrice records the per-node price and estimates the cost of the run.
+func (r *Result) SetHourlyPrice(price float64) {
+	r.HourlyPrice = price
+	r.Cost = price * float64(r.Nodes) * float64(r.ElapsedSeconds) / 3600
+}
+
+// Store persists benchmark results.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a result store in ~/.pctl/benchmarks.
+func NewStore() (*Store, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	dir := filepath.Join(homeDir, ".pctl", "benchmarks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create benchmarks directory: %w", err)
+	}
+
+	return &Store{dir: dir}, nil
+}
+
+// Save writes a result to the store and returns its path.
+func (s *Store) Save(result *Result) (string, error) {
+	name := fmt.Sprintf("%s-%s-%s.json", result.Cluster, result.Suite, result.Timestamp.Format("20060102-150405"))
+	path := filepath.Join(s.dir, name)
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write result file: %w", err)
+	}
+
+	return path, nil
+}
+
+// List returns all stored results, oldest first.
+func (s *Store) List() ([]*Result, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmarks directory: %w", err)
+	}
+
+	var results []*Result
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		var result Result
+		if err := json.Unmarshal(data, &result); err != nil {
+			// Skip invalid result files
+			continue
+		}
+		results = append(results, &result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.Before(results[j].Timestamp)
+	})
+
+	return results, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreSaveAndList(t *testing.T) {
+	tmpHome := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	defer os.Setenv("HOME", originalHome)
+
+	store, err := NewStore()
+	if err != nil {
+		t.Fatalf("NewStore() failed: %v", err)
+	}
+
+	now := time.Now()
+	later := &Result{Suite: SuiteHPL, Cluster: "test-cluster", Nodes: 2, Value: 732.21, Unit: "GFLOPS", Timestamp: now}
+	earlier := &Result{Suite: SuiteSTREAM, Cluster: "test-cluster", Nodes: 1, Value: 45612.3, Unit: "MB/s", Timestamp: now.Add(-time.Hour)}
+
+	for _, result := range []*Result{later, earlier} {
+		path, err := store.Save(result)
+		if err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		if filepath.Dir(path) != filepath.Join(tmpHome, ".pctl", "benchmarks") {
+			t.Errorf("Expected result in ~/.pctl/benchmarks, got %s", path)
+		}
+	}
+
+	results, err := store.List()
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Suite != SuiteSTREAM || results[1].Suite != SuiteHPL {
+		t.Errorf("Expected results oldest first, got %s then %s", results[0].Suite, results[1].Suite)
+	}
+	if results[1].Value != 732.21 {
+		t.Errorf("Expected HPL value 732.21, got %v", results[1].Value)
+	}
+}
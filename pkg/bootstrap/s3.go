@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -76,22 +77,60 @@ func (m *S3Manager) UploadBootstrapScript(ctx context.Context, clusterName, scri
 	return s3URI, nil
 }
 
-// DeleteBootstrapScript deletes a bootstrap script from S3.
-func (m *S3Manager) DeleteBootstrapScript(ctx context.Context, s3URI string) error {
-	// Parse S3 URI: s3://bucket/key
-	if !strings.HasPrefix(s3URI, "s3://") {
-		return fmt.Errorf("invalid S3 URI: %s", s3URI)
+// UploadBuildFile uploads a file needed by an AMI build instance to S3 and
+// returns the S3 URI. Files are stored under ami-builds/{buildID}/.
+func (m *S3Manager) UploadBuildFile(ctx context.Context, buildID, name string, content []byte) (string, error) {
+	accountID, err := m.getAccountID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get AWS account ID: %w", err)
 	}
 
-	parts := strings.SplitN(strings.TrimPrefix(s3URI, "s3://"), "/", 2)
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid S3 URI format: %s", s3URI)
+	bucketName := fmt.Sprintf("pctl-bootstrap-%s-%s", m.region, accountID)
+	if err := m.ensureBucketExists(ctx, bucketName); err != nil {
+		return "", fmt.Errorf("failed to ensure bucket exists: %w", err)
 	}
 
-	bucketName := parts[0]
-	objectKey := parts[1]
+	objectKey := fmt.Sprintf("ami-builds/%s/%s", buildID, name)
+	_, err = m.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(objectKey),
+		Body:        bytes.NewReader(content),
+		ContentType: aws.String("application/octet-stream"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload build file: %w", err)
+	}
+
+	return fmt.Sprintf("s3://%s/%s", bucketName, objectKey), nil
+}
+
+// PresignDownloadURL returns a URL that downloads the object without AWS
+// credentials until it expires.
+func (m *S3Manager) PresignDownloadURL(ctx context.Context, s3URI string, expires time.Duration) (string, error) {
+	bucketName, objectKey, err := parseS3URI(s3URI)
+	if err != nil {
+		return "", err
+	}
 
-	_, err := m.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+	presigned, err := s3.NewPresignClient(m.s3Client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download URL: %w", err)
+	}
+
+	return presigned.URL, nil
+}
+
+// DeleteBootstrapScript deletes a bootstrap script from S3.
+func (m *S3Manager) DeleteBootstrapScript(ctx context.Context, s3URI string) error {
+	bucketName, objectKey, err := parseS3URI(s3URI)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(bucketName),
 		Key:    aws.String(objectKey),
 	})
@@ -103,6 +142,20 @@ func (m *S3Manager) DeleteBootstrapScript(ctx context.Context, s3URI string) err
 	return nil
 }
 
+// parseS3URI splits an s3://bucket/key URI.
+func parseS3URI(s3URI string) (string, string, error) {
+	if !strings.HasPrefix(s3URI, "s3://") {
+		return "", "", fmt.Errorf("invalid S3 URI: %s", s3URI)
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(s3URI, "s3://"), "/", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid S3 URI format: %s", s3URI)
+	}
+
+	return parts[0], parts[1], nil
+}
+
 func (m *S3Manager) getAccountID(ctx context.Context) (string, error) {
 	result, err := m.stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
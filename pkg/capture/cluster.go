@@ -16,6 +16,7 @@ package capture
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/scttfrdmn/petal/pkg/template"
@@ -33,6 +34,8 @@ type ClusterCapture struct {
 	Scheduler string
 	// Users are detected users (UIDs 1000-65000)
 	Users []User
+	// ContainerRuntimes are the container runtimes found on the cluster
+	ContainerRuntimes []ContainerRuntime
 }
 
 // User represents a cluster user.
@@ -68,6 +71,11 @@ func (cc *ClusterCapturer) CaptureFromCommands(outputs map[string]string) *Clust
 		capture.AvailableModules = cc.parseModuleAvail(moduleAvail)
 	}
 
+	// Lmod's module spider also lists modules hidden behind the hierarchy
+	if moduleSpider, ok := outputs["module_spider"]; ok {
+		capture.AvailableModules = mergeModules(capture.AvailableModules, cc.parseModuleSpider(moduleSpider))
+	}
+
 	// Parse module list output
 	if moduleList, ok := outputs["module_list"]; ok {
 		capture.LoadedModules = ParseModuleList(moduleList)
@@ -88,6 +96,11 @@ func (cc *ClusterCapturer) CaptureFromCommands(outputs map[string]string) *Clust
 		capture.InstalledSoftware = cc.detectInstalledSoftware(which)
 	}
 
+	// Detect container runtimes
+	if runtimes, ok := outputs["container_runtimes"]; ok {
+		capture.ContainerRuntimes = detectContainerRuntimes(runtimes)
+	}
+
 	return capture
 }
 
@@ -114,6 +127,19 @@ func (cc *ClusterCapturer) GenerateTemplate(capture *ClusterCapture, clusterName
 	// Convert available modules to Spack packages
 	spackPackages, unmapped := cc.moduleDB.ConvertModules(capture.AvailableModules)
 
+	// Container runtimes are installed with Spack so captured container
+	// workflows keep running
+	for _, runtime := range capture.ContainerRuntimes {
+		pkg := runtime.SpackPackage()
+		if pkg == "" {
+			fmt.Printf("Warning: container runtime %s cannot be installed with Spack; apptainer can run its images without a daemon\n", runtime.Name)
+			continue
+		}
+		if !slices.Contains(spackPackages, pkg) {
+			spackPackages = append(spackPackages, pkg)
+		}
+	}
+
 	tmpl.Software = template.SoftwareConfig{
 		SpackPackages: spackPackages,
 	}
@@ -167,6 +193,74 @@ func (cc *ClusterCapturer) parseModuleAvail(output string) []string {
 	return modules
 }
 
+// parseModuleSpider parses the module list printed by Lmod's `module spider`.
+// Unlike `module avail`, it includes modules in every branch of a hierarchy,
+// such as MPI builds that only become loadable after a compiler is loaded.
+// Each package is listed as "  name: name/version, name/version" and may wrap
+// onto continuation lines; descriptions are indented further.
+// Extensions, marked (E), are provided by other modules and skipped. Output
+// from module systems without spider yields no modules.
+func (cc *ClusterCapturer) parseModuleSpider(output string) []string {
+	var modules []string
+	family := ""
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "---") {
+			family = ""
+			continue
+		}
+
+		// Package line: "  gcc: gcc/9.3.0, gcc/11.2.0"
+		entries := ""
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent > 0 {
+			if name, rest, ok := strings.Cut(trimmed, ": "); ok && indent < 4 && !strings.ContainsAny(name, " \t") {
+				family = name
+				entries = rest
+			} else if family != "" && strings.HasPrefix(trimmed, family+"/") {
+				// Continuation of a wrapped version list
+				entries = trimmed
+			}
+		}
+		if entries == "" {
+			continue
+		}
+
+		for _, entry := range strings.Split(entries, ",") {
+			entry = strings.TrimSpace(entry)
+			if strings.HasSuffix(entry, "(E)") {
+				continue
+			}
+			// Drop markers such as (D) or <aL>
+			if i := strings.IndexAny(entry, " (<"); i >= 0 {
+				entry = entry[:i]
+			}
+			if entry == family || strings.HasPrefix(entry, family+"/") {
+				modules = append(modules, entry)
+			}
+		}
+	}
+
+	return modules
+}
+
+// mergeModules appends the modules in extra not already in modules, keeping
+// the first occurrence of each.
+func mergeModules(modules, extra []string) []string {
+	seen := make(map[string]bool, len(modules)+len(extra))
+	var merged []string
+	for _, list := range [][]string{modules, extra} {
+		for _, module := range list {
+			if !seen[module] {
+				seen[module] = true
+				merged = append(merged, module)
+			}
+		}
+	}
+	return merged
+}
+
 func (cc *ClusterCapturer) detectSchedulerType(output string) string {
 	output = strings.ToLower(output)
 
@@ -232,10 +326,12 @@ func (cc *ClusterCapturer) detectInstalledSoftware(output string) map[string]str
 // GenerateCaptureCommands returns a map of commands to run on the remote cluster.
 func GenerateCaptureCommands() map[string]string {
 	return map[string]string{
-		"module_avail":   "module avail 2>&1",
-		"module_list":    "module list 2>&1",
-		"scheduler_info": "which squeue sbatch qstat qsub 2>&1 || squeue --version 2>&1 || qstat --version 2>&1",
-		"user_list":      "getent passwd",
-		"which_commands": "for cmd in gcc gfortran python python3 R julia perl cmake; do echo \"$cmd: $(which $cmd 2>/dev/null)\"; done",
+		"module_avail":       "module avail 2>&1",
+		"module_spider":      "module spider 2>&1",
+		"module_list":        "module list 2>&1",
+		"scheduler_info":     "which squeue sbatch qstat qsub 2>&1 || squeue --version 2>&1 || qstat --version 2>&1",
+		"user_list":          "getent passwd",
+		"which_commands":     "for cmd in gcc gfortran python python3 R julia perl cmake; do echo \"$cmd: $(which $cmd 2>/dev/null)\"; done",
+		"container_runtimes": containerRuntimesCommand,
 	}
 }
@@ -212,6 +212,97 @@ Use "module spider" to find all possible modules and extensions.
 	}
 }
 
+const testModuleSpider = `
+-----------------------------------------------------------------------------------------------------
+The following is a list of the modules and extensions currently available:
+-----------------------------------------------------------------------------------------------------
+  fftw: fftw/3.3.10
+    FFTW: a C subroutine library for computing the discrete Fourier transform
+
+  gcc: gcc/9.3.0, gcc/11.2.0
+    The GNU Compiler Collection
+
+  intel/compiler: intel/compiler/2021.4.0
+
+  numpy: numpy/1.24.0 (E)
+
+  openmpi: openmpi/4.1.1, openmpi/4.1.5, openmpi/5.0.0, openmpi/5.0.1, openmpi/5.0.2,
+           openmpi/5.0.3
+    An open source Message Passing Interface implementation.
+
+  python: python/3.9.5, python/3.11.4 (D)
+
+-----------------------------------------------------------------------------------------------------
+
+To learn more about a package execute:
+
+   $ module spider Foo
+
+where "Foo" is the name of a module.
+
+To find detailed information about a particular package you
+must specify the version if there is more than one version:
+
+   $ module spider Foo/11.1
+
+-----------------------------------------------------------------------------------------------------
+`
+
+func TestParseModuleSpider(t *testing.T) {
+	cc := NewClusterCapturer()
+
+	modules := cc.parseModuleSpider(testModuleSpider)
+
+	expected := []string{
+		"fftw/3.3.10",
+		"gcc/9.3.0", "gcc/11.2.0",
+		"intel/compiler/2021.4.0",
+		"openmpi/4.1.1", "openmpi/4.1.5", "openmpi/5.0.0", "openmpi/5.0.1", "openmpi/5.0.2",
+		"openmpi/5.0.3",
+		"python/3.9.5", "python/3.11.4",
+	}
+	if strings.Join(modules, " ") != strings.Join(expected, " ") {
+		t.Errorf("parseModuleSpider() = %v, want %v", modules, expected)
+	}
+}
+
+func TestParseModuleSpiderWithoutLmod(t *testing.T) {
+	cc := NewClusterCapturer()
+
+	// Environment Modules has no spider subcommand
+	modules := cc.parseModuleSpider("ERROR: Invalid command 'spider'\n  Try 'module --help' for more information.\n")
+	if len(modules) != 0 {
+		t.Errorf("parseModuleSpider() = %v, want none", modules)
+	}
+}
+
+func TestCaptureFromCommandsMergesModuleSpider(t *testing.T) {
+	cc := NewClusterCapturer()
+
+	// avail only shows the Core modules; the MPI builds are hidden behind gcc
+	outputs := map[string]string{
+		"module_avail": `
+----------------------- /opt/apps/modulefiles/Core -----------------------
+gcc/9.3.0  gcc/11.2.0  python/3.11.4
+`,
+		"module_spider": testModuleSpider,
+	}
+
+	capture := cc.CaptureFromCommands(outputs)
+
+	expected := []string{
+		"gcc/9.3.0", "gcc/11.2.0", "python/3.11.4",
+		"fftw/3.3.10",
+		"intel/compiler/2021.4.0",
+		"openmpi/4.1.1", "openmpi/4.1.5", "openmpi/5.0.0", "openmpi/5.0.1", "openmpi/5.0.2",
+		"openmpi/5.0.3",
+		"python/3.9.5",
+	}
+	if strings.Join(capture.AvailableModules, " ") != strings.Join(expected, " ") {
+		t.Errorf("AvailableModules = %v, want %v", capture.AvailableModules, expected)
+	}
+}
+
 func TestDetectSchedulerType(t *testing.T) {
 	cc := NewClusterCapturer()
 
@@ -350,10 +441,12 @@ func TestGenerateCaptureCommands(t *testing.T) {
 
 	expectedKeys := []string{
 		"module_avail",
+		"module_spider",
 		"module_list",
 		"scheduler_info",
 		"user_list",
 		"which_commands",
+		"container_runtimes",
 	}
 
 	if len(commands) != len(expectedKeys) {
@@ -375,6 +468,10 @@ func TestGenerateCaptureCommands(t *testing.T) {
 		t.Error("module_avail command should contain 'module avail'")
 	}
 
+	if !strings.Contains(commands["module_spider"], "module spider") {
+		t.Error("module_spider command should contain 'module spider'")
+	}
+
 	if !strings.Contains(commands["user_list"], "getent passwd") {
 		t.Error("user_list command should contain 'getent passwd'")
 	}
@@ -0,0 +1,99 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"regexp"
+	"strings"
+)
+
+// containerRuntimeNames are the container runtimes capture looks for, in
+// the order they are reported.
+var containerRuntimeNames = []string{"singularity", "apptainer", "docker", "podman"}
+
+// containerRuntimesCommand lists the path of each container runtime, then
+// the version of each one found as "<runtime>-version: <output>".
+const containerRuntimesCommand = `for cmd in singularity apptainer docker podman; do echo "$cmd: $(which $cmd 2>/dev/null)"; done; ` +
+	`for cmd in singularity apptainer docker podman; do which $cmd >/dev/null 2>&1 && echo "$cmd-version: $($cmd --version 2>&1 | head -1)"; done`
+
+// ContainerRuntime is a container runtime found on the cluster.
+type ContainerRuntime struct {
+	// Name is singularity, apptainer, docker, or podman
+	Name string
+	// Path is where the runtime's command was found
+	Path string
+	// Version is the runtime's version, if it could be determined
+	Version string
+}
+
+// SpackPackage returns the Spack package that provides the runtime, or ""
+// if it cannot be installed with Spack. Singularity maps to SingularityCE,
+// the maintained open-source Singularity.
+func (r ContainerRuntime) SpackPackage() string {
+	switch r.Name {
+	case "singularity":
+		return "singularityce"
+	case "apptainer":
+		return "apptainer"
+	case "podman":
+		return "podman"
+	}
+	return ""
+}
+
+// versionPattern matches a dotted version number in --version output.
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// detectContainerRuntimes parses the output of containerRuntimesCommand.
+func detectContainerRuntimes(output string) []ContainerRuntime {
+	paths := make(map[string]string)
+	versions := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if runtime, ok := strings.CutSuffix(name, "-version"); ok {
+			versions[runtime] = value
+		} else if value != "" && !strings.Contains(value, "not found") {
+			paths[name] = value
+		}
+	}
+
+	var runtimes []ContainerRuntime
+	for _, name := range containerRuntimeNames {
+		path, ok := paths[name]
+		if !ok {
+			continue
+		}
+		version := versions[name]
+		// Apptainer installs a singularity command for compatibility;
+		// it is the same runtime, not a separate Singularity install
+		if name == "singularity" && strings.HasPrefix(strings.ToLower(version), "apptainer") {
+			if _, ok := paths["apptainer"]; ok {
+				continue
+			}
+			name = "apptainer"
+		}
+		runtimes = append(runtimes, ContainerRuntime{
+			Name:    name,
+			Path:    path,
+			Version: versionPattern.FindString(version),
+		})
+	}
+	return runtimes
+}
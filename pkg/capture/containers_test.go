@@ -0,0 +1,134 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestDetectContainerRuntimes(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []ContainerRuntime
+	}{
+		{
+			name: "none",
+			output: `singularity:
+apptainer:
+docker:
+podman:
+`,
+		},
+		{
+			name: "singularity",
+			output: `singularity: /usr/bin/singularity
+apptainer:
+docker:
+podman:
+singularity-version: singularity-ce version 3.11.4-1.el8
+`,
+			want: []ContainerRuntime{{Name: "singularity", Path: "/usr/bin/singularity", Version: "3.11.4"}},
+		},
+		{
+			name: "apptainer with singularity link",
+			output: `singularity: /usr/bin/singularity
+apptainer: /usr/bin/apptainer
+docker:
+podman:
+singularity-version: apptainer version 1.2.5-1.el8
+apptainer-version: apptainer version 1.2.5-1.el8
+`,
+			want: []ContainerRuntime{{Name: "apptainer", Path: "/usr/bin/apptainer", Version: "1.2.5"}},
+		},
+		{
+			name: "apptainer only as singularity",
+			output: `singularity: /opt/apptainer/bin/singularity
+apptainer:
+singularity-version: apptainer version 1.3.0
+`,
+			want: []ContainerRuntime{{Name: "apptainer", Path: "/opt/apptainer/bin/singularity", Version: "1.3.0"}},
+		},
+		{
+			name: "docker",
+			output: `singularity:
+apptainer:
+docker: /usr/bin/docker
+podman:
+docker-version: Docker version 24.0.7, build afdd53b
+`,
+			want: []ContainerRuntime{{Name: "docker", Path: "/usr/bin/docker", Version: "24.0.7"}},
+		},
+		{
+			name: "podman without version",
+			output: `singularity:
+apptainer:
+docker:
+podman: /usr/bin/podman
+`,
+			want: []ContainerRuntime{{Name: "podman", Path: "/usr/bin/podman"}},
+		},
+		{
+			name: "several",
+			output: `singularity: /usr/local/bin/singularity
+apptainer:
+docker: /usr/bin/docker
+podman: /usr/bin/podman
+singularity-version: SingularityPRO version 3.9-9.el8
+docker-version: Docker version 20.10.21, build baeda1f
+podman-version: podman version 4.9.4-rhel
+`,
+			want: []ContainerRuntime{
+				{Name: "singularity", Path: "/usr/local/bin/singularity", Version: "3.9"},
+				{Name: "docker", Path: "/usr/bin/docker", Version: "20.10.21"},
+				{Name: "podman", Path: "/usr/bin/podman", Version: "4.9.4"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectContainerRuntimes(tt.output)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectContainerRuntimes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTemplateContainerRuntimes(t *testing.T) {
+	cc := NewClusterCapturer()
+	capture := cc.CaptureFromCommands(map[string]string{
+		"container_runtimes": `singularity: /usr/bin/singularity
+apptainer:
+docker: /usr/bin/docker
+podman: /usr/bin/podman
+singularity-version: singularity-ce version 4.1.2
+`,
+	})
+
+	tmpl := cc.GenerateTemplate(capture, "my-cluster")
+
+	for _, pkg := range []string{"singularityce", "podman"} {
+		if !slices.Contains(tmpl.Software.SpackPackages, pkg) {
+			t.Errorf("Expected %s in spack packages, got %v", pkg, tmpl.Software.SpackPackages)
+		}
+	}
+	if len(tmpl.Software.SpackPackages) != 2 {
+		t.Errorf("Docker has no Spack package and should not be added, got %v", tmpl.Software.SpackPackages)
+	}
+}
@@ -18,6 +18,7 @@ package capture
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -203,7 +204,8 @@ func ParseModuleList(output string) []string {
 	return modules
 }
 
-// SuggestAlternatives suggests alternative Spack packages for unmapped modules.
+// SuggestAlternatives suggests alternative Spack packages for unmapped
+// modules, sorted by on-prem name.
 func (db *ModuleDatabase) SuggestAlternatives(moduleName string) []string {
 	normalized := normalizeModuleName(moduleName)
 	var suggestions []string
@@ -216,6 +218,7 @@ func (db *ModuleDatabase) SuggestAlternatives(moduleName string) []string {
 				mapping.OnPremName, mapping.SpackPackage, mapping.Confidence*100))
 		}
 	}
+	sort.Strings(suggestions)
 
 	return suggestions
 }
@@ -0,0 +1,144 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Confidence levels used to group module mappings in reports.
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// ConfidenceLevel buckets a mapping confidence (0.0 to 1.0).
+func ConfidenceLevel(confidence float64) string {
+	switch {
+	case confidence >= 0.9:
+		return ConfidenceHigh
+	case confidence >= 0.6:
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
+
+// ModuleReportEntry is one detected module in a confidence report.
+type ModuleReportEntry struct {
+	// Module is the on-prem module name as detected
+	Module string
+	// SpackPackage is the mapped package, empty if unmapped
+	SpackPackage string
+	// Confidence is the mapping confidence (0.0 to 1.0)
+	Confidence float64
+	// Suggestions are candidate mappings for unmapped modules
+	Suggestions []string
+}
+
+// ConfidenceReport lists how each captured module mapped to Spack, so the
+// generated template can be reviewed before building.
+type ConfidenceReport struct {
+	// Mapped are modules with a Spack package, least confident first
+	Mapped []ModuleReportEntry
+	// Unmapped are modules with no Spack package, in detection order
+	Unmapped []ModuleReportEntry
+}
+
+// ConfidenceReport builds a confidence report for the modules in a capture.
+func (cc *ClusterCapturer) ConfidenceReport(capture *ClusterCapture) *ConfidenceReport {
+	return cc.moduleDB.BuildConfidenceReport(capture.AvailableModules)
+}
+
+// BuildConfidenceReport looks up each module, recording its mapping and
+// confidence, or suggestions if it is unmapped.
+func (db *ModuleDatabase) BuildConfidenceReport(modules []string) *ConfidenceReport {
+	report := &ConfidenceReport{}
+	for _, module := range modules {
+		if mapping, ok := db.Lookup(module); ok {
+			report.Mapped = append(report.Mapped, ModuleReportEntry{
+				Module:       module,
+				SpackPackage: mapping.SpackPackage,
+				Confidence:   mapping.Confidence,
+			})
+			continue
+		}
+		report.Unmapped = append(report.Unmapped, ModuleReportEntry{
+			Module:      module,
+			Suggestions: db.SuggestAlternatives(module),
+		})
+	}
+
+	sort.SliceStable(report.Mapped, func(i, j int) bool {
+		return report.Mapped[i].Confidence < report.Mapped[j].Confidence
+	})
+
+	return report
+}
+
+// Markdown renders the report as a Markdown document.
+func (r *ConfidenceReport) Markdown() string {
+	var b strings.Builder
+
+	counts := make(map[string]int)
+	for _, entry := range r.Mapped {
+		counts[ConfidenceLevel(entry.Confidence)]++
+	}
+
+	b.WriteString("# Capture Confidence Report\n\n")
+	b.WriteString(fmt.Sprintf("%d modules detected: %d mapped (%d high, %d medium, %d low confidence), %d unmapped.\n\n",
+		len(r.Mapped)+len(r.Unmapped), len(r.Mapped),
+		counts[ConfidenceHigh], counts[ConfidenceMedium], counts[ConfidenceLow], len(r.Unmapped)))
+	b.WriteString("Review medium and low confidence mappings and unmapped modules before building.\n\n")
+
+	b.WriteString("## Mapped Modules\n\n")
+	if len(r.Mapped) == 0 {
+		b.WriteString("None.\n\n")
+	} else {
+		b.WriteString("| Module | Spack Package | Confidence |\n")
+		b.WriteString("|--------|---------------|------------|\n")
+		for _, entry := range r.Mapped {
+			b.WriteString(fmt.Sprintf("| %s | %s | %.0f%% (%s) |\n",
+				markdownCell(entry.Module), markdownCell(entry.SpackPackage),
+				entry.Confidence*100, ConfidenceLevel(entry.Confidence)))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Unmapped Modules\n\n")
+	if len(r.Unmapped) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("| Module | Suggestions |\n")
+		b.WriteString("|--------|-------------|\n")
+		for _, entry := range r.Unmapped {
+			suggestions := "none"
+			if len(entry.Suggestions) > 0 {
+				suggestions = strings.Join(entry.Suggestions, "<br>")
+			}
+			b.WriteString(fmt.Sprintf("| %s | %s |\n", markdownCell(entry.Module), markdownCell(suggestions)))
+		}
+	}
+
+	return b.String()
+}
+
+// markdownCell escapes pipes so a value stays in its table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
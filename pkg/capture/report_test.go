@@ -0,0 +1,125 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfidenceLevel(t *testing.T) {
+	tests := map[float64]string{
+		1.0:  ConfidenceHigh,
+		0.9:  ConfidenceHigh,
+		0.75: ConfidenceMedium,
+		0.6:  ConfidenceMedium,
+		0.5:  ConfidenceLow,
+		0.0:  ConfidenceLow,
+	}
+	for confidence, want := range tests {
+		if got := ConfidenceLevel(confidence); got != want {
+			t.Errorf("ConfidenceLevel(%v) = %s, want %s", confidence, got, want)
+		}
+	}
+}
+
+func newReportTestCapture() (*ClusterCapturer, *ClusterCapture) {
+	cc := NewClusterCapturer()
+	cc.moduleDB.AddMapping("abaqus", "abaqus@2023", 0.7)
+	cc.moduleDB.AddMapping("vasp", "vasp@6.4.1", 0.5)
+
+	capture := &ClusterCapture{
+		AvailableModules: []string{
+			"gcc/11.2.0",
+			"vasp/6.4.1",
+			"intel-oneapi/2023.1",
+			"abaqus/2023.1",
+			"openmpi/4.1.1",
+			"mycode/2.1",
+		},
+	}
+	return cc, capture
+}
+
+func TestBuildConfidenceReport(t *testing.T) {
+	cc, capture := newReportTestCapture()
+	report := cc.ConfidenceReport(capture)
+
+	// Mapped modules are ordered least confident first, keeping detection
+	// order for equal confidence
+	var mapped []string
+	for _, entry := range report.Mapped {
+		mapped = append(mapped, entry.Module+"="+entry.SpackPackage)
+	}
+	want := "vasp/6.4.1=vasp@6.4.1 abaqus/2023.1=abaqus@2023 gcc/11.2.0=gcc@11.3.0 openmpi/4.1.1=openmpi@4.1.4"
+	if strings.Join(mapped, " ") != want {
+		t.Errorf("Mapped = %v, want %s", mapped, want)
+	}
+
+	if len(report.Unmapped) != 2 {
+		t.Fatalf("Unmapped = %+v, want 2 entries", report.Unmapped)
+	}
+	intel := report.Unmapped[0]
+	if intel.Module != "intel-oneapi/2023.1" || len(intel.Suggestions) != 1 ||
+		!strings.Contains(intel.Suggestions[0], "intel-oneapi-compilers@2023.1.0") {
+		t.Errorf("Unmapped[0] = %+v, want intel suggestion", intel)
+	}
+	if mycode := report.Unmapped[1]; mycode.Module != "mycode/2.1" || len(mycode.Suggestions) != 0 {
+		t.Errorf("Unmapped[1] = %+v, want mycode without suggestions", mycode)
+	}
+}
+
+func TestConfidenceReportMarkdown(t *testing.T) {
+	cc, capture := newReportTestCapture()
+	markdown := cc.ConfidenceReport(capture).Markdown()
+
+	for _, want := range []string{
+		"# Capture Confidence Report",
+		"6 modules detected: 4 mapped (2 high, 1 medium, 1 low confidence), 2 unmapped.",
+		"| Module | Spack Package | Confidence |",
+		"| vasp/6.4.1 | vasp@6.4.1 | 50% (low) |",
+		"| abaqus/2023.1 | abaqus@2023 | 70% (medium) |",
+		"| gcc/11.2.0 | gcc@11.3.0 | 100% (high) |",
+		"| Module | Suggestions |",
+		"| intel-oneapi/2023.1 | intel -> intel-oneapi-compilers@2023.1.0 (confidence: 90%) |",
+		"| mycode/2.1 | none |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown() missing %q\n%s", want, markdown)
+		}
+	}
+
+	if strings.Index(markdown, "vasp/6.4.1") > strings.Index(markdown, "gcc/11.2.0") {
+		t.Error("low confidence mappings should be listed before high confidence ones")
+	}
+}
+
+func TestConfidenceReportMarkdownEmpty(t *testing.T) {
+	report := NewModuleDatabase().BuildConfidenceReport(nil)
+	markdown := report.Markdown()
+
+	if !strings.Contains(markdown, "0 modules detected") {
+		t.Errorf("Markdown() = %q, want 0 modules detected", markdown)
+	}
+	if strings.Count(markdown, "None.") != 2 {
+		t.Errorf("Markdown() should say None. for both sections:\n%s", markdown)
+	}
+}
+
+func TestMarkdownCell(t *testing.T) {
+	if got := markdownCell("a|b"); got != `a\|b` {
+		t.Errorf("markdownCell() = %q", got)
+	}
+}
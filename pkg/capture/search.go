@@ -0,0 +1,108 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// Match quality for package search, best first.
+const (
+	matchExact = iota
+	matchPrefix
+	matchSubstring
+	matchFuzzy
+	noMatch
+)
+
+// Search finds Spack packages in the database whose module or package name
+// matches query. Matching is case-insensitive and fuzzy: the query's
+// characters must appear in order, so "qesp" finds quantum-espresso. Results
+// are ranked exact, prefix, substring, then fuzzy matches, each sorted by
+// package name. An empty query returns every package.
+func (db *ModuleDatabase) Search(query string) []*ModuleMapping {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	best := make(map[string]*ModuleMapping)
+	ranks := make(map[string]int)
+	for _, mapping := range db.mappings {
+		rank := searchRank(query, mapping)
+		if rank == noMatch {
+			continue
+		}
+		// Several module names can map to one package (blas, lapack -> openblas)
+		if prev, ok := ranks[mapping.SpackPackage]; ok && prev <= rank {
+			continue
+		}
+		best[mapping.SpackPackage] = mapping
+		ranks[mapping.SpackPackage] = rank
+	}
+
+	results := make([]*ModuleMapping, 0, len(best))
+	for _, mapping := range best {
+		results = append(results, mapping)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		ri, rj := ranks[results[i].SpackPackage], ranks[results[j].SpackPackage]
+		if ri != rj {
+			return ri < rj
+		}
+		return results[i].SpackPackage < results[j].SpackPackage
+	})
+
+	return results
+}
+
+// searchRank returns how well query matches a mapping's module or Spack package name.
+func searchRank(query string, mapping *ModuleMapping) int {
+	if query == "" {
+		return matchExact
+	}
+
+	rank := noMatch
+	for _, name := range []string{strings.ToLower(mapping.OnPremName), template.SpackPackageName(mapping.SpackPackage)} {
+		var r int
+		switch {
+		case name == query:
+			r = matchExact
+		case strings.HasPrefix(name, query):
+			r = matchPrefix
+		case strings.Contains(name, query):
+			r = matchSubstring
+		case isSubsequence(query, name):
+			r = matchFuzzy
+		default:
+			r = noMatch
+		}
+		if r < rank {
+			rank = r
+		}
+	}
+	return rank
+}
+
+// isSubsequence reports whether the characters of query appear in s in order.
+func isSubsequence(query, s string) bool {
+	i := 0
+	for j := 0; j < len(s) && i < len(query); j++ {
+		if s[j] == query[i] {
+			i++
+		}
+	}
+	return i == len(query)
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func searchPackages(db *ModuleDatabase, query string) []string {
+	var packages []string
+	for _, mapping := range db.Search(query) {
+		packages = append(packages, mapping.SpackPackage)
+	}
+	return packages
+}
+
+func TestSearch(t *testing.T) {
+	db := NewModuleDatabase()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"exact module name", "gromacs", []string{"gromacs@2023.1"}},
+		{"case insensitive", "GROMACS", []string{"gromacs@2023.1"}},
+		{"spack name differs from module", "blast-plus", []string{"blast-plus@2.14.0"}},
+		{"fuzzy", "qesp", []string{"quantum-espresso@7.2"}},
+		{"no match", "nonexistent", nil},
+		{"shared package listed once", "openblas", []string{"openblas@0.3.23"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := searchPackages(db, tt.query)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Search(%q)[%d] = %s, want %s", tt.query, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	db := NewModuleDatabase()
+
+	got := searchPackages(db, "mpi")
+	if len(got) == 0 || got[0] != "mpich@4.0" {
+		t.Fatalf("Search(mpi) = %v, want prefix match mpich@4.0 first", got)
+	}
+
+	// Substring matches rank above fuzzy ones
+	position := make(map[string]int)
+	for i, pkg := range got {
+		position[pkg] = i
+	}
+	for _, pkg := range []string{"openmpi@4.1.4", "intel-oneapi-mpi@2021.9.0", "mvapich2@2.3.7"} {
+		if _, ok := position[pkg]; !ok {
+			t.Errorf("Search(mpi) missing %s: %v", pkg, got)
+		}
+	}
+	if position["openmpi@4.1.4"] > position["mvapich2@2.3.7"] {
+		t.Errorf("substring match openmpi ranked below fuzzy match mvapich2: %v", got)
+	}
+}
+
+func TestSearchEmptyQuery(t *testing.T) {
+	db := NewModuleDatabase()
+
+	all := db.Search("")
+	seen := make(map[string]bool)
+	for _, mapping := range all {
+		if seen[mapping.SpackPackage] {
+			t.Errorf("duplicate package %s", mapping.SpackPackage)
+		}
+		seen[mapping.SpackPackage] = true
+		if !template.ValidSpackSpec(mapping.SpackPackage) {
+			t.Errorf("index entry %q is not a valid package spec", mapping.SpackPackage)
+		}
+	}
+	if !seen["gcc@11.3.0"] || !seen["openblas@0.3.23"] {
+		t.Errorf("Search(\"\") missing packages: %v", searchPackages(db, ""))
+	}
+}
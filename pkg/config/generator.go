@@ -17,7 +17,9 @@ package config
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/scttfrdmn/petal/internal/version"
 	"github.com/scttfrdmn/petal/pkg/software"
 	"github.com/scttfrdmn/petal/pkg/template"
 	"gopkg.in/yaml.v3"
@@ -29,10 +31,37 @@ type Generator struct {
 	KeyName string
 	// SubnetID is the subnet ID for the cluster (if not auto-creating VPC)
 	SubnetID string
+	// ComputeSubnetIDs are the subnets compute queues launch in, one per
+	// Availability Zone (defaults to SubnetID)
+	ComputeSubnetIDs []string
 	// CustomAMI is a custom AMI ID to use instead of default
 	CustomAMI string
 	// BootstrapScriptS3URI is the S3 URI for the bootstrap script
 	BootstrapScriptS3URI string
+	// TemplateName is the name of the source template (defaults to the cluster name)
+	TemplateName string
+	// Owner is the user creating the cluster
+	Owner string
+	// Tags are additional tags, overriding template tags with the same key
+	Tags map[string]string
+	// FixedTags, if set, are the complete cluster tags, used instead of the
+	// generated ones; updates pass the tags the cluster was created with
+	FixedTags map[string]string
+	// PlacementGroups maps queue names to the cluster placement group their
+	// nodes are launched in
+	PlacementGroups map[string]string
+	// HeadNodeSecurityGroups are additional security groups attached to the
+	// head node (e.g., the pctl-created group carrying custom ingress rules)
+	HeadNodeSecurityGroups []string
+	// SSHAllowedIPs restricts SSH to the head node through ParallelCluster's
+	// own security group (ParallelCluster allows 0.0.0.0/0 when unset)
+	SSHAllowedIPs string
+	// PersistentHomeID is the EBS volume or EFS file system mounted at /home
+	// when the template declares a persistent home
+	PersistentHomeID string
+	// Skeletons are the packed local user skeleton directories, keyed by
+	// path, embedded in the bootstrap script
+	Skeletons map[string][]byte
 }
 
 // NewGenerator creates a new config generator.
@@ -57,7 +86,7 @@ func (g *Generator) buildParallelClusterConfig(tmpl *template.Template) map[stri
 	config := map[string]interface{}{
 		"Region": tmpl.Cluster.Region,
 		"Image": map[string]interface{}{
-			"Os": "alinux2023",
+			"Os": tmpl.Cluster.GetOS(),
 		},
 	}
 
@@ -66,6 +95,10 @@ func (g *Generator) buildParallelClusterConfig(tmpl *template.Template) map[stri
 		config["Image"].(map[string]interface{})["CustomAmi"] = g.CustomAMI
 	}
 
+	if tags := g.buildTags(tmpl); len(tags) > 0 {
+		config["Tags"] = tags
+	}
+
 	// Head node configuration
 	headNode := map[string]interface{}{
 		"InstanceType": tmpl.Compute.HeadNode,
@@ -83,15 +116,21 @@ func (g *Generator) buildParallelClusterConfig(tmpl *template.Template) map[stri
 		},
 	}
 
-	// Add Iam configuration for S3 access if there are S3 mounts or bootstrap script
-	if len(tmpl.Data.S3Mounts) > 0 || g.BootstrapScriptS3URI != "" {
-		headNode["Iam"] = map[string]interface{}{
-			"AdditionalIamPolicies": []map[string]interface{}{
-				{
-					"Policy": "arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess",
-				},
-			},
-		}
+	// Add Iam configuration for S3 mounts, the bootstrap script, and template IAM settings
+	iam := g.buildIam(tmpl)
+	if iam != nil {
+		headNode["Iam"] = iam
+	}
+
+	if g.SSHAllowedIPs != "" {
+		headNode["Ssh"].(map[string]interface{})["AllowedIps"] = g.SSHAllowedIPs
+	}
+
+	if len(g.HeadNodeSecurityGroups) > 0 {
+		headNode["Networking"].(map[string]interface{})["AdditionalSecurityGroups"] = g.HeadNodeSecurityGroups
+	}
+	if proxy := proxyConfig(tmpl); proxy != nil {
+		headNode["Networking"].(map[string]interface{})["Proxy"] = proxy
 	}
 
 	config["HeadNode"] = headNode
@@ -104,58 +143,145 @@ func (g *Generator) buildParallelClusterConfig(tmpl *template.Template) map[stri
 	// Build compute queues
 	var queues []map[string]interface{}
 	for _, queue := range tmpl.Compute.Queues {
-		pcQueue := map[string]interface{}{
-			"Name": queue.Name,
-			"ComputeResources": []map[string]interface{}{
+		var computeResources []map[string]interface{}
+		switch {
+		case len(queue.ComputeResources) > 0:
+			// Named compute resources, each scaling independently
+			for _, resource := range queue.ComputeResources {
+				computeResources = append(computeResources, buildComputeResource(resource))
+			}
+		case len(queue.InstanceTypes) > 1:
+			// Add multiple instance types if specified
+			for i, instanceType := range queue.InstanceTypes {
+				computeResources = append(computeResources, map[string]interface{}{
+					"Name":                              fmt.Sprintf("%s-nodes-%d", queue.Name, i),
+					"InstanceType":                      instanceType,
+					"MinCount":                          queue.StaticNodes() / len(queue.InstanceTypes),
+					"MaxCount":                          queue.MaxCount / len(queue.InstanceTypes),
+					"DisableSimultaneousMultithreading": false,
+				})
+			}
+		default:
+			computeResources = []map[string]interface{}{
 				{
 					"Name":                              queue.Name + "-nodes",
-					"InstanceType":                      queue.InstanceTypes[0], // Use first instance type
-					"MinCount":                          queue.MinCount,
+					"InstanceType":                      queue.InstanceTypes[0],
+					"MinCount":                          queue.StaticNodes(),
 					"MaxCount":                          queue.MaxCount,
 					"DisableSimultaneousMultithreading": false,
 				},
-			},
+			}
+		}
+
+		pcQueue := map[string]interface{}{
+			"Name":             queue.Name,
+			"ComputeResources": computeResources,
 			"Networking": map[string]interface{}{
-				"SubnetIds": []string{g.SubnetID},
+				"SubnetIds": g.queueSubnetIDs(queue.Name),
 			},
 		}
 
-		// Add multiple instance types if specified
-		if len(queue.InstanceTypes) > 1 {
-			computeResources := []map[string]interface{}{}
-			for i, instanceType := range queue.InstanceTypes {
-				computeResources = append(computeResources, map[string]interface{}{
-					"Name":                              fmt.Sprintf("%s-nodes-%d", queue.Name, i),
-					"InstanceType":                      instanceType,
-					"MinCount":                          queue.MinCount / len(queue.InstanceTypes),
-					"MaxCount":                          queue.MaxCount / len(queue.InstanceTypes),
-					"DisableSimultaneousMultithreading": false,
-				})
+		if proxy := proxyConfig(tmpl); proxy != nil {
+			pcQueue["Networking"].(map[string]interface{})["Proxy"] = proxy
+		}
+
+		// The queue's own AMI replaces the cluster AMI for its nodes
+		if queue.Image != nil {
+			pcQueue["Image"] = map[string]interface{}{
+				"CustomAmi": queue.Image.CustomAMI,
 			}
-			pcQueue["ComputeResources"] = computeResources
 		}
 
-		// Add IAM for S3 access if needed for S3 mounts or bootstrap script
-		if len(tmpl.Data.S3Mounts) > 0 || g.BootstrapScriptS3URI != "" {
-			pcQueue["Iam"] = map[string]interface{}{
-				"AdditionalIamPolicies": []map[string]interface{}{
-					{
-						"Policy": "arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess",
+		// Validation ensures all resources in a queue share a capacity type
+		if len(queue.ComputeResources) > 0 && queue.ComputeResources[0].GetCapacityType() == template.CapacityTypeSpot {
+			pcQueue["CapacityType"] = "SPOT"
+		}
+
+		// Nodes launch into the group's On-Demand Capacity Reservations
+		if queue.CapacityReservation != nil {
+			pcQueue["CapacityReservationTarget"] = map[string]interface{}{
+				"CapacityReservationResourceGroupArn": queue.CapacityReservation.ResourceGroupARN,
+			}
+		}
+
+		// ParallelCluster sets EFA per compute resource; GPUDirect RDMA is
+		// always on for supporting instances in recent versions, but stating
+		// it keeps the intent visible in the generated config
+		if queue.EFAEnabled() {
+			efa := map[string]interface{}{"Enabled": true}
+			if queue.GPUDirectRDMA() {
+				efa["GdrSupport"] = true
+			}
+			for _, computeResource := range computeResources {
+				computeResource["Efa"] = efa
+			}
+		}
+
+		// Launch nodes in the queue's placement group for low-latency networking
+		if groupName, ok := g.PlacementGroups[queue.Name]; ok {
+			for _, computeResource := range computeResources {
+				computeResource["Networking"] = map[string]interface{}{
+					"PlacementGroup": map[string]interface{}{
+						"Enabled": true,
+						"Name":    groupName,
 					},
-				},
+				}
 			}
 		}
 
+		// Queue tags are merged with cluster tags so the queue's nodes
+		// carry both, with queue values winning on conflict
+		if len(queue.Tags) > 0 {
+			pcQueue["Tags"] = tagList(template.MergeTags(tmpl.Cluster.Tags, queue.Tags))
+		}
+
+		// Compute nodes get the same IAM settings as the head node
+		if iam != nil {
+			pcQueue["Iam"] = iam
+		}
+
 		queues = append(queues, pcQueue)
 	}
 
 	scheduling["SlurmQueues"] = queues
+
+	slurmSettings := map[string]interface{}{}
+
+	// Idle time before dynamic nodes are scaled down
+	if tmpl.Compute.ScaledownIdleTime > 0 {
+		slurmSettings["ScaledownIdletime"] = tmpl.Compute.ScaledownIdleTime
+	}
+
+	// ParallelCluster runs slurmdbd against an external database itself; a
+	// local database is set up by the bootstrap script instead, with
+	// slurmctld pointed at it through slurm.conf settings
+	if tmpl.Scheduler.LocalAccounting() {
+		slurmSettings["CustomSlurmSettings"] = []map[string]interface{}{
+			{"AccountingStorageType": "accounting_storage/slurmdbd"},
+			{"AccountingStorageHost": "localhost"},
+		}
+	}
+	if tmpl.Scheduler.ExternalAccounting() {
+		accounting := tmpl.Scheduler.Accounting
+		database := map[string]interface{}{
+			"Uri":               accounting.URI,
+			"UserName":          accounting.UserName,
+			"PasswordSecretArn": accounting.PasswordSecretARN,
+		}
+		if accounting.DatabaseName != "" {
+			database["DatabaseName"] = accounting.DatabaseName
+		}
+		slurmSettings["Database"] = database
+	}
+
+	if len(slurmSettings) > 0 {
+		scheduling["SlurmSettings"] = slurmSettings
+	}
 	config["Scheduling"] = scheduling
 
 	// Shared storage configuration
+	var sharedStorage []map[string]interface{}
 	if len(tmpl.Data.S3Mounts) > 0 {
-		var sharedStorage []map[string]interface{}
-
 		// Add shared EBS for home directories
 		sharedStorage = append(sharedStorage, map[string]interface{}{
 			"MountDir":    "/shared",
@@ -166,10 +292,18 @@ func (g *Generator) buildParallelClusterConfig(tmpl *template.Template) map[stri
 				"Size":       100, // 100GB
 			},
 		})
-
+	}
+	if tmpl.Data.PersistentHome != nil {
+		sharedStorage = append(sharedStorage, g.buildPersistentHomeStorage(*tmpl.Data.PersistentHome))
+	}
+	if len(sharedStorage) > 0 {
 		config["SharedStorage"] = sharedStorage
 	}
 
+	if monitoring := buildMonitoring(tmpl.Observability); monitoring != nil {
+		config["Monitoring"] = monitoring
+	}
+
 	// Custom bootstrap actions for software installation and user creation
 	if g.BootstrapScriptS3URI != "" {
 		config["HeadNode"].(map[string]interface{})["CustomActions"] = map[string]interface{}{
@@ -182,9 +316,230 @@ func (g *Generator) buildParallelClusterConfig(tmpl *template.Template) map[stri
 	return config
 }
 
+// buildMonitoring builds the ParallelCluster Monitoring settings, or returns
+// nil when the template leaves them at ParallelCluster's defaults.
+func buildMonitoring(o template.ObservabilityConfig) map[string]interface{} {
+	if !o.IsSet() {
+		return nil
+	}
+
+	monitoring := map[string]interface{}{
+		"Dashboards": map[string]interface{}{
+			"CloudWatch": map[string]interface{}{"Enabled": o.DashboardEnabled()},
+		},
+		"Alarms": map[string]interface{}{"Enabled": o.AlarmsEnabled()},
+	}
+	if o.LogRetentionDays != 0 {
+		monitoring["Logs"] = map[string]interface{}{
+			"CloudWatch": map[string]interface{}{
+				"Enabled":         true,
+				"RetentionInDays": o.LogRetentionDays,
+			},
+		}
+	}
+	return monitoring
+}
+
+// queueSubnetIDs returns the subnets a queue launches in. Queues in a
+// placement group are limited to a single Availability Zone.
+func (g *Generator) queueSubnetIDs(queueName string) []string {
+	if len(g.ComputeSubnetIDs) == 0 {
+		return []string{g.SubnetID}
+	}
+	if _, ok := g.PlacementGroups[queueName]; ok {
+		return g.ComputeSubnetIDs[:1]
+	}
+	return g.ComputeSubnetIDs
+}
+
+// s3ReadOnlyPolicy lets instances read S3 mounts and the bootstrap script.
+const s3ReadOnlyPolicy = "arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess"
+
+// buildIam builds the ParallelCluster Iam settings shared by the head node
+// and queues, or returns nil if no extra permissions are needed.
+func (g *Generator) buildIam(tmpl *template.Template) map[string]interface{} {
+	var policies []string
+	seen := make(map[string]bool)
+	addPolicy := func(policy string) {
+		if !seen[policy] {
+			seen[policy] = true
+			policies = append(policies, policy)
+		}
+	}
+	if len(tmpl.Data.S3Mounts) > 0 || g.BootstrapScriptS3URI != "" {
+		addPolicy(s3ReadOnlyPolicy)
+	}
+	for _, policy := range tmpl.IAM.AdditionalPolicies {
+		addPolicy(policy)
+	}
+
+	iam := map[string]interface{}{}
+	if len(policies) > 0 {
+		var additional []map[string]interface{}
+		for _, policy := range policies {
+			additional = append(additional, map[string]interface{}{"Policy": policy})
+		}
+		iam["AdditionalIamPolicies"] = additional
+	}
+	if len(tmpl.IAM.S3Access) > 0 {
+		var s3Access []map[string]interface{}
+		for _, access := range tmpl.IAM.S3Access {
+			s3Access = append(s3Access, map[string]interface{}{
+				"BucketName":        access.Bucket,
+				"EnableWriteAccess": access.EnableWrite,
+			})
+		}
+		iam["S3Access"] = s3Access
+	}
+
+	if len(iam) == 0 {
+		return nil
+	}
+	return iam
+}
+
+// buildPersistentHomeStorage builds the /home shared storage entry. An
+// existing volume is never deleted by ParallelCluster; without an ID (e.g.,
+// when only exporting a config) a new volume is requested with a Retain
+// deletion policy so it still outlives the cluster.
+func (g *Generator) buildPersistentHomeStorage(home template.PersistentHome) map[string]interface{} {
+	id := g.PersistentHomeID
+	if id == "" {
+		id = home.ID
+	}
+
+	storage := map[string]interface{}{
+		"MountDir": "/home",
+		"Name":     "persistent-home",
+	}
+
+	if home.GetType() == template.StorageTypeEFS {
+		storage["StorageType"] = "Efs"
+		storage["EfsSettings"] = map[string]interface{}{
+			"FileSystemId": id,
+		}
+		return storage
+	}
+
+	storage["StorageType"] = "Ebs"
+	if id != "" {
+		storage["EbsSettings"] = map[string]interface{}{
+			"VolumeId": id,
+		}
+	} else {
+		storage["EbsSettings"] = map[string]interface{}{
+			"VolumeType":     "gp3",
+			"Size":           home.GetSize(),
+			"DeletionPolicy": "Retain",
+		}
+	}
+	return storage
+}
+
+// buildComputeResource builds a ParallelCluster compute resource. Resources
+// with several instance types use flexible instance types (Instances).
+func buildComputeResource(resource template.ComputeResource) map[string]interface{} {
+	computeResource := map[string]interface{}{
+		"Name":                              resource.Name,
+		"MinCount":                          resource.StaticCount,
+		"MaxCount":                          resource.MaxCount,
+		"DisableSimultaneousMultithreading": false,
+	}
+
+	if len(resource.InstanceTypes) == 1 {
+		computeResource["InstanceType"] = resource.InstanceTypes[0]
+	} else {
+		instances := make([]map[string]interface{}, 0, len(resource.InstanceTypes))
+		for _, instanceType := range resource.InstanceTypes {
+			instances = append(instances, map[string]interface{}{"InstanceType": instanceType})
+		}
+		computeResource["Instances"] = instances
+	}
+
+	return computeResource
+}
+
+// buildTags builds the top-level cluster tags, which ParallelCluster propagates
+// to the CloudFormation stack and the resources it creates.
+func (g *Generator) buildTags(tmpl *template.Template) []map[string]interface{} {
+	return tagList(g.ClusterTags(tmpl))
+}
+
+// ClusterTags returns the tags applied to the cluster's resources.
+// ParallelCluster cannot change them on update, so they are recorded when the
+// cluster is created.
+func (g *Generator) ClusterTags(tmpl *template.Template) map[string]string {
+	if g.FixedTags != nil {
+		tags := make(map[string]string, len(g.FixedTags))
+		for key, value := range g.FixedTags {
+			tags[key] = value
+		}
+		return tags
+	}
+
+	tags := map[string]string{
+		"pctl:version": version.Version,
+	}
+
+	templateName := g.TemplateName
+	if templateName == "" {
+		templateName = tmpl.Cluster.Name
+	}
+	tags["pctl:template"] = templateName
+
+	if len(tmpl.Software.SpackPackages) > 0 {
+		tags["pctl:fingerprint"] = tmpl.ComputeFingerprint().Hash
+	}
+	if g.Owner != "" {
+		tags["pctl:owner"] = g.Owner
+	}
+
+	for key, value := range tmpl.Cluster.Tags {
+		tags[key] = value
+	}
+	for key, value := range g.Tags {
+		tags[key] = value
+	}
+
+	return tags
+}
+
+// tagList converts tags to ParallelCluster's Key/Value list, sorted by key.
+func tagList(tags map[string]string) []map[string]interface{} {
+	// Sort for stable output
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, map[string]interface{}{
+			"Key":   key,
+			"Value": tags[key],
+		})
+	}
+
+	return result
+}
+
+// proxyConfig returns the ParallelCluster Proxy setting for network.proxy,
+// or nil when instances reach the internet directly.
+func proxyConfig(tmpl *template.Template) map[string]interface{} {
+	if tmpl.Network.Proxy == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"HttpProxyAddress": tmpl.Network.Proxy,
+	}
+}
+
 // GenerateBootstrapScript generates a bootstrap script for software installation and user setup.
 // This now delegates to the software.Manager for a more robust implementation.
 func (g *Generator) GenerateBootstrapScript(tmpl *template.Template) string {
 	manager := software.NewManager()
+	manager.SetSkeletons(g.Skeletons)
+	manager.SetSlurmAccounting(tmpl.Scheduler.LocalAccounting())
 	return manager.GenerateBootstrapScript(tmpl, true, true)
 }
@@ -15,6 +15,8 @@
 package config
 
 import (
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -231,3 +233,1031 @@ func TestGenerateWithCustomAMI(t *testing.T) {
 		t.Errorf("Expected CustomAmi=ami-0123456789, got %v", image["CustomAmi"])
 	}
 }
+
+func TestGenerateWithQueueImage(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1", OS: "ubuntu2204"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues: []template.Queue{
+				{Name: "cpu", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10},
+				{
+					Name:          "gpu",
+					InstanceTypes: []string{"g5.xlarge"},
+					MaxCount:      4,
+					Image:         &template.QueueImage{CustomAMI: "ami-0fedcba9876543210", OS: "ubuntu2204"},
+				},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	gen.CustomAMI = "ami-0123456789abcdef0"
+
+	config, err := gen.Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	image := parsed["Image"].(map[string]interface{})
+	if image["Os"] != "ubuntu2204" || image["CustomAmi"] != "ami-0123456789abcdef0" {
+		t.Errorf("Expected cluster image ubuntu2204/ami-0123456789abcdef0, got %v", image)
+	}
+
+	queues := parsed["Scheduling"].(map[string]interface{})["SlurmQueues"].([]interface{})
+	if _, ok := queues[0].(map[string]interface{})["Image"]; ok {
+		t.Error("Queue without an image should use the cluster AMI")
+	}
+	queueImage, ok := queues[1].(map[string]interface{})["Image"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected Image on gpu queue")
+	}
+	if queueImage["CustomAmi"] != "ami-0fedcba9876543210" {
+		t.Errorf("Expected gpu queue CustomAmi=ami-0fedcba9876543210, got %v", queueImage["CustomAmi"])
+	}
+	if _, ok := queueImage["Os"]; ok {
+		t.Error("Queue Image must not set Os; ParallelCluster only accepts it on the cluster Image")
+	}
+}
+
+func TestGenerateWithEFA(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues: []template.Queue{
+				{Name: "cpu", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10},
+				{
+					Name:          "mpi",
+					InstanceTypes: []string{"c5n.18xlarge", "hpc6a.48xlarge"},
+					MaxCount:      8,
+					EFA:           &template.QueueEFA{Enabled: true},
+				},
+				{
+					Name:          "gpu",
+					InstanceTypes: []string{"p5.48xlarge"},
+					MaxCount:      4,
+					EFA:           &template.QueueEFA{Enabled: true, GPUDirectRDMA: true},
+				},
+			},
+		},
+	}
+
+	config, err := NewGenerator().Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	queues := parsed["Scheduling"].(map[string]interface{})["SlurmQueues"].([]interface{})
+	resourcesOf := func(i int) []interface{} {
+		return queues[i].(map[string]interface{})["ComputeResources"].([]interface{})
+	}
+
+	if _, ok := resourcesOf(0)[0].(map[string]interface{})["Efa"]; ok {
+		t.Error("Queue without efa should not set Efa")
+	}
+
+	for _, resource := range resourcesOf(1) {
+		efa, ok := resource.(map[string]interface{})["Efa"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected Efa on every mpi compute resource, got %v", resource)
+		}
+		if efa["Enabled"] != true {
+			t.Errorf("Expected Efa.Enabled=true, got %v", efa)
+		}
+		if _, ok := efa["GdrSupport"]; ok {
+			t.Errorf("GdrSupport should only be set with gpu_direct_rdma, got %v", efa)
+		}
+	}
+
+	efa := resourcesOf(2)[0].(map[string]interface{})["Efa"].(map[string]interface{})
+	if efa["Enabled"] != true || efa["GdrSupport"] != true {
+		t.Errorf("Expected Efa with GdrSupport on gpu queue, got %v", efa)
+	}
+}
+
+func TestGenerateWithCapacityReservation(t *testing.T) {
+	const groupARN = "arn:aws:resource-groups:us-east-1:123456789012:group/gpu-reservations"
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues: []template.Queue{
+				{Name: "cpu", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10},
+				{
+					Name:                "train",
+					InstanceTypes:       []string{"p5.48xlarge"},
+					MaxCount:            4,
+					CapacityReservation: &template.QueueCapacityReservation{ResourceGroupARN: groupARN},
+				},
+				{
+					Name:                "infer",
+					InstanceTypes:       []string{"p5.48xlarge"},
+					MaxCount:            2,
+					CapacityReservation: &template.QueueCapacityReservation{ResourceGroupARN: groupARN},
+				},
+			},
+		},
+	}
+
+	config, err := NewGenerator().Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	queues := parsed["Scheduling"].(map[string]interface{})["SlurmQueues"].([]interface{})
+	if _, ok := queues[0].(map[string]interface{})["CapacityReservationTarget"]; ok {
+		t.Error("Queue without capacity_reservation should not set CapacityReservationTarget")
+	}
+	for _, i := range []int{1, 2} {
+		queue := queues[i].(map[string]interface{})
+		target, ok := queue["CapacityReservationTarget"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected CapacityReservationTarget on queue %v", queue["Name"])
+		}
+		if target["CapacityReservationResourceGroupArn"] != groupARN {
+			t.Errorf("Expected queue %v to target %s, got %v", queue["Name"], groupARN, target)
+		}
+	}
+}
+
+func TestGenerateWithProxy(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues:   []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10}},
+		},
+		Network: template.NetworkConfig{Proxy: "http://proxy.example.com:3128"},
+	}
+
+	config, err := NewGenerator().Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	headNodeNetworking := parsed["HeadNode"].(map[string]interface{})["Networking"].(map[string]interface{})
+	if proxy, ok := headNodeNetworking["Proxy"].(map[string]interface{}); !ok || proxy["HttpProxyAddress"] != "http://proxy.example.com:3128" {
+		t.Errorf("Expected head node HttpProxyAddress, got %v", headNodeNetworking["Proxy"])
+	}
+
+	queue := parsed["Scheduling"].(map[string]interface{})["SlurmQueues"].([]interface{})[0].(map[string]interface{})
+	queueNetworking := queue["Networking"].(map[string]interface{})
+	if proxy, ok := queueNetworking["Proxy"].(map[string]interface{}); !ok || proxy["HttpProxyAddress"] != "http://proxy.example.com:3128" {
+		t.Errorf("Expected queue HttpProxyAddress, got %v", queueNetworking["Proxy"])
+	}
+}
+
+func TestGenerateOS(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{
+			Name:   "test-cluster",
+			Region: "us-east-1",
+		},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues: []template.Queue{
+				{
+					Name:          "compute",
+					InstanceTypes: []string{"c5.xlarge"},
+					MinCount:      0,
+					MaxCount:      10,
+				},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	gen.KeyName = "my-key"
+	gen.SubnetID = "subnet-12345"
+
+	for _, tt := range []struct{ os, want string }{
+		{"", "alinux2023"},
+		{"rocky9", "rocky9"},
+	} {
+		tmpl.Cluster.OS = tt.os
+		config, err := gen.Generate(tmpl)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+			t.Fatalf("Failed to parse generated config: %v", err)
+		}
+
+		image := parsed["Image"].(map[string]interface{})
+		if image["Os"] != tt.want {
+			t.Errorf("os %q: Image.Os = %v, want %s", tt.os, image["Os"], tt.want)
+		}
+	}
+}
+
+func TestGenerateWithTags(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{
+			Name:   "test-cluster",
+			Region: "us-east-1",
+			Tags: map[string]string{
+				"project":     "genomics",
+				"cost-center": "1234",
+			},
+		},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues: []template.Queue{
+				{Name: "compute", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10},
+			},
+		},
+		Software: template.SoftwareConfig{
+			SpackPackages: []string{"gcc@11.3.0"},
+		},
+	}
+
+	gen := NewGenerator()
+	gen.TemplateName = "bioinformatics"
+	gen.Owner = "alice"
+	gen.Tags = map[string]string{"cost-center": "5678"}
+
+	config, err := gen.Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	tagList, ok := parsed["Tags"].([]interface{})
+	if !ok {
+		t.Fatal("Tags not found or wrong type")
+	}
+
+	tags := make(map[string]string)
+	var keys []string
+	for _, item := range tagList {
+		tag := item.(map[string]interface{})
+		key := tag["Key"].(string)
+		tags[key] = tag["Value"].(string)
+		keys = append(keys, key)
+	}
+
+	expected := map[string]string{
+		"pctl:template":    "bioinformatics",
+		"pctl:fingerprint": tmpl.ComputeFingerprint().Hash,
+		"pctl:owner":       "alice",
+		"project":          "genomics",
+		"cost-center":      "5678", // flag overrides template
+	}
+	for key, want := range expected {
+		if tags[key] != want {
+			t.Errorf("Tag %s = %q, want %q", key, tags[key], want)
+		}
+	}
+	if tags["pctl:version"] == "" {
+		t.Error("Expected pctl:version tag")
+	}
+
+	// Tags are emitted in sorted order for stable configs
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] > keys[i] {
+			t.Errorf("Tags not sorted: %v", keys)
+			break
+		}
+	}
+}
+
+func TestGenerateRoleTags(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{
+			Name:   "test-cluster",
+			Region: "us-east-1",
+			Tags:   map[string]string{"project": "genomics", "role": "cluster"},
+		},
+		Compute: template.ComputeConfig{
+			HeadNode:     "t3.xlarge",
+			HeadNodeTags: map[string]string{"role": "head"},
+			Queues: []template.Queue{
+				{Name: "gpu", InstanceTypes: []string{"g5.xlarge"}, MaxCount: 4, Tags: map[string]string{"role": "compute", "team": "ml"}},
+				{Name: "cpu", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	gen.KeyName = "my-key"
+	gen.SubnetID = "subnet-12345"
+
+	config, err := gen.Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	tagMap := func(value interface{}) map[string]string {
+		tags := make(map[string]string)
+		list, _ := value.([]interface{})
+		for _, item := range list {
+			tag := item.(map[string]interface{})
+			tags[tag["Key"].(string)] = tag["Value"].(string)
+		}
+		return tags
+	}
+
+	// Cluster tags are unchanged by role tags
+	if got := tagMap(parsed["Tags"])["role"]; got != "cluster" {
+		t.Errorf("cluster role tag = %q, want cluster", got)
+	}
+
+	// ParallelCluster has no head node tags; they are applied after creation
+	headNode := parsed["HeadNode"].(map[string]interface{})
+	if _, ok := headNode["Tags"]; ok {
+		t.Error("HeadNode should not have Tags")
+	}
+
+	queues := parsed["Scheduling"].(map[string]interface{})["SlurmQueues"].([]interface{})
+	gpu := queues[0].(map[string]interface{})
+	gpuTags := tagMap(gpu["Tags"])
+	want := map[string]string{"project": "genomics", "role": "compute", "team": "ml"}
+	if len(gpuTags) != len(want) {
+		t.Errorf("gpu queue tags = %v, want %v", gpuTags, want)
+	}
+	for key, value := range want {
+		if gpuTags[key] != value {
+			t.Errorf("gpu queue tag %s = %q, want %q", key, gpuTags[key], value)
+		}
+	}
+
+	cpu := queues[1].(map[string]interface{})
+	if _, ok := cpu["Tags"]; ok {
+		t.Error("queue without tags should not have Tags")
+	}
+}
+
+func TestGenerateDefaultTags(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues:   []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10}},
+		},
+	}
+
+	config, err := NewGenerator().Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if !strings.Contains(config, "Key: pctl:template") || !strings.Contains(config, "Value: test-cluster") {
+		t.Error("Template tag should default to the cluster name")
+	}
+	if strings.Contains(config, "pctl:fingerprint") {
+		t.Error("Fingerprint tag should be omitted without software packages")
+	}
+	if strings.Contains(config, "pctl:owner") {
+		t.Error("Owner tag should be omitted when owner is unknown")
+	}
+}
+
+func TestGenerateStaticAndDynamicCapacity(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{
+			Name:   "test-cluster",
+			Region: "us-east-1",
+		},
+		Compute: template.ComputeConfig{
+			HeadNode:          "t3.xlarge",
+			ScaledownIdleTime: 15,
+			Queues: []template.Queue{
+				{
+					Name:          "always-on",
+					InstanceTypes: []string{"c5.2xlarge"},
+					StaticCount:   2,
+					MaxCount:      10,
+				},
+				{
+					Name:          "legacy",
+					InstanceTypes: []string{"c5.2xlarge"},
+					MinCount:      1,
+					MaxCount:      4,
+				},
+			},
+		},
+	}
+
+	config, err := NewGenerator().Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	scheduling := parsed["Scheduling"].(map[string]interface{})
+	queues := scheduling["SlurmQueues"].([]interface{})
+
+	tests := []struct {
+		queue    int
+		minCount int
+		maxCount int
+	}{
+		{0, 2, 10}, // 2 static, 8 dynamic
+		{1, 1, 4},  // MinCount is static when StaticCount is unset
+	}
+	for _, tt := range tests {
+		queue := queues[tt.queue].(map[string]interface{})
+		resource := queue["ComputeResources"].([]interface{})[0].(map[string]interface{})
+		if resource["MinCount"] != tt.minCount {
+			t.Errorf("queue %d: MinCount = %v, want %d", tt.queue, resource["MinCount"], tt.minCount)
+		}
+		if resource["MaxCount"] != tt.maxCount {
+			t.Errorf("queue %d: MaxCount = %v, want %d", tt.queue, resource["MaxCount"], tt.maxCount)
+		}
+	}
+
+	slurmSettings, ok := scheduling["SlurmSettings"].(map[string]interface{})
+	if !ok {
+		t.Fatal("SlurmSettings not found")
+	}
+	if slurmSettings["ScaledownIdletime"] != 15 {
+		t.Errorf("ScaledownIdletime = %v, want 15", slurmSettings["ScaledownIdletime"])
+	}
+}
+
+func TestGenerateWithoutScaledownIdleTime(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues:   []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10}},
+		},
+	}
+
+	config, err := NewGenerator().Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if strings.Contains(config, "SlurmSettings") {
+		t.Error("SlurmSettings should be omitted when scaledown idle time is not set")
+	}
+}
+
+func TestGenerateWithPlacementGroup(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues: []template.Queue{
+				{Name: "mpi", InstanceTypes: []string{"c5n.18xlarge", "c6in.32xlarge"}, MaxCount: 16, PlacementGroup: true},
+				{Name: "serial", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	gen.PlacementGroups = map[string]string{"mpi": "pctl-test-cluster-mpi"}
+
+	config, err := gen.Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	queues := parsed["Scheduling"].(map[string]interface{})["SlurmQueues"].([]interface{})
+
+	mpiResources := queues[0].(map[string]interface{})["ComputeResources"].([]interface{})
+	for _, cr := range mpiResources {
+		networking, ok := cr.(map[string]interface{})["Networking"].(map[string]interface{})
+		if !ok {
+			t.Fatal("Expected Networking on mpi compute resource")
+		}
+		pg := networking["PlacementGroup"].(map[string]interface{})
+		if pg["Enabled"] != true || pg["Name"] != "pctl-test-cluster-mpi" {
+			t.Errorf("Expected placement group pctl-test-cluster-mpi, got %v", pg)
+		}
+	}
+
+	serialResources := queues[1].(map[string]interface{})["ComputeResources"].([]interface{})
+	if _, ok := serialResources[0].(map[string]interface{})["Networking"]; ok {
+		t.Error("Queue without placement group should not have compute resource Networking")
+	}
+}
+
+func TestGenerateMultiAZSubnets(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues: []template.Queue{
+				{Name: "mpi", InstanceTypes: []string{"c5n.18xlarge"}, MaxCount: 16, PlacementGroup: true},
+				{Name: "serial", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10},
+			},
+		},
+	}
+
+	gen := NewGenerator()
+	gen.SubnetID = "subnet-a"
+	gen.ComputeSubnetIDs = []string{"subnet-a", "subnet-b", "subnet-c"}
+	gen.PlacementGroups = map[string]string{"mpi": "pctl-test-cluster-mpi"}
+
+	config, err := gen.Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	queues := parsed["Scheduling"].(map[string]interface{})["SlurmQueues"].([]interface{})
+	subnets := func(i int) []interface{} {
+		networking := queues[i].(map[string]interface{})["Networking"].(map[string]interface{})
+		return networking["SubnetIds"].([]interface{})
+	}
+
+	// A placement group cannot span Availability Zones
+	if mpi := subnets(0); len(mpi) != 1 || mpi[0] != "subnet-a" {
+		t.Errorf("Expected placement group queue in subnet-a only, got %v", mpi)
+	}
+	if serial := subnets(1); len(serial) != 3 {
+		t.Errorf("Expected serial queue across all three subnets, got %v", serial)
+	}
+
+	headSubnet := parsed["HeadNode"].(map[string]interface{})["Networking"].(map[string]interface{})["SubnetId"]
+	if headSubnet != "subnet-a" {
+		t.Errorf("Expected head node in subnet-a, got %v", headSubnet)
+	}
+}
+
+func TestGenerateWithComputeResources(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues: []template.Queue{
+				{
+					Name: "mixed",
+					ComputeResources: []template.ComputeResource{
+						{Name: "cpu", InstanceTypes: []string{"c5.2xlarge"}, StaticCount: 1, MaxCount: 8},
+						{Name: "gpu", InstanceTypes: []string{"g5.xlarge", "g5.2xlarge"}, MaxCount: 4},
+					},
+				},
+				{
+					Name: "spot",
+					ComputeResources: []template.ComputeResource{
+						{Name: "burst", InstanceTypes: []string{"c5.4xlarge"}, MaxCount: 20, CapacityType: template.CapacityTypeSpot},
+					},
+				},
+			},
+		},
+	}
+
+	config, err := NewGenerator().Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	queues := parsed["Scheduling"].(map[string]interface{})["SlurmQueues"].([]interface{})
+	mixed := queues[0].(map[string]interface{})
+	resources := mixed["ComputeResources"].([]interface{})
+	if len(resources) != 2 {
+		t.Fatalf("Expected 2 compute resources, got %d", len(resources))
+	}
+
+	cpu := resources[0].(map[string]interface{})
+	if cpu["Name"] != "cpu" || cpu["InstanceType"] != "c5.2xlarge" || cpu["MinCount"] != 1 || cpu["MaxCount"] != 8 {
+		t.Errorf("Unexpected cpu compute resource: %v", cpu)
+	}
+
+	gpu := resources[1].(map[string]interface{})
+	if gpu["Name"] != "gpu" || gpu["MinCount"] != 0 || gpu["MaxCount"] != 4 {
+		t.Errorf("Unexpected gpu compute resource: %v", gpu)
+	}
+	if _, ok := gpu["InstanceType"]; ok {
+		t.Error("Resource with several instance types should use Instances, not InstanceType")
+	}
+	instances := gpu["Instances"].([]interface{})
+	if len(instances) != 2 || instances[1].(map[string]interface{})["InstanceType"] != "g5.2xlarge" {
+		t.Errorf("Unexpected gpu Instances: %v", instances)
+	}
+
+	if _, ok := mixed["CapacityType"]; ok {
+		t.Error("On-demand queue should not set CapacityType")
+	}
+	if spot := queues[1].(map[string]interface{}); spot["CapacityType"] != "SPOT" {
+		t.Errorf("Expected spot queue CapacityType SPOT, got %v", spot["CapacityType"])
+	}
+}
+
+func TestGenerateWithHeadNodeSecurityGroups(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues:   []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+		},
+	}
+
+	headNodeNetworking := func(gen *Generator) map[string]interface{} {
+		config, err := gen.Generate(tmpl)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+			t.Fatalf("Failed to parse generated config: %v", err)
+		}
+		return parsed["HeadNode"].(map[string]interface{})["Networking"].(map[string]interface{})
+	}
+
+	gen := NewGenerator()
+	gen.SubnetID = "subnet-12345"
+	if _, ok := headNodeNetworking(gen)["AdditionalSecurityGroups"]; ok {
+		t.Error("AdditionalSecurityGroups should be omitted when none are set")
+	}
+
+	gen.HeadNodeSecurityGroups = []string{"sg-12345"}
+	networking := headNodeNetworking(gen)
+	groups, ok := networking["AdditionalSecurityGroups"].([]interface{})
+	if !ok || len(groups) != 1 || groups[0] != "sg-12345" {
+		t.Errorf("AdditionalSecurityGroups = %v, want [sg-12345]", networking["AdditionalSecurityGroups"])
+	}
+	if networking["SubnetId"] != "subnet-12345" {
+		t.Errorf("SubnetId = %v, want subnet-12345", networking["SubnetId"])
+	}
+}
+
+func TestGenerateWithSSHAllowedIPs(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues:   []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+		},
+	}
+
+	headNodeSSH := func(gen *Generator) map[string]interface{} {
+		config, err := gen.Generate(tmpl)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+			t.Fatalf("Failed to parse generated config: %v", err)
+		}
+		return parsed["HeadNode"].(map[string]interface{})["Ssh"].(map[string]interface{})
+	}
+
+	gen := NewGenerator()
+	gen.KeyName = "my-key"
+	if _, ok := headNodeSSH(gen)["AllowedIps"]; ok {
+		t.Error("AllowedIps should be omitted when not set")
+	}
+
+	gen.SSHAllowedIPs = "203.0.113.0/24"
+	ssh := headNodeSSH(gen)
+	if ssh["AllowedIps"] != "203.0.113.0/24" || ssh["KeyName"] != "my-key" {
+		t.Errorf("Ssh = %v, want KeyName my-key and AllowedIps 203.0.113.0/24", ssh)
+	}
+}
+
+func TestGenerateWithPersistentHome(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues:   []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+		},
+		Data: template.DataConfig{
+			PersistentHome: &template.PersistentHome{Name: "lab-home", Size: 200},
+		},
+	}
+
+	homeStorage := func(gen *Generator) map[string]interface{} {
+		config, err := gen.Generate(tmpl)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+			t.Fatalf("Failed to parse generated config: %v", err)
+		}
+		storage, ok := parsed["SharedStorage"].([]interface{})
+		if !ok || len(storage) != 1 {
+			t.Fatalf("SharedStorage = %v, want one entry", parsed["SharedStorage"])
+		}
+		home := storage[0].(map[string]interface{})
+		if home["MountDir"] != "/home" {
+			t.Errorf("MountDir = %v, want /home", home["MountDir"])
+		}
+		return home
+	}
+
+	// An existing volume is mounted by ID and never deleted by ParallelCluster
+	gen := NewGenerator()
+	gen.PersistentHomeID = "vol-0123"
+	home := homeStorage(gen)
+	ebs := home["EbsSettings"].(map[string]interface{})
+	if home["StorageType"] != "Ebs" || ebs["VolumeId"] != "vol-0123" {
+		t.Errorf("Expected existing EBS volume vol-0123, got %v", home)
+	}
+	if _, ok := ebs["Size"]; ok {
+		t.Error("Size should be omitted for an existing volume")
+	}
+
+	// Without an ID a retained volume is requested
+	gen.PersistentHomeID = ""
+	ebs = homeStorage(gen)["EbsSettings"].(map[string]interface{})
+	if ebs["DeletionPolicy"] != "Retain" || ebs["Size"] != 200 {
+		t.Errorf("Expected retained 200 GiB volume, got %v", ebs)
+	}
+
+	tmpl.Data.PersistentHome = &template.PersistentHome{Name: "lab-home", Type: template.StorageTypeEFS, ID: "fs-0123"}
+	home = homeStorage(NewGenerator())
+	efs := home["EfsSettings"].(map[string]interface{})
+	if home["StorageType"] != "Efs" || efs["FileSystemId"] != "fs-0123" {
+		t.Errorf("Expected EFS file system fs-0123, got %v", home)
+	}
+}
+
+func TestGenerateWithIAM(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues: []template.Queue{
+				{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10},
+				{Name: "gpu", InstanceTypes: []string{"g5.xlarge"}, MaxCount: 2},
+			},
+		},
+		Data: template.DataConfig{
+			S3Mounts: []template.S3Mount{{Bucket: "my-data", MountPoint: "/shared/data"}},
+		},
+		IAM: template.IAMConfig{
+			AdditionalPolicies: []string{
+				"arn:aws:iam::aws:policy/AmazonDynamoDBReadOnlyAccess",
+				"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess",
+			},
+			S3Access: []template.S3Access{
+				{Bucket: "results", EnableWrite: true},
+				{Bucket: "reference"},
+			},
+		},
+	}
+
+	config, err := NewGenerator().Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+
+	checkIam := func(where string, iam map[string]interface{}) {
+		var policies []interface{}
+		for _, policy := range iam["AdditionalIamPolicies"].([]interface{}) {
+			policies = append(policies, policy.(map[string]interface{})["Policy"])
+		}
+		// The S3 mount policy is not repeated
+		wantPolicies := []interface{}{
+			"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess",
+			"arn:aws:iam::aws:policy/AmazonDynamoDBReadOnlyAccess",
+		}
+		if !reflect.DeepEqual(policies, wantPolicies) {
+			t.Errorf("%s AdditionalIamPolicies = %v, want %v", where, policies, wantPolicies)
+		}
+
+		wantS3 := []interface{}{
+			map[string]interface{}{"BucketName": "results", "EnableWriteAccess": true},
+			map[string]interface{}{"BucketName": "reference", "EnableWriteAccess": false},
+		}
+		if !reflect.DeepEqual(iam["S3Access"], wantS3) {
+			t.Errorf("%s S3Access = %v, want %v", where, iam["S3Access"], wantS3)
+		}
+	}
+
+	checkIam("HeadNode", parsed["HeadNode"].(map[string]interface{})["Iam"].(map[string]interface{}))
+	for _, queue := range parsed["Scheduling"].(map[string]interface{})["SlurmQueues"].([]interface{}) {
+		q := queue.(map[string]interface{})
+		iam, ok := q["Iam"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Queue %v missing Iam", q["Name"])
+		}
+		checkIam(fmt.Sprintf("queue %v", q["Name"]), iam)
+	}
+}
+
+func TestGenerateWithoutIAM(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues:   []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+		},
+	}
+
+	config, err := NewGenerator().Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if strings.Contains(config, "Iam:") {
+		t.Errorf("Config should have no Iam settings:\n%s", config)
+	}
+}
+
+func accountingTestTemplate(accounting *template.AccountingConfig) *template.Template {
+	return &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode:          "t3.xlarge",
+			Queues:            []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+			ScaledownIdleTime: 15,
+		},
+		Scheduler: template.SchedulerConfig{Accounting: accounting},
+	}
+}
+
+func TestGenerateWithExternalAccounting(t *testing.T) {
+	tmpl := accountingTestTemplate(&template.AccountingConfig{
+		Enabled:           true,
+		Database:          template.AccountingDatabaseExternal,
+		URI:               "slurmdb.abc123.us-east-1.rds.amazonaws.com:3306",
+		UserName:          "slurm",
+		PasswordSecretARN: "arn:aws:secretsmanager:us-east-1:123456789012:secret:slurmdb-AbCdEf",
+	})
+
+	gen := NewGenerator()
+	config, err := gen.Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("Failed to parse generated config: %v", err)
+	}
+	slurmSettings := parsed["Scheduling"].(map[string]interface{})["SlurmSettings"].(map[string]interface{})
+	if slurmSettings["ScaledownIdletime"] != 15 {
+		t.Errorf("ScaledownIdletime = %v, want 15 alongside the database", slurmSettings["ScaledownIdletime"])
+	}
+	database, ok := slurmSettings["Database"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("SlurmSettings.Database not found:\n%s", config)
+	}
+	want := map[string]interface{}{
+		"Uri":               "slurmdb.abc123.us-east-1.rds.amazonaws.com:3306",
+		"UserName":          "slurm",
+		"PasswordSecretArn": "arn:aws:secretsmanager:us-east-1:123456789012:secret:slurmdb-AbCdEf",
+	}
+	if !reflect.DeepEqual(database, want) {
+		t.Errorf("Database = %v, want %v", database, want)
+	}
+
+	// An external database needs no bootstrap setup
+	if script := gen.GenerateBootstrapScript(tmpl); strings.Contains(script, "slurmdbd") {
+		t.Error("bootstrap script should not set up slurmdbd for an external database")
+	}
+}
+
+func TestGenerateWithLocalAccounting(t *testing.T) {
+	tmpl := accountingTestTemplate(&template.AccountingConfig{Enabled: true})
+
+	gen := NewGenerator()
+	config, err := gen.Generate(tmpl)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if strings.Contains(config, "Database") {
+		t.Errorf("config should not configure a ParallelCluster database for local accounting:\n%s", config)
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	slurmSettings := parsed["Scheduling"].(map[string]interface{})["SlurmSettings"].(map[string]interface{})
+	want := []interface{}{
+		map[string]interface{}{"AccountingStorageType": "accounting_storage/slurmdbd"},
+		map[string]interface{}{"AccountingStorageHost": "localhost"},
+	}
+	if !reflect.DeepEqual(slurmSettings["CustomSlurmSettings"], want) {
+		t.Errorf("CustomSlurmSettings = %#v, want %#v", slurmSettings["CustomSlurmSettings"], want)
+	}
+
+	script := gen.GenerateBootstrapScript(tmpl)
+	for _, want := range []string{"# SLURM ACCOUNTING", "mariadb-server", "systemctl enable --now slurmdbd"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("bootstrap script should contain %q", want)
+		}
+	}
+	if strings.Contains(script, ">> \"$SLURM_ETC/slurm.conf\"") {
+		t.Error("bootstrap script should not edit slurm.conf")
+	}
+
+	// Disabled accounting sets up nothing
+	tmpl.Scheduler.Accounting.Enabled = false
+	if script := gen.GenerateBootstrapScript(tmpl); strings.Contains(script, "slurmdbd") {
+		t.Error("bootstrap script should not set up slurmdbd when accounting is disabled")
+	}
+}
+
+func TestGenerateWithObservability(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name          string
+		observability template.ObservabilityConfig
+		want          map[string]interface{}
+	}{
+		{"unset keeps ParallelCluster defaults", template.ObservabilityConfig{}, nil},
+		{
+			"retention only",
+			template.ObservabilityConfig{LogRetentionDays: 30},
+			map[string]interface{}{
+				"Dashboards": map[string]interface{}{"CloudWatch": map[string]interface{}{"Enabled": true}},
+				"Alarms":     map[string]interface{}{"Enabled": true},
+				"Logs":       map[string]interface{}{"CloudWatch": map[string]interface{}{"Enabled": true, "RetentionInDays": 30}},
+			},
+		},
+		{
+			"dashboard and alarms off",
+			template.ObservabilityConfig{Dashboard: &disabled, Alarms: &disabled},
+			map[string]interface{}{
+				"Dashboards": map[string]interface{}{"CloudWatch": map[string]interface{}{"Enabled": false}},
+				"Alarms":     map[string]interface{}{"Enabled": false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := &template.Template{
+				Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+				Compute: template.ComputeConfig{
+					HeadNode: "t3.xlarge",
+					Queues:   []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+				},
+				Observability: tt.observability,
+			}
+
+			config, err := NewGenerator().Generate(tmpl)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			var parsed map[string]interface{}
+			if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+				t.Fatalf("Failed to parse generated config: %v", err)
+			}
+
+			monitoring, ok := parsed["Monitoring"]
+			if tt.want == nil {
+				if ok {
+					t.Errorf("Monitoring = %v, want it omitted", monitoring)
+				}
+				return
+			}
+			if !reflect.DeepEqual(monitoring, tt.want) {
+				t.Errorf("Monitoring = %v, want %v", monitoring, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// ErrNoDefaultVPC is returned when the account has no default VPC in the region.
+var ErrNoDefaultVPC = errors.New("no default VPC")
+
+// DefaultSubnet is a public subnet in the account's default VPC.
+type DefaultSubnet struct {
+	VpcID            string
+	SubnetID         string
+	AvailabilityZone string
+}
+
+// FindDefaultSubnet finds the default VPC and a public subnet in it that
+// assigns public IPs on launch, so a cluster can run without a pctl-created VPC.
+func (m *Manager) FindDefaultSubnet(ctx context.Context) (*DefaultSubnet, error) {
+	vpcs, err := m.ec2Client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("isDefault"), Values: []string{"true"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe VPCs: %w", err)
+	}
+	if len(vpcs.Vpcs) == 0 {
+		return nil, fmt.Errorf("%w in region %s", ErrNoDefaultVPC, m.region)
+	}
+	vpcID := aws.ToString(vpcs.Vpcs[0].VpcId)
+
+	subnets, err := m.ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{vpcID}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe subnets in %s: %w", vpcID, err)
+	}
+
+	subnet, ok := selectPublicSubnet(subnets.Subnets)
+	if !ok {
+		return nil, fmt.Errorf("default VPC %s has no available subnet that assigns public IPs on launch", vpcID)
+	}
+
+	return &DefaultSubnet{
+		VpcID:            vpcID,
+		SubnetID:         aws.ToString(subnet.SubnetId),
+		AvailabilityZone: aws.ToString(subnet.AvailabilityZone),
+	}, nil
+}
+
+// selectPublicSubnet picks the available subnet with public IPs on launch
+// that has the most free addresses, breaking ties by availability zone so
+// the choice is stable.
+func selectPublicSubnet(subnets []types.Subnet) (types.Subnet, bool) {
+	var candidates []types.Subnet
+	for _, subnet := range subnets {
+		if subnet.State == types.SubnetStateAvailable && aws.ToBool(subnet.MapPublicIpOnLaunch) {
+			candidates = append(candidates, subnet)
+		}
+	}
+	if len(candidates) == 0 {
+		return types.Subnet{}, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		fi, fj := aws.ToInt32(candidates[i].AvailableIpAddressCount), aws.ToInt32(candidates[j].AvailableIpAddressCount)
+		if fi != fj {
+			return fi > fj
+		}
+		return aws.ToString(candidates[i].AvailabilityZone) < aws.ToString(candidates[j].AvailabilityZone)
+	})
+	return candidates[0], true
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// fakeDefaultVPC answers the default VPC lookups made by FindDefaultSubnet.
+type fakeDefaultVPC struct {
+	ec2API
+	vpcs        []types.Vpc
+	subnets     []types.Subnet
+	vpcFilters  []types.Filter
+	subnetVpcID string
+}
+
+func (f *fakeDefaultVPC) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	f.vpcFilters = params.Filters
+	return &ec2.DescribeVpcsOutput{Vpcs: f.vpcs}, nil
+}
+
+func (f *fakeDefaultVPC) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	for _, filter := range params.Filters {
+		if aws.ToString(filter.Name) == "vpc-id" && len(filter.Values) == 1 {
+			f.subnetVpcID = filter.Values[0]
+		}
+	}
+	return &ec2.DescribeSubnetsOutput{Subnets: f.subnets}, nil
+}
+
+func testSubnet(id, az string, public bool, free int32) types.Subnet {
+	return types.Subnet{
+		SubnetId:                aws.String(id),
+		AvailabilityZone:        aws.String(az),
+		MapPublicIpOnLaunch:     aws.Bool(public),
+		AvailableIpAddressCount: aws.Int32(free),
+		State:                   types.SubnetStateAvailable,
+	}
+}
+
+func TestFindDefaultSubnet(t *testing.T) {
+	fake := &fakeDefaultVPC{
+		vpcs: []types.Vpc{{VpcId: aws.String("vpc-default"), IsDefault: aws.Bool(true)}},
+		subnets: []types.Subnet{
+			testSubnet("subnet-private", "us-east-1a", false, 8000),
+			testSubnet("subnet-b", "us-east-1b", true, 4000),
+			testSubnet("subnet-c", "us-east-1c", true, 4090),
+		},
+	}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	subnet, err := m.FindDefaultSubnet(context.Background())
+	if err != nil {
+		t.Fatalf("FindDefaultSubnet() error = %v", err)
+	}
+	if subnet.VpcID != "vpc-default" || subnet.SubnetID != "subnet-c" || subnet.AvailabilityZone != "us-east-1c" {
+		t.Errorf("FindDefaultSubnet() = %+v, want subnet-c in vpc-default", subnet)
+	}
+
+	if len(fake.vpcFilters) != 1 || aws.ToString(fake.vpcFilters[0].Name) != "isDefault" || fake.vpcFilters[0].Values[0] != "true" {
+		t.Errorf("DescribeVpcs filters = %+v, want isDefault=true", fake.vpcFilters)
+	}
+	if fake.subnetVpcID != "vpc-default" {
+		t.Errorf("DescribeSubnets vpc-id = %q, want vpc-default", fake.subnetVpcID)
+	}
+}
+
+func TestFindDefaultSubnetNoDefaultVPC(t *testing.T) {
+	m := &Manager{ec2Client: &fakeDefaultVPC{}, region: "eu-west-1"}
+
+	_, err := m.FindDefaultSubnet(context.Background())
+	if !errors.Is(err, ErrNoDefaultVPC) {
+		t.Fatalf("FindDefaultSubnet() error = %v, want ErrNoDefaultVPC", err)
+	}
+	if !strings.Contains(err.Error(), "eu-west-1") {
+		t.Errorf("error %q should name the region", err)
+	}
+}
+
+func TestFindDefaultSubnetNoPublicSubnet(t *testing.T) {
+	fake := &fakeDefaultVPC{
+		vpcs:    []types.Vpc{{VpcId: aws.String("vpc-default"), IsDefault: aws.Bool(true)}},
+		subnets: []types.Subnet{testSubnet("subnet-private", "us-east-1a", false, 4000)},
+	}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	_, err := m.FindDefaultSubnet(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no available subnet that assigns public IPs") {
+		t.Errorf("FindDefaultSubnet() error = %v, want no public subnet error", err)
+	}
+}
+
+func TestSelectPublicSubnet(t *testing.T) {
+	pending := testSubnet("subnet-pending", "us-east-1a", true, 9000)
+	pending.State = types.SubnetStatePending
+
+	tests := []struct {
+		name    string
+		subnets []types.Subnet
+		want    string
+	}{
+		{"most free addresses", []types.Subnet{testSubnet("subnet-a", "us-east-1a", true, 100), testSubnet("subnet-b", "us-east-1b", true, 200)}, "subnet-b"},
+		{"tie broken by zone", []types.Subnet{testSubnet("subnet-d", "us-east-1d", true, 100), testSubnet("subnet-a", "us-east-1a", true, 100)}, "subnet-a"},
+		{"skips private", []types.Subnet{testSubnet("subnet-private", "us-east-1a", false, 900), testSubnet("subnet-public", "us-east-1b", true, 10)}, "subnet-public"},
+		{"skips unavailable", []types.Subnet{pending, testSubnet("subnet-b", "us-east-1b", true, 10)}, "subnet-b"},
+		{"none public", []types.Subnet{testSubnet("subnet-private", "us-east-1a", false, 900)}, ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subnet, ok := selectPublicSubnet(tt.subnets)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("selectPublicSubnet() = %s, want none", aws.ToString(subnet.SubnetId))
+				}
+				return
+			}
+			if !ok || aws.ToString(subnet.SubnetId) != tt.want {
+				t.Errorf("selectPublicSubnet() = %s, %v; want %s", aws.ToString(subnet.SubnetId), ok, tt.want)
+			}
+		})
+	}
+}
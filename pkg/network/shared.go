@@ -0,0 +1,206 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// CreateSubnetsInVPC creates public subnets, a route table through the VPC's
+// existing internet gateway, and a security group for a cluster inside a
+// caller-provided VPC. The returned resources are marked SharedVPC, so
+// DeleteNetwork removes only what was created here. opts may be nil; its
+// DNS settings, EnableNAT, and S3Endpoint do not apply to a shared VPC.
+func (m *Manager) CreateSubnetsInVPC(ctx context.Context, vpcID, clusterName string, opts *Options) (*NetworkResources, error) {
+	resources := &NetworkResources{
+		VpcID:         vpcID,
+		Region:        m.region,
+		ClusterName:   clusterName,
+		ManagedByPctl: true,
+		SharedVPC:     true,
+	}
+
+	// Check the VPC and plan the subnets before creating anything
+	sshCIDRs, err := resolveSSHCIDRs(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	azCount := opts.azCount()
+	zones, err := m.selectZones(ctx, azCount)
+	if err != nil {
+		return nil, err
+	}
+	igwID, err := m.findInternetGateway(ctx, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	resources.InternetGatewayID = igwID
+	cidrs, err := m.planSharedSubnets(ctx, vpcID, azCount)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, cidr := range cidrs {
+		var zone string
+		if zones != nil {
+			zone = zones[i]
+		}
+		subnetID, err := m.createSubnet(ctx, clusterName, vpcID, cidr, "public", zone)
+		if err != nil {
+			m.cleanup(ctx, resources)
+			return nil, fmt.Errorf("failed to create subnet %s: %w", cidr, err)
+		}
+		resources.PublicSubnetIDs = append(resources.PublicSubnetIDs, subnetID)
+	}
+	resources.PublicSubnetID = resources.PublicSubnetIDs[0]
+
+	routeTableID, err := m.createRouteTable(ctx, clusterName, vpcID, igwID, resources.PublicSubnetIDs)
+	if err != nil {
+		m.cleanup(ctx, resources)
+		return nil, fmt.Errorf("failed to create route table: %w", err)
+	}
+	resources.RouteTableID = routeTableID
+
+	var ingressRules []IngressRule
+	if opts != nil {
+		ingressRules = opts.IngressRules
+	}
+	sgID, err := m.createSecurityGroup(ctx, clusterName, vpcID, sshCIDRs, ingressRules)
+	resources.SecurityGroupID = sgID // Set even on failure so cleanup removes it
+	resources.SSHCIDRs = sshCIDRs
+	if err != nil {
+		m.cleanup(ctx, resources)
+		return nil, fmt.Errorf("failed to create security group: %w", err)
+	}
+
+	return resources, nil
+}
+
+// findInternetGateway returns the internet gateway attached to vpcID.
+func (m *Manager) findInternetGateway(ctx context.Context, vpcID string) (string, error) {
+	output, err := m.ec2Client.DescribeInternetGateways(ctx, &ec2.DescribeInternetGatewaysInput{
+		Filters: []types.Filter{
+			{Name: aws.String("attachment.vpc-id"), Values: []string{vpcID}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe internet gateways for %s: %w", vpcID, err)
+	}
+	if len(output.InternetGateways) == 0 {
+		return "", fmt.Errorf("VPC %s has no internet gateway attached; the head node needs one for SSH access", vpcID)
+	}
+	return aws.ToString(output.InternetGateways[0].InternetGatewayId), nil
+}
+
+// planSharedSubnets picks count free /24 CIDRs in vpcID that do not overlap
+// its existing subnets.
+func (m *Manager) planSharedSubnets(ctx context.Context, vpcID string, count int) ([]string, error) {
+	vpcs, err := m.ec2Client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{
+		VpcIds: []string{vpcID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe VPC %s: %w", vpcID, err)
+	}
+	if len(vpcs.Vpcs) == 0 {
+		return nil, fmt.Errorf("VPC %s not found in region %s", vpcID, m.region)
+	}
+
+	subnets, err := m.ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{vpcID}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe subnets in %s: %w", vpcID, err)
+	}
+	var used []string
+	for _, subnet := range subnets.Subnets {
+		used = append(used, aws.ToString(subnet.CidrBlock))
+	}
+
+	cidrs := freeSubnetCIDRs(vpcCIDRBlocks(vpcs.Vpcs[0]), used, count)
+	if len(cidrs) < count {
+		return nil, fmt.Errorf("VPC %s has room for only %d of the %d /24 subnets needed", vpcID, len(cidrs), count)
+	}
+	return cidrs, nil
+}
+
+// vpcCIDRBlocks returns the IPv4 CIDR blocks associated with a VPC, primary
+// first.
+func vpcCIDRBlocks(vpc types.Vpc) []string {
+	blocks := []string{aws.ToString(vpc.CidrBlock)}
+	for _, assoc := range vpc.CidrBlockAssociationSet {
+		cidr := aws.ToString(assoc.CidrBlock)
+		if cidr == blocks[0] {
+			continue
+		}
+		if assoc.CidrBlockState != nil && assoc.CidrBlockState.State != types.VpcCidrBlockStateCodeAssociated {
+			continue
+		}
+		blocks = append(blocks, cidr)
+	}
+	return blocks
+}
+
+// freeSubnetCIDRs returns up to count /24 blocks from the VPC CIDR blocks,
+// lowest first, that overlap none of the used CIDRs.
+func freeSubnetCIDRs(vpcCIDRs, used []string, count int) []string {
+	var taken []*net.IPNet
+	for _, cidr := range used {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			taken = append(taken, ipNet)
+		}
+	}
+
+	var free []string
+	for _, cidr := range vpcCIDRs {
+		_, block, err := net.ParseCIDR(cidr)
+		if err != nil || block.IP.To4() == nil {
+			continue
+		}
+		ones, _ := block.Mask.Size()
+		if ones > 24 {
+			continue
+		}
+
+		start := binary.BigEndian.Uint32(block.IP.To4())
+		for i := uint32(0); i < 1<<(24-ones) && len(free) < count; i++ {
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, start+i<<8)
+			candidate := &net.IPNet{IP: ip, Mask: net.CIDRMask(24, 32)}
+			if !overlapsAny(candidate, taken) {
+				free = append(free, candidate.String())
+			}
+		}
+	}
+	return free
+}
+
+// overlapsAny reports whether cidr overlaps any of the networks.
+func overlapsAny(cidr *net.IPNet, networks []*net.IPNet) bool {
+	for _, n := range networks {
+		if n.Contains(cidr.IP) || cidr.Contains(n.IP) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// fakeSharedVPC serves an existing VPC with subnets already in use, on top
+// of the calls recorded by fakeEC2.
+type fakeSharedVPC struct {
+	*fakeEC2
+	vpc         types.Vpc
+	usedCIDRs   []string
+	igwIDs      []string
+	subnetCIDRs []string
+}
+
+func (f *fakeSharedVPC) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	return &ec2.DescribeVpcsOutput{Vpcs: []types.Vpc{f.vpc}}, nil
+}
+
+func (f *fakeSharedVPC) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	output := &ec2.DescribeSubnetsOutput{}
+	for _, cidr := range f.usedCIDRs {
+		output.Subnets = append(output.Subnets, types.Subnet{CidrBlock: aws.String(cidr)})
+	}
+	return output, nil
+}
+
+func (f *fakeSharedVPC) DescribeInternetGateways(ctx context.Context, params *ec2.DescribeInternetGatewaysInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInternetGatewaysOutput, error) {
+	output := &ec2.DescribeInternetGatewaysOutput{}
+	for _, id := range f.igwIDs {
+		output.InternetGateways = append(output.InternetGateways, types.InternetGateway{InternetGatewayId: aws.String(id)})
+	}
+	return output, nil
+}
+
+func (f *fakeSharedVPC) CreateSubnet(ctx context.Context, params *ec2.CreateSubnetInput, optFns ...func(*ec2.Options)) (*ec2.CreateSubnetOutput, error) {
+	f.subnetCIDRs = append(f.subnetCIDRs, aws.ToString(params.CidrBlock))
+	id := fmt.Sprintf("subnet-%d", len(f.subnetCIDRs))
+	return &ec2.CreateSubnetOutput{Subnet: &types.Subnet{SubnetId: aws.String(id)}}, nil
+}
+
+func (f *fakeSharedVPC) ModifySubnetAttribute(ctx context.Context, params *ec2.ModifySubnetAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifySubnetAttributeOutput, error) {
+	return &ec2.ModifySubnetAttributeOutput{}, nil
+}
+
+func newFakeSharedVPC() *fakeSharedVPC {
+	return &fakeSharedVPC{
+		fakeEC2:   &fakeEC2{},
+		vpc:       types.Vpc{VpcId: aws.String("vpc-shared"), CidrBlock: aws.String("10.1.0.0/16")},
+		usedCIDRs: []string{"10.1.0.0/24", "10.1.1.0/24"},
+		igwIDs:    []string{"igw-shared"},
+	}
+}
+
+func TestCreateSubnetsInVPC(t *testing.T) {
+	fake := newFakeSharedVPC()
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	resources, err := m.CreateSubnetsInVPC(context.Background(), "vpc-shared", "test-cluster", &Options{
+		AllowedSSHCIDRs: []string{"203.0.113.7/32"},
+	})
+	if err != nil {
+		t.Fatalf("CreateSubnetsInVPC() failed: %v", err)
+	}
+
+	if !resources.SharedVPC || !resources.ManagedByPctl || resources.VpcID != "vpc-shared" {
+		t.Errorf("Expected pctl-managed resources in shared VPC, got %+v", resources)
+	}
+	if resources.InternetGatewayID != "igw-shared" || resources.PublicSubnetID != "subnet-1" {
+		t.Errorf("Unexpected internet gateway or subnet: %+v", resources)
+	}
+	if resources.SecurityGroupID != "sg-12345" || resources.RouteTableID == "" {
+		t.Errorf("Expected security group and route table, got %+v", resources)
+	}
+	if !reflect.DeepEqual(fake.subnetCIDRs, []string{"10.1.2.0/24"}) {
+		t.Errorf("Expected the first free /24, got %v", fake.subnetCIDRs)
+	}
+	if len(fake.routes) != 1 || aws.ToString(fake.routes[0].GatewayId) != "igw-shared" {
+		t.Errorf("Expected default route through the existing internet gateway, got %+v", fake.routes)
+	}
+}
+
+func TestCreateSubnetsInVPCWithoutInternetGateway(t *testing.T) {
+	fake := newFakeSharedVPC()
+	fake.igwIDs = nil
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	_, err := m.CreateSubnetsInVPC(context.Background(), "vpc-shared", "test-cluster", &Options{
+		AllowedSSHCIDRs: []string{"203.0.113.7/32"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no internet gateway") {
+		t.Fatalf("Expected missing internet gateway error, got %v", err)
+	}
+	if len(fake.subnetCIDRs) != 0 {
+		t.Errorf("Expected no subnets created, got %v", fake.subnetCIDRs)
+	}
+}
+
+func TestCleanupSharedVPCKeepsVPC(t *testing.T) {
+	fake := &fakeEC2{}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	err := m.DeleteNetwork(context.Background(), &NetworkResources{
+		VpcID:             "vpc-shared",
+		InternetGatewayID: "igw-shared",
+		PublicSubnetID:    "subnet-1",
+		RouteTableID:      "rtb-public",
+		ManagedByPctl:     true,
+		SharedVPC:         true,
+	})
+	if err != nil {
+		t.Fatalf("DeleteNetwork() failed: %v", err)
+	}
+
+	want := []string{"DeleteRouteTable:rtb-public", "DeleteSubnet:subnet-1"}
+	if !reflect.DeepEqual(fake.calls, want) {
+		t.Errorf("Expected only pctl's resources deleted %v, got %v", want, fake.calls)
+	}
+}
+
+func TestFreeSubnetCIDRs(t *testing.T) {
+	tests := []struct {
+		name  string
+		vpc   []string
+		used  []string
+		count int
+		want  []string
+	}{
+		{"empty VPC", []string{"10.1.0.0/16"}, nil, 2, []string{"10.1.0.0/24", "10.1.1.0/24"}},
+		{"skips used", []string{"10.1.0.0/16"}, []string{"10.1.0.0/24", "10.1.2.0/24"}, 2, []string{"10.1.1.0/24", "10.1.3.0/24"}},
+		{"skips larger subnet", []string{"10.1.0.0/16"}, []string{"10.1.0.0/22"}, 1, []string{"10.1.4.0/24"}},
+		{"skips smaller subnet", []string{"10.1.0.0/16"}, []string{"10.1.0.16/28"}, 1, []string{"10.1.1.0/24"}},
+		{"secondary block", []string{"10.1.0.0/24", "10.2.0.0/23"}, []string{"10.1.0.0/24"}, 2, []string{"10.2.0.0/24", "10.2.1.0/24"}},
+		{"VPC smaller than /24", []string{"10.1.0.0/25"}, nil, 1, nil},
+		{"full VPC", []string{"10.1.0.0/23"}, []string{"10.1.0.0/23"}, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := freeSubnetCIDRs(tt.vpc, tt.used, tt.count); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("freeSubnetCIDRs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVPCCIDRBlocks(t *testing.T) {
+	vpc := types.Vpc{
+		CidrBlock: aws.String("10.1.0.0/16"),
+		CidrBlockAssociationSet: []types.VpcCidrBlockAssociation{
+			{CidrBlock: aws.String("10.1.0.0/16"), CidrBlockState: &types.VpcCidrBlockState{State: types.VpcCidrBlockStateCodeAssociated}},
+			{CidrBlock: aws.String("10.2.0.0/16"), CidrBlockState: &types.VpcCidrBlockState{State: types.VpcCidrBlockStateCodeAssociated}},
+			{CidrBlock: aws.String("10.3.0.0/16"), CidrBlockState: &types.VpcCidrBlockState{State: types.VpcCidrBlockStateCodeDisassociated}},
+		},
+	}
+
+	if got, want := vpcCIDRBlocks(vpc), []string{"10.1.0.0/16", "10.2.0.0/16"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("vpcCIDRBlocks() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// publicIPURL returns the caller's public IPv4 address as plain text.
+var publicIPURL = "https://checkip.amazonaws.com"
+
+// DetectPublicIP returns the public IPv4 address this machine reaches AWS
+// from.
+func DetectPublicIP(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, publicIPURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up public IP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", fmt.Errorf("failed to read public IP: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("public IP lookup returned status %d", resp.StatusCode)
+	}
+
+	text := strings.TrimSpace(string(data))
+	ip := net.ParseIP(text)
+	if ip == nil || ip.To4() == nil {
+		return "", fmt.Errorf("public IP lookup returned %q, not an IPv4 address", text)
+	}
+	return ip.String(), nil
+}
+
+// ValidateSSHCIDRs checks that each CIDR allowed to SSH to a cluster is a
+// valid IPv4 CIDR.
+func ValidateSSHCIDRs(cidrs []string) error {
+	for _, cidr := range cidrs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid SSH CIDR %q: expected an IPv4 CIDR such as 203.0.113.0/24", cidr)
+		}
+		if ip.To4() == nil {
+			return fmt.Errorf("invalid SSH CIDR %q: only IPv4 CIDRs are supported", cidr)
+		}
+	}
+	return nil
+}
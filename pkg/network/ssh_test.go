@@ -0,0 +1,125 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestValidateSSHCIDRs(t *testing.T) {
+	if err := ValidateSSHCIDRs([]string{"203.0.113.0/24", "198.51.100.7/32"}); err != nil {
+		t.Errorf("ValidateSSHCIDRs() error = %v", err)
+	}
+
+	tests := []struct {
+		cidr    string
+		wantErr string
+	}{
+		{"203.0.113.7", "expected an IPv4 CIDR"},
+		{"203.0.113.0/33", "expected an IPv4 CIDR"},
+		{"office", "expected an IPv4 CIDR"},
+		{"2001:db8::/32", "only IPv4 CIDRs are supported"},
+	}
+	for _, tt := range tests {
+		err := ValidateSSHCIDRs([]string{"10.0.0.0/8", tt.cidr})
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), tt.cidr) {
+			t.Errorf("ValidateSSHCIDRs(%q) error = %v, want %q", tt.cidr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDetectPublicIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{"ipv4 with newline", http.StatusOK, "203.0.113.7\n", "203.0.113.7", false},
+		{"not an address", http.StatusOK, "<html>", "", true},
+		{"ipv6", http.StatusOK, "2001:db8::1\n", "", true},
+		{"server error", http.StatusServiceUnavailable, "", "", true},
+	}
+
+	original := publicIPURL
+	defer func() { publicIPURL = original }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+			publicIPURL = server.URL
+
+			got, err := DetectPublicIP(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectPublicIP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DetectPublicIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIngressPermissionsSSHCIDRs(t *testing.T) {
+	permissions := ingressPermissions("sg-12345", []string{"203.0.113.0/24", "198.51.100.7/32"}, nil)
+	if len(permissions) != 2 {
+		t.Fatalf("Expected the SSH and intra-group rules, got %d", len(permissions))
+	}
+
+	ssh := permissions[0]
+	if aws.ToInt32(ssh.FromPort) != 22 || aws.ToInt32(ssh.ToPort) != 22 || len(ssh.IpRanges) != 2 {
+		t.Fatalf("Unexpected SSH rule: %+v", ssh)
+	}
+	for i, want := range []string{"203.0.113.0/24", "198.51.100.7/32"} {
+		if got := aws.ToString(ssh.IpRanges[i].CidrIp); got != want {
+			t.Errorf("SSH range %d = %s, want %s", i, got, want)
+		}
+	}
+
+	group := permissions[1]
+	if aws.ToString(group.IpProtocol) != "-1" || len(group.UserIdGroupPairs) != 1 || aws.ToString(group.UserIdGroupPairs[0].GroupId) != "sg-12345" {
+		t.Errorf("Intra-group rule not preserved: %+v", group)
+	}
+
+	for _, permission := range permissions {
+		for _, ipRange := range permission.IpRanges {
+			if aws.ToString(ipRange.CidrIp) == "0.0.0.0/0" {
+				t.Error("No rule should be open to 0.0.0.0/0")
+			}
+		}
+	}
+}
+
+func TestCreateNetworkRejectsInvalidSSHCIDR(t *testing.T) {
+	// Any EC2 call would panic through the fake's nil embedded interface
+	m := &Manager{ec2Client: &fakeEC2{}, region: "us-east-1"}
+
+	_, err := m.CreateNetwork(context.Background(), "test-cluster", &Options{AllowedSSHCIDRs: []string{"0.0.0.0"}})
+	if err == nil || !strings.Contains(err.Error(), `invalid SSH CIDR "0.0.0.0"`) {
+		t.Errorf("CreateNetwork() error = %v, want invalid SSH CIDR", err)
+	}
+}
@@ -18,7 +18,9 @@ package network
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -28,20 +30,134 @@ import (
 
 // NetworkResources represents created network resources.
 type NetworkResources struct {
-	VpcID             string
-	PublicSubnetID    string
-	PrivateSubnetID   string
+	VpcID           string
+	PublicSubnetID  string
+	PrivateSubnetID string
+	// PublicSubnetIDs and PrivateSubnetIDs hold one subnet per Availability
+	// Zone; PublicSubnetID and PrivateSubnetID are the first of each
+	PublicSubnetIDs   []string
+	PrivateSubnetIDs  []string
 	InternetGatewayID string
 	RouteTableID      string
-	SecurityGroupID   string
-	Region            string
-	ClusterName       string
-	ManagedByPctl     bool
+	// NatGatewayID, EIPAllocationID, and PrivateRouteTableID are set when
+	// the private subnets route outbound traffic through a NAT gateway
+	NatGatewayID        string
+	EIPAllocationID     string
+	PrivateRouteTableID string
+	// S3EndpointID is the gateway VPC endpoint that keeps S3 traffic off
+	// the internet gateway and NAT
+	S3EndpointID    string
+	SecurityGroupID string
+	// SSHCIDRs are the CIDRs the security group allows SSH from
+	SSHCIDRs      []string
+	DhcpOptionsID string
+	Region        string
+	ClusterName   string
+	ManagedByPctl bool
+	// SharedVPC marks subnets created in a caller-provided VPC; cleanup
+	// deletes only what pctl created and leaves the VPC and its internet
+	// gateway in place
+	SharedVPC bool
+}
+
+// Options configures optional VPC settings.
+type Options struct {
+	// DomainName is the DNS search domain handed out by DHCP
+	DomainName string
+	// DNSServers are the DNS server IPs handed out by DHCP
+	// (defaults to AmazonProvidedDNS when only DomainName is set)
+	DNSServers []string
+	// IngressRules are additional inbound rules on the cluster security group
+	IngressRules []IngressRule
+	// AllowedSSHCIDRs are the IPv4 CIDRs allowed to SSH to the cluster
+	// (defaults to the caller's public IP)
+	AllowedSSHCIDRs []string
+	// AZCount is the number of Availability Zones to spread subnets across
+	// (defaults to 1, in a zone chosen by AWS)
+	AZCount int
+	// EnableNAT gives the private subnets outbound internet access through
+	// a NAT gateway in the first public subnet. NAT gateways are billed
+	// hourly, so this is off by default.
+	EnableNAT bool
+	// S3Endpoint adds a gateway VPC endpoint for S3 to the VPC's route
+	// tables, so S3 traffic avoids NAT and internet data charges
+	S3Endpoint bool
+}
+
+// natGatewayWaitTimeout bounds how long to wait for a NAT gateway to become
+// available or finish deleting.
+const natGatewayWaitTimeout = 10 * time.Minute
+
+// MaxAZCount is the most Availability Zones a pctl VPC spreads across.
+const MaxAZCount = 6
+
+// azCount returns the number of Availability Zones to create subnets in.
+func (o *Options) azCount() int {
+	if o == nil || o.AZCount < 1 {
+		return 1
+	}
+	return o.AZCount
+}
+
+// subnetCIDRs returns the non-overlapping public and private /24s of the
+// 10.0.0.0/16 VPC for the Availability Zone at index. The first zone keeps
+// the original 10.0.1.0/24 and 10.0.2.0/24.
+func subnetCIDRs(index int) (public, private string) {
+	return fmt.Sprintf("10.0.%d.0/24", 2*index+1), fmt.Sprintf("10.0.%d.0/24", 2*index+2)
+}
+
+// IngressRule allows inbound traffic on a port range from an IPv4 CIDR.
+type IngressRule struct {
+	Protocol    string
+	FromPort    int32
+	ToPort      int32
+	CIDR        string
+	Description string
+}
+
+// hasDhcpOptions reports whether a custom DHCP options set is needed.
+func (o *Options) hasDhcpOptions() bool {
+	return o != nil && (o.DomainName != "" || len(o.DNSServers) > 0)
+}
+
+// ec2API is the subset of the EC2 API used by Manager.
+type ec2API interface {
+	CreateVpc(ctx context.Context, params *ec2.CreateVpcInput, optFns ...func(*ec2.Options)) (*ec2.CreateVpcOutput, error)
+	ModifyVpcAttribute(ctx context.Context, params *ec2.ModifyVpcAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyVpcAttributeOutput, error)
+	DeleteVpc(ctx context.Context, params *ec2.DeleteVpcInput, optFns ...func(*ec2.Options)) (*ec2.DeleteVpcOutput, error)
+	CreateDhcpOptions(ctx context.Context, params *ec2.CreateDhcpOptionsInput, optFns ...func(*ec2.Options)) (*ec2.CreateDhcpOptionsOutput, error)
+	AssociateDhcpOptions(ctx context.Context, params *ec2.AssociateDhcpOptionsInput, optFns ...func(*ec2.Options)) (*ec2.AssociateDhcpOptionsOutput, error)
+	DeleteDhcpOptions(ctx context.Context, params *ec2.DeleteDhcpOptionsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteDhcpOptionsOutput, error)
+	CreateInternetGateway(ctx context.Context, params *ec2.CreateInternetGatewayInput, optFns ...func(*ec2.Options)) (*ec2.CreateInternetGatewayOutput, error)
+	AttachInternetGateway(ctx context.Context, params *ec2.AttachInternetGatewayInput, optFns ...func(*ec2.Options)) (*ec2.AttachInternetGatewayOutput, error)
+	DetachInternetGateway(ctx context.Context, params *ec2.DetachInternetGatewayInput, optFns ...func(*ec2.Options)) (*ec2.DetachInternetGatewayOutput, error)
+	DeleteInternetGateway(ctx context.Context, params *ec2.DeleteInternetGatewayInput, optFns ...func(*ec2.Options)) (*ec2.DeleteInternetGatewayOutput, error)
+	CreateSubnet(ctx context.Context, params *ec2.CreateSubnetInput, optFns ...func(*ec2.Options)) (*ec2.CreateSubnetOutput, error)
+	ModifySubnetAttribute(ctx context.Context, params *ec2.ModifySubnetAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifySubnetAttributeOutput, error)
+	DeleteSubnet(ctx context.Context, params *ec2.DeleteSubnetInput, optFns ...func(*ec2.Options)) (*ec2.DeleteSubnetOutput, error)
+	CreateRouteTable(ctx context.Context, params *ec2.CreateRouteTableInput, optFns ...func(*ec2.Options)) (*ec2.CreateRouteTableOutput, error)
+	CreateRoute(ctx context.Context, params *ec2.CreateRouteInput, optFns ...func(*ec2.Options)) (*ec2.CreateRouteOutput, error)
+	AssociateRouteTable(ctx context.Context, params *ec2.AssociateRouteTableInput, optFns ...func(*ec2.Options)) (*ec2.AssociateRouteTableOutput, error)
+	DeleteRouteTable(ctx context.Context, params *ec2.DeleteRouteTableInput, optFns ...func(*ec2.Options)) (*ec2.DeleteRouteTableOutput, error)
+	CreateSecurityGroup(ctx context.Context, params *ec2.CreateSecurityGroupInput, optFns ...func(*ec2.Options)) (*ec2.CreateSecurityGroupOutput, error)
+	AuthorizeSecurityGroupIngress(ctx context.Context, params *ec2.AuthorizeSecurityGroupIngressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupIngressOutput, error)
+	DeleteSecurityGroup(ctx context.Context, params *ec2.DeleteSecurityGroupInput, optFns ...func(*ec2.Options)) (*ec2.DeleteSecurityGroupOutput, error)
+	DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error)
+	DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+	DescribeAvailabilityZones(ctx context.Context, params *ec2.DescribeAvailabilityZonesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAvailabilityZonesOutput, error)
+	DescribeInternetGateways(ctx context.Context, params *ec2.DescribeInternetGatewaysInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInternetGatewaysOutput, error)
+	CreateVpcEndpoint(ctx context.Context, params *ec2.CreateVpcEndpointInput, optFns ...func(*ec2.Options)) (*ec2.CreateVpcEndpointOutput, error)
+	DeleteVpcEndpoints(ctx context.Context, params *ec2.DeleteVpcEndpointsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteVpcEndpointsOutput, error)
+	AllocateAddress(ctx context.Context, params *ec2.AllocateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AllocateAddressOutput, error)
+	ReleaseAddress(ctx context.Context, params *ec2.ReleaseAddressInput, optFns ...func(*ec2.Options)) (*ec2.ReleaseAddressOutput, error)
+	CreateNatGateway(ctx context.Context, params *ec2.CreateNatGatewayInput, optFns ...func(*ec2.Options)) (*ec2.CreateNatGatewayOutput, error)
+	DeleteNatGateway(ctx context.Context, params *ec2.DeleteNatGatewayInput, optFns ...func(*ec2.Options)) (*ec2.DeleteNatGatewayOutput, error)
+	DescribeNatGateways(ctx context.Context, params *ec2.DescribeNatGatewaysInput, optFns ...func(*ec2.Options)) (*ec2.DescribeNatGatewaysOutput, error)
 }
 
 // Manager manages VPC and networking resources.
 type Manager struct {
-	ec2Client *ec2.Client
+	ec2Client ec2API
 	region    string
 }
 
@@ -59,13 +175,25 @@ func NewManager(ctx context.Context, region string) (*Manager, error) {
 }
 
 // CreateNetwork creates a complete VPC network for a cluster.
-func (m *Manager) CreateNetwork(ctx context.Context, clusterName string) (*NetworkResources, error) {
+// opts may be nil to use the default VPC settings.
+func (m *Manager) CreateNetwork(ctx context.Context, clusterName string, opts *Options) (*NetworkResources, error) {
 	resources := &NetworkResources{
 		Region:        m.region,
 		ClusterName:   clusterName,
 		ManagedByPctl: true,
 	}
 
+	// Resolve SSH access and pick the Availability Zones before creating anything
+	sshCIDRs, err := resolveSSHCIDRs(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	azCount := opts.azCount()
+	zones, err := m.selectZones(ctx, azCount)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create VPC
 	vpcID, err := m.createVPC(ctx, clusterName)
 	if err != nil {
@@ -73,6 +201,16 @@ func (m *Manager) CreateNetwork(ctx context.Context, clusterName string) (*Netwo
 	}
 	resources.VpcID = vpcID
 
+	// Apply custom DNS settings
+	if opts.hasDhcpOptions() {
+		dhcpOptionsID, err := m.createDhcpOptions(ctx, clusterName, vpcID, opts)
+		if err != nil {
+			m.cleanup(ctx, resources)
+			return nil, fmt.Errorf("failed to configure DHCP options: %w", err)
+		}
+		resources.DhcpOptionsID = dhcpOptionsID
+	}
+
 	// Create Internet Gateway
 	igwID, err := m.createInternetGateway(ctx, clusterName, vpcID)
 	if err != nil {
@@ -81,37 +219,83 @@ func (m *Manager) CreateNetwork(ctx context.Context, clusterName string) (*Netwo
 	}
 	resources.InternetGatewayID = igwID
 
-	// Create public subnet for head node
-	publicSubnetID, err := m.createSubnet(ctx, clusterName, vpcID, "10.0.1.0/24", "public")
-	if err != nil {
-		m.cleanup(ctx, resources)
-		return nil, fmt.Errorf("failed to create public subnet: %w", err)
-	}
-	resources.PublicSubnetID = publicSubnetID
+	// Create a public and a private subnet in each zone; the head node uses
+	// the first public subnet
+	for i := 0; i < azCount; i++ {
+		var zone string
+		if zones != nil {
+			zone = zones[i]
+		}
+		publicCIDR, privateCIDR := subnetCIDRs(i)
 
-	// Create private subnet for compute nodes
-	privateSubnetID, err := m.createSubnet(ctx, clusterName, vpcID, "10.0.2.0/24", "private")
-	if err != nil {
-		m.cleanup(ctx, resources)
-		return nil, fmt.Errorf("failed to create private subnet: %w", err)
+		publicSubnetID, err := m.createSubnet(ctx, clusterName, vpcID, publicCIDR, "public", zone)
+		if err != nil {
+			m.cleanup(ctx, resources)
+			return nil, fmt.Errorf("failed to create public subnet: %w", err)
+		}
+		resources.PublicSubnetIDs = append(resources.PublicSubnetIDs, publicSubnetID)
+
+		privateSubnetID, err := m.createSubnet(ctx, clusterName, vpcID, privateCIDR, "private", zone)
+		if err != nil {
+			m.cleanup(ctx, resources)
+			return nil, fmt.Errorf("failed to create private subnet: %w", err)
+		}
+		resources.PrivateSubnetIDs = append(resources.PrivateSubnetIDs, privateSubnetID)
 	}
-	resources.PrivateSubnetID = privateSubnetID
+	resources.PublicSubnetID = resources.PublicSubnetIDs[0]
+	resources.PrivateSubnetID = resources.PrivateSubnetIDs[0]
 
 	// Create and configure route table
-	routeTableID, err := m.createRouteTable(ctx, clusterName, vpcID, igwID, publicSubnetID)
+	routeTableID, err := m.createRouteTable(ctx, clusterName, vpcID, igwID, resources.PublicSubnetIDs)
 	if err != nil {
 		m.cleanup(ctx, resources)
 		return nil, fmt.Errorf("failed to create route table: %w", err)
 	}
 	resources.RouteTableID = routeTableID
 
+	// Route the private subnets through a NAT gateway. A single gateway
+	// serves every zone to keep the cost down.
+	if opts != nil && opts.EnableNAT {
+		fmt.Printf("🌐 Creating NAT gateway (billed hourly while the cluster exists)...\n")
+		if err := m.createNatGateway(ctx, clusterName, resources); err != nil {
+			m.cleanup(ctx, resources)
+			return nil, fmt.Errorf("failed to create NAT gateway: %w", err)
+		}
+
+		privateRouteTableID, err := m.createPrivateRouteTable(ctx, clusterName, vpcID, resources.NatGatewayID, resources.PrivateSubnetIDs)
+		resources.PrivateRouteTableID = privateRouteTableID // Set even on failure so cleanup removes it
+		if err != nil {
+			m.cleanup(ctx, resources)
+			return nil, fmt.Errorf("failed to create private route table: %w", err)
+		}
+	}
+
+	// Route S3 traffic through a gateway endpoint, which is free
+	if opts != nil && opts.S3Endpoint {
+		routeTableIDs := []string{resources.RouteTableID}
+		if resources.PrivateRouteTableID != "" {
+			routeTableIDs = append(routeTableIDs, resources.PrivateRouteTableID)
+		}
+		endpointID, err := m.createS3Endpoint(ctx, clusterName, vpcID, routeTableIDs)
+		if err != nil {
+			m.cleanup(ctx, resources)
+			return nil, fmt.Errorf("failed to create S3 endpoint: %w", err)
+		}
+		resources.S3EndpointID = endpointID
+	}
+
 	// Create security group
-	sgID, err := m.createSecurityGroup(ctx, clusterName, vpcID)
+	var ingressRules []IngressRule
+	if opts != nil {
+		ingressRules = opts.IngressRules
+	}
+	sgID, err := m.createSecurityGroup(ctx, clusterName, vpcID, sshCIDRs, ingressRules)
+	resources.SecurityGroupID = sgID // Set even on failure so cleanup removes it
+	resources.SSHCIDRs = sshCIDRs
 	if err != nil {
 		m.cleanup(ctx, resources)
 		return nil, fmt.Errorf("failed to create security group: %w", err)
 	}
-	resources.SecurityGroupID = sgID
 
 	return resources, nil
 }
@@ -166,6 +350,61 @@ You can request a limit increase at: https://console.aws.amazon.com/servicequota
 	return *output.Vpc.VpcId, nil
 }
 
+// createDhcpOptions creates a DHCP options set with the custom domain name and
+// DNS servers and associates it with the VPC.
+func (m *Manager) createDhcpOptions(ctx context.Context, clusterName, vpcID string, opts *Options) (string, error) {
+	output, err := m.ec2Client.CreateDhcpOptions(ctx, &ec2.CreateDhcpOptionsInput{
+		DhcpConfigurations: dhcpConfigurations(opts),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeDhcpOptions,
+				Tags: []types.Tag{
+					{Key: aws.String("Name"), Value: aws.String(fmt.Sprintf("pctl-%s-dhcp", clusterName))},
+					{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
+					{Key: aws.String("ClusterName"), Value: aws.String(clusterName)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	dhcpOptionsID := aws.ToString(output.DhcpOptions.DhcpOptionsId)
+
+	_, err = m.ec2Client.AssociateDhcpOptions(ctx, &ec2.AssociateDhcpOptionsInput{
+		DhcpOptionsId: aws.String(dhcpOptionsID),
+		VpcId:         aws.String(vpcID),
+	})
+	if err != nil {
+		// Not yet associated, so it can be deleted right away
+		m.ec2Client.DeleteDhcpOptions(ctx, &ec2.DeleteDhcpOptionsInput{DhcpOptionsId: aws.String(dhcpOptionsID)})
+		return "", fmt.Errorf("failed to associate DHCP options: %w", err)
+	}
+
+	return dhcpOptionsID, nil
+}
+
+// dhcpConfigurations builds the DHCP options for a custom domain and DNS
+// servers. Without explicit servers, AmazonProvidedDNS is kept so instances
+// can still resolve names.
+func dhcpConfigurations(opts *Options) []types.NewDhcpConfiguration {
+	servers := opts.DNSServers
+	if len(servers) == 0 {
+		servers = []string{"AmazonProvidedDNS"}
+	}
+
+	configs := []types.NewDhcpConfiguration{
+		{Key: aws.String("domain-name-servers"), Values: servers},
+	}
+	if opts.DomainName != "" {
+		configs = append(configs, types.NewDhcpConfiguration{
+			Key:    aws.String("domain-name"),
+			Values: []string{opts.DomainName},
+		})
+	}
+	return configs
+}
+
 func (m *Manager) createInternetGateway(ctx context.Context, clusterName, vpcID string) (string, error) {
 	output, err := m.ec2Client.CreateInternetGateway(ctx, &ec2.CreateInternetGatewayInput{
 		TagSpecifications: []types.TagSpecification{
@@ -197,22 +436,89 @@ func (m *Manager) createInternetGateway(ctx context.Context, clusterName, vpcID
 	return igwID, nil
 }
 
-func (m *Manager) createSubnet(ctx context.Context, clusterName, vpcID, cidr, subnetType string) (string, error) {
-	output, err := m.ec2Client.CreateSubnet(ctx, &ec2.CreateSubnetInput{
+// resolveSSHCIDRs returns the CIDRs allowed to SSH to the cluster,
+// defaulting to the caller's public IP.
+func resolveSSHCIDRs(ctx context.Context, opts *Options) ([]string, error) {
+	var sshCIDRs []string
+	if opts != nil {
+		sshCIDRs = opts.AllowedSSHCIDRs
+	}
+	if len(sshCIDRs) > 0 {
+		if err := ValidateSSHCIDRs(sshCIDRs); err != nil {
+			return nil, err
+		}
+		return sshCIDRs, nil
+	}
+
+	ip, err := DetectPublicIP(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect public IP for SSH access (use --ssh-cidr to set it): %w", err)
+	}
+	fmt.Printf("🔒 Allowing SSH from this machine's public IP: %s/32\n", ip)
+	return []string{ip + "/32"}, nil
+}
+
+// selectZones returns the zones to create subnets in, or nil to let AWS
+// choose the zone when only one is needed.
+func (m *Manager) selectZones(ctx context.Context, azCount int) ([]string, error) {
+	if azCount > MaxAZCount {
+		return nil, fmt.Errorf("cannot spread a VPC across %d availability zones (maximum %d)", azCount, MaxAZCount)
+	}
+	if azCount == 1 {
+		return nil, nil
+	}
+	return m.availabilityZones(ctx, azCount)
+}
+
+// availabilityZones returns count available Availability Zones in the
+// region, in name order. Local and Wavelength Zones are excluded.
+func (m *Manager) availabilityZones(ctx context.Context, count int) ([]string, error) {
+	output, err := m.ec2Client.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{
+		Filters: []types.Filter{
+			{Name: aws.String("state"), Values: []string{"available"}},
+			{Name: aws.String("zone-type"), Values: []string{"availability-zone"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe availability zones: %w", err)
+	}
+
+	var zones []string
+	for _, zone := range output.AvailabilityZones {
+		zones = append(zones, aws.ToString(zone.ZoneName))
+	}
+	sort.Strings(zones)
+	if len(zones) < count {
+		return nil, fmt.Errorf("%d availability zones requested but %s has only %d available", count, m.region, len(zones))
+	}
+	return zones[:count], nil
+}
+
+// createSubnet creates a subnet in zone, or in a zone chosen by AWS if zone
+// is empty.
+func (m *Manager) createSubnet(ctx context.Context, clusterName, vpcID, cidr, subnetType, zone string) (string, error) {
+	name := fmt.Sprintf("pctl-%s-%s", clusterName, subnetType)
+	input := &ec2.CreateSubnetInput{
 		VpcId:     aws.String(vpcID),
 		CidrBlock: aws.String(cidr),
 		TagSpecifications: []types.TagSpecification{
 			{
 				ResourceType: types.ResourceTypeSubnet,
 				Tags: []types.Tag{
-					{Key: aws.String("Name"), Value: aws.String(fmt.Sprintf("pctl-%s-%s", clusterName, subnetType))},
+					{Key: aws.String("Name"), Value: aws.String(name)},
 					{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
 					{Key: aws.String("ClusterName"), Value: aws.String(clusterName)},
 					{Key: aws.String("Type"), Value: aws.String(subnetType)},
 				},
 			},
 		},
-	})
+	}
+	if zone != "" {
+		input.AvailabilityZone = aws.String(zone)
+		input.TagSpecifications[0].Tags[0].Value = aws.String(name + "-" + zone)
+	}
+
+	output, err := m.ec2Client.CreateSubnet(ctx, input)
 	if err != nil {
 		return "", err
 	}
@@ -233,7 +539,7 @@ func (m *Manager) createSubnet(ctx context.Context, clusterName, vpcID, cidr, su
 	return subnetID, nil
 }
 
-func (m *Manager) createRouteTable(ctx context.Context, clusterName, vpcID, igwID, publicSubnetID string) (string, error) {
+func (m *Manager) createRouteTable(ctx context.Context, clusterName, vpcID, igwID string, publicSubnetIDs []string) (string, error) {
 	output, err := m.ec2Client.CreateRouteTable(ctx, &ec2.CreateRouteTableInput{
 		VpcId: aws.String(vpcID),
 		TagSpecifications: []types.TagSpecification{
@@ -263,19 +569,146 @@ func (m *Manager) createRouteTable(ctx context.Context, clusterName, vpcID, igwI
 		return "", fmt.Errorf("failed to create route: %w", err)
 	}
 
-	// Associate with public subnet
-	_, err = m.ec2Client.AssociateRouteTable(ctx, &ec2.AssociateRouteTableInput{
-		RouteTableId: aws.String(routeTableID),
-		SubnetId:     aws.String(publicSubnetID),
+	// Associate with the public subnets
+	for _, subnetID := range publicSubnetIDs {
+		_, err = m.ec2Client.AssociateRouteTable(ctx, &ec2.AssociateRouteTableInput{
+			RouteTableId: aws.String(routeTableID),
+			SubnetId:     aws.String(subnetID),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to associate route table: %w", err)
+		}
+	}
+
+	return routeTableID, nil
+}
+
+// createNatGateway allocates an Elastic IP and creates a NAT gateway with it
+// in the first public subnet, waiting until the gateway is available. The IDs
+// are recorded in resources as soon as each exists so cleanup can remove them.
+func (m *Manager) createNatGateway(ctx context.Context, clusterName string, resources *NetworkResources) error {
+	tags := func(name string) []types.Tag {
+		return []types.Tag{
+			{Key: aws.String("Name"), Value: aws.String(name)},
+			{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
+			{Key: aws.String("ClusterName"), Value: aws.String(clusterName)},
+		}
+	}
+
+	eip, err := m.ec2Client.AllocateAddress(ctx, &ec2.AllocateAddressInput{
+		Domain: types.DomainTypeVpc,
+		TagSpecifications: []types.TagSpecification{
+			{ResourceType: types.ResourceTypeElasticIp, Tags: tags(fmt.Sprintf("pctl-%s-nat", clusterName))},
+		},
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to associate route table: %w", err)
+		return fmt.Errorf("failed to allocate Elastic IP: %w", err)
+	}
+	resources.EIPAllocationID = aws.ToString(eip.AllocationId)
+
+	output, err := m.ec2Client.CreateNatGateway(ctx, &ec2.CreateNatGatewayInput{
+		SubnetId:     aws.String(resources.PublicSubnetID),
+		AllocationId: aws.String(resources.EIPAllocationID),
+		TagSpecifications: []types.TagSpecification{
+			{ResourceType: types.ResourceTypeNatgateway, Tags: tags(fmt.Sprintf("pctl-%s-nat", clusterName))},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	resources.NatGatewayID = aws.ToString(output.NatGateway.NatGatewayId)
+
+	waiter := ec2.NewNatGatewayAvailableWaiter(m.ec2Client)
+	err = waiter.Wait(ctx, &ec2.DescribeNatGatewaysInput{
+		NatGatewayIds: []string{resources.NatGatewayID},
+	}, natGatewayWaitTimeout)
+	if err != nil {
+		return fmt.Errorf("NAT gateway %s did not become available: %w", resources.NatGatewayID, err)
+	}
+
+	return nil
+}
+
+// createPrivateRouteTable creates a route table that sends outbound traffic
+// through the NAT gateway and associates it with the private subnets.
+func (m *Manager) createPrivateRouteTable(ctx context.Context, clusterName, vpcID, natGatewayID string, privateSubnetIDs []string) (string, error) {
+	output, err := m.ec2Client.CreateRouteTable(ctx, &ec2.CreateRouteTableInput{
+		VpcId: aws.String(vpcID),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeRouteTable,
+				Tags: []types.Tag{
+					{Key: aws.String("Name"), Value: aws.String(fmt.Sprintf("pctl-%s-private", clusterName))},
+					{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
+					{Key: aws.String("ClusterName"), Value: aws.String(clusterName)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	routeTableID := *output.RouteTable.RouteTableId
+
+	_, err = m.ec2Client.CreateRoute(ctx, &ec2.CreateRouteInput{
+		RouteTableId:         aws.String(routeTableID),
+		DestinationCidrBlock: aws.String("0.0.0.0/0"),
+		NatGatewayId:         aws.String(natGatewayID),
+	})
+	if err != nil {
+		return routeTableID, fmt.Errorf("failed to create NAT route: %w", err)
+	}
+
+	for _, subnetID := range privateSubnetIDs {
+		_, err = m.ec2Client.AssociateRouteTable(ctx, &ec2.AssociateRouteTableInput{
+			RouteTableId: aws.String(routeTableID),
+			SubnetId:     aws.String(subnetID),
+		})
+		if err != nil {
+			return routeTableID, fmt.Errorf("failed to associate private route table: %w", err)
+		}
 	}
 
 	return routeTableID, nil
 }
 
-func (m *Manager) createSecurityGroup(ctx context.Context, clusterName, vpcID string) (string, error) {
+// s3EndpointServiceName returns the name of the S3 gateway endpoint service in
+// region. The China regions prefix their service names with "cn.".
+func s3EndpointServiceName(region string) string {
+	if strings.HasPrefix(region, "cn-") {
+		return fmt.Sprintf("cn.com.amazonaws.%s.s3", region)
+	}
+	return fmt.Sprintf("com.amazonaws.%s.s3", region)
+}
+
+// createS3Endpoint creates a gateway VPC endpoint for the region's S3
+// service and adds it to the route tables.
+func (m *Manager) createS3Endpoint(ctx context.Context, clusterName, vpcID string, routeTableIDs []string) (string, error) {
+	output, err := m.ec2Client.CreateVpcEndpoint(ctx, &ec2.CreateVpcEndpointInput{
+		VpcId:           aws.String(vpcID),
+		ServiceName:     aws.String(s3EndpointServiceName(m.region)),
+		VpcEndpointType: types.VpcEndpointTypeGateway,
+		RouteTableIds:   routeTableIDs,
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeVpcEndpoint,
+				Tags: []types.Tag{
+					{Key: aws.String("Name"), Value: aws.String(fmt.Sprintf("pctl-%s-s3", clusterName))},
+					{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
+					{Key: aws.String("ClusterName"), Value: aws.String(clusterName)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(output.VpcEndpoint.VpcEndpointId), nil
+}
+
+func (m *Manager) createSecurityGroup(ctx context.Context, clusterName, vpcID string, sshCIDRs []string, rules []IngressRule) (string, error) {
 	output, err := m.ec2Client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
 		GroupName:   aws.String(fmt.Sprintf("pctl-%s", clusterName)),
 		Description: aws.String(fmt.Sprintf("Security group for pctl cluster %s", clusterName)),
@@ -297,31 +730,56 @@ func (m *Manager) createSecurityGroup(ctx context.Context, clusterName, vpcID st
 
 	sgID := *output.GroupId
 
-	// Allow SSH from anywhere (you may want to restrict this)
 	_, err = m.ec2Client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
-		GroupId: aws.String(sgID),
-		IpPermissions: []types.IpPermission{
-			{
-				IpProtocol: aws.String("tcp"),
-				FromPort:   aws.Int32(22),
-				ToPort:     aws.Int32(22),
-				IpRanges:   []types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
-			},
-			{
-				IpProtocol: aws.String("-1"),
-				UserIdGroupPairs: []types.UserIdGroupPair{
-					{GroupId: aws.String(sgID)},
-				},
-			},
-		},
+		GroupId:       aws.String(sgID),
+		IpPermissions: ingressPermissions(sgID, sshCIDRs, rules),
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to authorize ingress rules: %w", err)
+		return sgID, fmt.Errorf("failed to authorize ingress rules: %w", err)
 	}
 
 	return sgID, nil
 }
 
+// ingressPermissions returns the inbound rules for the cluster security group:
+// SSH from sshCIDRs, all traffic within the group, and any additional rules.
+func ingressPermissions(sgID string, sshCIDRs []string, rules []IngressRule) []types.IpPermission {
+	var sshRanges []types.IpRange
+	for _, cidr := range sshCIDRs {
+		sshRanges = append(sshRanges, types.IpRange{CidrIp: aws.String(cidr), Description: aws.String("SSH")})
+	}
+
+	permissions := []types.IpPermission{
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int32(22),
+			ToPort:     aws.Int32(22),
+			IpRanges:   sshRanges,
+		},
+		{
+			IpProtocol: aws.String("-1"),
+			UserIdGroupPairs: []types.UserIdGroupPair{
+				{GroupId: aws.String(sgID)},
+			},
+		},
+	}
+
+	for _, rule := range rules {
+		ipRange := types.IpRange{CidrIp: aws.String(rule.CIDR)}
+		if rule.Description != "" {
+			ipRange.Description = aws.String(rule.Description)
+		}
+		permissions = append(permissions, types.IpPermission{
+			IpProtocol: aws.String(rule.Protocol),
+			FromPort:   aws.Int32(rule.FromPort),
+			ToPort:     aws.Int32(rule.ToPort),
+			IpRanges:   []types.IpRange{ipRange},
+		})
+	}
+
+	return permissions
+}
+
 // DeleteNetwork deletes all network resources for a cluster.
 func (m *Manager) DeleteNetwork(ctx context.Context, resources *NetworkResources) error {
 	if !resources.ManagedByPctl {
@@ -334,6 +792,24 @@ func (m *Manager) DeleteNetwork(ctx context.Context, resources *NetworkResources
 func (m *Manager) cleanup(ctx context.Context, resources *NetworkResources) error {
 	var lastErr error
 
+	// Delete the NAT gateway first: it holds the Elastic IP and blocks
+	// deleting its subnet and detaching the internet gateway until it is gone
+	if resources.NatGatewayID != "" {
+		if err := m.deleteNatGateway(ctx, resources.NatGatewayID); err != nil {
+			lastErr = err
+		}
+	}
+
+	// Release the Elastic IP once the NAT gateway no longer uses it
+	if resources.EIPAllocationID != "" {
+		_, err := m.ec2Client.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{
+			AllocationId: aws.String(resources.EIPAllocationID),
+		})
+		if err != nil {
+			lastErr = fmt.Errorf("failed to release Elastic IP: %w", err)
+		}
+	}
+
 	// Delete security group
 	if resources.SecurityGroupID != "" {
 		_, err := m.ec2Client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{
@@ -344,7 +820,32 @@ func (m *Manager) cleanup(ctx context.Context, resources *NetworkResources) erro
 		}
 	}
 
-	// Delete route table (association is deleted automatically)
+	// Delete the S3 endpoint before the route tables it adds routes to
+	if resources.S3EndpointID != "" {
+		output, err := m.ec2Client.DeleteVpcEndpoints(ctx, &ec2.DeleteVpcEndpointsInput{
+			VpcEndpointIds: []string{resources.S3EndpointID},
+		})
+		if err == nil && len(output.Unsuccessful) > 0 {
+			err = fmt.Errorf("endpoint %s was not deleted", resources.S3EndpointID)
+			if item := output.Unsuccessful[0].Error; item != nil {
+				err = fmt.Errorf("%s", aws.ToString(item.Message))
+			}
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("failed to delete S3 endpoint: %w", err)
+		}
+	}
+
+	// Delete route tables (associations are deleted automatically)
+	if resources.PrivateRouteTableID != "" {
+		_, err := m.ec2Client.DeleteRouteTable(ctx, &ec2.DeleteRouteTableInput{
+			RouteTableId: aws.String(resources.PrivateRouteTableID),
+		})
+		if err != nil {
+			lastErr = fmt.Errorf("failed to delete private route table: %w", err)
+		}
+	}
+
 	if resources.RouteTableID != "" {
 		_, err := m.ec2Client.DeleteRouteTable(ctx, &ec2.DeleteRouteTableInput{
 			RouteTableId: aws.String(resources.RouteTableID),
@@ -355,24 +856,30 @@ func (m *Manager) cleanup(ctx context.Context, resources *NetworkResources) erro
 	}
 
 	// Delete subnets
-	if resources.PublicSubnetID != "" {
+	for _, subnetID := range uniqueSubnetIDs(resources.PublicSubnetID, resources.PublicSubnetIDs) {
 		_, err := m.ec2Client.DeleteSubnet(ctx, &ec2.DeleteSubnetInput{
-			SubnetId: aws.String(resources.PublicSubnetID),
+			SubnetId: aws.String(subnetID),
 		})
 		if err != nil {
 			lastErr = fmt.Errorf("failed to delete public subnet: %w", err)
 		}
 	}
 
-	if resources.PrivateSubnetID != "" {
+	for _, subnetID := range uniqueSubnetIDs(resources.PrivateSubnetID, resources.PrivateSubnetIDs) {
 		_, err := m.ec2Client.DeleteSubnet(ctx, &ec2.DeleteSubnetInput{
-			SubnetId: aws.String(resources.PrivateSubnetID),
+			SubnetId: aws.String(subnetID),
 		})
 		if err != nil {
 			lastErr = fmt.Errorf("failed to delete private subnet: %w", err)
 		}
 	}
 
+	// A shared VPC, its internet gateway, and its DHCP options belong to
+	// the caller
+	if resources.SharedVPC {
+		return lastErr
+	}
+
 	// Detach and delete internet gateway
 	if resources.InternetGatewayID != "" {
 		if resources.VpcID != "" {
@@ -403,5 +910,48 @@ func (m *Manager) cleanup(ctx context.Context, resources *NetworkResources) erro
 		}
 	}
 
+	// Delete DHCP options (only possible once the VPC no longer uses them)
+	if resources.DhcpOptionsID != "" {
+		_, err := m.ec2Client.DeleteDhcpOptions(ctx, &ec2.DeleteDhcpOptionsInput{
+			DhcpOptionsId: aws.String(resources.DhcpOptionsID),
+		})
+		if err != nil {
+			lastErr = fmt.Errorf("failed to delete DHCP options: %w", err)
+		}
+	}
+
 	return lastErr
 }
+
+// deleteNatGateway deletes a NAT gateway and waits until it is gone.
+func (m *Manager) deleteNatGateway(ctx context.Context, natGatewayID string) error {
+	_, err := m.ec2Client.DeleteNatGateway(ctx, &ec2.DeleteNatGatewayInput{
+		NatGatewayId: aws.String(natGatewayID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete NAT gateway: %w", err)
+	}
+
+	waiter := ec2.NewNatGatewayDeletedWaiter(m.ec2Client)
+	err = waiter.Wait(ctx, &ec2.DescribeNatGatewaysInput{
+		NatGatewayIds: []string{natGatewayID},
+	}, natGatewayWaitTimeout)
+	if err != nil {
+		return fmt.Errorf("NAT gateway %s did not finish deleting: %w", natGatewayID, err)
+	}
+	return nil
+}
+
+// uniqueSubnetIDs merges the single subnet field of resources recorded before
+// multi-AZ support with the per-zone list, dropping duplicates and blanks.
+func uniqueSubnetIDs(first string, ids []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, id := range append([]string{first}, ids...) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -15,7 +15,14 @@
 package network
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
 func TestNetworkResources(t *testing.T) {
@@ -322,3 +329,517 @@ func TestNetworkResourcesClone(t *testing.T) {
 		t.Error("Modifying clone affected original")
 	}
 }
+
+// fakeEC2 records the DHCP and VPC calls made by Manager. Calls not
+// overridden here panic through the nil embedded interface.
+type fakeEC2 struct {
+	ec2API
+	calls        []string
+	dhcpConfigs  []types.NewDhcpConfiguration
+	associateVpc string
+	associateErr error
+	permissions  []types.IpPermission
+	authorizeErr error
+	zones        []string
+	natState     types.NatGatewayState
+	routes       []*ec2.CreateRouteInput
+	associations []string
+	endpoint     *ec2.CreateVpcEndpointInput
+}
+
+func (f *fakeEC2) CreateVpcEndpoint(ctx context.Context, params *ec2.CreateVpcEndpointInput, optFns ...func(*ec2.Options)) (*ec2.CreateVpcEndpointOutput, error) {
+	f.calls = append(f.calls, "CreateVpcEndpoint")
+	f.endpoint = params
+	return &ec2.CreateVpcEndpointOutput{VpcEndpoint: &types.VpcEndpoint{VpcEndpointId: aws.String("vpce-12345")}}, nil
+}
+
+func (f *fakeEC2) DeleteVpcEndpoints(ctx context.Context, params *ec2.DeleteVpcEndpointsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteVpcEndpointsOutput, error) {
+	f.calls = append(f.calls, "DeleteVpcEndpoints:"+strings.Join(params.VpcEndpointIds, ","))
+	return &ec2.DeleteVpcEndpointsOutput{}, nil
+}
+
+func (f *fakeEC2) AllocateAddress(ctx context.Context, params *ec2.AllocateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AllocateAddressOutput, error) {
+	f.calls = append(f.calls, "AllocateAddress")
+	return &ec2.AllocateAddressOutput{AllocationId: aws.String("eipalloc-12345")}, nil
+}
+
+func (f *fakeEC2) ReleaseAddress(ctx context.Context, params *ec2.ReleaseAddressInput, optFns ...func(*ec2.Options)) (*ec2.ReleaseAddressOutput, error) {
+	f.calls = append(f.calls, "ReleaseAddress:"+aws.ToString(params.AllocationId))
+	return &ec2.ReleaseAddressOutput{}, nil
+}
+
+func (f *fakeEC2) CreateNatGateway(ctx context.Context, params *ec2.CreateNatGatewayInput, optFns ...func(*ec2.Options)) (*ec2.CreateNatGatewayOutput, error) {
+	f.calls = append(f.calls, "CreateNatGateway:"+aws.ToString(params.SubnetId)+":"+aws.ToString(params.AllocationId))
+	return &ec2.CreateNatGatewayOutput{NatGateway: &types.NatGateway{NatGatewayId: aws.String("nat-12345")}}, nil
+}
+
+func (f *fakeEC2) DeleteNatGateway(ctx context.Context, params *ec2.DeleteNatGatewayInput, optFns ...func(*ec2.Options)) (*ec2.DeleteNatGatewayOutput, error) {
+	f.calls = append(f.calls, "DeleteNatGateway:"+aws.ToString(params.NatGatewayId))
+	f.natState = types.NatGatewayStateDeleted
+	return &ec2.DeleteNatGatewayOutput{}, nil
+}
+
+func (f *fakeEC2) DescribeNatGateways(ctx context.Context, params *ec2.DescribeNatGatewaysInput, optFns ...func(*ec2.Options)) (*ec2.DescribeNatGatewaysOutput, error) {
+	f.calls = append(f.calls, "DescribeNatGateways")
+	return &ec2.DescribeNatGatewaysOutput{
+		NatGateways: []types.NatGateway{{NatGatewayId: aws.String(params.NatGatewayIds[0]), State: f.natState}},
+	}, nil
+}
+
+func (f *fakeEC2) CreateRouteTable(ctx context.Context, params *ec2.CreateRouteTableInput, optFns ...func(*ec2.Options)) (*ec2.CreateRouteTableOutput, error) {
+	f.calls = append(f.calls, "CreateRouteTable")
+	return &ec2.CreateRouteTableOutput{RouteTable: &types.RouteTable{RouteTableId: aws.String("rtb-private")}}, nil
+}
+
+func (f *fakeEC2) CreateRoute(ctx context.Context, params *ec2.CreateRouteInput, optFns ...func(*ec2.Options)) (*ec2.CreateRouteOutput, error) {
+	f.calls = append(f.calls, "CreateRoute")
+	f.routes = append(f.routes, params)
+	return &ec2.CreateRouteOutput{}, nil
+}
+
+func (f *fakeEC2) AssociateRouteTable(ctx context.Context, params *ec2.AssociateRouteTableInput, optFns ...func(*ec2.Options)) (*ec2.AssociateRouteTableOutput, error) {
+	f.calls = append(f.calls, "AssociateRouteTable")
+	f.associations = append(f.associations, aws.ToString(params.SubnetId))
+	return &ec2.AssociateRouteTableOutput{}, nil
+}
+
+func (f *fakeEC2) DeleteRouteTable(ctx context.Context, params *ec2.DeleteRouteTableInput, optFns ...func(*ec2.Options)) (*ec2.DeleteRouteTableOutput, error) {
+	f.calls = append(f.calls, "DeleteRouteTable:"+aws.ToString(params.RouteTableId))
+	return &ec2.DeleteRouteTableOutput{}, nil
+}
+
+func (f *fakeEC2) DescribeAvailabilityZones(ctx context.Context, params *ec2.DescribeAvailabilityZonesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAvailabilityZonesOutput, error) {
+	f.calls = append(f.calls, "DescribeAvailabilityZones")
+	output := &ec2.DescribeAvailabilityZonesOutput{}
+	for _, zone := range f.zones {
+		output.AvailabilityZones = append(output.AvailabilityZones, types.AvailabilityZone{ZoneName: aws.String(zone)})
+	}
+	return output, nil
+}
+
+func (f *fakeEC2) DeleteSubnet(ctx context.Context, params *ec2.DeleteSubnetInput, optFns ...func(*ec2.Options)) (*ec2.DeleteSubnetOutput, error) {
+	f.calls = append(f.calls, "DeleteSubnet:"+aws.ToString(params.SubnetId))
+	return &ec2.DeleteSubnetOutput{}, nil
+}
+
+func (f *fakeEC2) CreateSecurityGroup(ctx context.Context, params *ec2.CreateSecurityGroupInput, optFns ...func(*ec2.Options)) (*ec2.CreateSecurityGroupOutput, error) {
+	f.calls = append(f.calls, "CreateSecurityGroup")
+	return &ec2.CreateSecurityGroupOutput{GroupId: aws.String("sg-12345")}, nil
+}
+
+func (f *fakeEC2) AuthorizeSecurityGroupIngress(ctx context.Context, params *ec2.AuthorizeSecurityGroupIngressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	f.calls = append(f.calls, "AuthorizeSecurityGroupIngress")
+	f.permissions = params.IpPermissions
+	return &ec2.AuthorizeSecurityGroupIngressOutput{}, f.authorizeErr
+}
+
+func (f *fakeEC2) CreateDhcpOptions(ctx context.Context, params *ec2.CreateDhcpOptionsInput, optFns ...func(*ec2.Options)) (*ec2.CreateDhcpOptionsOutput, error) {
+	f.calls = append(f.calls, "CreateDhcpOptions")
+	f.dhcpConfigs = params.DhcpConfigurations
+	return &ec2.CreateDhcpOptionsOutput{
+		DhcpOptions: &types.DhcpOptions{DhcpOptionsId: aws.String("dopt-12345")},
+	}, nil
+}
+
+func (f *fakeEC2) AssociateDhcpOptions(ctx context.Context, params *ec2.AssociateDhcpOptionsInput, optFns ...func(*ec2.Options)) (*ec2.AssociateDhcpOptionsOutput, error) {
+	f.calls = append(f.calls, "AssociateDhcpOptions")
+	f.associateVpc = aws.ToString(params.VpcId)
+	return &ec2.AssociateDhcpOptionsOutput{}, f.associateErr
+}
+
+func (f *fakeEC2) DeleteDhcpOptions(ctx context.Context, params *ec2.DeleteDhcpOptionsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteDhcpOptionsOutput, error) {
+	f.calls = append(f.calls, "DeleteDhcpOptions")
+	return &ec2.DeleteDhcpOptionsOutput{}, nil
+}
+
+func (f *fakeEC2) DeleteVpc(ctx context.Context, params *ec2.DeleteVpcInput, optFns ...func(*ec2.Options)) (*ec2.DeleteVpcOutput, error) {
+	f.calls = append(f.calls, "DeleteVpc")
+	return &ec2.DeleteVpcOutput{}, nil
+}
+
+func dhcpValues(configs []types.NewDhcpConfiguration, key string) []string {
+	for _, c := range configs {
+		if aws.ToString(c.Key) == key {
+			return c.Values
+		}
+	}
+	return nil
+}
+
+func TestOptionsHasDhcpOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *Options
+		want bool
+	}{
+		{"nil", nil, false},
+		{"empty", &Options{}, false},
+		{"domain", &Options{DomainName: "hpc.example.com"}, true},
+		{"servers", &Options{DNSServers: []string{"10.0.0.2"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.hasDhcpOptions(); got != tt.want {
+				t.Errorf("hasDhcpOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDhcpConfigurations(t *testing.T) {
+	configs := dhcpConfigurations(&Options{DomainName: "hpc.example.com"})
+
+	servers := dhcpValues(configs, "domain-name-servers")
+	if len(servers) != 1 || servers[0] != "AmazonProvidedDNS" {
+		t.Errorf("Expected AmazonProvidedDNS by default, got %v", servers)
+	}
+
+	domain := dhcpValues(configs, "domain-name")
+	if len(domain) != 1 || domain[0] != "hpc.example.com" {
+		t.Errorf("Expected domain-name hpc.example.com, got %v", domain)
+	}
+
+	configs = dhcpConfigurations(&Options{DNSServers: []string{"10.0.0.2", "10.0.0.3"}})
+	if servers := dhcpValues(configs, "domain-name-servers"); len(servers) != 2 || servers[0] != "10.0.0.2" {
+		t.Errorf("Expected custom DNS servers, got %v", servers)
+	}
+	if domain := dhcpValues(configs, "domain-name"); domain != nil {
+		t.Errorf("Expected no domain-name without DomainName, got %v", domain)
+	}
+}
+
+func TestCreateDhcpOptions(t *testing.T) {
+	fake := &fakeEC2{}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	id, err := m.createDhcpOptions(context.Background(), "test-cluster", "vpc-12345", &Options{
+		DomainName: "hpc.example.com",
+		DNSServers: []string{"10.0.0.2"},
+	})
+	if err != nil {
+		t.Fatalf("createDhcpOptions() failed: %v", err)
+	}
+
+	if id != "dopt-12345" {
+		t.Errorf("Expected dopt-12345, got %s", id)
+	}
+	if fake.associateVpc != "vpc-12345" {
+		t.Errorf("Expected association with vpc-12345, got %s", fake.associateVpc)
+	}
+	if domain := dhcpValues(fake.dhcpConfigs, "domain-name"); len(domain) != 1 || domain[0] != "hpc.example.com" {
+		t.Errorf("Expected domain-name hpc.example.com, got %v", domain)
+	}
+}
+
+func TestCreateDhcpOptionsAssociateFails(t *testing.T) {
+	fake := &fakeEC2{associateErr: errors.New("denied")}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	_, err := m.createDhcpOptions(context.Background(), "test-cluster", "vpc-12345", &Options{DomainName: "hpc.example.com"})
+	if err == nil {
+		t.Fatal("Expected error when association fails")
+	}
+
+	if last := fake.calls[len(fake.calls)-1]; last != "DeleteDhcpOptions" {
+		t.Errorf("Expected unassociated DHCP options to be deleted, calls: %v", fake.calls)
+	}
+}
+
+func TestCleanupDeletesDhcpOptionsAfterVpc(t *testing.T) {
+	fake := &fakeEC2{}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	err := m.cleanup(context.Background(), &NetworkResources{
+		VpcID:         "vpc-12345",
+		DhcpOptionsID: "dopt-12345",
+	})
+	if err != nil {
+		t.Fatalf("cleanup() failed: %v", err)
+	}
+
+	if len(fake.calls) != 2 || fake.calls[0] != "DeleteVpc" || fake.calls[1] != "DeleteDhcpOptions" {
+		t.Errorf("Expected DeleteVpc then DeleteDhcpOptions, got %v", fake.calls)
+	}
+}
+
+func TestCreateSecurityGroupIngressRules(t *testing.T) {
+	fake := &fakeEC2{}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	sgID, err := m.createSecurityGroup(context.Background(), "test-cluster", "vpc-12345", []string{"203.0.113.7/32"}, []IngressRule{
+		{Protocol: "tcp", FromPort: 8888, ToPort: 8888, CIDR: "10.0.0.0/16", Description: "Jupyter"},
+		{Protocol: "udp", FromPort: 27000, ToPort: 27009, CIDR: "192.168.0.0/24"},
+	})
+	if err != nil {
+		t.Fatalf("createSecurityGroup() failed: %v", err)
+	}
+	if sgID != "sg-12345" {
+		t.Errorf("Expected sg-12345, got %s", sgID)
+	}
+
+	// SSH and intra-group rules come first, then the custom rules
+	if len(fake.permissions) != 4 {
+		t.Fatalf("Expected 4 ingress permissions, got %d", len(fake.permissions))
+	}
+	if aws.ToInt32(fake.permissions[0].FromPort) != 22 {
+		t.Errorf("Expected SSH rule first, got port %d", aws.ToInt32(fake.permissions[0].FromPort))
+	}
+
+	jupyter := fake.permissions[2]
+	if aws.ToString(jupyter.IpProtocol) != "tcp" || aws.ToInt32(jupyter.FromPort) != 8888 || aws.ToInt32(jupyter.ToPort) != 8888 {
+		t.Errorf("Unexpected Jupyter rule: %+v", jupyter)
+	}
+	if aws.ToString(jupyter.IpRanges[0].CidrIp) != "10.0.0.0/16" || aws.ToString(jupyter.IpRanges[0].Description) != "Jupyter" {
+		t.Errorf("Unexpected Jupyter range: %+v", jupyter.IpRanges[0])
+	}
+
+	license := fake.permissions[3]
+	if aws.ToString(license.IpProtocol) != "udp" || aws.ToInt32(license.FromPort) != 27000 || aws.ToInt32(license.ToPort) != 27009 {
+		t.Errorf("Unexpected license rule: %+v", license)
+	}
+	if license.IpRanges[0].Description != nil {
+		t.Errorf("Expected no description, got %q", aws.ToString(license.IpRanges[0].Description))
+	}
+}
+
+func TestCreateSecurityGroupDefaultRules(t *testing.T) {
+	fake := &fakeEC2{}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	if _, err := m.createSecurityGroup(context.Background(), "test-cluster", "vpc-12345", []string{"203.0.113.7/32"}, nil); err != nil {
+		t.Fatalf("createSecurityGroup() failed: %v", err)
+	}
+	if len(fake.permissions) != 2 {
+		t.Errorf("Expected only the SSH and intra-group rules, got %d", len(fake.permissions))
+	}
+}
+
+func TestCreateSecurityGroupAuthorizeFails(t *testing.T) {
+	fake := &fakeEC2{authorizeErr: errors.New("InvalidPermission.Duplicate")}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	sgID, err := m.createSecurityGroup(context.Background(), "test-cluster", "vpc-12345", []string{"203.0.113.7/32"}, nil)
+	if err == nil {
+		t.Fatal("Expected error when authorizing ingress fails")
+	}
+	// The group exists, so its ID is returned for cleanup
+	if sgID != "sg-12345" {
+		t.Errorf("Expected sg-12345 for cleanup, got %q", sgID)
+	}
+}
+
+func TestSubnetCIDRsDoNotOverlap(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < MaxAZCount; i++ {
+		public, private := subnetCIDRs(i)
+		for _, cidr := range []string{public, private} {
+			if seen[cidr] {
+				t.Fatalf("CIDR %s allocated twice", cidr)
+			}
+			seen[cidr] = true
+		}
+	}
+
+	// The first zone keeps the CIDRs single-AZ VPCs have always used
+	if public, private := subnetCIDRs(0); public != "10.0.1.0/24" || private != "10.0.2.0/24" {
+		t.Errorf("subnetCIDRs(0) = %s, %s", public, private)
+	}
+}
+
+func TestOptionsAZCount(t *testing.T) {
+	var nilOpts *Options
+	if got := nilOpts.azCount(); got != 1 {
+		t.Errorf("nil options azCount() = %d, want 1", got)
+	}
+	if got := (&Options{}).azCount(); got != 1 {
+		t.Errorf("default azCount() = %d, want 1", got)
+	}
+	if got := (&Options{AZCount: 3}).azCount(); got != 3 {
+		t.Errorf("azCount() = %d, want 3", got)
+	}
+}
+
+func TestAvailabilityZones(t *testing.T) {
+	fake := &fakeEC2{zones: []string{"us-east-1c", "us-east-1a", "us-east-1b"}}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	zones, err := m.availabilityZones(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("availabilityZones() failed: %v", err)
+	}
+	if len(zones) != 2 || zones[0] != "us-east-1a" || zones[1] != "us-east-1b" {
+		t.Errorf("Expected the first two zones in order, got %v", zones)
+	}
+
+	if _, err := m.availabilityZones(context.Background(), 4); err == nil {
+		t.Error("Expected error when more zones are requested than the region has")
+	}
+}
+
+func TestCreateNetworkRejectsTooManyZones(t *testing.T) {
+	fake := &fakeEC2{}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	_, err := m.CreateNetwork(context.Background(), "test-cluster", &Options{
+		AZCount:         MaxAZCount + 1,
+		AllowedSSHCIDRs: []string{"203.0.113.7/32"},
+	})
+	if err == nil {
+		t.Fatal("Expected error for too many availability zones")
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("Expected no AWS calls, got %v", fake.calls)
+	}
+}
+
+func TestUniqueSubnetIDs(t *testing.T) {
+	got := uniqueSubnetIDs("subnet-a", []string{"subnet-a", "subnet-b", "", "subnet-b"})
+	if len(got) != 2 || got[0] != "subnet-a" || got[1] != "subnet-b" {
+		t.Errorf("uniqueSubnetIDs() = %v", got)
+	}
+	if got := uniqueSubnetIDs("", nil); len(got) != 0 {
+		t.Errorf("Expected no subnets, got %v", got)
+	}
+}
+
+func TestCleanupDeletesEverySubnet(t *testing.T) {
+	fake := &fakeEC2{}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	err := m.cleanup(context.Background(), &NetworkResources{
+		PublicSubnetID:   "subnet-pub-a",
+		PublicSubnetIDs:  []string{"subnet-pub-a", "subnet-pub-b"},
+		PrivateSubnetID:  "subnet-priv-a",
+		PrivateSubnetIDs: []string{"subnet-priv-a", "subnet-priv-b"},
+	})
+	if err != nil {
+		t.Fatalf("cleanup() failed: %v", err)
+	}
+
+	want := []string{
+		"DeleteSubnet:subnet-pub-a", "DeleteSubnet:subnet-pub-b",
+		"DeleteSubnet:subnet-priv-a", "DeleteSubnet:subnet-priv-b",
+	}
+	if strings.Join(fake.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, fake.calls)
+	}
+}
+
+func TestCreateNatGateway(t *testing.T) {
+	fake := &fakeEC2{natState: types.NatGatewayStateAvailable}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	resources := &NetworkResources{PublicSubnetID: "subnet-pub-a"}
+	if err := m.createNatGateway(context.Background(), "test-cluster", resources); err != nil {
+		t.Fatalf("createNatGateway() failed: %v", err)
+	}
+
+	if resources.EIPAllocationID != "eipalloc-12345" || resources.NatGatewayID != "nat-12345" {
+		t.Errorf("Expected EIP and NAT gateway IDs recorded, got %+v", resources)
+	}
+	want := []string{"AllocateAddress", "CreateNatGateway:subnet-pub-a:eipalloc-12345", "DescribeNatGateways"}
+	if strings.Join(fake.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, fake.calls)
+	}
+}
+
+func TestCreatePrivateRouteTable(t *testing.T) {
+	fake := &fakeEC2{}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	id, err := m.createPrivateRouteTable(context.Background(), "test-cluster", "vpc-12345", "nat-12345", []string{"subnet-priv-a", "subnet-priv-b"})
+	if err != nil {
+		t.Fatalf("createPrivateRouteTable() failed: %v", err)
+	}
+	if id != "rtb-private" {
+		t.Errorf("Expected rtb-private, got %s", id)
+	}
+
+	if len(fake.routes) != 1 {
+		t.Fatalf("Expected 1 route, got %d", len(fake.routes))
+	}
+	route := fake.routes[0]
+	if aws.ToString(route.DestinationCidrBlock) != "0.0.0.0/0" || aws.ToString(route.NatGatewayId) != "nat-12345" || route.GatewayId != nil {
+		t.Errorf("Expected default route through nat-12345, got %+v", route)
+	}
+	if strings.Join(fake.associations, ",") != "subnet-priv-a,subnet-priv-b" {
+		t.Errorf("Expected both private subnets associated, got %v", fake.associations)
+	}
+}
+
+func TestCleanupDeletesNatGatewayBeforeReleasingEIP(t *testing.T) {
+	fake := &fakeEC2{natState: types.NatGatewayStateAvailable}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	err := m.cleanup(context.Background(), &NetworkResources{
+		PrivateSubnetID:     "subnet-priv-a",
+		NatGatewayID:        "nat-12345",
+		EIPAllocationID:     "eipalloc-12345",
+		PrivateRouteTableID: "rtb-private",
+	})
+	if err != nil {
+		t.Fatalf("cleanup() failed: %v", err)
+	}
+
+	want := []string{
+		"DeleteNatGateway:nat-12345", "DescribeNatGateways", "ReleaseAddress:eipalloc-12345",
+		"DeleteRouteTable:rtb-private", "DeleteSubnet:subnet-priv-a",
+	}
+	if strings.Join(fake.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, fake.calls)
+	}
+}
+
+func TestCreateS3Endpoint(t *testing.T) {
+	fake := &fakeEC2{}
+	m := &Manager{ec2Client: fake, region: "eu-west-1"}
+
+	id, err := m.createS3Endpoint(context.Background(), "test-cluster", "vpc-12345", []string{"rtb-public", "rtb-private"})
+	if err != nil {
+		t.Fatalf("createS3Endpoint() failed: %v", err)
+	}
+	if id != "vpce-12345" {
+		t.Errorf("Expected vpce-12345, got %s", id)
+	}
+
+	ep := fake.endpoint
+	if aws.ToString(ep.ServiceName) != "com.amazonaws.eu-west-1.s3" || ep.VpcEndpointType != types.VpcEndpointTypeGateway {
+		t.Errorf("Expected S3 gateway endpoint in eu-west-1, got %s %s", aws.ToString(ep.ServiceName), ep.VpcEndpointType)
+	}
+	if strings.Join(ep.RouteTableIds, ",") != "rtb-public,rtb-private" {
+		t.Errorf("Expected both route tables, got %v", ep.RouteTableIds)
+	}
+}
+
+func TestS3EndpointServiceName(t *testing.T) {
+	tests := map[string]string{
+		"us-east-1":      "com.amazonaws.us-east-1.s3",
+		"us-gov-west-1":  "com.amazonaws.us-gov-west-1.s3",
+		"cn-north-1":     "cn.com.amazonaws.cn-north-1.s3",
+		"cn-northwest-1": "cn.com.amazonaws.cn-northwest-1.s3",
+	}
+	for region, want := range tests {
+		if got := s3EndpointServiceName(region); got != want {
+			t.Errorf("s3EndpointServiceName(%s) = %s, want %s", region, got, want)
+		}
+	}
+}
+
+func TestCleanupDeletesS3EndpointBeforeRouteTables(t *testing.T) {
+	fake := &fakeEC2{}
+	m := &Manager{ec2Client: fake, region: "us-east-1"}
+
+	err := m.cleanup(context.Background(), &NetworkResources{
+		RouteTableID: "rtb-public",
+		S3EndpointID: "vpce-12345",
+	})
+	if err != nil {
+		t.Fatalf("cleanup() failed: %v", err)
+	}
+
+	want := []string{"DeleteVpcEndpoints:vpce-12345", "DeleteRouteTable:rtb-public"}
+	if strings.Join(fake.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, fake.calls)
+	}
+}
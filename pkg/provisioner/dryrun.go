@@ -0,0 +1,265 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	pcconfig "github.com/scttfrdmn/petal/pkg/config"
+	"github.com/scttfrdmn/petal/pkg/network"
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// DryRunPlan records what a dry-run provisioner would have done in AWS.
+type DryRunPlan struct {
+	// Steps are the actions that would have been taken, in order
+	Steps []string
+	// Config is the ParallelCluster configuration that would have been
+	// submitted to pcluster create-cluster
+	Config string
+
+	out      io.Writer
+	stateDir string
+}
+
+// record adds a step to the plan and prints it.
+func (plan *DryRunPlan) record(format string, args ...interface{}) {
+	step := fmt.Sprintf(format, args...)
+	plan.Steps = append(plan.Steps, step)
+	fmt.Fprintf(plan.out, "🔍 [dry run] Would %s\n", step)
+}
+
+// Close removes the copy of the local state the dry run worked against.
+func (plan *DryRunPlan) Close() error {
+	return os.RemoveAll(plan.stateDir)
+}
+
+// NewDryRunProvisioner creates a provisioner that runs the full create and
+// delete flows without AWS credentials or the pcluster CLI. Every AWS and
+// pcluster call is replaced with a fake that records the step in the plan and
+// returns a plausible response. Local state is read from a copy, so nothing
+// the dry run saves outlives it; call Close on the plan when done.
+func NewDryRunProvisioner(out io.Writer) (*Provisioner, *DryRunPlan, error) {
+	stateMgr, err := state.NewManager()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create state manager: %w", err)
+	}
+
+	stateDir, err := os.MkdirTemp("", "pctl-dry-run-state-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create dry run state directory: %w", err)
+	}
+	plan := &DryRunPlan{out: out, stateDir: stateDir}
+
+	dryRunState, err := stateMgr.CopyTo(stateDir)
+	if err != nil {
+		plan.Close()
+		return nil, nil, err
+	}
+
+	p := &Provisioner{
+		stateManager:         dryRunState,
+		configGen:            pcconfig.NewGenerator(),
+		pcluster:             &dryRunPCluster{plan: plan, created: map[string]bool{}},
+		networkDeleteBackoff: []time.Duration{0},
+	}
+
+	p.createNetwork = func(ctx context.Context, tmpl *template.Template, opts *CreateOptions) (*network.NetworkResources, error) {
+		return dryRunNetwork(plan, tmpl, opts), nil
+	}
+	p.monitorCreation = func(ctx context.Context, clusterState *state.ClusterState, opts *CreateOptions) error {
+		plan.record("wait for CloudFormation stack %s to reach CREATE_COMPLETE", clusterState.StackName)
+		return nil
+	}
+	p.waitForStackDeletion = func(ctx context.Context, clusterState *state.ClusterState) error {
+		plan.record("wait for CloudFormation stack %s to be deleted", clusterState.StackName)
+		return nil
+	}
+	p.deleteNetwork = func(ctx context.Context, clusterState *state.ClusterState) error {
+		plan.record("delete network resources in %s", clusterState.VpcID)
+		return nil
+	}
+	p.getStackEvents = func(ctx context.Context, clusterState *state.ClusterState) ([]types.StackEvent, error) {
+		return nil, nil
+	}
+	p.deleteResource = func(ctx context.Context, region string, res *ResourceStatus) error {
+		plan.record("delete %s %s", res.Type, res.PhysicalID)
+		return nil
+	}
+	p.retryStackDelete = func(ctx context.Context, clusterState *state.ClusterState, retain []string) error {
+		plan.record("retry deleting stack %s retaining %s", clusterState.StackName, strings.Join(retain, ", "))
+		return nil
+	}
+	p.createPlacementGroup = func(ctx context.Context, region, clusterName, name string) error {
+		plan.record("create cluster placement group %s in %s", name, region)
+		return nil
+	}
+	p.deletePlacementGroup = func(ctx context.Context, region, name string) error {
+		plan.record("delete placement group %s", name)
+		return nil
+	}
+	p.describeAMITags = func(ctx context.Context, region, amiID string) (map[string]string, error) {
+		plan.record("check the ParallelCluster version of AMI %s", amiID)
+		return map[string]string{}, nil
+	}
+	p.subnetAvailabilityZone = func(ctx context.Context, region, subnetID string) (string, error) {
+		return dryRunZone(region), nil
+	}
+	p.instanceTypeOfferings = func(ctx context.Context, region string, instanceTypes []string) (map[string][]string, error) {
+		plan.record("check that %s are offered in %s", strings.Join(instanceTypes, ", "), dryRunZone(region))
+		offerings := make(map[string][]string, len(instanceTypes))
+		for _, instanceType := range instanceTypes {
+			offerings[instanceType] = []string{dryRunZone(region)}
+		}
+		return offerings, nil
+	}
+	p.tagInstance = func(ctx context.Context, region, instanceID string, tags map[string]string) error {
+		plan.record("tag instance %s with %d tag(s)", instanceID, len(tags))
+		return nil
+	}
+	p.createHomeVolume = func(ctx context.Context, region, zone, name string, size int, tags map[string]string) (string, error) {
+		plan.record("create %d GiB gp3 volume %s in %s", size, name, zone)
+		return "vol-dryrun" + name, nil
+	}
+	p.volumeAvailabilityZone = func(ctx context.Context, region, volumeID string) (string, error) {
+		return dryRunZone(region), nil
+	}
+	p.uploadBootstrapScript = func(ctx context.Context, region, clusterName, content string) (string, error) {
+		uri := fmt.Sprintf("s3://pctl-bootstrap-%s-dryrun/%s/install-software.sh", region, clusterName)
+		plan.record("upload bootstrap script (%d bytes) to %s", len(content), uri)
+		return uri, nil
+	}
+
+	return p, plan, nil
+}
+
+// dryRunZone is the availability zone dry-run lookups report.
+func dryRunZone(region string) string {
+	return region + "a"
+}
+
+// dryRunNetwork records the network createClusterNetwork would create and
+// returns placeholder IDs for it.
+func dryRunNetwork(plan *DryRunPlan, tmpl *template.Template, opts *CreateOptions) *network.NetworkResources {
+	name := tmpl.Cluster.Name
+	azCount := opts.AZCount
+	if azCount < 1 {
+		azCount = 1
+	}
+
+	resources := &network.NetworkResources{
+		VpcID:           "vpc-dryrun-" + name,
+		SecurityGroupID: "sg-dryrun-" + name,
+		RouteTableID:    "rtb-dryrun-" + name,
+		Region:          tmpl.Cluster.Region,
+		ClusterName:     name,
+		ManagedByPctl:   true,
+	}
+	if opts.VpcID != "" {
+		resources.VpcID = opts.VpcID
+		resources.SharedVPC = true
+		plan.record("create subnets and a security group in existing VPC %s", opts.VpcID)
+	} else {
+		resources.InternetGatewayID = "igw-dryrun-" + name
+		plan.record("create VPC %s with an internet gateway", resources.VpcID)
+	}
+
+	for i := 1; i <= azCount; i++ {
+		resources.PublicSubnetIDs = append(resources.PublicSubnetIDs, fmt.Sprintf("subnet-dryrun-%s-public-%d", name, i))
+		resources.PrivateSubnetIDs = append(resources.PrivateSubnetIDs, fmt.Sprintf("subnet-dryrun-%s-private-%d", name, i))
+	}
+	resources.PublicSubnetID = resources.PublicSubnetIDs[0]
+	resources.PrivateSubnetID = resources.PrivateSubnetIDs[0]
+	plan.record("create %d public and %d private subnet(s) across %d availability zone(s)",
+		azCount, azCount, azCount)
+
+	sshCIDRs := "your public IP"
+	if len(opts.SSHCIDRs) > 0 {
+		sshCIDRs = strings.Join(opts.SSHCIDRs, ", ")
+		resources.SSHCIDRs = opts.SSHCIDRs
+	}
+	plan.record("create security group %s allowing SSH from %s", resources.SecurityGroupID, sshCIDRs)
+
+	if opts.VpcID == "" && (tmpl.Network.DomainName != "" || len(tmpl.Network.DNSServers) > 0) {
+		resources.DhcpOptionsID = "dopt-dryrun-" + name
+		plan.record("create DHCP options %s", resources.DhcpOptionsID)
+	}
+	if opts.EnableNAT {
+		resources.NatGatewayID = "nat-dryrun-" + name
+		resources.EIPAllocationID = "eipalloc-dryrun-" + name
+		resources.PrivateRouteTableID = "rtb-dryrun-" + name + "-private"
+		plan.record("create NAT gateway %s for the private subnets", resources.NatGatewayID)
+	}
+	if len(tmpl.Data.S3Mounts) > 0 && !opts.NoS3Endpoint {
+		resources.S3EndpointID = "vpce-dryrun-" + name
+		plan.record("create S3 gateway endpoint %s", resources.S3EndpointID)
+	}
+
+	return resources
+}
+
+// dryRunPCluster is a PClusterClient that records pcluster commands instead
+// of running them. Clusters it creates are reported as CREATE_COMPLETE.
+type dryRunPCluster struct {
+	plan    *DryRunPlan
+	created map[string]bool
+}
+
+func (d *dryRunPCluster) CreateCluster(ctx context.Context, name, configPath, region string) error {
+	config, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	d.plan.Config = string(config)
+	d.plan.record("run pcluster create-cluster for %s in %s with the generated config (%d bytes)", name, region, len(config))
+	d.created[name] = true
+	return nil
+}
+
+func (d *dryRunPCluster) DescribeCluster(ctx context.Context, name, region string) (*ClusterStatus, error) {
+	if !d.created[name] {
+		return nil, fmt.Errorf("cluster %s does not exist", name)
+	}
+	return &ClusterStatus{
+		Name:               name,
+		Status:             "CREATE_COMPLETE",
+		Region:             region,
+		HeadNodeIP:         "203.0.113.10",
+		HeadNodePrivateIP:  "10.0.1.10",
+		HeadNodeInstanceID: "i-dryrun-" + name,
+	}, nil
+}
+
+func (d *dryRunPCluster) DeleteCluster(ctx context.Context, name, region string) error {
+	d.plan.record("run pcluster delete-cluster for %s in %s", name, region)
+	return nil
+}
+
+func (d *dryRunPCluster) UpdateCluster(ctx context.Context, name, configPath, region string) error {
+	d.plan.record("run pcluster update-cluster for %s in %s", name, region)
+	return nil
+}
+
+func (d *dryRunPCluster) UpdateComputeFleet(ctx context.Context, name, region, status string) error {
+	d.plan.record("run pcluster update-compute-fleet --status %s for %s in %s", status, name, region)
+	return nil
+}
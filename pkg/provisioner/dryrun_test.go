@@ -0,0 +1,147 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// failOnAWSRequests points the AWS SDK at a server that fails the test on
+// any request, and hides real credentials.
+func failOnAWSRequests(t *testing.T) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		if n := requests.Load(); n > 0 {
+			t.Errorf("dry run made %d AWS request(s)", n)
+		}
+	})
+
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("PATH", "")
+}
+
+func TestDryRunCreateCluster(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	failOnAWSRequests(t)
+
+	var out bytes.Buffer
+	p, plan, err := NewDryRunProvisioner(&out)
+	if err != nil {
+		t.Fatalf("NewDryRunProvisioner() error = %v", err)
+	}
+	defer plan.Close()
+
+	tmpl := createTestTemplate()
+	tmpl.Compute.Queues[0].PlacementGroup = true
+	tmpl.Compute.HeadNodeTags = map[string]string{"team": "genomics"}
+	tmpl.Users = []template.User{{Name: "alice", UID: 5001, GID: 5001}}
+
+	opts := &CreateOptions{KeyName: "my-key", AZCount: 2, SSHCIDRs: []string{"203.0.113.0/24"}}
+	if err := p.CreateCluster(context.Background(), tmpl, opts); err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+
+	wantSteps := []string{
+		"create cluster placement group",
+		"create VPC vpc-dryrun-test-cluster",
+		"create 2 public and 2 private subnet(s) across 2 availability zone(s)",
+		"create security group sg-dryrun-test-cluster allowing SSH from 203.0.113.0/24",
+		"upload bootstrap script",
+		"run pcluster create-cluster for test-cluster in us-east-1",
+		"wait for CloudFormation stack test-cluster to reach CREATE_COMPLETE",
+		"tag instance i-dryrun-test-cluster with 1 tag(s)",
+	}
+	if len(plan.Steps) != len(wantSteps) {
+		t.Fatalf("plan has %d steps, want %d:\n%s", len(plan.Steps), len(wantSteps), strings.Join(plan.Steps, "\n"))
+	}
+	for i, want := range wantSteps {
+		if !strings.HasPrefix(plan.Steps[i], want) {
+			t.Errorf("step %d = %q, want prefix %q", i, plan.Steps[i], want)
+		}
+		if !strings.Contains(out.String(), "Would "+plan.Steps[i]) {
+			t.Errorf("step %q was not printed", plan.Steps[i])
+		}
+	}
+
+	// The generated config uses the simulated network and bootstrap script
+	for _, want := range []string{"subnet-dryrun-test-cluster-public-1", "my-key", "s3://pctl-bootstrap-us-east-1-dryrun/test-cluster/install-software.sh",
+		// SSH is restricted on both the pctl and ParallelCluster security groups
+		"sg-dryrun-test-cluster", "AllowedIps: 203.0.113.0/24"} {
+		if !strings.Contains(plan.Config, want) {
+			t.Errorf("config should contain %q:\n%s", want, plan.Config)
+		}
+	}
+
+	// Nothing is saved to the real state
+	realState, err := state.NewManager()
+	if err != nil {
+		t.Fatalf("state.NewManager() error = %v", err)
+	}
+	if realState.Exists("test-cluster") {
+		t.Error("dry run saved cluster state")
+	}
+
+	if err := plan.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(plan.stateDir); !os.IsNotExist(err) {
+		t.Errorf("Close() left the dry run state directory behind")
+	}
+}
+
+func TestDryRunCreateClusterSeesExistingState(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	failOnAWSRequests(t)
+
+	realState, err := state.NewManager()
+	if err != nil {
+		t.Fatalf("state.NewManager() error = %v", err)
+	}
+	if err := realState.Save(&state.ClusterState{Name: "test-cluster", Region: "us-east-1"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	p, plan, err := NewDryRunProvisioner(&bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("NewDryRunProvisioner() error = %v", err)
+	}
+	defer plan.Close()
+
+	err = p.CreateCluster(context.Background(), createTestTemplate(), &CreateOptions{KeyName: "my-key"})
+	if err == nil || !strings.Contains(err.Error(), "exists in local state") {
+		t.Errorf("CreateCluster() error = %v, want the local state conflict", err)
+	}
+}
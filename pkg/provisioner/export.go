@@ -0,0 +1,112 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"gopkg.in/yaml.v3"
+)
+
+// stackTemplateAPI is the subset of the CloudFormation client used to
+// export a stack's template.
+type stackTemplateAPI interface {
+	GetTemplate(ctx context.Context, params *cloudformation.GetTemplateInput, optFns ...func(*cloudformation.Options)) (*cloudformation.GetTemplateOutput, error)
+}
+
+// ExportCloudFormation returns the CloudFormation template of a cluster's
+// stack. pcluster cannot render the template without creating the stack,
+// so this reads the template CloudFormation holds for the stack, which is
+// available as soon as create-cluster has submitted it.
+func (p *Provisioner) ExportCloudFormation(ctx context.Context, stackName, region string) ([]byte, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return exportStackTemplate(ctx, cloudformation.NewFromConfig(cfg), stackName)
+}
+
+// exportStackTemplate fetches the template of stackName as submitted, JSON
+// or YAML.
+func exportStackTemplate(ctx context.Context, client stackTemplateAPI, stackName string) ([]byte, error) {
+	result, err := client.GetTemplate(ctx, &cloudformation.GetTemplateInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template for stack %s: %w", stackName, err)
+	}
+
+	body := []byte(aws.ToString(result.TemplateBody))
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse template for stack %s: %w", stackName, err)
+	}
+	if _, ok := doc["Resources"]; !ok {
+		return nil, fmt.Errorf("template for stack %s has no Resources section", stackName)
+	}
+
+	return body, nil
+}
+
+// FormatExportedTemplate renders an exported template for writing to path:
+// YAML for .yaml/.yml paths and indented JSON otherwise.
+func FormatExportedTemplate(body []byte, path string) ([]byte, error) {
+	isJSON := json.Valid(body)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if !isJSON {
+			return body, nil
+		}
+		var node yaml.Node
+		if err := yaml.Unmarshal(body, &node); err != nil {
+			return nil, fmt.Errorf("failed to convert template to YAML: %w", err)
+		}
+		clearFlowStyle(&node)
+		out, err := yaml.Marshal(&node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert template to YAML: %w", err)
+		}
+		return out, nil
+	default:
+		if !isJSON {
+			return nil, fmt.Errorf("the stack template is YAML; write it to a .yaml file instead")
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, body, "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to format template: %w", err)
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	}
+}
+
+// clearFlowStyle switches nodes parsed from JSON to block style so the
+// YAML output reads like a hand-written template.
+func clearFlowStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+	for _, child := range node.Content {
+		clearFlowStyle(child)
+	}
+}
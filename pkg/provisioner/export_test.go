@@ -0,0 +1,134 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"gopkg.in/yaml.v3"
+)
+
+// sampleClusterTemplate is a trimmed CloudFormation template of a pcluster stack.
+const sampleClusterTemplate = `{
+  "AWSTemplateFormatVersion": "2010-09-09",
+  "Description": "AWS ParallelCluster Template",
+  "Resources": {
+    "HeadNode": {
+      "Type": "AWS::EC2::Instance",
+      "Properties": {"InstanceType": "t3.xlarge", "EbsOptimized": "true"}
+    },
+    "ClusterPlacementGroup": {
+      "Type": "AWS::EC2::PlacementGroup",
+      "Properties": {"Strategy": "cluster"}
+    }
+  }
+}`
+
+// fakeStackTemplate serves GetTemplate from templates keyed by stack name.
+type fakeStackTemplate struct {
+	templates map[string]string
+}
+
+func (f *fakeStackTemplate) GetTemplate(ctx context.Context, params *cloudformation.GetTemplateInput, optFns ...func(*cloudformation.Options)) (*cloudformation.GetTemplateOutput, error) {
+	body, ok := f.templates[aws.ToString(params.StackName)]
+	if !ok {
+		return nil, fmt.Errorf("Stack with id %s does not exist", aws.ToString(params.StackName))
+	}
+	return &cloudformation.GetTemplateOutput{
+		TemplateBody:    aws.String(body),
+		StagesAvailable: []types.TemplateStage{types.TemplateStageOriginal, types.TemplateStageProcessed},
+	}, nil
+}
+
+func TestExportStackTemplate(t *testing.T) {
+	yamlTemplate := "AWSTemplateFormatVersion: '2010-09-09'\nResources:\n  HeadNode:\n    Type: AWS::EC2::Instance\n"
+	client := &fakeStackTemplate{templates: map[string]string{
+		"json-cluster":  sampleClusterTemplate,
+		"yaml-cluster":  yamlTemplate,
+		"empty-cluster": `{"Description": "empty"}`,
+	}}
+
+	body, err := exportStackTemplate(context.Background(), client, "json-cluster")
+	if err != nil {
+		t.Fatalf("exportStackTemplate() error = %v", err)
+	}
+	var doc struct {
+		Resources map[string]struct {
+			Type string `json:"Type"`
+		} `json:"Resources"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("exported template is not JSON: %v", err)
+	}
+	if doc.Resources["HeadNode"].Type != "AWS::EC2::Instance" {
+		t.Errorf("HeadNode type = %q, want AWS::EC2::Instance", doc.Resources["HeadNode"].Type)
+	}
+	if doc.Resources["ClusterPlacementGroup"].Type != "AWS::EC2::PlacementGroup" {
+		t.Error("placement group resource missing from exported template")
+	}
+
+	body, err = exportStackTemplate(context.Background(), client, "yaml-cluster")
+	if err != nil || string(body) != yamlTemplate {
+		t.Errorf("exportStackTemplate(yaml) = %q, %v, want the YAML template unchanged", body, err)
+	}
+
+	if _, err := exportStackTemplate(context.Background(), client, "empty-cluster"); err == nil || !strings.Contains(err.Error(), "no Resources section") {
+		t.Errorf("expected a missing Resources error, got %v", err)
+	}
+	if _, err := exportStackTemplate(context.Background(), client, "missing"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected the GetTemplate error, got %v", err)
+	}
+}
+
+func TestFormatExportedTemplate(t *testing.T) {
+	out, err := FormatExportedTemplate([]byte(sampleClusterTemplate), "stack.json")
+	if err != nil {
+		t.Fatalf("FormatExportedTemplate(json) error = %v", err)
+	}
+	if !json.Valid(out) || !strings.HasSuffix(string(out), "}\n") {
+		t.Errorf("JSON output should be valid and newline-terminated, got:\n%s", out)
+	}
+
+	out, err = FormatExportedTemplate([]byte(sampleClusterTemplate), "stack.yaml")
+	if err != nil {
+		t.Fatalf("FormatExportedTemplate(yaml) error = %v", err)
+	}
+	if strings.Contains(string(out), "{") {
+		t.Errorf("YAML output should use block style, got:\n%s", out)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("YAML output does not parse: %v", err)
+	}
+	props := doc["Resources"].(map[string]interface{})["HeadNode"].(map[string]interface{})["Properties"].(map[string]interface{})
+	if props["EbsOptimized"] != "true" {
+		t.Errorf("EbsOptimized = %#v, want the string \"true\"", props["EbsOptimized"])
+	}
+
+	yamlTemplate := []byte("Resources:\n  HeadNode:\n    Type: AWS::EC2::Instance\n")
+	if out, err := FormatExportedTemplate(yamlTemplate, "stack.yml"); err != nil || string(out) != string(yamlTemplate) {
+		t.Errorf("YAML template should be written unchanged, got %q, %v", out, err)
+	}
+	if _, err := FormatExportedTemplate(yamlTemplate, "stack.json"); err == nil {
+		t.Error("expected an error writing a YAML template to a .json file")
+	}
+}
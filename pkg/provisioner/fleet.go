@@ -0,0 +1,104 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scttfrdmn/petal/pkg/state"
+)
+
+// Compute fleet statuses requested with pcluster update-compute-fleet.
+const (
+	fleetStopRequested  = "STOP_REQUESTED"
+	fleetStartRequested = "START_REQUESTED"
+)
+
+// Compute fleet statuses a Slurm cluster settles in.
+const (
+	FleetStatusRunning = "RUNNING"
+	FleetStatusStopped = "STOPPED"
+)
+
+// fleetStatusTimeout bounds the wait for the compute fleet to start or stop.
+const fleetStatusTimeout = 15 * time.Minute
+
+// SetComputeFleet starts (enabled) or stops the cluster's compute fleet and
+// waits until it has. Stopping terminates the compute nodes, and any jobs
+// running on them, but keeps the head node and the cluster's queues.
+func (p *Provisioner) SetComputeFleet(ctx context.Context, name string, enabled bool) error {
+	clusterState, err := p.stateManager.Load(name)
+	if err != nil {
+		return fmt.Errorf("failed to load cluster state: %w", err)
+	}
+	return p.setComputeFleet(ctx, clusterState, enabled)
+}
+
+// setComputeFleet requests the fleet status change, unless the fleet is
+// already there, and records the resulting status in cluster state.
+func (p *Provisioner) setComputeFleet(ctx context.Context, clusterState *state.ClusterState, enabled bool) error {
+	request, settled, action := fleetStopRequested, FleetStatusStopped, "stop"
+	if enabled {
+		request, settled, action = fleetStartRequested, FleetStatusRunning, "start"
+	}
+
+	status, err := p.pcluster.DescribeCluster(ctx, clusterState.Name, clusterState.Region)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster status: %w", err)
+	}
+	if status.SchedulerState != settled {
+		if err := p.pcluster.UpdateComputeFleet(ctx, clusterState.Name, clusterState.Region, request); err != nil {
+			return fmt.Errorf("failed to %s compute fleet: %w", action, err)
+		}
+
+		err := p.waitForCluster(ctx, clusterState, fleetStatusTimeout, func(status *ClusterStatus) bool {
+			return status.SchedulerState == settled
+		})
+		if err != nil {
+			return fmt.Errorf("failed to wait for compute fleet to %s: %w", action, err)
+		}
+	}
+
+	clusterState.ComputeFleetStatus = settled
+	if err := p.stateManager.Save(clusterState); err != nil {
+		return fmt.Errorf("failed to update state: %w", err)
+	}
+	return nil
+}
+
+// stopComputeFleet stops the cluster's compute fleet for an update.
+func (p *Provisioner) stopComputeFleet(ctx context.Context, clusterState *state.ClusterState) error {
+	fmt.Printf("⏸️  Stopping compute fleet...\n")
+	if err := p.setComputeFleet(ctx, clusterState, false); err != nil {
+		// Running jobs were interrupted either way; bring the fleet back
+		p.startComputeFleet(ctx, clusterState)
+		return err
+	}
+
+	fmt.Printf("✅ Compute fleet stopped\n")
+	return nil
+}
+
+// startComputeFleet starts the cluster's compute fleet again after an update.
+// A failure is only reported, since the update itself is unaffected.
+func (p *Provisioner) startComputeFleet(ctx context.Context, clusterState *state.ClusterState) {
+	fmt.Printf("▶️  Starting compute fleet...\n")
+	if err := p.setComputeFleet(ctx, clusterState, true); err != nil {
+		fmt.Printf("⚠️  Warning: %v\n", err)
+		fmt.Printf("   Start it with: pctl start %s\n", clusterState.Name)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSetComputeFleet(t *testing.T) {
+	var calls []string
+	p, pcluster := newUpdateTestProvisioner(t, &calls)
+	ctx := context.Background()
+
+	if err := p.SetComputeFleet(ctx, "test-cluster", false); err != nil {
+		t.Fatalf("SetComputeFleet(false) failed: %v", err)
+	}
+	expected := []string{"describe-cluster", "update-compute-fleet:STOP_REQUESTED", "describe-cluster"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+	saved, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if saved.ComputeFleetStatus != FleetStatusStopped {
+		t.Errorf("Expected recorded fleet status STOPPED, got %q", saved.ComputeFleetStatus)
+	}
+
+	// Stopping a stopped fleet only records its status
+	calls = nil
+	if err := p.SetComputeFleet(ctx, "test-cluster", false); err != nil {
+		t.Fatalf("second SetComputeFleet(false) failed: %v", err)
+	}
+	if !reflect.DeepEqual(calls, []string{"describe-cluster"}) {
+		t.Errorf("Expected no fleet change for a stopped fleet, got %v", calls)
+	}
+
+	calls = nil
+	if err := p.SetComputeFleet(ctx, "test-cluster", true); err != nil {
+		t.Fatalf("SetComputeFleet(true) failed: %v", err)
+	}
+	expected = []string{"describe-cluster", "update-compute-fleet:START_REQUESTED", "describe-cluster"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+	if fleet := pcluster.clusters["test-cluster"].SchedulerState; fleet != FleetStatusRunning {
+		t.Errorf("Expected the fleet to be running, got %s", fleet)
+	}
+	saved, err = p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if saved.ComputeFleetStatus != FleetStatusRunning {
+		t.Errorf("Expected recorded fleet status RUNNING, got %q", saved.ComputeFleetStatus)
+	}
+}
+
+func TestSetComputeFleetUnknownCluster(t *testing.T) {
+	var calls []string
+	p, _ := newUpdateTestProvisioner(t, &calls)
+
+	if err := p.SetComputeFleet(context.Background(), "missing", false); err == nil {
+		t.Fatal("Expected an error for a cluster without state")
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no pcluster calls, got %v", calls)
+	}
+}
+
+func TestGetClusterStatusRecordsFleetStatus(t *testing.T) {
+	var calls []string
+	p, pcluster := newUpdateTestProvisioner(t, &calls)
+	pcluster.clusters["test-cluster"].SchedulerState = FleetStatusStopped
+
+	if _, err := p.GetClusterStatus(context.Background(), "test-cluster"); err != nil {
+		t.Fatalf("GetClusterStatus() failed: %v", err)
+	}
+	saved, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if saved.ComputeFleetStatus != FleetStatusStopped {
+		t.Errorf("Expected recorded fleet status STOPPED, got %q", saved.ComputeFleetStatus)
+	}
+}
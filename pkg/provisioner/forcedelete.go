@@ -0,0 +1,189 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/scttfrdmn/petal/pkg/state"
+)
+
+// forceDeleteStack recovers a stack stuck in DELETE_FAILED. Resources that
+// failed to delete are removed directly; anything that still cannot be
+// removed is retained so the stack delete can complete. It returns the
+// retained resources.
+func (p *Provisioner) forceDeleteStack(ctx context.Context, clusterState *state.ClusterState) ([]*ResourceStatus, error) {
+	events, err := p.getStackEvents(ctx, clusterState)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack events: %w", err)
+	}
+
+	failed := deleteFailedResources(events, clusterState.StackName)
+	if len(failed) == 0 {
+		return nil, fmt.Errorf("stack deletion failed but no failed resources were found")
+	}
+
+	var retained []*ResourceStatus
+	var retainIDs []string
+	homeID := p.persistentHomeID(clusterState)
+	for _, res := range failed {
+		if homeID != "" && res.PhysicalID == homeID {
+			// Never delete the persistent home, even if the stack lists it
+			fmt.Printf("💾 Keeping persistent home %s (%s)\n", clusterState.PersistentHome, homeID)
+			retainIDs = append(retainIDs, res.LogicalID)
+			continue
+		}
+		fmt.Printf("🧹 Deleting %s (%s)...\n", res.LogicalID, res.Type)
+		if err := p.deleteResource(ctx, clusterState.Region, res); err != nil {
+			fmt.Printf("   ⚠️  Could not delete %s: %v\n", res.LogicalID, err)
+			retained = append(retained, res)
+			retainIDs = append(retainIDs, res.LogicalID)
+			continue
+		}
+		fmt.Printf("   ✅ Deleted %s\n", res.LogicalID)
+	}
+
+	fmt.Printf("🔄 Retrying stack deletion...\n")
+	if err := p.retryStackDelete(ctx, clusterState, retainIDs); err != nil {
+		return retained, fmt.Errorf("failed to retry stack deletion: %w", err)
+	}
+
+	if err := p.waitForStackDeletion(ctx, clusterState); err != nil {
+		return retained, err
+	}
+
+	return retained, nil
+}
+
+// deleteFailedResources returns the resources whose most recent event in
+// events (oldest first) is DELETE_FAILED, excluding the stack itself.
+func deleteFailedResources(events []types.StackEvent, stackName string) []*ResourceStatus {
+	latest := make(map[string]*ResourceStatus)
+	var order []string
+	for _, event := range events {
+		logicalID := aws.ToString(event.LogicalResourceId)
+		if logicalID == stackName {
+			continue
+		}
+		if _, ok := latest[logicalID]; !ok {
+			order = append(order, logicalID)
+		}
+		latest[logicalID] = &ResourceStatus{
+			LogicalID:  logicalID,
+			PhysicalID: aws.ToString(event.PhysicalResourceId),
+			Type:       aws.ToString(event.ResourceType),
+			Status:     event.ResourceStatus,
+			StatusText: aws.ToString(event.ResourceStatusReason),
+			Timestamp:  aws.ToTime(event.Timestamp),
+		}
+	}
+
+	var failed []*ResourceStatus
+	for _, logicalID := range order {
+		if res := latest[logicalID]; res.Status == types.ResourceStatusDeleteFailed {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// describeStackEvents returns the cluster stack's events, oldest first.
+func describeStackEvents(ctx context.Context, clusterState *state.ClusterState) ([]types.StackEvent, error) {
+	monitor, err := NewProgressMonitor(ctx, clusterState.StackName, clusterState.Region, clusterState.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create progress monitor: %w", err)
+	}
+	return monitor.getStackEvents(ctx)
+}
+
+// deleteStackResource deletes a single stack resource directly through the
+// service that owns it. Only resource types known to block stack deletion
+// are supported.
+func deleteStackResource(ctx context.Context, region string, res *ResourceStatus) error {
+	if res.PhysicalID == "" {
+		return fmt.Errorf("no physical resource ID")
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	switch res.Type {
+	case "AWS::EC2::Instance":
+		ec2Client := ec2.NewFromConfig(cfg)
+		// Termination protection is the usual reason an instance survives
+		_, err := ec2Client.ModifyInstanceAttribute(ctx, &ec2.ModifyInstanceAttributeInput{
+			InstanceId:            aws.String(res.PhysicalID),
+			DisableApiTermination: &ec2types.AttributeBooleanValue{Value: aws.Bool(false)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to disable termination protection: %w", err)
+		}
+		_, err = ec2Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+			InstanceIds: []string{res.PhysicalID},
+		})
+		return err
+	case "AWS::EC2::SecurityGroup":
+		_, err := ec2.NewFromConfig(cfg).DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{
+			GroupId: aws.String(res.PhysicalID),
+		})
+		return err
+	case "AWS::EC2::NetworkInterface":
+		_, err := ec2.NewFromConfig(cfg).DeleteNetworkInterface(ctx, &ec2.DeleteNetworkInterfaceInput{
+			NetworkInterfaceId: aws.String(res.PhysicalID),
+		})
+		return err
+	case "AWS::EC2::Volume":
+		_, err := ec2.NewFromConfig(cfg).DeleteVolume(ctx, &ec2.DeleteVolumeInput{
+			VolumeId: aws.String(res.PhysicalID),
+		})
+		return err
+	case "AWS::Logs::LogGroup":
+		_, err := cloudwatchlogs.NewFromConfig(cfg).DeleteLogGroup(ctx, &cloudwatchlogs.DeleteLogGroupInput{
+			LogGroupName: aws.String(res.PhysicalID),
+		})
+		return err
+	default:
+		return fmt.Errorf("direct deletion of %s is not supported", res.Type)
+	}
+}
+
+// deleteStackRetaining retries deletion of a DELETE_FAILED stack, skipping the
+// resources in retain.
+func deleteStackRetaining(ctx context.Context, clusterState *state.ClusterState, retain []string) error {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(clusterState.Region))
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	input := &cloudformation.DeleteStackInput{
+		StackName: aws.String(clusterState.StackName),
+	}
+	if len(retain) > 0 {
+		input.RetainResources = retain
+	}
+
+	_, err = cloudformation.NewFromConfig(cfg).DeleteStack(ctx, input)
+	return err
+}
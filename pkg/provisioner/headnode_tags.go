@@ -0,0 +1,89 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// applyHeadNodeTags tags the head node with the template's head node tags.
+// ParallelCluster has no head node tag setting, so they are applied once the
+// head node exists; cluster tags already reach it through the stack.
+func (p *Provisioner) applyHeadNodeTags(ctx context.Context, tmpl *template.Template) error {
+	instanceID, _, err := p.GetHeadNodeInstanceID(ctx, tmpl.Cluster.Name)
+	if err != nil {
+		return err
+	}
+	return p.tagInstance(ctx, tmpl.Cluster.Region, instanceID, tmpl.Compute.HeadNodeTags)
+}
+
+// tagEC2Instance tags an instance and its attached EBS volumes.
+func tagEC2Instance(ctx context.Context, region, instanceID string, tags map[string]string) error {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := ec2.NewFromConfig(cfg)
+
+	result, err := client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe instance %s: %w", instanceID, err)
+	}
+
+	resources := []string{instanceID}
+	for _, reservation := range result.Reservations {
+		for _, instance := range reservation.Instances {
+			for _, mapping := range instance.BlockDeviceMappings {
+				if mapping.Ebs != nil && mapping.Ebs.VolumeId != nil {
+					resources = append(resources, *mapping.Ebs.VolumeId)
+				}
+			}
+		}
+	}
+
+	_, err = client.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: resources,
+		Tags:      ec2Tags(tags),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag instance %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+// ec2Tags converts a tag map to EC2 tags, sorted by key.
+func ec2Tags(tags map[string]string) []ec2types.Tag {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]ec2types.Tag, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, ec2types.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return result
+}
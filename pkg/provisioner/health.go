@@ -0,0 +1,147 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SinfoFormat is the sinfo output format read by ParseSinfo: partition,
+// availability, node count, and node state.
+const SinfoFormat = "%P|%a|%D|%T"
+
+// SlurmPartition is a Slurm partition as reported by sinfo.
+type SlurmPartition struct {
+	Name string
+	// Default is set for the partition jobs go to when none is given
+	Default bool
+	// Available is set when the partition is up
+	Available bool
+	// Nodes is the total number of nodes across all states
+	Nodes int
+	// States are the node states, e.g., "idle~" for powered-down nodes
+	States []string
+}
+
+// ParseSinfo parses `sinfo --noheader --format=SinfoFormat` output. sinfo
+// prints a line per partition and node state; these are merged into one
+// SlurmPartition per partition, in the order they first appear.
+func ParseSinfo(output string) ([]SlurmPartition, error) {
+	var partitions []SlurmPartition
+	index := make(map[string]int)
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "|")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected sinfo line %q", line)
+		}
+		nodes, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected node count in sinfo line %q", line)
+		}
+
+		name := strings.TrimSuffix(fields[0], "*")
+		i, ok := index[name]
+		if !ok {
+			i = len(partitions)
+			index[name] = i
+			partitions = append(partitions, SlurmPartition{
+				Name:      name,
+				Default:   strings.HasSuffix(fields[0], "*"),
+				Available: fields[1] == "up",
+			})
+		}
+		partitions[i].Nodes += nodes
+		partitions[i].States = append(partitions[i].States, fields[3])
+	}
+
+	return partitions, nil
+}
+
+// HealthCheckResult is the outcome of one cluster health check.
+type HealthCheckResult struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// SlurmHealthChecks checks that the scheduler answered sinfo and squeue and
+// that a partition is up for each of queues. sinfoErr and squeueErr are the
+// errors from running the commands on the head node.
+func SlurmHealthChecks(sinfoOutput string, sinfoErr, squeueErr error, queues []string) []HealthCheckResult {
+	var results []HealthCheckResult
+
+	var partitions []SlurmPartition
+	sinfo := HealthCheckResult{Name: "Scheduler responds (sinfo)"}
+	if sinfoErr != nil {
+		sinfo.Detail = sinfoErr.Error()
+	} else if parsed, err := ParseSinfo(sinfoOutput); err != nil {
+		sinfo.Detail = err.Error()
+	} else {
+		partitions = parsed
+		sinfo.Passed = true
+		sinfo.Detail = fmt.Sprintf("%d partition(s)", len(partitions))
+	}
+	results = append(results, sinfo)
+
+	squeue := HealthCheckResult{Name: "Job queue responds (squeue)", Passed: squeueErr == nil}
+	if squeueErr != nil {
+		squeue.Detail = squeueErr.Error()
+	}
+	results = append(results, squeue)
+
+	for _, queue := range queues {
+		check := HealthCheckResult{Name: fmt.Sprintf("Partition %s visible", queue)}
+		switch partition := findPartition(partitions, queue); {
+		case !sinfo.Passed:
+			check.Detail = "sinfo unavailable"
+		case partition == nil:
+			check.Detail = "not reported by sinfo"
+		case !partition.Available:
+			check.Detail = "partition is down"
+		default:
+			check.Passed = true
+			check.Detail = fmt.Sprintf("up, %d node(s) (%s)", partition.Nodes, strings.Join(partition.States, ", "))
+		}
+		results = append(results, check)
+	}
+
+	return results
+}
+
+// HealthChecksPassed returns whether every check passed.
+func HealthChecksPassed(results []HealthCheckResult) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+func findPartition(partitions []SlurmPartition, name string) *SlurmPartition {
+	for i := range partitions {
+		if partitions[i].Name == name {
+			return &partitions[i]
+		}
+	}
+	return nil
+}
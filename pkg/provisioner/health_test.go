@@ -0,0 +1,134 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sampleSinfo is sinfo output from a cluster with two queues, one of them
+// the default, with powered-down and running nodes.
+const sampleSinfo = `compute*|up|8|idle~
+compute*|up|2|mixed
+gpu|up|4|idle~
+debug|down|1|down~
+`
+
+func TestParseSinfo(t *testing.T) {
+	partitions, err := ParseSinfo(sampleSinfo)
+	if err != nil {
+		t.Fatalf("ParseSinfo() error = %v", err)
+	}
+
+	want := []SlurmPartition{
+		{Name: "compute", Default: true, Available: true, Nodes: 10, States: []string{"idle~", "mixed"}},
+		{Name: "gpu", Available: true, Nodes: 4, States: []string{"idle~"}},
+		{Name: "debug", Nodes: 1, States: []string{"down~"}},
+	}
+	if !reflect.DeepEqual(partitions, want) {
+		t.Errorf("ParseSinfo() = %+v, want %+v", partitions, want)
+	}
+}
+
+func TestParseSinfoInvalid(t *testing.T) {
+	for _, output := range []string{
+		"PARTITION AVAIL NODES STATE\ncompute* up 8 idle~",
+		"compute*|up|many|idle~",
+	} {
+		if _, err := ParseSinfo(output); err == nil {
+			t.Errorf("ParseSinfo(%q) should fail", output)
+		}
+	}
+}
+
+func TestSlurmHealthChecks(t *testing.T) {
+	results := SlurmHealthChecks(sampleSinfo, nil, nil, []string{"compute", "gpu"})
+	if !HealthChecksPassed(results) {
+		t.Fatalf("expected all checks to pass: %+v", results)
+	}
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want sinfo, squeue, and one per queue", len(results))
+	}
+	if results[2].Name != "Partition compute visible" || results[2].Detail != "up, 10 node(s) (idle~, mixed)" {
+		t.Errorf("compute result = %+v", results[2])
+	}
+}
+
+func TestSlurmHealthChecksFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		sinfo      string
+		sinfoErr   error
+		squeueErr  error
+		queues     []string
+		wantFailed []string
+		wantDetail string
+	}{
+		{
+			name:       "partition down",
+			sinfo:      sampleSinfo,
+			queues:     []string{"debug"},
+			wantFailed: []string{"Partition debug visible"},
+			wantDetail: "partition is down",
+		},
+		{
+			name:       "partition missing",
+			sinfo:      sampleSinfo,
+			queues:     []string{"highmem"},
+			wantFailed: []string{"Partition highmem visible"},
+			wantDetail: "not reported by sinfo",
+		},
+		{
+			name:       "slurmctld not responding",
+			sinfoErr:   errors.New("slurm_load_partitions: Unable to contact slurm controller"),
+			squeueErr:  errors.New("slurm_load_jobs error: Unable to contact slurm controller"),
+			queues:     []string{"compute"},
+			wantFailed: []string{"Scheduler responds (sinfo)", "Job queue responds (squeue)", "Partition compute visible"},
+			wantDetail: "sinfo unavailable",
+		},
+		{
+			name:       "unparseable sinfo",
+			sinfo:      "garbage",
+			queues:     []string{"compute"},
+			wantFailed: []string{"Scheduler responds (sinfo)", "Partition compute visible"},
+			wantDetail: "sinfo unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := SlurmHealthChecks(tt.sinfo, tt.sinfoErr, tt.squeueErr, tt.queues)
+			if HealthChecksPassed(results) {
+				t.Fatal("HealthChecksPassed() = true, want false")
+			}
+
+			var failed []string
+			for _, result := range results {
+				if !result.Passed {
+					failed = append(failed, result.Name)
+				}
+			}
+			if !reflect.DeepEqual(failed, tt.wantFailed) {
+				t.Errorf("failed checks = %v, want %v", failed, tt.wantFailed)
+			}
+			if last := results[len(results)-1]; !strings.Contains(last.Detail, tt.wantDetail) {
+				t.Errorf("partition detail = %q, want %q", last.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// templateInstanceTypes returns the head node and queue instance types, in
+// template order without duplicates.
+func templateInstanceTypes(tmpl *template.Template) []string {
+	seen := make(map[string]bool)
+	var types []string
+	add := func(instanceType string) {
+		if instanceType != "" && !seen[instanceType] {
+			seen[instanceType] = true
+			types = append(types, instanceType)
+		}
+	}
+
+	add(tmpl.Compute.HeadNode)
+	for _, queue := range tmpl.Compute.Queues {
+		for _, instanceType := range queue.AllInstanceTypes() {
+			add(instanceType)
+		}
+	}
+	return types
+}
+
+// checkInstanceTypeOfferings verifies that every instance type in the template
+// is offered in the subnet's availability zone, so creation fails before any
+// resources exist rather than partway through the stack.
+func (p *Provisioner) checkInstanceTypeOfferings(ctx context.Context, tmpl *template.Template, subnetID string) error {
+	region := tmpl.Cluster.Region
+	zone, err := p.subnetAvailabilityZone(ctx, region, subnetID)
+	if err != nil {
+		return fmt.Errorf("failed to look up subnet %s: %w", subnetID, err)
+	}
+
+	instanceTypes := templateInstanceTypes(tmpl)
+	offerings, err := p.instanceTypeOfferings(ctx, region, instanceTypes)
+	if err != nil {
+		return fmt.Errorf("failed to look up instance type offerings: %w", err)
+	}
+
+	return offeringsError(subnetID, zone, region, instanceTypes, offerings)
+}
+
+// offeringsError reports the instance types not offered in zone, given the
+// zones each type is offered in. It returns nil when all types are offered.
+func offeringsError(subnetID, zone, region string, instanceTypes []string, offerings map[string][]string) error {
+	var missing []string
+	for _, instanceType := range instanceTypes {
+		zones := offerings[instanceType]
+		if containsString(zones, zone) {
+			continue
+		}
+		if len(zones) == 0 {
+			missing = append(missing, fmt.Sprintf("%s (not offered in %s)", instanceType, region))
+		} else {
+			missing = append(missing, fmt.Sprintf("%s (available in: %s)", instanceType, strings.Join(zones, ", ")))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("instance types not offered in availability zone %s of subnet %s:\n  - %s",
+		zone, subnetID, strings.Join(missing, "\n  - "))
+	if common := commonZones(instanceTypes, offerings); len(common) > 0 {
+		msg += fmt.Sprintf("\n\nUse a subnet in an availability zone that offers all of them: %s", strings.Join(common, ", "))
+	} else {
+		msg += fmt.Sprintf("\n\nNo availability zone in %s offers all of them; choose different instance types", region)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// commonZones returns the zones, sorted, that offer every instance type.
+func commonZones(instanceTypes []string, offerings map[string][]string) []string {
+	counts := make(map[string]int)
+	for _, instanceType := range instanceTypes {
+		for _, zone := range offerings[instanceType] {
+			counts[zone]++
+		}
+	}
+
+	var zones []string
+	for zone, count := range counts {
+		if count == len(instanceTypes) {
+			zones = append(zones, zone)
+		}
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// describeSubnetAvailabilityZone returns the availability zone of a subnet.
+func describeSubnetAvailabilityZone(ctx context.Context, region, subnetID string) (string, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	result, err := ec2.NewFromConfig(cfg).DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		SubnetIds: []string{subnetID},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(result.Subnets) == 0 {
+		return "", fmt.Errorf("subnet %s not found", subnetID)
+	}
+	return aws.ToString(result.Subnets[0].AvailabilityZone), nil
+}
+
+// describeInstanceTypeOfferings returns the availability zones, sorted, that
+// offer each of the instance types.
+func describeInstanceTypeOfferings(ctx context.Context, region string, instanceTypes []string) (map[string][]string, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	paginator := ec2.NewDescribeInstanceTypeOfferingsPaginator(ec2.NewFromConfig(cfg), &ec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: ec2types.LocationTypeAvailabilityZone,
+		Filters: []ec2types.Filter{
+			{Name: aws.String("instance-type"), Values: instanceTypes},
+		},
+	})
+
+	offerings := make(map[string][]string)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, offering := range page.InstanceTypeOfferings {
+			instanceType := string(offering.InstanceType)
+			offerings[instanceType] = append(offerings[instanceType], aws.ToString(offering.Location))
+		}
+	}
+	for _, zones := range offerings {
+		sort.Strings(zones)
+	}
+	return offerings, nil
+}
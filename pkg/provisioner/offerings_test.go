@@ -0,0 +1,118 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func newOfferingsProvisioner(zone string, offerings map[string][]string) *Provisioner {
+	return &Provisioner{
+		subnetAvailabilityZone: func(ctx context.Context, region, subnetID string) (string, error) {
+			return zone, nil
+		},
+		instanceTypeOfferings: func(ctx context.Context, region string, instanceTypes []string) (map[string][]string, error) {
+			return offerings, nil
+		},
+	}
+}
+
+func offeringsTemplate() *template.Template {
+	return &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.large",
+			Queues: []template.Queue{
+				{Name: "cpu", InstanceTypes: []string{"c5.xlarge", "t3.large"}, MaxCount: 10},
+				{Name: "gpu", ComputeResources: []template.ComputeResource{
+					{Name: "p5", InstanceTypes: []string{"p5.48xlarge"}, MaxCount: 2},
+				}},
+			},
+		},
+	}
+}
+
+func TestTemplateInstanceTypes(t *testing.T) {
+	got := templateInstanceTypes(offeringsTemplate())
+	want := []string{"t3.large", "c5.xlarge", "p5.48xlarge"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("templateInstanceTypes() = %v, want %v", got, want)
+	}
+}
+
+func TestCheckInstanceTypeOfferings(t *testing.T) {
+	allZones := []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+
+	p := newOfferingsProvisioner("us-east-1a", map[string][]string{
+		"t3.large":    allZones,
+		"c5.xlarge":   allZones,
+		"p5.48xlarge": allZones,
+	})
+	if err := p.checkInstanceTypeOfferings(context.Background(), offeringsTemplate(), "subnet-a"); err != nil {
+		t.Errorf("checkInstanceTypeOfferings() unexpected error = %v", err)
+	}
+}
+
+func TestCheckInstanceTypeOfferingsUnavailable(t *testing.T) {
+	allZones := []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+
+	p := newOfferingsProvisioner("us-east-1a", map[string][]string{
+		"t3.large":    allZones,
+		"c5.xlarge":   allZones,
+		"p5.48xlarge": {"us-east-1b", "us-east-1c"},
+	})
+	err := p.checkInstanceTypeOfferings(context.Background(), offeringsTemplate(), "subnet-a")
+	if err == nil {
+		t.Fatal("checkInstanceTypeOfferings() expected error for p5.48xlarge")
+	}
+	for _, want := range []string{
+		"availability zone us-east-1a of subnet subnet-a",
+		"p5.48xlarge (available in: us-east-1b, us-east-1c)",
+		"offers all of them: us-east-1b, us-east-1c",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "c5.xlarge") {
+		t.Errorf("error %q should only list unavailable types", err)
+	}
+}
+
+func TestCheckInstanceTypeOfferingsNoCommonZone(t *testing.T) {
+	p := newOfferingsProvisioner("us-east-1a", map[string][]string{
+		"t3.large":  {"us-east-1a", "us-east-1b"},
+		"c5.xlarge": {"us-east-1a"},
+	})
+	tmpl := offeringsTemplate()
+	tmpl.Compute.Queues = tmpl.Compute.Queues[:1]
+	tmpl.Compute.Queues = append(tmpl.Compute.Queues, template.Queue{Name: "hpc", InstanceTypes: []string{"hpc7g.16xlarge"}, MaxCount: 4})
+
+	err := p.checkInstanceTypeOfferings(context.Background(), tmpl, "subnet-a")
+	if err == nil {
+		t.Fatal("checkInstanceTypeOfferings() expected error for hpc7g.16xlarge")
+	}
+	if !strings.Contains(err.Error(), "hpc7g.16xlarge (not offered in us-east-1)") {
+		t.Errorf("error %q should report the type is not offered in the region", err)
+	}
+	if !strings.Contains(err.Error(), "No availability zone in us-east-1 offers all of them") {
+		t.Errorf("error %q should say no zone offers all types", err)
+	}
+}
@@ -20,23 +20,88 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
+	"os/user"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/scttfrdmn/petal/internal/config"
+	"github.com/scttfrdmn/petal/pkg/ami"
 	"github.com/scttfrdmn/petal/pkg/bootstrap"
 	pcconfig "github.com/scttfrdmn/petal/pkg/config"
 	"github.com/scttfrdmn/petal/pkg/network"
+	"github.com/scttfrdmn/petal/pkg/software"
 	"github.com/scttfrdmn/petal/pkg/state"
 	"github.com/scttfrdmn/petal/pkg/template"
 )
 
+// statusDeleteFailedNetwork marks a cluster whose stack was deleted but whose
+// pctl-managed network resources could not be. The network IDs are kept in
+// state so PruneNetworks can finish the cleanup.
+const statusDeleteFailedNetwork = "DELETE_FAILED_NETWORK"
+
+// statusDeleteFailedPlacementGroups marks a cluster whose placement groups
+// could not be deleted yet, usually because its instances were still
+// terminating. The remaining group names are kept in state so PruneNetworks
+// can finish the cleanup.
+const statusDeleteFailedPlacementGroups = "DELETE_FAILED_PLACEMENT_GROUPS"
+
+// statusDeletePending marks a cluster whose stack deletion was started
+// without waiting for it. Its network, placement groups, and persistent home
+// claim are kept in state until PruneNetworks sees the stack is gone.
+const statusDeletePending = "DELETE_PENDING"
+
+// pclusterVersionTag is the tag pcluster build-image puts on the AMIs it builds.
+const pclusterVersionTag = "parallelcluster:version"
+
+// defaultNetworkDeleteBackoff is the wait before each network deletion retry.
+// ENIs left by cluster instances can take a few minutes to be released.
+var defaultNetworkDeleteBackoff = []time.Duration{15 * time.Second, 30 * time.Second, 60 * time.Second, 120 * time.Second}
+
 // Provisioner handles cluster provisioning using ParallelCluster.
 type Provisioner struct {
 	stateManager *state.Manager
 	configGen    *pcconfig.Generator
+	pcluster     PClusterClient
+
+	// Creation steps, replaceable in tests
+	createNetwork   func(ctx context.Context, tmpl *template.Template, opts *CreateOptions) (*network.NetworkResources, error)
+	monitorCreation func(ctx context.Context, clusterState *state.ClusterState, opts *CreateOptions) error
+
+	// Teardown steps, replaceable in tests
+	waitForStackDeletion func(ctx context.Context, clusterState *state.ClusterState) error
+	deleteNetwork        func(ctx context.Context, clusterState *state.ClusterState) error
+	networkDeleteBackoff []time.Duration
+
+	// Force-delete steps, replaceable in tests
+	getStackEvents   func(ctx context.Context, clusterState *state.ClusterState) ([]types.StackEvent, error)
+	deleteResource   func(ctx context.Context, region string, res *ResourceStatus) error
+	retryStackDelete func(ctx context.Context, clusterState *state.ClusterState, retain []string) error
+
+	// Placement group steps, replaceable in tests
+	createPlacementGroup func(ctx context.Context, region, clusterName, name string) error
+	deletePlacementGroup func(ctx context.Context, region, name string) error
+
+	// AMI lookup, replaceable in tests
+	describeAMITags func(ctx context.Context, region, amiID string) (map[string]string, error)
+
+	// Instance type availability lookups, replaceable in tests
+	subnetAvailabilityZone func(ctx context.Context, region, subnetID string) (string, error)
+	instanceTypeOfferings  func(ctx context.Context, region string, instanceTypes []string) (map[string][]string, error)
+
+	// Instance tagging, replaceable in tests
+	tagInstance func(ctx context.Context, region, instanceID string, tags map[string]string) error
+
+	// Persistent home volume steps, replaceable in tests
+	createHomeVolume       func(ctx context.Context, region, zone, name string, size int, tags map[string]string) (string, error)
+	volumeAvailabilityZone func(ctx context.Context, region, volumeID string) (string, error)
+
+	// Bootstrap script upload, replaceable in tests
+	uploadBootstrapScript func(ctx context.Context, region, clusterName, content string) (string, error)
+
+	// clusterPollInterval is how often cluster updates are checked
+	clusterPollInterval time.Duration
 }
 
 // NewProvisioner creates a new provisioner.
@@ -46,16 +111,37 @@ func NewProvisioner() (*Provisioner, error) {
 		return nil, fmt.Errorf("failed to create state manager: %w", err)
 	}
 
-	return &Provisioner{
+	p := &Provisioner{
 		stateManager: stateMgr,
 		configGen:    pcconfig.NewGenerator(),
-	}, nil
+		pcluster:     NewExecPClusterClient(),
+	}
+	p.createNetwork = createClusterNetwork
+	p.monitorCreation = p.monitorClusterCreation
+	p.waitForStackDeletion = p.monitorStackDeletion
+	p.deleteNetwork = p.deleteClusterNetwork
+	p.networkDeleteBackoff = defaultNetworkDeleteBackoff
+	p.describeAMITags = lookupAMITags
+	p.getStackEvents = describeStackEvents
+	p.deleteResource = deleteStackResource
+	p.retryStackDelete = deleteStackRetaining
+	p.createPlacementGroup = createEC2PlacementGroup
+	p.deletePlacementGroup = deleteEC2PlacementGroup
+	p.tagInstance = tagEC2Instance
+	p.subnetAvailabilityZone = describeSubnetAvailabilityZone
+	p.instanceTypeOfferings = describeInstanceTypeOfferings
+	p.createHomeVolume = createEC2HomeVolume
+	p.volumeAvailabilityZone = describeVolumeAvailabilityZone
+	p.uploadBootstrapScript = uploadS3BootstrapScript
+	p.clusterPollInterval = defaultClusterPollInterval
+
+	return p, nil
 }
 
 // CreateCluster creates a new cluster from a template.
 func (p *Provisioner) CreateCluster(ctx context.Context, tmpl *template.Template, opts *CreateOptions) error {
 	// Check if cluster already exists in AWS (not just local state)
-	awsStatus, err := p.runPClusterDescribe(ctx, tmpl.Cluster.Name, tmpl.Cluster.Region)
+	awsStatus, err := p.pcluster.DescribeCluster(ctx, tmpl.Cluster.Name, tmpl.Cluster.Region)
 	if err == nil {
 		// Cluster exists in AWS
 		if awsStatus.Status == "CREATE_FAILED" || awsStatus.Status == "DELETE_FAILED" {
@@ -76,56 +162,164 @@ func (p *Provisioner) CreateCluster(ctx context.Context, tmpl *template.Template
 		return fmt.Errorf("template validation failed: %w", err)
 	}
 
+	// A subnet pins the cluster to one availability zone; make sure it offers
+	// every instance type before creating anything
+	if opts.SubnetID != "" {
+		if err := p.checkInstanceTypeOfferings(ctx, tmpl, opts.SubnetID); err != nil {
+			return err
+		}
+	}
+
+	// Custom AMIs must be reusable and match the pcluster CLI's
+	// ParallelCluster version
+	if opts.CustomAMI != "" {
+		if err := p.checkCustomAMI(ctx, tmpl.Cluster.Region, opts.CustomAMI); err != nil {
+			return err
+		}
+	}
+	if err := p.checkQueueAMIs(ctx, tmpl); err != nil {
+		return err
+	}
+
+	// Create cluster placement groups for tightly-coupled queues
+	placementGroups, err := p.createPlacementGroups(ctx, tmpl)
+	if err != nil {
+		return err
+	}
+
+	// State is filled in as resources are created so failures can clean up
+	clusterState := &state.ClusterState{
+		Name:   tmpl.Cluster.Name,
+		Region: tmpl.Cluster.Region,
+	}
+	for _, queue := range tmpl.Compute.Queues {
+		if groupName, ok := placementGroups[queue.Name]; ok {
+			fmt.Printf("✅ Placement group for queue %s: %s\n", queue.Name, groupName)
+			clusterState.PlacementGroups = append(clusterState.PlacementGroups, groupName)
+		}
+	}
+
 	// Create network resources if not provided
 	var networkResources *network.NetworkResources
 	subnetID := opts.SubnetID
 	if subnetID == "" {
-		fmt.Printf("🌐 Creating VPC and networking resources...\n")
-		netMgr, err := network.NewManager(ctx, tmpl.Cluster.Region)
-		if err != nil {
-			return fmt.Errorf("failed to create network manager: %w", err)
+		if opts.VpcID != "" {
+			fmt.Printf("🌐 Creating subnets and security group in %s...\n", opts.VpcID)
+		} else {
+			fmt.Printf("🌐 Creating VPC and networking resources...\n")
 		}
-
-		networkResources, err = netMgr.CreateNetwork(ctx, tmpl.Cluster.Name)
+		networkResources, err = p.createNetwork(ctx, tmpl, opts)
 		if err != nil {
-			return fmt.Errorf("failed to create network: %w", err)
+			p.deletePlacementGroups(ctx, clusterState)
+			return err
 		}
+		recordNetworkResources(clusterState, networkResources)
 		subnetID = networkResources.PublicSubnetID
-		fmt.Printf("✅ VPC created: %s\n", networkResources.VpcID)
-		fmt.Printf("✅ Public subnet: %s\n", networkResources.PublicSubnetID)
-		fmt.Printf("✅ Private subnet: %s\n", networkResources.PrivateSubnetID)
+		if networkResources.SharedVPC {
+			fmt.Printf("✅ Using existing VPC: %s\n", networkResources.VpcID)
+		} else {
+			fmt.Printf("✅ VPC created: %s\n", networkResources.VpcID)
+		}
+		fmt.Printf("✅ Public subnets: %s\n", strings.Join(networkResources.PublicSubnetIDs, ", "))
+		if len(networkResources.PrivateSubnetIDs) > 0 {
+			fmt.Printf("✅ Private subnets: %s\n", strings.Join(networkResources.PrivateSubnetIDs, ", "))
+		}
+		if networkResources.DhcpOptionsID != "" {
+			fmt.Printf("✅ DHCP options: %s\n", networkResources.DhcpOptionsID)
+		}
+		if networkResources.NatGatewayID != "" {
+			fmt.Printf("✅ NAT gateway: %s\n", networkResources.NatGatewayID)
+		}
+		if networkResources.S3EndpointID != "" {
+			fmt.Printf("✅ S3 endpoint: %s\n", networkResources.S3EndpointID)
+		}
+	}
+
+	// Attach the persistent /home volume, creating it on first use. The
+	// volume is tagged like the cluster, so --tags must be set first.
+	p.configGen.TemplateName = templateName(opts.TemplatePath)
+	p.configGen.Owner = currentOwner()
+	p.configGen.Tags = opts.Tags
+	p.configGen.FixedTags = nil
+	persistentHome, err := p.preparePersistentHome(ctx, tmpl, subnetID, p.configGen.ClusterTags(tmpl))
+	if err != nil {
+		p.cleanupFailedCreate(ctx, clusterState, "")
+		return err
+	}
+	if persistentHome != nil {
+		clusterState.PersistentHome = persistentHome.Name
 	}
 
 	// Generate and upload bootstrap script if needed
-	// Skip if CustomAMI is provided (software pre-installed in AMI)
+	// Skip if CustomAMI is provided (software pre-installed in AMI), except to
+	// set up local Slurm accounting, which belongs to the new head node
 	var bootstrapS3URI string
-	if opts.CustomAMI == "" && (len(tmpl.Software.SpackPackages) > 0 || len(tmpl.Users) > 0 || len(tmpl.Data.S3Mounts) > 0) {
+	bootstrapTmpl := tmpl
+	needsBootstrap := len(tmpl.Software.SpackPackages) > 0 || len(tmpl.Users) > 0 || len(tmpl.Data.S3Mounts) > 0 || tmpl.Scheduler.LocalAccounting()
+	if opts.CustomAMI != "" {
+		needsBootstrap = tmpl.Scheduler.LocalAccounting()
+		bootstrapTmpl = &template.Template{Cluster: tmpl.Cluster, Scheduler: tmpl.Scheduler, Network: tmpl.Network}
+		if needsBootstrap {
+			fmt.Printf("📀 Using custom AMI with pre-installed software (bootstrap only sets up Slurm accounting)\n")
+		} else {
+			fmt.Printf("📀 Using custom AMI with pre-installed software (skipping bootstrap)\n")
+		}
+	}
+	if needsBootstrap {
 		fmt.Printf("📝 Generating bootstrap script...\n")
 
+		// Local user skeletons are embedded in the script
+		skeletons, err := software.LoadSkeletons(bootstrapTmpl.Users)
+		if err != nil {
+			return fmt.Errorf("failed to load user skeletons: %w", err)
+		}
+		p.configGen.Skeletons = skeletons
+
 		// Generate bootstrap script content
-		scriptContent := p.configGen.GenerateBootstrapScript(tmpl)
+		scriptContent := p.configGen.GenerateBootstrapScript(bootstrapTmpl)
 
 		// Upload to S3
 		fmt.Printf("☁️  Uploading bootstrap script to S3...\n")
-		s3Mgr, err := bootstrap.NewS3Manager(ctx, tmpl.Cluster.Region)
-		if err != nil {
-			return fmt.Errorf("failed to create S3 manager: %w", err)
-		}
-
-		bootstrapS3URI, err = s3Mgr.UploadBootstrapScript(ctx, tmpl.Cluster.Name, scriptContent)
+		bootstrapS3URI, err = p.uploadBootstrapScript(ctx, tmpl.Cluster.Region, tmpl.Cluster.Name, scriptContent)
 		if err != nil {
-			return fmt.Errorf("failed to upload bootstrap script: %w", err)
+			return err
 		}
 		fmt.Printf("✅ Bootstrap script uploaded: %s\n", bootstrapS3URI)
-	} else if opts.CustomAMI != "" {
-		fmt.Printf("📀 Using custom AMI with pre-installed software (skipping bootstrap)\n")
 	}
 
 	// Generate ParallelCluster config
 	p.configGen.KeyName = opts.KeyName
 	p.configGen.SubnetID = subnetID
+	p.configGen.ComputeSubnetIDs = nil
+	if networkResources != nil {
+		switch {
+		case networkResources.NatGatewayID != "":
+			// The private subnets reach the internet through the NAT gateway
+			p.configGen.ComputeSubnetIDs = networkResources.PrivateSubnetIDs
+		case len(networkResources.PublicSubnetIDs) > 1:
+			// Compute shares the head node's public subnets; the private
+			// subnets have no route for nodes to reach AWS endpoints
+			p.configGen.ComputeSubnetIDs = networkResources.PublicSubnetIDs
+		}
+	}
 	p.configGen.CustomAMI = opts.CustomAMI
 	p.configGen.BootstrapScriptS3URI = bootstrapS3URI
+	p.configGen.PlacementGroups = placementGroups
+	p.configGen.HeadNodeSecurityGroups = nil
+	p.configGen.SSHAllowedIPs = ""
+	p.configGen.PersistentHomeID = ""
+	if persistentHome != nil {
+		p.configGen.PersistentHomeID = persistentHome.ID
+	}
+	if networkResources != nil {
+		// The pctl security group carries the allowed SSH CIDRs and custom
+		// ingress rules. ParallelCluster's own group takes a single CIDR, so
+		// it gets the first; together they allow SSH from exactly the list.
+		p.configGen.HeadNodeSecurityGroups = []string{networkResources.SecurityGroupID}
+		if len(networkResources.SSHCIDRs) > 0 {
+			p.configGen.SSHAllowedIPs = networkResources.SSHCIDRs[0]
+		}
+	}
 
 	pcConfig, err := p.configGen.Generate(tmpl)
 	if err != nil {
@@ -141,28 +335,16 @@ func (p *Provisioner) CreateCluster(ctx context.Context, tmpl *template.Template
 
 	// Create initial state
 	// Note: ParallelCluster creates stacks with the cluster name (not pctl-{name})
-	clusterState := &state.ClusterState{
-		Name:                 tmpl.Cluster.Name,
-		Region:               tmpl.Cluster.Region,
-		Status:               "CREATE_IN_PROGRESS",
-		StackName:            tmpl.Cluster.Name,
-		TemplatePath:         opts.TemplatePath,
-		CreatedAt:            time.Now(),
-		CustomAMI:            opts.CustomAMI,
-		KeyName:              opts.KeyName,
-		BootstrapScriptS3URI: bootstrapS3URI,
-	}
-
-	// Store network resources if we created them
-	if networkResources != nil {
-		clusterState.VpcID = networkResources.VpcID
-		clusterState.PublicSubnetID = networkResources.PublicSubnetID
-		clusterState.PrivateSubnetID = networkResources.PrivateSubnetID
-		clusterState.SecurityGroupID = networkResources.SecurityGroupID
-		clusterState.InternetGatewayID = networkResources.InternetGatewayID
-		clusterState.RouteTableID = networkResources.RouteTableID
-		clusterState.NetworkManagedByPctl = true
-	}
+	clusterState.Status = "CREATE_IN_PROGRESS"
+	clusterState.StackName = tmpl.Cluster.Name
+	clusterState.TemplatePath = absTemplatePath(opts.TemplatePath)
+	clusterState.CreatedAt = time.Now()
+	clusterState.CustomAMI = opts.CustomAMI
+	clusterState.KeyName = opts.KeyName
+	clusterState.BootstrapScriptS3URI = bootstrapS3URI
+	clusterState.SubnetID = subnetID
+	clusterState.Tags = p.configGen.ClusterTags(tmpl)
+	clusterState.Overrides = opts.Overrides
 
 	if err := p.stateManager.Save(clusterState); err != nil {
 		return fmt.Errorf("failed to save initial state: %w", err)
@@ -170,53 +352,18 @@ func (p *Provisioner) CreateCluster(ctx context.Context, tmpl *template.Template
 
 	// Create cluster using pcluster CLI (initiates async creation)
 	fmt.Printf("🔧 Initiating cluster creation...\n")
-	if err := p.runPClusterCreateAsync(ctx, tmpl.Cluster.Name, configPath, tmpl.Cluster.Region); err != nil {
-		clusterState.Status = "CREATE_FAILED"
-		p.stateManager.Save(clusterState)
-
-		// Clean up network resources if we created them
-		if networkResources != nil {
-			fmt.Printf("\n🧹 Cleaning up network resources due to cluster creation failure...\n")
-			netMgr, _ := network.NewManager(ctx, tmpl.Cluster.Region)
-			if netMgr != nil {
-				netMgr.DeleteNetwork(ctx, networkResources)
-			}
-		}
+	if err := p.pcluster.CreateCluster(ctx, tmpl.Cluster.Name, configPath, tmpl.Cluster.Region); err != nil {
+		p.cleanupFailedCreate(ctx, clusterState, "CREATE_FAILED")
+		p.releasePersistentHomeAfterFailedCreate(ctx, clusterState)
 
 		return fmt.Errorf("failed to create cluster: %w", err)
 	}
 
 	// Monitor cluster creation progress
-	stackName := clusterState.StackName
-	monitor, err := NewProgressMonitor(ctx, stackName, tmpl.Cluster.Region, tmpl.Cluster.Name)
-	if err != nil {
-		fmt.Printf("⚠️  Warning: Failed to create progress monitor: %v\n", err)
-		fmt.Printf("⏳ Cluster is being created in the background. Check status with: pctl status %s\n", tmpl.Cluster.Name)
-	} else {
-		// Monitor with timeout (30 minutes max)
-		monitorCtx, cancel := context.WithTimeout(ctx, 30*time.Minute)
-		defer cancel()
-
-		if err := monitor.MonitorCreation(monitorCtx); err != nil {
-			if monitorCtx.Err() == context.DeadlineExceeded {
-				fmt.Printf("\n⚠️  Monitoring timeout reached (30 minutes). Cluster is still being created.\n")
-				fmt.Printf("Check status with: pctl status %s\n", tmpl.Cluster.Name)
-			} else {
-				clusterState.Status = "CREATE_FAILED"
-				p.stateManager.Save(clusterState)
-
-				// Clean up network resources if we created them
-				if networkResources != nil {
-					fmt.Printf("\n🧹 Cleaning up network resources due to cluster creation failure...\n")
-					netMgr, _ := network.NewManager(ctx, tmpl.Cluster.Region)
-					if netMgr != nil {
-						netMgr.DeleteNetwork(ctx, networkResources)
-					}
-				}
+	if err := p.monitorCreation(ctx, clusterState, opts); err != nil {
+		p.cleanupFailedCreate(ctx, clusterState, "CREATE_FAILED")
 
-				return fmt.Errorf("cluster creation failed: %w", err)
-			}
-		}
+		return fmt.Errorf("cluster creation failed: %w", err)
 	}
 
 	// Update state
@@ -225,46 +372,232 @@ func (p *Provisioner) CreateCluster(ctx context.Context, tmpl *template.Template
 		return fmt.Errorf("failed to update state: %w", err)
 	}
 
+	if len(tmpl.Compute.HeadNodeTags) > 0 {
+		if err := p.applyHeadNodeTags(ctx, tmpl); err != nil {
+			fmt.Printf("⚠️  Warning: failed to apply head node tags: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// createClusterNetwork creates the VPC, or the subnets inside opts.VpcID,
+// for a new cluster.
+func createClusterNetwork(ctx context.Context, tmpl *template.Template, opts *CreateOptions) (*network.NetworkResources, error) {
+	netMgr, err := network.NewManager(ctx, tmpl.Cluster.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create network manager: %w", err)
+	}
+
+	netOpts := &network.Options{
+		DomainName:      tmpl.Network.DomainName,
+		DNSServers:      tmpl.Network.DNSServers,
+		IngressRules:    networkIngressRules(tmpl.Network.IngressRules),
+		AllowedSSHCIDRs: opts.SSHCIDRs,
+		AZCount:         opts.AZCount,
+		EnableNAT:       opts.EnableNAT,
+		S3Endpoint:      len(tmpl.Data.S3Mounts) > 0 && !opts.NoS3Endpoint,
+	}
+
+	var networkResources *network.NetworkResources
+	if opts.VpcID != "" {
+		networkResources, err = netMgr.CreateSubnetsInVPC(ctx, opts.VpcID, tmpl.Cluster.Name, netOpts)
+	} else {
+		networkResources, err = netMgr.CreateNetwork(ctx, tmpl.Cluster.Name, netOpts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create network: %w", err)
+	}
+
+	return networkResources, nil
+}
+
+// uploadS3BootstrapScript uploads a cluster's bootstrap script to the pctl
+// bootstrap bucket and returns its S3 URI.
+func uploadS3BootstrapScript(ctx context.Context, region, clusterName, content string) (string, error) {
+	s3Mgr, err := bootstrap.NewS3Manager(ctx, region)
+	if err != nil {
+		return "", fmt.Errorf("failed to create S3 manager: %w", err)
+	}
+
+	uri, err := s3Mgr.UploadBootstrapScript(ctx, clusterName, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload bootstrap script: %w", err)
+	}
+	return uri, nil
+}
+
+// recordNetworkResources stores the IDs of the network resources pctl
+// created for a cluster in its state, so they can be deleted with it.
+func recordNetworkResources(clusterState *state.ClusterState, networkResources *network.NetworkResources) {
+	clusterState.VpcID = networkResources.VpcID
+	clusterState.PublicSubnetID = networkResources.PublicSubnetID
+	clusterState.PrivateSubnetID = networkResources.PrivateSubnetID
+	clusterState.PublicSubnetIDs = networkResources.PublicSubnetIDs
+	clusterState.PrivateSubnetIDs = networkResources.PrivateSubnetIDs
+	clusterState.SSHCIDRs = networkResources.SSHCIDRs
+	clusterState.SecurityGroupID = networkResources.SecurityGroupID
+	clusterState.InternetGatewayID = networkResources.InternetGatewayID
+	clusterState.RouteTableID = networkResources.RouteTableID
+	clusterState.DhcpOptionsID = networkResources.DhcpOptionsID
+	clusterState.NatGatewayID = networkResources.NatGatewayID
+	clusterState.EIPAllocationID = networkResources.EIPAllocationID
+	clusterState.PrivateRouteTableID = networkResources.PrivateRouteTableID
+	clusterState.S3EndpointID = networkResources.S3EndpointID
+	clusterState.SharedVPC = networkResources.SharedVPC
+	clusterState.NetworkManagedByPctl = true
+}
+
+// cleanupFailedCreate deletes the network resources and placement groups
+// created for a cluster whose creation failed. A non-empty status is saved
+// to the cluster's state first.
+func (p *Provisioner) cleanupFailedCreate(ctx context.Context, clusterState *state.ClusterState, status string) {
+	if status != "" {
+		clusterState.Status = status
+		p.stateManager.Save(clusterState)
+	}
+
+	if clusterState.NetworkManagedByPctl {
+		fmt.Printf("\n🧹 Cleaning up network resources due to cluster creation failure...\n")
+		if err := p.deleteNetwork(ctx, clusterState); err != nil {
+			fmt.Printf("⚠️  Warning: failed to delete network resources: %v\n", err)
+		}
+	}
+	p.deletePlacementGroups(ctx, clusterState)
+}
+
+// monitorClusterCreation follows cluster creation until it completes. It
+// only returns an error if creation failed; when progress cannot be
+// monitored, or monitoring times out, the cluster is left to finish in the
+// background.
+func (p *Provisioner) monitorClusterCreation(ctx context.Context, clusterState *state.ClusterState, opts *CreateOptions) error {
+	var monitor *ProgressMonitor
+	var err error
+	if opts.Progress != nil {
+		monitor, err = NewProgressMonitorWithCallbacks(ctx, clusterState.StackName, clusterState.Region, clusterState.Name, *opts.Progress)
+	} else {
+		monitor, err = NewProgressMonitor(ctx, clusterState.StackName, clusterState.Region, clusterState.Name)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to create progress monitor: %v\n", err)
+		fmt.Printf("⏳ Cluster is being created in the background. Check status with: pctl status %s\n", clusterState.Name)
+		return nil
+	}
+	monitor.SetPollInterval(opts.PollInterval)
+
+	timeout := opts.monitorTimeout()
+	monitorCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := monitor.MonitorCreation(monitorCtx); err != nil {
+		if monitorCtx.Err() == context.DeadlineExceeded {
+			fmt.Printf("\n⚠️  Monitoring timeout reached (%s). Cluster is still being created.\n", formatDuration(timeout))
+			fmt.Printf("Resume watching with: pctl status %s --watch\n", clusterState.Name)
+			return nil
+		}
+		return err
+	}
+
 	return nil
 }
 
 // DeleteCluster deletes a cluster.
-func (p *Provisioner) DeleteCluster(ctx context.Context, name string) error {
+// Network resources and local state are only removed once the cluster stack
+// has finished deleting, so they are no longer in use. Without opts.Wait the
+// cleanup is left to PruneNetworks.
+func (p *Provisioner) DeleteCluster(ctx context.Context, name string, opts *DeleteOptions) error {
+	if opts == nil {
+		opts = &DeleteOptions{}
+	}
+
 	// Load cluster state
 	clusterState, err := p.stateManager.Load(name)
 	if err != nil {
 		return fmt.Errorf("failed to load cluster state: %w", err)
 	}
 
-	// Delete cluster using pcluster CLI
-	if err := p.runPClusterDelete(ctx, name, clusterState.Region); err != nil {
+	// Delete cluster using pcluster CLI (initiates async deletion)
+	if err := p.pcluster.DeleteCluster(ctx, name, clusterState.Region); err != nil {
 		return fmt.Errorf("failed to delete cluster: %w", err)
 	}
 
+	if opts.Wait || opts.ForceDelete {
+		clusterState.Status = "DELETE_IN_PROGRESS"
+		if err := p.stateManager.Save(clusterState); err != nil {
+			return fmt.Errorf("failed to update state: %w", err)
+		}
+
+		err := p.waitForStackDeletion(ctx, clusterState)
+		if err != nil && opts.ForceDelete {
+			fmt.Printf("\n⚠️  Stack deletion failed: %v\n", err)
+			fmt.Printf("🔨 Force-deleting resources that failed to delete...\n")
+
+			var retained []*ResourceStatus
+			retained, err = p.forceDeleteStack(ctx, clusterState)
+			if len(retained) > 0 {
+				fmt.Printf("\n⚠️  The following resources were retained and must be deleted manually:\n")
+				for _, res := range retained {
+					fmt.Printf("   - %s (%s) %s\n", res.LogicalID, res.Type, res.PhysicalID)
+				}
+				fmt.Println()
+			}
+		}
+		if err != nil {
+			clusterState.Status = "DELETE_FAILED"
+			p.stateManager.Save(clusterState)
+			return fmt.Errorf("cluster deletion did not complete: %w", err)
+		}
+	} else {
+		// The stack is still deleting and its instances still use the
+		// network, so leave everything in state for 'pctl network prune'
+		clusterState.Status = statusDeletePending
+		if err := p.stateManager.Save(clusterState); err != nil {
+			return fmt.Errorf("failed to update state: %w", err)
+		}
+		if clusterState.NetworkManagedByPctl || len(clusterState.PlacementGroups) > 0 {
+			fmt.Printf("⏳ Cluster stack deletion started; networking is kept until it finishes.\n")
+			fmt.Printf("   Clean up once the stack is gone with: pctl network prune\n")
+		}
+		return nil
+	}
+
+	// The persistent home is not part of the stack and outlives the cluster
+	p.releasePersistentHome(clusterState)
+
 	// Delete network resources if managed by pctl
 	if clusterState.NetworkManagedByPctl {
-		fmt.Printf("🧹 Deleting VPC and networking resources...\n")
-		netMgr, err := network.NewManager(ctx, clusterState.Region)
-		if err != nil {
-			fmt.Printf("⚠️  Warning: failed to create network manager: %v\n", err)
+		if clusterState.SharedVPC {
+			fmt.Printf("🧹 Deleting subnets and security group (keeping shared VPC %s)...\n", clusterState.VpcID)
 		} else {
-			networkResources := &network.NetworkResources{
-				VpcID:             clusterState.VpcID,
-				PublicSubnetID:    clusterState.PublicSubnetID,
-				PrivateSubnetID:   clusterState.PrivateSubnetID,
-				SecurityGroupID:   clusterState.SecurityGroupID,
-				InternetGatewayID: clusterState.InternetGatewayID,
-				RouteTableID:      clusterState.RouteTableID,
-				Region:            clusterState.Region,
-				ClusterName:       name,
-				ManagedByPctl:     true,
+			fmt.Printf("🧹 Deleting VPC and networking resources...\n")
+		}
+		if err := p.deleteNetworkWithRetry(ctx, clusterState); err != nil {
+			// Keep state with the network IDs so the VPC is not orphaned
+			clusterState.Status = statusDeleteFailedNetwork
+			if saveErr := p.stateManager.Save(clusterState); saveErr != nil {
+				return fmt.Errorf("failed to delete network resources: %w (and failed to save state: %v)", err, saveErr)
 			}
-			if err := netMgr.DeleteNetwork(ctx, networkResources); err != nil {
-				fmt.Printf("⚠️  Warning: failed to delete network resources: %v\n", err)
-			} else {
-				fmt.Printf("✅ Network resources deleted\n")
+			return fmt.Errorf("failed to delete network resources: %w\n\nThe cluster was deleted but its VPC (%s) remains. To retry cleanup:\n  pctl network prune",
+				err, clusterState.VpcID)
+		}
+		fmt.Printf("✅ Network resources deleted\n")
+	}
+
+	// Delete placement groups. The cluster's instances may still be
+	// terminating, so groups that are still in use stay in state for
+	// 'pctl network prune' instead of being forgotten.
+	if len(clusterState.PlacementGroups) > 0 {
+		if err := p.deletePlacementGroups(ctx, clusterState); err != nil {
+			clusterState.Status = statusDeleteFailedPlacementGroups
+			if saveErr := p.stateManager.Save(clusterState); saveErr != nil {
+				return fmt.Errorf("%w (and failed to save state: %v)", err, saveErr)
 			}
+			fmt.Printf("⚠️  Warning: %v\n", err)
+			fmt.Printf("   Placement groups can only be deleted once the cluster's instances have terminated.\n")
+			fmt.Printf("   Retry cleanup with: pctl network prune\n")
+			return nil
 		}
+		fmt.Printf("✅ Placement groups deleted\n")
 	}
 
 	// Remove state
@@ -275,155 +608,360 @@ func (p *Provisioner) DeleteCluster(ctx context.Context, name string) error {
 	return nil
 }
 
-// GetClusterStatus gets the status of a cluster.
-func (p *Provisioner) GetClusterStatus(ctx context.Context, name string) (*ClusterStatus, error) {
-	// Load cluster state
-	clusterState, err := p.stateManager.Load(name)
+// PruneNetworks retries network deletion for clusters left in the
+// DELETE_FAILED_NETWORK state, finishes the cleanup of clusters deleted
+// without waiting once their stack is gone, and removes their local state
+// on success. It returns the names of the clusters that were cleaned up.
+func (p *Provisioner) PruneNetworks(ctx context.Context) ([]string, error) {
+	clusters, err := p.stateManager.List()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load cluster state: %w", err)
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
 	}
 
-	// Get status from ParallelCluster
-	status, err := p.runPClusterDescribe(ctx, name, clusterState.Region)
-	if err != nil {
-		return nil, fmt.Errorf("failed to describe cluster: %w", err)
+	var pruned []string
+	var failures []string
+	for _, clusterState := range clusters {
+		if clusterState.Status != statusDeleteFailedNetwork && clusterState.Status != statusDeleteFailedPlacementGroups &&
+			clusterState.Status != statusDeletePending {
+			continue
+		}
+
+		if clusterState.Status == statusDeletePending {
+			gone, err := p.stackGone(ctx, clusterState)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", clusterState.Name, err))
+				continue
+			}
+			if !gone {
+				failures = append(failures, fmt.Sprintf("%s: cluster stack is still being deleted; retry once it is gone", clusterState.Name))
+				continue
+			}
+			p.releasePersistentHome(clusterState)
+			if !clusterState.NetworkManagedByPctl {
+				clusterState.Status = statusDeleteFailedPlacementGroups
+			} else {
+				clusterState.Status = statusDeleteFailedNetwork
+			}
+			if err := p.stateManager.Save(clusterState); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: failed to update state: %v", clusterState.Name, err))
+				continue
+			}
+		}
+
+		if clusterState.Status == statusDeleteFailedNetwork {
+			if err := p.deleteNetwork(ctx, clusterState); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", clusterState.Name, err))
+				continue
+			}
+		}
+		if err := p.deletePlacementGroups(ctx, clusterState); err != nil {
+			clusterState.Status = statusDeleteFailedPlacementGroups
+			p.stateManager.Save(clusterState)
+			failures = append(failures, fmt.Sprintf("%s: %v", clusterState.Name, err))
+			continue
+		}
+
+		if err := p.stateManager.Delete(clusterState.Name); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: failed to delete state: %v", clusterState.Name, err))
+			continue
+		}
+		pruned = append(pruned, clusterState.Name)
 	}
 
-	return status, nil
-}
+	if len(failures) > 0 {
+		return pruned, fmt.Errorf("failed to prune %d network(s):\n  - %s", len(failures), strings.Join(failures, "\n  - "))
+	}
 
-// ListClusters lists all managed clusters.
-func (p *Provisioner) ListClusters() ([]*state.ClusterState, error) {
-	return p.stateManager.List()
+	return pruned, nil
 }
 
-// DeleteLocalState deletes only the local state for a cluster without touching AWS resources.
-func (p *Provisioner) DeleteLocalState(name string) error {
-	return p.stateManager.Delete(name)
+// stackGone reports whether the cluster's stack has finished deleting.
+func (p *Provisioner) stackGone(ctx context.Context, clusterState *state.ClusterState) (bool, error) {
+	_, err := p.pcluster.DescribeCluster(ctx, clusterState.Name, clusterState.Region)
+	if err == nil {
+		return false, nil
+	}
+	if strings.Contains(err.Error(), "does not exist") {
+		return true, nil
+	}
+	return false, fmt.Errorf("failed to check cluster stack: %w", err)
 }
 
-// GetStateManager returns the state manager for direct state access.
-func (p *Provisioner) GetStateManager() (*state.Manager, error) {
-	return p.stateManager, nil
+// deleteNetworkWithRetry deletes cluster network resources, retrying with
+// backoff while resources are still in use by the departing cluster.
+func (p *Provisioner) deleteNetworkWithRetry(ctx context.Context, clusterState *state.ClusterState) error {
+	err := p.deleteNetwork(ctx, clusterState)
+	for attempt, delay := range p.networkDeleteBackoff {
+		if err == nil {
+			return nil
+		}
+
+		fmt.Printf("⚠️  Network deletion failed (attempt %d/%d): %v\n", attempt+1, len(p.networkDeleteBackoff)+1, err)
+		fmt.Printf("⏳ Retrying in %s...\n", delay)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		err = p.deleteNetwork(ctx, clusterState)
+	}
+
+	return err
 }
 
-func (p *Provisioner) writeConfigFile(name, content string) (string, error) {
-	configDir, err := config.GetConfigDir()
+// monitorStackDeletion waits for the cluster's CloudFormation stack to be deleted.
+func (p *Provisioner) monitorStackDeletion(ctx context.Context, clusterState *state.ClusterState) error {
+	monitor, err := NewProgressMonitor(ctx, clusterState.StackName, clusterState.Region, clusterState.Name)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to create progress monitor: %w", err)
 	}
 
-	path := filepath.Join(configDir, fmt.Sprintf("%s-config.yaml", name))
-	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
-		return "", err
+	// Monitor with timeout (30 minutes max)
+	monitorCtx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	if err := monitor.MonitorDeletion(monitorCtx); err != nil {
+		if monitorCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("monitoring timeout reached (30 minutes), stack is still being deleted")
+		}
+		return err
 	}
 
-	return path, nil
+	return nil
 }
 
-func (p *Provisioner) getPClusterBinary() (string, error) {
-	// Use only the private venv pcluster installation
-	homeDir, err := os.UserHomeDir()
+// deleteClusterNetwork deletes the pctl-managed network resources recorded in cluster state.
+func (p *Provisioner) deleteClusterNetwork(ctx context.Context, clusterState *state.ClusterState) error {
+	netMgr, err := network.NewManager(ctx, clusterState.Region)
 	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
+		return fmt.Errorf("failed to create network manager: %w", err)
 	}
 
-	venvPCluster := filepath.Join(homeDir, ".pctl", "venv", "bin", "pcluster")
-	if _, err := os.Stat(venvPCluster); err != nil {
-		return "", fmt.Errorf("pcluster not found in private venv (%s)\n\nThe pctl installation may be corrupted. Please reinstall pctl.", venvPCluster)
+	networkResources := &network.NetworkResources{
+		VpcID:               clusterState.VpcID,
+		PublicSubnetID:      clusterState.PublicSubnetID,
+		PrivateSubnetID:     clusterState.PrivateSubnetID,
+		PublicSubnetIDs:     clusterState.PublicSubnetIDs,
+		PrivateSubnetIDs:    clusterState.PrivateSubnetIDs,
+		SecurityGroupID:     clusterState.SecurityGroupID,
+		InternetGatewayID:   clusterState.InternetGatewayID,
+		RouteTableID:        clusterState.RouteTableID,
+		DhcpOptionsID:       clusterState.DhcpOptionsID,
+		NatGatewayID:        clusterState.NatGatewayID,
+		EIPAllocationID:     clusterState.EIPAllocationID,
+		PrivateRouteTableID: clusterState.PrivateRouteTableID,
+		S3EndpointID:        clusterState.S3EndpointID,
+		SharedVPC:           clusterState.SharedVPC,
+		Region:              clusterState.Region,
+		ClusterName:         clusterState.Name,
+		ManagedByPctl:       true,
 	}
 
-	return venvPCluster, nil
+	return netMgr.DeleteNetwork(ctx, networkResources)
 }
 
-func (p *Provisioner) runPClusterCreate(ctx context.Context, name, configPath, region string) error {
-	pclusterBin, err := p.getPClusterBinary()
+// checkCustomAMI rejects a custom AMI snapshotted from a cluster's head
+// node, and warns when one was built from a ParallelCluster version the
+// configured pcluster CLI does not support. The version check is advisory:
+// lookup failures are reported but never block cluster creation.
+func (p *Provisioner) checkCustomAMI(ctx context.Context, region, amiID string) error {
+	tags, err := p.describeAMITags(ctx, region, amiID)
 	if err != nil {
+		fmt.Printf("⚠️  Warning: could not check custom AMI %s: %v\n", amiID, err)
+		return nil
+	}
+
+	if err := headNodeSnapshotError(amiID, tags); err != nil {
 		return err
 	}
 
-	cmd := exec.CommandContext(ctx, pclusterBin, "create-cluster",
-		"--cluster-name", name,
-		"--cluster-configuration", configPath,
-		"--region", region,
-	)
+	cfg, err := config.Load()
+	if err != nil {
+		return nil
+	}
+	if warning := customAMIVersionWarning(amiID, tags, cfg.ParallelCluster.Version); warning != "" {
+		fmt.Printf("⚠️  Warning: %s\n", warning)
+	}
+	return nil
+}
+
+// checkQueueAMIs runs checkCustomAMI for each AMI a queue overrides the
+// cluster AMI with, since ParallelCluster requires every node to run the
+// same ParallelCluster version.
+func (p *Provisioner) checkQueueAMIs(ctx context.Context, tmpl *template.Template) error {
+	checked := make(map[string]bool)
+	for _, queue := range tmpl.Compute.Queues {
+		if queue.Image == nil || checked[queue.Image.CustomAMI] {
+			continue
+		}
+		checked[queue.Image.CustomAMI] = true
+		if err := p.checkCustomAMI(ctx, tmpl.Cluster.Region, queue.Image.CustomAMI); err != nil {
+			return fmt.Errorf("queue %s: %w", queue.Name, err)
+		}
+	}
+	return nil
+}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// headNodeSnapshotError returns an error if an AMI was snapshotted from a
+// cluster's head node with pctl ami build --from-running-cluster. Such an
+// AMI still holds that cluster's configuration and identity, so nodes of
+// another cluster must not boot from it.
+func headNodeSnapshotError(amiID string, tags map[string]string) error {
+	sourceCluster := tags[ami.TagSourceCluster]
+	if sourceCluster == "" {
+		return nil
+	}
+	return fmt.Errorf("custom AMI %s is a snapshot of the head node of cluster %s and holds that cluster's Slurm configuration, munge key, SSH host keys, and cloud-init state; it cannot be used as a cluster AMI\n\nBuild a reusable AMI with: pctl ami build --seed <seed>", amiID, sourceCluster)
+}
 
-	return cmd.Run()
+// customAMIVersionWarning compares the ParallelCluster version recorded on a
+// custom AMI against the pcluster CLI version. Returns "" if they are compatible.
+func customAMIVersionWarning(amiID string, tags map[string]string, cliVersion string) string {
+	amiVersion := tags[ami.TagParallelClusterVersion]
+	if amiVersion == "" {
+		// AMIs from pcluster build-image carry ParallelCluster's own tag
+		amiVersion = tags[pclusterVersionTag]
+	}
+	if amiVersion == "" {
+		return fmt.Sprintf("custom AMI %s has no %s tag; cannot confirm it matches pcluster %s", amiID, ami.TagParallelClusterVersion, cliVersion)
+	}
+	if !ami.VersionsCompatible(amiVersion, cliVersion) {
+		return fmt.Sprintf("custom AMI %s was built for ParallelCluster %s but pcluster CLI is %s\n   Head and compute nodes may fail to start. Rebuild the AMI with: pctl ami build", amiID, amiVersion, cliVersion)
+	}
+	return ""
 }
 
-// runPClusterCreateAsync initiates cluster creation and runs it in background
-// The pcluster create-cluster command is started but we don't wait for it to complete
-// The progress monitor will track the CloudFormation stack creation instead
-func (p *Provisioner) runPClusterCreateAsync(ctx context.Context, name, configPath, region string) error {
-	pclusterBin, err := p.getPClusterBinary()
+// lookupAMITags returns the tags of an AMI.
+func lookupAMITags(ctx context.Context, region, amiID string) (map[string]string, error) {
+	amiMgr, err := ami.NewManager(ctx, region)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to create AMI manager: %w", err)
+	}
+
+	metadata, err := amiMgr.GetAMI(ctx, amiID)
+	if err != nil {
+		return nil, err
 	}
 
-	cmd := exec.CommandContext(ctx, pclusterBin, "create-cluster",
-		"--cluster-name", name,
-		"--cluster-configuration", configPath,
-		"--region", region,
-	)
+	return metadata.Tags, nil
+}
 
-	// Capture stderr to log errors, but let stdout go to null
-	var stderrBuf strings.Builder
-	cmd.Stderr = &stderrBuf
-	cmd.Stdout = os.Stdout // Let stdout show pcluster initial response
+// GetClusterStatus gets the status of a cluster.
+func (p *Provisioner) GetClusterStatus(ctx context.Context, name string) (*ClusterStatus, error) {
+	// Load cluster state
+	clusterState, err := p.stateManager.Load(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cluster state: %w", err)
+	}
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start pcluster create-cluster: %w", err)
+	// Get status from ParallelCluster
+	status, err := p.pcluster.DescribeCluster(ctx, name, clusterState.Region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe cluster: %w", err)
 	}
 
-	// Launch goroutine to wait for command completion and log any errors
-	go func() {
-		if err := cmd.Wait(); err != nil {
-			if stderrBuf.Len() > 0 {
-				fmt.Fprintf(os.Stderr, "\nWarning: pcluster command error: %v\n%s\n", err, stderrBuf.String())
-			}
-		}
-	}()
+	// Keep the recorded fleet status current; failing to is not fatal
+	if status.SchedulerState != "" && status.SchedulerState != clusterState.ComputeFleetStatus {
+		clusterState.ComputeFleetStatus = status.SchedulerState
+		p.stateManager.Save(clusterState)
+	}
 
-	return nil
+	return status, nil
 }
 
-func (p *Provisioner) runPClusterDelete(ctx context.Context, name, region string) error {
-	pclusterBin, err := p.getPClusterBinary()
+// WatchClusterCreation reattaches the progress monitor to a cluster whose
+// creation is still in progress, for example after pctl create timed out or
+// was interrupted. Clusters that have already finished creating are reported
+// without monitoring.
+func (p *Provisioner) WatchClusterCreation(ctx context.Context, name string, pollInterval, timeout time.Duration) error {
+	clusterState, err := p.stateManager.Load(name)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to load cluster state: %w", err)
+	}
+	if clusterState.StackName == "" {
+		return fmt.Errorf("cluster %s has no CloudFormation stack recorded in its state", name)
 	}
 
-	cmd := exec.CommandContext(ctx, pclusterBin, "delete-cluster",
-		"--cluster-name", name,
-		"--region", region,
-	)
+	status, err := p.pcluster.DescribeCluster(ctx, name, clusterState.Region)
+	if err != nil {
+		return fmt.Errorf("failed to describe cluster: %w", err)
+	}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	switch status.Status {
+	case "CREATE_IN_PROGRESS":
+	case "CREATE_COMPLETE":
+		fmt.Printf("✅ Cluster %s has already been created\n", name)
+		return p.recordCreationStatus(clusterState, status.Status)
+	case "CREATE_FAILED":
+		if err := p.recordCreationStatus(clusterState, status.Status); err != nil {
+			return err
+		}
+		return fmt.Errorf("cluster %s creation failed\n\nTo clean up the failed stack:\n  pctl delete %s", name, name)
+	default:
+		fmt.Printf("ℹ️  Cluster %s is not being created (status: %s); nothing to watch\n", name, status.Status)
+		return nil
+	}
 
-	return cmd.Run()
-}
+	opts := &CreateOptions{PollInterval: pollInterval, MonitorTimeout: timeout}
+	if err := p.monitorCreation(ctx, clusterState, opts); err != nil {
+		p.recordCreationStatus(clusterState, "CREATE_FAILED")
+		return fmt.Errorf("cluster creation failed: %w", err)
+	}
 
-func (p *Provisioner) runPClusterDescribe(ctx context.Context, name, region string) (*ClusterStatus, error) {
-	pclusterBin, err := p.getPClusterBinary()
+	// The monitor returns without error when its timeout is reached, so
+	// record what ParallelCluster reports rather than assuming completion
+	status, err = p.pcluster.DescribeCluster(ctx, name, clusterState.Region)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to describe cluster: %w", err)
+	}
+	return p.recordCreationStatus(clusterState, status.Status)
+}
+
+// recordCreationStatus saves status to the cluster's state if it changed.
+func (p *Provisioner) recordCreationStatus(clusterState *state.ClusterState, status string) error {
+	if clusterState.Status == status {
+		return nil
+	}
+	clusterState.Status = status
+	if err := p.stateManager.Save(clusterState); err != nil {
+		return fmt.Errorf("failed to update state: %w", err)
 	}
+	return nil
+}
+
+// ListClusters lists all managed clusters.
+func (p *Provisioner) ListClusters() ([]*state.ClusterState, error) {
+	return p.stateManager.List()
+}
+
+// DeleteLocalState deletes only the local state for a cluster without touching AWS resources.
+func (p *Provisioner) DeleteLocalState(name string) error {
+	return p.stateManager.Delete(name)
+}
 
-	cmd := exec.CommandContext(ctx, pclusterBin, "describe-cluster",
-		"--cluster-name", name,
-		"--region", region,
-	)
+// GetStateManager returns the state manager for direct state access.
+func (p *Provisioner) GetStateManager() (*state.Manager, error) {
+	return p.stateManager, nil
+}
 
-	output, err := cmd.CombinedOutput()
+func (p *Provisioner) writeConfigFile(name, content string) (string, error) {
+	configDir, err := config.GetConfigDir()
 	if err != nil {
-		return nil, fmt.Errorf("pcluster describe-cluster failed: %w: %s", err, output)
+		return "", err
 	}
 
-	// Parse JSON output from pcluster describe-cluster
+	path := filepath.Join(configDir, fmt.Sprintf("%s-config.yaml", name))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// parseDescribeOutput converts pcluster describe-cluster JSON into a ClusterStatus.
+func parseDescribeOutput(name, region string, output []byte) (*ClusterStatus, error) {
 	var pcResponse pclusterDescribeResponse
 	if err := json.Unmarshal(output, &pcResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse pcluster output: %w", err)
@@ -439,28 +977,168 @@ func (p *Provisioner) runPClusterDescribe(ctx context.Context, name, region stri
 	// Extract head node info if available
 	if pcResponse.HeadNode != nil {
 		status.HeadNodeIP = pcResponse.HeadNode.PublicIPAddress
+		status.HeadNodePrivateIP = pcResponse.HeadNode.PrivateIPAddress
+		status.HeadNodeInstanceID = pcResponse.HeadNode.InstanceID
 	}
 
 	return status, nil
 }
 
+// GetHeadNodeInstanceID resolves the EC2 instance ID of a cluster's head node,
+// as needed for SSM Session Manager connections.
+func (p *Provisioner) GetHeadNodeInstanceID(ctx context.Context, name string) (string, *ClusterStatus, error) {
+	status, err := p.GetClusterStatus(ctx, name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	instanceID, err := headNodeInstanceID(status)
+	if err != nil {
+		return "", status, err
+	}
+
+	return instanceID, status, nil
+}
+
+// headNodeInstanceID returns the head node instance ID from a cluster status.
+func headNodeInstanceID(status *ClusterStatus) (string, error) {
+	if status.HeadNodeInstanceID == "" {
+		return "", fmt.Errorf("head node instance ID not available for cluster %s (status: %s)", status.Name, status.Status)
+	}
+	return status.HeadNodeInstanceID, nil
+}
+
 // CreateOptions contains options for cluster creation.
 type CreateOptions struct {
 	TemplatePath string
 	KeyName      string
 	SubnetID     string
 	CustomAMI    string
+	Tags         map[string]string
 	DryRun       bool
+	// Progress, if set, receives creation progress from the stack monitor
+	Progress *ProgressCallbacks
+	// PollInterval, if set, is how often the stack monitor checks progress
+	PollInterval time.Duration
+	// SSHCIDRs are the CIDRs allowed to SSH to a pctl-created VPC
+	// (defaults to the caller's public IP)
+	SSHCIDRs []string
+	// AZCount spreads a pctl-created VPC, and the compute queues, across
+	// this many Availability Zones
+	AZCount int
+	// EnableNAT routes a pctl-created VPC's private subnets through a NAT
+	// gateway and launches compute nodes there
+	EnableNAT bool
+	// VpcID, if set without SubnetID, is an existing VPC to create the
+	// cluster's subnets and security group in instead of a new VPC
+	VpcID string
+	// NoS3Endpoint skips the S3 gateway endpoint a pctl-created VPC gets
+	// when the template mounts S3 buckets
+	NoS3Endpoint bool
+	// MonitorTimeout, if set, is how long to follow creation before leaving
+	// the cluster to finish in the background (default 30 minutes)
+	MonitorTimeout time.Duration
+	// Overrides are the create flags already applied to the template; they
+	// are recorded in the cluster's state so updates re-apply them
+	Overrides *state.TemplateOverrides
+}
+
+// DefaultMonitorTimeout is how long cluster creation is followed by default.
+const DefaultMonitorTimeout = 30 * time.Minute
+
+// monitorTimeout returns MonitorTimeout, or DefaultMonitorTimeout if unset.
+func (o *CreateOptions) monitorTimeout() time.Duration {
+	if o.MonitorTimeout > 0 {
+		return o.MonitorTimeout
+	}
+	return DefaultMonitorTimeout
+}
+
+// networkIngressRules converts validated template ingress rules to the
+// network package's form.
+func networkIngressRules(rules []template.IngressRule) []network.IngressRule {
+	var out []network.IngressRule
+	for _, rule := range rules {
+		from, to, _ := rule.PortRange()
+		out = append(out, network.IngressRule{
+			Protocol:    rule.GetProtocol(),
+			FromPort:    int32(from),
+			ToPort:      int32(to),
+			CIDR:        rule.CIDR,
+			Description: rule.Description,
+		})
+	}
+	return out
+}
+
+// templateName derives a template name from its path (e.g., "seeds/bio.yaml" -> "bio").
+func templateName(path string) string {
+	if path == "" {
+		return ""
+	}
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// absTemplatePath makes a template path absolute so it can be found again
+// from any working directory (e.g., by ami build --from-cluster).
+func absTemplatePath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// currentOwner returns the local user name for the owner tag.
+func currentOwner() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// DeleteOptions contains options for cluster deletion.
+type DeleteOptions struct {
+	// Wait blocks until the cluster stack is deleted before cleaning up
+	// network resources and local state
+	Wait bool
+	// ForceDelete handles DELETE_FAILED by deleting the failed resources
+	// directly and retrying, retaining any that cannot be removed. Implies Wait.
+	ForceDelete bool
 }
 
 // ClusterStatus represents the status of a cluster.
 type ClusterStatus struct {
-	Name           string
-	Status         string
-	Region         string
-	HeadNodeIP     string
-	ComputeNodes   int
-	SchedulerState string
+	Name           string `json:"name"`
+	Status         string `json:"status"`
+	Region         string `json:"region"`
+	HeadNodeIP     string `json:"head_node_ip,omitempty"`
+	ComputeNodes   int    `json:"compute_nodes"`
+	SchedulerState string `json:"scheduler_state,omitempty"`
+	// HeadNodePrivateIP is the head node private IP address
+	HeadNodePrivateIP string `json:"head_node_private_ip,omitempty"`
+	// HeadNodeInstanceID is the head node EC2 instance ID
+	HeadNodeInstanceID string `json:"head_node_instance_id,omitempty"`
+}
+
+// Ready reports whether the cluster is up and usable. A failed update is
+// rolled back to the previous configuration, so the cluster remains usable.
+func (s *ClusterStatus) Ready() bool {
+	switch s.Status {
+	case "CREATE_COMPLETE", "UPDATE_COMPLETE", "UPDATE_FAILED":
+		return true
+	}
+	return false
+}
+
+// Failed reports whether the cluster's last operation left it unusable, e.g.
+// CREATE_FAILED or DELETE_FAILED. UPDATE_FAILED is not a failure here, since
+// the cluster is rolled back and remains Ready.
+func (s *ClusterStatus) Failed() bool {
+	return strings.Contains(s.Status, "FAILED") && !s.Ready()
 }
 
 // pclusterDescribeResponse represents the JSON response from pcluster describe-cluster
@@ -473,6 +1151,7 @@ type pclusterDescribeResponse struct {
 
 // pclusterHeadNode represents head node information from pcluster
 type pclusterHeadNode struct {
+	InstanceID       string `json:"instanceId"`
 	PublicIPAddress  string `json:"publicIpAddress"`
 	PrivateIPAddress string `json:"privateIpAddress"`
 	InstanceType     string `json:"instanceType"`
@@ -15,7 +15,18 @@
 package provisioner
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/scttfrdmn/petal/pkg/template"
 )
 
 func TestCreateOptions(t *testing.T) {
@@ -406,3 +417,737 @@ func TestClusterStatusSchedulerStates(t *testing.T) {
 		}
 	}
 }
+
+// newTestProvisioner creates a provisioner with state in a temporary HOME and
+// teardown steps that record the order they are called in.
+func newTestProvisioner(t *testing.T, calls *[]string) *Provisioner {
+	t.Helper()
+
+	tmpHome := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpHome)
+	t.Cleanup(func() { os.Setenv("HOME", originalHome) })
+
+	stateMgr, err := state.NewManager()
+	if err != nil {
+		t.Fatalf("failed to create state manager: %v", err)
+	}
+
+	return &Provisioner{
+		stateManager: stateMgr,
+		pcluster:     &fakePCluster{calls: calls},
+		waitForStackDeletion: func(ctx context.Context, clusterState *state.ClusterState) error {
+			*calls = append(*calls, "wait")
+			return nil
+		},
+		deleteNetwork: func(ctx context.Context, clusterState *state.ClusterState) error {
+			// State must still exist while network is torn down
+			if _, err := stateMgr.Load(clusterState.Name); err != nil {
+				t.Errorf("state removed before network deletion: %v", err)
+			}
+			*calls = append(*calls, "delete-network")
+			return nil
+		},
+		deletePlacementGroup: func(ctx context.Context, region, name string) error {
+			*calls = append(*calls, "delete-placement-group:"+name)
+			return nil
+		},
+		networkDeleteBackoff: []time.Duration{0, 0},
+	}
+}
+
+func TestDeleteClusterWaitOrdering(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:                 "test-cluster",
+		Region:               "us-east-1",
+		StackName:            "test-cluster",
+		VpcID:                "vpc-123",
+		NetworkManagedByPctl: true,
+	})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{Wait: true}); err != nil {
+		t.Fatalf("DeleteCluster() failed: %v", err)
+	}
+
+	expected := []string{"delete-stack", "wait", "delete-network"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected teardown order %v, got %v", expected, calls)
+	}
+
+	if p.stateManager.Exists("test-cluster") {
+		t.Error("State should be removed after deletion completes")
+	}
+}
+
+func TestDeleteClusterNoWait(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:                 "test-cluster",
+		Region:               "us-east-1",
+		NetworkManagedByPctl: true,
+	})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", nil); err != nil {
+		t.Fatalf("DeleteCluster() failed: %v", err)
+	}
+
+	// The stack is still deleting, so the network must be left alone
+	if !reflect.DeepEqual(calls, []string{"delete-stack"}) {
+		t.Errorf("Expected only the stack deletion, got %v", calls)
+	}
+	saved, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("State should be kept until the stack is gone: %v", err)
+	}
+	if saved.Status != statusDeletePending {
+		t.Errorf("Status = %q, want %q", saved.Status, statusDeletePending)
+	}
+
+	// Prune leaves the cluster alone while its stack still exists
+	fake := p.pcluster.(*fakePCluster)
+	fake.clusters = map[string]*ClusterStatus{"test-cluster": {Name: "test-cluster", Status: "DELETE_IN_PROGRESS"}}
+	calls = nil
+	pruned, err := p.PruneNetworks(context.Background())
+	if err == nil {
+		t.Error("PruneNetworks() should report the stack that is still deleting")
+	}
+	if len(pruned) != 0 || !reflect.DeepEqual(calls, []string{"describe-cluster"}) {
+		t.Errorf("Expected nothing pruned while the stack exists, got %v (calls: %v)", pruned, calls)
+	}
+
+	fake.clusters = nil
+	calls = nil
+	pruned, err = p.PruneNetworks(context.Background())
+	if err != nil {
+		t.Fatalf("PruneNetworks() failed: %v", err)
+	}
+	if !reflect.DeepEqual(pruned, []string{"test-cluster"}) {
+		t.Errorf("pruned = %v, want [test-cluster]", pruned)
+	}
+	expected := []string{"describe-cluster", "delete-network"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+	if p.stateManager.Exists("test-cluster") {
+		t.Error("State should be removed once the stack is gone")
+	}
+}
+
+func TestDeleteClusterWaitFailureKeepsNetwork(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+	p.waitForStackDeletion = func(ctx context.Context, clusterState *state.ClusterState) error {
+		calls = append(calls, "wait")
+		return errors.New("stack deletion failed")
+	}
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:                 "test-cluster",
+		Region:               "us-east-1",
+		VpcID:                "vpc-123",
+		NetworkManagedByPctl: true,
+	})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{Wait: true}); err == nil {
+		t.Fatal("Expected error when stack deletion fails")
+	}
+
+	expected := []string{"delete-stack", "wait"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected teardown order %v, got %v", expected, calls)
+	}
+
+	clusterState, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("State should be kept when stack deletion fails: %v", err)
+	}
+	if clusterState.Status != "DELETE_FAILED" {
+		t.Errorf("Expected status DELETE_FAILED, got %s", clusterState.Status)
+	}
+	if clusterState.VpcID != "vpc-123" {
+		t.Error("Network IDs should be retained in state")
+	}
+}
+
+func TestDeleteClusterNetworkRetry(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	attempts := 0
+	p.deleteNetwork = func(ctx context.Context, clusterState *state.ClusterState) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("DependencyViolation: security group in use")
+		}
+		return nil
+	}
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:                 "test-cluster",
+		Region:               "us-east-1",
+		NetworkManagedByPctl: true,
+	})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{Wait: true}); err != nil {
+		t.Fatalf("DeleteCluster() failed: %v", err)
+	}
+
+	if attempts != 3 {
+		t.Errorf("Expected 3 network deletion attempts, got %d", attempts)
+	}
+	if p.stateManager.Exists("test-cluster") {
+		t.Error("State should be removed once network deletion succeeds")
+	}
+}
+
+func TestDeleteClusterRetainsNetworkOnFailure(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	attempts := 0
+	p.deleteNetwork = func(ctx context.Context, clusterState *state.ClusterState) error {
+		attempts++
+		return errors.New("DependencyViolation: security group in use")
+	}
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:                 "test-cluster",
+		Region:               "us-east-1",
+		VpcID:                "vpc-123",
+		PublicSubnetID:       "subnet-pub",
+		PrivateSubnetID:      "subnet-priv",
+		SecurityGroupID:      "sg-123",
+		NetworkManagedByPctl: true,
+	})
+
+	err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{Wait: true})
+	if err == nil {
+		t.Fatal("Expected error when network deletion keeps failing")
+	}
+
+	// One initial attempt plus one per backoff step
+	if attempts != 3 {
+		t.Errorf("Expected 3 network deletion attempts, got %d", attempts)
+	}
+
+	clusterState, loadErr := p.stateManager.Load("test-cluster")
+	if loadErr != nil {
+		t.Fatalf("State should be retained when network deletion fails: %v", loadErr)
+	}
+	if clusterState.Status != "DELETE_FAILED_NETWORK" {
+		t.Errorf("Expected status DELETE_FAILED_NETWORK, got %s", clusterState.Status)
+	}
+	if clusterState.VpcID != "vpc-123" || clusterState.SecurityGroupID != "sg-123" {
+		t.Error("Network IDs should be retained in state")
+	}
+
+	// Prune finishes the job once the network can be deleted
+	p.deleteNetwork = func(ctx context.Context, clusterState *state.ClusterState) error {
+		return nil
+	}
+	pruned, err := p.PruneNetworks(context.Background())
+	if err != nil {
+		t.Fatalf("PruneNetworks() failed: %v", err)
+	}
+	if !reflect.DeepEqual(pruned, []string{"test-cluster"}) {
+		t.Errorf("Expected [test-cluster] to be pruned, got %v", pruned)
+	}
+	if p.stateManager.Exists("test-cluster") {
+		t.Error("State should be removed after prune")
+	}
+}
+
+func TestDeleteClusterKeepsPlacementGroupsInUse(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	// Instances can still be terminating when the stack reports deleted, so
+	// EC2 refuses to delete the group the first time
+	inUse := true
+	p.deletePlacementGroup = func(ctx context.Context, region, name string) error {
+		calls = append(calls, "delete-placement-group:"+name)
+		if inUse {
+			return errors.New("placement group " + name + " is in use")
+		}
+		return nil
+	}
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:            "test-cluster",
+		Region:          "us-east-1",
+		StackName:       "test-cluster",
+		PlacementGroups: []string{"pctl-test-cluster-mpi", "pctl-test-cluster-gpu"},
+	})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{Wait: true}); err != nil {
+		t.Fatalf("DeleteCluster() failed: %v", err)
+	}
+
+	saved, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("State should be kept while placement groups remain: %v", err)
+	}
+	if saved.Status != statusDeleteFailedPlacementGroups {
+		t.Errorf("Status = %q, want %q", saved.Status, statusDeleteFailedPlacementGroups)
+	}
+	if !reflect.DeepEqual(saved.PlacementGroups, []string{"pctl-test-cluster-mpi", "pctl-test-cluster-gpu"}) {
+		t.Errorf("PlacementGroups = %v, want both groups kept", saved.PlacementGroups)
+	}
+
+	inUse = false
+	calls = nil
+	pruned, err := p.PruneNetworks(context.Background())
+	if err != nil {
+		t.Fatalf("PruneNetworks() failed: %v", err)
+	}
+	if !reflect.DeepEqual(pruned, []string{"test-cluster"}) {
+		t.Errorf("pruned = %v, want [test-cluster]", pruned)
+	}
+	// The network was not pctl's, so only the placement groups are retried
+	expected := []string{"delete-placement-group:pctl-test-cluster-mpi", "delete-placement-group:pctl-test-cluster-gpu"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+	if p.stateManager.Exists("test-cluster") {
+		t.Error("State should be removed once the placement groups are gone")
+	}
+}
+
+func TestPruneNetworksSkipsOtherClusters(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	p.stateManager.Save(&state.ClusterState{Name: "running", Status: "CREATE_COMPLETE", NetworkManagedByPctl: true})
+
+	pruned, err := p.PruneNetworks(context.Background())
+	if err != nil {
+		t.Fatalf("PruneNetworks() failed: %v", err)
+	}
+	if len(pruned) != 0 || len(calls) != 0 {
+		t.Errorf("Expected no clusters to be pruned, got %v (calls: %v)", pruned, calls)
+	}
+	if !p.stateManager.Exists("running") {
+		t.Error("Unrelated cluster state should not be removed")
+	}
+}
+
+func TestTemplateName(t *testing.T) {
+	tests := map[string]string{
+		"":                         "",
+		"bio.yaml":                 "bio",
+		"seeds/bioinformatics.yml": "bioinformatics",
+		"/abs/path/ml-cluster":     "ml-cluster",
+	}
+	for path, want := range tests {
+		if got := templateName(path); got != want {
+			t.Errorf("templateName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestParseDescribeOutputHeadNode(t *testing.T) {
+	output := []byte(`{
+  "clusterName": "test-cluster",
+  "clusterStatus": "CREATE_COMPLETE",
+  "cloudFormationStackStatus": "CREATE_COMPLETE",
+  "computeFleetStatus": "RUNNING",
+  "headNode": {
+    "instanceId": "i-0123456789abcdef0",
+    "instanceType": "t3.medium",
+    "state": "running",
+    "privateIpAddress": "10.0.1.5"
+  }
+}`)
+
+	status, err := parseDescribeOutput("test-cluster", "us-east-1", output)
+	if err != nil {
+		t.Fatalf("parseDescribeOutput() failed: %v", err)
+	}
+
+	if status.HeadNodeInstanceID != "i-0123456789abcdef0" {
+		t.Errorf("Expected HeadNodeInstanceID i-0123456789abcdef0, got %s", status.HeadNodeInstanceID)
+	}
+	if status.HeadNodePrivateIP != "10.0.1.5" {
+		t.Errorf("Expected HeadNodePrivateIP 10.0.1.5, got %s", status.HeadNodePrivateIP)
+	}
+	if status.HeadNodeIP != "" {
+		t.Errorf("Expected empty HeadNodeIP for private head node, got %s", status.HeadNodeIP)
+	}
+
+	instanceID, err := headNodeInstanceID(status)
+	if err != nil {
+		t.Fatalf("headNodeInstanceID() failed: %v", err)
+	}
+	if instanceID != "i-0123456789abcdef0" {
+		t.Errorf("Expected instance ID i-0123456789abcdef0, got %s", instanceID)
+	}
+}
+
+func TestParseDescribeOutputNoHeadNode(t *testing.T) {
+	output := []byte(`{"clusterStatus": "CREATE_IN_PROGRESS", "computeFleetStatus": "UNKNOWN"}`)
+
+	status, err := parseDescribeOutput("test-cluster", "us-east-1", output)
+	if err != nil {
+		t.Fatalf("parseDescribeOutput() failed: %v", err)
+	}
+
+	if _, err := headNodeInstanceID(status); err == nil {
+		t.Error("Expected error when head node instance ID is not available")
+	}
+
+	if _, err := parseDescribeOutput("test-cluster", "us-east-1", []byte("not json")); err == nil {
+		t.Error("Expected error for invalid describe output")
+	}
+}
+
+func TestCreateOptionsMonitorTimeout(t *testing.T) {
+	if got := (&CreateOptions{}).monitorTimeout(); got != 30*time.Minute {
+		t.Errorf("monitorTimeout() default = %v, want 30m", got)
+	}
+	if got := (&CreateOptions{MonitorTimeout: 90 * time.Minute}).monitorTimeout(); got != 90*time.Minute {
+		t.Errorf("monitorTimeout() = %v, want 1h30m", got)
+	}
+}
+
+func TestClusterStatusReadyAndFailed(t *testing.T) {
+	tests := []struct {
+		status     string
+		wantReady  bool
+		wantFailed bool
+	}{
+		{"CREATE_IN_PROGRESS", false, false},
+		{"CREATE_COMPLETE", true, false},
+		{"CREATE_FAILED", false, true},
+		{"UPDATE_COMPLETE", true, false},
+		{"UPDATE_FAILED", true, false},
+		{"DELETE_FAILED", false, true},
+		{statusDeleteFailedNetwork, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			status := &ClusterStatus{Status: tt.status}
+			if got := status.Ready(); got != tt.wantReady {
+				t.Errorf("Ready() = %v, want %v", got, tt.wantReady)
+			}
+			if got := status.Failed(); got != tt.wantFailed {
+				t.Errorf("Failed() = %v, want %v", got, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestClusterStatusJSON(t *testing.T) {
+	status := &ClusterStatus{
+		Name:           "test-cluster",
+		Status:         "CREATE_COMPLETE",
+		Region:         "us-east-1",
+		HeadNodeIP:     "203.0.113.10",
+		ComputeNodes:   2,
+		SchedulerState: FleetStatusRunning,
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	expected := `{"name":"test-cluster","status":"CREATE_COMPLETE","region":"us-east-1","head_node_ip":"203.0.113.10","compute_nodes":2,"scheduler_state":"RUNNING"}`
+	if string(data) != expected {
+		t.Errorf("json.Marshal() = %s, want %s", data, expected)
+	}
+}
+
+func TestCustomAMIVersionWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        map[string]string
+		cliVersion  string
+		wantWarning bool
+	}{
+		{
+			name:       "matching version",
+			tags:       map[string]string{"ParallelClusterVersion": "3.14.0"},
+			cliVersion: "3.14.0",
+		},
+		{
+			name:       "patch difference",
+			tags:       map[string]string{"ParallelClusterVersion": "3.14.0"},
+			cliVersion: "3.14.1",
+		},
+		{
+			name:        "minor mismatch",
+			tags:        map[string]string{"ParallelClusterVersion": "3.12.0"},
+			cliVersion:  "3.14.0",
+			wantWarning: true,
+		},
+		{
+			name:       "pcluster build-image tag",
+			tags:       map[string]string{"parallelcluster:version": "3.14.0"},
+			cliVersion: "3.14.0",
+		},
+		{
+			name:        "pcluster build-image tag mismatch",
+			tags:        map[string]string{"parallelcluster:version": "3.11.1"},
+			cliVersion:  "3.14.0",
+			wantWarning: true,
+		},
+		{
+			name:        "missing tag",
+			tags:        map[string]string{"ManagedBy": "pctl"},
+			cliVersion:  "3.14.0",
+			wantWarning: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning := customAMIVersionWarning("ami-12345", tt.tags, tt.cliVersion)
+			if tt.wantWarning && warning == "" {
+				t.Error("Expected a warning, got none")
+			}
+			if !tt.wantWarning && warning != "" {
+				t.Errorf("Expected no warning, got: %s", warning)
+			}
+		})
+	}
+}
+
+func TestDeleteFailedResources(t *testing.T) {
+	at := func(minutes int) *time.Time {
+		ts := time.Date(2025, 1, 1, 12, minutes, 0, 0, time.UTC)
+		return &ts
+	}
+	event := func(logicalID, physicalID, resourceType string, status types.ResourceStatus, minutes int) types.StackEvent {
+		return types.StackEvent{
+			LogicalResourceId:  aws.String(logicalID),
+			PhysicalResourceId: aws.String(physicalID),
+			ResourceType:       aws.String(resourceType),
+			ResourceStatus:     status,
+			Timestamp:          at(minutes),
+		}
+	}
+
+	// Oldest first, as returned by getStackEvents
+	events := []types.StackEvent{
+		event("test-cluster", "arn:stack", "AWS::CloudFormation::Stack", types.ResourceStatusDeleteInProgress, 0),
+		event("HeadNode", "i-123", "AWS::EC2::Instance", types.ResourceStatusDeleteInProgress, 1),
+		event("HeadNode", "i-123", "AWS::EC2::Instance", types.ResourceStatusDeleteFailed, 2),
+		event("ComputeSG", "sg-123", "AWS::EC2::SecurityGroup", types.ResourceStatusDeleteFailed, 2),
+		event("LogGroup", "/aws/parallelcluster/test", "AWS::Logs::LogGroup", types.ResourceStatusDeleteFailed, 2),
+		event("test-cluster", "arn:stack", "AWS::CloudFormation::Stack", types.ResourceStatusDeleteFailed, 3),
+		// A later attempt removed the log group
+		event("LogGroup", "/aws/parallelcluster/test", "AWS::Logs::LogGroup", types.ResourceStatusDeleteComplete, 4),
+	}
+
+	failed := deleteFailedResources(events, "test-cluster")
+
+	if len(failed) != 2 {
+		t.Fatalf("Expected 2 failed resources, got %d", len(failed))
+	}
+	if failed[0].LogicalID != "HeadNode" || failed[0].PhysicalID != "i-123" {
+		t.Errorf("Expected HeadNode/i-123, got %s/%s", failed[0].LogicalID, failed[0].PhysicalID)
+	}
+	if failed[1].LogicalID != "ComputeSG" || failed[1].Type != "AWS::EC2::SecurityGroup" {
+		t.Errorf("Expected ComputeSG security group, got %s (%s)", failed[1].LogicalID, failed[1].Type)
+	}
+}
+
+// forceDeleteEvents is a DELETE_FAILED stack with two stuck resources.
+func forceDeleteEvents() []types.StackEvent {
+	return []types.StackEvent{
+		{
+			LogicalResourceId:  aws.String("HeadNode"),
+			PhysicalResourceId: aws.String("i-123"),
+			ResourceType:       aws.String("AWS::EC2::Instance"),
+			ResourceStatus:     types.ResourceStatusDeleteFailed,
+		},
+		{
+			LogicalResourceId:  aws.String("Bucket"),
+			PhysicalResourceId: aws.String("my-bucket"),
+			ResourceType:       aws.String("AWS::S3::Bucket"),
+			ResourceStatus:     types.ResourceStatusDeleteFailed,
+		},
+	}
+}
+
+func TestDeleteClusterForceDelete(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	waits := 0
+	p.waitForStackDeletion = func(ctx context.Context, clusterState *state.ClusterState) error {
+		waits++
+		calls = append(calls, "wait")
+		if waits == 1 {
+			return errors.New("stack deletion failed")
+		}
+		return nil
+	}
+	p.getStackEvents = func(ctx context.Context, clusterState *state.ClusterState) ([]types.StackEvent, error) {
+		return forceDeleteEvents(), nil
+	}
+	p.deleteResource = func(ctx context.Context, region string, res *ResourceStatus) error {
+		calls = append(calls, "delete-"+res.LogicalID)
+		if res.Type == "AWS::S3::Bucket" {
+			return errors.New("bucket not empty")
+		}
+		return nil
+	}
+	var retained []string
+	p.retryStackDelete = func(ctx context.Context, clusterState *state.ClusterState, retain []string) error {
+		calls = append(calls, "retry-stack")
+		retained = retain
+		return nil
+	}
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:      "test-cluster",
+		Region:    "us-east-1",
+		StackName: "test-cluster",
+	})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{ForceDelete: true}); err != nil {
+		t.Fatalf("DeleteCluster() failed: %v", err)
+	}
+
+	expected := []string{"delete-stack", "wait", "delete-HeadNode", "delete-Bucket", "retry-stack", "wait"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected steps %v, got %v", expected, calls)
+	}
+
+	if !reflect.DeepEqual(retained, []string{"Bucket"}) {
+		t.Errorf("Expected only Bucket to be retained, got %v", retained)
+	}
+
+	if p.stateManager.Exists("test-cluster") {
+		t.Error("State should be removed after forced deletion completes")
+	}
+}
+
+func TestDeleteClusterForceDeleteRetryFails(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	p.waitForStackDeletion = func(ctx context.Context, clusterState *state.ClusterState) error {
+		return errors.New("stack deletion failed")
+	}
+	p.getStackEvents = func(ctx context.Context, clusterState *state.ClusterState) ([]types.StackEvent, error) {
+		return forceDeleteEvents(), nil
+	}
+	p.deleteResource = func(ctx context.Context, region string, res *ResourceStatus) error {
+		return nil
+	}
+	p.retryStackDelete = func(ctx context.Context, clusterState *state.ClusterState, retain []string) error {
+		return nil
+	}
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:      "test-cluster",
+		Region:    "us-east-1",
+		StackName: "test-cluster",
+	})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{ForceDelete: true}); err == nil {
+		t.Fatal("Expected error when stack still fails to delete")
+	}
+
+	clusterState, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("State should be kept after failed force delete: %v", err)
+	}
+	if clusterState.Status != "DELETE_FAILED" {
+		t.Errorf("Expected status DELETE_FAILED, got %s", clusterState.Status)
+	}
+}
+
+func TestCreatePlacementGroups(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+	p.createPlacementGroup = func(ctx context.Context, region, clusterName, name string) error {
+		calls = append(calls, "create-placement-group:"+name)
+		return nil
+	}
+
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			Queues: []template.Queue{
+				{Name: "mpi", PlacementGroup: true},
+				{Name: "serial"},
+			},
+		},
+	}
+
+	groups, err := p.createPlacementGroups(context.Background(), tmpl)
+	if err != nil {
+		t.Fatalf("createPlacementGroups() failed: %v", err)
+	}
+
+	expected := map[string]string{"mpi": "pctl-test-cluster-mpi"}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Expected groups %v, got %v", expected, groups)
+	}
+	if !reflect.DeepEqual(calls, []string{"create-placement-group:pctl-test-cluster-mpi"}) {
+		t.Errorf("Expected one placement group to be created, got %v", calls)
+	}
+}
+
+func TestCreatePlacementGroupsRollback(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+	p.createPlacementGroup = func(ctx context.Context, region, clusterName, name string) error {
+		if name == "pctl-test-cluster-second" {
+			return errors.New("quota exceeded")
+		}
+		calls = append(calls, "create-placement-group:"+name)
+		return nil
+	}
+
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			Queues: []template.Queue{
+				{Name: "first", PlacementGroup: true},
+				{Name: "second", PlacementGroup: true},
+			},
+		},
+	}
+
+	if _, err := p.createPlacementGroups(context.Background(), tmpl); err == nil {
+		t.Fatal("Expected error when placement group creation fails")
+	}
+
+	expected := []string{"create-placement-group:pctl-test-cluster-first", "delete-placement-group:pctl-test-cluster-first"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected created groups to be rolled back %v, got %v", expected, calls)
+	}
+}
+
+func TestDeleteClusterPlacementGroups(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:                 "test-cluster",
+		Region:               "us-east-1",
+		StackName:            "test-cluster",
+		VpcID:                "vpc-123",
+		NetworkManagedByPctl: true,
+		PlacementGroups:      []string{"pctl-test-cluster-mpi"},
+	})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{Wait: true}); err != nil {
+		t.Fatalf("DeleteCluster() failed: %v", err)
+	}
+
+	expected := []string{"delete-stack", "wait", "delete-network", "delete-placement-group:pctl-test-cluster-mpi"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected teardown order %v, got %v", expected, calls)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PClusterClient performs ParallelCluster cluster operations. The default
+// implementation runs the pcluster CLI; tests substitute a fake.
+type PClusterClient interface {
+	// CreateCluster starts creating a cluster from a configuration file. It
+	// returns once creation has been initiated; progress is tracked through
+	// the cluster's CloudFormation stack.
+	CreateCluster(ctx context.Context, name, configPath, region string) error
+	// DescribeCluster returns the cluster's status, or an error if the
+	// cluster does not exist.
+	DescribeCluster(ctx context.Context, name, region string) (*ClusterStatus, error)
+	// DeleteCluster starts deleting a cluster.
+	DeleteCluster(ctx context.Context, name, region string) error
+	// UpdateCluster applies a new configuration file to a cluster.
+	UpdateCluster(ctx context.Context, name, configPath, region string) error
+	// UpdateComputeFleet requests a compute fleet status change, either
+	// STOP_REQUESTED or START_REQUESTED.
+	UpdateComputeFleet(ctx context.Context, name, region, status string) error
+}
+
+// execPClusterClient runs the pcluster CLI from pctl's private venv.
+type execPClusterClient struct{}
+
+// NewExecPClusterClient returns a PClusterClient that runs the pcluster CLI.
+func NewExecPClusterClient() PClusterClient {
+	return execPClusterClient{}
+}
+
+// pclusterBinary returns the path of the pcluster CLI in pctl's private venv.
+func pclusterBinary() (string, error) {
+	// Use only the private venv pcluster installation
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	venvPCluster := filepath.Join(homeDir, ".pctl", "venv", "bin", "pcluster")
+	if _, err := os.Stat(venvPCluster); err != nil {
+		return "", fmt.Errorf("pcluster not found in private venv (%s)\n\nThe pctl installation may be corrupted. Please reinstall pctl.", venvPCluster)
+	}
+
+	return venvPCluster, nil
+}
+
+// CreateCluster starts pcluster create-cluster without waiting for it to
+// finish. The progress monitor tracks the CloudFormation stack instead.
+func (execPClusterClient) CreateCluster(ctx context.Context, name, configPath, region string) error {
+	pclusterBin, err := pclusterBinary()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, pclusterBin, "create-cluster",
+		"--cluster-name", name,
+		"--cluster-configuration", configPath,
+		"--region", region,
+	)
+
+	// Capture stderr to log errors, but let stdout go to null
+	var stderrBuf strings.Builder
+	cmd.Stderr = &stderrBuf
+	cmd.Stdout = os.Stdout // Let stdout show pcluster initial response
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start pcluster create-cluster: %w", err)
+	}
+
+	// Launch goroutine to wait for command completion and log any errors
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			if stderrBuf.Len() > 0 {
+				fmt.Fprintf(os.Stderr, "\nWarning: pcluster command error: %v\n%s\n", err, stderrBuf.String())
+			}
+		}
+	}()
+
+	return nil
+}
+
+// DescribeCluster runs pcluster describe-cluster.
+func (execPClusterClient) DescribeCluster(ctx context.Context, name, region string) (*ClusterStatus, error) {
+	pclusterBin, err := pclusterBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, pclusterBin, "describe-cluster",
+		"--cluster-name", name,
+		"--region", region,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("pcluster describe-cluster failed: %w: %s", err, output)
+	}
+
+	return parseDescribeOutput(name, region, output)
+}
+
+// DeleteCluster runs pcluster delete-cluster, which initiates deletion.
+func (execPClusterClient) DeleteCluster(ctx context.Context, name, region string) error {
+	pclusterBin, err := pclusterBinary()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, pclusterBin, "delete-cluster",
+		"--cluster-name", name,
+		"--region", region,
+	)
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// UpdateCluster runs pcluster update-cluster, which initiates the update.
+func (execPClusterClient) UpdateCluster(ctx context.Context, name, configPath, region string) error {
+	pclusterBin, err := pclusterBinary()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, pclusterBin, "update-cluster",
+		"--cluster-name", name,
+		"--cluster-configuration", configPath,
+		"--region", region,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("pcluster update-cluster failed: %w: %s", err, output)
+	}
+
+	return nil
+}
+
+// UpdateComputeFleet runs pcluster update-compute-fleet, which initiates the
+// fleet status change.
+func (execPClusterClient) UpdateComputeFleet(ctx context.Context, name, region, status string) error {
+	pclusterBin, err := pclusterBinary()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, pclusterBin, "update-compute-fleet",
+		"--cluster-name", name,
+		"--status", status,
+		"--region", region,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("pcluster update-compute-fleet failed: %w: %s", err, output)
+	}
+
+	return nil
+}
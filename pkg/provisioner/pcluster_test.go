@@ -0,0 +1,540 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pcconfig "github.com/scttfrdmn/petal/pkg/config"
+	"github.com/scttfrdmn/petal/pkg/network"
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// fakePCluster is a PClusterClient that records calls instead of running
+// the pcluster CLI.
+type fakePCluster struct {
+	calls *[]string
+
+	// clusters are the clusters DescribeCluster reports as existing
+	clusters  map[string]*ClusterStatus
+	createErr error
+	// created, if set, is registered as existing by CreateCluster even when
+	// it fails, like a stack pcluster started before erroring
+	created   *ClusterStatus
+	deleteErr error
+
+	// updateErrs are returned by successive UpdateCluster calls; once they
+	// run out, updates succeed and leave the cluster in updateStatus
+	updateErrs   []error
+	updateStatus string
+
+	// config is the configuration passed to the last CreateCluster call
+	config string
+}
+
+func (f *fakePCluster) record(call string) {
+	if f.calls != nil {
+		*f.calls = append(*f.calls, call)
+	}
+}
+
+func (f *fakePCluster) CreateCluster(ctx context.Context, name, configPath, region string) error {
+	f.record("create-cluster")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("config file not readable: %w", err)
+	}
+	f.config = string(data)
+	if f.created != nil {
+		if f.clusters == nil {
+			f.clusters = make(map[string]*ClusterStatus)
+		}
+		f.clusters[name] = f.created
+	}
+	return f.createErr
+}
+
+func (f *fakePCluster) DescribeCluster(ctx context.Context, name, region string) (*ClusterStatus, error) {
+	f.record("describe-cluster")
+	if status, ok := f.clusters[name]; ok {
+		return status, nil
+	}
+	return nil, fmt.Errorf("cluster %s does not exist", name)
+}
+
+func (f *fakePCluster) DeleteCluster(ctx context.Context, name, region string) error {
+	f.record("delete-stack")
+	return f.deleteErr
+}
+
+func (f *fakePCluster) UpdateCluster(ctx context.Context, name, configPath, region string) error {
+	f.record("update-cluster")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("config file not readable: %w", err)
+	}
+	f.config = string(data)
+
+	if len(f.updateErrs) > 0 {
+		err := f.updateErrs[0]
+		f.updateErrs = f.updateErrs[1:]
+		return err
+	}
+	if status, ok := f.clusters[name]; ok {
+		status.Status = f.updateStatus
+		if status.Status == "" {
+			status.Status = "UPDATE_COMPLETE"
+		}
+	}
+	return nil
+}
+
+func (f *fakePCluster) UpdateComputeFleet(ctx context.Context, name, region, status string) error {
+	f.record("update-compute-fleet:" + status)
+	if cluster, ok := f.clusters[name]; ok {
+		switch status {
+		case fleetStopRequested:
+			cluster.SchedulerState = "STOPPED"
+		case fleetStartRequested:
+			cluster.SchedulerState = "RUNNING"
+		}
+	}
+	return nil
+}
+
+// newCreateTestProvisioner extends newTestProvisioner with creation steps
+// that record the order they are called in.
+func newCreateTestProvisioner(t *testing.T, calls *[]string) (*Provisioner, *fakePCluster) {
+	t.Helper()
+
+	p := newTestProvisioner(t, calls)
+	pcluster := &fakePCluster{calls: calls}
+	p.pcluster = pcluster
+	p.configGen = pcconfig.NewGenerator()
+	p.createNetwork = func(ctx context.Context, tmpl *template.Template, opts *CreateOptions) (*network.NetworkResources, error) {
+		*calls = append(*calls, "create-network")
+		return &network.NetworkResources{
+			VpcID:           "vpc-123",
+			PublicSubnetID:  "subnet-public",
+			PublicSubnetIDs: []string{"subnet-public"},
+			SecurityGroupID: "sg-123",
+			Region:          tmpl.Cluster.Region,
+			ClusterName:     tmpl.Cluster.Name,
+			ManagedByPctl:   true,
+		}, nil
+	}
+	p.monitorCreation = func(ctx context.Context, clusterState *state.ClusterState, opts *CreateOptions) error {
+		*calls = append(*calls, "monitor")
+		return nil
+	}
+	return p, pcluster
+}
+
+func createTestTemplate() *template.Template {
+	return &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: template.ComputeConfig{
+			HeadNode: "t3.medium",
+			Queues:   []template.Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+		},
+	}
+}
+
+func TestCreateClusterSuccess(t *testing.T) {
+	var calls []string
+	p, pcluster := newCreateTestProvisioner(t, &calls)
+
+	if err := p.CreateCluster(context.Background(), createTestTemplate(), &CreateOptions{KeyName: "my-key"}); err != nil {
+		t.Fatalf("CreateCluster() failed: %v", err)
+	}
+
+	expected := []string{"describe-cluster", "create-network", "create-cluster", "monitor"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected creation order %v, got %v", expected, calls)
+	}
+	if !strings.Contains(pcluster.config, "subnet-public") {
+		t.Errorf("Config passed to pcluster should use the created subnet:\n%s", pcluster.config)
+	}
+
+	clusterState, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("State should be saved: %v", err)
+	}
+	if clusterState.Status != "CREATE_COMPLETE" {
+		t.Errorf("Expected status CREATE_COMPLETE, got %s", clusterState.Status)
+	}
+	if clusterState.VpcID != "vpc-123" || !clusterState.NetworkManagedByPctl {
+		t.Errorf("Network resources should be recorded in state, got VPC %q managed=%v", clusterState.VpcID, clusterState.NetworkManagedByPctl)
+	}
+}
+
+func TestCreateClusterAlreadyExists(t *testing.T) {
+	tests := []struct {
+		status  string
+		wantErr string
+	}{
+		{"CREATE_COMPLETE", "already exists in AWS with status: CREATE_COMPLETE"},
+		{"CREATE_FAILED", "pctl delete test-cluster"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			var calls []string
+			p, pcluster := newCreateTestProvisioner(t, &calls)
+			pcluster.clusters = map[string]*ClusterStatus{"test-cluster": {Name: "test-cluster", Status: tt.status}}
+
+			err := p.CreateCluster(context.Background(), createTestTemplate(), &CreateOptions{KeyName: "my-key"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CreateCluster() error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(calls, []string{"describe-cluster"}) {
+				t.Errorf("Nothing should be created for an existing cluster, got %v", calls)
+			}
+			if p.stateManager.Exists("test-cluster") {
+				t.Error("No state should be saved for an existing cluster")
+			}
+		})
+	}
+}
+
+func TestCreateClusterPClusterFailure(t *testing.T) {
+	var calls []string
+	p, pcluster := newCreateTestProvisioner(t, &calls)
+	pcluster.createErr = errors.New("pcluster exited with status 1")
+
+	err := p.CreateCluster(context.Background(), createTestTemplate(), &CreateOptions{KeyName: "my-key"})
+	if err == nil || !strings.Contains(err.Error(), "failed to create cluster") {
+		t.Fatalf("CreateCluster() error = %v, want pcluster failure", err)
+	}
+
+	expected := []string{"describe-cluster", "create-network", "create-cluster", "delete-network"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected order %v, got %v", expected, calls)
+	}
+
+	clusterState, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("State should be kept after a failed create: %v", err)
+	}
+	if clusterState.Status != "CREATE_FAILED" {
+		t.Errorf("Expected status CREATE_FAILED, got %s", clusterState.Status)
+	}
+}
+
+func TestCreateClusterMonitorFailure(t *testing.T) {
+	var calls []string
+	p, _ := newCreateTestProvisioner(t, &calls)
+	p.monitorCreation = func(ctx context.Context, clusterState *state.ClusterState, opts *CreateOptions) error {
+		calls = append(calls, "monitor")
+		if clusterState.Status != "CREATE_IN_PROGRESS" {
+			t.Errorf("Expected status CREATE_IN_PROGRESS while monitoring, got %s", clusterState.Status)
+		}
+		return errors.New("cluster creation failed and rolled back")
+	}
+
+	err := p.CreateCluster(context.Background(), createTestTemplate(), &CreateOptions{KeyName: "my-key"})
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("CreateCluster() error = %v, want monitor failure", err)
+	}
+
+	expected := []string{"describe-cluster", "create-network", "create-cluster", "monitor", "delete-network"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected order %v, got %v", expected, calls)
+	}
+
+	clusterState, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("State should be kept after a failed create: %v", err)
+	}
+	if clusterState.Status != "CREATE_FAILED" {
+		t.Errorf("Expected status CREATE_FAILED, got %s", clusterState.Status)
+	}
+}
+
+func TestCreateClusterExistingSubnetSkipsNetwork(t *testing.T) {
+	var calls []string
+	p, pcluster := newCreateTestProvisioner(t, &calls)
+	p.subnetAvailabilityZone = func(ctx context.Context, region, subnetID string) (string, error) {
+		return "us-east-1a", nil
+	}
+	p.instanceTypeOfferings = func(ctx context.Context, region string, instanceTypes []string) (map[string][]string, error) {
+		return map[string][]string{"t3.medium": {"us-east-1a"}, "c5.xlarge": {"us-east-1a"}}, nil
+	}
+	pcluster.createErr = errors.New("pcluster exited with status 1")
+
+	if err := p.CreateCluster(context.Background(), createTestTemplate(), &CreateOptions{KeyName: "my-key", SubnetID: "subnet-existing"}); err == nil {
+		t.Fatal("Expected error when pcluster fails")
+	}
+
+	// A network pctl did not create is never deleted
+	expected := []string{"describe-cluster", "create-cluster"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected order %v, got %v", expected, calls)
+	}
+}
+
+func TestGetClusterStatus(t *testing.T) {
+	var calls []string
+	p, pcluster := newCreateTestProvisioner(t, &calls)
+	pcluster.clusters = map[string]*ClusterStatus{"test-cluster": {Name: "test-cluster", Status: "CREATE_COMPLETE", HeadNodeIP: "203.0.113.10"}}
+
+	if _, err := p.GetClusterStatus(context.Background(), "test-cluster"); err == nil {
+		t.Error("Expected error for a cluster without local state")
+	}
+
+	p.stateManager.Save(&state.ClusterState{Name: "test-cluster", Region: "us-east-1"})
+	status, err := p.GetClusterStatus(context.Background(), "test-cluster")
+	if err != nil {
+		t.Fatalf("GetClusterStatus() failed: %v", err)
+	}
+	if status.Status != "CREATE_COMPLETE" || status.HeadNodeIP != "203.0.113.10" {
+		t.Errorf("GetClusterStatus() = %+v", status)
+	}
+}
+
+func TestWatchClusterCreation(t *testing.T) {
+	tests := []struct {
+		name       string
+		stackName  string
+		status     string
+		monitorErr error
+		wantErr    string
+		wantCalls  []string
+		wantStatus string
+	}{
+		{
+			name:       "in progress",
+			stackName:  "test-cluster",
+			status:     "CREATE_IN_PROGRESS",
+			wantCalls:  []string{"describe-cluster", "monitor", "describe-cluster"},
+			wantStatus: "CREATE_COMPLETE",
+		},
+		{
+			name:       "already complete",
+			stackName:  "test-cluster",
+			status:     "CREATE_COMPLETE",
+			wantCalls:  []string{"describe-cluster"},
+			wantStatus: "CREATE_COMPLETE",
+		},
+		{
+			name:       "already failed",
+			stackName:  "test-cluster",
+			status:     "CREATE_FAILED",
+			wantErr:    "creation failed",
+			wantCalls:  []string{"describe-cluster"},
+			wantStatus: "CREATE_FAILED",
+		},
+		{
+			name:       "not being created",
+			stackName:  "test-cluster",
+			status:     "UPDATE_IN_PROGRESS",
+			wantCalls:  []string{"describe-cluster"},
+			wantStatus: "CREATE_IN_PROGRESS",
+		},
+		{
+			name:       "monitor failure",
+			stackName:  "test-cluster",
+			status:     "CREATE_IN_PROGRESS",
+			monitorErr: errors.New("cluster creation failed and rolled back"),
+			wantErr:    "rolled back",
+			wantCalls:  []string{"describe-cluster", "monitor"},
+			wantStatus: "CREATE_FAILED",
+		},
+		{
+			name:       "no stack in state",
+			status:     "CREATE_IN_PROGRESS",
+			wantErr:    "no CloudFormation stack",
+			wantStatus: "CREATE_IN_PROGRESS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			p, pcluster := newCreateTestProvisioner(t, &calls)
+			cluster := &ClusterStatus{Name: "test-cluster", Status: tt.status}
+			pcluster.clusters = map[string]*ClusterStatus{"test-cluster": cluster}
+			p.monitorCreation = func(ctx context.Context, clusterState *state.ClusterState, opts *CreateOptions) error {
+				calls = append(calls, "monitor")
+				if clusterState.StackName != tt.stackName || clusterState.Region != "us-east-1" {
+					t.Errorf("monitor got stack %q in %q", clusterState.StackName, clusterState.Region)
+				}
+				if opts.PollInterval != 20*time.Second || opts.MonitorTimeout != time.Hour {
+					t.Errorf("monitor got interval %v and timeout %v", opts.PollInterval, opts.MonitorTimeout)
+				}
+				if tt.monitorErr != nil {
+					return tt.monitorErr
+				}
+				cluster.Status = "CREATE_COMPLETE"
+				return nil
+			}
+			p.stateManager.Save(&state.ClusterState{
+				Name:      "test-cluster",
+				Region:    "us-east-1",
+				StackName: tt.stackName,
+				Status:    "CREATE_IN_PROGRESS",
+			})
+
+			err := p.WatchClusterCreation(context.Background(), "test-cluster", 20*time.Second, time.Hour)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("WatchClusterCreation() failed: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("WatchClusterCreation() error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("Expected calls %v, got %v", tt.wantCalls, calls)
+			}
+
+			clusterState, err := p.stateManager.Load("test-cluster")
+			if err != nil {
+				t.Fatalf("Failed to load state: %v", err)
+			}
+			if clusterState.Status != tt.wantStatus {
+				t.Errorf("Expected status %s, got %s", tt.wantStatus, clusterState.Status)
+			}
+		})
+	}
+}
+
+func TestDeleteClusterPClusterFailure(t *testing.T) {
+	var calls []string
+	p, pcluster := newCreateTestProvisioner(t, &calls)
+	pcluster.deleteErr = errors.New("pcluster exited with status 1")
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:                 "test-cluster",
+		Region:               "us-east-1",
+		VpcID:                "vpc-123",
+		NetworkManagedByPctl: true,
+	})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{Wait: true}); err == nil {
+		t.Fatal("Expected error when pcluster delete-cluster fails")
+	}
+
+	if !reflect.DeepEqual(calls, []string{"delete-stack"}) {
+		t.Errorf("Nothing should be torn down after delete-cluster fails, got %v", calls)
+	}
+	if !p.stateManager.Exists("test-cluster") {
+		t.Error("State should be kept when delete-cluster fails")
+	}
+}
+
+func TestCreateClusterQueueImages(t *testing.T) {
+	var calls []string
+	p, pcluster := newCreateTestProvisioner(t, &calls)
+	var checked []string
+	p.describeAMITags = func(ctx context.Context, region, amiID string) (map[string]string, error) {
+		checked = append(checked, amiID)
+		return map[string]string{"ParallelClusterVersion": "3.14.0"}, nil
+	}
+
+	tmpl := createTestTemplate()
+	gpuImage := &template.QueueImage{CustomAMI: "ami-0fedcba9876543210"}
+	tmpl.Compute.Queues = append(tmpl.Compute.Queues,
+		template.Queue{Name: "gpu", InstanceTypes: []string{"g5.xlarge"}, MaxCount: 4, Image: gpuImage},
+		template.Queue{Name: "gpu-large", InstanceTypes: []string{"g5.12xlarge"}, MaxCount: 2, Image: gpuImage},
+	)
+
+	if err := p.CreateCluster(context.Background(), tmpl, &CreateOptions{KeyName: "my-key"}); err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+
+	// Queues sharing an AMI check its version once
+	if !reflect.DeepEqual(checked, []string{"ami-0fedcba9876543210"}) {
+		t.Errorf("Expected queue AMI version checked once, got %v", checked)
+	}
+	if strings.Count(pcluster.config, "CustomAmi: ami-0fedcba9876543210") != 2 {
+		t.Errorf("config should set the AMI on both gpu queues:\n%s", pcluster.config)
+	}
+}
+
+func TestCreateClusterRejectsHeadNodeSnapshot(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(tmpl *template.Template, opts *CreateOptions)
+	}{
+		{"cluster AMI", func(tmpl *template.Template, opts *CreateOptions) {
+			opts.CustomAMI = "ami-0123456789abcdef0"
+		}},
+		{"queue AMI", func(tmpl *template.Template, opts *CreateOptions) {
+			tmpl.Compute.Queues[0].Image = &template.QueueImage{CustomAMI: "ami-0123456789abcdef0"}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			p, _ := newCreateTestProvisioner(t, &calls)
+			p.describeAMITags = func(ctx context.Context, region, amiID string) (map[string]string, error) {
+				return map[string]string{"pctl:source-cluster": "genomics", "ParallelClusterVersion": "3.14.0"}, nil
+			}
+
+			tmpl := createTestTemplate()
+			opts := &CreateOptions{KeyName: "my-key"}
+			tt.modify(tmpl, opts)
+			err := p.CreateCluster(context.Background(), tmpl, opts)
+			if err == nil || !strings.Contains(err.Error(), "snapshot of the head node of cluster genomics") {
+				t.Fatalf("Expected head node snapshot error, got: %v", err)
+			}
+			for _, call := range calls {
+				if call == "create-cluster" {
+					t.Errorf("Expected no cluster to be created, got %v", calls)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateClusterCustomAMILocalAccounting(t *testing.T) {
+	var calls []string
+	p, pcluster := newCreateTestProvisioner(t, &calls)
+	p.describeAMITags = func(ctx context.Context, region, amiID string) (map[string]string, error) {
+		return nil, nil
+	}
+	var script string
+	p.uploadBootstrapScript = func(ctx context.Context, region, clusterName, content string) (string, error) {
+		script = content
+		return "s3://bootstrap/test-cluster/install-software.sh", nil
+	}
+
+	tmpl := createTestTemplate()
+	tmpl.Software.SpackPackages = []string{"samtools@1.17"}
+	tmpl.Scheduler.Accounting = &template.AccountingConfig{Enabled: true}
+
+	if err := p.CreateCluster(context.Background(), tmpl, &CreateOptions{KeyName: "my-key", CustomAMI: "ami-custom"}); err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+
+	// The custom AMI has the software; the bootstrap only sets up accounting
+	if !strings.Contains(script, "systemctl enable --now slurmdbd") {
+		t.Error("bootstrap script should set up slurmdbd on the head node")
+	}
+	if strings.Contains(script, "samtools") {
+		t.Error("bootstrap script should not reinstall software baked into the custom AMI")
+	}
+	if !strings.Contains(pcluster.config, "s3://bootstrap/test-cluster/install-software.sh") {
+		t.Errorf("config should run the bootstrap script:\n%s", pcluster.config)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// persistentHomeTag marks EBS volumes created by pctl for a persistent home,
+// with the logical name as its value.
+const persistentHomeTag = "pctl:persistent-home"
+
+// volumeAvailableTimeout bounds the wait for a new EBS volume to be usable.
+const volumeAvailableTimeout = 5 * time.Minute
+
+// preparePersistentHome resolves the volume backing the template's persistent
+// /home and marks it as mounted by the cluster. A volume already recorded
+// under the logical name is reused; otherwise the template's ID is adopted or,
+// for EBS, a new volume is created in the availability zone of subnetID and
+// tagged with clusterTags. It returns nil if the template has no persistent
+// home.
+func (p *Provisioner) preparePersistentHome(ctx context.Context, tmpl *template.Template, subnetID string, clusterTags map[string]string) (*state.PersistentVolume, error) {
+	home := tmpl.Data.PersistentHome
+	if home == nil {
+		return nil, nil
+	}
+	region := tmpl.Cluster.Region
+
+	volume, err := p.stateManager.LoadVolume(home.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load persistent home %s: %w", home.Name, err)
+	}
+
+	var zone string
+	if home.GetType() == template.StorageTypeEBS {
+		// EBS volumes can only attach to instances in their own zone
+		zone, err = p.subnetAvailabilityZone(ctx, region, subnetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up availability zone of subnet %s: %w", subnetID, err)
+		}
+	}
+
+	if volume != nil {
+		if err := checkPersistentVolume(volume, home, tmpl.Cluster.Name, region, zone); err != nil {
+			return nil, err
+		}
+		fmt.Printf("💾 Reattaching persistent home %s: %s\n", volume.Name, volume.ID)
+	} else {
+		volume = &state.PersistentVolume{
+			Name:   home.Name,
+			Type:   home.GetType(),
+			ID:     home.ID,
+			Region: region,
+		}
+		if volume.Type == template.StorageTypeEBS {
+			volume.AvailabilityZone = zone
+			if volume.ID == "" {
+				fmt.Printf("💾 Creating %d GiB persistent home volume %s in %s...\n", home.GetSize(), home.Name, zone)
+				volume.ID, err = p.createHomeVolume(ctx, region, zone, home.Name, home.GetSize(), homeVolumeTags(home.Name, clusterTags))
+				volume.CreatedByPctl = true
+				if err != nil {
+					if volume.ID != "" {
+						// Record the volume so a retry reuses it instead of creating another
+						p.stateManager.SaveVolume(volume)
+					}
+					return nil, fmt.Errorf("failed to create persistent home volume: %w", err)
+				}
+			} else {
+				volumeZone, err := p.volumeAvailabilityZone(ctx, region, volume.ID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to look up persistent home volume %s: %w", volume.ID, err)
+				}
+				if volumeZone != zone {
+					return nil, fmt.Errorf("persistent home volume %s is in %s but subnet %s is in %s; use a subnet in %s",
+						volume.ID, volumeZone, subnetID, zone, volumeZone)
+				}
+			}
+		}
+		fmt.Printf("✅ Persistent home %s: %s\n", volume.Name, volume.ID)
+	}
+
+	volume.Cluster = tmpl.Cluster.Name
+	if err := p.stateManager.SaveVolume(volume); err != nil {
+		return nil, fmt.Errorf("failed to record persistent home %s: %w", volume.Name, err)
+	}
+	return volume, nil
+}
+
+// checkPersistentVolume reports whether a recorded volume can be mounted by
+// the cluster being created.
+func checkPersistentVolume(volume *state.PersistentVolume, home *template.PersistentHome, clusterName, region, zone string) error {
+	if volume.Cluster != "" && volume.Cluster != clusterName {
+		return fmt.Errorf("persistent home %s (%s) is still mounted by cluster %s; delete that cluster first", volume.Name, volume.ID, volume.Cluster)
+	}
+	if volume.Type != home.GetType() {
+		return fmt.Errorf("persistent home %s is recorded as %s, not %s", volume.Name, volume.Type, home.GetType())
+	}
+	if home.ID != "" && home.ID != volume.ID {
+		return fmt.Errorf("persistent home %s is recorded as %s, but the template specifies %s", volume.Name, volume.ID, home.ID)
+	}
+	if volume.Region != region {
+		return fmt.Errorf("persistent home %s (%s) is in %s, not %s", volume.Name, volume.ID, volume.Region, region)
+	}
+	if volume.AvailabilityZone != "" && volume.AvailabilityZone != zone {
+		return fmt.Errorf("persistent home %s (%s) is in %s but the cluster subnet is in %s; use a subnet in %s",
+			volume.Name, volume.ID, volume.AvailabilityZone, zone, volume.AvailabilityZone)
+	}
+	return nil
+}
+
+// releasePersistentHome records that a deleted cluster no longer mounts its
+// persistent home. The volume itself is never deleted.
+func (p *Provisioner) releasePersistentHome(clusterState *state.ClusterState) {
+	if clusterState.PersistentHome == "" {
+		return
+	}
+
+	if err := p.stateManager.ReleaseVolume(clusterState.PersistentHome, clusterState.Name); err != nil {
+		fmt.Printf("⚠️  Warning: failed to release persistent home %s: %v\n", clusterState.PersistentHome, err)
+		return
+	}
+	fmt.Printf("💾 Persistent home %s retained; it will be reattached by the next cluster using it\n", clusterState.PersistentHome)
+}
+
+// releasePersistentHomeAfterFailedCreate releases the persistent home of a
+// cluster whose creation could not be initiated. pcluster can fail after the
+// stack was created, so the claim is kept, and released by pctl delete,
+// unless the stack is confirmed not to exist.
+func (p *Provisioner) releasePersistentHomeAfterFailedCreate(ctx context.Context, clusterState *state.ClusterState) {
+	if clusterState.PersistentHome == "" {
+		return
+	}
+
+	gone, err := p.stackGone(ctx, clusterState)
+	if err != nil || !gone {
+		fmt.Printf("⚠️  Persistent home %s stays claimed by %s until the cluster is deleted: pctl delete %s\n",
+			clusterState.PersistentHome, clusterState.Name, clusterState.Name)
+		return
+	}
+	p.releasePersistentHome(clusterState)
+}
+
+// persistentHomeID returns the volume ID of the cluster's persistent home,
+// or "" if it has none.
+func (p *Provisioner) persistentHomeID(clusterState *state.ClusterState) string {
+	if clusterState.PersistentHome == "" {
+		return ""
+	}
+	volume, err := p.stateManager.LoadVolume(clusterState.PersistentHome)
+	if err != nil || volume == nil {
+		return ""
+	}
+	return volume.ID
+}
+
+// homeVolumeTags returns the tags for a persistent home volume: the
+// cluster's tags, so cost tools attribute the volume like the cluster's
+// other storage, and the tags pctl identifies the volume by, which take
+// precedence.
+func homeVolumeTags(name string, clusterTags map[string]string) map[string]string {
+	tags := make(map[string]string, len(clusterTags)+3)
+	for key, value := range clusterTags {
+		tags[key] = value
+	}
+	tags["Name"] = "pctl-home-" + name
+	tags["ManagedBy"] = "pctl"
+	tags[persistentHomeTag] = name
+	return tags
+}
+
+// createEC2HomeVolume creates a gp3 EBS volume for a persistent home and
+// waits for it to become available.
+func createEC2HomeVolume(ctx context.Context, region, zone, name string, size int, tags map[string]string) (string, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := ec2.NewFromConfig(cfg)
+
+	result, err := client.CreateVolume(ctx, &ec2.CreateVolumeInput{
+		AvailabilityZone: aws.String(zone),
+		Size:             aws.Int32(int32(size)),
+		VolumeType:       ec2types.VolumeTypeGp3,
+		Encrypted:        aws.Bool(true),
+		TagSpecifications: []ec2types.TagSpecification{
+			{
+				ResourceType: ec2types.ResourceTypeVolume,
+				Tags:         ec2Tags(tags),
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	volumeID := aws.ToString(result.VolumeId)
+
+	waiter := ec2.NewVolumeAvailableWaiter(client)
+	if err := waiter.Wait(ctx, &ec2.DescribeVolumesInput{VolumeIds: []string{volumeID}}, volumeAvailableTimeout); err != nil {
+		return volumeID, fmt.Errorf("volume %s did not become available: %w", volumeID, err)
+	}
+	return volumeID, nil
+}
+
+// describeVolumeAvailabilityZone returns the availability zone of an EBS volume.
+func describeVolumeAvailabilityZone(ctx context.Context, region, volumeID string) (string, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	result, err := ec2.NewFromConfig(cfg).DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		VolumeIds: []string{volumeID},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(result.Volumes) == 0 {
+		return "", fmt.Errorf("volume %s not found", volumeID)
+	}
+	return aws.ToString(result.Volumes[0].AvailabilityZone), nil
+}
@@ -0,0 +1,341 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	pcconfig "github.com/scttfrdmn/petal/pkg/config"
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func persistentHomeTemplate(clusterName string) *template.Template {
+	return &template.Template{
+		Cluster: template.ClusterConfig{Name: clusterName, Region: "us-east-1"},
+		Data: template.DataConfig{
+			PersistentHome: &template.PersistentHome{Name: "lab-home", Size: 200},
+		},
+	}
+}
+
+func newPersistentHomeProvisioner(t *testing.T, calls *[]string, zone string) *Provisioner {
+	p := newTestProvisioner(t, calls)
+	p.subnetAvailabilityZone = func(ctx context.Context, region, subnetID string) (string, error) {
+		return zone, nil
+	}
+	p.createHomeVolume = func(ctx context.Context, region, zone, name string, size int, tags map[string]string) (string, error) {
+		*calls = append(*calls, "create-volume")
+		return "vol-0123", nil
+	}
+	p.volumeAvailabilityZone = func(ctx context.Context, region, volumeID string) (string, error) {
+		return "us-east-1a", nil
+	}
+	return p
+}
+
+func TestPreparePersistentHomeCreatesOnce(t *testing.T) {
+	var calls []string
+	p := newPersistentHomeProvisioner(t, &calls, "us-east-1a")
+
+	volume, err := p.preparePersistentHome(context.Background(), persistentHomeTemplate("first"), "subnet-a", nil)
+	if err != nil {
+		t.Fatalf("preparePersistentHome() failed: %v", err)
+	}
+	if volume.ID != "vol-0123" || volume.AvailabilityZone != "us-east-1a" || volume.Cluster != "first" || !volume.CreatedByPctl {
+		t.Errorf("unexpected volume: %+v", volume)
+	}
+
+	// Once released, a recreated cluster reattaches the same volume
+	if err := p.stateManager.ReleaseVolume("lab-home", "first"); err != nil {
+		t.Fatalf("ReleaseVolume() failed: %v", err)
+	}
+	volume, err = p.preparePersistentHome(context.Background(), persistentHomeTemplate("second"), "subnet-a", nil)
+	if err != nil {
+		t.Fatalf("preparePersistentHome() failed: %v", err)
+	}
+	if volume.ID != "vol-0123" || volume.Cluster != "second" {
+		t.Errorf("unexpected volume: %+v", volume)
+	}
+
+	if !reflect.DeepEqual(calls, []string{"create-volume"}) {
+		t.Errorf("Expected a single volume creation, got %v", calls)
+	}
+}
+
+func TestPreparePersistentHomeVolumeTags(t *testing.T) {
+	var calls []string
+	p := newPersistentHomeProvisioner(t, &calls, "us-east-1a")
+	var volumeTags map[string]string
+	p.createHomeVolume = func(ctx context.Context, region, zone, name string, size int, tags map[string]string) (string, error) {
+		volumeTags = tags
+		return "vol-0123", nil
+	}
+
+	tmpl := persistentHomeTemplate("first")
+	tmpl.Cluster.Tags = map[string]string{"CostCenter": "genomics", "ManagedBy": "someone-else"}
+	gen := pcconfig.NewGenerator()
+	gen.Tags = map[string]string{"Project": "rna-seq", "CostCenter": "oncology"}
+	if _, err := p.preparePersistentHome(context.Background(), tmpl, "subnet-a", gen.ClusterTags(tmpl)); err != nil {
+		t.Fatalf("preparePersistentHome() failed: %v", err)
+	}
+
+	// The volume carries the cluster's tags, --tags over the seed's; pctl's
+	// own tags win
+	want := map[string]string{
+		"Name":            "pctl-home-lab-home",
+		"ManagedBy":       "pctl",
+		persistentHomeTag: "lab-home",
+		"CostCenter":      "oncology",
+		"Project":         "rna-seq",
+		"pctl:template":   "first",
+	}
+	for key, value := range want {
+		if volumeTags[key] != value {
+			t.Errorf("volume tag %s = %q, want %q", key, volumeTags[key], value)
+		}
+	}
+}
+
+func TestPreparePersistentHomeAdoptsExisting(t *testing.T) {
+	var calls []string
+	p := newPersistentHomeProvisioner(t, &calls, "us-east-1b")
+
+	tmpl := persistentHomeTemplate("test-cluster")
+	tmpl.Data.PersistentHome.ID = "vol-0abc"
+
+	_, err := p.preparePersistentHome(context.Background(), tmpl, "subnet-b", nil)
+	if err == nil || !strings.Contains(err.Error(), "vol-0abc is in us-east-1a but subnet subnet-b is in us-east-1b") {
+		t.Errorf("Expected availability zone error, got %v", err)
+	}
+
+	p.subnetAvailabilityZone = func(ctx context.Context, region, subnetID string) (string, error) {
+		return "us-east-1a", nil
+	}
+	volume, err := p.preparePersistentHome(context.Background(), tmpl, "subnet-a", nil)
+	if err != nil {
+		t.Fatalf("preparePersistentHome() failed: %v", err)
+	}
+	if volume.ID != "vol-0abc" || volume.CreatedByPctl {
+		t.Errorf("unexpected volume: %+v", volume)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no volume creation, got %v", calls)
+	}
+}
+
+func TestPreparePersistentHomeConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		volume  state.PersistentVolume
+		zone    string
+		wantErr string
+	}{
+		{
+			name:    "mounted by another cluster",
+			volume:  state.PersistentVolume{Type: "ebs", ID: "vol-0123", Region: "us-east-1", AvailabilityZone: "us-east-1a", Cluster: "other"},
+			zone:    "us-east-1a",
+			wantErr: "still mounted by cluster other",
+		},
+		{
+			name:    "different availability zone",
+			volume:  state.PersistentVolume{Type: "ebs", ID: "vol-0123", Region: "us-east-1", AvailabilityZone: "us-east-1a"},
+			zone:    "us-east-1c",
+			wantErr: "use a subnet in us-east-1a",
+		},
+		{
+			name:    "different region",
+			volume:  state.PersistentVolume{Type: "ebs", ID: "vol-0123", Region: "us-west-2", AvailabilityZone: "us-west-2a"},
+			zone:    "us-east-1a",
+			wantErr: "is in us-west-2, not us-east-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			p := newPersistentHomeProvisioner(t, &calls, tt.zone)
+
+			volume := tt.volume
+			volume.Name = "lab-home"
+			if err := p.stateManager.SaveVolume(&volume); err != nil {
+				t.Fatalf("SaveVolume() failed: %v", err)
+			}
+
+			_, err := p.preparePersistentHome(context.Background(), persistentHomeTemplate("test-cluster"), "subnet-a", nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if len(calls) != 0 {
+				t.Errorf("Expected no volume creation, got %v", calls)
+			}
+		})
+	}
+}
+
+func TestDeleteClusterKeepsPersistentHome(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:           "test-cluster",
+		Region:         "us-east-1",
+		StackName:      "test-cluster",
+		PersistentHome: "lab-home",
+	})
+	p.stateManager.SaveVolume(&state.PersistentVolume{
+		Name:    "lab-home",
+		Type:    "ebs",
+		ID:      "vol-0123",
+		Region:  "us-east-1",
+		Cluster: "test-cluster",
+	})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{Wait: true}); err != nil {
+		t.Fatalf("DeleteCluster() failed: %v", err)
+	}
+
+	if p.stateManager.Exists("test-cluster") {
+		t.Error("Cluster state should be removed")
+	}
+
+	volume, err := p.stateManager.LoadVolume("lab-home")
+	if err != nil || volume == nil {
+		t.Fatalf("Persistent home record should be kept, got %v, %v", volume, err)
+	}
+	if volume.ID != "vol-0123" {
+		t.Errorf("Expected volume vol-0123, got %s", volume.ID)
+	}
+	if volume.Cluster != "" {
+		t.Errorf("Volume should be released, still mounted by %s", volume.Cluster)
+	}
+}
+
+func TestDeleteClusterWithoutPersistentHome(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	// A volume mounted by another cluster is left alone
+	p.stateManager.Save(&state.ClusterState{Name: "test-cluster", Region: "us-east-1", StackName: "test-cluster"})
+	p.stateManager.SaveVolume(&state.PersistentVolume{Name: "lab-home", ID: "vol-0123", Cluster: "other"})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{Wait: true}); err != nil {
+		t.Fatalf("DeleteCluster() failed: %v", err)
+	}
+
+	volume, _ := p.stateManager.LoadVolume("lab-home")
+	if volume == nil || volume.Cluster != "other" {
+		t.Errorf("Volume of another cluster should be untouched, got %+v", volume)
+	}
+}
+
+func TestForceDeleteKeepsPersistentHome(t *testing.T) {
+	var calls []string
+	p := newTestProvisioner(t, &calls)
+
+	waits := 0
+	p.waitForStackDeletion = func(ctx context.Context, clusterState *state.ClusterState) error {
+		waits++
+		if waits == 1 {
+			return errors.New("stack deletion failed")
+		}
+		return nil
+	}
+	p.getStackEvents = func(ctx context.Context, clusterState *state.ClusterState) ([]types.StackEvent, error) {
+		return append(forceDeleteEvents(), types.StackEvent{
+			LogicalResourceId:  aws.String("PersistentHome"),
+			PhysicalResourceId: aws.String("vol-0123"),
+			ResourceType:       aws.String("AWS::EC2::Volume"),
+			ResourceStatus:     types.ResourceStatusDeleteFailed,
+		}), nil
+	}
+	var deleted []string
+	p.deleteResource = func(ctx context.Context, region string, res *ResourceStatus) error {
+		deleted = append(deleted, res.LogicalID)
+		return nil
+	}
+	var retained []string
+	p.retryStackDelete = func(ctx context.Context, clusterState *state.ClusterState, retain []string) error {
+		retained = retain
+		return nil
+	}
+
+	p.stateManager.Save(&state.ClusterState{
+		Name:           "test-cluster",
+		Region:         "us-east-1",
+		StackName:      "test-cluster",
+		PersistentHome: "lab-home",
+	})
+	p.stateManager.SaveVolume(&state.PersistentVolume{Name: "lab-home", ID: "vol-0123", Cluster: "test-cluster"})
+
+	if err := p.DeleteCluster(context.Background(), "test-cluster", &DeleteOptions{ForceDelete: true}); err != nil {
+		t.Fatalf("DeleteCluster() failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(deleted, []string{"HeadNode", "Bucket"}) {
+		t.Errorf("Expected HeadNode and Bucket to be deleted, got %v", deleted)
+	}
+	if !reflect.DeepEqual(retained, []string{"PersistentHome"}) {
+		t.Errorf("Expected PersistentHome to be retained, got %v", retained)
+	}
+}
+
+func TestCreateClusterFailureKeepsPersistentHomeClaim(t *testing.T) {
+	tests := []struct {
+		name        string
+		stackExists bool
+		wantCluster string
+	}{
+		{name: "stack never created", stackExists: false, wantCluster: ""},
+		{name: "stack left behind", stackExists: true, wantCluster: "test-cluster"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			p, pcluster := newCreateTestProvisioner(t, &calls)
+			p.subnetAvailabilityZone = func(ctx context.Context, region, subnetID string) (string, error) {
+				return "us-east-1a", nil
+			}
+			p.createHomeVolume = func(ctx context.Context, region, zone, name string, size int, tags map[string]string) (string, error) {
+				return "vol-0123", nil
+			}
+			pcluster.createErr = errors.New("pcluster exited with status 1")
+			if tt.stackExists {
+				pcluster.created = &ClusterStatus{Status: "CREATE_IN_PROGRESS"}
+			}
+
+			tmpl := createTestTemplate()
+			tmpl.Data.PersistentHome = &template.PersistentHome{Name: "lab-home", Size: 200}
+			if err := p.CreateCluster(context.Background(), tmpl, &CreateOptions{KeyName: "my-key"}); err == nil {
+				t.Fatal("CreateCluster() should fail")
+			}
+
+			volume, err := p.stateManager.LoadVolume("lab-home")
+			if err != nil || volume == nil {
+				t.Fatalf("Persistent home record should be kept: %v", err)
+			}
+			if volume.Cluster != tt.wantCluster {
+				t.Errorf("Expected persistent home claimed by %q, got %q", tt.wantCluster, volume.Cluster)
+			}
+		})
+	}
+}
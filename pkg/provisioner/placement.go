@@ -0,0 +1,114 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// placementGroupName returns the name of the placement group pctl creates for
+// a cluster queue.
+func placementGroupName(clusterName, queueName string) string {
+	return fmt.Sprintf("pctl-%s-%s", clusterName, queueName)
+}
+
+// createPlacementGroups creates a cluster placement group for each queue that
+// requests one. It returns the group names keyed by queue name. If any group
+// fails to be created, the ones already created are deleted.
+func (p *Provisioner) createPlacementGroups(ctx context.Context, tmpl *template.Template) (map[string]string, error) {
+	groups := make(map[string]string)
+	for _, queue := range tmpl.Compute.Queues {
+		if !queue.PlacementGroup {
+			continue
+		}
+
+		name := placementGroupName(tmpl.Cluster.Name, queue.Name)
+		if err := p.createPlacementGroup(ctx, tmpl.Cluster.Region, tmpl.Cluster.Name, name); err != nil {
+			for _, created := range groups {
+				p.deletePlacementGroup(ctx, tmpl.Cluster.Region, created)
+			}
+			return nil, fmt.Errorf("failed to create placement group %s: %w", name, err)
+		}
+		groups[queue.Name] = name
+	}
+
+	return groups, nil
+}
+
+// deletePlacementGroups deletes the placement groups recorded in cluster state.
+// Groups can only be deleted once the cluster's instances have terminated;
+// those that could not be deleted are left in clusterState.PlacementGroups.
+func (p *Provisioner) deletePlacementGroups(ctx context.Context, clusterState *state.ClusterState) error {
+	var failures []string
+	var remaining []string
+	for _, name := range clusterState.PlacementGroups {
+		if err := p.deletePlacementGroup(ctx, clusterState.Region, name); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			remaining = append(remaining, name)
+		}
+	}
+	clusterState.PlacementGroups = remaining
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to delete %d placement group(s):\n  - %s", len(failures), strings.Join(failures, "\n  - "))
+	}
+	return nil
+}
+
+// createEC2PlacementGroup creates a tagged EC2 cluster placement group.
+func createEC2PlacementGroup(ctx context.Context, region, clusterName, name string) error {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	_, err = ec2.NewFromConfig(cfg).CreatePlacementGroup(ctx, &ec2.CreatePlacementGroupInput{
+		GroupName: aws.String(name),
+		Strategy:  ec2types.PlacementStrategyCluster,
+		TagSpecifications: []ec2types.TagSpecification{
+			{
+				ResourceType: ec2types.ResourceTypePlacementGroup,
+				Tags: []ec2types.Tag{
+					{Key: aws.String("Name"), Value: aws.String(name)},
+					{Key: aws.String("ManagedBy"), Value: aws.String("pctl")},
+					{Key: aws.String("ClusterName"), Value: aws.String(clusterName)},
+				},
+			},
+		},
+	})
+	return err
+}
+
+// deleteEC2PlacementGroup deletes an EC2 placement group.
+func deleteEC2PlacementGroup(ctx context.Context, region, name string) error {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	_, err = ec2.NewFromConfig(cfg).DeletePlacementGroup(ctx, &ec2.DeletePlacementGroupInput{
+		GroupName: aws.String(name),
+	})
+	return err
+}
@@ -18,7 +18,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,21 +29,68 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	logtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/schollz/progressbar/v3"
 )
 
+// Progress phases reported to ProgressMonitor.OnPhaseChange.
+const (
+	// PhaseInfrastructure is CloudFormation stack creation (0-70%)
+	PhaseInfrastructure = "infrastructure"
+	// PhaseConfiguration is head node and scheduler initialization (70-100%)
+	PhaseConfiguration = "configuration"
+	// PhaseComplete means the cluster is ready (100%)
+	PhaseComplete = "complete"
+	// PhaseRollback means stack creation failed and is being rolled back
+	PhaseRollback = "rollback"
+	// PhaseFailed means cluster creation failed
+	PhaseFailed = "failed"
+)
+
 // ProgressMonitor monitors cluster creation progress via CloudFormation events
 type ProgressMonitor struct {
-	cfnClient   *cloudformation.Client
+	// OnEvent, if set, is called with each new CloudFormation resource event
+	OnEvent func(ResourceStatus)
+	// OnPhaseChange, if set, is called when the phase or its progress
+	// percentage changes
+	OnPhaseChange func(phase string, pct int)
+
+	cfnClient   cloudFormationAPI
+	logsClient  cloudWatchLogsAPI
 	stackName   string
 	region      string
 	clusterName string
 	startTime   time.Time
+	renderer    *progressRenderer
+	out         io.Writer
+
+	// pollInterval overrides each phase's default polling interval
+	pollInterval time.Duration
+
+	// Last reported phase, to only report changes
+	phase    string
+	phasePct int
+
+	// events caches the stack's events so each poll only fetches new ones
+	events stackEventCache
+}
+
+// ProgressCallbacks let programs embedding pctl render creation progress
+// themselves.
+type ProgressCallbacks struct {
+	// OnEvent is called with each new CloudFormation resource event
+	OnEvent func(ResourceStatus)
+	// OnPhaseChange is called when the phase or its progress percentage changes
+	OnPhaseChange func(phase string, pct int)
+	// Quiet disables the built-in progress output
+	Quiet bool
 }
 
 // ResourceStatus tracks the status of a CloudFormation resource
 type ResourceStatus struct {
 	LogicalID  string
+	PhysicalID string
 	Type       string
 	Status     types.ResourceStatus
 	StatusText string
@@ -88,16 +138,63 @@ func NewProgressMonitor(ctx context.Context, stackName, region, clusterName stri
 
 	return &ProgressMonitor{
 		cfnClient:   cloudformation.NewFromConfig(cfg),
+		logsClient:  cloudwatchlogs.NewFromConfig(cfg),
 		stackName:   stackName,
 		region:      region,
 		clusterName: clusterName,
 		startTime:   time.Now(),
+		renderer:    newProgressRenderer(),
+		out:         os.Stdout,
 	}, nil
 }
 
+// NewProgressMonitorWithCallbacks creates a progress monitor that reports
+// events and phase changes to callbacks, in addition to or instead of
+// printing them.
+func NewProgressMonitorWithCallbacks(ctx context.Context, stackName, region, clusterName string, callbacks ProgressCallbacks) (*ProgressMonitor, error) {
+	pm, err := NewProgressMonitor(ctx, stackName, region, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	pm.OnEvent = callbacks.OnEvent
+	pm.OnPhaseChange = callbacks.OnPhaseChange
+	if callbacks.Quiet {
+		pm.out = io.Discard
+		pm.renderer = &progressRenderer{out: io.Discard}
+	}
+
+	return pm, nil
+}
+
+// SetPollInterval makes every monitoring phase poll at interval instead of
+// its default. Zero restores the defaults.
+func (pm *ProgressMonitor) SetPollInterval(interval time.Duration) {
+	pm.pollInterval = interval
+}
+
+// interval returns the polling interval for a phase whose default is def.
+func (pm *ProgressMonitor) interval(def time.Duration) time.Duration {
+	if pm.pollInterval > 0 {
+		return pm.pollInterval
+	}
+	return def
+}
+
+// reportPhase calls OnPhaseChange if the phase or percentage changed since
+// the last report.
+func (pm *ProgressMonitor) reportPhase(phase string, pct int) {
+	if pm.OnPhaseChange == nil || (phase == pm.phase && pct == pm.phasePct) {
+		return
+	}
+	pm.phase = phase
+	pm.phasePct = pct
+	pm.OnPhaseChange(phase, pct)
+}
+
 // MonitorCreation monitors cluster creation and displays real-time progress
 func (pm *ProgressMonitor) MonitorCreation(ctx context.Context) error {
-	fmt.Printf("\n🚀 Monitoring cluster creation: %s\n\n", pm.clusterName)
+	fmt.Fprintf(pm.out, "\n🚀 Monitoring cluster creation: %s\n\n", pm.clusterName)
 
 	// Phase 1: Monitor CloudFormation infrastructure (0-70%)
 	if err := pm.monitorInfrastructure(ctx); err != nil {
@@ -114,19 +211,24 @@ func (pm *ProgressMonitor) MonitorCreation(ctx context.Context) error {
 
 // monitorInfrastructure monitors CloudFormation stack creation (Phase 1: 0-70%)
 func (pm *ProgressMonitor) monitorInfrastructure(ctx context.Context) error {
+	// Start a new progress block below any earlier output
+	pm.renderer.Done()
+
 	// Wait for stack to be created (pcluster create-cluster is async)
-	fmt.Printf("⏳ Waiting for CloudFormation stack to be created...\n")
+	fmt.Fprintf(pm.out, "⏳ Waiting for CloudFormation stack to be created...\n")
 	if err := pm.waitForStackToExist(ctx); err != nil {
 		return fmt.Errorf("stack creation timeout: %w", err)
 	}
 
+	pm.reportPhase(PhaseInfrastructure, 0)
+
 	// Track seen events to avoid duplicates
 	seenEvents := make(map[string]bool)
 
 	// Track resources
 	resources := make(map[string]*ResourceStatus)
 
-	ticker := time.NewTicker(15 * time.Second)
+	ticker := time.NewTicker(pm.interval(15 * time.Second))
 	defer ticker.Stop()
 
 	// Initial check
@@ -150,10 +252,12 @@ func (pm *ProgressMonitor) monitorInfrastructure(ctx context.Context) error {
 			}
 
 			if stackStatus == types.StackStatusCreateComplete {
-				fmt.Printf("\n✅ Infrastructure provisioning complete! (70%%)\n")
+				pm.reportPhase(PhaseInfrastructure, 70)
+				fmt.Fprintf(pm.out, "\n✅ Infrastructure provisioning complete! (70%%)\n")
 				return nil
 			} else if stackStatus == types.StackStatusCreateFailed {
 				// Display failure details
+				pm.reportPhase(PhaseFailed, pm.phasePct)
 				pm.displayFailureDetails(ctx)
 				return fmt.Errorf("cluster creation failed")
 			} else if stackStatus == types.StackStatusRollbackInProgress {
@@ -161,6 +265,7 @@ func (pm *ProgressMonitor) monitorInfrastructure(ctx context.Context) error {
 				return pm.monitorRollback(ctx)
 			} else if stackStatus == types.StackStatusRollbackComplete {
 				// Rollback completed, show failure details
+				pm.reportPhase(PhaseFailed, pm.phasePct)
 				pm.displayFailureDetails(ctx)
 				return fmt.Errorf("cluster creation failed and rolled back")
 			}
@@ -169,44 +274,72 @@ func (pm *ProgressMonitor) monitorInfrastructure(ctx context.Context) error {
 }
 
 func (pm *ProgressMonitor) checkAndDisplayProgress(ctx context.Context, seenEvents map[string]bool, resources map[string]*ResourceStatus) error {
-	// Get stack events
-	events, err := pm.getStackEvents(ctx)
+	// Fetch this tick's new events; failure details reuse the cache
+	events, err := pm.refreshEvents(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get stack events: %w", err)
 	}
 
-	// Process new events
+	// Display progress if there are new events
+	if pm.processEvents(events, seenEvents, resources) {
+		pm.displayProgress(resources)
+		pm.reportPhase(PhaseInfrastructure, infrastructureProgress(resources))
+	}
+
+	return nil
+}
+
+// processEvents records unseen stack events in resources, passing each to
+// OnEvent, and reports whether any were new.
+func (pm *ProgressMonitor) processEvents(events []types.StackEvent, seenEvents map[string]bool, resources map[string]*ResourceStatus) bool {
 	newEvents := false
 	for _, event := range events {
-		eventKey := fmt.Sprintf("%s-%s-%s", *event.LogicalResourceId, event.ResourceStatus, event.Timestamp.String())
-		if !seenEvents[eventKey] {
-			seenEvents[eventKey] = true
-			newEvents = true
-
-			// Update resource tracking
-			if event.LogicalResourceId != nil && *event.LogicalResourceId != pm.stackName {
-				resources[*event.LogicalResourceId] = &ResourceStatus{
-					LogicalID:  *event.LogicalResourceId,
-					Type:       aws.ToString(event.ResourceType),
-					Status:     event.ResourceStatus,
-					StatusText: string(event.ResourceStatus),
-					Timestamp:  *event.Timestamp,
-				}
+		eventKey := fmt.Sprintf("%s-%s-%s", aws.ToString(event.LogicalResourceId), event.ResourceStatus, aws.ToTime(event.Timestamp).String())
+		if seenEvents[eventKey] {
+			continue
+		}
+		seenEvents[eventKey] = true
+		newEvents = true
+
+		// Update resource tracking
+		if event.LogicalResourceId != nil && *event.LogicalResourceId != pm.stackName {
+			res := &ResourceStatus{
+				LogicalID:  *event.LogicalResourceId,
+				PhysicalID: aws.ToString(event.PhysicalResourceId),
+				Type:       aws.ToString(event.ResourceType),
+				Status:     event.ResourceStatus,
+				StatusText: string(event.ResourceStatus),
+				Timestamp:  aws.ToTime(event.Timestamp),
+			}
+			resources[res.LogicalID] = res
+			if pm.OnEvent != nil {
+				pm.OnEvent(*res)
 			}
 		}
 	}
+	return newEvents
+}
 
-	// Display progress if there are new events
-	if newEvents {
-		pm.displayProgress(resources)
+// infrastructureProgress returns the infrastructure phase progress (0-70%)
+// from the share of resources created.
+func infrastructureProgress(resources map[string]*ResourceStatus) int {
+	if len(resources) == 0 {
+		return 0
 	}
-
-	return nil
+	completed := 0
+	for _, res := range resources {
+		if res.Status == types.ResourceStatusCreateComplete {
+			completed++
+		}
+	}
+	return (completed * 70) / len(resources)
 }
 
 func (pm *ProgressMonitor) displayProgress(resources map[string]*ResourceStatus) {
-	// Clear previous output (simple version - just add spacing)
-	fmt.Printf("\n")
+	var b strings.Builder
+	defer func() { pm.renderer.Render(b.String()) }()
+
+	fmt.Fprintf(&b, "\n")
 
 	// Count resources by status
 	var completed, inProgress, failed int
@@ -225,60 +358,52 @@ func (pm *ProgressMonitor) displayProgress(resources map[string]*ResourceStatus)
 
 	total := len(resources)
 	if total == 0 {
-		fmt.Printf("⏳ Initiating cluster creation...\n")
+		fmt.Fprintf(&b, "⏳ Initiating cluster creation...\n")
 		return
 	}
 
 	// Display active and important resources
-	fmt.Printf("📦 Infrastructure Provisioning:\n")
+	fmt.Fprintf(&b, "📦 Infrastructure Provisioning:\n")
 	for _, res := range resourcesToDisplay {
 		icon := pm.getStatusIcon(res.Status)
 		resourceName := pm.getReadableResourceName(res.LogicalID, res.Type)
-		fmt.Printf("  %s %-35s %s\n", icon, resourceName, res.Status)
+		fmt.Fprintf(&b, "  %s %-35s %s\n", icon, resourceName, res.Status)
 	}
 
 	// Calculate progress percentage (infrastructure phase: 0-70%)
-	progressPct := 0
-	if total > 0 {
-		progressPct = (completed * 70) / total
-	}
+	progressPct := infrastructureProgress(resources)
 
 	// Display progress bar
 	elapsed := time.Since(pm.startTime)
 
-	fmt.Printf("\n")
-	bar := progressbar.NewOptions(100,
-		progressbar.OptionSetDescription("Progress"),
-		progressbar.OptionSetWidth(40),
-		progressbar.OptionShowCount(),
-		progressbar.OptionSetPredictTime(true),
-		progressbar.OptionShowElapsedTimeOnFinish(),
-		progressbar.OptionSetElapsedTime(true),
-	)
-	bar.Set(progressPct)
-	fmt.Printf("\n")
+	fmt.Fprintf(&b, "\n%s\n",
+		progressBarLine(progressPct,
+			progressbar.OptionSetPredictTime(true),
+			progressbar.OptionShowElapsedTimeOnFinish(),
+			progressbar.OptionSetElapsedTime(true),
+		))
 
 	// Display summary with time estimates
-	fmt.Printf("Resources: %d/%d created", completed, total)
+	fmt.Fprintf(&b, "Resources: %d/%d created", completed, total)
 	if failed > 0 {
-		fmt.Printf(" (%d failed)", failed)
+		fmt.Fprintf(&b, " (%d failed)", failed)
 	}
-	fmt.Printf(" | Elapsed: %s", formatDuration(elapsed))
+	fmt.Fprintf(&b, " | Elapsed: %s", formatDuration(elapsed))
 
 	// Show remaining time estimate if there are incomplete resources
 	if inProgress > 0 || (completed < total) {
 		remainingTime := pm.calculateRemainingTime(resources)
 		if remainingTime > 0 {
 			etaTime := time.Now().Add(remainingTime)
-			fmt.Printf(" | Remaining: ~%s | ETA: %s",
+			fmt.Fprintf(&b, " | Remaining: ~%s | ETA: %s",
 				formatDuration(remainingTime),
 				etaTime.Format("15:04:05"))
 		}
 	}
-	fmt.Printf("\n")
+	fmt.Fprintf(&b, "\n")
 
 	if inProgress > 0 {
-		fmt.Printf("⏳ %d resource(s) in progress...\n", inProgress)
+		fmt.Fprintf(&b, "⏳ %d resource(s) in progress...\n", inProgress)
 	}
 }
 
@@ -360,24 +485,28 @@ func (pm *ProgressMonitor) getStatusIcon(status types.ResourceStatus) string {
 	}
 }
 
+// getStackEvents returns all of the stack's events, oldest first, fetching
+// only events newer than those already cached.
 func (pm *ProgressMonitor) getStackEvents(ctx context.Context) ([]types.StackEvent, error) {
-	input := &cloudformation.DescribeStackEventsInput{
-		StackName: aws.String(pm.stackName),
-	}
-
-	result, err := pm.cfnClient.DescribeStackEvents(ctx, input)
-	if err != nil {
+	if _, err := pm.refreshEvents(ctx); err != nil {
 		return nil, err
 	}
+	return pm.events.events, nil
+}
 
-	// Return events in chronological order (oldest first)
-	events := result.StackEvents
-	for i := len(events)/2 - 1; i >= 0; i-- {
-		opp := len(events) - 1 - i
-		events[i], events[opp] = events[opp], events[i]
-	}
+// refreshEvents fetches events newer than those already cached and returns
+// them, oldest first.
+func (pm *ProgressMonitor) refreshEvents(ctx context.Context) ([]types.StackEvent, error) {
+	return pm.events.refresh(ctx, pm.cfnClient, pm.stackName)
+}
 
-	return events, nil
+// cachedEvents returns the cached stack events, oldest first, fetching them
+// only if no poll has fetched them yet.
+func (pm *ProgressMonitor) cachedEvents(ctx context.Context) ([]types.StackEvent, error) {
+	if !pm.events.fetched {
+		return pm.getStackEvents(ctx)
+	}
+	return pm.events.events, nil
 }
 
 func (pm *ProgressMonitor) getStackStatus(ctx context.Context) (types.StackStatus, error) {
@@ -517,7 +646,8 @@ func (pm *ProgressMonitor) calculateClusterProgress(status *pclusterDescribeResp
 
 // displayClusterProgress displays cluster configuration phase progress
 func (pm *ProgressMonitor) displayClusterProgress(status *pclusterDescribeResponse, progress int) {
-	fmt.Printf("\n🎯 Cluster Configuration:\n")
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n🎯 Cluster Configuration:\n")
 
 	// Head node status
 	headNodeIcon := "⏳"
@@ -529,7 +659,7 @@ func (pm *ProgressMonitor) displayClusterProgress(status *pclusterDescribeRespon
 		headNodeIcon = "✅"
 		headNodeStatus = "READY"
 	}
-	fmt.Printf("  Head Node:        %s %s\n", headNodeIcon, headNodeStatus)
+	fmt.Fprintf(&b, "  Head Node:        %s %s\n", headNodeIcon, headNodeStatus)
 
 	// Scheduler status (Slurm)
 	schedulerIcon := "⏳"
@@ -541,7 +671,7 @@ func (pm *ProgressMonitor) displayClusterProgress(status *pclusterDescribeRespon
 		schedulerIcon = "✅"
 		schedulerStatus = "ACTIVE"
 	}
-	fmt.Printf("  Slurm Controller: %s %s\n", schedulerIcon, schedulerStatus)
+	fmt.Fprintf(&b, "  Slurm Controller: %s %s\n", schedulerIcon, schedulerStatus)
 
 	// Compute fleet status
 	computeIcon := "⏳"
@@ -553,28 +683,26 @@ func (pm *ProgressMonitor) displayClusterProgress(status *pclusterDescribeRespon
 	} else if computeStatus == "STARTING" {
 		computeIcon = "🔄"
 	}
-	fmt.Printf("  Compute Fleet:    %s %s\n", computeIcon, computeStatus)
+	fmt.Fprintf(&b, "  Compute Fleet:    %s %s\n", computeIcon, computeStatus)
 
 	// Progress bar
 	elapsed := time.Since(pm.startTime)
-	fmt.Printf("\n")
-	bar := progressbar.NewOptions(100,
-		progressbar.OptionSetDescription("Progress"),
-		progressbar.OptionSetWidth(40),
-		progressbar.OptionShowCount(),
-	)
-	bar.Set(progress)
-	fmt.Printf("\n")
+	fmt.Fprintf(&b, "\n%s\n", progressBarLine(progress))
 
-	fmt.Printf("Status: %s | Elapsed: %s\n", status.ClusterStatus, formatDuration(elapsed))
+	fmt.Fprintf(&b, "Status: %s | Elapsed: %s\n", status.ClusterStatus, formatDuration(elapsed))
+	pm.renderer.Render(b.String())
 }
 
 // MonitorClusterConfiguration monitors cluster initialization from 70-100%
 func (pm *ProgressMonitor) MonitorClusterConfiguration(ctx context.Context) error {
-	fmt.Printf("\n🎯 Cluster Configuration:\n")
-	fmt.Printf("⏳ Monitoring cluster initialization...\n")
+	// Start a new progress block below any earlier output
+	pm.renderer.Done()
 
-	ticker := time.NewTicker(10 * time.Second)
+	fmt.Fprintf(pm.out, "\n🎯 Cluster Configuration:\n")
+	fmt.Fprintf(pm.out, "⏳ Monitoring cluster initialization...\n")
+	pm.reportPhase(PhaseConfiguration, 70)
+
+	ticker := time.NewTicker(pm.interval(10 * time.Second))
 	defer ticker.Stop()
 
 	// Initial check
@@ -597,13 +725,16 @@ func (pm *ProgressMonitor) MonitorClusterConfiguration(ctx context.Context) erro
 			pm.displayClusterProgress(status, progress)
 
 			if status.ClusterStatus == "CREATE_COMPLETE" {
-				fmt.Printf("\n✅ Cluster fully ready!\n")
+				pm.reportPhase(PhaseComplete, 100)
+				fmt.Fprintf(pm.out, "\n✅ Cluster fully ready!\n")
 				return nil
 			}
 
 			if status.ClusterStatus == "CREATE_FAILED" {
+				pm.reportPhase(PhaseFailed, progress)
 				return fmt.Errorf("cluster configuration failed")
 			}
+			pm.reportPhase(PhaseConfiguration, progress)
 		}
 	}
 }
@@ -616,13 +747,20 @@ type FailureDetails struct {
 	FailureTime       time.Time
 }
 
-// getFailedResources returns all resources that failed during creation
+// getFailedResources returns all resources that failed during creation,
+// oldest first
 func (pm *ProgressMonitor) getFailedResources(ctx context.Context) ([]*ResourceStatus, error) {
-	events, err := pm.getStackEvents(ctx)
+	events, err := pm.cachedEvents(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	return orderFailures(events), nil
+}
+
+// orderFailures extracts CREATE_FAILED events in chronological order.
+// CloudFormation returns stack events newest first.
+func orderFailures(events []types.StackEvent) []*ResourceStatus {
 	var failedResources []*ResourceStatus
 	for _, event := range events {
 		if event.ResourceStatus == types.ResourceStatusCreateFailed {
@@ -631,12 +769,149 @@ func (pm *ProgressMonitor) getFailedResources(ctx context.Context) ([]*ResourceS
 				Type:       aws.ToString(event.ResourceType),
 				Status:     event.ResourceStatus,
 				StatusText: aws.ToString(event.ResourceStatusReason),
-				Timestamp:  *event.Timestamp,
+				Timestamp:  aws.ToTime(event.Timestamp),
 			})
 		}
 	}
 
-	return failedResources, nil
+	sort.SliceStable(failedResources, func(i, j int) bool {
+		return failedResources[i].Timestamp.Before(failedResources[j].Timestamp)
+	})
+
+	return failedResources
+}
+
+// isCascadingFailure reports whether a failure only reflects another
+// resource's failure (cancelled creation, or a reason naming another failed
+// resource) rather than being a root cause itself.
+func isCascadingFailure(failure *ResourceStatus, failures []*ResourceStatus) bool {
+	if strings.Contains(failure.StatusText, "Resource creation cancelled") {
+		return true
+	}
+	for _, other := range failures {
+		if other != failure && other.LogicalID != "" && strings.Contains(failure.StatusText, other.LogicalID) {
+			return true
+		}
+	}
+	return false
+}
+
+// findRootCause returns the earliest failure that was not caused by another
+// failure, falling back to the earliest failure overall. Failures must be in
+// chronological order.
+func findRootCause(failures []*ResourceStatus) *ResourceStatus {
+	if len(failures) == 0 {
+		return nil
+	}
+	for _, failure := range failures {
+		if !isCascadingFailure(failure, failures) {
+			return failure
+		}
+	}
+	return failures[0]
+}
+
+// failureChain returns the root cause followed by the failures it led to, in
+// chronological order: cancelled resources and failures whose reason names
+// the root or an earlier failure in the chain.
+func failureChain(failures []*ResourceStatus) []*ResourceStatus {
+	root := findRootCause(failures)
+	if root == nil {
+		return nil
+	}
+
+	chain := []*ResourceStatus{root}
+	inChain := map[*ResourceStatus]bool{root: true}
+	for _, failure := range failures {
+		if inChain[failure] {
+			continue
+		}
+		if strings.Contains(failure.StatusText, "Resource creation cancelled") {
+			chain = append(chain, failure)
+			inChain[failure] = true
+			continue
+		}
+		for _, link := range chain {
+			if strings.Contains(failure.StatusText, link.LogicalID) {
+				chain = append(chain, failure)
+				inChain[failure] = true
+				break
+			}
+		}
+	}
+
+	return chain
+}
+
+// isBootstrapFailure reports whether a failure is likely caused by the node
+// bootstrap, whose real error is only visible in the cluster logs.
+func isBootstrapFailure(failure *ResourceStatus) bool {
+	return failure.Type == "AWS::CloudFormation::WaitCondition" || failure.Type == "AWS::EC2::Instance"
+}
+
+// cloudWatchLogsAPI is the subset of the CloudWatch Logs client used to read
+// cluster logs.
+type cloudWatchLogsAPI interface {
+	DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error)
+	FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error)
+}
+
+// getBootstrapErrors fetches the latest error lines from the cluster's
+// CloudWatch log group, where ParallelCluster ships cfn-init and cloud-init
+// output. FilterLogEvents returns matches oldest first, so every page since
+// the log group was created is read to reach the lines nearest the failure.
+func (pm *ProgressMonitor) getBootstrapErrors(ctx context.Context) ([]string, error) {
+	groups, err := pm.logsClient.DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String("/aws/parallelcluster/" + pm.clusterName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe log groups: %w", err)
+	}
+	if len(groups.LogGroups) == 0 {
+		return nil, nil
+	}
+
+	// Log group names carry a creation timestamp; use the newest
+	latest := groups.LogGroups[0]
+	for _, group := range groups.LogGroups[1:] {
+		if aws.ToInt64(group.CreationTime) > aws.ToInt64(latest.CreationTime) {
+			latest = group
+		}
+	}
+
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:  latest.LogGroupName,
+		FilterPattern: aws.String("?ERROR ?FATAL ?Error"),
+		StartTime:     latest.CreationTime,
+	}
+	var events []logtypes.FilteredLogEvent
+	for {
+		result, err := pm.logsClient.FilterLogEvents(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cluster logs: %w", err)
+		}
+		events = append(events, result.Events...)
+		if result.NextToken == nil || aws.ToString(result.NextToken) == aws.ToString(input.NextToken) {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	// Events from different log streams interleave; keep only the last few
+	// lines, which are closest to the failure
+	sort.SliceStable(events, func(i, j int) bool {
+		return aws.ToInt64(events[i].Timestamp) < aws.ToInt64(events[j].Timestamp)
+	})
+	const maxLines = 5
+	var lines []string
+	for _, event := range events {
+		lines = append(lines, strings.TrimSpace(aws.ToString(event.Message)))
+	}
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+
+	return lines, nil
 }
 
 // getConsoleURL returns the AWS Console URL for the CloudFormation stack
@@ -659,43 +934,74 @@ func (pm *ProgressMonitor) getCloudWatchLogsURL() string {
 
 // displayFailureDetails displays detailed information about a failed cluster creation
 func (pm *ProgressMonitor) displayFailureDetails(ctx context.Context) error {
-	fmt.Printf("\n❌ Cluster creation failed!\n\n")
+	fmt.Fprintf(pm.out, "\n❌ Cluster creation failed!\n\n")
 
 	// Get failed resources
 	failedResources, err := pm.getFailedResources(ctx)
 	if err != nil {
-		fmt.Printf("Unable to retrieve failure details: %v\n", err)
+		fmt.Fprintf(pm.out, "Unable to retrieve failure details: %v\n", err)
 		return nil
 	}
 
 	if len(failedResources) == 0 {
-		fmt.Printf("No specific resource failures found.\n")
+		fmt.Fprintf(pm.out, "No specific resource failures found.\n")
 		return nil
 	}
 
-	// Display the most recent failure
-	latestFailure := failedResources[0]
-	fmt.Printf("Failed Resource: %s (%s)\n",
-		latestFailure.LogicalID,
-		latestFailure.Type)
+	// Display the root cause and the failures it led to
+	chain := failureChain(failedResources)
+	rootCause := chain[0]
+	fmt.Fprintf(pm.out, "Root Cause: %s (%s)\n",
+		rootCause.LogicalID,
+		rootCause.Type)
 
-	if latestFailure.StatusText != "" {
-		fmt.Printf("Reason: %s\n", latestFailure.StatusText)
+	if rootCause.StatusText != "" {
+		fmt.Fprintf(pm.out, "Reason: %s\n", rootCause.StatusText)
 	}
 
-	fmt.Printf("Status: %s\n", latestFailure.Status)
-	fmt.Printf("Timestamp: %s\n\n", latestFailure.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(pm.out, "Status: %s\n", rootCause.Status)
+	fmt.Fprintf(pm.out, "Timestamp: %s\n\n", rootCause.Timestamp.Format("2006-01-02 15:04:05"))
+
+	if len(chain) > 1 {
+		fmt.Fprintf(pm.out, "Failure Chain:\n")
+		for i, failure := range chain {
+			fmt.Fprintf(pm.out, "  %d. %s (%s)\n", i+1, failure.LogicalID, failure.Type)
+			if i > 0 && failure.StatusText != "" {
+				fmt.Fprintf(pm.out, "     %s\n", failure.StatusText)
+			}
+		}
+		fmt.Fprintln(pm.out)
+	}
+
+	// The stack only reports that a node failed to signal; the actual
+	// bootstrap error lives in the cluster logs
+	for _, failure := range chain {
+		if !isBootstrapFailure(failure) {
+			continue
+		}
+		lines, err := pm.getBootstrapErrors(ctx)
+		if err != nil {
+			fmt.Fprintf(pm.out, "Unable to retrieve bootstrap logs: %v\n\n", err)
+		} else if len(lines) > 0 {
+			fmt.Fprintf(pm.out, "Bootstrap Errors (from CloudWatch Logs):\n")
+			for _, line := range lines {
+				fmt.Fprintf(pm.out, "  %s\n", line)
+			}
+			fmt.Fprintln(pm.out)
+		}
+		break
+	}
 
 	// Show AWS Console links
-	fmt.Printf("View in AWS Console:\n")
-	fmt.Printf("  CloudFormation: %s\n", pm.getConsoleURL())
-	fmt.Printf("  CloudWatch Logs: %s\n\n", pm.getCloudWatchLogsURL())
+	fmt.Fprintf(pm.out, "View in AWS Console:\n")
+	fmt.Fprintf(pm.out, "  CloudFormation: %s\n", pm.getConsoleURL())
+	fmt.Fprintf(pm.out, "  CloudWatch Logs: %s\n\n", pm.getCloudWatchLogsURL())
 
 	// Show troubleshooting hints
-	fmt.Printf("Troubleshooting:\n")
-	hints := getTroubleshootingHints(latestFailure.Type, latestFailure.StatusText)
+	fmt.Fprintf(pm.out, "Troubleshooting:\n")
+	hints := getTroubleshootingHints(rootCause.Type, rootCause.StatusText)
 	for _, hint := range hints {
-		fmt.Printf("  • %s\n", hint)
+		fmt.Fprintf(pm.out, "  • %s\n", hint)
 	}
 
 	return nil
@@ -735,12 +1041,16 @@ func getTroubleshootingHints(resourceType, statusReason string) []string {
 
 // monitorRollback monitors the rollback progress when stack creation fails
 func (pm *ProgressMonitor) monitorRollback(ctx context.Context) error {
-	fmt.Printf("\n🔄 Stack creation failed, rolling back...\n\n")
+	// Start a new progress block below any earlier output
+	pm.renderer.Done()
+
+	fmt.Fprintf(pm.out, "\n🔄 Stack creation failed, rolling back...\n\n")
+	pm.reportPhase(PhaseRollback, pm.phasePct)
 
 	seenEvents := make(map[string]bool)
 	resources := make(map[string]*ResourceStatus)
 
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(pm.interval(10 * time.Second))
 	defer ticker.Stop()
 
 	for {
@@ -753,23 +1063,7 @@ func (pm *ProgressMonitor) monitorRollback(ctx context.Context) error {
 				return err
 			}
 
-			// Track DELETE events
-			for _, event := range events {
-				if strings.Contains(string(event.ResourceStatus), "DELETE") {
-					eventKey := fmt.Sprintf("%s-%s", aws.ToString(event.LogicalResourceId), event.ResourceStatus)
-					if !seenEvents[eventKey] {
-						seenEvents[eventKey] = true
-						resources[aws.ToString(event.LogicalResourceId)] = &ResourceStatus{
-							LogicalID:  aws.ToString(event.LogicalResourceId),
-							Type:       aws.ToString(event.ResourceType),
-							Status:     event.ResourceStatus,
-							StatusText: string(event.ResourceStatus),
-							Timestamp:  *event.Timestamp,
-						}
-					}
-				}
-			}
-
+			pm.trackDeleteEvents(events, seenEvents, resources)
 			pm.displayRollbackProgress(resources)
 
 			// Check if rollback complete
@@ -777,7 +1071,8 @@ func (pm *ProgressMonitor) monitorRollback(ctx context.Context) error {
 			if err != nil {
 				// Stack might be deleted
 				if strings.Contains(err.Error(), "does not exist") {
-					fmt.Printf("\n✅ Rollback complete (stack deleted)\n")
+					fmt.Fprintf(pm.out, "\n✅ Rollback complete (stack deleted)\n")
+					pm.reportPhase(PhaseFailed, pm.phasePct)
 					return fmt.Errorf("cluster creation failed and rolled back")
 				}
 				return err
@@ -785,16 +1080,104 @@ func (pm *ProgressMonitor) monitorRollback(ctx context.Context) error {
 
 			if stackStatus == types.StackStatusRollbackComplete ||
 				stackStatus == types.StackStatusDeleteComplete {
-				fmt.Printf("\n✅ Rollback complete\n")
+				fmt.Fprintf(pm.out, "\n✅ Rollback complete\n")
+				pm.reportPhase(PhaseFailed, pm.phasePct)
 				return fmt.Errorf("cluster creation failed and rolled back")
 			}
 		}
 	}
 }
 
+// MonitorDeletion monitors cluster stack deletion until the stack is gone
+func (pm *ProgressMonitor) MonitorDeletion(ctx context.Context) error {
+	// Start a new progress block below any earlier output
+	pm.renderer.Done()
+
+	fmt.Fprintf(pm.out, "\n🗑️  Monitoring cluster deletion: %s\n", pm.clusterName)
+
+	seenEvents := make(map[string]bool)
+	resources := make(map[string]*ResourceStatus)
+
+	ticker := time.NewTicker(pm.interval(15 * time.Second))
+	defer ticker.Stop()
+
+	for {
+		stackStatus, err := pm.getStackStatus(ctx)
+		if err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				fmt.Fprintf(pm.out, "\n✅ Stack deletion complete\n")
+				return nil
+			}
+			return fmt.Errorf("failed to get stack status: %w", err)
+		}
+
+		events, err := pm.getStackEvents(ctx)
+		if err == nil {
+			if pm.trackDeleteEvents(events, seenEvents, resources) {
+				pm.displayDeleteProgress("Deletion", resources)
+			}
+		}
+
+		switch stackStatus {
+		case types.StackStatusDeleteComplete:
+			fmt.Fprintf(pm.out, "\n✅ Stack deletion complete\n")
+			return nil
+		case types.StackStatusDeleteFailed:
+			var failed []string
+			for _, res := range resources {
+				if res.Status == types.ResourceStatusDeleteFailed {
+					failed = append(failed, pm.getReadableResourceName(res.LogicalID, res.Type))
+				}
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("stack deletion failed (could not delete: %s)", strings.Join(failed, ", "))
+			}
+			return fmt.Errorf("stack deletion failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// trackDeleteEvents records DELETE events in resources and reports whether any were new
+func (pm *ProgressMonitor) trackDeleteEvents(events []types.StackEvent, seenEvents map[string]bool, resources map[string]*ResourceStatus) bool {
+	newEvents := false
+	for _, event := range events {
+		if !strings.Contains(string(event.ResourceStatus), "DELETE") {
+			continue
+		}
+		if aws.ToString(event.LogicalResourceId) == pm.stackName {
+			continue
+		}
+		eventKey := fmt.Sprintf("%s-%s", aws.ToString(event.LogicalResourceId), event.ResourceStatus)
+		if !seenEvents[eventKey] {
+			seenEvents[eventKey] = true
+			newEvents = true
+			resources[aws.ToString(event.LogicalResourceId)] = &ResourceStatus{
+				LogicalID:  aws.ToString(event.LogicalResourceId),
+				Type:       aws.ToString(event.ResourceType),
+				Status:     event.ResourceStatus,
+				StatusText: string(event.ResourceStatus),
+				Timestamp:  aws.ToTime(event.Timestamp),
+			}
+		}
+	}
+	return newEvents
+}
+
 // displayRollbackProgress displays rollback progress
 func (pm *ProgressMonitor) displayRollbackProgress(resources map[string]*ResourceStatus) {
-	fmt.Printf("\n🔄 Rollback Progress:\n")
+	pm.displayDeleteProgress("Rollback", resources)
+}
+
+// displayDeleteProgress displays progress of resources being deleted
+func (pm *ProgressMonitor) displayDeleteProgress(label string, resources map[string]*ResourceStatus) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n🔄 %s Progress:\n", label)
 
 	var deleted, inProgress, pending int
 	var displayedCount int
@@ -815,7 +1198,7 @@ func (pm *ProgressMonitor) displayRollbackProgress(resources map[string]*Resourc
 
 		// Only display up to maxDisplay resources
 		if displayedCount < maxDisplay {
-			fmt.Printf("  %s %-35s %s\n",
+			fmt.Fprintf(&b, "  %s %-35s %s\n",
 				icon,
 				pm.getReadableResourceName(res.LogicalID, res.Type),
 				res.Status)
@@ -827,12 +1210,13 @@ func (pm *ProgressMonitor) displayRollbackProgress(resources map[string]*Resourc
 	elapsed := time.Since(pm.startTime)
 
 	if total > maxDisplay {
-		fmt.Printf("  ... and %d more resources\n", total-maxDisplay)
+		fmt.Fprintf(&b, "  ... and %d more resources\n", total-maxDisplay)
 	}
 
-	fmt.Printf("\nRollback: %d/%d resources deleted", deleted, total)
+	fmt.Fprintf(&b, "\n%s: %d/%d resources deleted", label, deleted, total)
 	if inProgress > 0 {
-		fmt.Printf(" (%d in progress)", inProgress)
+		fmt.Fprintf(&b, " (%d in progress)", inProgress)
 	}
-	fmt.Printf(" | Elapsed: %s\n", formatDuration(elapsed))
+	fmt.Fprintf(&b, " | Elapsed: %s\n", formatDuration(elapsed))
+	pm.renderer.Render(b.String())
 }
@@ -0,0 +1,315 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	logtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// fakeCloudWatchLogs serves one log group and its matching events oldest
+// first in pages of pageSize.
+type fakeCloudWatchLogs struct {
+	events   []logtypes.FilteredLogEvent
+	pageSize int
+
+	startTime *int64
+}
+
+func (f *fakeCloudWatchLogs) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	return &cloudwatchlogs.DescribeLogGroupsOutput{
+		LogGroups: []logtypes.LogGroup{
+			{LogGroupName: aws.String("/aws/parallelcluster/my-cluster-202501011100"), CreationTime: aws.Int64(1000)},
+			{LogGroupName: aws.String("/aws/parallelcluster/my-cluster-202501011200"), CreationTime: aws.Int64(2000)},
+		},
+	}, nil
+}
+
+func (f *fakeCloudWatchLogs) FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	f.startTime = params.StartTime
+
+	start := 0
+	if params.NextToken != nil {
+		start, _ = strconv.Atoi(*params.NextToken)
+	}
+	end := min(start+f.pageSize, len(f.events))
+	out := &cloudwatchlogs.FilterLogEventsOutput{Events: f.events[start:end]}
+	if end < len(f.events) {
+		out.NextToken = aws.String(strconv.Itoa(end))
+	}
+	return out, nil
+}
+
+func TestGetBootstrapErrorsReturnsLatest(t *testing.T) {
+	fake := &fakeCloudWatchLogs{pageSize: 4}
+	for i := 0; i < 10; i++ {
+		fake.events = append(fake.events, logtypes.FilteredLogEvent{
+			Message:   aws.String(fmt.Sprintf("ERROR line %d\n", i)),
+			Timestamp: aws.Int64(int64(2000 + i)),
+		})
+	}
+	pm := &ProgressMonitor{logsClient: fake, clusterName: "my-cluster"}
+
+	lines, err := pm.getBootstrapErrors(context.Background())
+	if err != nil {
+		t.Fatalf("getBootstrapErrors() error = %v", err)
+	}
+
+	// The newest log group is read from its creation to the last page
+	if aws.ToInt64(fake.startTime) != 2000 {
+		t.Errorf("FilterLogEvents StartTime = %d, want 2000", aws.ToInt64(fake.startTime))
+	}
+	want := []string{"ERROR line 5", "ERROR line 6", "ERROR line 7", "ERROR line 8", "ERROR line 9"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("getBootstrapErrors() = %v, want %v", lines, want)
+	}
+}
+
+// failedEvent builds a CREATE_FAILED stack event at the given offset from a base time.
+func failedEvent(logicalID, resourceType, reason string, offset time.Duration) types.StackEvent {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	return types.StackEvent{
+		LogicalResourceId:    aws.String(logicalID),
+		ResourceType:         aws.String(resourceType),
+		ResourceStatus:       types.ResourceStatusCreateFailed,
+		ResourceStatusReason: aws.String(reason),
+		Timestamp:            aws.Time(base.Add(offset)),
+	}
+}
+
+// bootstrapFailureEvents is a typical head node bootstrap failure, newest first
+// as returned by DescribeStackEvents.
+func bootstrapFailureEvents() []types.StackEvent {
+	return []types.StackEvent{
+		failedEvent("my-cluster", "AWS::CloudFormation::Stack",
+			"The following resource(s) failed to create: [HeadNodeWaitCondition, ComputeFleet].", 4*time.Minute),
+		failedEvent("ComputeFleet", "AWS::CloudFormation::Stack",
+			"Resource creation cancelled", 3*time.Minute),
+		{
+			LogicalResourceId: aws.String("HeadNode"),
+			ResourceType:      aws.String("AWS::EC2::Instance"),
+			ResourceStatus:    types.ResourceStatusCreateComplete,
+			Timestamp:         aws.Time(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)),
+		},
+		failedEvent("HeadNodeWaitCondition", "AWS::CloudFormation::WaitCondition",
+			"Failed to receive 1 resource signal(s) within the specified duration", 2*time.Minute),
+	}
+}
+
+func TestOrderFailures(t *testing.T) {
+	failures := orderFailures(bootstrapFailureEvents())
+
+	want := []string{"HeadNodeWaitCondition", "ComputeFleet", "my-cluster"}
+	if len(failures) != len(want) {
+		t.Fatalf("Expected %d failures, got %d", len(want), len(failures))
+	}
+	for i, id := range want {
+		if failures[i].LogicalID != id {
+			t.Errorf("failures[%d] = %s, want %s", i, failures[i].LogicalID, id)
+		}
+	}
+}
+
+func TestFindRootCause(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []types.StackEvent
+		want   string
+	}{
+		{
+			name:   "wait condition behind cancelled and stack failures",
+			events: bootstrapFailureEvents(),
+			want:   "HeadNodeWaitCondition",
+		},
+		{
+			name: "earliest independent failure wins",
+			events: []types.StackEvent{
+				failedEvent("RoleB", "AWS::IAM::Role", "Access denied", 2*time.Minute),
+				failedEvent("RoleA", "AWS::IAM::Role", "Access denied", 1*time.Minute),
+			},
+			want: "RoleA",
+		},
+		{
+			name: "reason referencing a later failure is downstream",
+			events: []types.StackEvent{
+				failedEvent("HeadNodeSecurityGroup", "AWS::EC2::SecurityGroup", "Invalid VPC", 2*time.Minute),
+				failedEvent("Dashboard", "AWS::CloudWatch::Dashboard", "Depends on HeadNodeSecurityGroup", 1*time.Minute),
+			},
+			want: "HeadNodeSecurityGroup",
+		},
+		{
+			name: "all cascading falls back to earliest",
+			events: []types.StackEvent{
+				failedEvent("B", "AWS::EC2::Subnet", "Resource creation cancelled", 2*time.Minute),
+				failedEvent("A", "AWS::EC2::Subnet", "Resource creation cancelled", 1*time.Minute),
+			},
+			want: "A",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := findRootCause(orderFailures(tt.events))
+			if root == nil {
+				t.Fatal("Expected a root cause, got nil")
+			}
+			if root.LogicalID != tt.want {
+				t.Errorf("Expected root cause %s, got %s", tt.want, root.LogicalID)
+			}
+		})
+	}
+
+	if findRootCause(nil) != nil {
+		t.Error("Expected nil root cause for no failures")
+	}
+}
+
+func TestFailureChain(t *testing.T) {
+	chain := failureChain(orderFailures(bootstrapFailureEvents()))
+
+	want := []string{"HeadNodeWaitCondition", "ComputeFleet", "my-cluster"}
+	if len(chain) != len(want) {
+		t.Fatalf("Expected chain of %d, got %d", len(want), len(chain))
+	}
+	for i, id := range want {
+		if chain[i].LogicalID != id {
+			t.Errorf("chain[%d] = %s, want %s", i, chain[i].LogicalID, id)
+		}
+	}
+
+	if !isBootstrapFailure(chain[0]) {
+		t.Error("Expected wait condition root cause to be a bootstrap failure")
+	}
+}
+
+// creationEvents is a stack creation in progress, oldest first.
+func creationEvents() []types.StackEvent {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	event := func(logicalID, resourceType string, status types.ResourceStatus, offset time.Duration) types.StackEvent {
+		return types.StackEvent{
+			LogicalResourceId:  aws.String(logicalID),
+			PhysicalResourceId: aws.String("phys-" + logicalID),
+			ResourceType:       aws.String(resourceType),
+			ResourceStatus:     status,
+			Timestamp:          aws.Time(base.Add(offset)),
+		}
+	}
+	return []types.StackEvent{
+		event("my-cluster", "AWS::CloudFormation::Stack", types.ResourceStatusCreateInProgress, 0),
+		event("HeadNodeRole", "AWS::IAM::Role", types.ResourceStatusCreateInProgress, time.Second),
+		event("HeadNodeRole", "AWS::IAM::Role", types.ResourceStatusCreateComplete, time.Minute),
+		event("HeadNode", "AWS::EC2::Instance", types.ResourceStatusCreateInProgress, 2*time.Minute),
+	}
+}
+
+func TestProcessEventsCallsOnEvent(t *testing.T) {
+	var got []ResourceStatus
+	pm := &ProgressMonitor{
+		stackName:   "my-cluster",
+		clusterName: "my-cluster",
+		OnEvent:     func(res ResourceStatus) { got = append(got, res) },
+	}
+
+	seen := make(map[string]bool)
+	resources := make(map[string]*ResourceStatus)
+	if !pm.processEvents(creationEvents(), seen, resources) {
+		t.Fatal("processEvents() should report new events")
+	}
+
+	// The stack's own event is not a resource event
+	if len(got) != 3 {
+		t.Fatalf("OnEvent called %d times, want 3", len(got))
+	}
+	if got[0].LogicalID != "HeadNodeRole" || got[0].Status != types.ResourceStatusCreateInProgress {
+		t.Errorf("first event = %+v, want HeadNodeRole CREATE_IN_PROGRESS", got[0])
+	}
+	if got[1].Status != types.ResourceStatusCreateComplete {
+		t.Errorf("second event status = %s, want CREATE_COMPLETE", got[1].Status)
+	}
+	if got[2].LogicalID != "HeadNode" || got[2].Type != "AWS::EC2::Instance" || got[2].PhysicalID != "phys-HeadNode" {
+		t.Errorf("third event = %+v, want HeadNode instance", got[2])
+	}
+
+	// Events already processed are not reported again
+	if pm.processEvents(creationEvents(), seen, resources) {
+		t.Error("processEvents() should not report seen events as new")
+	}
+	if len(got) != 3 {
+		t.Errorf("OnEvent called again for seen events (%d calls)", len(got))
+	}
+
+	// 1 of 2 resources created: half of the 70% infrastructure phase
+	if pct := infrastructureProgress(resources); pct != 35 {
+		t.Errorf("infrastructureProgress() = %d, want 35", pct)
+	}
+}
+
+func TestReportPhaseOnlyReportsChanges(t *testing.T) {
+	type phaseChange struct {
+		phase string
+		pct   int
+	}
+	var got []phaseChange
+	pm := &ProgressMonitor{
+		OnPhaseChange: func(phase string, pct int) { got = append(got, phaseChange{phase, pct}) },
+	}
+
+	pm.reportPhase(PhaseInfrastructure, 0)
+	pm.reportPhase(PhaseInfrastructure, 35)
+	pm.reportPhase(PhaseInfrastructure, 35)
+	pm.reportPhase(PhaseConfiguration, 70)
+	pm.reportPhase(PhaseComplete, 100)
+
+	want := []phaseChange{
+		{PhaseInfrastructure, 0},
+		{PhaseInfrastructure, 35},
+		{PhaseConfiguration, 70},
+		{PhaseComplete, 100},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("OnPhaseChange calls = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("call %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// Without a callback, reporting is a no-op
+	(&ProgressMonitor{}).reportPhase(PhaseFailed, 10)
+}
+
+func TestProgressMonitorPollInterval(t *testing.T) {
+	pm := &ProgressMonitor{}
+	if got := pm.interval(15 * time.Second); got != 15*time.Second {
+		t.Errorf("interval() without override = %v, want the phase default", got)
+	}
+
+	pm.SetPollInterval(45 * time.Second)
+	for _, def := range []time.Duration{10 * time.Second, 15 * time.Second} {
+		if got := pm.interval(def); got != 45*time.Second {
+			t.Errorf("interval(%v) = %v, want 45s", def, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/term"
+)
+
+// progressRenderer draws progress blocks. On a terminal each block replaces
+// the previous one in place; otherwise blocks are appended so logs and pipes
+// get plain text without ANSI escape codes.
+type progressRenderer struct {
+	out io.Writer
+	tty bool
+	// lines is the height of the block currently on screen
+	lines int
+}
+
+// newProgressRenderer creates a renderer for stdout, redrawing in place if
+// stdout is a terminal.
+func newProgressRenderer() *progressRenderer {
+	return &progressRenderer{
+		out: os.Stdout,
+		tty: term.IsTerminal(int(os.Stdout.Fd())),
+	}
+}
+
+// Render draws block, replacing the previously rendered block on a terminal.
+func (r *progressRenderer) Render(block string) {
+	if r.tty && r.lines > 0 {
+		// Move to the start of the previous block and clear to end of screen
+		fmt.Fprintf(r.out, "\033[%dA\r\033[J", r.lines)
+	}
+	io.WriteString(r.out, block)
+	r.lines = strings.Count(block, "\n")
+}
+
+// Done leaves the current block on screen so output printed after it is not
+// overwritten by the next Render.
+func (r *progressRenderer) Done() {
+	r.lines = 0
+}
+
+// progressBarLine renders a single-line progress bar at pct percent.
+func progressBarLine(pct int, options ...progressbar.Option) string {
+	var buf bytes.Buffer
+	options = append([]progressbar.Option{
+		progressbar.OptionSetWriter(&buf),
+		progressbar.OptionSetDescription("Progress"),
+		progressbar.OptionSetWidth(40),
+		progressbar.OptionShowCount(),
+	}, options...)
+
+	bar := progressbar.NewOptions(100, options...)
+	bar.Set(pct)
+
+	return strings.TrimLeft(buf.String(), "\r")
+}
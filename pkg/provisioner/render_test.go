@@ -0,0 +1,77 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func TestProgressRendererNonTTYAppends(t *testing.T) {
+	var out bytes.Buffer
+	pm := &ProgressMonitor{
+		stackName: "test-cluster",
+		startTime: time.Now(),
+		renderer:  &progressRenderer{out: &out, tty: false},
+	}
+
+	resources := map[string]*ResourceStatus{
+		"HeadNode": {LogicalID: "HeadNode", Type: "AWS::EC2::Instance", Status: types.ResourceStatusCreateInProgress},
+	}
+	pm.displayProgress(resources)
+
+	resources["HeadNode"].Status = types.ResourceStatusCreateComplete
+	pm.displayProgress(resources)
+	pm.displayDeleteProgress("Deletion", resources)
+
+	text := out.String()
+	if strings.Contains(text, "\033[") {
+		t.Errorf("Non-TTY output should not contain ANSI escape codes, got:\n%q", text)
+	}
+	if n := strings.Count(text, "📦 Infrastructure Provisioning:"); n != 2 {
+		t.Errorf("Expected both progress blocks to be appended, found %d", n)
+	}
+	if !strings.Contains(text, "Deletion: 0/1 resources deleted") {
+		t.Errorf("Expected delete progress to be appended, got:\n%s", text)
+	}
+}
+
+func TestProgressRendererTTYRedraws(t *testing.T) {
+	var out bytes.Buffer
+	r := &progressRenderer{out: &out, tty: true}
+
+	r.Render("line 1\nline 2\n")
+	if strings.Contains(out.String(), "\033[") {
+		t.Error("First render should not move the cursor")
+	}
+
+	out.Reset()
+	r.Render("line 1\nline 2 updated\n")
+	if !strings.HasPrefix(out.String(), "\033[2A\r\033[J") {
+		t.Errorf("Expected previous 2-line block to be cleared, got %q", out.String())
+	}
+
+	// After Done, the next block starts below the previous one
+	r.Done()
+	out.Reset()
+	r.Render("next phase\n")
+	if strings.Contains(out.String(), "\033[") {
+		t.Errorf("Expected no redraw after Done, got %q", out.String())
+	}
+}
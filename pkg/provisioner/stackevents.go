@@ -0,0 +1,101 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// cloudFormationAPI is the subset of the CloudFormation client used to
+// monitor stacks.
+type cloudFormationAPI interface {
+	DescribeStackEvents(ctx context.Context, params *cloudformation.DescribeStackEventsInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStackEventsOutput, error)
+	DescribeStacks(ctx context.Context, params *cloudformation.DescribeStacksInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error)
+}
+
+// stackEventCache accumulates a stack's events across polls. CloudFormation
+// returns events newest first, so each refresh pages back only until it
+// reaches events older than the newest one already cached.
+type stackEventCache struct {
+	// events are all events fetched so far, oldest first
+	events []types.StackEvent
+	// seen holds the IDs of cached events
+	seen map[string]bool
+	// lastSeen is the timestamp of the newest cached event
+	lastSeen time.Time
+	// fetched is set once the first refresh succeeds
+	fetched bool
+}
+
+// refresh fetches events newer than the cache and returns them oldest first.
+func (c *stackEventCache) refresh(ctx context.Context, client cloudFormationAPI, stackName string) ([]types.StackEvent, error) {
+	if c.seen == nil {
+		c.seen = make(map[string]bool)
+	}
+
+	var fresh []types.StackEvent
+	input := &cloudformation.DescribeStackEventsInput{
+		StackName: aws.String(stackName),
+	}
+	for {
+		result, err := client.DescribeStackEvents(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		reachedCached := false
+		for _, event := range result.StackEvents {
+			timestamp := aws.ToTime(event.Timestamp)
+			if c.fetched && timestamp.Before(c.lastSeen) {
+				reachedCached = true
+				break
+			}
+			// Events sharing the newest cached timestamp may be old or new
+			if id := aws.ToString(event.EventId); id != "" && c.seen[id] {
+				continue
+			}
+			fresh = append(fresh, event)
+		}
+
+		if reachedCached || result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	// Pages are newest first; the cache is oldest first
+	for i := len(fresh)/2 - 1; i >= 0; i-- {
+		opp := len(fresh) - 1 - i
+		fresh[i], fresh[opp] = fresh[opp], fresh[i]
+	}
+
+	for _, event := range fresh {
+		if id := aws.ToString(event.EventId); id != "" {
+			c.seen[id] = true
+		}
+		if timestamp := aws.ToTime(event.Timestamp); timestamp.After(c.lastSeen) {
+			c.lastSeen = timestamp
+		}
+	}
+	c.events = append(c.events, fresh...)
+	c.fetched = true
+
+	return fresh, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// fakeCloudFormation serves stack events newest first in pages of pageSize
+// and stack statuses in sequence, repeating the last one.
+type fakeCloudFormation struct {
+	events   []types.StackEvent // oldest first
+	pageSize int
+	statuses []types.StackStatus
+
+	eventCalls  int
+	statusCalls int
+}
+
+func (f *fakeCloudFormation) DescribeStackEvents(ctx context.Context, params *cloudformation.DescribeStackEventsInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStackEventsOutput, error) {
+	f.eventCalls++
+
+	newestFirst := make([]types.StackEvent, 0, len(f.events))
+	for i := len(f.events) - 1; i >= 0; i-- {
+		newestFirst = append(newestFirst, f.events[i])
+	}
+
+	start := 0
+	if params.NextToken != nil {
+		start, _ = strconv.Atoi(*params.NextToken)
+	}
+	end := min(start+f.pageSize, len(newestFirst))
+	out := &cloudformation.DescribeStackEventsOutput{StackEvents: newestFirst[start:end]}
+	if end < len(newestFirst) {
+		out.NextToken = aws.String(strconv.Itoa(end))
+	}
+	return out, nil
+}
+
+func (f *fakeCloudFormation) DescribeStacks(ctx context.Context, params *cloudformation.DescribeStacksInput, optFns ...func(*cloudformation.Options)) (*cloudformation.DescribeStacksOutput, error) {
+	status := f.statuses[min(f.statusCalls, len(f.statuses)-1)]
+	f.statusCalls++
+	return &cloudformation.DescribeStacksOutput{
+		Stacks: []types.Stack{{StackName: params.StackName, StackStatus: status}},
+	}, nil
+}
+
+// stackEvent builds a stack event with an ID at the given offset from a base time.
+func stackEvent(n int, logicalID string, status types.ResourceStatus, offset time.Duration) types.StackEvent {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	return types.StackEvent{
+		EventId:           aws.String(fmt.Sprintf("event-%d", n)),
+		LogicalResourceId: aws.String(logicalID),
+		ResourceType:      aws.String("AWS::EC2::Subnet"),
+		ResourceStatus:    status,
+		Timestamp:         aws.Time(base.Add(offset)),
+	}
+}
+
+func TestStackEventCacheIncremental(t *testing.T) {
+	fake := &fakeCloudFormation{pageSize: 2}
+	for i := 0; i < 5; i++ {
+		fake.events = append(fake.events, stackEvent(i, fmt.Sprintf("Subnet%d", i), types.ResourceStatusCreateInProgress, time.Duration(i)*time.Second))
+	}
+
+	var cache stackEventCache
+	fresh, err := cache.refresh(context.Background(), fake, "my-cluster")
+	if err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+	if fake.eventCalls != 3 {
+		t.Errorf("first refresh made %d calls, want 3 (all pages)", fake.eventCalls)
+	}
+	if len(fresh) != 5 || aws.ToString(fresh[0].EventId) != "event-0" || aws.ToString(fresh[4].EventId) != "event-4" {
+		t.Fatalf("first refresh returned %d events, want event-0..event-4 oldest first", len(fresh))
+	}
+
+	// A new event sharing the newest timestamp, and one after it
+	fake.events = append(fake.events,
+		stackEvent(5, "Subnet4", types.ResourceStatusCreateComplete, 4*time.Second),
+		stackEvent(6, "Subnet0", types.ResourceStatusCreateComplete, 5*time.Second),
+	)
+	fake.eventCalls = 0
+
+	fresh, err = cache.refresh(context.Background(), fake, "my-cluster")
+	if err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+	// The second page reaches events older than the newest cached one
+	if fake.eventCalls != 2 {
+		t.Errorf("incremental refresh made %d calls, want 2", fake.eventCalls)
+	}
+	if len(fresh) != 2 || aws.ToString(fresh[0].EventId) != "event-5" || aws.ToString(fresh[1].EventId) != "event-6" {
+		t.Errorf("incremental refresh returned %v, want event-5, event-6", eventIDs(fresh))
+	}
+	if len(cache.events) != 7 {
+		t.Errorf("cache holds %d events, want 7", len(cache.events))
+	}
+
+	fake.eventCalls = 0
+	fresh, err = cache.refresh(context.Background(), fake, "my-cluster")
+	if err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+	if fake.eventCalls != 1 || len(fresh) != 0 {
+		t.Errorf("refresh with no new events made %d calls and returned %d events, want 1 and 0", fake.eventCalls, len(fresh))
+	}
+}
+
+func TestMonitorInfrastructureFetchesEventsOncePerTick(t *testing.T) {
+	fake := &fakeCloudFormation{
+		pageSize: 100,
+		events: []types.StackEvent{
+			stackEvent(0, "PublicSubnet", types.ResourceStatusCreateInProgress, 0),
+			stackEvent(1, "PublicSubnet", types.ResourceStatusCreateFailed, time.Second),
+		},
+		// Stack exists, then one tick in progress, then failed
+		statuses: []types.StackStatus{
+			types.StackStatusCreateInProgress,
+			types.StackStatusCreateInProgress,
+			types.StackStatusCreateFailed,
+		},
+	}
+	pm := &ProgressMonitor{
+		cfnClient:    fake,
+		stackName:    "my-cluster",
+		clusterName:  "my-cluster",
+		startTime:    time.Now(),
+		renderer:     &progressRenderer{out: io.Discard},
+		out:          io.Discard,
+		pollInterval: time.Millisecond,
+	}
+
+	if err := pm.monitorInfrastructure(context.Background()); err == nil {
+		t.Fatal("monitorInfrastructure() expected an error for a failed stack")
+	}
+
+	// The initial check plus two ticks; failure details reuse the cache
+	if fake.eventCalls != 3 {
+		t.Errorf("DescribeStackEvents called %d times, want 3", fake.eventCalls)
+	}
+}
+
+// eventIDs returns the IDs of events, for error messages.
+func eventIDs(events []types.StackEvent) []string {
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, aws.ToString(event.EventId))
+	}
+	return ids
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// defaultClusterPollInterval is how often cluster updates and compute fleet
+// status changes are checked.
+const defaultClusterPollInterval = 15 * time.Second
+
+// updateTimeout bounds the wait for a cluster update.
+const updateTimeout = 60 * time.Minute
+
+// UpdateOptions contains options for cluster updates.
+type UpdateOptions struct {
+	// TemplatePath is the template the cluster is updated from
+	TemplatePath string
+	// StopFleet stops the compute fleet when the update requires it, and
+	// starts it again once the update has finished
+	StopFleet bool
+}
+
+// UpdateCluster applies template changes to a running cluster. The
+// ParallelCluster config is regenerated with the network, key, AMI,
+// bootstrap script, and tags the cluster was created with, since
+// ParallelCluster does not allow those to change.
+func (p *Provisioner) UpdateCluster(ctx context.Context, tmpl *template.Template, opts *UpdateOptions) error {
+	clusterState, err := p.stateManager.Load(tmpl.Cluster.Name)
+	if err != nil {
+		return fmt.Errorf("failed to load cluster state: %w", err)
+	}
+	if err := applyOverrides(tmpl, clusterState.Overrides); err != nil {
+		return err
+	}
+	if tmpl.Cluster.Region != clusterState.Region {
+		return fmt.Errorf("cluster %s is in %s, but the template targets %s; a cluster cannot be moved to another region",
+			clusterState.Name, clusterState.Region, tmpl.Cluster.Region)
+	}
+
+	if err := tmpl.Validate(); err != nil {
+		return fmt.Errorf("template validation failed: %w", err)
+	}
+
+	awsStatus, err := p.pcluster.DescribeCluster(ctx, clusterState.Name, clusterState.Region)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster status: %w", err)
+	}
+	if strings.HasSuffix(awsStatus.Status, "_IN_PROGRESS") || strings.HasPrefix(awsStatus.Status, "DELETE_") || awsStatus.Status == "CREATE_FAILED" {
+		return fmt.Errorf("cluster %s cannot be updated while its status is %s", clusterState.Name, awsStatus.Status)
+	}
+
+	subnetID := clusterState.SubnetID
+	if subnetID == "" {
+		subnetID = clusterState.PublicSubnetID
+	}
+	if subnetID == "" {
+		return fmt.Errorf("cluster %s has no head node subnet recorded in its state; it was created by an older pctl and must be recreated to be updated", clusterState.Name)
+	}
+	if !clusterState.NetworkManagedByPctl {
+		if err := p.checkInstanceTypeOfferings(ctx, tmpl, subnetID); err != nil {
+			return err
+		}
+	}
+	if err := p.checkQueueAMIs(ctx, tmpl); err != nil {
+		return err
+	}
+
+	persistentHomeID, err := p.updatePersistentHomeID(tmpl, clusterState)
+	if err != nil {
+		return err
+	}
+
+	placementGroups, err := p.updatePlacementGroups(ctx, tmpl, clusterState)
+	if err != nil {
+		return err
+	}
+
+	// Regenerate the ParallelCluster config around the cluster's existing
+	// resources
+	p.configGen.KeyName = clusterState.KeyName
+	p.configGen.SubnetID = subnetID
+	p.configGen.ComputeSubnetIDs = nil
+	switch {
+	case clusterState.NatGatewayID != "":
+		p.configGen.ComputeSubnetIDs = clusterState.PrivateSubnetIDs
+	case len(clusterState.PublicSubnetIDs) > 1:
+		p.configGen.ComputeSubnetIDs = clusterState.PublicSubnetIDs
+	}
+	p.configGen.CustomAMI = clusterState.CustomAMI
+	p.configGen.BootstrapScriptS3URI = clusterState.BootstrapScriptS3URI
+	p.configGen.TemplateName = templateName(opts.TemplatePath)
+	p.configGen.Owner = currentOwner()
+	p.configGen.Tags = nil
+	p.configGen.FixedTags = clusterState.Tags
+	p.configGen.PlacementGroups = placementGroups
+	p.configGen.HeadNodeSecurityGroups = nil
+	p.configGen.SSHAllowedIPs = ""
+	p.configGen.PersistentHomeID = persistentHomeID
+	if clusterState.NetworkManagedByPctl && (len(clusterState.SSHCIDRs) > 0 || len(tmpl.Network.IngressRules) > 0) {
+		// Keep the head node's SSH restricted as it was created
+		p.configGen.HeadNodeSecurityGroups = []string{clusterState.SecurityGroupID}
+		if len(clusterState.SSHCIDRs) > 0 {
+			p.configGen.SSHAllowedIPs = clusterState.SSHCIDRs[0]
+		}
+	}
+
+	if changed := changedSeedTags(tmpl.Cluster.Tags, clusterState.Tags); len(changed) > 0 {
+		fmt.Printf("⚠️  Warning: ParallelCluster cannot change a cluster's tags; ignoring seed tag changes to %s\n", strings.Join(changed, ", "))
+	}
+
+	pcConfig, err := p.configGen.Generate(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to generate ParallelCluster config: %w", err)
+	}
+
+	configPath, err := p.writeConfigFile(clusterState.Name, pcConfig)
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	defer os.Remove(configPath)
+
+	fmt.Printf("🔧 Initiating cluster update...\n")
+	err = p.pcluster.UpdateCluster(ctx, clusterState.Name, configPath, clusterState.Region)
+	if err != nil && fleetStopRequired(err) {
+		if !opts.StopFleet {
+			return fmt.Errorf("this update requires the compute fleet of cluster %s to be stopped first\n\nRetry with --stop-fleet to stop it, update, and start it again, or stop it yourself:\n  pcluster update-compute-fleet --cluster-name %s --status %s --region %s",
+				clusterState.Name, clusterState.Name, fleetStopRequested, clusterState.Region)
+		}
+
+		if err := p.stopComputeFleet(ctx, clusterState); err != nil {
+			return err
+		}
+		defer p.startComputeFleet(ctx, clusterState)
+
+		fmt.Printf("🔧 Retrying cluster update...\n")
+		err = p.pcluster.UpdateCluster(ctx, clusterState.Name, configPath, clusterState.Region)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update cluster: %w", err)
+	}
+
+	clusterState.Status = "UPDATE_IN_PROGRESS"
+	if err := p.stateManager.Save(clusterState); err != nil {
+		return fmt.Errorf("failed to update state: %w", err)
+	}
+
+	fmt.Printf("⏳ Waiting for the update to complete...\n")
+	var finalStatus string
+	waitErr := p.waitForCluster(ctx, clusterState, updateTimeout, func(status *ClusterStatus) bool {
+		finalStatus = status.Status
+		return finalStatus != "UPDATE_IN_PROGRESS"
+	})
+	if waitErr != nil {
+		return fmt.Errorf("failed to wait for cluster update: %w", waitErr)
+	}
+
+	clusterState.Status = finalStatus
+	if finalStatus == "UPDATE_COMPLETE" {
+		clusterState.TemplatePath = absTemplatePath(opts.TemplatePath)
+	}
+	if err := p.stateManager.Save(clusterState); err != nil {
+		return fmt.Errorf("failed to update state: %w", err)
+	}
+	if finalStatus != "UPDATE_COMPLETE" {
+		return fmt.Errorf("cluster update finished with status %s; ParallelCluster rolls failed updates back to the previous configuration", finalStatus)
+	}
+
+	if len(tmpl.Compute.HeadNodeTags) > 0 {
+		if err := p.applyHeadNodeTags(ctx, tmpl); err != nil {
+			fmt.Printf("⚠️  Warning: failed to apply head node tags: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// fleetStopRequired reports whether pcluster rejected an update because the
+// compute fleet has to be stopped first.
+func fleetStopRequired(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "compute nodes must be stopped") || strings.Contains(msg, "stop the compute fleet")
+}
+
+// updatePersistentHomeID returns the volume ID of the persistent /home the
+// cluster already mounts. The shared /home cannot be swapped by an update.
+func (p *Provisioner) updatePersistentHomeID(tmpl *template.Template, clusterState *state.ClusterState) (string, error) {
+	var name string
+	if tmpl.Data.PersistentHome != nil {
+		name = tmpl.Data.PersistentHome.Name
+	}
+	if name != clusterState.PersistentHome {
+		return "", fmt.Errorf("the persistent home of cluster %s cannot be changed by an update (mounted: %q, template: %q)",
+			clusterState.Name, clusterState.PersistentHome, name)
+	}
+	if name == "" {
+		return "", nil
+	}
+
+	id := p.persistentHomeID(clusterState)
+	if id == "" {
+		return "", fmt.Errorf("persistent home %s of cluster %s not found in state", name, clusterState.Name)
+	}
+	return id, nil
+}
+
+// updatePlacementGroups returns the placement groups of the template's queues
+// keyed by queue name, creating the groups of queues that did not have one.
+// New groups are recorded in cluster state so they are deleted with it.
+func (p *Provisioner) updatePlacementGroups(ctx context.Context, tmpl *template.Template, clusterState *state.ClusterState) (map[string]string, error) {
+	existing := make(map[string]bool)
+	for _, name := range clusterState.PlacementGroups {
+		existing[name] = true
+	}
+
+	groups := make(map[string]string)
+	for _, queue := range tmpl.Compute.Queues {
+		if !queue.PlacementGroup {
+			continue
+		}
+
+		name := placementGroupName(clusterState.Name, queue.Name)
+		if !existing[name] {
+			if err := p.createPlacementGroup(ctx, clusterState.Region, clusterState.Name, name); err != nil {
+				return nil, fmt.Errorf("failed to create placement group %s: %w", name, err)
+			}
+			fmt.Printf("✅ Placement group for queue %s: %s\n", queue.Name, name)
+			clusterState.PlacementGroups = append(clusterState.PlacementGroups, name)
+		}
+		groups[queue.Name] = name
+	}
+
+	return groups, nil
+}
+
+// waitForCluster polls the cluster's status until done returns true, giving
+// up after timeout.
+func (p *Provisioner) waitForCluster(ctx context.Context, clusterState *state.ClusterState, timeout time.Duration, done func(*ClusterStatus) bool) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		status, err := p.pcluster.DescribeCluster(ctx, clusterState.Name, clusterState.Region)
+		if err != nil {
+			return fmt.Errorf("failed to get cluster status: %w", err)
+		}
+		if done(status) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out after %s (last status: %s, compute fleet: %s)", timeout, status.Status, status.SchedulerState)
+			}
+			return ctx.Err()
+		case <-time.After(p.clusterPollInterval):
+		}
+	}
+}
+
+// applyOverrides re-applies the create flags recorded in a cluster's state,
+// so an update from the seed does not undo them.
+func applyOverrides(tmpl *template.Template, overrides *state.TemplateOverrides) error {
+	if overrides == nil {
+		return nil
+	}
+	if overrides.Region != "" {
+		tmpl.Cluster.Region = overrides.Region
+	}
+	if overrides.EFAGDR {
+		tmpl.EnableGPUDirectRDMA()
+	}
+	for queueName, groupARN := range overrides.CapacityReservationGroups {
+		if err := tmpl.SetCapacityReservationGroup(queueName, groupARN); err != nil {
+			return fmt.Errorf("failed to re-apply --compute-on-demand-capacity: %w", err)
+		}
+	}
+	if overrides.ScaleToZero {
+		tmpl.ScaleToZero()
+	}
+	return nil
+}
+
+// changedSeedTags returns the sorted keys of seed tags that are missing from,
+// or differ in, the tags the cluster was created with. Clusters created
+// before their tags were recorded have none to compare against.
+func changedSeedTags(seedTags, clusterTags map[string]string) []string {
+	if clusterTags == nil {
+		return nil
+	}
+
+	var changed []string
+	for key, value := range seedTags {
+		if current, ok := clusterTags[key]; !ok || current != value {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,314 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	pcconfig "github.com/scttfrdmn/petal/pkg/config"
+	"github.com/scttfrdmn/petal/pkg/state"
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// fleetStopError is how pcluster rejects updates that need a stopped fleet.
+var fleetStopError = errors.New(`pcluster update-cluster failed: exit status 1: {"message": "Update failure", "changeSet": [{"parameter": "Scheduling.SlurmQueues[compute].ComputeResources[compute].InstanceType", "reason": "All compute nodes must be stopped. Stop the compute fleet with the pcluster update-compute-fleet command"}]}`)
+
+// newUpdateTestProvisioner returns a provisioner with a running
+// test-cluster, created by pctl with its own network.
+func newUpdateTestProvisioner(t *testing.T, calls *[]string) (*Provisioner, *fakePCluster) {
+	t.Helper()
+
+	p := newTestProvisioner(t, calls)
+	pcluster := &fakePCluster{
+		calls: calls,
+		clusters: map[string]*ClusterStatus{
+			"test-cluster": {Name: "test-cluster", Status: "CREATE_COMPLETE", SchedulerState: "RUNNING"},
+		},
+	}
+	p.pcluster = pcluster
+	p.configGen = pcconfig.NewGenerator()
+	p.createPlacementGroup = func(ctx context.Context, region, clusterName, name string) error {
+		*calls = append(*calls, "create-placement-group:"+name)
+		return nil
+	}
+
+	err := p.stateManager.Save(&state.ClusterState{
+		Name:                 "test-cluster",
+		Region:               "us-east-1",
+		Status:               "CREATE_COMPLETE",
+		StackName:            "test-cluster",
+		KeyName:              "my-key",
+		SubnetID:             "subnet-public",
+		PublicSubnetID:       "subnet-public",
+		PublicSubnetIDs:      []string{"subnet-public"},
+		SecurityGroupID:      "sg-123",
+		NetworkManagedByPctl: true,
+		Tags:                 map[string]string{"pctl:owner": "alice", "pctl:version": "0.9.0"},
+	})
+	if err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+	return p, pcluster
+}
+
+func TestUpdateClusterSuccess(t *testing.T) {
+	var calls []string
+	p, pcluster := newUpdateTestProvisioner(t, &calls)
+
+	tmpl := createTestTemplate()
+	tmpl.Compute.Queues[0].MaxCount = 20
+	if err := p.UpdateCluster(context.Background(), tmpl, &UpdateOptions{TemplatePath: "cluster.yaml"}); err != nil {
+		t.Fatalf("UpdateCluster() failed: %v", err)
+	}
+
+	expected := []string{"describe-cluster", "update-cluster", "describe-cluster"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+	for _, want := range []string{"SubnetId: subnet-public", "KeyName: my-key", "MaxCount: 20", "Value: alice", "Value: 0.9.0"} {
+		if !strings.Contains(pcluster.config, want) {
+			t.Errorf("Expected update config to contain %q:\n%s", want, pcluster.config)
+		}
+	}
+
+	saved, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if saved.Status != "UPDATE_COMPLETE" {
+		t.Errorf("Expected status UPDATE_COMPLETE, got %s", saved.Status)
+	}
+	if !strings.HasSuffix(saved.TemplatePath, "/cluster.yaml") {
+		t.Errorf("Expected absolute template path, got %q", saved.TemplatePath)
+	}
+}
+
+func TestUpdateClusterFleetStopRequired(t *testing.T) {
+	var calls []string
+	p, pcluster := newUpdateTestProvisioner(t, &calls)
+	pcluster.updateErrs = []error{fleetStopError}
+
+	err := p.UpdateCluster(context.Background(), createTestTemplate(), &UpdateOptions{})
+	if err == nil {
+		t.Fatal("Expected an error when the compute fleet must be stopped")
+	}
+	for _, want := range []string{"--stop-fleet", "pcluster update-compute-fleet --cluster-name test-cluster --status STOP_REQUESTED"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got: %v", want, err)
+		}
+	}
+
+	expected := []string{"describe-cluster", "update-cluster"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+}
+
+func TestUpdateClusterStopFleet(t *testing.T) {
+	var calls []string
+	p, pcluster := newUpdateTestProvisioner(t, &calls)
+	pcluster.updateErrs = []error{fleetStopError}
+
+	if err := p.UpdateCluster(context.Background(), createTestTemplate(), &UpdateOptions{StopFleet: true}); err != nil {
+		t.Fatalf("UpdateCluster() failed: %v", err)
+	}
+
+	expected := []string{
+		"describe-cluster",
+		"update-cluster",
+		"describe-cluster",
+		"update-compute-fleet:STOP_REQUESTED",
+		"describe-cluster",
+		"update-cluster",
+		"describe-cluster",
+		"describe-cluster",
+		"update-compute-fleet:START_REQUESTED",
+		"describe-cluster",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+	if fleet := pcluster.clusters["test-cluster"].SchedulerState; fleet != "RUNNING" {
+		t.Errorf("Expected the compute fleet to be restarted, got %s", fleet)
+	}
+}
+
+func TestUpdateClusterRolledBack(t *testing.T) {
+	var calls []string
+	p, pcluster := newUpdateTestProvisioner(t, &calls)
+	pcluster.updateStatus = "UPDATE_FAILED"
+
+	err := p.UpdateCluster(context.Background(), createTestTemplate(), &UpdateOptions{TemplatePath: "cluster.yaml"})
+	if err == nil || !strings.Contains(err.Error(), "UPDATE_FAILED") {
+		t.Fatalf("Expected UPDATE_FAILED error, got: %v", err)
+	}
+
+	saved, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if saved.Status != "UPDATE_FAILED" {
+		t.Errorf("Expected status UPDATE_FAILED, got %s", saved.Status)
+	}
+	if saved.TemplatePath != "" {
+		t.Errorf("Expected template path to be unchanged by a failed update, got %q", saved.TemplatePath)
+	}
+}
+
+func TestUpdateClusterCreatesNewPlacementGroups(t *testing.T) {
+	var calls []string
+	p, pcluster := newUpdateTestProvisioner(t, &calls)
+
+	tmpl := createTestTemplate()
+	tmpl.Compute.Queues[0].PlacementGroup = true
+	if err := p.UpdateCluster(context.Background(), tmpl, &UpdateOptions{}); err != nil {
+		t.Fatalf("UpdateCluster() failed: %v", err)
+	}
+
+	if calls[1] != "create-placement-group:pctl-test-cluster-compute" {
+		t.Errorf("Expected the placement group to be created before updating, got %v", calls)
+	}
+	if !strings.Contains(pcluster.config, "pctl-test-cluster-compute") {
+		t.Errorf("Expected update config to use the placement group:\n%s", pcluster.config)
+	}
+
+	saved, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	if !reflect.DeepEqual(saved.PlacementGroups, []string{"pctl-test-cluster-compute"}) {
+		t.Errorf("Expected new placement group in state, got %v", saved.PlacementGroups)
+	}
+
+	// A second update reuses the recorded group
+	calls = nil
+	if err := p.UpdateCluster(context.Background(), tmpl, &UpdateOptions{}); err != nil {
+		t.Fatalf("second UpdateCluster() failed: %v", err)
+	}
+	for _, call := range calls {
+		if strings.HasPrefix(call, "create-placement-group") {
+			t.Errorf("Expected the existing placement group to be reused, got %v", calls)
+		}
+	}
+}
+
+func TestUpdateClusterRejectsUnchangeableSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(tmpl *template.Template)
+		wantErr string
+	}{
+		{
+			name:    "unknown cluster",
+			modify:  func(tmpl *template.Template) { tmpl.Cluster.Name = "missing" },
+			wantErr: "not found",
+		},
+		{
+			name:    "region change",
+			modify:  func(tmpl *template.Template) { tmpl.Cluster.Region = "us-west-2" },
+			wantErr: "another region",
+		},
+		{
+			name: "persistent home added",
+			modify: func(tmpl *template.Template) {
+				tmpl.Data.PersistentHome = &template.PersistentHome{Name: "lab-home"}
+			},
+			wantErr: "persistent home",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			p, _ := newUpdateTestProvisioner(t, &calls)
+
+			tmpl := createTestTemplate()
+			tt.modify(tmpl)
+			err := p.UpdateCluster(context.Background(), tmpl, &UpdateOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+			for _, call := range calls {
+				if call == "update-cluster" {
+					t.Errorf("Expected no update to be attempted, got %v", calls)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateClusterReappliesCreateOverrides(t *testing.T) {
+	var calls []string
+	p, pcluster := newUpdateTestProvisioner(t, &calls)
+
+	clusterState, err := p.stateManager.Load("test-cluster")
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	clusterState.SSHCIDRs = []string{"203.0.113.0/24"}
+	clusterState.Overrides = &state.TemplateOverrides{Region: "us-east-1", ScaleToZero: true}
+	if err := p.stateManager.Save(clusterState); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+
+	// The seed names another region and keeps nodes running; the cluster
+	// was created with --region and --scale-to-zero
+	tmpl := createTestTemplate()
+	tmpl.Cluster.Region = "us-west-2"
+	tmpl.Compute.Queues[0].MinCount = 2
+	if err := p.UpdateCluster(context.Background(), tmpl, &UpdateOptions{}); err != nil {
+		t.Fatalf("UpdateCluster() failed: %v", err)
+	}
+
+	for _, want := range []string{"AllowedIps: 203.0.113.0/24", "- sg-123", "MinCount: 0"} {
+		if !strings.Contains(pcluster.config, want) {
+			t.Errorf("Expected update config to contain %q:\n%s", want, pcluster.config)
+		}
+	}
+	if strings.Contains(pcluster.config, "MinCount: 2") {
+		t.Errorf("Expected --scale-to-zero to be re-applied:\n%s", pcluster.config)
+	}
+}
+
+func TestUpdateClusterKeepsCreatedTags(t *testing.T) {
+	var calls []string
+	p, pcluster := newUpdateTestProvisioner(t, &calls)
+
+	tmpl := createTestTemplate()
+	tmpl.Cluster.Tags = map[string]string{"project": "genomics", "pctl:owner": "alice"}
+	tmpl.Software.SpackPackages = []string{"gcc@11.3.0"}
+	if err := p.UpdateCluster(context.Background(), tmpl, &UpdateOptions{TemplatePath: "cluster.yaml"}); err != nil {
+		t.Fatalf("UpdateCluster() failed: %v", err)
+	}
+
+	for _, want := range []string{"Key: pctl:owner", "Value: alice", "Key: pctl:version", "Value: 0.9.0"} {
+		if !strings.Contains(pcluster.config, want) {
+			t.Errorf("Expected update config to contain %q:\n%s", want, pcluster.config)
+		}
+	}
+	for _, unwanted := range []string{"project", "pctl:template", "pctl:fingerprint"} {
+		if strings.Contains(pcluster.config, unwanted) {
+			t.Errorf("Expected update config to keep the created tags only, found %q:\n%s", unwanted, pcluster.config)
+		}
+	}
+
+	if got := changedSeedTags(tmpl.Cluster.Tags, map[string]string{"pctl:owner": "alice"}); !reflect.DeepEqual(got, []string{"project"}) {
+		t.Errorf("changedSeedTags() = %v, want [project]", got)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SinfoSummaryCommand prints one line per partition with its node counts by
+// state, as read by ParseSinfoSummary.
+const SinfoSummaryCommand = "sinfo -s"
+
+// PendingJobsCommand prints the number of jobs waiting in the Slurm queue,
+// failing if squeue does.
+const PendingJobsCommand = "set -o pipefail; squeue -h -t PENDING | wc -l"
+
+// RunningJobsCommand prints the number of running Slurm jobs, failing if
+// squeue does.
+const RunningJobsCommand = "set -o pipefail; squeue -h -t RUNNING | wc -l"
+
+// PartitionUtilization is a Slurm partition's node counts as reported by
+// `sinfo -s`. Powered-down cloud nodes count as idle.
+type PartitionUtilization struct {
+	Name string `json:"name"`
+	// Default is set for the partition jobs go to when none is given
+	Default bool `json:"default"`
+	// Available is set when the partition is up
+	Available bool `json:"available"`
+	Allocated int  `json:"allocated"`
+	Idle      int  `json:"idle"`
+	// Other is nodes that are neither allocated nor idle, e.g., down or drained
+	Other int `json:"other"`
+	Total int `json:"total"`
+}
+
+// Percent returns the share of the partition's nodes that are allocated.
+func (p PartitionUtilization) Percent() float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return float64(p.Allocated) / float64(p.Total) * 100
+}
+
+// SlurmUtilization summarizes how busy a cluster's scheduler is.
+type SlurmUtilization struct {
+	Partitions  []PartitionUtilization `json:"partitions"`
+	PendingJobs int                    `json:"pending_jobs"`
+}
+
+// ParseSinfoSummary parses `sinfo -s` output, with or without its header:
+//
+//	PARTITION AVAIL  TIMELIMIT   NODES(A/I/O/T) NODELIST
+//	compute*     up   infinite        2/8/0/10 compute-dy-c5xlarge-[1-10]
+func ParseSinfoSummary(output string) ([]PartitionUtilization, error) {
+	var partitions []PartitionUtilization
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] == "PARTITION" {
+			continue
+		}
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("unexpected sinfo -s line %q", line)
+		}
+
+		counts := strings.Split(fields[3], "/")
+		if len(counts) != 4 {
+			return nil, fmt.Errorf("unexpected node counts in sinfo -s line %q", line)
+		}
+		var n [4]int
+		for i, count := range counts {
+			value, err := strconv.Atoi(count)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected node counts in sinfo -s line %q", line)
+			}
+			n[i] = value
+		}
+
+		partitions = append(partitions, PartitionUtilization{
+			Name:      strings.TrimSuffix(fields[0], "*"),
+			Default:   strings.HasSuffix(fields[0], "*"),
+			Available: fields[1] == "up",
+			Allocated: n[0],
+			Idle:      n[1],
+			Other:     n[2],
+			Total:     n[3],
+		})
+	}
+
+	return partitions, nil
+}
+
+// ParseJobCount parses the line count printed by PendingJobsCommand.
+func ParseJobCount(output string) (int, error) {
+	count, err := strconv.Atoi(strings.TrimSpace(output))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected job count %q", strings.TrimSpace(output))
+	}
+	return count, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sampleSinfoSummary is `sinfo -s` output from a cluster with a busy
+// default queue, an idle GPU queue, and a drained debug queue.
+const sampleSinfoSummary = `PARTITION AVAIL  TIMELIMIT   NODES(A/I/O/T) NODELIST
+compute*     up   infinite        6/4/0/10 compute-dy-c5xlarge-[1-10]
+gpu          up   infinite         0/4/0/4 gpu-dy-g4dnxlarge-[1-4]
+debug      down   infinite         0/0/1/1 debug-st-t3medium-1
+`
+
+func TestParseSinfoSummary(t *testing.T) {
+	partitions, err := ParseSinfoSummary(sampleSinfoSummary)
+	if err != nil {
+		t.Fatalf("ParseSinfoSummary() error = %v", err)
+	}
+
+	want := []PartitionUtilization{
+		{Name: "compute", Default: true, Available: true, Allocated: 6, Idle: 4, Total: 10},
+		{Name: "gpu", Available: true, Idle: 4, Total: 4},
+		{Name: "debug", Other: 1, Total: 1},
+	}
+	if !reflect.DeepEqual(partitions, want) {
+		t.Errorf("ParseSinfoSummary() = %+v, want %+v", partitions, want)
+	}
+
+	if got := partitions[0].Percent(); got != 60 {
+		t.Errorf("Percent() = %v, want 60", got)
+	}
+	if got := (PartitionUtilization{}).Percent(); got != 0 {
+		t.Errorf("Percent() of an empty partition = %v, want 0", got)
+	}
+}
+
+func TestParseSinfoSummaryInvalid(t *testing.T) {
+	for _, output := range []string{
+		"compute* up infinite",
+		"compute* up infinite 6/4/10 compute-dy-[1-10]",
+		"compute* up infinite a/b/c/d compute-dy-[1-10]",
+	} {
+		if _, err := ParseSinfoSummary(output); err == nil {
+			t.Errorf("ParseSinfoSummary(%q) should fail", output)
+		}
+	}
+}
+
+func TestParseJobCount(t *testing.T) {
+	if count, err := ParseJobCount("  12\n"); err != nil || count != 12 {
+		t.Errorf("ParseJobCount() = %d, %v, want 12", count, err)
+	}
+	if _, err := ParseJobCount("squeue: error: Unable to contact slurm controller"); err == nil {
+		t.Error("ParseJobCount() should fail on non-numeric output")
+	}
+}
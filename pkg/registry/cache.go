@@ -0,0 +1,83 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultIndexCacheTTL is how long a cached registry index is used before it
+// is fetched again.
+const DefaultIndexCacheTTL = time.Hour
+
+// CacheNeverExpires is a CacheTTL that keeps a cached index until Refresh.
+const CacheNeverExpires time.Duration = -1
+
+// cachedIndex is a registry index stored on disk with the time it was
+// fetched.
+type cachedIndex struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Index     json.RawMessage `json:"index"`
+}
+
+// indexCachePath returns where the registry's index is cached, keyed by
+// owner, repository, branch, and base path.
+func (g *GitHubRegistry) indexCachePath() string {
+	return filepath.Join(g.CacheDir, url.PathEscape(g.Owner), url.PathEscape(g.Repo), url.PathEscape(g.Branch), url.PathEscape(g.BasePath), "index.json")
+}
+
+// loadCachedIndex returns the cached index if it is still fresh. A missing,
+// unreadable, or expired cache is a miss, as is every lookup with a zero TTL.
+func (g *GitHubRegistry) loadCachedIndex() ([]byte, bool) {
+	if g.CacheDir == "" || g.Refresh || g.CacheTTL == 0 {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(g.indexCachePath())
+	if err != nil {
+		return nil, false
+	}
+
+	var cached cachedIndex
+	if err := json.Unmarshal(data, &cached); err != nil || len(cached.Index) == 0 {
+		return nil, false
+	}
+	if g.CacheTTL > 0 && time.Since(cached.FetchedAt) > g.CacheTTL {
+		return nil, false
+	}
+
+	return cached.Index, true
+}
+
+// saveCachedIndex stores a freshly fetched index.
+func (g *GitHubRegistry) saveCachedIndex(index []byte) error {
+	if g.CacheDir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(cachedIndex{FetchedAt: time.Now(), Index: index})
+	if err != nil {
+		return fmt.Errorf("failed to encode registry cache: %w", err)
+	}
+	if err := writeFileAtomic(g.indexCachePath(), data); err != nil {
+		return fmt.Errorf("failed to write registry cache: %w", err)
+	}
+	return nil
+}
@@ -16,11 +16,11 @@ package registry
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
+	"path"
 	"strings"
 	"time"
 )
@@ -35,8 +35,23 @@ type GitHubRegistry struct {
 	Branch string
 	// BasePath is the base path in the repo for templates (default: templates)
 	BasePath string
+	// Token authenticates reads for private repositories. When set, files
+	// are read through the GitHub Contents API instead of
+	// raw.githubusercontent.com, which cannot serve private repositories.
+	Token string
+	// CacheDir caches the registry index on disk so repeated lists and
+	// searches skip the network. Empty disables caching.
+	CacheDir string
+	// CacheTTL is how long a cached index is used before it is fetched
+	// again. Zero skips the cache; a negative TTL, such as CacheNeverExpires,
+	// keeps the cached index until Refresh.
+	CacheTTL time.Duration
+	// Refresh ignores the cached index and fetches a fresh one.
+	Refresh bool
 	// client is the HTTP client
 	client *http.Client
+	// apiURL is the GitHub REST API base URL
+	apiURL string
 }
 
 // NewGitHubRegistry creates a new GitHub-based registry.
@@ -46,12 +61,55 @@ func NewGitHubRegistry(owner, repo string) *GitHubRegistry {
 		Repo:     repo,
 		Branch:   "main",
 		BasePath: "seeds",
+		CacheTTL: DefaultIndexCacheTTL,
 		client:   &http.Client{Timeout: 30 * time.Second},
+		apiURL:   "https://api.github.com",
 	}
 }
 
-// List returns all available templates from the GitHub registry.
+// String returns the registry's repository, e.g. github.com/owner/repo.
+func (g *GitHubRegistry) String() string {
+	return fmt.Sprintf("github.com/%s/%s", g.Owner, g.Repo)
+}
+
+// List returns all available templates from the GitHub registry. The index
+// is read from the cache when CacheDir holds a fresh copy.
 func (g *GitHubRegistry) List() ([]*TemplateMetadata, error) {
+	data, cached := g.loadCachedIndex()
+	if !cached {
+		var err error
+		data, err = g.fetchIndex()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var templates []*TemplateMetadata
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse registry index: %w", err)
+	}
+
+	if !cached {
+		// A cache that cannot be written only costs the next fetch
+		_ = g.saveCachedIndex(data)
+	}
+
+	return templates, nil
+}
+
+// fetchIndex downloads the registry's index.json.
+func (g *GitHubRegistry) fetchIndex() ([]byte, error) {
+	if g.Token != "" {
+		data, _, err := g.getContents(path.Join(g.BasePath, "index.json"), g.Token)
+		if errors.Is(err, errContentNotFound) {
+			return nil, fmt.Errorf("registry index not found (status %d)", http.StatusNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch registry index: %w", err)
+		}
+		return data, nil
+	}
+
 	// Fetch the registry index file
 	indexURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s/index.json",
 		g.Owner, g.Repo, g.Branch, g.BasePath)
@@ -62,16 +120,19 @@ func (g *GitHubRegistry) List() ([]*TemplateMetadata, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("registry index not found (status %d); set GITHUB_TOKEN, or the source's token in the config file, if the registry is private", resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("registry index not found (status %d)", resp.StatusCode)
 	}
 
-	var templates []*TemplateMetadata
-	if err := json.NewDecoder(resp.Body).Decode(&templates); err != nil {
-		return nil, fmt.Errorf("failed to parse registry index: %w", err)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry index: %w", err)
 	}
 
-	return templates, nil
+	return data, nil
 }
 
 // Search searches for templates by keyword.
@@ -112,59 +173,83 @@ func (g *GitHubRegistry) Get(name string) (string, error) {
 		return "", err
 	}
 
-	// Construct raw GitHub URL
-	templateURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s/%s",
-		g.Owner, g.Repo, g.Branch, g.BasePath, metadata.Path)
+	content, err := g.fetch(metadata.Path)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
 
-	resp, err := g.client.Get(templateURL)
+// fetch downloads a file from the registry, relative to BasePath. A response
+// shorter than its Content-Length is reported as an incomplete download.
+func (g *GitHubRegistry) fetch(filePath string) ([]byte, error) {
+	if g.Token != "" {
+		content, _, err := g.getContents(path.Join(g.BasePath, filePath), g.Token)
+		if errors.Is(err, errContentNotFound) {
+			return nil, fmt.Errorf("%s not found (status %d)", filePath, http.StatusNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", filePath, err)
+		}
+		return content, nil
+	}
+
+	fileURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s/%s",
+		g.Owner, g.Repo, g.Branch, g.BasePath, filePath)
+
+	resp, err := g.client.Get(fileURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch template: %w", err)
+		return nil, fmt.Errorf("failed to fetch %s: %w", filePath, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("template not found (status %d)", resp.StatusCode)
+		return nil, fmt.Errorf("%s not found (status %d)", filePath, resp.StatusCode)
 	}
 
 	content, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read template: %w", err)
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
 	}
 
-	return string(content), nil
+	if resp.ContentLength >= 0 && int64(len(content)) != resp.ContentLength {
+		return nil, fmt.Errorf("incomplete download of %s: got %d of %d bytes", filePath, len(content), resp.ContentLength)
+	}
+
+	return content, nil
 }
 
-// GetMetadata retrieves metadata for a template.
+// GetMetadata retrieves metadata for a template. The name may pin a version
+// as name@version; otherwise the highest version is returned.
 func (g *GitHubRegistry) GetMetadata(name string) (*TemplateMetadata, error) {
 	all, err := g.List()
 	if err != nil {
 		return nil, err
 	}
 
-	for _, tmpl := range all {
-		if tmpl.Name == name {
-			return tmpl, nil
-		}
-	}
-
-	return nil, fmt.Errorf("template %q not found", name)
+	return SelectVersion(all, name)
 }
 
-// Pull downloads a template to local filesystem.
+// Pull downloads a template to local filesystem. Files are written atomically,
+// so an interrupted pull never leaves a truncated file behind. Templates with
+// additional files are pulled resumably; see pullFiles.
 func (g *GitHubRegistry) Pull(name, destination string) error {
-	content, err := g.Get(name)
+	metadata, err := g.GetMetadata(name)
 	if err != nil {
 		return err
 	}
 
-	// Ensure destination directory exists
-	dir := filepath.Dir(destination)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create destination directory: %w", err)
+	if len(metadata.Files) > 0 {
+		return g.pullFiles(metadata, destination)
+	}
+
+	content, err := g.fetch(metadata.Path)
+	if err != nil {
+		return err
 	}
 
-	// Write template to file
-	if err := os.WriteFile(destination, []byte(content), 0644); err != nil {
+	if err := writeFileAtomic(destination, content); err != nil {
 		return fmt.Errorf("failed to write template: %w", err)
 	}
 
@@ -0,0 +1,308 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// Template metadata keys read when building an index entry.
+const (
+	MetadataName        = "name"
+	MetadataTitle       = "title"
+	MetadataDescription = "description"
+	MetadataAuthor      = "author"
+	MetadataVersion     = "version"
+	// MetadataTags is a comma-separated list of tags
+	MetadataTags = "tags"
+)
+
+// slugPattern matches the template names and versions that may appear in
+// registry paths.
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// errContentNotFound is returned when a file does not exist in the repository.
+var errContentNotFound = errors.New("not found")
+
+// NewIndexEntry computes the registry index entry for a template file.
+// Metadata comes from the template's metadata section; the name defaults to
+// the file name and the title to the file's leading comment line. The entry
+// points at <name>.yaml in the registry, or <name>/<version>.yaml for a
+// versioned template so earlier versions stay available, and records the
+// content's SHA-256. The name and version must be lowercase slugs, since
+// they become registry paths.
+func NewIndexEntry(tmpl *template.Template, filename string, content []byte, source string, updatedAt time.Time) (*TemplateMetadata, error) {
+	name := tmpl.Metadata[MetadataName]
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+
+	title := tmpl.Metadata[MetadataTitle]
+	if title == "" {
+		title = leadingComment(content)
+	}
+	if title == "" {
+		title = tmpl.Cluster.Name
+	}
+
+	tags := []string{}
+	for _, tag := range strings.Split(tmpl.Metadata[MetadataTags], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	version := tmpl.Metadata[MetadataVersion]
+	if err := validateSlugs(name, version); err != nil {
+		return nil, err
+	}
+
+	return &TemplateMetadata{
+		Name:        name,
+		Title:       title,
+		Description: tmpl.Metadata[MetadataDescription],
+		Author:      tmpl.Metadata[MetadataAuthor],
+		Version:     version,
+		Tags:        tags,
+		Source:      source,
+		Path:        entryPath(name, version),
+		SHA256:      contentHash(content),
+		UpdatedAt:   updatedAt.UTC(),
+	}, nil
+}
+
+// entryPath returns the registry path of a template: <name>.yaml, or
+// <name>/<version>.yaml for a versioned template.
+func entryPath(name, version string) string {
+	if version == "" {
+		return name + ".yaml"
+	}
+	return path.Join(name, version+".yaml")
+}
+
+// validateSlugs checks that a template name and optional version are slugs
+// that cannot escape the registry directory.
+func validateSlugs(name, version string) error {
+	if !slugPattern.MatchString(name) {
+		return fmt.Errorf("invalid template name %q: set metadata.name to lowercase letters, digits, '.', '_', or '-', starting with a letter or digit", name)
+	}
+	if version != "" && !slugPattern.MatchString(version) {
+		return fmt.Errorf("invalid template version %q: use lowercase letters, digits, '.', '_', or '-', starting with a letter or digit", version)
+	}
+	return nil
+}
+
+// leadingComment returns the text of the first comment line of a YAML file,
+// or "" if the file does not start with a comment.
+func leadingComment(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			return ""
+		}
+		return strings.TrimSpace(strings.TrimLeft(line, "#"))
+	}
+	return ""
+}
+
+// MergeIndex returns index with entry added, replacing any existing entry of
+// the same name and version in place; other versions are kept. Reports
+// whether an entry was replaced.
+func MergeIndex(index []*TemplateMetadata, entry *TemplateMetadata) ([]*TemplateMetadata, bool) {
+	merged := make([]*TemplateMetadata, 0, len(index)+1)
+	replaced := false
+	for _, existing := range index {
+		if existing.Name == entry.Name && existing.Version == entry.Version {
+			merged = append(merged, entry)
+			replaced = true
+			continue
+		}
+		merged = append(merged, existing)
+	}
+	if !replaced {
+		merged = append(merged, entry)
+	}
+	return merged, replaced
+}
+
+// Publish commits a template file and the updated index.json to the registry
+// through the GitHub Contents API, authenticating with token. The template is
+// written first, so the index never references a file that does not exist.
+// The committed index replaces any cached copy.
+func (g *GitHubRegistry) Publish(entry *TemplateMetadata, content []byte, token string) error {
+	if err := validateSlugs(entry.Name, entry.Version); err != nil {
+		return err
+	}
+	if entry.Path != entryPath(entry.Name, entry.Version) {
+		return fmt.Errorf("invalid template path %q for %s", entry.Path, entry.Name)
+	}
+	templatePath := path.Join(g.BasePath, entry.Path)
+	indexPath := path.Join(g.BasePath, "index.json")
+
+	var index []*TemplateMetadata
+	indexData, indexSHA, err := g.getContents(indexPath, token)
+	switch {
+	case errors.Is(err, errContentNotFound):
+	case err != nil:
+		return fmt.Errorf("failed to fetch registry index: %w", err)
+	default:
+		if err := json.Unmarshal(indexData, &index); err != nil {
+			return fmt.Errorf("failed to parse registry index: %w", err)
+		}
+	}
+
+	_, templateSHA, err := g.getContents(templatePath, token)
+	if err != nil && !errors.Is(err, errContentNotFound) {
+		return fmt.Errorf("failed to fetch %s: %w", templatePath, err)
+	}
+
+	index, replaced := MergeIndex(index, entry)
+	verb := "Add"
+	if replaced {
+		verb = "Update"
+	}
+	ref := entry.Name
+	if entry.Version != "" {
+		ref += "@" + entry.Version
+	}
+	message := fmt.Sprintf("%s template %s", verb, ref)
+
+	if err := g.putContents(templatePath, content, templateSHA, message, token); err != nil {
+		return fmt.Errorf("failed to commit %s: %w", templatePath, err)
+	}
+
+	indexData, err = json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal registry index: %w", err)
+	}
+	indexData = append(indexData, '\n')
+	if err := g.putContents(indexPath, indexData, indexSHA, message, token); err != nil {
+		return fmt.Errorf("failed to commit %s: %w", indexPath, err)
+	}
+
+	// A stale cache would hide the new template until it expires; one that
+	// cannot be rewritten is removed instead
+	if err := g.saveCachedIndex(indexData); err != nil {
+		os.Remove(g.indexCachePath())
+	}
+
+	return nil
+}
+
+// contentsURL returns the Contents API URL for a file in the repository.
+func (g *GitHubRegistry) contentsURL(filePath string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/contents/%s", g.apiURL, g.Owner, g.Repo, filePath)
+}
+
+// githubRequest sends an authenticated GitHub REST API request.
+func (g *GitHubRegistry) githubRequest(method, url, token string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return g.client.Do(req)
+}
+
+// getContents returns a file's content and blob SHA from the registry
+// branch, or errContentNotFound if the file does not exist.
+func (g *GitHubRegistry) getContents(filePath, token string) ([]byte, string, error) {
+	resp, err := g.githubRequest(http.MethodGet, g.contentsURL(filePath)+"?ref="+g.Branch, token, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", errContentNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	var file struct {
+		SHA      string `json:"sha"`
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return nil, "", fmt.Errorf("failed to parse GitHub API response: %w", err)
+	}
+	if file.Encoding != "base64" {
+		return nil, "", fmt.Errorf("unsupported content encoding %q", file.Encoding)
+	}
+
+	// GitHub wraps base64 content across lines
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode file content: %w", err)
+	}
+	return data, file.SHA, nil
+}
+
+// putContents creates or updates a file on the registry branch. sha is the
+// blob SHA of the file being replaced, or "" to create it.
+func (g *GitHubRegistry) putContents(filePath string, content []byte, sha, message, token string) error {
+	request := map[string]string{
+		"message": message,
+		"content": base64.StdEncoding.EncodeToString(content),
+		"branch":  g.Branch,
+	}
+	if sha != "" {
+		request["sha"] = sha
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := g.githubRequest(http.MethodPut, g.contentsURL(filePath), token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, apiErr.Message)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+const publishTemplateContent = `# Genomics Cluster Template
+# Use Case: variant calling
+
+cluster:
+  name: genomics
+  region: us-east-1
+`
+
+func TestNewIndexEntry(t *testing.T) {
+	updated := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "genomics"},
+		Metadata: map[string]string{
+			"description": "Variant calling pipelines",
+			"author":      "Jane Doe",
+			"version":     "1.2.0",
+			"tags":        "bio, genomics ,,gatk",
+		},
+	}
+
+	entry, err := NewIndexEntry(tmpl, "seeds/library/genomics.yaml", []byte(publishTemplateContent), "github.com/owner/repo", updated)
+	if err != nil {
+		t.Fatalf("NewIndexEntry() error = %v", err)
+	}
+
+	want := &TemplateMetadata{
+		Name:        "genomics",
+		Title:       "Genomics Cluster Template",
+		Description: "Variant calling pipelines",
+		Author:      "Jane Doe",
+		Version:     "1.2.0",
+		Tags:        []string{"bio", "genomics", "gatk"},
+		Source:      "github.com/owner/repo",
+		Path:        "genomics/1.2.0.yaml",
+		SHA256:      contentHash([]byte(publishTemplateContent)),
+		UpdatedAt:   updated,
+	}
+	gotJSON, _ := json.Marshal(entry)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("NewIndexEntry() = %s\nwant %s", gotJSON, wantJSON)
+	}
+}
+
+func TestNewIndexEntryMetadataOverrides(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster:  template.ClusterConfig{Name: "genomics"},
+		Metadata: map[string]string{"name": "gatk-cluster", "title": "GATK Cluster"},
+	}
+
+	entry, err := NewIndexEntry(tmpl, "genomics.yaml", []byte(publishTemplateContent), "", time.Now())
+	if err != nil {
+		t.Fatalf("NewIndexEntry() error = %v", err)
+	}
+	if entry.Name != "gatk-cluster" || entry.Path != "gatk-cluster.yaml" {
+		t.Errorf("name/path = %s/%s, want gatk-cluster/gatk-cluster.yaml", entry.Name, entry.Path)
+	}
+	if entry.Title != "GATK Cluster" {
+		t.Errorf("Title = %q, want metadata title", entry.Title)
+	}
+
+	// Without a leading comment the title falls back to the cluster name
+	entry, err = NewIndexEntry(&template.Template{Cluster: template.ClusterConfig{Name: "genomics"}},
+		"genomics.yaml", []byte("cluster:\n  name: genomics\n"), "", time.Now())
+	if err != nil {
+		t.Fatalf("NewIndexEntry() error = %v", err)
+	}
+	if entry.Title != "genomics" {
+		t.Errorf("Title = %q, want cluster name", entry.Title)
+	}
+	if entry.Tags == nil || len(entry.Tags) != 0 {
+		t.Errorf("Tags = %#v, want empty list", entry.Tags)
+	}
+}
+
+func TestNewIndexEntryRejectsUnsafeNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		metadata map[string]string
+	}{
+		{"parent directory name", "genomics.yaml", map[string]string{"name": "../index"}},
+		{"nested name", "genomics.yaml", map[string]string{"name": "a/b"}},
+		{"uppercase file name", "My Genomics.yaml", nil},
+		{"parent directory version", "genomics.yaml", map[string]string{"version": "../../index"}},
+		{"leading dot version", "genomics.yaml", map[string]string{"version": ".hidden"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := &template.Template{Cluster: template.ClusterConfig{Name: "genomics"}, Metadata: tt.metadata}
+			if _, err := NewIndexEntry(tmpl, tt.filename, []byte(publishTemplateContent), "", time.Now()); err == nil {
+				t.Error("NewIndexEntry() expected an error")
+			}
+		})
+	}
+
+	g := NewGitHubRegistry("owner", "repo")
+	if err := g.Publish(&TemplateMetadata{Name: "ml", Path: "../ml.yaml"}, nil, "token"); err == nil {
+		t.Error("Publish() expected an error for a path outside the registry")
+	}
+}
+
+func TestMergeIndex(t *testing.T) {
+	index := []*TemplateMetadata{{Name: "a", Version: "1"}, {Name: "b", Version: "1"}}
+
+	merged, replaced := MergeIndex(index, &TemplateMetadata{Name: "a", Version: "1", Title: "updated"})
+	if !replaced || len(merged) != 2 || merged[0].Title != "updated" || merged[1].Name != "b" {
+		t.Errorf("MergeIndex() replace = %v, %+v", replaced, merged)
+	}
+	if index[0].Title != "" {
+		t.Error("MergeIndex() modified the input index")
+	}
+
+	// A new version is added alongside the earlier ones
+	merged, replaced = MergeIndex(index, &TemplateMetadata{Name: "a", Version: "2"})
+	if replaced || len(merged) != 3 || merged[0].Version != "1" || merged[2].Version != "2" {
+		t.Errorf("MergeIndex() new version = %v, %+v", replaced, merged)
+	}
+
+	merged, replaced = MergeIndex(index, &TemplateMetadata{Name: "c"})
+	if replaced || len(merged) != 3 || merged[2].Name != "c" {
+		t.Errorf("MergeIndex() add = %v, %+v", replaced, merged)
+	}
+}
+
+func TestGitHubRegistryPublish(t *testing.T) {
+	existing := []*TemplateMetadata{{Name: "ml", Path: "ml.yaml"}}
+	existingJSON, _ := json.Marshal(existing)
+
+	puts := map[string]map[string]string{}
+	var order []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/contents/seeds/index.json":
+			// GitHub wraps base64 content across lines
+			encoded := base64.StdEncoding.EncodeToString(existingJSON)
+			json.NewEncoder(w).Encode(map[string]string{
+				"sha":      "index-sha",
+				"encoding": "base64",
+				"content":  encoded[:10] + "\n" + encoded[10:],
+			})
+		case r.Method == http.MethodGet:
+			http.NotFound(w, r)
+		case r.Method == http.MethodPut:
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			puts[r.URL.Path] = body
+			order = append(order, r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	reg := NewGitHubRegistry("owner", "repo")
+	reg.apiURL = server.URL
+	reg.CacheDir = t.TempDir()
+	reg.CacheTTL = CacheNeverExpires
+	if err := reg.saveCachedIndex(existingJSON); err != nil {
+		t.Fatalf("failed to seed the index cache: %v", err)
+	}
+
+	entry := &TemplateMetadata{Name: "genomics", Path: "genomics.yaml"}
+	if err := reg.Publish(entry, []byte(publishTemplateContent), "secret"); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	wantOrder := []string{"/repos/owner/repo/contents/seeds/genomics.yaml", "/repos/owner/repo/contents/seeds/index.json"}
+	if strings.Join(order, ",") != strings.Join(wantOrder, ",") {
+		t.Fatalf("PUT order = %v, want %v", order, wantOrder)
+	}
+
+	templatePut := puts[wantOrder[0]]
+	if _, ok := templatePut["sha"]; ok {
+		t.Error("new template file should be created without a sha")
+	}
+	if content, _ := base64.StdEncoding.DecodeString(templatePut["content"]); string(content) != publishTemplateContent {
+		t.Errorf("template content = %q", content)
+	}
+	if templatePut["message"] != "Add template genomics" || templatePut["branch"] != "main" {
+		t.Errorf("template commit = %+v", templatePut)
+	}
+
+	indexPut := puts[wantOrder[1]]
+	if indexPut["sha"] != "index-sha" {
+		t.Errorf("index sha = %q, want index-sha", indexPut["sha"])
+	}
+	indexData, _ := base64.StdEncoding.DecodeString(indexPut["content"])
+	var index []*TemplateMetadata
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		t.Fatalf("failed to parse committed index: %v", err)
+	}
+	if len(index) != 2 || index[0].Name != "ml" || index[1].Name != "genomics" {
+		t.Errorf("committed index = %+v, want ml and genomics", index)
+	}
+
+	// The cache holds the committed index, so the template lists at once
+	cached, ok := reg.loadCachedIndex()
+	var cachedIndex []*TemplateMetadata
+	if !ok || json.Unmarshal(cached, &cachedIndex) != nil || len(cachedIndex) != 2 || cachedIndex[1].Name != "genomics" {
+		t.Errorf("cached index = %s, want the committed index", cached)
+	}
+}
+
+func TestGitHubRegistryPublishError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+	}))
+	defer server.Close()
+
+	reg := NewGitHubRegistry("owner", "repo")
+	reg.apiURL = server.URL
+
+	err := reg.Publish(&TemplateMetadata{Name: "genomics", Path: "genomics.yaml"}, []byte("x"), "secret")
+	if err == nil || !strings.Contains(err.Error(), "Resource not accessible") {
+		t.Errorf("Publish() error = %v, want GitHub API message", err)
+	}
+}
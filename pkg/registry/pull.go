@@ -0,0 +1,183 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// pullManifest records the files of a multi-file pull that have landed. It is
+// kept next to the template while a pull is incomplete, so a re-run only
+// fetches the files that are missing.
+type pullManifest struct {
+	// Template is the registry template name
+	Template string `json:"template"`
+	// Version is the template version being pulled
+	Version string `json:"version,omitempty"`
+	// Files maps each landed file, relative to the destination directory,
+	// to the SHA-256 of its content
+	Files map[string]string `json:"files"`
+}
+
+// manifestPath returns the path of the pull manifest for a destination.
+func manifestPath(destination string) string {
+	return filepath.Join(filepath.Dir(destination), "."+filepath.Base(destination)+".pull.json")
+}
+
+// pullFiles downloads a template and its additional files. Additional files
+// are placed relative to the destination's directory and the template itself
+// is written last, so its presence means the pull is complete. Progress is
+// recorded in a manifest after each file; if the pull is interrupted, a
+// re-run verifies what already landed and fetches only the rest.
+func (g *GitHubRegistry) pullFiles(metadata *TemplateMetadata, destination string) error {
+	destDir := filepath.Dir(destination)
+
+	// Remote path for each local file, in download order
+	type pullFile struct {
+		local  string
+		remote string
+	}
+	var files []pullFile
+	for _, file := range metadata.Files {
+		clean := path.Clean(file)
+		if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("template %q lists file %q outside its directory", metadata.Name, file)
+		}
+		files = append(files, pullFile{local: filepath.FromSlash(clean), remote: path.Join(path.Dir(metadata.Path), clean)})
+	}
+	files = append(files, pullFile{local: filepath.Base(destination), remote: metadata.Path})
+
+	manifest := loadPullManifest(destination)
+	if manifest == nil || manifest.Template != metadata.Name || manifest.Version != metadata.Version {
+		manifest = &pullManifest{Template: metadata.Name, Version: metadata.Version, Files: map[string]string{}}
+	}
+
+	for i, file := range files {
+		localPath := filepath.Join(destDir, file.local)
+		if hash, ok := manifest.Files[file.local]; ok && fileHash(localPath) == hash {
+			continue
+		}
+
+		content, err := g.fetch(file.remote)
+		if err == nil {
+			err = writeFileAtomic(localPath, content)
+		}
+		if err != nil {
+			return fmt.Errorf("pull incomplete (%d of %d files); re-run to resume: %w", i, len(files), err)
+		}
+
+		manifest.Files[file.local] = contentHash(content)
+		if err := savePullManifest(destination, manifest); err != nil {
+			return err
+		}
+	}
+
+	// Verify every expected file landed intact before declaring success
+	for _, file := range files {
+		if fileHash(filepath.Join(destDir, file.local)) != manifest.Files[file.local] {
+			return fmt.Errorf("pull verification failed: %s is missing or modified; re-run to resume", file.local)
+		}
+	}
+
+	if err := os.Remove(manifestPath(destination)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove pull manifest: %w", err)
+	}
+
+	return nil
+}
+
+// loadPullManifest returns the manifest of an incomplete pull, or nil if
+// there is none.
+func loadPullManifest(destination string) *pullManifest {
+	data, err := os.ReadFile(manifestPath(destination))
+	if err != nil {
+		return nil
+	}
+
+	var manifest pullManifest
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.Files == nil {
+		return nil
+	}
+	return &manifest
+}
+
+// savePullManifest writes the manifest of an in-progress pull.
+func savePullManifest(destination string, manifest *pullManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pull manifest: %w", err)
+	}
+
+	if err := writeFileAtomic(manifestPath(destination), data); err != nil {
+		return fmt.Errorf("failed to write pull manifest: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file in the target directory and
+// renames it into place, so readers never see a partially written file.
+func writeFileAtomic(filename string, data []byte) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmpName, filename); err != nil {
+		return fmt.Errorf("failed to move file into place: %w", err)
+	}
+	return nil
+}
+
+// contentHash returns the hex SHA-256 of data.
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileHash returns the hex SHA-256 of a file's content, or an empty string
+// if it cannot be read.
+func fileHash(filename string) string {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	return contentHash(data)
+}
@@ -16,6 +16,8 @@
 package registry
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -38,6 +40,11 @@ type TemplateMetadata struct {
 	Source string `json:"source"`
 	// Path is the path to the template file in the source
 	Path string `json:"path"`
+	// SHA256 is the hex SHA-256 of the template file, set when it is published
+	SHA256 string `json:"sha256,omitempty"`
+	// Files are additional files pulled alongside the template, relative to
+	// the template's directory in the source
+	Files []string `json:"files,omitempty"`
 	// UpdatedAt is when the template was last updated
 	UpdatedAt time.Time `json:"updated_at"`
 	// Stars is the number of stars/likes
@@ -54,28 +61,42 @@ type Registry interface {
 	// Search searches for templates by keyword
 	Search(query string) ([]*TemplateMetadata, error)
 
-	// Get retrieves template content by name
+	// Get retrieves template content by name, or name@version
 	Get(name string) (string, error)
 
-	// GetMetadata retrieves metadata for a template
+	// GetMetadata retrieves metadata for a template by name, or name@version
 	GetMetadata(name string) (*TemplateMetadata, error)
 
-	// Pull downloads a template to local filesystem
+	// Pull downloads a template, by name or name@version, to local filesystem
 	Pull(name, destination string) error
 }
 
 // DefaultRegistry is the default template registry URL.
 const DefaultRegistry = "https://github.com/scttfrdmn/petal-registry"
 
-// Manager manages template registries.
+// DefaultQueryTimeout is the overall deadline for querying all registries.
+const DefaultQueryTimeout = 20 * time.Second
+
+// DefaultMaxConcurrentQueries is the number of registries queried at once.
+const DefaultMaxConcurrentQueries = 4
+
+// Manager manages template registries. Registries are kept in priority order,
+// the order they were added.
 type Manager struct {
 	registries []Registry
+	// QueryTimeout is the overall deadline for List and Search across all
+	// registries; registries that have not answered by then are skipped
+	QueryTimeout time.Duration
+	// MaxConcurrentQueries limits how many registries are queried at once
+	MaxConcurrentQueries int
 }
 
 // NewManager creates a new registry manager.
 func NewManager() *Manager {
 	return &Manager{
-		registries: []Registry{},
+		registries:           []Registry{},
+		QueryTimeout:         DefaultQueryTimeout,
+		MaxConcurrentQueries: DefaultMaxConcurrentQueries,
 	}
 }
 
@@ -84,51 +105,180 @@ func (m *Manager) AddRegistry(r Registry) {
 	m.registries = append(m.registries, r)
 }
 
-// List lists all templates from all registries.
+// RegistryFailure records a registry that errored or timed out during a query.
+type RegistryFailure struct {
+	// Registry identifies the registry
+	Registry string
+	// Err is the error the registry returned, or the context error
+	Err error
+	// TimedOut is true if the registry did not answer before the deadline
+	TimedOut bool
+}
+
+// QueryResult holds templates merged from all registries that answered, in
+// registry priority order, along with the registries that did not.
+type QueryResult struct {
+	// Templates are the merged results
+	Templates []*TemplateMetadata
+	// Failures lists registries whose results are missing
+	Failures []RegistryFailure
+}
+
+// ListAll lists templates from all registries concurrently, returning partial
+// results if some registries fail or do not answer before QueryTimeout.
+func (m *Manager) ListAll(ctx context.Context) *QueryResult {
+	return m.queryAll(ctx, func(reg Registry) ([]*TemplateMetadata, error) {
+		return reg.List()
+	})
+}
+
+// SearchAll searches all registries concurrently, returning partial results
+// if some registries fail or do not answer before QueryTimeout.
+func (m *Manager) SearchAll(ctx context.Context, query string) *QueryResult {
+	return m.queryAll(ctx, func(reg Registry) ([]*TemplateMetadata, error) {
+		return reg.Search(query)
+	})
+}
+
+// List lists all templates from all registries. It fails only if no registry
+// answered.
 func (m *Manager) List() ([]*TemplateMetadata, error) {
-	var all []*TemplateMetadata
-	for _, reg := range m.registries {
-		templates, err := reg.List()
-		if err != nil {
-			return nil, fmt.Errorf("failed to list from registry: %w", err)
-		}
-		all = append(all, templates...)
+	result := m.ListAll(context.Background())
+	if len(m.registries) > 0 && len(result.Failures) == len(m.registries) {
+		return nil, fmt.Errorf("failed to list from registry: %w", result.Failures[0].Err)
 	}
-	return all, nil
+	return result.Templates, nil
 }
 
-// Search searches all registries for templates.
+// Search searches all registries for templates, skipping registries that fail.
 func (m *Manager) Search(query string) ([]*TemplateMetadata, error) {
-	var results []*TemplateMetadata
-	for _, reg := range m.registries {
-		templates, err := reg.Search(query)
-		if err != nil {
-			// Log error but continue with other registries
+	return m.SearchAll(context.Background(), query).Templates, nil
+}
+
+// queryOutcome is one registry's answer to a query.
+type queryOutcome struct {
+	templates []*TemplateMetadata
+	err       error
+}
+
+// queryAll runs query against every registry with at most
+// MaxConcurrentQueries in flight, and merges the answers in registry order.
+// Registries still running at the deadline are reported as timed out and
+// their late answers discarded.
+func (m *Manager) queryAll(ctx context.Context, query func(Registry) ([]*TemplateMetadata, error)) *QueryResult {
+	if m.QueryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.QueryTimeout)
+		defer cancel()
+	}
+
+	limit := m.MaxConcurrentQueries
+	if limit <= 0 {
+		limit = DefaultMaxConcurrentQueries
+	}
+	sem := make(chan struct{}, limit)
+
+	// Buffered so abandoned queries can finish without blocking
+	outcomes := make([]chan queryOutcome, len(m.registries))
+	for i := range outcomes {
+		outcomes[i] = make(chan queryOutcome, 1)
+	}
+
+	// Start queries in priority order as slots free up
+	go func() {
+		for i, reg := range m.registries {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				for _, out := range outcomes[i:] {
+					out <- queryOutcome{err: ctx.Err()}
+				}
+				return
+			}
+
+			go func(reg Registry, out chan<- queryOutcome) {
+				defer func() { <-sem }()
+				templates, err := query(reg)
+				out <- queryOutcome{templates: templates, err: err}
+			}(reg, outcomes[i])
+		}
+	}()
+
+	result := &QueryResult{}
+	for i, out := range outcomes {
+		var outcome queryOutcome
+		select {
+		case outcome = <-out:
+		case <-ctx.Done():
+			// Prefer an answer that arrived at the deadline
+			select {
+			case outcome = <-out:
+			default:
+				outcome = queryOutcome{err: ctx.Err()}
+			}
+		}
+
+		if outcome.err != nil {
+			result.Failures = append(result.Failures, RegistryFailure{
+				Registry: registryName(m.registries[i], i),
+				Err:      outcome.err,
+				TimedOut: errors.Is(outcome.err, context.DeadlineExceeded),
+			})
 			continue
 		}
-		results = append(results, templates...)
+		result.Templates = append(result.Templates, outcome.templates...)
 	}
-	return results, nil
+
+	return result
 }
 
-// Get retrieves a template by name from the first registry that has it.
+// registryName identifies a registry in failure reports.
+func registryName(r Registry, index int) string {
+	if s, ok := r.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("registry %d", index+1)
+}
+
+// Get retrieves a template by name, or name@version, from the first registry
+// that has it.
 func (m *Manager) Get(name string) (string, error) {
+	var versionErr error
 	for _, reg := range m.registries {
 		content, err := reg.Get(name)
 		if err == nil {
 			return content, nil
 		}
+		if versionErr == nil && errors.Is(err, ErrVersionNotFound) {
+			versionErr = err
+		}
 	}
-	return "", fmt.Errorf("template %q not found in any registry", name)
+	return "", notFoundError(name, versionErr)
 }
 
-// Pull downloads a template to the local filesystem.
+// Pull downloads a template, by name or name@version, to the local
+// filesystem from the first registry that has it. Download errors from that
+// registry are returned as-is so a partial pull can be resumed.
 func (m *Manager) Pull(name, destination string) error {
+	var versionErr error
 	for _, reg := range m.registries {
-		err := reg.Pull(name, destination)
-		if err == nil {
-			return nil
+		if _, err := reg.GetMetadata(name); err != nil {
+			if versionErr == nil && errors.Is(err, ErrVersionNotFound) {
+				versionErr = err
+			}
+			continue
 		}
+		return reg.Pull(name, destination)
+	}
+	return notFoundError(name, versionErr)
+}
+
+// notFoundError reports a template no registry has. If a registry has the
+// template but not the requested version, that error, which lists the
+// versions available, is returned instead.
+func notFoundError(name string, versionErr error) error {
+	if versionErr != nil {
+		return versionErr
 	}
 	return fmt.Errorf("template %q not found in any registry", name)
 }
@@ -15,6 +15,8 @@
 package registry
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -420,6 +422,20 @@ func TestManagerPullMultipleRegistries(t *testing.T) {
 	}
 }
 
+func TestManagerPullReturnsDownloadError(t *testing.T) {
+	manager := NewManager()
+
+	reg := newMockRegistry()
+	reg.templates["template1"] = &TemplateMetadata{Name: "template1"}
+	reg.pullErr = fmt.Errorf("pull incomplete (1 of 3 files); re-run to resume")
+	manager.AddRegistry(reg)
+
+	err := manager.Pull("template1", "/tmp/dest")
+	if err == nil || !strings.Contains(err.Error(), "re-run to resume") {
+		t.Errorf("Expected the registry's download error, got %v", err)
+	}
+}
+
 func TestDefaultRegistry(t *testing.T) {
 	if DefaultRegistry == "" {
 		t.Error("DefaultRegistry should not be empty")
@@ -483,6 +499,130 @@ func TestManagerNoRegistries(t *testing.T) {
 	}
 }
 
+// slowRegistry answers only once release is closed.
+type slowRegistry struct {
+	*mockRegistry
+	release chan struct{}
+}
+
+func (s *slowRegistry) List() ([]*TemplateMetadata, error) {
+	<-s.release
+	return s.mockRegistry.List()
+}
+
+func (s *slowRegistry) Search(query string) ([]*TemplateMetadata, error) {
+	<-s.release
+	return s.mockRegistry.Search(query)
+}
+
+func (s *slowRegistry) String() string {
+	return "slow"
+}
+
+func newQueryTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	slow := &slowRegistry{mockRegistry: newMockRegistry(), release: make(chan struct{})}
+	slow.templates["slow-template"] = &TemplateMetadata{Name: "slow-template"}
+	t.Cleanup(func() { close(slow.release) })
+
+	failing := newMockRegistry()
+	failing.listErr = fmt.Errorf("list failed")
+	failing.searchErr = fmt.Errorf("search failed")
+
+	first := newMockRegistry()
+	first.templates["template-a"] = &TemplateMetadata{Name: "template-a"}
+	last := newMockRegistry()
+	last.templates["template-b"] = &TemplateMetadata{Name: "template-b"}
+
+	manager := NewManager()
+	manager.QueryTimeout = 100 * time.Millisecond
+	manager.AddRegistry(first)
+	manager.AddRegistry(slow)
+	manager.AddRegistry(failing)
+	manager.AddRegistry(last)
+	return manager
+}
+
+func TestManagerListAllPartialResults(t *testing.T) {
+	manager := newQueryTestManager(t)
+
+	start := time.Now()
+	result := manager.ListAll(context.Background())
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("ListAll() took %v, should return at the deadline", elapsed)
+	}
+
+	// Fast results are merged in registry priority order
+	var names []string
+	for _, tmpl := range result.Templates {
+		names = append(names, tmpl.Name)
+	}
+	if strings.Join(names, ",") != "template-a,template-b" {
+		t.Errorf("Templates = %v, want [template-a template-b]", names)
+	}
+
+	if len(result.Failures) != 2 {
+		t.Fatalf("Failures = %+v, want 2", result.Failures)
+	}
+	if result.Failures[0].Registry != "slow" || !result.Failures[0].TimedOut {
+		t.Errorf("Failures[0] = %+v, want slow registry timed out", result.Failures[0])
+	}
+	if result.Failures[1].Registry != "registry 3" || result.Failures[1].TimedOut {
+		t.Errorf("Failures[1] = %+v, want registry 3 errored", result.Failures[1])
+	}
+
+	// List keeps the partial results rather than failing
+	templates, err := manager.List()
+	if err != nil {
+		t.Fatalf("List() should not fail with partial results: %v", err)
+	}
+	if len(templates) != 2 {
+		t.Errorf("List() returned %d templates, want 2", len(templates))
+	}
+}
+
+func TestManagerSearchAllPartialResults(t *testing.T) {
+	manager := newQueryTestManager(t)
+
+	start := time.Now()
+	result := manager.SearchAll(context.Background(), "template")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("SearchAll() took %v, should return at the deadline", elapsed)
+	}
+
+	if len(result.Templates) != 2 || result.Templates[0].Name != "template-a" || result.Templates[1].Name != "template-b" {
+		t.Errorf("Templates = %+v, want template-a then template-b", result.Templates)
+	}
+	if len(result.Failures) != 2 {
+		t.Errorf("Failures = %+v, want 2", result.Failures)
+	}
+}
+
+func TestManagerQueryConcurrencyLimit(t *testing.T) {
+	manager := NewManager()
+	manager.MaxConcurrentQueries = 1
+
+	// With one query at a time, a registry queued behind the slow one times
+	// out without being queried
+	slow := &slowRegistry{mockRegistry: newMockRegistry(), release: make(chan struct{})}
+	t.Cleanup(func() { close(slow.release) })
+	queued := newMockRegistry()
+	queued.templates["queued"] = &TemplateMetadata{Name: "queued"}
+
+	manager.QueryTimeout = 50 * time.Millisecond
+	manager.AddRegistry(slow)
+	manager.AddRegistry(queued)
+
+	result := manager.ListAll(context.Background())
+	if len(result.Templates) != 0 {
+		t.Errorf("Templates = %+v, want none", result.Templates)
+	}
+	if len(result.Failures) != 2 || !result.Failures[0].TimedOut || !result.Failures[1].TimedOut {
+		t.Errorf("Failures = %+v, want both registries timed out", result.Failures)
+	}
+}
+
 func TestParseGitHubURL(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -860,6 +1000,70 @@ func TestGitHubRegistryGet(t *testing.T) {
 	}
 }
 
+func TestGitHubRegistryGetPrivate(t *testing.T) {
+	templateContent := "cluster:\n  name: test\n"
+	indexJSON, _ := json.Marshal([]*TemplateMetadata{{Name: "template1", Path: "template1.yaml"}})
+	files := map[string][]byte{
+		"/repos/test/repo/contents/seeds/index.json":     indexJSON,
+		"/repos/test/repo/contents/seeds/template1.yaml": []byte(templateContent),
+	}
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want Bearer secret", got)
+		}
+		if got := r.URL.Query().Get("ref"); got != "main" {
+			t.Errorf("ref = %q, want main", got)
+		}
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"sha":      "abc123",
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString(content),
+		})
+	}))
+	defer server.Close()
+
+	reg := NewGitHubRegistry("test", "repo")
+	reg.apiURL = server.URL
+	reg.Token = "secret"
+	// Private registries must not be read through raw.githubusercontent.com
+	reg.client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "raw.githubusercontent.com" {
+			t.Errorf("unexpected raw request %s", req.URL)
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	content, err := reg.Get("template1")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if content != templateContent {
+		t.Errorf("Expected content %q, got %q", templateContent, content)
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected index and template requests, got %v", requests)
+	}
+
+	if _, err := reg.Get("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Get() of a missing template error = %v, want not found", err)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
 func TestGitHubRegistryGetNotFound(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasSuffix(r.URL.Path, "/index.json") {
@@ -992,3 +1196,318 @@ func (t *testTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	return http.DefaultTransport.RoundTrip(req)
 }
+
+func TestGitHubRegistryPullIncompleteDownload(t *testing.T) {
+	destination := filepath.Join(t.TempDir(), "template.yaml")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/index.json") {
+			json.NewEncoder(w).Encode([]*TemplateMetadata{{Name: "template1", Path: "template1.yaml"}})
+			return
+		}
+		// Connection drops partway through the body
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("cluster:\n  name: te"))
+	}))
+	defer server.Close()
+
+	reg := NewGitHubRegistry("test", "repo")
+	reg.client = &http.Client{
+		Transport: &testTransport{baseURL: server.URL, owner: "test", repo: "repo", branch: "main"},
+	}
+
+	if err := reg.Pull("template1", destination); err == nil {
+		t.Fatal("Expected error for truncated download")
+	}
+
+	if _, err := os.Stat(destination); !os.IsNotExist(err) {
+		t.Error("Truncated download should not leave a file at the destination")
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(destination))
+	if len(entries) != 0 {
+		t.Errorf("Expected no leftover files, found %d", len(entries))
+	}
+}
+
+// multiFileServer serves a template with additional files and counts the
+// requests for each file. Paths in failing return a server error.
+func multiFileServer(t *testing.T, requests map[string]int, failing map[string]bool) *httptest.Server {
+	t.Helper()
+
+	files := map[string]string{
+		"/hpc/cluster.yaml":       "cluster:\n  name: hpc\n",
+		"/hpc/scripts/setup.sh":   "#!/bin/bash\necho setup\n",
+		"/hpc/data/modules.txt":   "gcc\nopenmpi\n",
+		"/hpc/data/inputs/a.json": "{}\n",
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/index.json") {
+			json.NewEncoder(w).Encode([]*TemplateMetadata{{
+				Name:    "hpc",
+				Version: "1.0.0",
+				Path:    "hpc/cluster.yaml",
+				Files:   []string{"scripts/setup.sh", "data/modules.txt", "data/inputs/a.json"},
+			}})
+			return
+		}
+
+		filePath := strings.TrimPrefix(r.URL.Path, "/seeds")
+		requests[filePath]++
+		if failing[filePath] {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		content, ok := files[filePath]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+}
+
+func TestGitHubRegistryPullResume(t *testing.T) {
+	destDir := t.TempDir()
+	destination := filepath.Join(destDir, "hpc.yaml")
+
+	requests := map[string]int{}
+	failing := map[string]bool{"/hpc/data/modules.txt": true}
+	server := multiFileServer(t, requests, failing)
+	defer server.Close()
+
+	reg := NewGitHubRegistry("test", "repo")
+	reg.client = &http.Client{
+		Transport: &testTransport{baseURL: server.URL, owner: "test", repo: "repo", branch: "main"},
+	}
+
+	// First pull fails partway through
+	err := reg.Pull("hpc", destination)
+	if err == nil || !strings.Contains(err.Error(), "re-run to resume") {
+		t.Fatalf("Expected resumable pull error, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "scripts", "setup.sh")); err != nil {
+		t.Errorf("Expected file pulled before the failure to be kept: %v", err)
+	}
+	if _, err := os.Stat(destination); !os.IsNotExist(err) {
+		t.Error("Template should not be written until all its files have landed")
+	}
+	if _, err := os.Stat(manifestPath(destination)); err != nil {
+		t.Errorf("Expected pull manifest to record progress: %v", err)
+	}
+
+	// Re-run once the network recovers
+	delete(failing, "/hpc/data/modules.txt")
+	if err := reg.Pull("hpc", destination); err != nil {
+		t.Fatalf("Resumed Pull() failed: %v", err)
+	}
+
+	if requests["/hpc/scripts/setup.sh"] != 1 {
+		t.Errorf("Expected setup.sh to be fetched once, got %d", requests["/hpc/scripts/setup.sh"])
+	}
+	if requests["/hpc/data/modules.txt"] != 2 {
+		t.Errorf("Expected modules.txt to be retried, got %d requests", requests["/hpc/data/modules.txt"])
+	}
+
+	expected := map[string]string{
+		"hpc.yaml":           "cluster:\n  name: hpc\n",
+		"scripts/setup.sh":   "#!/bin/bash\necho setup\n",
+		"data/modules.txt":   "gcc\nopenmpi\n",
+		"data/inputs/a.json": "{}\n",
+	}
+	for rel, want := range expected {
+		got, err := os.ReadFile(filepath.Join(destDir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Errorf("Expected %s to be pulled: %v", rel, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s content = %q, want %q", rel, got, want)
+		}
+	}
+
+	if _, err := os.Stat(manifestPath(destination)); !os.IsNotExist(err) {
+		t.Error("Pull manifest should be removed once the pull completes")
+	}
+}
+
+func TestGitHubRegistryPullResumeRefetchesModified(t *testing.T) {
+	destDir := t.TempDir()
+	destination := filepath.Join(destDir, "hpc.yaml")
+
+	requests := map[string]int{}
+	failing := map[string]bool{"/hpc/cluster.yaml": true}
+	server := multiFileServer(t, requests, failing)
+	defer server.Close()
+
+	reg := NewGitHubRegistry("test", "repo")
+	reg.client = &http.Client{
+		Transport: &testTransport{baseURL: server.URL, owner: "test", repo: "repo", branch: "main"},
+	}
+
+	if err := reg.Pull("hpc", destination); err == nil {
+		t.Fatal("Expected pull to fail")
+	}
+
+	// A landed file was changed locally between runs
+	os.WriteFile(filepath.Join(destDir, "scripts", "setup.sh"), []byte("edited"), 0644)
+
+	delete(failing, "/hpc/cluster.yaml")
+	if err := reg.Pull("hpc", destination); err != nil {
+		t.Fatalf("Resumed Pull() failed: %v", err)
+	}
+
+	if requests["/hpc/scripts/setup.sh"] != 2 {
+		t.Errorf("Expected modified setup.sh to be re-fetched, got %d requests", requests["/hpc/scripts/setup.sh"])
+	}
+	if requests["/hpc/data/modules.txt"] != 1 {
+		t.Errorf("Expected intact modules.txt to be skipped, got %d requests", requests["/hpc/data/modules.txt"])
+	}
+}
+
+func TestGitHubRegistryPullCleanRepull(t *testing.T) {
+	destDir := t.TempDir()
+	destination := filepath.Join(destDir, "hpc.yaml")
+
+	requests := map[string]int{}
+	server := multiFileServer(t, requests, map[string]bool{})
+	defer server.Close()
+
+	reg := NewGitHubRegistry("test", "repo")
+	reg.client = &http.Client{
+		Transport: &testTransport{baseURL: server.URL, owner: "test", repo: "repo", branch: "main"},
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := reg.Pull("hpc", destination); err != nil {
+			t.Fatalf("Pull() #%d failed: %v", i+1, err)
+		}
+	}
+
+	// A completed pull leaves no manifest, so pulling again fetches everything
+	for _, file := range []string{"/hpc/cluster.yaml", "/hpc/scripts/setup.sh", "/hpc/data/modules.txt", "/hpc/data/inputs/a.json"} {
+		if requests[file] != 2 {
+			t.Errorf("Expected %s to be fetched on each pull, got %d", file, requests[file])
+		}
+	}
+}
+
+func TestGitHubRegistryPullRejectsEscapingFiles(t *testing.T) {
+	destination := filepath.Join(t.TempDir(), "evil.yaml")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/index.json") {
+			json.NewEncoder(w).Encode([]*TemplateMetadata{{
+				Name:  "evil",
+				Path:  "evil/cluster.yaml",
+				Files: []string{"../../.bashrc"},
+			}})
+			return
+		}
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	reg := NewGitHubRegistry("test", "repo")
+	reg.client = &http.Client{
+		Transport: &testTransport{baseURL: server.URL, owner: "test", repo: "repo", branch: "main"},
+	}
+
+	if err := reg.Pull("evil", destination); err == nil || !strings.Contains(err.Error(), "outside its directory") {
+		t.Errorf("Expected error for file outside template directory, got %v", err)
+	}
+}
+
+func TestGitHubRegistryListCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode([]*TemplateMetadata{{Name: "bioinformatics"}})
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	newRegistry := func() *GitHubRegistry {
+		reg := NewGitHubRegistry("test", "repo")
+		reg.CacheDir = cacheDir
+		reg.client = &http.Client{
+			Transport: &testTransport{
+				baseURL: server.URL,
+				owner:   "test",
+				repo:    "repo",
+				branch:  "main",
+			},
+		}
+		return reg
+	}
+
+	for i := 0; i < 2; i++ {
+		templates, err := newRegistry().List()
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		if len(templates) != 1 || templates[0].Name != "bioinformatics" {
+			t.Errorf("List() = %v, want the bioinformatics template", templates)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected the second List within the TTL to use the cache, got %d requests", requests)
+	}
+
+	refreshed := newRegistry()
+	refreshed.Refresh = true
+	if _, err := refreshed.List(); err != nil {
+		t.Fatalf("List() with Refresh failed: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected Refresh to fetch the index, got %d requests", requests)
+	}
+
+	expired := newRegistry()
+	expired.CacheTTL = time.Nanosecond
+	if _, err := expired.List(); err != nil {
+		t.Fatalf("List() with an expired cache failed: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected an expired cache to fetch the index, got %d requests", requests)
+	}
+
+	other := newRegistry()
+	other.Branch = "develop"
+	other.client.Transport.(*testTransport).branch = "develop"
+	if _, err := other.List(); err != nil {
+		t.Fatalf("List() on another branch failed: %v", err)
+	}
+	if requests != 4 {
+		t.Errorf("Expected each branch to be cached separately, got %d requests", requests)
+	}
+
+	otherPath := newRegistry()
+	otherPath.BasePath = "library"
+	if _, err := otherPath.List(); err != nil {
+		t.Fatalf("List() with another base path failed: %v", err)
+	}
+	if requests != 5 {
+		t.Errorf("Expected each base path to be cached separately, got %d requests", requests)
+	}
+
+	// A zero TTL skips the cache; a negative one keeps it however old
+	uncached := newRegistry()
+	uncached.CacheTTL = 0
+	if _, err := uncached.List(); err != nil {
+		t.Fatalf("List() with a zero TTL failed: %v", err)
+	}
+	if requests != 6 {
+		t.Errorf("Expected a zero TTL to fetch the index, got %d requests", requests)
+	}
+	kept := newRegistry()
+	kept.CacheTTL = CacheNeverExpires
+	if _, err := kept.List(); err != nil {
+		t.Fatalf("List() with a never-expiring cache failed: %v", err)
+	}
+	if requests != 6 {
+		t.Errorf("Expected a never-expiring cache to be used, got %d requests", requests)
+	}
+}
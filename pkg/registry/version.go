@@ -0,0 +1,208 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrVersionNotFound is returned when a template exists but not at the
+// requested version.
+var ErrVersionNotFound = errors.New("version not found")
+
+// ParseTemplateRef splits a template reference of the form name or
+// name@version.
+func ParseTemplateRef(ref string) (name, version string) {
+	if i := strings.LastIndex(ref, "@"); i > 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// semver is a parsed semantic version. Build metadata is ignored.
+type semver struct {
+	major, minor, patch int
+	prerelease          []string
+}
+
+// parseSemver parses a semantic version such as 1.2.0, v1.2.0, or
+// 2.0.0-rc.1. Missing minor and patch numbers are treated as zero.
+func parseSemver(version string) (semver, bool) {
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+
+	var s semver
+	if i := strings.Index(v, "-"); i >= 0 {
+		if i == len(v)-1 {
+			return semver{}, false
+		}
+		s.prerelease = strings.Split(v[i+1:], ".")
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return semver{}, false
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		numbers[i] = n
+	}
+	s.major, s.minor, s.patch = numbers[0], numbers[1], numbers[2]
+	return s, true
+}
+
+// compareSemver orders versions by semantic version precedence, returning
+// -1, 0, or 1. A prerelease sorts before its release.
+func compareSemver(a, b semver) int {
+	for _, d := range []int{a.major - b.major, a.minor - b.minor, a.patch - b.patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	switch {
+	case len(a.prerelease) == 0 && len(b.prerelease) == 0:
+		return 0
+	case len(a.prerelease) == 0:
+		return 1
+	case len(b.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.prerelease) && i < len(b.prerelease); i++ {
+		x, y := a.prerelease[i], b.prerelease[i]
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+		switch {
+		case xErr == nil && yErr == nil:
+			if xn != yn {
+				return sign(xn - yn)
+			}
+		case xErr == nil:
+			// Numeric identifiers sort before alphanumeric ones
+			return -1
+		case yErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(x, y); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(a.prerelease) - len(b.prerelease))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// CompareVersions orders two template versions, returning -1, 0, or 1.
+// Semantic versions sort by precedence and above versions that are not
+// semantic, which sort by string.
+func CompareVersions(a, b string) int {
+	as, aOK := parseSemver(a)
+	bs, bOK := parseSemver(b)
+	switch {
+	case aOK && bOK:
+		return compareSemver(as, bs)
+	case aOK:
+		return 1
+	case bOK:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// SortVersions sorts template entries newest version first.
+func SortVersions(templates []*TemplateMetadata) {
+	sort.SliceStable(templates, func(i, j int) bool {
+		return CompareVersions(templates[i].Version, templates[j].Version) > 0
+	})
+}
+
+// sameVersion reports whether two version strings name the same version,
+// e.g. 1.2.0 and v1.2.0.
+func sameVersion(a, b string) bool {
+	if a == b {
+		return true
+	}
+	as, aOK := parseSemver(a)
+	bs, bOK := parseSemver(b)
+	return aOK && bOK && compareSemver(as, bs) == 0
+}
+
+// SelectVersion finds the template a reference names among index entries.
+// name@version selects that exact version; a bare name selects the highest
+// version, preferring releases over prereleases.
+func SelectVersion(templates []*TemplateMetadata, ref string) (*TemplateMetadata, error) {
+	name, version := ParseTemplateRef(ref)
+
+	var candidates []*TemplateMetadata
+	for _, tmpl := range templates {
+		if tmpl.Name == name {
+			candidates = append(candidates, tmpl)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("template %q not found", name)
+	}
+	SortVersions(candidates)
+
+	if version != "" {
+		for _, tmpl := range candidates {
+			if sameVersion(tmpl.Version, version) {
+				return tmpl, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: template %q has no version %q (available: %s)",
+			ErrVersionNotFound, name, version, strings.Join(availableVersions(candidates), ", "))
+	}
+
+	for _, tmpl := range candidates {
+		if s, ok := parseSemver(tmpl.Version); ok && len(s.prerelease) == 0 {
+			return tmpl, nil
+		}
+	}
+	return candidates[0], nil
+}
+
+// availableVersions lists the versions of sorted candidates for messages.
+func availableVersions(candidates []*TemplateMetadata) []string {
+	versions := make([]string, 0, len(candidates))
+	for _, tmpl := range candidates {
+		if tmpl.Version == "" {
+			versions = append(versions, "(unversioned)")
+		} else {
+			versions = append(versions, tmpl.Version)
+		}
+	}
+	return versions
+}
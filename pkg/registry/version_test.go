@@ -0,0 +1,181 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTemplateRef(t *testing.T) {
+	tests := []struct {
+		ref         string
+		wantName    string
+		wantVersion string
+	}{
+		{"bio", "bio", ""},
+		{"bio@1.2.0", "bio", "1.2.0"},
+		{"bio@", "bio", ""},
+		{"@1.2.0", "@1.2.0", ""},
+	}
+
+	for _, tt := range tests {
+		name, version := ParseTemplateRef(tt.ref)
+		if name != tt.wantName || version != tt.wantVersion {
+			t.Errorf("ParseTemplateRef(%q) = %q, %q, want %q, %q", tt.ref, name, version, tt.wantName, tt.wantVersion)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2.0", 0},
+		{"v1.2.0", "1.2.0", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "10.0.0", -1},
+		{"1.2.0-rc.1", "1.2.0", -1},
+		{"1.2.0-rc.2", "1.2.0-rc.10", -1},
+		{"1.2.0-alpha", "1.2.0-1", 1},
+		{"1.2.0-alpha", "1.2.0-alpha.1", -1},
+		{"1.2.0+build.5", "1.2.0", 0},
+		{"1.0.0", "latest", 1},
+		{"", "0.0.1", -1},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSelectVersion(t *testing.T) {
+	index := []*TemplateMetadata{
+		{Name: "bio", Version: "1.2.0", Path: "bio/1.2.0.yaml"},
+		{Name: "bio", Version: "1.10.0", Path: "bio/1.10.0.yaml"},
+		{Name: "bio", Version: "2.0.0-rc.1", Path: "bio/2.0.0-rc.1.yaml"},
+		{Name: "ml", Path: "ml.yaml"},
+	}
+
+	tests := []struct {
+		ref      string
+		wantPath string
+		wantErr  string
+	}{
+		{"bio", "bio/1.10.0.yaml", ""},
+		{"bio@1.2.0", "bio/1.2.0.yaml", ""},
+		{"bio@v1.2.0", "bio/1.2.0.yaml", ""},
+		{"bio@2.0.0-rc.1", "bio/2.0.0-rc.1.yaml", ""},
+		{"ml", "ml.yaml", ""},
+		{"bio@3.0.0", "", `template "bio" has no version "3.0.0" (available: 2.0.0-rc.1, 1.10.0, 1.2.0)`},
+		{"chem", "", `template "chem" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := SelectVersion(index, tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("SelectVersion() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectVersion() error = %v", err)
+			}
+			if got.Path != tt.wantPath {
+				t.Errorf("SelectVersion() path = %s, want %s", got.Path, tt.wantPath)
+			}
+		})
+	}
+
+	// Only prereleases: the highest one is selected
+	prereleases := []*TemplateMetadata{{Name: "new", Version: "0.1.0-beta"}, {Name: "new", Version: "0.1.0-alpha"}}
+	if got, err := SelectVersion(prereleases, "new"); err != nil || got.Version != "0.1.0-beta" {
+		t.Errorf("SelectVersion() = %v, %v, want 0.1.0-beta", got, err)
+	}
+
+	if _, err := SelectVersion(index, "bio@3.0.0"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("SelectVersion() error = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestGitHubRegistryPullVersion(t *testing.T) {
+	index := []*TemplateMetadata{
+		{Name: "bio", Version: "1.2.0", Path: "bio/1.2.0.yaml"},
+		{Name: "bio", Version: "1.10.0", Path: "bio/1.10.0.yaml"},
+	}
+	files := map[string]string{
+		"/seeds/bio/1.2.0.yaml":  "# bio 1.2.0\n",
+		"/seeds/bio/1.10.0.yaml": "# bio 1.10.0\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/seeds/index.json" {
+			json.NewEncoder(w).Encode(index)
+			return
+		}
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	reg := NewGitHubRegistry("test", "repo")
+	reg.client = &http.Client{
+		Transport: &testTransport{baseURL: server.URL, owner: "test", repo: "repo", branch: "main"},
+	}
+	manager := NewManager()
+	manager.AddRegistry(reg)
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"bio", "# bio 1.10.0\n"},
+		{"bio@1.2.0", "# bio 1.2.0\n"},
+	}
+	for _, tt := range tests {
+		destination := filepath.Join(t.TempDir(), "bio.yaml")
+		if err := manager.Pull(tt.ref, destination); err != nil {
+			t.Fatalf("Pull(%q) error = %v", tt.ref, err)
+		}
+		content, err := os.ReadFile(destination)
+		if err != nil {
+			t.Fatalf("Failed to read pulled template: %v", err)
+		}
+		if string(content) != tt.want {
+			t.Errorf("Pull(%q) content = %q, want %q", tt.ref, content, tt.want)
+		}
+	}
+
+	// A missing version names the versions that exist, not "not found"
+	err := manager.Pull("bio@9.0.0", filepath.Join(t.TempDir(), "bio.yaml"))
+	if !errors.Is(err, ErrVersionNotFound) || !strings.Contains(err.Error(), "available: 1.10.0, 1.2.0") {
+		t.Errorf("Pull() error = %v, want the available versions", err)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import "strings"
+
+// SlurmEtcDir is where ParallelCluster keeps the Slurm configuration on the
+// head node, shared with the compute nodes.
+const SlurmEtcDir = "/opt/slurm/etc"
+
+// accountingDatabase is the MariaDB database slurmdbd stores accounting in.
+const accountingDatabase = "slurm_acct_db"
+
+// GenerateAccountingScript returns the bootstrap section that sets up Slurm
+// accounting on the head node: MariaDB and slurmdbd. The slurm.conf settings
+// pointing slurmctld at slurmdbd come from the ParallelCluster config. The
+// database password is generated on the head node so it never leaves it.
+func GenerateAccountingScript() string {
+	var script strings.Builder
+
+	script.WriteString("echo \"Setting up Slurm accounting...\"\n")
+	script.WriteString("SLURM_ETC=" + SlurmEtcDir + "\n")
+	script.WriteString("if [ ! -f \"$SLURM_ETC/slurm.conf\" ]; then\n")
+	script.WriteString("  echo \"Slurm configuration not found in $SLURM_ETC; is this the head node?\"\n")
+	script.WriteString("  exit 1\n")
+	script.WriteString("fi\n\n")
+
+	script.WriteString("# Install and start MariaDB\n")
+	script.WriteString("if command -v dnf >/dev/null 2>&1; then\n")
+	script.WriteString("  dnf install -y mariadb-server || dnf install -y mariadb105-server\n")
+	script.WriteString("elif command -v yum >/dev/null 2>&1; then\n")
+	script.WriteString("  yum install -y mariadb-server\n")
+	script.WriteString("else\n")
+	script.WriteString("  export DEBIAN_FRONTEND=noninteractive\n")
+	script.WriteString("  apt-get update -y\n")
+	script.WriteString("  apt-get install -y mariadb-server\n")
+	script.WriteString("fi\n")
+	script.WriteString("systemctl enable --now mariadb\n\n")
+
+	script.WriteString("# Create the accounting database and its user\n")
+	script.WriteString("SLURMDBD_PASSWORD=$(openssl rand -hex 24)\n")
+	script.WriteString("mysql -u root <<SQL\n")
+	script.WriteString("CREATE DATABASE IF NOT EXISTS " + accountingDatabase + ";\n")
+	script.WriteString("CREATE USER IF NOT EXISTS 'slurm'@'localhost' IDENTIFIED BY '${SLURMDBD_PASSWORD}';\n")
+	script.WriteString("ALTER USER 'slurm'@'localhost' IDENTIFIED BY '${SLURMDBD_PASSWORD}';\n")
+	script.WriteString("GRANT ALL ON " + accountingDatabase + ".* TO 'slurm'@'localhost';\n")
+	script.WriteString("SQL\n\n")
+
+	script.WriteString("# Configure and start slurmdbd\n")
+	script.WriteString("cat > \"$SLURM_ETC/slurmdbd.conf\" <<EOF\n")
+	script.WriteString("AuthType=auth/munge\n")
+	script.WriteString("DbdHost=localhost\n")
+	script.WriteString("SlurmUser=slurm\n")
+	script.WriteString("LogFile=/var/log/slurmdbd.log\n")
+	script.WriteString("PidFile=/var/run/slurmdbd.pid\n")
+	script.WriteString("StorageType=accounting_storage/mysql\n")
+	script.WriteString("StorageHost=localhost\n")
+	script.WriteString("StorageUser=slurm\n")
+	script.WriteString("StoragePass=${SLURMDBD_PASSWORD}\n")
+	script.WriteString("StorageLoc=" + accountingDatabase + "\n")
+	script.WriteString("EOF\n")
+	script.WriteString("chown slurm:slurm \"$SLURM_ETC/slurmdbd.conf\"\n")
+	script.WriteString("chmod 600 \"$SLURM_ETC/slurmdbd.conf\"\n\n")
+
+	script.WriteString("cat > /etc/systemd/system/slurmdbd.service <<EOF\n")
+	script.WriteString("[Unit]\n")
+	script.WriteString("Description=Slurm accounting database daemon\n")
+	script.WriteString("After=network-online.target munge.service mariadb.service\n\n")
+	script.WriteString("[Service]\n")
+	script.WriteString("Type=simple\n")
+	script.WriteString("ExecStart=/opt/slurm/sbin/slurmdbd -D -f $SLURM_ETC/slurmdbd.conf\n")
+	script.WriteString("Restart=on-failure\n\n")
+	script.WriteString("[Install]\n")
+	script.WriteString("WantedBy=multi-user.target\n")
+	script.WriteString("EOF\n")
+	script.WriteString("systemctl daemon-reload\n")
+	script.WriteString("systemctl enable --now slurmdbd\n\n")
+
+	script.WriteString("# Register the cluster once slurmdbd answers\n")
+	script.WriteString("for attempt in $(seq 1 30); do\n")
+	script.WriteString("  /opt/slurm/bin/sacctmgr -n show cluster >/dev/null 2>&1 && break\n")
+	script.WriteString("  sleep 2\n")
+	script.WriteString("done\n")
+	script.WriteString("SLURM_CLUSTER=$(sed -n 's/^ClusterName=//p' \"$SLURM_ETC/slurm.conf\" | head -1)\n")
+	script.WriteString("/opt/slurm/bin/sacctmgr -i add cluster \"$SLURM_CLUSTER\" || echo \"Cluster $SLURM_CLUSTER is already registered\"\n")
+	script.WriteString("systemctl restart slurmctld\n")
+	script.WriteString("echo \"Slurm accounting setup complete\"\n")
+
+	return script.String()
+}
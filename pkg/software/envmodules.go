@@ -0,0 +1,127 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnvModulesConfig holds configuration for environment-modules (Tcl) setup.
+type EnvModulesConfig struct {
+	// ModulePath is the root path for site modules
+	ModulePath string
+	// SpackRoot is the Spack installation path
+	SpackRoot string
+}
+
+// DefaultEnvModulesConfig returns the default environment-modules configuration.
+func DefaultEnvModulesConfig() *EnvModulesConfig {
+	return &EnvModulesConfig{
+		ModulePath: "/opt/modules",
+		SpackRoot:  "/opt/spack",
+	}
+}
+
+// EnvModulesInstaller generates scripts for installing and configuring environment-modules.
+type EnvModulesInstaller struct {
+	config *EnvModulesConfig
+}
+
+// NewEnvModulesInstaller creates a new environment-modules installer.
+func NewEnvModulesInstaller(config *EnvModulesConfig) *EnvModulesInstaller {
+	if config == nil {
+		config = DefaultEnvModulesConfig()
+	}
+	return &EnvModulesInstaller{config: config}
+}
+
+// GenerateInstallScript generates a bash script to install environment-modules.
+func (e *EnvModulesInstaller) GenerateInstallScript() string {
+	var script strings.Builder
+
+	script.WriteString("#!/bin/bash\n")
+	script.WriteString("set -e\n\n")
+	script.WriteString("# Environment Modules Installation Script\n")
+	script.WriteString("# Generated by pctl\n\n")
+
+	script.WriteString("echo \"Installing environment-modules...\"\n")
+	script.WriteString("yum install -y environment-modules tcl\n\n")
+
+	// Create module directories
+	script.WriteString("# Create module directories\n")
+	script.WriteString(fmt.Sprintf("mkdir -p %s\n\n", e.config.ModulePath))
+
+	// Setup environment-modules for all users
+	script.WriteString("# Setup environment-modules for all users\n")
+	script.WriteString("cat > /etc/profile.d/z00_modules.sh << 'EOF'\n")
+	script.WriteString("# environment-modules setup\n")
+	script.WriteString("if ! type module >/dev/null 2>&1; then\n")
+	script.WriteString("  . /usr/share/Modules/init/sh\n")
+	script.WriteString("fi\n")
+	script.WriteString(fmt.Sprintf("module use %s\n", e.config.ModulePath))
+	script.WriteString("\n")
+	script.WriteString("# Add Spack-generated Tcl modules for each architecture\n")
+	script.WriteString(fmt.Sprintf("for arch_dir in %s/share/spack/modules/*; do\n", e.config.SpackRoot))
+	script.WriteString("  [ -d \"$arch_dir\" ] && module use \"$arch_dir\"\n")
+	script.WriteString("done\n")
+	script.WriteString("EOF\n\n")
+
+	script.WriteString("echo \"environment-modules installation complete!\"\n")
+	script.WriteString(fmt.Sprintf("echo \"Modules will be available at: %s\"\n", e.config.ModulePath))
+
+	return script.String()
+}
+
+// GenerateSpackIntegrationScript generates a script to integrate Spack with environment-modules.
+func (e *EnvModulesInstaller) GenerateSpackIntegrationScript() string {
+	var script strings.Builder
+
+	script.WriteString("#!/bin/bash\n")
+	script.WriteString("set -e\n\n")
+	script.WriteString("# Spack-Tcl Modules Integration Script\n")
+	script.WriteString("# Generated by pctl\n\n")
+
+	// Source Spack
+	script.WriteString(fmt.Sprintf(". %s/share/spack/setup-env.sh\n\n", e.config.SpackRoot))
+
+	script.WriteString("echo \"Configuring Spack to generate Tcl modules...\"\n\n")
+
+	// Create Spack modules configuration
+	script.WriteString("mkdir -p ~/.spack\n")
+	script.WriteString("cat > ~/.spack/modules.yaml << 'EOF'\n")
+	script.WriteString("modules:\n")
+	script.WriteString("  default:\n")
+	script.WriteString("    enable:\n")
+	script.WriteString("      - tcl\n")
+	script.WriteString("    tcl:\n")
+	script.WriteString("      hash_length: 7\n")
+	script.WriteString("      all:\n")
+	script.WriteString("        environment:\n")
+	script.WriteString("          set:\n")
+	script.WriteString("            '{name}_ROOT': '{prefix}'\n")
+	script.WriteString("      projections:\n")
+	script.WriteString("        all: '{name}/{version}-{hash:7}'\n")
+	script.WriteString("EOF\n\n")
+
+	// Generate modules from installed packages
+	script.WriteString("echo \"Generating Tcl modules for installed Spack packages...\"\n")
+	script.WriteString("spack module tcl refresh --delete-tree -y\n\n")
+
+	script.WriteString("echo \"Spack-Tcl modules integration complete!\"\n")
+	script.WriteString("echo \"Use 'module avail' to see available modules\"\n")
+
+	return script.String()
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// MaxInlineLicenseSize is the largest license file embedded in the build
+// script; larger files are downloaded from S3 to stay within the EC2 user
+// data limit.
+const MaxInlineLicenseSize = 4 * 1024
+
+// licenseSensitiveFlag marks a license file that must not be left in the AMI.
+const licenseSensitiveFlag = "sensitive"
+
+// licenseDestinationPattern restricts destinations to absolute paths that are
+// safe to use unquoted in the build script.
+var licenseDestinationPattern = regexp.MustCompile(`^/[A-Za-z0-9._+@/-]+$`)
+
+// LicenseFile is a local file, such as a FlexLM license for a commercial
+// compiler, staged on the build instance before software is installed.
+type LicenseFile struct {
+	// Source is the local path
+	Source string
+	// Destination is the absolute path on the build instance
+	Destination string
+	// Sensitive files are removed before the AMI is created
+	Sensitive bool
+	// Content is embedded in the build script when URL is empty
+	Content []byte
+	// URL is a presigned URL the file is downloaded from instead of being
+	// embedded
+	URL string
+}
+
+// ParseLicenseFileSpec parses a src:dest[:sensitive] specification without
+// reading the source file.
+func ParseLicenseFileSpec(spec string) (*LicenseFile, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid license file '%s' (expected src:dest or src:dest:%s)", spec, licenseSensitiveFlag)
+	}
+
+	file := &LicenseFile{Source: parts[0], Destination: parts[1]}
+	if len(parts) == 3 {
+		if parts[2] != licenseSensitiveFlag {
+			return nil, fmt.Errorf("invalid license file '%s': unknown flag '%s' (supported: %s)", spec, parts[2], licenseSensitiveFlag)
+		}
+		file.Sensitive = true
+	}
+
+	if !licenseDestinationPattern.MatchString(file.Destination) || path.Clean(file.Destination) != file.Destination {
+		return nil, fmt.Errorf("invalid license file '%s': destination must be a clean absolute path using letters, digits, and ._+@-/", spec)
+	}
+	if file.Destination == "/" || strings.HasSuffix(file.Destination, "/") {
+		return nil, fmt.Errorf("invalid license file '%s': destination must be a file path", spec)
+	}
+
+	return file, nil
+}
+
+// LoadLicenseFile parses a src:dest[:sensitive] specification and reads the
+// source file.
+func LoadLicenseFile(spec string) (*LicenseFile, error) {
+	file, err := ParseLicenseFileSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(file.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read license file: %w", err)
+	}
+	file.Content = content
+
+	return file, nil
+}
+
+// Inline reports whether the file is small enough to embed in the build script.
+func (f *LicenseFile) Inline() bool {
+	return len(f.Content) <= MaxInlineLicenseSize
+}
+
+// GenerateLicenseStagingScript places license files at their destinations,
+// decoding embedded files and downloading the rest.
+func GenerateLicenseStagingScript(files []*LicenseFile) string {
+	var script strings.Builder
+
+	script.WriteString("echo \"Staging license files...\"\n")
+	for _, file := range files {
+		mode := "0644"
+		if file.Sensitive {
+			mode = "0600"
+		}
+		script.WriteString(fmt.Sprintf("mkdir -p %s\n", path.Dir(file.Destination)))
+		if file.URL != "" {
+			script.WriteString(fmt.Sprintf("curl -fsSL -o %s '%s'\n", file.Destination, file.URL))
+		} else {
+			script.WriteString(fmt.Sprintf("echo '%s' | base64 -d > %s\n", base64.StdEncoding.EncodeToString(file.Content), file.Destination))
+		}
+		script.WriteString(fmt.Sprintf("chmod %s %s\n", mode, file.Destination))
+	}
+	script.WriteString("echo \"License files staged\"\n")
+
+	return script.String()
+}
+
+// GenerateLicenseRemovalScript removes sensitive license files, along with
+// cloud-init's copy of the build script that may embed them, so they are not
+// captured in the AMI. It returns an empty string if no file is sensitive.
+func GenerateLicenseRemovalScript(files []*LicenseFile) string {
+	var script strings.Builder
+
+	for _, file := range files {
+		if file.Sensitive {
+			script.WriteString(fmt.Sprintf("rm -f %s\n", file.Destination))
+		}
+	}
+	if script.Len() == 0 {
+		return ""
+	}
+	script.WriteString("rm -f /var/lib/cloud/instance/user-data.txt /var/lib/cloud/instance/user-data.txt.i /var/lib/cloud/instance/scripts/*\n")
+
+	return "echo \"Removing sensitive license files...\"\n" + script.String()
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func TestParseLicenseFileSpec(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          string
+		wantDest      string
+		wantSensitive bool
+		wantErr       bool
+	}{
+		{"src and dest", "intel.lic:/opt/intel/licenses/intel.lic", "/opt/intel/licenses/intel.lic", false, false},
+		{"sensitive", "./matlab.dat:/usr/local/MATLAB/licenses/license.dat:sensitive", "/usr/local/MATLAB/licenses/license.dat", true, false},
+		{"missing dest", "intel.lic", "", false, true},
+		{"empty source", ":/opt/intel.lic", "", false, true},
+		{"unknown flag", "intel.lic:/opt/intel.lic:secret", "", false, true},
+		{"relative dest", "intel.lic:opt/intel.lic", "", false, true},
+		{"unclean dest", "intel.lic:/opt/../etc/passwd", "", false, true},
+		{"dest with quote", "intel.lic:/opt/intel'.lic", "", false, true},
+		{"dest directory", "intel.lic:/opt/intel/", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseLicenseFileSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLicenseFileSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if file.Destination != tt.wantDest {
+				t.Errorf("Destination = %q, want %q", file.Destination, tt.wantDest)
+			}
+			if file.Sensitive != tt.wantSensitive {
+				t.Errorf("Sensitive = %v, want %v", file.Sensitive, tt.wantSensitive)
+			}
+		})
+	}
+}
+
+func TestLoadLicenseFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "intel.lic")
+	if err := os.WriteFile(src, []byte("SERVER license-host 0 27009\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadLicenseFile(src + ":/opt/intel/licenses/intel.lic")
+	if err != nil {
+		t.Fatalf("LoadLicenseFile() error = %v", err)
+	}
+	if string(file.Content) != "SERVER license-host 0 27009\n" {
+		t.Errorf("Content = %q", file.Content)
+	}
+	if !file.Inline() {
+		t.Error("small license file should be embedded inline")
+	}
+
+	if _, err := LoadLicenseFile(filepath.Join(dir, "missing.lic") + ":/opt/missing.lic"); err == nil {
+		t.Error("LoadLicenseFile() expected error for a missing source")
+	}
+
+	large := &LicenseFile{Content: make([]byte, MaxInlineLicenseSize+1)}
+	if large.Inline() {
+		t.Error("license file over MaxInlineLicenseSize should not be embedded inline")
+	}
+}
+
+func TestManager_GenerateBootstrapScript_LicenseFiles(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{
+			Name:   "test-cluster",
+			Region: "us-east-1",
+		},
+		Software: template.SoftwareConfig{
+			SpackPackages: []string{"intel-oneapi-compilers@2024.0.0"},
+		},
+	}
+
+	content := []byte("SERVER license-host 0 27009\n")
+	files := []*LicenseFile{
+		{Source: "intel.lic", Destination: "/opt/intel/licenses/intel.lic", Content: content},
+		{Source: "big.dat", Destination: "/usr/local/MATLAB/licenses/license.dat", Sensitive: true, URL: "https://bucket.s3.amazonaws.com/license?X-Amz-Signature=abc"},
+	}
+
+	manager := NewManager()
+	manager.SetLicenseFiles(files)
+	script := manager.GenerateBootstrapScript(tmpl, false, false)
+
+	inline := "echo '" + base64.StdEncoding.EncodeToString(content) + "' | base64 -d > /opt/intel/licenses/intel.lic"
+	download := "curl -fsSL -o /usr/local/MATLAB/licenses/license.dat 'https://bucket.s3.amazonaws.com/license?X-Amz-Signature=abc'"
+	for _, want := range []string{
+		"mkdir -p /opt/intel/licenses",
+		inline,
+		"chmod 0644 /opt/intel/licenses/intel.lic",
+		"mkdir -p /usr/local/MATLAB/licenses",
+		download,
+		"chmod 0600 /usr/local/MATLAB/licenses/license.dat",
+		"rm -f /usr/local/MATLAB/licenses/license.dat",
+		"rm -f /var/lib/cloud/instance/user-data.txt",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Script should contain %q", want)
+		}
+	}
+	if strings.Contains(script, "rm -f /opt/intel/licenses/intel.lic") {
+		t.Error("Script should keep license files that are not sensitive")
+	}
+
+	// Licenses are staged before installing and sensitive ones removed
+	// before the build reports completion
+	staged := strings.Index(script, inline)
+	installed := strings.Index(script, "spack install")
+	removed := strings.Index(script, "rm -f /usr/local/MATLAB/licenses/license.dat")
+	complete := strings.Index(script, "update_progress_tag \"Installation complete\" 100")
+	if !(staged < installed && installed < removed && removed < complete) {
+		t.Errorf("unexpected order: staged=%d installed=%d removed=%d complete=%d", staged, installed, removed, complete)
+	}
+}
+
+func TestGenerateLicenseRemovalScriptNoSensitive(t *testing.T) {
+	files := []*LicenseFile{{Source: "intel.lic", Destination: "/opt/intel/licenses/intel.lic"}}
+	if script := GenerateLicenseRemovalScript(files); script != "" {
+		t.Errorf("GenerateLicenseRemovalScript() = %q, want empty", script)
+	}
+}
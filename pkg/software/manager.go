@@ -21,10 +21,21 @@ import (
 	"github.com/scttfrdmn/petal/pkg/template"
 )
 
-// Manager coordinates Spack and Lmod installation and configuration.
+// BootstrapLogPath is where the bootstrap script copies its output on the
+// build instance, so progress markers can be read without the console.
+const BootstrapLogPath = "/var/log/pctl-bootstrap.log"
+
+// Manager coordinates Spack and module system installation and configuration.
 type Manager struct {
-	spackInstaller *SpackInstaller
-	lmodInstaller  *LmodInstaller
+	spackInstaller      *SpackInstaller
+	lmodInstaller       *LmodInstaller
+	envModulesInstaller *EnvModulesInstaller
+	spackLock           *SpackLock
+	licenseFiles        []*LicenseFile
+	skeletons           map[string][]byte
+	preInstall          *PreInstallScript
+	verify              []string
+	slurmAccounting     bool
 }
 
 // NewManager creates a new software manager.
@@ -33,11 +44,54 @@ func NewManager() *Manager {
 	lmodConfig := DefaultLmodConfig()
 
 	return &Manager{
-		spackInstaller: NewSpackInstaller(spackConfig),
-		lmodInstaller:  NewLmodInstaller(lmodConfig),
+		spackInstaller:      NewSpackInstaller(spackConfig),
+		lmodInstaller:       NewLmodInstaller(lmodConfig),
+		envModulesInstaller: NewEnvModulesInstaller(DefaultEnvModulesConfig()),
 	}
 }
 
+// SetSpackLock makes the bootstrap script install from a Spack lockfile
+// instead of concretizing the template's package specs.
+func (m *Manager) SetSpackLock(lock *SpackLock) {
+	m.spackLock = lock
+}
+
+// SetSpackConfigFiles makes the bootstrap script write Spack configuration
+// files (e.g., packages.yaml with external packages) before installing.
+func (m *Manager) SetSpackConfigFiles(files []*SpackConfigFile) {
+	m.spackInstaller.config.ConfigFiles = files
+}
+
+// SetLicenseFiles makes the bootstrap script stage license files before
+// installing software and remove the sensitive ones once it is done.
+func (m *Manager) SetLicenseFiles(files []*LicenseFile) {
+	m.licenseFiles = files
+}
+
+// SetSkeletons provides the packed local skeleton directories, keyed by
+// path, that the bootstrap script copies into new home directories.
+func (m *Manager) SetSkeletons(skeletons map[string][]byte) {
+	m.skeletons = skeletons
+}
+
+// SetPreInstallScript makes the bootstrap script run a script before any
+// software is installed.
+func (m *Manager) SetPreInstallScript(script *PreInstallScript) {
+	m.preInstall = script
+}
+
+// SetVerifyCommands makes the bootstrap script run commands after software
+// is installed, failing if any of them fails.
+func (m *Manager) SetVerifyCommands(commands []string) {
+	m.verify = commands
+}
+
+// SetSlurmAccounting makes the bootstrap script set up Slurm accounting with
+// a local database. It only applies to scripts run on a cluster head node.
+func (m *Manager) SetSlurmAccounting(enabled bool) {
+	m.slurmAccounting = enabled
+}
+
 // GenerateBootstrapScript generates a complete bootstrap script for software installation.
 // This replaces the old bootstrap script generation in pkg/config/generator.go
 func (m *Manager) GenerateBootstrapScript(tmpl *template.Template, includeUsers, includeS3Mounts bool) string {
@@ -50,9 +104,12 @@ func (m *Manager) GenerateBootstrapScript(tmpl *template.Template, includeUsers,
 	script.WriteString("# Region: " + tmpl.Cluster.Region + "\n\n")
 
 	script.WriteString("# Enable detailed logging\n")
-	script.WriteString("exec 1> >(logger -s -t pctl-bootstrap) 2>&1\n")
+	script.WriteString(fmt.Sprintf("exec 1> >(tee -a %s | logger -s -t pctl-bootstrap) 2>&1\n", BootstrapLogPath))
 	script.WriteString("echo \"Starting pctl bootstrap at $(date)\"\n\n")
 
+	// The proxy must be set up before anything reaches the network
+	script.WriteString(GenerateProxyScript(tmpl.Network))
+
 	// Add progress tagging helper function
 	script.WriteString("# Helper function to update progress tag\n")
 	script.WriteString("update_progress_tag() {\n")
@@ -76,6 +133,15 @@ func (m *Manager) GenerateBootstrapScript(tmpl *template.Template, includeUsers,
 	script.WriteString("# Initialize progress\n")
 	script.WriteString("update_progress_tag \"Bootstrap started\" 0\n\n")
 
+	// Site setup (repos, kernel parameters, ...) runs before any installs
+	if m.preInstall != nil {
+		script.WriteString("#" + strings.Repeat("=", 78) + "\n")
+		script.WriteString("# PRE-INSTALL SCRIPT\n")
+		script.WriteString("#" + strings.Repeat("=", 78) + "\n\n")
+		script.WriteString(GeneratePreInstallScript(m.preInstall))
+		script.WriteString("\n")
+	}
+
 	// User creation
 	if includeUsers && len(tmpl.Users) > 0 {
 		script.WriteString("#" + strings.Repeat("=", 78) + "\n")
@@ -87,6 +153,9 @@ func (m *Manager) GenerateBootstrapScript(tmpl *template.Template, includeUsers,
 				user.GID, user.Name, user.Name))
 			script.WriteString(fmt.Sprintf("useradd -u %d -g %d -m -s /bin/bash %s 2>/dev/null || echo \"User %s already exists\"\n",
 				user.UID, user.GID, user.Name, user.Name))
+			if user.Skeleton != "" {
+				script.WriteString(GenerateSkeletonScript(user, m.skeletons[user.Skeleton]))
+			}
 		}
 		script.WriteString("echo \"User creation complete\"\n\n")
 	}
@@ -108,8 +177,27 @@ func (m *Manager) GenerateBootstrapScript(tmpl *template.Template, includeUsers,
 		script.WriteString("echo \"S3 mount setup complete\"\n\n")
 	}
 
+	// Accounting is set up before the long software installation so jobs
+	// run in the meantime are recorded
+	if m.slurmAccounting {
+		script.WriteString("#" + strings.Repeat("=", 78) + "\n")
+		script.WriteString("# SLURM ACCOUNTING\n")
+		script.WriteString("#" + strings.Repeat("=", 78) + "\n\n")
+		script.WriteString(GenerateAccountingScript())
+		script.WriteString("\n")
+	}
+
+	// License files are staged before installers need them
+	if len(m.licenseFiles) > 0 {
+		script.WriteString("#" + strings.Repeat("=", 78) + "\n")
+		script.WriteString("# LICENSE FILES\n")
+		script.WriteString("#" + strings.Repeat("=", 78) + "\n\n")
+		script.WriteString(GenerateLicenseStagingScript(m.licenseFiles))
+		script.WriteString("\n")
+	}
+
 	// Software installation
-	if len(tmpl.Software.SpackPackages) > 0 {
+	if len(tmpl.Software.SpackPackages) > 0 || m.spackLock != nil {
 		script.WriteString("#" + strings.Repeat("=", 78) + "\n")
 		script.WriteString("# SOFTWARE INSTALLATION\n")
 		script.WriteString("#" + strings.Repeat("=", 78) + "\n\n")
@@ -120,23 +208,51 @@ func (m *Manager) GenerateBootstrapScript(tmpl *template.Template, includeUsers,
 		script.WriteString(m.spackInstaller.GenerateInstallScript())
 		script.WriteString("\n")
 
-		// Install Lmod
-		script.WriteString("update_progress_tag \"Installing Lmod module system\" 15\n")
-		script.WriteString("# Install Lmod\n")
-		script.WriteString(m.lmodInstaller.GenerateInstallScript())
-		script.WriteString("\n")
+		// Install module system
+		useEnvModules := tmpl.Software.GetModuleSystem() == template.ModuleSystemEnvironmentModules
+		installModules := tmpl.Software.InstallsModuleSystem()
+		if useEnvModules {
+			script.WriteString("update_progress_tag \"Installing environment-modules\" 15\n")
+			script.WriteString("# Install environment-modules\n")
+			script.WriteString(m.envModulesInstaller.GenerateInstallScript())
+			script.WriteString("\n")
+		} else if installModules {
+			script.WriteString("update_progress_tag \"Installing Lmod module system\" 15\n")
+			script.WriteString("# Install Lmod\n")
+			script.WriteString(m.lmodInstaller.GenerateInstallScript())
+			script.WriteString("\n")
+		}
 
 		// Install packages
 		script.WriteString("update_progress_tag \"Starting package installation\" 20\n")
-		script.WriteString("# Install Spack packages\n")
-		script.WriteString(m.spackInstaller.GeneratePackageInstallScript(tmpl.Software.SpackPackages))
+		if m.spackLock != nil {
+			script.WriteString(fmt.Sprintf("# Install Spack packages from spack.lock (sha256 %s)\n", m.spackLock.Hash))
+			script.WriteString(m.spackInstaller.GenerateLockInstallScript(m.spackLock))
+		} else {
+			script.WriteString("# Install Spack packages\n")
+			script.WriteString(m.spackInstaller.GeneratePackageInstallScript(tmpl.Software.SpackPackages))
+		}
 		script.WriteString("\n")
 
-		// Integrate Spack with Lmod
-		script.WriteString("update_progress_tag \"Integrating Spack with Lmod\" 85\n")
-		script.WriteString("# Integrate Spack with Lmod\n")
-		script.WriteString(m.lmodInstaller.GenerateSpackIntegrationScript())
-		script.WriteString("\n")
+		// Integrate Spack with the module system
+		if useEnvModules {
+			script.WriteString("update_progress_tag \"Integrating Spack with environment-modules\" 85\n")
+			script.WriteString("# Integrate Spack with environment-modules\n")
+			script.WriteString(m.envModulesInstaller.GenerateSpackIntegrationScript())
+			script.WriteString("\n")
+		} else if installModules {
+			script.WriteString("update_progress_tag \"Integrating Spack with Lmod\" 85\n")
+			script.WriteString("# Integrate Spack with Lmod\n")
+			script.WriteString(m.lmodInstaller.GenerateSpackIntegrationScript())
+			script.WriteString("\n")
+		}
+
+		// Load default modules on login
+		if len(tmpl.Software.DefaultModules) > 0 {
+			script.WriteString("# Configure default modules\n")
+			script.WriteString(GenerateDefaultModulesScript(tmpl.Software.DefaultModules))
+			script.WriteString("\n")
+		}
 
 		// Mark completion at 100%
 		script.WriteString("update_progress_tag \"Finalizing installation\" 95\n")
@@ -146,6 +262,22 @@ func (m *Manager) GenerateBootstrapScript(tmpl *template.Template, includeUsers,
 		script.WriteString("sync\n\n")
 	}
 
+	// Verification runs before license removal, since licensed software may
+	// need its license to start
+	if len(m.verify) > 0 {
+		script.WriteString("#" + strings.Repeat("=", 78) + "\n")
+		script.WriteString("# VERIFICATION\n")
+		script.WriteString("#" + strings.Repeat("=", 78) + "\n\n")
+		script.WriteString(GenerateVerifyScript(m.verify))
+		script.WriteString("\n")
+	}
+
+	if removal := GenerateLicenseRemovalScript(m.licenseFiles); removal != "" {
+		script.WriteString("# Remove sensitive license files\n")
+		script.WriteString(removal)
+		script.WriteString("\n")
+	}
+
 	script.WriteString("update_progress_tag \"Installation complete\" 100\n")
 	script.WriteString("echo \"Bootstrap complete at $(date)\"\n")
 	script.WriteString("echo \"Cluster is ready for use!\"\n")
@@ -182,3 +314,19 @@ func (m *Manager) GenerateSoftwareOnlyScript(packages []string) string {
 
 	return script.String()
 }
+
+// GenerateDefaultModulesScript generates a script that writes a login profile
+// loading the given modules for all users.
+func GenerateDefaultModulesScript(modules []string) string {
+	var script strings.Builder
+
+	script.WriteString("echo \"Configuring default modules...\"\n")
+	script.WriteString("cat > /etc/profile.d/z01_pctl_default_modules.sh << 'EOF'\n")
+	script.WriteString("# Default modules loaded by pctl\n")
+	script.WriteString("if type module >/dev/null 2>&1; then\n")
+	script.WriteString(fmt.Sprintf("  module load %s\n", strings.Join(modules, " ")))
+	script.WriteString("fi\n")
+	script.WriteString("EOF\n")
+
+	return script.String()
+}
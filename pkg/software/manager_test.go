@@ -15,6 +15,7 @@
 package software
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -67,6 +68,8 @@ func TestManager_GenerateBootstrapScript(t *testing.T) {
 		"python@3.10",
 		"gcc@11.3.0",
 		"Bootstrap complete",
+		// Output is kept on disk so progress can be read over SSM
+		"tee -a " + BootstrapLogPath,
 	}
 
 	for _, section := range requiredSections {
@@ -165,3 +168,203 @@ func TestManager_GenerateBootstrapScript_NoSoftware(t *testing.T) {
 		t.Error("Script should not contain software installation section when no packages specified")
 	}
 }
+
+func TestManager_GenerateBootstrapScript_ModuleSystem(t *testing.T) {
+	tests := []struct {
+		name         string
+		moduleSystem string
+		want         []string
+		notWant      []string
+	}{
+		{
+			name:         "default is lmod",
+			moduleSystem: "",
+			want:         []string{"Lmod Installation", "spack module lmod refresh"},
+			notWant:      []string{"Environment Modules Installation", "spack module tcl refresh"},
+		},
+		{
+			name:         "explicit lmod",
+			moduleSystem: template.ModuleSystemLmod,
+			want:         []string{"Lmod Installation", "spack module lmod refresh"},
+			notWant:      []string{"spack module tcl refresh"},
+		},
+		{
+			name:         "environment-modules",
+			moduleSystem: template.ModuleSystemEnvironmentModules,
+			want:         []string{"yum install -y environment-modules", "spack module tcl refresh", "- tcl"},
+			notWant:      []string{"Lmod Installation", "spack module lmod refresh"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := &template.Template{
+				Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+				Software: template.SoftwareConfig{
+					SpackPackages: []string{"gcc@11.3.0"},
+					ModuleSystem:  tt.moduleSystem,
+				},
+			}
+
+			script := NewManager().GenerateBootstrapScript(tmpl, false, false)
+
+			for _, want := range tt.want {
+				if !strings.Contains(script, want) {
+					t.Errorf("Script missing %q", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(script, notWant) {
+					t.Errorf("Script should not contain %q", notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestManager_GenerateBootstrapScript_DefaultModules(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Software: template.SoftwareConfig{
+			SpackPackages:  []string{"gcc@11.3.0", "openmpi@4.1.4"},
+			DefaultModules: []string{"gcc", "openmpi"},
+		},
+	}
+
+	script := NewManager().GenerateBootstrapScript(tmpl, false, false)
+
+	if !strings.Contains(script, "/etc/profile.d/z01_pctl_default_modules.sh") {
+		t.Error("Script should write default modules profile")
+	}
+	if !strings.Contains(script, "module load gcc openmpi") {
+		t.Error("Script should load default modules in order")
+	}
+
+	// Default modules must be configured after modules are generated
+	if strings.Index(script, "module load gcc openmpi") < strings.Index(script, "spack module lmod refresh") {
+		t.Error("Default modules should be configured after Spack module generation")
+	}
+
+	tmpl.Software.DefaultModules = nil
+	script = NewManager().GenerateBootstrapScript(tmpl, false, false)
+	if strings.Contains(script, "z01_pctl_default_modules.sh") {
+		t.Error("Script should not write default modules profile when none are configured")
+	}
+}
+
+func TestGenerateDefaultModulesScript(t *testing.T) {
+	script := GenerateDefaultModulesScript([]string{"python/3.10", "gcc"})
+
+	expected := []string{
+		"cat > /etc/profile.d/z01_pctl_default_modules.sh << 'EOF'",
+		"if type module >/dev/null 2>&1; then",
+		"  module load python/3.10 gcc\n",
+		"EOF\n",
+	}
+	for _, want := range expected {
+		if !strings.Contains(script, want) {
+			t.Errorf("Script missing %q", want)
+		}
+	}
+}
+
+func TestManager_GenerateBootstrapScript_SpackLock(t *testing.T) {
+	lock, err := ParseSpackLock([]byte(testSpackLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		packages []string
+	}{
+		{"lock only", nil},
+		{"lock overrides packages", []string{"gcc@11.3.0", "openmpi@4.1.5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := &template.Template{
+				Cluster: template.ClusterConfig{
+					Name:   "test-cluster",
+					Region: "us-east-1",
+				},
+				Software: template.SoftwareConfig{
+					SpackPackages: tt.packages,
+				},
+			}
+
+			manager := NewManager()
+			manager.SetSpackLock(lock)
+			script := manager.GenerateBootstrapScript(tmpl, false, false)
+
+			if !strings.Contains(script, "SOFTWARE INSTALLATION") {
+				t.Error("Script should install software when a lockfile is set")
+			}
+			if !strings.Contains(script, "spack -e "+SpackLockEnvPath+" install") {
+				t.Error("Script should install from the lockfile environment")
+			}
+			if !strings.Contains(script, lock.Hash) {
+				t.Error("Script should reference the lockfile hash")
+			}
+			for _, pkg := range tt.packages {
+				if strings.Contains(script, "spack install --fail-fast --use-buildcache=auto "+pkg) {
+					t.Errorf("Script should not install %s from its spec", pkg)
+				}
+			}
+			if !strings.Contains(script, "spack module lmod refresh") {
+				t.Error("Script should still integrate Spack with Lmod")
+			}
+		})
+	}
+}
+
+func TestManager_GenerateBootstrapScript_SkipLmod(t *testing.T) {
+	installLmod := false
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Software: template.SoftwareConfig{
+			SpackPackages: []string{"gcc@11.3.0", "openmpi@4.1.4"},
+			InstallLmod:   &installLmod,
+		},
+	}
+
+	script := NewManager().GenerateBootstrapScript(tmpl, false, false)
+
+	for _, notWant := range []string{
+		"Lmod Installation",
+		"Installing Lmod module system",
+		"Integrating Spack with Lmod",
+		"spack module lmod refresh",
+		"Environment Modules Installation",
+	} {
+		if strings.Contains(script, notWant) {
+			t.Errorf("Script should not contain %q", notWant)
+		}
+	}
+	for _, want := range []string{"Installing Spack package manager", "openmpi@4.1.4", "Installation complete"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Script missing %q", want)
+		}
+	}
+
+	// Progress still climbs steadily to 100%
+	last := -1
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(line, "update_progress_tag \"") {
+			continue
+		}
+		fields := strings.Fields(line)
+		var percent int
+		if _, err := fmt.Sscanf(fields[len(fields)-1], "%d", &percent); err != nil {
+			t.Fatalf("Unparseable progress line %q", line)
+		}
+		if percent <= last {
+			t.Errorf("Progress went from %d%% to %d%%", last, percent)
+		}
+		last = percent
+	}
+	if last != 100 {
+		t.Errorf("Final progress = %d%%, want 100%%", last)
+	}
+}
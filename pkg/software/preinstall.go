@@ -0,0 +1,97 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// preInstallScriptPath is where the pre-install script is written on the
+// instance before it runs.
+const preInstallScriptPath = "/tmp/pctl-pre-install.sh"
+
+// PreInstallScript is a shell script run before any software is installed.
+type PreInstallScript struct {
+	// Source is the S3 URI or local path the script came from, or "inline"
+	Source string
+	// Content is the script itself; empty for S3 scripts, which the
+	// instance downloads
+	Content string
+	// URL is a presigned download URL for an S3 script, so the instance
+	// needs no S3 permissions
+	URL string
+}
+
+// InS3 reports whether the script is downloaded from S3 on the instance.
+func (s *PreInstallScript) InS3() bool {
+	return strings.HasPrefix(s.Source, "s3://")
+}
+
+// LoadPreInstallScript resolves a pre-install script setting: an S3 URI, an
+// inline script starting with #!, or the path of a local script. Local and
+// inline scripts must be sh or bash scripts.
+func LoadPreInstallScript(value string) (*PreInstallScript, error) {
+	switch {
+	case strings.HasPrefix(value, "s3://"):
+		if !strings.HasSuffix(value, ".sh") || strings.ContainsAny(value, "'\n") {
+			return nil, fmt.Errorf("invalid pre-install script %q: S3 scripts must be .sh objects without quotes", value)
+		}
+		return &PreInstallScript{Source: value}, nil
+	case template.IsInlineScript(value):
+		if err := template.ValidateShellScript(value); err != nil {
+			return nil, fmt.Errorf("invalid inline pre-install script: %w", err)
+		}
+		return &PreInstallScript{Source: "inline", Content: value}, nil
+	}
+
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pre-install script: %w", err)
+	}
+	if err := template.ValidateShellScript(string(data)); err != nil {
+		return nil, fmt.Errorf("invalid pre-install script %s: %w", value, err)
+	}
+	return &PreInstallScript{Source: value, Content: string(data)}, nil
+}
+
+// GeneratePreInstallScript returns the bootstrap section that writes or
+// downloads the pre-install script and runs it. A failing script fails the
+// bootstrap.
+func GeneratePreInstallScript(s *PreInstallScript) string {
+	var script strings.Builder
+
+	script.WriteString("update_progress_tag \"Running pre-install script\" 5\n")
+	script.WriteString(fmt.Sprintf("echo \"Running pre-install script from %s...\"\n", s.Source))
+	if s.URL != "" {
+		script.WriteString(fmt.Sprintf("curl -fsSL -o %s '%s'\n", preInstallScriptPath, s.URL))
+	} else if s.InS3() {
+		script.WriteString(fmt.Sprintf("aws s3 cp '%s' %s\n", s.Source, preInstallScriptPath))
+	} else {
+		// Base64 avoids quoting and heredoc delimiter clashes with the script
+		encoded := base64.StdEncoding.EncodeToString([]byte(s.Content))
+		script.WriteString(fmt.Sprintf("echo '%s' | base64 -d > %s\n", encoded, preInstallScriptPath))
+	}
+	script.WriteString(fmt.Sprintf("chmod 700 %s\n", preInstallScriptPath))
+	script.WriteString(fmt.Sprintf("%s\n", preInstallScriptPath))
+	script.WriteString(fmt.Sprintf("rm -f %s\n", preInstallScriptPath))
+	script.WriteString("echo \"Pre-install script complete\"\n")
+
+	return script.String()
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func TestLoadPreInstallScript(t *testing.T) {
+	dir := t.TempDir()
+	local := filepath.Join(dir, "setup.sh")
+	if err := os.WriteFile(local, []byte("#!/usr/bin/env bash\ndnf install -y python3.11\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	python := filepath.Join(dir, "setup.py")
+	if err := os.WriteFile(python, []byte("#!/usr/bin/env python3\nprint('hi')\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		value       string
+		wantSource  string
+		wantContent string
+		wantErr     bool
+	}{
+		{"s3", "s3://my-bucket/scripts/setup.sh", "s3://my-bucket/scripts/setup.sh", "", false},
+		{"inline", "#!/bin/sh\nsysctl -w vm.swappiness=10\n", "inline", "#!/bin/sh\nsysctl -w vm.swappiness=10\n", false},
+		{"local", local, local, "#!/usr/bin/env bash\ndnf install -y python3.11\n", false},
+		{"s3 not a shell script", "s3://my-bucket/scripts/setup.py", "", "", true},
+		{"s3 with quote", "s3://my-bucket/it's.sh", "", "", true},
+		{"inline python", "#!/usr/bin/python3\nprint('hi')\n", "", "", true},
+		{"local python", python, "", "", true},
+		{"missing file", filepath.Join(dir, "missing.sh"), "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := LoadPreInstallScript(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadPreInstallScript(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if script.Source != tt.wantSource {
+				t.Errorf("Source = %q, want %q", script.Source, tt.wantSource)
+			}
+			if script.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", script.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestGeneratePreInstallScript(t *testing.T) {
+	content := "#!/bin/bash\ncat > /etc/yum.repos.d/site.repo <<'EOF'\n[site]\nEOF\n"
+	inline := GeneratePreInstallScript(&PreInstallScript{Source: "inline", Content: content})
+	encoded := "echo '" + base64.StdEncoding.EncodeToString([]byte(content)) + "' | base64 -d > /tmp/pctl-pre-install.sh"
+	if !strings.Contains(inline, encoded) {
+		t.Errorf("inline script should be written base64-encoded, got:\n%s", inline)
+	}
+
+	s3 := GeneratePreInstallScript(&PreInstallScript{Source: "s3://my-bucket/setup.sh"})
+	if !strings.Contains(s3, "aws s3 cp 's3://my-bucket/setup.sh' /tmp/pctl-pre-install.sh") {
+		t.Errorf("S3 script should be downloaded with the AWS CLI, got:\n%s", s3)
+	}
+
+	presigned := GeneratePreInstallScript(&PreInstallScript{Source: "s3://my-bucket/setup.sh", URL: "https://my-bucket.s3.amazonaws.com/setup.sh?X-Amz-Signature=abc"})
+	if !strings.Contains(presigned, "curl -fsSL -o /tmp/pctl-pre-install.sh 'https://my-bucket.s3.amazonaws.com/setup.sh?X-Amz-Signature=abc'") {
+		t.Errorf("presigned script should be downloaded with curl, got:\n%s", presigned)
+	}
+
+	for _, want := range []string{"chmod 700 /tmp/pctl-pre-install.sh", "\n/tmp/pctl-pre-install.sh\n", "rm -f /tmp/pctl-pre-install.sh"} {
+		if !strings.Contains(inline, want) {
+			t.Errorf("script should contain %q", want)
+		}
+	}
+}
+
+func TestManager_GenerateBootstrapScript_PreInstallScript(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{
+			Name:   "test-cluster",
+			Region: "us-east-1",
+		},
+		Software: template.SoftwareConfig{
+			SpackPackages: []string{"gcc@11.3.0"},
+		},
+	}
+
+	manager := NewManager()
+	script := manager.GenerateBootstrapScript(tmpl, false, false)
+	if strings.Contains(script, "pctl-pre-install.sh") {
+		t.Error("Script should not run a pre-install script when none is set")
+	}
+
+	manager.SetPreInstallScript(&PreInstallScript{Source: "inline", Content: "#!/bin/bash\nsysctl -w vm.swappiness=10\n"})
+	script = manager.GenerateBootstrapScript(tmpl, false, false)
+
+	// The hook runs after progress tracking starts and before Spack is installed
+	started := strings.Index(script, "update_progress_tag \"Bootstrap started\" 0")
+	hook := strings.Index(script, "/tmp/pctl-pre-install.sh\n")
+	spack := strings.Index(script, "Installing Spack package manager")
+	if started < 0 || hook < 0 || spack < 0 {
+		t.Fatalf("missing section: started=%d hook=%d spack=%d", started, hook, spack)
+	}
+	if !(started < hook && hook < spack) {
+		t.Errorf("unexpected order: started=%d hook=%d spack=%d", started, hook, spack)
+	}
+}
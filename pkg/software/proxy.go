@@ -0,0 +1,57 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"strings"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// DefaultNoProxy are the hosts that always bypass network.proxy: instance
+// metadata (IMDS), which a proxy cannot reach on the instance's behalf, and
+// the AWS service endpoints used for progress tags and S3.
+var DefaultNoProxy = []string{
+	"169.254.169.254",
+	"localhost",
+	"127.0.0.1",
+	".amazonaws.com",
+}
+
+// GenerateProxyScript returns the bootstrap section that routes downloads
+// through network.proxy. The VPC CIDRs are looked up from instance metadata
+// and added to no_proxy so traffic within the VPC stays direct. Returns ""
+// when no proxy is set.
+func GenerateProxyScript(network template.NetworkConfig) string {
+	if network.Proxy == "" {
+		return ""
+	}
+
+	noProxy := append(append([]string{}, DefaultNoProxy...), network.NoProxy...)
+
+	var script strings.Builder
+	script.WriteString("# Route downloads through the proxy; instance metadata, AWS endpoints,\n")
+	script.WriteString("# and the VPC bypass it\n")
+	script.WriteString("IMDS_TOKEN=$(curl -s --noproxy '*' -X PUT \"http://169.254.169.254/latest/api/token\" -H \"X-aws-ec2-metadata-token-ttl-seconds: 21600\")\n")
+	script.WriteString("IMDS_MAC=$(curl -s --noproxy '*' -H \"X-aws-ec2-metadata-token: $IMDS_TOKEN\" http://169.254.169.254/latest/meta-data/mac)\n")
+	script.WriteString("VPC_CIDRS=$(curl -s --noproxy '*' -H \"X-aws-ec2-metadata-token: $IMDS_TOKEN\" \"http://169.254.169.254/latest/meta-data/network/interfaces/macs/$IMDS_MAC/vpc-ipv4-cidr-blocks\" | paste -sd, -)\n")
+	script.WriteString("export http_proxy=" + shellQuote(network.Proxy) + "\n")
+	script.WriteString("export https_proxy=\"$http_proxy\" HTTP_PROXY=\"$http_proxy\" HTTPS_PROXY=\"$http_proxy\"\n")
+	script.WriteString("export no_proxy=" + shellQuote(strings.Join(noProxy, ",")) + "\"${VPC_CIDRS:+,$VPC_CIDRS}\"\n")
+	script.WriteString("export NO_PROXY=\"$no_proxy\"\n")
+	script.WriteString("echo \"Using proxy for downloads (no_proxy: $no_proxy)\"\n\n")
+
+	return script.String()
+}
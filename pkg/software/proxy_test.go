@@ -0,0 +1,70 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func TestGenerateProxyScript(t *testing.T) {
+	if script := GenerateProxyScript(template.NetworkConfig{}); script != "" {
+		t.Errorf("Expected no proxy setup without network.proxy, got:\n%s", script)
+	}
+
+	script := GenerateProxyScript(template.NetworkConfig{
+		Proxy:   "http://proxy.example.com:3128",
+		NoProxy: []string{".corp.example.com"},
+	})
+
+	for _, want := range []string{
+		"export http_proxy='http://proxy.example.com:3128'\n",
+		"HTTPS_PROXY=\"$http_proxy\"",
+		"export no_proxy='169.254.169.254,localhost,127.0.0.1,.amazonaws.com,.corp.example.com'\"${VPC_CIDRS:+,$VPC_CIDRS}\"\n",
+		"export NO_PROXY=\"$no_proxy\"",
+		"/vpc-ipv4-cidr-blocks",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Proxy script missing %q:\n%s", want, script)
+		}
+	}
+
+	// Metadata lookups must not go through the proxy
+	for _, line := range strings.Split(script, "\n") {
+		if strings.Contains(line, "curl") && !strings.Contains(line, "--noproxy '*'") {
+			t.Errorf("IMDS request should bypass the proxy: %s", line)
+		}
+	}
+}
+
+func TestBootstrapScriptSetsProxyFirst(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster:  template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Network:  template.NetworkConfig{Proxy: "http://proxy.example.com:3128"},
+		Software: template.SoftwareConfig{SpackPackages: []string{"samtools@1.17"}},
+	}
+
+	script := NewManager().GenerateBootstrapScript(tmpl, true, true)
+
+	proxy := strings.Index(script, "export http_proxy=")
+	if proxy < 0 {
+		t.Fatalf("Bootstrap script should set up the proxy:\n%s", script)
+	}
+	if tagging := strings.Index(script, "update_progress_tag \"Bootstrap started\""); tagging < proxy {
+		t.Error("Proxy must be set up before progress tagging reaches AWS")
+	}
+}
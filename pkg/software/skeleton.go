@@ -0,0 +1,147 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// skeletonMarker is created in a home directory once its skeleton has been
+// copied, so nodes sharing /home do not copy it again.
+const skeletonMarker = ".pctl-skeleton"
+
+// LoadSkeleton packs a local skeleton directory into a gzipped tar archive
+// for embedding in the bootstrap script. Only regular files and directories
+// are included.
+func LoadSkeleton(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read skeleton directory %s: %w", dir, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to archive skeleton directory %s: %w", dir, err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress skeleton directory %s: %w", dir, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// LoadSkeletons packs the local skeleton directories of users, keyed by
+// skeleton path. Skeletons in S3 are downloaded by the nodes instead.
+func LoadSkeletons(users []template.User) (map[string][]byte, error) {
+	skeletons := make(map[string][]byte)
+	for _, user := range users {
+		if user.Skeleton == "" || user.SkeletonInS3() {
+			continue
+		}
+		if _, ok := skeletons[user.Skeleton]; ok {
+			continue
+		}
+		archive, err := LoadSkeleton(user.Skeleton)
+		if err != nil {
+			return nil, err
+		}
+		skeletons[user.Skeleton] = archive
+	}
+	return skeletons, nil
+}
+
+// GenerateSkeletonScript copies a user's skeleton into their home directory
+// and gives them ownership of it. archive is the packed local skeleton and
+// is ignored for skeletons in S3. Existing files are never overwritten, and
+// the copy happens only once per home directory.
+func GenerateSkeletonScript(user template.User, archive []byte) string {
+	var script strings.Builder
+	home := "/home/" + user.Name
+	marker := home + "/" + skeletonMarker
+
+	var fetch string
+	switch {
+	case user.SkeletonInS3():
+		fetch = fmt.Sprintf("aws s3 cp --recursive --only-show-errors '%s' \"$SKEL_DIR\"", user.Skeleton)
+	case archive != nil:
+		fetch = fmt.Sprintf("echo '%s' | base64 -d | tar -xz -C \"$SKEL_DIR\"", base64.StdEncoding.EncodeToString(archive))
+	default:
+		return fmt.Sprintf("echo \"Warning: skeleton %s for %s was not loaded\"\n", user.Skeleton, user.Name)
+	}
+
+	script.WriteString(fmt.Sprintf("if [ ! -e %s ]; then\n", marker))
+	script.WriteString("  SKEL_DIR=$(mktemp -d)\n")
+	script.WriteString(fmt.Sprintf("  if %s; then\n", fetch))
+	script.WriteString(fmt.Sprintf("    cp -rn \"$SKEL_DIR\"/. %s/\n", home))
+	script.WriteString(fmt.Sprintf("    touch %s\n", marker))
+	script.WriteString(fmt.Sprintf("    chown -R %d:%d %s\n", user.UID, user.GID, home))
+	script.WriteString(fmt.Sprintf("    echo \"Copied skeleton into %s\"\n", home))
+	script.WriteString("  else\n")
+	script.WriteString(fmt.Sprintf("    echo \"Warning: Failed to copy skeleton for %s\"\n", user.Name))
+	script.WriteString("  fi\n")
+	script.WriteString("  rm -rf \"$SKEL_DIR\"\n")
+	script.WriteString("fi\n")
+
+	return script.String()
+}
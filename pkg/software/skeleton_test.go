@@ -0,0 +1,166 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+// archiveContents unpacks a skeleton archive into a map of name to content,
+// with directories mapped to "/".
+func archiveContents(t *testing.T, archive []byte) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	tr := tar.NewReader(gz)
+	contents := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar Next() error = %v", err)
+		}
+		if header.Typeflag == tar.TypeDir {
+			contents[header.Name] = "/"
+			continue
+		}
+		data, _ := io.ReadAll(tr)
+		contents[header.Name] = string(data)
+	}
+	return contents
+}
+
+func TestLoadSkeleton(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".bashrc"), []byte("module load gcc\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, ".config", "lab"), 0755)
+	os.WriteFile(filepath.Join(dir, ".config", "lab", "settings"), []byte("x=1\n"), 0600)
+	os.Symlink("/etc/passwd", filepath.Join(dir, "passwd"))
+
+	archive, err := LoadSkeleton(dir)
+	if err != nil {
+		t.Fatalf("LoadSkeleton() error = %v", err)
+	}
+
+	want := map[string]string{
+		".bashrc":              "module load gcc\n",
+		".config":              "/",
+		".config/lab":          "/",
+		".config/lab/settings": "x=1\n",
+	}
+	if got := archiveContents(t, archive); !reflect.DeepEqual(got, want) {
+		t.Errorf("archive contents = %v, want %v", got, want)
+	}
+
+	if _, err := LoadSkeleton(filepath.Join(dir, "missing")); err == nil {
+		t.Error("LoadSkeleton() of a missing directory should fail")
+	}
+}
+
+func TestLoadSkeletons(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "README"), []byte("Welcome\n"), 0644)
+
+	skeletons, err := LoadSkeletons([]template.User{
+		{Name: "alice", UID: 5001, GID: 5001, Skeleton: dir},
+		{Name: "bob", UID: 5002, GID: 5002, Skeleton: dir},
+		{Name: "carol", UID: 5003, GID: 5003, Skeleton: "s3://lab-skel/home"},
+		{Name: "dave", UID: 5004, GID: 5004},
+	})
+	if err != nil {
+		t.Fatalf("LoadSkeletons() error = %v", err)
+	}
+
+	var keys []string
+	for key := range skeletons {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{dir}) {
+		t.Errorf("LoadSkeletons() keys = %v, want only the local directory", keys)
+	}
+}
+
+func TestManager_GenerateBootstrapScript_Skeletons(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "README"), []byte("Welcome\n"), 0644)
+
+	tmpl := &template.Template{
+		Cluster: template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Users: []template.User{
+			{Name: "alice", UID: 5001, GID: 5001, Skeleton: dir},
+			{Name: "bob", UID: 5002, GID: 5002, Skeleton: "s3://lab-skel/home"},
+			{Name: "carol", UID: 5003, GID: 5003},
+		},
+	}
+	skeletons, err := LoadSkeletons(tmpl.Users)
+	if err != nil {
+		t.Fatalf("LoadSkeletons() error = %v", err)
+	}
+
+	manager := NewManager()
+	manager.SetSkeletons(skeletons)
+	script := manager.GenerateBootstrapScript(tmpl, true, false)
+
+	embedded := base64.StdEncoding.EncodeToString(skeletons[dir])
+	for _, want := range []string{
+		"if [ ! -e /home/alice/.pctl-skeleton ]; then",
+		"echo '" + embedded + "' | base64 -d | tar -xz -C \"$SKEL_DIR\"",
+		"cp -rn \"$SKEL_DIR\"/. /home/alice/",
+		"chown -R 5001:5001 /home/alice",
+		"if [ ! -e /home/bob/.pctl-skeleton ]; then",
+		"aws s3 cp --recursive --only-show-errors 's3://lab-skel/home' \"$SKEL_DIR\"",
+		"cp -rn \"$SKEL_DIR\"/. /home/bob/",
+		"chown -R 5002:5002 /home/bob",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q", want)
+		}
+	}
+	if strings.Contains(script, "/home/carol") {
+		t.Error("script should not copy a skeleton for a user without one")
+	}
+
+	// Each skeleton is copied after its user is created
+	if strings.Index(script, "useradd -u 5001") > strings.Index(script, "/home/alice/.pctl-skeleton") {
+		t.Error("alice's skeleton should be copied after useradd")
+	}
+	if strings.Index(script, "useradd -u 5002") > strings.Index(script, "/home/bob/.pctl-skeleton") {
+		t.Error("bob's skeleton should be copied after useradd")
+	}
+}
+
+func TestGenerateSkeletonScriptNotLoaded(t *testing.T) {
+	script := GenerateSkeletonScript(template.User{Name: "alice", UID: 5001, GID: 5001, Skeleton: "./skel"}, nil)
+	if !strings.Contains(script, "Warning: skeleton ./skel for alice was not loaded") || strings.Contains(script, "chown") {
+		t.Errorf("unexpected script for an unloaded skeleton:\n%s", script)
+	}
+}
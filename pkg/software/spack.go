@@ -30,6 +30,8 @@ type SpackConfig struct {
 	CompilerPackages []string
 	// Packages are the packages to install
 	Packages []string
+	// ConfigFiles are written to Spack's site scope before installing
+	ConfigFiles []*SpackConfigFile
 }
 
 // DefaultSpackConfig returns the default Spack configuration.
@@ -88,6 +90,11 @@ func (s *SpackInstaller) GenerateInstallScript() string {
 	script.WriteString("# Source Spack environment\n")
 	script.WriteString(fmt.Sprintf(". %s/share/spack/setup-env.sh\n\n", s.config.InstallPath))
 
+	// Site configuration must be in place before compilers and externals are detected
+	if len(s.config.ConfigFiles) > 0 {
+		script.WriteString(s.generateConfigScript())
+	}
+
 	// Initialize Spack
 	script.WriteString("echo \"Initializing Spack...\"\n")
 	script.WriteString("spack compiler find\n\n")
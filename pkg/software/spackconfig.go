@@ -0,0 +1,138 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// spackConfigDelimiter ends the heredoc each config file is written with.
+const spackConfigDelimiter = "PCTL_SPACK_CONFIG"
+
+// spackConfigSections are the Spack configuration sections that can be
+// supplied as files; each is named <section>.yaml.
+var spackConfigSections = map[string]bool{
+	"bootstrap":   true,
+	"compilers":   true,
+	"concretizer": true,
+	"config":      true,
+	"mirrors":     true,
+	"modules":     true,
+	"packages":    true,
+	"repos":       true,
+	"upstreams":   true,
+}
+
+// SpackConfigFile is a Spack configuration file written to Spack's site
+// scope before any packages are installed, e.g. packages.yaml declaring
+// system compilers and MPI as externals.
+type SpackConfigFile struct {
+	// Section is the configuration section (e.g., "packages")
+	Section string
+	// Content is the raw YAML
+	Content []byte
+}
+
+// FileName returns the file name Spack reads the section from.
+func (f *SpackConfigFile) FileName() string {
+	return f.Section + ".yaml"
+}
+
+// LoadSpackConfigFile reads a Spack configuration file. The section is taken
+// from the file name, so the file must be named like packages.yaml.
+func LoadSpackConfigFile(filename string) (*SpackConfigFile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spack config file: %w", err)
+	}
+
+	base := filepath.Base(filename)
+	section := strings.TrimSuffix(strings.TrimSuffix(base, ".yaml"), ".yml")
+	file, err := ParseSpackConfigFile(section, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spack config file %s: %w", filename, err)
+	}
+	return file, nil
+}
+
+// ParseSpackConfigFile validates inline configuration for a section. The
+// content must be YAML whose top-level key is the section name, as Spack
+// requires.
+func ParseSpackConfigFile(section string, data []byte) (*SpackConfigFile, error) {
+	if !spackConfigSections[section] {
+		return nil, fmt.Errorf("unknown spack config section '%s' (supported: %s)", section, strings.Join(spackConfigSectionNames(), ", "))
+	}
+
+	// The file is embedded in a heredoc ending at this line
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == spackConfigDelimiter {
+			return nil, fmt.Errorf("content cannot contain the line %s", spackConfigDelimiter)
+		}
+	}
+
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if _, ok := root[section]; !ok {
+		return nil, fmt.Errorf("missing top-level '%s:' key", section)
+	}
+	if len(root) != 1 {
+		return nil, fmt.Errorf("must contain only the top-level '%s:' key", section)
+	}
+
+	return &SpackConfigFile{Section: section, Content: data}, nil
+}
+
+// spackConfigSectionNames returns the supported sections in sorted order.
+func spackConfigSectionNames() []string {
+	names := make([]string, 0, len(spackConfigSections))
+	for name := range spackConfigSections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// generateConfigScript writes the configuration files to Spack's site scope
+// and checks that Spack can read them.
+func (s *SpackInstaller) generateConfigScript() string {
+	var script strings.Builder
+
+	siteDir := path.Join(s.config.InstallPath, "etc", "spack")
+	script.WriteString("# Site Spack configuration\n")
+	script.WriteString("echo \"Writing Spack site configuration...\"\n")
+	script.WriteString(fmt.Sprintf("mkdir -p %s\n", siteDir))
+	for _, file := range s.config.ConfigFiles {
+		content := string(file.Content)
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		script.WriteString(fmt.Sprintf("cat > %s << '%s'\n", path.Join(siteDir, file.FileName()), spackConfigDelimiter))
+		script.WriteString(content)
+		script.WriteString(spackConfigDelimiter + "\n")
+		script.WriteString(fmt.Sprintf("spack config --scope site get %s > /dev/null\n", file.Section))
+	}
+	script.WriteString("\n")
+
+	return script.String()
+}
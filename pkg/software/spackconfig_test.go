@@ -0,0 +1,141 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+const testPackagesYAML = `packages:
+  openmpi:
+    externals:
+    - spec: openmpi@4.1.5
+      prefix: /opt/amazon/openmpi
+    buildable: false
+`
+
+func TestParseSpackConfigFile(t *testing.T) {
+	file, err := ParseSpackConfigFile("packages", []byte(testPackagesYAML))
+	if err != nil {
+		t.Fatalf("ParseSpackConfigFile() error = %v", err)
+	}
+	if file.FileName() != "packages.yaml" {
+		t.Errorf("FileName() = %q, want packages.yaml", file.FileName())
+	}
+}
+
+func TestParseSpackConfigFileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		content string
+		wantErr string
+	}{
+		{"unknown section", "settings", "settings: {}\n", "unknown spack config section 'settings'"},
+		{"invalid yaml", "config", "config: [unclosed\n", "failed to parse YAML"},
+		{"wrong top-level key", "config", testPackagesYAML, "missing top-level 'config:' key"},
+		{"extra top-level key", "packages", testPackagesYAML + "config: {}\n", "only the top-level 'packages:' key"},
+		{"heredoc delimiter", "config", "config:\n  build_jobs: 8\n" + spackConfigDelimiter + "\n", "cannot contain the line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSpackConfigFile(tt.section, []byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseSpackConfigFile() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadSpackConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("config:\n  build_jobs: 16\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := LoadSpackConfigFile(path)
+	if err != nil {
+		t.Fatalf("LoadSpackConfigFile() error = %v", err)
+	}
+	if file.Section != "config" {
+		t.Errorf("Section = %q, want config", file.Section)
+	}
+
+	// The section comes from the file name
+	misnamed := filepath.Join(dir, "site.yaml")
+	if err := os.WriteFile(misnamed, []byte("config:\n  build_jobs: 16\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSpackConfigFile(misnamed); err == nil || !strings.Contains(err.Error(), "unknown spack config section 'site'") {
+		t.Errorf("LoadSpackConfigFile() error = %v, want unknown section error", err)
+	}
+}
+
+func TestBootstrapScriptWritesSpackConfig(t *testing.T) {
+	packagesFile, err := ParseSpackConfigFile("packages", []byte(testPackagesYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configFile, err := ParseSpackConfigFile("config", []byte("config:\n  install_tree:\n    root: /shared/spack"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	manager.SetSpackConfigFiles([]*SpackConfigFile{packagesFile, configFile})
+	script := manager.GenerateBootstrapScript(&template.Template{
+		Cluster:  template.ClusterConfig{Name: "test", Region: "us-east-1"},
+		Software: template.SoftwareConfig{SpackPackages: []string{"gromacs@2023.1"}},
+	}, false, false)
+
+	for _, want := range []string{
+		"mkdir -p /opt/spack/etc/spack\n",
+		"cat > /opt/spack/etc/spack/packages.yaml << 'PCTL_SPACK_CONFIG'\n" + testPackagesYAML + "PCTL_SPACK_CONFIG\n",
+		"cat > /opt/spack/etc/spack/config.yaml << 'PCTL_SPACK_CONFIG'\nconfig:\n  install_tree:\n    root: /shared/spack\nPCTL_SPACK_CONFIG\n",
+		"spack config --scope site get packages > /dev/null\n",
+		"spack config --scope site get config > /dev/null\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("bootstrap script missing %q", want)
+		}
+	}
+
+	// Externals must be configured before compilers are detected and packages installed
+	configAt := strings.Index(script, "packages.yaml")
+	if compilerAt := strings.Index(script, "spack compiler find"); compilerAt < configAt {
+		t.Error("spack config should be written before spack compiler find")
+	}
+	if installAt := strings.Index(script, "spack install"); installAt < configAt {
+		t.Error("spack config should be written before packages are installed")
+	}
+}
+
+func TestBootstrapScriptWithoutSpackConfig(t *testing.T) {
+	script := NewManager().GenerateBootstrapScript(&template.Template{
+		Cluster:  template.ClusterConfig{Name: "test", Region: "us-east-1"},
+		Software: template.SoftwareConfig{SpackPackages: []string{"gromacs@2023.1"}},
+	}, false, false)
+
+	if strings.Contains(script, "etc/spack") {
+		t.Error("bootstrap script should not write site config when none is set")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Locations used on the build instance when installing from a lockfile.
+const (
+	// SpackLockPath is where the lockfile is written
+	SpackLockPath = "/opt/pctl/spack.lock"
+	// SpackLockEnvPath is where the Spack environment is created from it
+	SpackLockEnvPath = "/opt/pctl/spack-env"
+)
+
+// SpackLock is a fully concretized Spack environment lockfile (spack.lock).
+type SpackLock struct {
+	// Content is the raw lockfile
+	Content []byte
+	// Hash is the SHA-256 of the lockfile content
+	Hash string
+	// Roots are the root specs of the environment
+	Roots []string
+	// URL is the presigned URL the build instance downloads the lockfile
+	// from; lockfiles are too large to embed in EC2 user data
+	URL string
+}
+
+// spackLockFile is the subset of the spack.lock format pctl inspects.
+type spackLockFile struct {
+	Roots []struct {
+		Hash string `json:"hash"`
+		Spec string `json:"spec"`
+	} `json:"roots"`
+	ConcreteSpecs map[string]json.RawMessage `json:"concrete_specs"`
+}
+
+// LoadSpackLock reads and validates a spack.lock file.
+func LoadSpackLock(path string) (*SpackLock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spack lockfile: %w", err)
+	}
+
+	lock, err := ParseSpackLock(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spack lockfile %s: %w", path, err)
+	}
+	return lock, nil
+}
+
+// ParseSpackLock validates lockfile content. The lockfile must be JSON with
+// at least one root spec and its concrete specs.
+func ParseSpackLock(data []byte) (*SpackLock, error) {
+	var file spackLockFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile JSON: %w", err)
+	}
+	if len(file.Roots) == 0 {
+		return nil, fmt.Errorf("lockfile has no root specs")
+	}
+	if len(file.ConcreteSpecs) == 0 {
+		return nil, fmt.Errorf("lockfile has no concrete specs")
+	}
+
+	lock := &SpackLock{Content: data}
+	for _, root := range file.Roots {
+		if _, ok := file.ConcreteSpecs[root.Hash]; !ok {
+			return nil, fmt.Errorf("root spec %q is not concretized in the lockfile", root.Spec)
+		}
+		lock.Roots = append(lock.Roots, root.Spec)
+	}
+
+	sum := sha256.Sum256(data)
+	lock.Hash = hex.EncodeToString(sum[:])
+
+	return lock, nil
+}
+
+// GenerateLockInstallScript generates a script that installs the exact
+// package versions recorded in a Spack lockfile. The lockfile is downloaded
+// from lock.URL, and its hash is checked before installing.
+func (s *SpackInstaller) GenerateLockInstallScript(lock *SpackLock) string {
+	var script strings.Builder
+
+	script.WriteString("#!/bin/bash\n")
+	script.WriteString("# Spack Lockfile Installation Script\n")
+	script.WriteString("# Generated by pctl\n\n")
+
+	// Source Spack
+	script.WriteString(fmt.Sprintf(". %s/share/spack/setup-env.sh\n\n", s.config.InstallPath))
+
+	script.WriteString(fmt.Sprintf("echo \"PCTL_PROGRESS: Installing %d root specs from spack.lock (20%%)\"\n", len(lock.Roots)))
+	script.WriteString(fmt.Sprintf("mkdir -p %s\n", path.Dir(SpackLockPath)))
+	script.WriteString(fmt.Sprintf("if ! curl -fsSL --retry 3 -o %s '%s'; then\n", SpackLockPath, lock.URL))
+	script.WriteString("  echo \"ERROR: Failed to download spack.lock\"\n")
+	script.WriteString("  exit 1\n")
+	script.WriteString("fi\n")
+	script.WriteString(fmt.Sprintf("if ! echo \"%s  %s\" | sha256sum -c -; then\n", lock.Hash, SpackLockPath))
+	script.WriteString("  echo \"ERROR: spack.lock does not match the expected hash\"\n")
+	script.WriteString("  exit 1\n")
+	script.WriteString("fi\n\n")
+
+	script.WriteString("# Create the environment from the lockfile (no re-concretization)\n")
+	script.WriteString(fmt.Sprintf("spack env create -d %s %s\n", SpackLockEnvPath, SpackLockPath))
+	script.WriteString(fmt.Sprintf("if ! spack -e %s install --fail-fast --use-buildcache=auto; then\n", SpackLockEnvPath))
+	script.WriteString("  echo \"ERROR: Failed to install from spack.lock\"\n")
+	script.WriteString("  exit 1\n")
+	script.WriteString("fi\n")
+
+	script.WriteString("\necho \"PCTL_PROGRESS: Package installation complete (80%)\"\n")
+	script.WriteString(fmt.Sprintf("spack -e %s find\n", SpackLockEnvPath))
+
+	return script.String()
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSpackLock = `{
+  "_meta": {"file-type": "spack-lockfile", "lockfile-version": 5},
+  "roots": [
+    {"hash": "abc123", "spec": "samtools@1.17"},
+    {"hash": "def456", "spec": "bwa@0.7.17"}
+  ],
+  "concrete_specs": {
+    "abc123": {"name": "samtools", "version": "1.17"},
+    "def456": {"name": "bwa", "version": "0.7.17"}
+  }
+}`
+
+func TestParseSpackLock(t *testing.T) {
+	lock, err := ParseSpackLock([]byte(testSpackLock))
+	if err != nil {
+		t.Fatalf("ParseSpackLock() error = %v", err)
+	}
+	if len(lock.Roots) != 2 || lock.Roots[0] != "samtools@1.17" || lock.Roots[1] != "bwa@0.7.17" {
+		t.Errorf("Roots = %v, want [samtools@1.17 bwa@0.7.17]", lock.Roots)
+	}
+	if len(lock.Hash) != 64 {
+		t.Errorf("Hash = %q, want a SHA-256 hex digest", lock.Hash)
+	}
+
+	again, _ := ParseSpackLock([]byte(testSpackLock))
+	if again.Hash != lock.Hash {
+		t.Error("Hash should be stable for identical content")
+	}
+}
+
+func TestParseSpackLockInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"not json", "roots:\n  - samtools", "failed to parse lockfile JSON"},
+		{"truncated", testSpackLock[:40], "failed to parse lockfile JSON"},
+		{"no roots", `{"concrete_specs": {"abc": {}}}`, "no root specs"},
+		{"no concrete specs", `{"roots": [{"hash": "abc", "spec": "bwa"}]}`, "no concrete specs"},
+		{"root not concretized", `{"roots": [{"hash": "abc", "spec": "bwa"}], "concrete_specs": {"xyz": {}}}`, `root spec "bwa"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSpackLock([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseSpackLock() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadSpackLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spack.lock")
+	if err := os.WriteFile(path, []byte(testSpackLock), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := LoadSpackLock(path)
+	if err != nil {
+		t.Fatalf("LoadSpackLock() error = %v", err)
+	}
+	if string(lock.Content) != testSpackLock {
+		t.Error("Content should be the raw lockfile")
+	}
+
+	if _, err := LoadSpackLock(filepath.Join(t.TempDir(), "missing.lock")); err == nil {
+		t.Error("LoadSpackLock() should fail for a missing file")
+	}
+}
+
+func TestSpackInstaller_GenerateLockInstallScript(t *testing.T) {
+	lock, err := ParseSpackLock([]byte(testSpackLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock.URL = "https://bucket.s3.amazonaws.com/ami-builds/build-1/spack.lock?X-Amz-Signature=abc"
+
+	script := NewSpackInstaller(nil).GenerateLockInstallScript(lock)
+
+	checks := []string{
+		". /opt/spack/share/spack/setup-env.sh",
+		"curl -fsSL --retry 3 -o " + SpackLockPath + " '" + lock.URL + "'",
+		lock.Hash + "  " + SpackLockPath,
+		"sha256sum -c",
+		"spack env create -d " + SpackLockEnvPath + " " + SpackLockPath,
+		"spack -e " + SpackLockEnvPath + " install --fail-fast",
+		"Package installation complete (80%)",
+	}
+	for _, check := range checks {
+		if !strings.Contains(script, check) {
+			t.Errorf("Script missing %q", check)
+		}
+	}
+
+	// The install must not re-concretize individual specs
+	if strings.Contains(script, "spack install --fail-fast --use-buildcache=auto samtools") {
+		t.Error("Script should install from the lockfile, not from package specs")
+	}
+
+	// The lockfile is downloaded, never embedded in the user data
+	if strings.Contains(script, `"_meta"`) || strings.Contains(script, "base64 -d") {
+		t.Error("Script should not embed the lockfile")
+	}
+}
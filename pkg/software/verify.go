@@ -0,0 +1,77 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VerifyFailedMarker starts the progress message reported when a build
+// verification command fails, so the AMI builder can fail the build instead
+// of creating an AMI.
+const VerifyFailedMarker = "PCTL_VERIFY_FAILED"
+
+// verifyProgress is the progress percentage reported while verifying.
+const verifyProgress = 97
+
+// maxVerifyTagCommand bounds the command text in the failure progress
+// message, keeping the tag under EC2's 256-character value limit.
+const maxVerifyTagCommand = 160
+
+// GenerateVerifyScript returns the bootstrap section that runs each
+// verification command in a login shell, so module and Spack environments
+// are set up. The first failing command reports VerifyFailedMarker as the
+// progress message and fails the bootstrap.
+func GenerateVerifyScript(commands []string) string {
+	var script strings.Builder
+
+	script.WriteString(fmt.Sprintf("update_progress_tag \"Verifying installation\" %d\n", verifyProgress))
+	for i, command := range commands {
+		check := fmt.Sprintf("check %d of %d", i+1, len(commands))
+		script.WriteString(fmt.Sprintf("echo \"PCTL_VERIFY: %s: \"%s\n", check, shellQuote(command)))
+		script.WriteString(fmt.Sprintf("if ! bash -lc %s; then\n", shellQuote(command)))
+		script.WriteString(fmt.Sprintf("  update_progress_tag %s %d\n",
+			shellQuote(fmt.Sprintf("%s: %s failed: %s", VerifyFailedMarker, check, tagSafe(command, maxVerifyTagCommand))), verifyProgress))
+		script.WriteString("  exit 1\n")
+		script.WriteString("fi\n")
+	}
+	script.WriteString("echo \"Verification complete\"\n")
+
+	return script.String()
+}
+
+// shellQuote quotes s as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// tagSafe replaces characters EC2 does not allow in tag values with
+// underscores and truncates s to at most limit characters.
+func tagSafe(s string, limit int) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune(" _.:/=+-@", r):
+			return r
+		}
+		return '_'
+	}, s)
+	if len(safe) > limit {
+		safe = safe[:limit-3] + "..."
+	}
+	return safe
+}
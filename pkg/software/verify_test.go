@@ -0,0 +1,110 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/scttfrdmn/petal/pkg/template"
+)
+
+func TestGenerateVerifyScript(t *testing.T) {
+	script := GenerateVerifyScript([]string{"gmx --version", "python3 -c 'import numpy'"})
+
+	for _, want := range []string{
+		`update_progress_tag "Verifying installation" 97`,
+		`if ! bash -lc 'gmx --version'; then`,
+		`if ! bash -lc 'python3 -c '\''import numpy'\'''; then`,
+		`update_progress_tag 'PCTL_VERIFY_FAILED: check 1 of 2 failed: gmx --version' 97`,
+		// Quotes are not allowed in tag values
+		`update_progress_tag 'PCTL_VERIFY_FAILED: check 2 of 2 failed: python3 -c _import numpy_' 97`,
+		"  exit 1\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestGenerateVerifyScriptRuns(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	run := func(commands ...string) (string, error) {
+		script := "update_progress_tag() { echo \"PCTL_PROGRESS: $1 ($2%)\"; }\n" + GenerateVerifyScript(commands)
+		out, err := exec.Command("bash", "-c", script).CombinedOutput()
+		return string(out), err
+	}
+
+	out, err := run("true", "test 1 -eq 1")
+	if err != nil {
+		t.Fatalf("passing checks failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Verification complete") {
+		t.Errorf("passing checks should complete:\n%s", out)
+	}
+
+	out, err = run("true", "false", "echo should-not-run")
+	if err == nil {
+		t.Fatalf("failing check should fail the script:\n%s", out)
+	}
+	if !strings.Contains(out, "PCTL_PROGRESS: PCTL_VERIFY_FAILED: check 2 of 3 failed: false (97%)") {
+		t.Errorf("failure should be reported as progress:\n%s", out)
+	}
+	if strings.Contains(out, "should-not-run") || strings.Contains(out, "Verification complete") {
+		t.Errorf("checks after a failure should not run:\n%s", out)
+	}
+}
+
+func TestTagSafe(t *testing.T) {
+	if got := tagSafe(`python3 -c "import numpy; print(1)"`, 100); got != "python3 -c _import numpy_ print_1__" {
+		t.Errorf("tagSafe() = %q", got)
+	}
+	if got := tagSafe(strings.Repeat("a", 20), 10); got != "aaaaaaa..." {
+		t.Errorf("tagSafe() = %q, want truncation to 10 characters", got)
+	}
+}
+
+func TestManager_GenerateBootstrapScript_Verify(t *testing.T) {
+	tmpl := &template.Template{
+		Cluster:  template.ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Software: template.SoftwareConfig{SpackPackages: []string{"gromacs@2023.1"}},
+	}
+
+	manager := NewManager()
+	if script := manager.GenerateBootstrapScript(tmpl, false, false); strings.Contains(script, VerifyFailedMarker) {
+		t.Error("Script should not verify when no commands are set")
+	}
+
+	manager.SetLicenseFiles([]*LicenseFile{{Source: "license.dat", Destination: "/opt/vendor/license.dat", Sensitive: true, Content: []byte("KEY")}})
+	manager.SetVerifyCommands([]string{"gmx --version"})
+	script := manager.GenerateBootstrapScript(tmpl, false, false)
+
+	// Verification runs after packages are installed, while licenses are
+	// still present, and before completion is reported
+	packages := strings.Index(script, "Finalizing installation")
+	verify := strings.Index(script, "bash -lc 'gmx --version'")
+	removal := strings.Index(script, "rm -f /opt/vendor/license.dat")
+	complete := strings.Index(script, `update_progress_tag "Installation complete" 100`)
+	if packages < 0 || verify < 0 || removal < 0 || complete < 0 {
+		t.Fatalf("missing section: packages=%d verify=%d removal=%d complete=%d", packages, verify, removal, complete)
+	}
+	if !(packages < verify && verify < removal && removal < complete) {
+		t.Errorf("unexpected order: packages=%d verify=%d removal=%d complete=%d", packages, verify, removal, complete)
+	}
+}
@@ -18,6 +18,7 @@ package state
 import (
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"time"
@@ -55,6 +56,14 @@ type ClusterState struct {
 	KeyName string `json:"key_name,omitempty"`
 	// Bootstrap script S3 URI
 	BootstrapScriptS3URI string `json:"bootstrap_script_s3_uri,omitempty"`
+	// SubnetID is the head node subnet
+	SubnetID string `json:"subnet_id,omitempty"`
+	// ComputeFleetStatus is the compute fleet status last seen or set by
+	// pctl, e.g. RUNNING or STOPPED
+	ComputeFleetStatus string `json:"compute_fleet_status,omitempty"`
+	// Tags are the tags applied to the cluster's resources at creation,
+	// which ParallelCluster does not allow to change on update
+	Tags map[string]string `json:"tags,omitempty"`
 	// Network resources (if managed by pctl)
 	VpcID                string `json:"vpc_id,omitempty"`
 	PublicSubnetID       string `json:"public_subnet_id,omitempty"`
@@ -62,7 +71,49 @@ type ClusterState struct {
 	SecurityGroupID      string `json:"security_group_id,omitempty"`
 	InternetGatewayID    string `json:"internet_gateway_id,omitempty"`
 	RouteTableID         string `json:"route_table_id,omitempty"`
+	DhcpOptionsID        string `json:"dhcp_options_id,omitempty"`
 	NetworkManagedByPctl bool   `json:"network_managed_by_pctl,omitempty"`
+	// PublicSubnetIDs and PrivateSubnetIDs are the per-zone subnets of a
+	// multi-AZ pctl VPC
+	PublicSubnetIDs  []string `json:"public_subnet_ids,omitempty"`
+	PrivateSubnetIDs []string `json:"private_subnet_ids,omitempty"`
+	// NatGatewayID, EIPAllocationID, and PrivateRouteTableID are the NAT
+	// resources created with --enable-nat
+	NatGatewayID        string `json:"nat_gateway_id,omitempty"`
+	EIPAllocationID     string `json:"eip_allocation_id,omitempty"`
+	PrivateRouteTableID string `json:"private_route_table_id,omitempty"`
+	// S3EndpointID is the gateway VPC endpoint for S3
+	S3EndpointID string `json:"s3_endpoint_id,omitempty"`
+	// SharedVPC means VpcID was provided with --vpc-id; only the subnets,
+	// route table, and security group in it are pctl's
+	SharedVPC bool `json:"shared_vpc,omitempty"`
+	// PlacementGroups are the cluster placement groups created by pctl
+	PlacementGroups []string `json:"placement_groups,omitempty"`
+	// PersistentHome is the logical name of the persistent /home volume
+	// mounted by the cluster, which is kept when the cluster is deleted
+	PersistentHome string `json:"persistent_home,omitempty"`
+	// SSHCIDRs are the CIDRs allowed to SSH to the head node of a
+	// pctl-created VPC
+	SSHCIDRs []string `json:"ssh_cidrs,omitempty"`
+	// Overrides are the create flags that changed the template, which
+	// updates re-apply
+	Overrides *TemplateOverrides `json:"overrides,omitempty"`
+}
+
+// TemplateOverrides records the create flags that change the template
+// rather than the resources around it.
+type TemplateOverrides struct {
+	// Region replaces the template's region (--region)
+	Region string `json:"region,omitempty"`
+	// ScaleToZero sets every queue's min_count and static_count to zero
+	// (--scale-to-zero)
+	ScaleToZero bool `json:"scale_to_zero,omitempty"`
+	// EFAGDR enables GPUDirect RDMA on the queues that support it
+	// (--compute-efa-gdr)
+	EFAGDR bool `json:"efa_gdr,omitempty"`
+	// CapacityReservationGroups maps queue names to On-Demand Capacity
+	// Reservation group ARNs (--compute-on-demand-capacity)
+	CapacityReservationGroups map[string]string `json:"capacity_reservation_groups,omitempty"`
 }
 
 // Manager manages cluster state.
@@ -122,6 +173,28 @@ func (m *Manager) Load(name string) (*ClusterState, error) {
 	return &state, nil
 }
 
+// TemplatePath returns the template a cluster was created from, checking
+// that the file still exists.
+func (m *Manager) TemplatePath(name string) (string, error) {
+	state, err := m.Load(name)
+	if err != nil {
+		return "", err
+	}
+
+	if state.TemplatePath == "" {
+		return "", fmt.Errorf("cluster %s has no recorded template path", name)
+	}
+
+	if _, err := os.Stat(state.TemplatePath); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("template %s for cluster %s no longer exists", state.TemplatePath, name)
+		}
+		return "", fmt.Errorf("failed to access template %s: %w", state.TemplatePath, err)
+	}
+
+	return state.TemplatePath, nil
+}
+
 // Delete deletes cluster state.
 func (m *Manager) Delete(name string) error {
 	path := m.statePath(name)
@@ -169,6 +242,38 @@ func (m *Manager) Exists(name string) bool {
 	return err == nil
 }
 
+// CopyTo copies the cluster and volume records into dir and returns a
+// manager for the copy. Dry runs use it to read real state while keeping
+// every write away from it.
+func (m *Manager) CopyTo(dir string) (*Manager, error) {
+	err := filepath.WalkDir(m.stateDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(m.stateDir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if filepath.Ext(path) != ".json" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy state: %w", err)
+	}
+
+	return &Manager{stateDir: dir}, nil
+}
+
 func (m *Manager) statePath(name string) string {
 	return filepath.Join(m.stateDir, name+".json")
 }
@@ -389,3 +389,102 @@ func TestStatePath(t *testing.T) {
 		t.Errorf("statePath() = %s, want %s", actual, expected)
 	}
 }
+
+func TestTemplatePath(t *testing.T) {
+	tempDir := t.TempDir()
+	manager := &Manager{stateDir: tempDir}
+
+	templatePath := filepath.Join(tempDir, "bio.yaml")
+	if err := os.WriteFile(templatePath, []byte("cluster:\n  name: bio\n"), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	manager.Save(&ClusterState{Name: "bio", TemplatePath: templatePath})
+	manager.Save(&ClusterState{Name: "moved", TemplatePath: filepath.Join(tempDir, "missing.yaml")})
+	manager.Save(&ClusterState{Name: "no-template"})
+
+	path, err := manager.TemplatePath("bio")
+	if err != nil {
+		t.Fatalf("TemplatePath() error = %v", err)
+	}
+	if path != templatePath {
+		t.Errorf("TemplatePath() = %s, want %s", path, templatePath)
+	}
+
+	for _, name := range []string{"moved", "no-template", "unknown"} {
+		if _, err := manager.TemplatePath(name); err == nil {
+			t.Errorf("TemplatePath(%q) expected error, got nil", name)
+		}
+	}
+}
+
+func TestVolumes(t *testing.T) {
+	tempDir := t.TempDir()
+	manager := &Manager{stateDir: tempDir}
+
+	if volume, err := manager.LoadVolume("lab-home"); err != nil || volume != nil {
+		t.Fatalf("LoadVolume() of unknown volume = %v, %v, want nil, nil", volume, err)
+	}
+
+	if err := manager.SaveVolume(&PersistentVolume{Name: "lab-home", Type: "ebs", ID: "vol-0123", Cluster: "bio"}); err != nil {
+		t.Fatalf("SaveVolume() error = %v", err)
+	}
+
+	// Releasing for a different cluster leaves the volume mounted
+	if err := manager.ReleaseVolume("lab-home", "other"); err != nil {
+		t.Fatalf("ReleaseVolume() error = %v", err)
+	}
+	volume, err := manager.LoadVolume("lab-home")
+	if err != nil {
+		t.Fatalf("LoadVolume() error = %v", err)
+	}
+	if volume.Cluster != "bio" || volume.CreatedAt.IsZero() {
+		t.Errorf("unexpected volume: %+v", volume)
+	}
+
+	if err := manager.ReleaseVolume("lab-home", "bio"); err != nil {
+		t.Fatalf("ReleaseVolume() error = %v", err)
+	}
+	volume, _ = manager.LoadVolume("lab-home")
+	if volume.Cluster != "" || volume.ID != "vol-0123" {
+		t.Errorf("Released volume should be kept unmounted, got %+v", volume)
+	}
+
+	// Volume records are never listed as clusters
+	clusters, err := manager.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(clusters) != 0 {
+		t.Errorf("List() = %d clusters, want 0", len(clusters))
+	}
+}
+
+func TestCopyTo(t *testing.T) {
+	manager := &Manager{stateDir: t.TempDir()}
+	if err := manager.Save(&ClusterState{Name: "existing", Region: "us-east-1"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := manager.SaveVolume(&PersistentVolume{Name: "home", Type: "ebs", ID: "vol-1"}); err != nil {
+		t.Fatalf("SaveVolume() error = %v", err)
+	}
+
+	copied, err := manager.CopyTo(t.TempDir())
+	if err != nil {
+		t.Fatalf("CopyTo() error = %v", err)
+	}
+	if !copied.Exists("existing") {
+		t.Error("copy is missing the cluster record")
+	}
+	if volume, err := copied.LoadVolume("home"); err != nil || volume == nil || volume.ID != "vol-1" {
+		t.Errorf("copy volume = %+v, %v, want vol-1", volume, err)
+	}
+
+	// Writes to the copy leave the original untouched
+	if err := copied.Save(&ClusterState{Name: "new", Region: "us-east-1"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if manager.Exists("new") {
+		t.Error("saving to the copy wrote to the original state")
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// volumesDir is the subdirectory of the state directory holding persistent
+// volume records. It is a directory so List never mistakes them for clusters.
+const volumesDir = "volumes"
+
+// PersistentVolume is a volume that outlives the clusters mounting it,
+// tracked by the logical name templates refer to it by.
+type PersistentVolume struct {
+	// Name is the logical name of the volume
+	Name string `json:"name"`
+	// Type is the storage type (ebs or efs)
+	Type string `json:"type"`
+	// ID is the EBS volume or EFS file system ID
+	ID string `json:"id"`
+	// Region is the AWS region of the volume
+	Region string `json:"region"`
+	// AvailabilityZone is the availability zone of an EBS volume
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	// Cluster is the cluster currently mounting the volume, if any
+	Cluster string `json:"cluster,omitempty"`
+	// CreatedByPctl is true if pctl created the volume
+	CreatedByPctl bool `json:"created_by_pctl,omitempty"`
+	// CreatedAt is when the volume was first recorded
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is when the record was last updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SaveVolume saves a persistent volume record.
+func (m *Manager) SaveVolume(volume *PersistentVolume) error {
+	if err := os.MkdirAll(filepath.Join(m.stateDir, volumesDir), 0755); err != nil {
+		return fmt.Errorf("failed to create volumes directory: %w", err)
+	}
+
+	volume.UpdatedAt = time.Now()
+	if volume.CreatedAt.IsZero() {
+		volume.CreatedAt = volume.UpdatedAt
+	}
+
+	data, err := json.MarshalIndent(volume, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal volume: %w", err)
+	}
+
+	if err := os.WriteFile(m.volumePath(volume.Name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write volume file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadVolume loads a persistent volume record. It returns nil without an
+// error if no volume is recorded under name.
+func (m *Manager) LoadVolume(name string) (*PersistentVolume, error) {
+	data, err := os.ReadFile(m.volumePath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read volume file: %w", err)
+	}
+
+	var volume PersistentVolume
+	if err := json.Unmarshal(data, &volume); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal volume: %w", err)
+	}
+
+	return &volume, nil
+}
+
+// ReleaseVolume records that cluster no longer mounts the named volume. The
+// record itself is kept so the volume can be reattached later.
+func (m *Manager) ReleaseVolume(name, cluster string) error {
+	volume, err := m.LoadVolume(name)
+	if err != nil {
+		return err
+	}
+	if volume == nil || volume.Cluster != cluster {
+		return nil
+	}
+
+	volume.Cluster = ""
+	return m.SaveVolume(volume)
+}
+
+func (m *Manager) volumePath(name string) string {
+	return filepath.Join(m.stateDir, volumesDir, name+".json")
+}
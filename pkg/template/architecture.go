@@ -0,0 +1,66 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import "strings"
+
+// CPU architectures as reported by EC2.
+const (
+	ArchitectureX86_64 = "x86_64"
+	ArchitectureARM64  = "arm64"
+)
+
+// armFamilies lists the Graviton instance families.
+var armFamilies = map[string]bool{
+	"t4g":    true,
+	"m6g":    true,
+	"m6gd":   true,
+	"m7g":    true,
+	"m7gd":   true,
+	"c6g":    true,
+	"c6gd":   true,
+	"c6gn":   true,
+	"c7g":    true,
+	"c7gd":   true,
+	"c7gn":   true,
+	"r6g":    true,
+	"r6gd":   true,
+	"r7g":    true,
+	"r7gd":   true,
+	"x2gd":   true,
+	"im4gn":  true,
+	"is4gen": true,
+}
+
+// InstanceArchitecture determines the CPU architecture for an instance type.
+// Unknown or unparseable instance types are assumed to be x86_64.
+func InstanceArchitecture(instanceType string) string {
+	// Extract family from instance type (e.g., "c7g.large" -> "c7g")
+	parts := strings.Split(instanceType, ".")
+	if len(parts) < 2 {
+		return ArchitectureX86_64
+	}
+
+	if armFamilies[parts[0]] {
+		return ArchitectureARM64
+	}
+	return ArchitectureX86_64
+}
+
+// Architecture returns the CPU architecture of the head node, which
+// determines the architecture of the AMI the template is built into.
+func (t *Template) Architecture() string {
+	return InstanceArchitecture(t.Compute.HeadNode)
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import "strings"
+
+// efaInstanceSizes maps instance families that support the Elastic Fabric
+// Adapter to the sizes that do; "*" means every size.
+var efaInstanceSizes = map[string][]string{
+	"c5n":    {"9xlarge", "18xlarge", "metal"},
+	"c6gn":   {"16xlarge"},
+	"c6i":    {"32xlarge", "metal"},
+	"c6in":   {"32xlarge", "metal"},
+	"c7g":    {"16xlarge", "metal"},
+	"c7gn":   {"16xlarge", "metal"},
+	"c7i":    {"48xlarge", "metal-48xl"},
+	"m5n":    {"24xlarge", "metal"},
+	"m5dn":   {"24xlarge", "metal"},
+	"m6i":    {"32xlarge", "metal"},
+	"m7i":    {"48xlarge", "metal-48xl"},
+	"r5n":    {"24xlarge", "metal"},
+	"r6i":    {"32xlarge", "metal"},
+	"hpc6a":  {"*"},
+	"hpc6id": {"*"},
+	"hpc7a":  {"*"},
+	"hpc7g":  {"*"},
+	"g4dn":   {"8xlarge", "12xlarge", "16xlarge", "metal"},
+	"g5":     {"8xlarge", "12xlarge", "16xlarge", "24xlarge", "48xlarge"},
+	"g6":     {"8xlarge", "12xlarge", "16xlarge", "24xlarge", "48xlarge"},
+	"p3dn":   {"24xlarge"},
+	"p4d":    {"24xlarge"},
+	"p4de":   {"24xlarge"},
+	"p5":     {"48xlarge"},
+	"p5e":    {"48xlarge"},
+	"p5en":   {"48xlarge"},
+	"trn1":   {"32xlarge"},
+	"trn1n":  {"32xlarge"},
+}
+
+// gdrFamilies lists the GPU instance families whose EFA supports
+// GPUDirect RDMA.
+var gdrFamilies = map[string]bool{
+	"p4d":  true,
+	"p4de": true,
+	"p5":   true,
+	"p5e":  true,
+	"p5en": true,
+}
+
+// efaFamilyListed reports whether an instance type's family is in
+// efaInstanceSizes. Families missing from it may be new ones that support
+// EFA, so they are left for EC2 to accept or reject.
+func efaFamilyListed(instanceType string) bool {
+	family, _, _ := strings.Cut(instanceType, ".")
+	_, ok := efaInstanceSizes[family]
+	return ok
+}
+
+// SupportsEFA reports whether an instance type can attach an Elastic Fabric
+// Adapter.
+func SupportsEFA(instanceType string) bool {
+	family, size, ok := strings.Cut(instanceType, ".")
+	if !ok {
+		return false
+	}
+	for _, s := range efaInstanceSizes[family] {
+		if s == "*" || s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsGPUDirectRDMA reports whether an instance type supports
+// GPUDirect RDMA over EFA.
+func SupportsGPUDirectRDMA(instanceType string) bool {
+	family, _, _ := strings.Cut(instanceType, ".")
+	return gdrFamilies[family] && SupportsEFA(instanceType)
+}
+
+// EFAEnabled reports whether the queue's nodes attach an EFA.
+func (q Queue) EFAEnabled() bool {
+	return q.EFA != nil && q.EFA.Enabled
+}
+
+// GPUDirectRDMA reports whether the queue's nodes use GPUDirect RDMA over EFA.
+func (q Queue) GPUDirectRDMA() bool {
+	return q.EFAEnabled() && q.EFA.GPUDirectRDMA
+}
+
+// EnableGPUDirectRDMA turns on EFA with GPUDirect RDMA for every queue whose
+// instance types all support it. It returns the names of the queues changed.
+func (t *Template) EnableGPUDirectRDMA() []string {
+	var enabled []string
+	for i := range t.Compute.Queues {
+		queue := &t.Compute.Queues[i]
+		instanceTypes := queue.AllInstanceTypes()
+		if len(instanceTypes) == 0 {
+			continue
+		}
+		supported := true
+		for _, instanceType := range instanceTypes {
+			if !SupportsGPUDirectRDMA(instanceType) {
+				supported = false
+				break
+			}
+		}
+		if !supported {
+			continue
+		}
+		queue.EFA = &QueueEFA{Enabled: true, GPUDirectRDMA: true}
+		enabled = append(enabled, queue.Name)
+	}
+	return enabled
+}
@@ -18,20 +18,51 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 )
 
+// FingerprintVersion is the current fingerprint scheme.
+//
+// Version 1 hashed only the software configuration. Version 2 also hashes the
+// base OS and CPU architecture, so AMIs built for different platforms with
+// identical package sets no longer collide.
+//
+// Package specs are normalized before hashing (see normalizePackages). This
+// does not change the scheme: a package list that was already sorted, trimmed,
+// and free of duplicates hashes exactly as before, so existing AMIs are still
+// found.
+const FingerprintVersion = 2
+
 // AMIFingerprint represents a unique identifier for an AMI based on software configuration.
 type AMIFingerprint struct {
+	// Version is the fingerprint scheme used to compute Hash
+	Version int
 	// BaseOS is the operating system (e.g., "amazonlinux2023")
 	BaseOS string
+	// Architecture is the CPU architecture (e.g., "x86_64", "arm64")
+	Architecture string
 	// SpackVersion is the Spack version (e.g., "releases/latest")
 	SpackVersion string
 	// LmodVersion is the Lmod version (e.g., "8.7.37")
 	LmodVersion string
-	// Packages is the sorted list of Spack packages
+	// Packages is the normalized, sorted list of Spack packages
 	Packages []string
+	// ModuleSystem is the module system (e.g., "lmod"), or "none" when Lmod
+	// is not installed
+	ModuleSystem string
+	// DefaultModules are the modules loaded on login, in load order
+	DefaultModules []string
+	// SpackLockHash is the SHA-256 of the spack.lock the software is
+	// installed from, if any
+	SpackLockHash string
+	// SpackConfigHash is the SHA-256 of the Spack configuration files
+	// installed before the software, if any
+	SpackConfigHash string
+	// PreInstallScriptHash is the SHA-256 of the pre-install script run
+	// before the software is installed, if any
+	PreInstallScriptHash string
 	// Hash is the computed SHA256 hash
 	Hash string
 }
@@ -41,21 +72,33 @@ type AMIFingerprint struct {
 func (t *Template) ComputeFingerprint() *AMIFingerprint {
 	// Default versions from pkg/software
 	const (
-		defaultBaseOS       = "amazonlinux2023"
 		defaultSpackVersion = "releases/latest"
 		defaultLmodVersion  = "8.7.37"
 	)
 
-	// Sort packages for consistent ordering
-	packages := make([]string, len(t.Software.SpackPackages))
-	copy(packages, t.Software.SpackPackages)
-	sort.Strings(packages)
+	packages := normalizePackages(t.Software.SpackPackages)
 
 	fp := &AMIFingerprint{
-		BaseOS:       defaultBaseOS,
+		Version:      FingerprintVersion,
+		BaseOS:       fingerprintBaseOS(t.Cluster.GetOS()),
+		Architecture: t.Architecture(),
 		SpackVersion: defaultSpackVersion,
 		LmodVersion:  defaultLmodVersion,
 		Packages:     packages,
+		ModuleSystem: t.Software.GetModuleSystem(),
+	}
+	if !t.Software.InstallsModuleSystem() {
+		fp.ModuleSystem = fingerprintNoModuleSystem
+	}
+	fp.DefaultModules = append(fp.DefaultModules, t.Software.DefaultModules...)
+	if t.Build.SpackLock != "" {
+		fp.SpackLockHash = fileHash(t.Build.SpackLock)
+	}
+	if len(t.Build.SpackConfig) > 0 {
+		fp.SpackConfigHash = spackConfigHash(t.Build.SpackConfig)
+	}
+	if t.Build.PreInstallScript != "" {
+		fp.PreInstallScriptHash = preInstallScriptHash(t.Build.PreInstallScript)
 	}
 
 	// Compute hash
@@ -64,14 +107,101 @@ func (t *Template) ComputeFingerprint() *AMIFingerprint {
 	return fp
 }
 
-// computeHash generates a SHA256 hash of the fingerprint components.
+// normalizePackages returns Spack specs in a canonical form so that
+// logically identical package lists share a fingerprint: whitespace is
+// trimmed and collapsed, empty entries and duplicates are dropped, and the
+// result is sorted.
+func normalizePackages(specs []string) []string {
+	seen := make(map[string]bool, len(specs))
+	packages := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.Join(strings.Fields(spec), " ")
+		if spec == "" || seen[spec] {
+			continue
+		}
+		seen[spec] = true
+		packages = append(packages, spec)
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// fingerprintNoModuleSystem is the module system recorded for AMIs built
+// without Lmod.
+const fingerprintNoModuleSystem = "none"
+
+// fingerprintBaseOS maps a ParallelCluster OS name to the name recorded in
+// fingerprints, which predates cluster.os and spells out Amazon Linux.
+func fingerprintBaseOS(os string) string {
+	return strings.Replace(os, "alinux", "amazonlinux", 1)
+}
+
+// preInstallScriptHash returns the SHA-256 of a pre-install script's content.
+// An S3 script is hashed by its URI, since its content is only fetched on the
+// build instance.
+func preInstallScriptHash(script string) string {
+	switch {
+	case strings.HasPrefix(script, skeletonS3Prefix), IsInlineScript(script):
+		hash := sha256.Sum256([]byte(script))
+		return hex.EncodeToString(hash[:])
+	default:
+		return fileHash(script)
+	}
+}
+
+// spackConfigHash returns the SHA-256 of Spack configuration files' content.
+// Each file holds a distinct section, named by its top-level key, so the
+// order the files are given in does not matter.
+func spackConfigHash(paths []string) string {
+	hashes := make([]string, 0, len(paths))
+	for _, path := range paths {
+		hashes = append(hashes, fileHash(path))
+	}
+	sort.Strings(hashes)
+	hash := sha256.Sum256([]byte(strings.Join(hashes, "|")))
+	return hex.EncodeToString(hash[:])
+}
+
+// fileHash returns the SHA-256 of a file's content. An unreadable file
+// hashes its path instead, so it never matches an AMI built from real content.
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		data = []byte("unreadable:" + path)
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// computeHash generates a SHA256 hash of the fingerprint components
+// using the scheme selected by fp.Version.
 func (fp *AMIFingerprint) computeHash() string {
-	// Create a canonical representation
-	parts := []string{
+	// Create a canonical representation; version 1 hashes carry no prefix
+	var parts []string
+	if fp.Version >= 2 {
+		parts = append(parts, fmt.Sprintf("v%d", fp.Version), "arch="+fp.Architecture)
+	}
+	parts = append(parts,
 		fp.BaseOS,
 		fp.SpackVersion,
 		fp.LmodVersion,
 		strings.Join(fp.Packages, "|"),
+	)
+	// Only non-default module settings contribute, so existing AMIs keep their hashes
+	if fp.ModuleSystem != "" && fp.ModuleSystem != ModuleSystemLmod {
+		parts = append(parts, "modules="+fp.ModuleSystem)
+	}
+	if len(fp.DefaultModules) > 0 {
+		parts = append(parts, "default-modules="+strings.Join(fp.DefaultModules, "|"))
+	}
+	if fp.SpackLockHash != "" {
+		parts = append(parts, "spack-lock="+fp.SpackLockHash)
+	}
+	if fp.SpackConfigHash != "" {
+		parts = append(parts, "spack-config="+fp.SpackConfigHash)
+	}
+	if fp.PreInstallScriptHash != "" {
+		parts = append(parts, "pre-install="+fp.PreInstallScriptHash)
 	}
 	canonical := strings.Join(parts, ":")
 
@@ -80,11 +210,23 @@ func (fp *AMIFingerprint) computeHash() string {
 	return hex.EncodeToString(hash[:])
 }
 
+// LegacyHash returns the hash this fingerprint had under scheme version 1,
+// which AMIs built before the architecture was fingerprinted are tagged with.
+func (fp *AMIFingerprint) LegacyHash() string {
+	legacy := *fp
+	legacy.Version = 1
+	return legacy.computeHash()
+}
+
 // String returns a human-readable representation of the fingerprint.
-// Format: al2-spack-latest-lmod-8.7.37-<short-hash>
+// Format: al2023-spack-latest-lmod-8.7.37-<short-hash>, with the architecture
+// after the OS for non-x86 builds (al2023-arm64-spack-...).
 func (fp *AMIFingerprint) String() string {
 	// Abbreviate base OS
 	osAbbrev := strings.ReplaceAll(fp.BaseOS, "amazonlinux", "al")
+	if fp.Architecture != "" && fp.Architecture != ArchitectureX86_64 {
+		osAbbrev += "-" + fp.Architecture
+	}
 
 	// Abbreviate Spack version
 	spackAbbrev := strings.ReplaceAll(fp.SpackVersion, "releases/", "")
@@ -115,6 +257,10 @@ func (fp *AMIFingerprint) Tags() map[string]string {
 		"pctl:lmod-version":  fp.LmodVersion,
 		"pctl:created-by":    "pctl",
 	}
+	if fp.Version >= 2 {
+		tags["pctl:fingerprint-version"] = fmt.Sprintf("%d", fp.Version)
+		tags["pctl:architecture"] = fp.Architecture
+	}
 
 	// Add package count
 	if len(fp.Packages) > 0 {
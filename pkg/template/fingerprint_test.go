@@ -15,6 +15,10 @@
 package template
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -150,6 +154,54 @@ func TestFingerprintPackageOrdering(t *testing.T) {
 	}
 }
 
+func TestFingerprintPackageNormalization(t *testing.T) {
+	fingerprint := func(packages ...string) *AMIFingerprint {
+		return (&Template{Software: SoftwareConfig{SpackPackages: packages}}).ComputeFingerprint()
+	}
+
+	base := fingerprint("gcc@11", "openmpi +cuda")
+	for _, packages := range [][]string{
+		{"openmpi +cuda", "gcc@11"},
+		{"gcc@11", "openmpi +cuda", "gcc@11"},
+		{" gcc@11 ", "openmpi  +cuda", ""},
+		{"openmpi +cuda", "gcc@11", "openmpi +cuda", "\tgcc@11"},
+	} {
+		fp := fingerprint(packages...)
+		if fp.Hash != base.Hash {
+			t.Errorf("%q hash differs from %q", packages, base.Packages)
+		}
+		if !reflect.DeepEqual(fp.Packages, []string{"gcc@11", "openmpi +cuda"}) {
+			t.Errorf("%q normalized to %q", packages, fp.Packages)
+		}
+	}
+
+	for _, packages := range [][]string{
+		{"gcc@11"},
+		{"gcc@11", "openmpi"},
+		{"gcc@11", "openmpi+cuda"},
+		{"gcc@11", "openmpi +cuda", "python@3.11"},
+	} {
+		if fingerprint(packages...).Hash == base.Hash {
+			t.Errorf("%q should not share a hash with %q", packages, base.Packages)
+		}
+	}
+
+	// Already-normalized lists hash as they did before normalization, so
+	// AMIs tagged with existing fingerprints are still found
+	legacy := &AMIFingerprint{
+		Version:      FingerprintVersion,
+		BaseOS:       base.BaseOS,
+		Architecture: base.Architecture,
+		SpackVersion: base.SpackVersion,
+		LmodVersion:  base.LmodVersion,
+		Packages:     []string{"gcc@11", "openmpi +cuda"},
+		ModuleSystem: base.ModuleSystem,
+	}
+	if legacy.computeHash() != base.Hash {
+		t.Error("normalization changed the hash of an already-normalized package list")
+	}
+}
+
 func TestFingerprintDifferences(t *testing.T) {
 	// Different packages should produce different hashes
 	template1 := &Template{
@@ -268,3 +320,270 @@ func TestFingerprintTagValue(t *testing.T) {
 		t.Errorf("TagValue() = %v, want abc123", fp.TagValue())
 	}
 }
+
+func TestFingerprintModuleSettings(t *testing.T) {
+	base := &Template{
+		Software: SoftwareConfig{SpackPackages: []string{"gcc@11.3.0"}},
+	}
+	explicitLmod := &Template{
+		Software: SoftwareConfig{SpackPackages: []string{"gcc@11.3.0"}, ModuleSystem: ModuleSystemLmod},
+	}
+	envModules := &Template{
+		Software: SoftwareConfig{SpackPackages: []string{"gcc@11.3.0"}, ModuleSystem: ModuleSystemEnvironmentModules},
+	}
+	defaults := &Template{
+		Software: SoftwareConfig{SpackPackages: []string{"gcc@11.3.0"}, DefaultModules: []string{"gcc"}},
+	}
+
+	baseHash := base.ComputeFingerprint().Hash
+	if explicitLmod.ComputeFingerprint().Hash != baseHash {
+		t.Error("Explicit lmod should hash the same as the default module system")
+	}
+	if envModules.ComputeFingerprint().Hash == baseHash {
+		t.Error("Module system should change the fingerprint")
+	}
+	if defaults.ComputeFingerprint().Hash == baseHash {
+		t.Error("Default modules should change the fingerprint")
+	}
+
+	installLmod, skipLmod := true, false
+	withLmod := &Template{
+		Software: SoftwareConfig{SpackPackages: []string{"gcc@11.3.0"}, InstallLmod: &installLmod},
+	}
+	withoutLmod := &Template{
+		Software: SoftwareConfig{SpackPackages: []string{"gcc@11.3.0"}, InstallLmod: &skipLmod},
+	}
+	if withLmod.ComputeFingerprint().Hash != baseHash {
+		t.Error("install_lmod: true should hash the same as the default")
+	}
+	fp := withoutLmod.ComputeFingerprint()
+	if fp.ModuleSystem != "none" || fp.Hash == baseHash {
+		t.Errorf("Skipping Lmod should change the fingerprint, got module system %q", fp.ModuleSystem)
+	}
+
+	// install_lmod does not affect environment-modules
+	envModules.Software.InstallLmod = &skipLmod
+	if envModules.ComputeFingerprint().ModuleSystem != ModuleSystemEnvironmentModules {
+		t.Error("install_lmod: false should not affect environment-modules")
+	}
+}
+
+func TestFingerprintSpackLock(t *testing.T) {
+	dir := t.TempDir()
+	writeLock := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	lockA := writeLock("a.lock", `{"roots": [{"hash": "aaa", "spec": "samtools@1.17"}]}`)
+	sameAsA := writeLock("copy.lock", `{"roots": [{"hash": "aaa", "spec": "samtools@1.17"}]}`)
+	lockB := writeLock("b.lock", `{"roots": [{"hash": "bbb", "spec": "samtools@1.18"}]}`)
+
+	newTemplate := func(lock string) *Template {
+		return &Template{
+			Software: SoftwareConfig{SpackPackages: []string{"samtools"}},
+			Build:    BuildConfig{SpackLock: lock},
+		}
+	}
+
+	plain := newTemplate("").ComputeFingerprint()
+	a := newTemplate(lockA).ComputeFingerprint()
+	if plain.SpackLockHash != "" {
+		t.Errorf("SpackLockHash = %q without a lockfile, want empty", plain.SpackLockHash)
+	}
+	if a.Hash == plain.Hash {
+		t.Error("A lockfile should change the fingerprint")
+	}
+	if newTemplate(sameAsA).ComputeFingerprint().Hash != a.Hash {
+		t.Error("Lockfiles with the same content should share a fingerprint")
+	}
+	if newTemplate(lockB).ComputeFingerprint().Hash == a.Hash {
+		t.Error("Lockfiles with different content should change the fingerprint")
+	}
+	if newTemplate(filepath.Join(dir, "missing.lock")).ComputeFingerprint().Hash == plain.Hash {
+		t.Error("A missing lockfile should not match the seed without one")
+	}
+}
+
+func TestFingerprintSpackConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	packages := writeConfig("packages.yaml", "packages:\n  openmpi:\n    buildable: false\n")
+	config := writeConfig("config.yaml", "config:\n  build_jobs: 16\n")
+	changed := writeConfig("other/packages.yaml", "packages:\n  openmpi:\n    buildable: true\n")
+
+	newTemplate := func(files ...string) *Template {
+		return &Template{
+			Software: SoftwareConfig{SpackPackages: []string{"samtools"}},
+			Build:    BuildConfig{SpackConfig: files},
+		}
+	}
+
+	plain := newTemplate().ComputeFingerprint()
+	withConfig := newTemplate(packages, config).ComputeFingerprint()
+	if plain.SpackConfigHash != "" {
+		t.Errorf("SpackConfigHash = %q without config files, want empty", plain.SpackConfigHash)
+	}
+	if withConfig.Hash == plain.Hash {
+		t.Error("Spack config files should change the fingerprint")
+	}
+	if newTemplate(config, packages).ComputeFingerprint().Hash != withConfig.Hash {
+		t.Error("The order of config files should not change the fingerprint")
+	}
+	if newTemplate(changed, config).ComputeFingerprint().Hash == withConfig.Hash {
+		t.Error("Config files with different content should change the fingerprint")
+	}
+}
+
+func TestFingerprintPreInstallScript(t *testing.T) {
+	dir := t.TempDir()
+	writeScript := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	scriptA := writeScript("a.sh", "#!/bin/bash\nyum install -y site-certs\n")
+	sameAsA := writeScript("copy.sh", "#!/bin/bash\nyum install -y site-certs\n")
+	scriptB := writeScript("b.sh", "#!/bin/bash\nyum install -y other-certs\n")
+
+	newTemplate := func(script string) *Template {
+		return &Template{
+			Software: SoftwareConfig{SpackPackages: []string{"samtools"}},
+			Build:    BuildConfig{PreInstallScript: script},
+		}
+	}
+
+	plain := newTemplate("").ComputeFingerprint()
+	a := newTemplate(scriptA).ComputeFingerprint()
+	if plain.PreInstallScriptHash != "" {
+		t.Errorf("PreInstallScriptHash = %q without a script, want empty", plain.PreInstallScriptHash)
+	}
+	if a.Hash == plain.Hash {
+		t.Error("A pre-install script should change the fingerprint")
+	}
+	if newTemplate(sameAsA).ComputeFingerprint().Hash != a.Hash {
+		t.Error("Scripts with the same content should share a fingerprint")
+	}
+	if newTemplate(scriptB).ComputeFingerprint().Hash == a.Hash {
+		t.Error("Scripts with different content should change the fingerprint")
+	}
+	inline := newTemplate("#!/bin/bash\nyum install -y site-certs\n").ComputeFingerprint()
+	if inline.Hash == plain.Hash || inline.Hash == newTemplate("#!/bin/bash\ntrue\n").ComputeFingerprint().Hash {
+		t.Error("Inline scripts should be fingerprinted by their content")
+	}
+	s3 := newTemplate("s3://site-scripts/setup.sh").ComputeFingerprint()
+	if s3.Hash == plain.Hash || s3.Hash == newTemplate("s3://site-scripts/setup-v2.sh").ComputeFingerprint().Hash {
+		t.Error("S3 scripts should be fingerprinted by their URI")
+	}
+}
+
+func TestFingerprintPlatform(t *testing.T) {
+	packages := []string{"gcc@11.3.0", "openmpi@4.1.4"}
+	newTemplate := func(os, headNode string) *Template {
+		return &Template{
+			Cluster:  ClusterConfig{OS: os},
+			Compute:  ComputeConfig{HeadNode: headNode},
+			Software: SoftwareConfig{SpackPackages: packages},
+		}
+	}
+
+	base := newTemplate("", "t3.large").ComputeFingerprint()
+	if base.Version != FingerprintVersion {
+		t.Errorf("Version = %d, want %d", base.Version, FingerprintVersion)
+	}
+	if base.Architecture != ArchitectureX86_64 {
+		t.Errorf("Architecture = %q, want %q", base.Architecture, ArchitectureX86_64)
+	}
+
+	explicitOS := newTemplate(DefaultOS, "t3.large").ComputeFingerprint()
+	if explicitOS.Hash != base.Hash {
+		t.Error("explicit default OS should not change the fingerprint")
+	}
+
+	ubuntu := newTemplate("ubuntu2204", "t3.large").ComputeFingerprint()
+	if ubuntu.BaseOS != "ubuntu2204" {
+		t.Errorf("BaseOS = %q, want ubuntu2204", ubuntu.BaseOS)
+	}
+	if ubuntu.Hash == base.Hash {
+		t.Error("different OS should produce a different fingerprint")
+	}
+
+	arm := newTemplate("", "c7g.large").ComputeFingerprint()
+	if arm.Architecture != ArchitectureARM64 {
+		t.Errorf("Architecture = %q, want %q", arm.Architecture, ArchitectureARM64)
+	}
+	if arm.Hash == base.Hash {
+		t.Error("different architecture should produce a different fingerprint")
+	}
+	if !strings.HasPrefix(arm.String(), "al2023-arm64-spack-") {
+		t.Errorf("String() = %q, want al2023-arm64-spack- prefix", arm.String())
+	}
+
+	tags := arm.Tags()
+	if tags["pctl:architecture"] != ArchitectureARM64 {
+		t.Errorf("pctl:architecture tag = %q, want %q", tags["pctl:architecture"], ArchitectureARM64)
+	}
+	if tags["pctl:fingerprint-version"] != "2" {
+		t.Errorf("pctl:fingerprint-version tag = %q, want 2", tags["pctl:fingerprint-version"])
+	}
+}
+
+func TestFingerprintLegacyVersion(t *testing.T) {
+	v1 := &AMIFingerprint{
+		Version:      1,
+		BaseOS:       "amazonlinux2023",
+		Architecture: ArchitectureARM64,
+		SpackVersion: "releases/latest",
+		LmodVersion:  "8.7.37",
+		Packages:     []string{"gcc@11.3.0"},
+	}
+	x86 := *v1
+	x86.Architecture = ArchitectureX86_64
+
+	// Version 1 hashes ignore architecture and omit the version tags
+	if v1.computeHash() != x86.computeHash() {
+		t.Error("version 1 hash should not depend on architecture")
+	}
+	if _, ok := v1.Tags()["pctl:fingerprint-version"]; ok {
+		t.Error("version 1 fingerprint should not have a version tag")
+	}
+
+	v2 := *v1
+	v2.Version = 2
+	if v2.computeHash() == v1.computeHash() {
+		t.Error("version 2 hash should differ from version 1")
+	}
+}
+
+func TestInstanceArchitecture(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		want         string
+	}{
+		{"t3.large", ArchitectureX86_64},
+		{"c6a.4xlarge", ArchitectureX86_64},
+		{"c7g.xlarge", ArchitectureARM64},
+		{"r6gd.2xlarge", ArchitectureARM64},
+		{"", ArchitectureX86_64},
+		{"invalid", ArchitectureX86_64},
+	}
+
+	for _, tt := range tests {
+		if got := InstanceArchitecture(tt.instanceType); got != tt.want {
+			t.Errorf("InstanceArchitecture(%q) = %q, want %q", tt.instanceType, got, tt.want)
+		}
+	}
+}
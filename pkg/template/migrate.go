@@ -0,0 +1,226 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Template schema versions, oldest first.
+const (
+	// APIVersionV1 is the original schema, used by templates without apiVersion
+	APIVersionV1 = "v1"
+	// APIVersionV2 names always-on queue nodes static_count instead of min_count
+	APIVersionV2 = "v2"
+
+	// CurrentAPIVersion is the schema version written by pctl
+	CurrentAPIVersion = APIVersionV2
+)
+
+// APIVersions lists the supported schema versions in upgrade order.
+var APIVersions = []string{APIVersionV1, APIVersionV2}
+
+// GetAPIVersion returns the template's schema version, defaulting to v1.
+func (t *Template) GetAPIVersion() string {
+	if t.APIVersion == "" {
+		return APIVersionV1
+	}
+	return t.APIVersion
+}
+
+// IsKnownAPIVersion reports whether version is a supported schema version.
+func IsKnownAPIVersion(version string) bool {
+	return apiVersionIndex(version) >= 0
+}
+
+func apiVersionIndex(version string) int {
+	for i, v := range APIVersions {
+		if v == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// Migration upgrades a template document from one schema version to the next.
+type Migration struct {
+	From        string
+	To          string
+	Description string
+	// Apply rewrites the document's top-level mapping node in place
+	Apply func(doc *yaml.Node) error
+}
+
+// migrations are the registered schema upgrades, in order.
+var migrations = []Migration{
+	{
+		From:        APIVersionV1,
+		To:          APIVersionV2,
+		Description: "rename queue min_count to static_count",
+		Apply:       migrateV1ToV2,
+	},
+}
+
+// Migrate upgrades raw template YAML to the target schema version and returns
+// the upgraded YAML along with the migrations that were applied. Comments and
+// key order are preserved. Downgrades are not supported.
+func Migrate(data []byte, target string) ([]byte, []Migration, error) {
+	if !IsKnownAPIVersion(target) {
+		return nil, nil, fmt.Errorf("unsupported target apiVersion %q (supported: %v)", target, APIVersions)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("failed to parse template: expected a YAML mapping")
+	}
+	doc := root.Content[0]
+
+	current := APIVersionV1
+	if node := mappingValue(doc, "apiVersion"); node != nil {
+		current = node.Value
+	}
+	if !IsKnownAPIVersion(current) {
+		return nil, nil, fmt.Errorf("template has unsupported apiVersion %q", current)
+	}
+	if apiVersionIndex(current) > apiVersionIndex(target) {
+		return nil, nil, fmt.Errorf("cannot convert template from %s to older version %s", current, target)
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if current == target {
+			break
+		}
+		if m.From != current {
+			continue
+		}
+		if err := m.Apply(doc); err != nil {
+			return nil, nil, fmt.Errorf("migration %s to %s failed: %w", m.From, m.To, err)
+		}
+		applied = append(applied, m)
+		current = m.To
+	}
+	if current != target {
+		return nil, nil, fmt.Errorf("no migration path from %s to %s", current, target)
+	}
+
+	setAPIVersion(doc, target)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode template: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode template: %w", err)
+	}
+
+	return buf.Bytes(), applied, nil
+}
+
+// migrateV1ToV2 renames each queue's min_count to static_count. A queue that
+// already sets a positive static_count keeps it, since it took precedence in
+// v1; a static_count of 0 meant "unset" in v1, so min_count replaces it.
+func migrateV1ToV2(doc *yaml.Node) error {
+	compute := mappingValue(doc, "compute")
+	if compute == nil {
+		return nil
+	}
+	queues := mappingValue(compute, "queues")
+	if queues == nil {
+		return nil
+	}
+	if queues.Kind != yaml.SequenceNode {
+		return fmt.Errorf("compute.queues must be a list")
+	}
+
+	for _, queue := range queues.Content {
+		if queue.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i < len(queue.Content); i += 2 {
+			if queue.Content[i].Value != "min_count" {
+				continue
+			}
+			if static := mappingValue(queue, "static_count"); static != nil {
+				if count, err := strconv.Atoi(static.Value); err == nil && count > 0 {
+					queue.Content = append(queue.Content[:i], queue.Content[i+2:]...)
+					break
+				}
+				removeMappingKey(queue, "static_count")
+			}
+			renameMappingKey(queue, "min_count", "static_count")
+			break
+		}
+	}
+
+	return nil
+}
+
+// removeMappingKey deletes key and its value from a mapping node.
+func removeMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// renameMappingKey renames key in a mapping node, keeping its position.
+func renameMappingKey(node *yaml.Node, key, newKey string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i].Value = newKey
+			return
+		}
+	}
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setAPIVersion sets apiVersion on the document, adding it as the first key.
+func setAPIVersion(doc *yaml.Node, version string) {
+	if node := mappingValue(doc, "apiVersion"); node != nil {
+		node.Value = version
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "apiVersion"}
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: version}
+	// Keep a leading comment above the new first key
+	if len(doc.Content) > 0 {
+		key.HeadComment, doc.Content[0].HeadComment = doc.Content[0].HeadComment, ""
+	}
+	doc.Content = append([]*yaml.Node{key, value}, doc.Content...)
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// v1Fixture is a template written before apiVersion existed.
+const v1Fixture = `# Bioinformatics cluster
+cluster:
+  name: bio-cluster
+  region: us-east-1
+compute:
+  head_node: t3.xlarge
+  queues:
+    - name: compute
+      instance_types:
+        - c5.xlarge
+      min_count: 2 # always-on nodes
+      max_count: 10
+    - name: override
+      instance_types:
+        - c5.2xlarge
+      min_count: 1
+      max_count: 4
+      static_count: 3
+software:
+  spack_packages:
+    - samtools@1.17
+`
+
+func TestMigrateV1ToCurrent(t *testing.T) {
+	out, applied, err := Migrate([]byte(v1Fixture), CurrentAPIVersion)
+	if err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	if len(applied) != 1 || applied[0].From != APIVersionV1 || applied[0].To != APIVersionV2 {
+		t.Errorf("Expected v1->v2 migration to be applied, got %+v", applied)
+	}
+
+	var tmpl Template
+	if err := yaml.Unmarshal(out, &tmpl); err != nil {
+		t.Fatalf("Failed to parse migrated template: %v", err)
+	}
+
+	if tmpl.APIVersion != CurrentAPIVersion {
+		t.Errorf("Expected apiVersion %s, got %q", CurrentAPIVersion, tmpl.APIVersion)
+	}
+
+	compute := tmpl.Compute.Queues[0]
+	if compute.StaticCount != 2 || compute.MinCount != 0 {
+		t.Errorf("Expected min_count renamed to static_count=2, got static=%d min=%d", compute.StaticCount, compute.MinCount)
+	}
+
+	// static_count took precedence in v1, so it is kept and min_count dropped
+	override := tmpl.Compute.Queues[1]
+	if override.StaticCount != 3 || override.MinCount != 0 {
+		t.Errorf("Expected static_count=3 kept, got static=%d min=%d", override.StaticCount, override.MinCount)
+	}
+
+	if err := tmpl.Validate(); err != nil {
+		t.Errorf("Migrated template failed validation: %v", err)
+	}
+
+	// Comments and key order survive the migration
+	text := string(out)
+	if !strings.HasPrefix(text, "# Bioinformatics cluster\napiVersion: v2\n") {
+		t.Errorf("Expected apiVersion as first key below the leading comment, got:\n%s", text)
+	}
+	if !strings.Contains(text, "# always-on nodes") {
+		t.Errorf("Expected comments to be preserved, got:\n%s", text)
+	}
+}
+
+func TestMigrateV1ZeroStaticCount(t *testing.T) {
+	// v1 ignored static_count: 0 and kept min_count nodes running
+	v1 := `cluster:
+  name: zero-static
+  region: us-east-1
+compute:
+  head_node: t3.medium
+  queues:
+    - name: compute
+      instance_types: [c5.xlarge]
+      static_count: 0
+      min_count: 2
+      max_count: 10
+`
+	out, _, err := Migrate([]byte(v1), CurrentAPIVersion)
+	if err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	var tmpl Template
+	if err := yaml.Unmarshal(out, &tmpl); err != nil {
+		t.Fatalf("Failed to parse migrated template: %v", err)
+	}
+	queue := tmpl.Compute.Queues[0]
+	if queue.StaticCount != 2 || queue.MinCount != 0 {
+		t.Errorf("Expected min_count moved to static_count=2, got static=%d min=%d", queue.StaticCount, queue.MinCount)
+	}
+	if strings.Count(string(out), "static_count") != 1 {
+		t.Errorf("Expected a single static_count key, got:\n%s", out)
+	}
+}
+
+func TestMigrateAlreadyCurrent(t *testing.T) {
+	input := "apiVersion: v2\ncluster:\n  name: test\n"
+
+	out, applied, err := Migrate([]byte(input), CurrentAPIVersion)
+	if err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("Expected no migrations, got %d", len(applied))
+	}
+	if string(out) != input {
+		t.Errorf("Expected unchanged template, got:\n%s", out)
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		target string
+	}{
+		{"unknown target", "cluster:\n  name: test\n", "v9"},
+		{"unknown source", "apiVersion: v9\ncluster:\n  name: test\n", CurrentAPIVersion},
+		{"downgrade", "apiVersion: v2\ncluster:\n  name: test\n", APIVersionV1},
+		{"not a mapping", "- a\n- b\n", CurrentAPIVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Migrate([]byte(tt.input), tt.target); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestValidateRejectsUnknownAPIVersion(t *testing.T) {
+	tmpl := &Template{
+		APIVersion: "v9",
+		Cluster:    ClusterConfig{Name: "test", Region: "us-east-1"},
+		Compute: ComputeConfig{
+			HeadNode: "t3.xlarge",
+			Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 2}},
+		},
+	}
+
+	err := tmpl.Validate()
+	if err == nil || !strings.Contains(err.Error(), "apiVersion") {
+		t.Errorf("Expected apiVersion validation error, got: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+// Severity is how serious a validation issue is.
+type Severity string
+
+// Validation issue severities.
+const (
+	// SeverityError issues make a template invalid
+	SeverityError Severity = "error"
+	// SeverityWarning issues are worth a look but do not fail validation
+	SeverityWarning Severity = "warning"
+)
+
+// ValidationIssue is a single validation finding.
+type ValidationIssue struct {
+	// Field is the template path the issue is about, e.g.
+	// "compute.queues[0].max_count", or "" if it concerns no single field
+	Field    string   `json:"field,omitempty"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"`
+}
+
+// ValidationReport is the structured result of validating a template.
+type ValidationReport struct {
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// Valid reports whether the template has no errors; warnings are allowed.
+func (r *ValidationReport) Valid() bool {
+	return len(r.Errors()) == 0
+}
+
+// Errors returns the issues with error severity.
+func (r *ValidationReport) Errors() []ValidationIssue {
+	return r.withSeverity(SeverityError)
+}
+
+// Warnings returns the issues with warning severity.
+func (r *ValidationReport) Warnings() []ValidationIssue {
+	return r.withSeverity(SeverityWarning)
+}
+
+func (r *ValidationReport) withSeverity(severity Severity) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// Err returns the report's errors as a *ValidationError, or nil if there
+// are none.
+func (r *ValidationReport) Err() error {
+	errs := &ValidationError{}
+	for _, issue := range r.Errors() {
+		errs.AddField(issue.Field, issue.Message)
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// ValidateDetailed validates a template and returns every error and
+// warning with the field it concerns. Warnings are priced with v.Price.
+func (v *Validator) ValidateDetailed(t *Template) *ValidationReport {
+	report := v.validateErrors(t)
+	report.Issues = append(report.Issues, v.warningIssues(t, v.Price)...)
+	return report
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateDetailed(t *testing.T) {
+	tmpl := &Template{
+		APIVersion: "pctl/v9",
+		Cluster:    ClusterConfig{Name: "test-cluster", Region: "mars-east-1"},
+		Compute: ComputeConfig{
+			HeadNode: "t3.medium",
+			Queues: []Queue{
+				{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MinCount: 2, MaxCount: 10},
+				{Name: "Bad", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 2000},
+			},
+		},
+		Users: []User{{Name: "alice", UID: 10, GID: 5000}},
+	}
+
+	report := NewValidator().ValidateDetailed(tmpl)
+	if report.Valid() {
+		t.Fatal("Valid() = true for a template with errors")
+	}
+
+	var got []string
+	for _, issue := range report.Errors() {
+		got = append(got, issue.Field)
+	}
+	want := []string{
+		"apiVersion",
+		"cluster.region",
+		"compute.queues[1].name",
+		"compute.queues[1].max_count",
+		"users[0].uid",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("error fields = %q, want %q", got, want)
+	}
+
+	warnings := report.Warnings()
+	if len(warnings) != 1 || warnings[0].Field != "compute.queues[0]" || warnings[0].Severity != SeverityWarning {
+		t.Errorf("Warnings() = %+v, want one warning for compute.queues[0]", warnings)
+	}
+
+	// ValidateTemplate reports the same errors, and only the errors
+	err := NewValidator().ValidateTemplate(tmpl)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("ValidateTemplate() error = %v, want *ValidationError", err)
+	}
+	if len(validationErr.Errors) != len(report.Errors()) {
+		t.Errorf("ValidateTemplate() reported %d errors, ValidateDetailed %d", len(validationErr.Errors), len(report.Errors()))
+	}
+	for i, issue := range report.Errors() {
+		if validationErr.Errors[i] != issue.Message {
+			t.Errorf("error %d = %q, want %q", i, validationErr.Errors[i], issue.Message)
+		}
+	}
+}
+
+func TestValidateDetailedFieldWithDigits(t *testing.T) {
+	tmpl := &Template{
+		Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: ComputeConfig{
+			HeadNode: "t3.medium",
+			Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+		},
+		Data: DataConfig{S3Mounts: []S3Mount{{Bucket: "my-bucket"}}},
+	}
+
+	errs := NewValidator().ValidateDetailed(tmpl).Errors()
+	if len(errs) != 1 || errs[0].Field != "data.s3_mounts[0].mount_point" {
+		t.Errorf("Errors() = %+v, want one error for data.s3_mounts[0].mount_point", errs)
+	}
+}
+
+func TestValidateDetailedValid(t *testing.T) {
+	tmpl := &Template{
+		Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: ComputeConfig{
+			HeadNode: "t3.medium",
+			Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MinCount: 1, MaxCount: 10}},
+		},
+	}
+
+	validator := NewValidator()
+	validator.Price = func(region, instanceType string) (float64, error) {
+		return 0.17, nil
+	}
+	report := validator.ValidateDetailed(tmpl)
+	if !report.Valid() || report.Err() != nil {
+		t.Fatalf("ValidateDetailed() = %+v, want a valid report", report)
+	}
+	warnings := report.Warnings()
+	if len(warnings) != 1 || warnings[0].Message != `queue "compute" keeps 1 node(s) running 24/7 (about $124/month while idle); set min_count and static_count to 0 to scale to zero when idle` {
+		t.Errorf("Warnings() = %+v, want a priced always-on warning", warnings)
+	}
+}
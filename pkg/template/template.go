@@ -16,44 +16,380 @@
 package template
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Template represents a pctl cluster template.
 type Template struct {
-	Cluster  ClusterConfig  `yaml:"cluster"`
-	Compute  ComputeConfig  `yaml:"compute"`
-	Software SoftwareConfig `yaml:"software,omitempty"`
-	Users    []User         `yaml:"users,omitempty"`
-	Data     DataConfig     `yaml:"data,omitempty"`
+	// APIVersion is the template schema version; templates without one are v1
+	APIVersion string        `yaml:"apiVersion,omitempty"`
+	Cluster    ClusterConfig `yaml:"cluster"`
+	Compute    ComputeConfig `yaml:"compute"`
+	// Scheduler configures Slurm features beyond the queues
+	Scheduler SchedulerConfig `yaml:"scheduler,omitempty"`
+	Software  SoftwareConfig  `yaml:"software,omitempty"`
+	Users     []User          `yaml:"users,omitempty"`
+	Data      DataConfig      `yaml:"data,omitempty"`
+	Network   NetworkConfig   `yaml:"network,omitempty"`
+	IAM       IAMConfig       `yaml:"iam,omitempty"`
+	Build     BuildConfig     `yaml:"build,omitempty"`
+	// Observability controls the CloudWatch resources ParallelCluster
+	// creates for the cluster
+	Observability ObservabilityConfig `yaml:"observability,omitempty"`
+	// Metadata describes the template (e.g., owner, project, description)
+	// and is applied as tags to AMIs built from it
+	Metadata map[string]string `yaml:"metadata,omitempty"`
 }
 
 // ClusterConfig holds cluster-level configuration.
 type ClusterConfig struct {
 	Name   string `yaml:"name"`
 	Region string `yaml:"region"`
+	// OS is the ParallelCluster operating system (e.g., "alinux2023", "ubuntu2204")
+	OS   string            `yaml:"os,omitempty"`
+	Tags map[string]string `yaml:"tags,omitempty"`
+}
+
+// DefaultOS is the operating system used when a template does not set cluster.os.
+const DefaultOS = "alinux2023"
+
+// SupportedOS lists the ParallelCluster operating systems accepted in cluster.os.
+var SupportedOS = []string{
+	"alinux2",
+	"alinux2023",
+	"ubuntu2004",
+	"ubuntu2204",
+	"ubuntu2404",
+	"rhel8",
+	"rhel9",
+	"rocky8",
+	"rocky9",
+}
+
+// GetOS returns the cluster operating system, defaulting to alinux2023.
+func (c ClusterConfig) GetOS() string {
+	if c.OS == "" {
+		return DefaultOS
+	}
+	return c.OS
+}
+
+// MaxResourceTags is the maximum number of user-defined tags on an AWS resource.
+const MaxResourceTags = 50
+
+// MergeTags returns base overlaid with overrides. Neither map is modified.
+func MergeTags(base, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
 }
 
 // ComputeConfig holds compute resource configuration.
 type ComputeConfig struct {
-	HeadNode string  `yaml:"head_node"`
-	Queues   []Queue `yaml:"queues"`
+	HeadNode string `yaml:"head_node"`
+	// HeadNodeTags are applied to the head node in addition to cluster.tags
+	HeadNodeTags map[string]string `yaml:"head_node_tags,omitempty"`
+	Queues       []Queue           `yaml:"queues"`
+	// ScaledownIdleTime is the minutes a dynamic node stays idle before termination
+	ScaledownIdleTime int `yaml:"scaledown_idle_time,omitempty"`
+}
+
+// SchedulerConfig holds cluster-wide Slurm configuration.
+type SchedulerConfig struct {
+	// Accounting enables Slurm job accounting (sacct) backed by slurmdbd
+	Accounting *AccountingConfig `yaml:"accounting,omitempty"`
+}
+
+// Supported Slurm accounting databases.
+const (
+	// AccountingDatabaseLocal runs MariaDB and slurmdbd on the head node (default)
+	AccountingDatabaseLocal = "local"
+	// AccountingDatabaseExternal uses an existing MySQL-compatible database
+	// (e.g., Amazon RDS or Aurora) through ParallelCluster's Slurm settings
+	AccountingDatabaseExternal = "external"
+)
+
+// AccountingConfig configures Slurm accounting. A local database lives and
+// dies with the head node; an external one keeps usage history across
+// clusters.
+type AccountingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Database is local (default) or external
+	Database string `yaml:"database,omitempty"`
+	// URI is the external database endpoint as host or host:port
+	URI string `yaml:"uri,omitempty"`
+	// UserName is the external database user
+	UserName string `yaml:"user_name,omitempty"`
+	// PasswordSecretARN is the Secrets Manager secret holding the external
+	// database user's password
+	PasswordSecretARN string `yaml:"password_secret_arn,omitempty"`
+	// DatabaseName is the external database to use (default: the cluster name)
+	DatabaseName string `yaml:"database_name,omitempty"`
+}
+
+// GetDatabase returns the accounting database type, defaulting to local.
+func (a AccountingConfig) GetDatabase() string {
+	if a.Database == "" {
+		return AccountingDatabaseLocal
+	}
+	return a.Database
+}
+
+// LocalAccounting reports whether Slurm accounting is enabled with a
+// database on the head node.
+func (s SchedulerConfig) LocalAccounting() bool {
+	return s.Accounting != nil && s.Accounting.Enabled && s.Accounting.GetDatabase() == AccountingDatabaseLocal
+}
+
+// ExternalAccounting reports whether Slurm accounting is enabled with an
+// external database.
+func (s SchedulerConfig) ExternalAccounting() bool {
+	return s.Accounting != nil && s.Accounting.Enabled && s.Accounting.GetDatabase() == AccountingDatabaseExternal
 }
 
 // Queue represents a compute queue configuration.
+//
+// A queue either lists InstanceTypes with queue-wide counts (the simple form),
+// or defines ComputeResources that each scale independently.
 type Queue struct {
 	Name          string   `yaml:"name"`
-	InstanceTypes []string `yaml:"instance_types"`
+	InstanceTypes []string `yaml:"instance_types,omitempty"`
 	MinCount      int      `yaml:"min_count"`
 	MaxCount      int      `yaml:"max_count"`
+	// StaticCount is the number of always-on nodes; overrides MinCount when set
+	StaticCount int `yaml:"static_count,omitempty"`
+	// ComputeResources are named groups of nodes with their own instance
+	// types and scaling, e.g. for mixed CPU/GPU or multi-size queues
+	ComputeResources []ComputeResource `yaml:"compute_resources,omitempty"`
+	// PlacementGroup launches the queue's nodes in a cluster placement group
+	// for low-latency networking between nodes (tightly-coupled MPI jobs)
+	PlacementGroup bool `yaml:"placement_group,omitempty"`
+	// Tags are applied to the queue's compute nodes in addition to cluster.tags
+	Tags map[string]string `yaml:"tags,omitempty"`
+	// Image overrides the cluster AMI for the queue's compute nodes
+	Image *QueueImage `yaml:"image,omitempty"`
+	// EFA attaches an Elastic Fabric Adapter to the queue's nodes for
+	// low-latency, OS-bypass networking between them
+	EFA *QueueEFA `yaml:"efa,omitempty"`
+	// CapacityReservation launches the queue's nodes into reserved
+	// On-Demand capacity, e.g. GPU capacity shared across queues
+	CapacityReservation *QueueCapacityReservation `yaml:"capacity_reservation,omitempty"`
 }
 
+// QueueCapacityReservation targets an On-Demand Capacity Reservation group
+// for a queue. Queues that share reserved capacity name the same group.
+type QueueCapacityReservation struct {
+	// ResourceGroupARN is the ARN of the resource group holding the
+	// capacity reservations, e.g.
+	// arn:aws:resource-groups:us-east-1:123456789012:group/gpu-reservations
+	ResourceGroupARN string `yaml:"resource_group_arn"`
+}
+
+// SetCapacityReservationGroup targets the capacity reservation group with
+// the given ARN for the named queue.
+func (t *Template) SetCapacityReservationGroup(queueName, groupARN string) error {
+	for i := range t.Compute.Queues {
+		if t.Compute.Queues[i].Name == queueName {
+			t.Compute.Queues[i].CapacityReservation = &QueueCapacityReservation{ResourceGroupARN: groupARN}
+			return nil
+		}
+	}
+	return fmt.Errorf("queue '%s' not found", queueName)
+}
+
+// QueueEFA configures Elastic Fabric Adapter networking for a queue.
+type QueueEFA struct {
+	// Enabled attaches an EFA to each node; every instance type in the
+	// queue must support it
+	Enabled bool `yaml:"enabled"`
+	// GPUDirectRDMA lets GPUs exchange data over EFA without staging it
+	// through host memory (p4d, p5 and similar GPU instances)
+	GPUDirectRDMA bool `yaml:"gpu_direct_rdma,omitempty"`
+}
+
+// QueueImage is the AMI a queue's compute nodes boot from instead of the
+// cluster AMI, e.g. a deep learning AMI for a GPU queue.
+type QueueImage struct {
+	// CustomAMI is the AMI ID. ParallelCluster requires it to be built on the
+	// cluster OS, for the same ParallelCluster version as the cluster.
+	CustomAMI string `yaml:"custom_ami"`
+	// OS is the operating system the AMI is built on, checked against
+	// cluster.os when set
+	OS string `yaml:"os,omitempty"`
+}
+
+// Supported compute capacity types.
+const (
+	// CapacityTypeOnDemand uses on-demand instances (default)
+	CapacityTypeOnDemand = "ondemand"
+	// CapacityTypeSpot uses spot instances
+	CapacityTypeSpot = "spot"
+)
+
+// ComputeResource is a named group of nodes within a queue.
+type ComputeResource struct {
+	Name string `yaml:"name"`
+	// InstanceTypes lists the instance types for the resource; more than one
+	// requires types with the same vCPU count and memory
+	InstanceTypes []string `yaml:"instance_types"`
+	// StaticCount is the number of always-on nodes
+	StaticCount int `yaml:"static_count,omitempty"`
+	MaxCount    int `yaml:"max_count"`
+	// CapacityType is ondemand (default) or spot. ParallelCluster sets
+	// capacity per queue, so all resources in a queue must agree.
+	CapacityType string `yaml:"capacity_type,omitempty"`
+}
+
+// GetCapacityType returns the capacity type, defaulting to on-demand.
+func (r ComputeResource) GetCapacityType() string {
+	if r.CapacityType == "" {
+		return CapacityTypeOnDemand
+	}
+	return r.CapacityType
+}
+
+// StaticNodes returns the number of always-on nodes in the queue.
+func (q Queue) StaticNodes() int {
+	if len(q.ComputeResources) > 0 {
+		total := 0
+		for _, r := range q.ComputeResources {
+			total += r.StaticCount
+		}
+		return total
+	}
+	if q.StaticCount > 0 {
+		return q.StaticCount
+	}
+	return q.MinCount
+}
+
+// MaxNodes returns the maximum number of nodes in the queue.
+func (q Queue) MaxNodes() int {
+	if len(q.ComputeResources) > 0 {
+		total := 0
+		for _, r := range q.ComputeResources {
+			total += r.MaxCount
+		}
+		return total
+	}
+	return q.MaxCount
+}
+
+// DynamicNodes returns the number of nodes launched on demand.
+func (q Queue) DynamicNodes() int {
+	if dynamic := q.MaxNodes() - q.StaticNodes(); dynamic > 0 {
+		return dynamic
+	}
+	return 0
+}
+
+// AllInstanceTypes returns the instance types used by the queue, across all
+// of its compute resources.
+func (q Queue) AllInstanceTypes() []string {
+	if len(q.ComputeResources) == 0 {
+		return q.InstanceTypes
+	}
+
+	var types []string
+	for _, r := range q.ComputeResources {
+		types = append(types, r.InstanceTypes...)
+	}
+	return types
+}
+
+// Supported module systems.
+const (
+	// ModuleSystemLmod is the Lua-based Lmod module system (default)
+	ModuleSystemLmod = "lmod"
+	// ModuleSystemEnvironmentModules is the Tcl-based environment-modules system
+	ModuleSystemEnvironmentModules = "environment-modules"
+)
+
 // SoftwareConfig holds software installation configuration.
 type SoftwareConfig struct {
-	SpackPackages []string `yaml:"spack_packages,omitempty"`
+	SpackPackages  []string `yaml:"spack_packages,omitempty"`
+	ModuleSystem   string   `yaml:"module_system,omitempty"`
+	DefaultModules []string `yaml:"default_modules,omitempty"`
+	// InstallLmod installs Lmod alongside Spack when the module system is
+	// lmod (default true); disable it for Spack-only or container setups
+	InstallLmod *bool `yaml:"install_lmod,omitempty"`
+}
+
+// GetModuleSystem returns the configured module system, defaulting to Lmod.
+func (s SoftwareConfig) GetModuleSystem() string {
+	if s.ModuleSystem == "" {
+		return ModuleSystemLmod
+	}
+	return s.ModuleSystem
+}
+
+// ObservabilityConfig controls the CloudWatch dashboard, alarms, and logs
+// ParallelCluster creates for a cluster. Unset fields keep ParallelCluster's
+// defaults: dashboard and alarms on, logs kept for 180 days.
+type ObservabilityConfig struct {
+	// Dashboard creates the cluster's CloudWatch dashboard (default true)
+	Dashboard *bool `yaml:"dashboard,omitempty"`
+	// Alarms creates CloudWatch alarms on head node health (default true)
+	Alarms *bool `yaml:"alarms,omitempty"`
+	// LogRetentionDays is how long CloudWatch Logs keeps the cluster's logs;
+	// it must be a retention period CloudWatch Logs supports
+	LogRetentionDays int `yaml:"log_retention_days,omitempty"`
+}
+
+// IsSet reports whether any observability setting is given.
+func (o ObservabilityConfig) IsSet() bool {
+	return o.Dashboard != nil || o.Alarms != nil || o.LogRetentionDays != 0
+}
+
+// DashboardEnabled returns whether the CloudWatch dashboard is created,
+// defaulting to true.
+func (o ObservabilityConfig) DashboardEnabled() bool {
+	return o.Dashboard == nil || *o.Dashboard
+}
+
+// AlarmsEnabled returns whether CloudWatch alarms are created, defaulting
+// to true.
+func (o ObservabilityConfig) AlarmsEnabled() bool {
+	return o.Alarms == nil || *o.Alarms
+}
+
+// DefaultLogRetentionDays is ParallelCluster's CloudWatch Logs retention.
+const DefaultLogRetentionDays = 180
+
+// GetLogRetentionDays returns the log retention in days, defaulting to
+// DefaultLogRetentionDays.
+func (o ObservabilityConfig) GetLogRetentionDays() int {
+	if o.LogRetentionDays == 0 {
+		return DefaultLogRetentionDays
+	}
+	return o.LogRetentionDays
+}
+
+// LogRetentionPeriods are the retention periods, in days, CloudWatch Logs
+// accepts.
+var LogRetentionPeriods = []int{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1096, 1827, 2192, 2557, 2922, 3288, 3653}
+
+// ShouldInstallLmod returns whether Lmod is installed, defaulting to true.
+func (s SoftwareConfig) ShouldInstallLmod() bool {
+	return s.InstallLmod == nil || *s.InstallLmod
+}
+
+// InstallsModuleSystem returns whether a module system is installed at all.
+func (s SoftwareConfig) InstallsModuleSystem() bool {
+	return s.GetModuleSystem() != ModuleSystemLmod || s.ShouldInstallLmod()
 }
 
 // User represents a cluster user.
@@ -61,11 +397,188 @@ type User struct {
 	Name string `yaml:"name"`
 	UID  int    `yaml:"uid"`
 	GID  int    `yaml:"gid"`
+	// Skeleton is copied into the user's new home directory: an S3 URI
+	// (s3://bucket/prefix) or a local directory embedded in the bootstrap
+	// script
+	Skeleton string `yaml:"skeleton,omitempty"`
+}
+
+// skeletonS3Prefix marks a skeleton stored in S3.
+const skeletonS3Prefix = "s3://"
+
+// SkeletonInS3 returns whether the user's skeleton is an S3 URI rather than
+// a local directory.
+func (u User) SkeletonInS3() bool {
+	return strings.HasPrefix(u.Skeleton, skeletonS3Prefix)
+}
+
+// BuildConfig holds settings for AMI builds from the template.
+type BuildConfig struct {
+	// PreInstallScript runs on the build instance before any software is
+	// installed: an S3 URI (s3://bucket/key.sh), a local script path, or an
+	// inline script starting with #!
+	PreInstallScript string `yaml:"pre_install_script,omitempty"`
+	// SpackLock is the path of a spack.lock to install exact package
+	// versions from instead of the seed's spack_packages
+	SpackLock string `yaml:"spack_lock,omitempty"`
+	// SpackConfig lists paths of Spack configuration files (config.yaml,
+	// packages.yaml, ...) installed in Spack's site scope before any
+	// packages are built
+	SpackConfig []string `yaml:"spack_config,omitempty"`
+	// Verify lists shell commands run after software is installed, such as
+	// "gmx --version"; the build fails instead of creating an AMI if any of
+	// them fails
+	Verify []string `yaml:"verify,omitempty"`
+}
+
+// shellInterpreters are the interpreters a pre-install script may use.
+var shellInterpreters = map[string]bool{"sh": true, "bash": true}
+
+// IsInlineScript reports whether a script setting holds the script itself
+// rather than a path or S3 URI.
+func IsInlineScript(value string) bool {
+	return strings.HasPrefix(value, "#!")
+}
+
+// ValidateShellScript checks that a script starts with a #! line for sh or
+// bash, either directly (#!/bin/bash) or through env (#!/usr/bin/env bash).
+func ValidateShellScript(content string) error {
+	line, _, _ := strings.Cut(content, "\n")
+	if !strings.HasPrefix(line, "#!") {
+		return fmt.Errorf("script must start with a #! line such as #!/bin/bash")
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) > 0 && filepath.Base(fields[0]) == "env" {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || !shellInterpreters[filepath.Base(fields[0])] {
+		return fmt.Errorf("script must be a shell script (#!/bin/bash or #!/bin/sh), got %q", line)
+	}
+	return nil
+}
+
+// IAMConfig grants cluster instances additional AWS permissions. The
+// settings apply to the head node and every queue.
+type IAMConfig struct {
+	// AdditionalPolicies are IAM policy ARNs attached to the instance roles
+	AdditionalPolicies []string `yaml:"additional_policies,omitempty"`
+	// S3Access grants access to S3 buckets
+	S3Access []S3Access `yaml:"s3_access,omitempty"`
+}
+
+// S3Access grants cluster instances access to an S3 bucket.
+type S3Access struct {
+	Bucket string `yaml:"bucket"`
+	// EnableWrite grants write access in addition to read access
+	EnableWrite bool `yaml:"enable_write,omitempty"`
+}
+
+// NetworkConfig holds VPC settings applied when pctl creates the network.
+type NetworkConfig struct {
+	// DomainName is the DNS search domain for cluster instances
+	DomainName string `yaml:"domain_name,omitempty"`
+	// DNSServers are custom DNS server IPs (e.g., on-prem resolvers)
+	DNSServers []string `yaml:"dns_servers,omitempty"`
+	// IngressRules open additional head node ports (e.g., Jupyter, license servers)
+	IngressRules []IngressRule `yaml:"ingress_rules,omitempty"`
+	// Proxy is the HTTP(S) proxy URL instances reach the internet through
+	// (e.g., http://proxy.example.com:3128)
+	Proxy string `yaml:"proxy,omitempty"`
+	// NoProxy are extra hosts, domains, or CIDRs that bypass the proxy, in
+	// addition to instance metadata, AWS endpoints, and the VPC
+	NoProxy []string `yaml:"no_proxy,omitempty"`
+}
+
+// Supported ingress rule protocols.
+const (
+	// ProtocolTCP is the default ingress protocol
+	ProtocolTCP = "tcp"
+	// ProtocolUDP is for UDP services
+	ProtocolUDP = "udp"
+)
+
+// IngressRule allows inbound traffic to the head node.
+type IngressRule struct {
+	// Port is a single port ("8888") or an inclusive range ("27000-27009")
+	Port string `yaml:"port"`
+	// Protocol is tcp (default) or udp
+	Protocol string `yaml:"protocol,omitempty"`
+	// CIDR is the IPv4 source range allowed to connect
+	CIDR string `yaml:"cidr"`
+	// Description is shown on the security group rule
+	Description string `yaml:"description,omitempty"`
+}
+
+// GetProtocol returns the rule's protocol, defaulting to TCP.
+func (r IngressRule) GetProtocol() string {
+	if r.Protocol == "" {
+		return ProtocolTCP
+	}
+	return r.Protocol
+}
+
+// PortRange returns the first and last port of the rule.
+func (r IngressRule) PortRange() (int, int, error) {
+	from, to, isRange := strings.Cut(r.Port, "-")
+	fromPort, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port '%s'", r.Port)
+	}
+	if !isRange {
+		return fromPort, fromPort, nil
+	}
+	toPort, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range '%s'", r.Port)
+	}
+	return fromPort, toPort, nil
 }
 
 // DataConfig holds data source configuration.
 type DataConfig struct {
 	S3Mounts []S3Mount `yaml:"s3_mounts,omitempty"`
+	// PersistentHome mounts a volume at /home that outlives the cluster
+	PersistentHome *PersistentHome `yaml:"persistent_home,omitempty"`
+}
+
+const (
+	// StorageTypeEBS is the default persistent home storage type
+	StorageTypeEBS = "ebs"
+	// StorageTypeEFS mounts an existing EFS file system
+	StorageTypeEFS = "efs"
+	// DefaultPersistentHomeSize is the size in GiB of a new persistent home volume
+	DefaultPersistentHomeSize = 100
+)
+
+// PersistentHome is a volume mounted at /home that is kept when the cluster
+// is deleted and reattached when a cluster using the same name is created.
+type PersistentHome struct {
+	// Name is the logical name the volume is tracked under
+	Name string `yaml:"name"`
+	// Type is ebs (default) or efs
+	Type string `yaml:"type,omitempty"`
+	// Size is the size in GiB of a new EBS volume
+	Size int `yaml:"size,omitempty"`
+	// ID is an existing volume (vol-...) or file system (fs-...) to use
+	// instead of creating one
+	ID string `yaml:"id,omitempty"`
+}
+
+// GetType returns the storage type, defaulting to EBS.
+func (h PersistentHome) GetType() string {
+	if h.Type == "" {
+		return StorageTypeEBS
+	}
+	return h.Type
+}
+
+// GetSize returns the size of a new volume, defaulting to DefaultPersistentHomeSize.
+func (h PersistentHome) GetSize() int {
+	if h.Size == 0 {
+		return DefaultPersistentHomeSize
+	}
+	return h.Size
 }
 
 // S3Mount represents an S3 bucket mount.
@@ -74,21 +587,50 @@ type S3Mount struct {
 	MountPoint string `yaml:"mount_point"`
 }
 
-// Load loads a template from a file.
+// Load loads a YAML or JSON template from a file.
 func Load(path string) (*Template, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read template file: %w", err)
 	}
 
+	if isJSON(path, data) {
+		// Check the syntax as JSON so errors point at the JSON problem
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON template: %w", err)
+		}
+	}
+
+	// JSON is valid YAML, so both formats decode through the same yaml tags
 	var tmpl Template
 	if err := yaml.Unmarshal(data, &tmpl); err != nil {
 		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
+	tmpl.ResolveSkeletons(filepath.Dir(path))
 
 	return &tmpl, nil
 }
 
+// ResolveSkeletons makes relative local user skeletons relative to dir, the
+// directory of the seed file, rather than the working directory.
+func (t *Template) ResolveSkeletons(dir string) {
+	for i, user := range t.Users {
+		if user.Skeleton != "" && !user.SkeletonInS3() && !filepath.IsAbs(user.Skeleton) {
+			t.Users[i].Skeleton = filepath.Join(dir, user.Skeleton)
+		}
+	}
+}
+
+// isJSON reports whether a template is JSON, by its .json extension or,
+// failing that, by its content starting with an object.
+func isJSON(path string, data []byte) bool {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
 // Validate validates the template using the default validator.
 func (t *Template) Validate() error {
 	validator := NewValidator()
@@ -15,6 +15,10 @@
 package template
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -97,3 +101,426 @@ func TestTemplateValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestQueueStaticAndDynamicNodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		queue       Queue
+		wantStatic  int
+		wantDynamic int
+	}{
+		{"all dynamic", Queue{MaxCount: 10}, 0, 10},
+		{"static count", Queue{StaticCount: 2, MaxCount: 10}, 2, 8},
+		{"min count as static", Queue{MinCount: 3, MaxCount: 10}, 3, 7},
+		{"static count overrides min count", Queue{MinCount: 1, StaticCount: 4, MaxCount: 10}, 4, 6},
+		{"all static", Queue{StaticCount: 5, MaxCount: 5}, 5, 0},
+		{"compute resources", Queue{ComputeResources: []ComputeResource{
+			{Name: "small", StaticCount: 1, MaxCount: 8},
+			{Name: "large", StaticCount: 2, MaxCount: 4},
+		}}, 3, 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.queue.StaticNodes(); got != tt.wantStatic {
+				t.Errorf("StaticNodes() = %d, want %d", got, tt.wantStatic)
+			}
+			if got := tt.queue.DynamicNodes(); got != tt.wantDynamic {
+				t.Errorf("DynamicNodes() = %d, want %d", got, tt.wantDynamic)
+			}
+		})
+	}
+}
+
+func TestLoadQueueComputeResources(t *testing.T) {
+	content := `cluster:
+  name: mixed
+  region: us-east-1
+compute:
+  head_node: t3.medium
+  queues:
+    - name: mixed
+      compute_resources:
+        - name: cpu
+          instance_types: [c5.2xlarge]
+          static_count: 1
+          max_count: 8
+        - name: gpu
+          instance_types: [g5.xlarge, g5.2xlarge]
+          max_count: 4
+          capacity_type: spot
+`
+	path := filepath.Join(t.TempDir(), "mixed.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	tmpl, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	queue := tmpl.Compute.Queues[0]
+	if len(queue.ComputeResources) != 2 {
+		t.Fatalf("Expected 2 compute resources, got %d", len(queue.ComputeResources))
+	}
+
+	gpu := queue.ComputeResources[1]
+	if gpu.Name != "gpu" || gpu.MaxCount != 4 || gpu.GetCapacityType() != CapacityTypeSpot {
+		t.Errorf("Unexpected gpu compute resource: %+v", gpu)
+	}
+	if queue.ComputeResources[0].GetCapacityType() != CapacityTypeOnDemand {
+		t.Errorf("Expected capacity type to default to %s", CapacityTypeOnDemand)
+	}
+
+	want := []string{"c5.2xlarge", "g5.xlarge", "g5.2xlarge"}
+	if got := queue.AllInstanceTypes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllInstanceTypes() = %v, want %v", got, want)
+	}
+	if got := queue.MaxNodes(); got != 12 {
+		t.Errorf("MaxNodes() = %d, want 12", got)
+	}
+}
+
+func TestLoadNetworkIngressRules(t *testing.T) {
+	content := `cluster:
+  name: services
+  region: us-east-1
+compute:
+  head_node: t3.medium
+  queues:
+    - name: compute
+      instance_types: [c5.xlarge]
+      max_count: 4
+network:
+  ingress_rules:
+    - port: 8888
+      cidr: 10.0.0.0/16
+      description: Jupyter
+    - port: 27000-27009
+      protocol: tcp
+      cidr: 192.168.0.0/24
+`
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	tmpl, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	rules := tmpl.Network.IngressRules
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 ingress rules, got %d", len(rules))
+	}
+
+	from, to, err := rules[0].PortRange()
+	if err != nil || from != 8888 || to != 8888 {
+		t.Errorf("PortRange() = %d, %d, %v; want 8888, 8888", from, to, err)
+	}
+	if rules[0].GetProtocol() != ProtocolTCP {
+		t.Errorf("Expected protocol to default to %s", ProtocolTCP)
+	}
+
+	from, to, err = rules[1].PortRange()
+	if err != nil || from != 27000 || to != 27009 {
+		t.Errorf("PortRange() = %d, %d, %v; want 27000, 27009", from, to, err)
+	}
+
+	if err := tmpl.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+}
+
+const equivalentYAML = `cluster:
+  name: genomics
+  region: us-west-2
+  tags:
+    project: genomics
+compute:
+  head_node: t3.xlarge
+  queues:
+    - name: compute
+      instance_types: [c5.2xlarge, c5.4xlarge]
+      max_count: 10
+      placement_group: true
+software:
+  spack_packages:
+    - samtools@1.17
+    - bwa@0.7.17
+users:
+  - name: alice
+    uid: 5001
+    gid: 5001
+data:
+  s3_mounts:
+    - bucket: my-genomics-data
+      mount_point: /shared/data
+network:
+  ingress_rules:
+    - port: 8888
+      cidr: 10.0.0.0/16
+metadata:
+  owner: research-computing
+`
+
+const equivalentJSON = `{
+	"cluster": {
+		"name": "genomics",
+		"region": "us-west-2",
+		"tags": {"project": "genomics"}
+	},
+	"compute": {
+		"head_node": "t3.xlarge",
+		"queues": [
+			{
+				"name": "compute",
+				"instance_types": ["c5.2xlarge", "c5.4xlarge"],
+				"max_count": 10,
+				"placement_group": true
+			}
+		]
+	},
+	"software": {
+		"spack_packages": ["samtools@1.17", "bwa@0.7.17"]
+	},
+	"users": [{"name": "alice", "uid": 5001, "gid": 5001}],
+	"data": {
+		"s3_mounts": [{"bucket": "my-genomics-data", "mount_point": "/shared/data"}]
+	},
+	"network": {
+		"ingress_rules": [{"port": 8888, "cidr": "10.0.0.0/16"}]
+	},
+	"metadata": {"owner": "research-computing"}
+}
+`
+
+func writeTemplate(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	return path
+}
+
+func TestLoadResolvesSkeletonsAgainstSeed(t *testing.T) {
+	content := `cluster:
+  name: lab
+  region: us-east-1
+compute:
+  head_node: t3.medium
+  queues:
+    - name: compute
+      instance_types: [c5.xlarge]
+      max_count: 4
+users:
+  - name: alice
+    uid: 5001
+    gid: 5001
+    skeleton: ./skel
+  - name: bob
+    uid: 5002
+    gid: 5002
+    skeleton: s3://lab-config/skel
+  - name: carol
+    uid: 5003
+    gid: 5003
+    skeleton: /etc/skel
+`
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "skel"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "lab.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	// Load from another working directory, as 'pctl create --seed dir/lab.yaml' would
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	tmpl, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	want := []string{filepath.Join(dir, "skel"), "s3://lab-config/skel", "/etc/skel"}
+	for i, user := range tmpl.Users {
+		if user.Skeleton != want[i] {
+			t.Errorf("users[%d].skeleton = %q, want %q", i, user.Skeleton, want[i])
+		}
+	}
+	if err := tmpl.Validate(); err != nil && strings.Contains(err.Error(), "users[0].skeleton") {
+		t.Errorf("Relative skeleton should be found next to the seed: %v", err)
+	}
+}
+
+func TestLoadJSONMatchesYAML(t *testing.T) {
+	fromYAML, err := Load(writeTemplate(t, "genomics.yaml", equivalentYAML))
+	if err != nil {
+		t.Fatalf("Load() YAML failed: %v", err)
+	}
+
+	// Detected by extension, and by content for other extensions
+	for _, name := range []string{"genomics.json", "genomics.seed"} {
+		t.Run(name, func(t *testing.T) {
+			fromJSON, err := Load(writeTemplate(t, name, equivalentJSON))
+			if err != nil {
+				t.Fatalf("Load() JSON failed: %v", err)
+			}
+			if !reflect.DeepEqual(fromYAML, fromJSON) {
+				t.Errorf("JSON template differs from YAML:\nyaml: %+v\njson: %+v", fromYAML, fromJSON)
+			}
+			if err := fromJSON.Validate(); err != nil {
+				t.Errorf("Validate() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadJSONValidatesLikeYAML(t *testing.T) {
+	yamlTmpl, err := Load(writeTemplate(t, "bad.yaml", "cluster:\n  name: bad\n  region: us-east-1\ncompute:\n  head_node: t3.medium\n"))
+	if err != nil {
+		t.Fatalf("Load() YAML failed: %v", err)
+	}
+	jsonTmpl, err := Load(writeTemplate(t, "bad.json", `{"cluster": {"name": "bad", "region": "us-east-1"}, "compute": {"head_node": "t3.medium"}}`))
+	if err != nil {
+		t.Fatalf("Load() JSON failed: %v", err)
+	}
+
+	yamlErr, jsonErr := yamlTmpl.Validate(), jsonTmpl.Validate()
+	if yamlErr == nil || jsonErr == nil {
+		t.Fatalf("Expected both templates to fail validation, got yaml=%v json=%v", yamlErr, jsonErr)
+	}
+	if yamlErr.Error() != jsonErr.Error() {
+		t.Errorf("Validation differs:\nyaml: %v\njson: %v", yamlErr, jsonErr)
+	}
+}
+
+func TestLoadInvalidJSON(t *testing.T) {
+	_, err := Load(writeTemplate(t, "broken.json", `{"cluster": {"name": "broken",}}`))
+	if err == nil {
+		t.Fatal("Expected error for invalid JSON")
+	}
+	if !strings.Contains(err.Error(), "JSON") {
+		t.Errorf("Expected a JSON parse error, got %v", err)
+	}
+}
+
+func TestEnableGPUDirectRDMA(t *testing.T) {
+	tmpl := &Template{
+		Compute: ComputeConfig{
+			Queues: []Queue{
+				{Name: "cpu", InstanceTypes: []string{"c5n.18xlarge"}, MaxCount: 10},
+				{Name: "gpu", InstanceTypes: []string{"p4d.24xlarge", "p5.48xlarge"}, MaxCount: 4},
+				{Name: "mixed", ComputeResources: []ComputeResource{
+					{Name: "a100", InstanceTypes: []string{"p4d.24xlarge"}, MaxCount: 2},
+					{Name: "small", InstanceTypes: []string{"g5.xlarge"}, MaxCount: 2},
+				}},
+			},
+		},
+	}
+
+	enabled := tmpl.EnableGPUDirectRDMA()
+	if len(enabled) != 1 || enabled[0] != "gpu" {
+		t.Errorf("EnableGPUDirectRDMA() = %v, want [gpu]", enabled)
+	}
+	if !tmpl.Compute.Queues[1].GPUDirectRDMA() {
+		t.Error("gpu queue should have EFA with GPUDirect RDMA enabled")
+	}
+	for _, i := range []int{0, 2} {
+		if tmpl.Compute.Queues[i].EFAEnabled() {
+			t.Errorf("queue %s has instance types without GPUDirect RDMA and should be unchanged", tmpl.Compute.Queues[i].Name)
+		}
+	}
+}
+
+func TestSetCapacityReservationGroup(t *testing.T) {
+	const groupARN = "arn:aws:resource-groups:us-east-1:123456789012:group/gpu-reservations"
+	tmpl := &Template{
+		Compute: ComputeConfig{
+			Queues: []Queue{
+				{Name: "cpu", InstanceTypes: []string{"c5.2xlarge"}, MaxCount: 10},
+				{Name: "gpu", InstanceTypes: []string{"p5.48xlarge"}, MaxCount: 4},
+			},
+		},
+	}
+
+	if err := tmpl.SetCapacityReservationGroup("gpu", groupARN); err != nil {
+		t.Fatalf("SetCapacityReservationGroup() error = %v", err)
+	}
+	if r := tmpl.Compute.Queues[1].CapacityReservation; r == nil || r.ResourceGroupARN != groupARN {
+		t.Errorf("gpu queue capacity_reservation = %v, want %s", r, groupARN)
+	}
+	if tmpl.Compute.Queues[0].CapacityReservation != nil {
+		t.Error("cpu queue should be unchanged")
+	}
+	if err := tmpl.SetCapacityReservationGroup("missing", groupARN); err == nil {
+		t.Error("SetCapacityReservationGroup() should fail for an unknown queue")
+	}
+}
+
+func TestSupportsEFA(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		efa          bool
+		gdr          bool
+	}{
+		{"c5n.18xlarge", true, false},
+		{"c5n.xlarge", false, false},
+		{"hpc7g.4xlarge", true, false},
+		{"g5.48xlarge", true, false},
+		{"p4d.24xlarge", true, true},
+		{"p5.48xlarge", true, true},
+		{"t3.micro", false, false},
+		{"invalid", false, false},
+	}
+	for _, tt := range tests {
+		if got := SupportsEFA(tt.instanceType); got != tt.efa {
+			t.Errorf("SupportsEFA(%q) = %v, want %v", tt.instanceType, got, tt.efa)
+		}
+		if got := SupportsGPUDirectRDMA(tt.instanceType); got != tt.gdr {
+			t.Errorf("SupportsGPUDirectRDMA(%q) = %v, want %v", tt.instanceType, got, tt.gdr)
+		}
+	}
+}
+
+func TestScaleToZero(t *testing.T) {
+	tmpl := &Template{
+		Compute: ComputeConfig{
+			Queues: []Queue{
+				{Name: "always", InstanceTypes: []string{"c5.xlarge"}, MinCount: 2, MaxCount: 10},
+				{Name: "static", InstanceTypes: []string{"c5.xlarge"}, MinCount: 1, StaticCount: 3, MaxCount: 10},
+				{Name: "mixed", ComputeResources: []ComputeResource{
+					{Name: "cpu", InstanceTypes: []string{"c5.xlarge"}, StaticCount: 1, MaxCount: 4},
+					{Name: "gpu", InstanceTypes: []string{"g4dn.xlarge"}, MaxCount: 2},
+				}},
+			},
+		},
+	}
+
+	if removed := tmpl.ScaleToZero(); removed != 6 {
+		t.Errorf("ScaleToZero() = %d, want 6", removed)
+	}
+	for _, queue := range tmpl.Compute.Queues {
+		if queue.MinCount != 0 || queue.StaticCount != 0 || queue.StaticNodes() != 0 {
+			t.Errorf("queue %s still has static nodes: %+v", queue.Name, queue)
+		}
+	}
+	if got := tmpl.Compute.Queues[2].MaxNodes(); got != 6 {
+		t.Errorf("MaxNodes() = %d after ScaleToZero, want max counts unchanged", got)
+	}
+	if tmpl.ScaleToZero() != 0 {
+		t.Error("ScaleToZero() on a scaled-to-zero template should remove nothing")
+	}
+}
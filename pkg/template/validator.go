@@ -16,14 +16,20 @@ package template
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 // ValidationError represents a collection of validation errors.
 type ValidationError struct {
 	Errors []string
+	// fields holds the template field each error is about, parallel to Errors
+	fields []string
 }
 
 // Error implements the error interface.
@@ -37,9 +43,16 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("%d validation errors:\n  - %s", len(e.Errors), strings.Join(e.Errors, "\n  - "))
 }
 
-// Add adds an error message to the validation error.
+// Add adds an error message that concerns no single template field.
 func (e *ValidationError) Add(msg string) {
+	e.AddField("", msg)
+}
+
+// AddField adds an error message about a template field, given as its path
+// (e.g. "compute.queues[0].name").
+func (e *ValidationError) AddField(field, msg string) {
 	e.Errors = append(e.Errors, msg)
+	e.fields = append(e.fields, field)
 }
 
 // HasErrors returns true if there are validation errors.
@@ -53,8 +66,20 @@ type Validator struct {
 	ValidRegions map[string]bool
 	// ValidInstanceTypes is a list of valid EC2 instance types (patterns)
 	ValidInstanceTypes []*regexp.Regexp
+	// Price prices always-on queues in ValidateDetailed warnings; nil
+	// reports them without an idle cost estimate
+	Price PriceFunc
 }
 
+// instanceTypePattern matches EC2 instance type names: a family of a class
+// prefix, generation, and attribute letters (c5, g4dn, hpc7g, m7i-flex,
+// plus the mac and high-memory u- families), then a size: nano through
+// xlarge, a numbered xlarge such as 24xlarge, or metal.
+var instanceTypePattern = regexp.MustCompile(`^` +
+	`(?:[a-z]{1,3}[0-9]+[a-z]*(?:-flex)?|mac[0-9]+(?:-m[0-9]+[a-z]*)?|u[0-9]*[a-z]*-[0-9]+tb[0-9]*)` +
+	`\.` +
+	`(?:nano|micro|small|medium|large|xlarge|(?:[2-9]|[1-9][0-9]{1,2})xlarge|metal(?:-[0-9]+xl)?)$`)
+
 // NewValidator creates a new validator with default rules.
 func NewValidator() *Validator {
 	return &Validator{
@@ -77,98 +102,420 @@ func NewValidator() *Validator {
 			"ca-central-1":   true,
 		},
 		ValidInstanceTypes: []*regexp.Regexp{
-			regexp.MustCompile(`^[a-z][0-9][a-z0-9]*\.[a-z0-9]+$`), // e.g., t3.medium, c5.xlarge, g4dn.xlarge
+			instanceTypePattern,
 		},
 	}
 }
 
-// ValidateTemplate performs comprehensive validation on a template.
+// ValidateTemplate performs comprehensive validation on a template. Only
+// errors are reported; see ValidateDetailed for warnings and per-field results.
 func (v *Validator) ValidateTemplate(t *Template) error {
+	return v.validateErrors(t).Err()
+}
+
+// validateErrors runs every validation check and reports the errors found.
+func (v *Validator) validateErrors(t *Template) *ValidationReport {
 	errs := &ValidationError{}
 
+	if !IsKnownAPIVersion(t.GetAPIVersion()) {
+		errs.AddField("apiVersion", fmt.Sprintf("unsupported apiVersion %q (supported: %s)", t.APIVersion, strings.Join(APIVersions, ", ")))
+	}
+
 	v.validateCluster(t, errs)
 	v.validateCompute(t, errs)
+	v.validateScheduler(t, errs)
 	v.validateSoftware(t, errs)
 	v.validateUsers(t, errs)
 	v.validateData(t, errs)
+	v.validateNetwork(t, errs)
+	v.validateIAM(t, errs)
+	v.validateBuild(t, errs)
+	validateObservability(t, errs)
 
-	if errs.HasErrors() {
-		return errs
+	// Metadata becomes AMI tags
+	for key, value := range t.Metadata {
+		if err := ValidateTag(key, value); err != nil {
+			errs.AddField("metadata", fmt.Sprintf("metadata: %v", err))
+		}
 	}
-	return nil
+
+	report := &ValidationReport{Issues: []ValidationIssue{}}
+	for i, msg := range errs.Errors {
+		report.Issues = append(report.Issues, ValidationIssue{Field: errs.fields[i], Message: msg, Severity: SeverityError})
+	}
+	return report
 }
 
 func (v *Validator) validateCluster(t *Template, errs *ValidationError) {
 	// Name validation
 	if t.Cluster.Name == "" {
-		errs.Add("cluster.name is required")
+		errs.AddField("cluster.name", "cluster.name is required")
 	} else if len(t.Cluster.Name) > 60 {
-		errs.Add("cluster.name must be 60 characters or less")
+		errs.AddField("cluster.name", "cluster.name must be 60 characters or less")
 	} else if !regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`).MatchString(t.Cluster.Name) {
-		errs.Add("cluster.name must start with a letter and contain only alphanumeric characters and hyphens")
+		errs.AddField("cluster.name", "cluster.name must start with a letter and contain only alphanumeric characters and hyphens")
 	}
 
 	// Region validation
 	if t.Cluster.Region == "" {
-		errs.Add("cluster.region is required")
+		errs.AddField("cluster.region", "cluster.region is required")
 	} else if !v.ValidRegions[t.Cluster.Region] {
-		errs.Add(fmt.Sprintf("cluster.region '%s' is not a valid AWS region", t.Cluster.Region))
+		errs.AddField("cluster.region", fmt.Sprintf("cluster.region '%s' is not a valid AWS region", t.Cluster.Region))
+	}
+
+	// OS validation
+	if t.Cluster.OS != "" && !isSupportedOS(t.Cluster.OS) {
+		errs.AddField("cluster.os", fmt.Sprintf("cluster.os '%s' is not supported (supported: %s)", t.Cluster.OS, strings.Join(SupportedOS, ", ")))
+	}
+
+	// Tags validation
+	for key, value := range t.Cluster.Tags {
+		if err := ValidateTag(key, value); err != nil {
+			errs.AddField("cluster.tags", fmt.Sprintf("cluster.tags: %v", err))
+		}
+	}
+}
+
+// isSupportedOS reports whether os is a ParallelCluster operating system pctl supports.
+func isSupportedOS(os string) bool {
+	for _, supported := range SupportedOS {
+		if os == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTag checks a tag key and value against AWS tagging rules.
+func ValidateTag(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("tag key cannot be empty")
+	}
+	// AWS limits count characters, not bytes
+	if utf8.RuneCountInString(key) > 128 {
+		return fmt.Errorf("tag key '%s' must be 128 characters or less", key)
+	}
+	if utf8.RuneCountInString(value) > 256 {
+		return fmt.Errorf("tag '%s' value must be 256 characters or less", key)
+	}
+	if strings.HasPrefix(strings.ToLower(key), "aws:") || strings.HasPrefix(strings.ToLower(key), "parallelcluster:") {
+		return fmt.Errorf("tag key '%s' uses a reserved prefix", key)
+	}
+	return nil
+}
+
+// validateRoleTags checks head node or queue tags, which are applied on top
+// of the cluster tags and must fit the per-resource tag limit once merged.
+func validateRoleTags(field string, clusterTags, tags map[string]string, errs *ValidationError) {
+	for key, value := range tags {
+		if err := ValidateTag(key, value); err != nil {
+			errs.AddField(field, fmt.Sprintf("%s: %v", field, err))
+		}
+	}
+	if len(tags) > 0 {
+		if count := len(MergeTags(clusterTags, tags)); count > MaxResourceTags {
+			errs.AddField(field, fmt.Sprintf("%s: %d tags with cluster.tags exceeds the AWS limit of %d", field, count, MaxResourceTags))
+		}
 	}
 }
 
 func (v *Validator) validateCompute(t *Template, errs *ValidationError) {
 	// Head node validation
 	if t.Compute.HeadNode == "" {
-		errs.Add("compute.head_node is required")
+		errs.AddField("compute.head_node", "compute.head_node is required")
 	} else if !v.isValidInstanceType(t.Compute.HeadNode) {
-		errs.Add(fmt.Sprintf("compute.head_node '%s' is not a valid instance type format", t.Compute.HeadNode))
+		errs.AddField("compute.head_node", fmt.Sprintf("compute.head_node '%s' is not a valid instance type format", t.Compute.HeadNode))
 	}
 
+	validateRoleTags("compute.head_node_tags", t.Cluster.Tags, t.Compute.HeadNodeTags, errs)
+
 	// Queues validation
 	if len(t.Compute.Queues) == 0 {
-		errs.Add("compute.queues must have at least one queue")
+		errs.AddField("compute.queues", "compute.queues must have at least one queue")
 	}
 
 	queueNames := make(map[string]bool)
 	for i, queue := range t.Compute.Queues {
 		// Queue name validation
 		if queue.Name == "" {
-			errs.Add(fmt.Sprintf("compute.queues[%d].name is required", i))
+			errs.AddField(fmt.Sprintf("compute.queues[%d].name", i), fmt.Sprintf("compute.queues[%d].name is required", i))
 		} else {
 			if queueNames[queue.Name] {
-				errs.Add(fmt.Sprintf("compute.queues[%d].name '%s' is duplicate", i, queue.Name))
+				errs.AddField(fmt.Sprintf("compute.queues[%d].name", i), fmt.Sprintf("compute.queues[%d].name '%s' is duplicate", i, queue.Name))
 			}
 			queueNames[queue.Name] = true
 
 			if !regexp.MustCompile(`^[a-z][a-z0-9-]*$`).MatchString(queue.Name) {
-				errs.Add(fmt.Sprintf("compute.queues[%d].name '%s' must start with lowercase letter and contain only lowercase letters, numbers, and hyphens", i, queue.Name))
+				errs.AddField(fmt.Sprintf("compute.queues[%d].name", i), fmt.Sprintf("compute.queues[%d].name '%s' must start with lowercase letter and contain only lowercase letters, numbers, and hyphens", i, queue.Name))
 			}
 		}
 
 		// Instance types validation
-		if len(queue.InstanceTypes) == 0 {
-			errs.Add(fmt.Sprintf("compute.queues[%d].instance_types must have at least one instance type", i))
+		if len(queue.ComputeResources) > 0 {
+			if len(queue.InstanceTypes) > 0 {
+				errs.AddField(fmt.Sprintf("compute.queues[%d]", i), fmt.Sprintf("compute.queues[%d] cannot set both instance_types and compute_resources", i))
+			}
+			if queue.MinCount != 0 || queue.MaxCount != 0 || queue.StaticCount != 0 {
+				errs.AddField(fmt.Sprintf("compute.queues[%d]", i), fmt.Sprintf("compute.queues[%d] node counts must be set on each compute resource when compute_resources is used", i))
+			}
+			v.validateComputeResources(i, queue, errs)
+		} else if len(queue.InstanceTypes) == 0 {
+			errs.AddField(fmt.Sprintf("compute.queues[%d].instance_types", i), fmt.Sprintf("compute.queues[%d].instance_types must have at least one instance type", i))
 		} else {
 			for j, instanceType := range queue.InstanceTypes {
 				if !v.isValidInstanceType(instanceType) {
-					errs.Add(fmt.Sprintf("compute.queues[%d].instance_types[%d] '%s' is not a valid instance type format", i, j, instanceType))
+					errs.AddField(fmt.Sprintf("compute.queues[%d].instance_types[%d]", i, j), fmt.Sprintf("compute.queues[%d].instance_types[%d] '%s' is not a valid instance type format", i, j, instanceType))
 				}
 			}
 		}
 
 		// Count validation
 		if queue.MinCount < 0 {
-			errs.Add(fmt.Sprintf("compute.queues[%d].min_count must be >= 0", i))
+			errs.AddField(fmt.Sprintf("compute.queues[%d].min_count", i), fmt.Sprintf("compute.queues[%d].min_count must be >= 0", i))
 		}
 		if queue.MaxCount < 0 {
-			errs.Add(fmt.Sprintf("compute.queues[%d].max_count must be >= 0", i))
+			errs.AddField(fmt.Sprintf("compute.queues[%d].max_count", i), fmt.Sprintf("compute.queues[%d].max_count must be >= 0", i))
 		}
 		if queue.MaxCount < queue.MinCount {
-			errs.Add(fmt.Sprintf("compute.queues[%d].max_count (%d) must be >= min_count (%d)", i, queue.MaxCount, queue.MinCount))
+			errs.AddField(fmt.Sprintf("compute.queues[%d].max_count", i), fmt.Sprintf("compute.queues[%d].max_count (%d) must be >= min_count (%d)", i, queue.MaxCount, queue.MinCount))
 		}
 		if queue.MaxCount > 1000 {
-			errs.Add(fmt.Sprintf("compute.queues[%d].max_count (%d) exceeds maximum of 1000", i, queue.MaxCount))
+			errs.AddField(fmt.Sprintf("compute.queues[%d].max_count", i), fmt.Sprintf("compute.queues[%d].max_count (%d) exceeds maximum of 1000", i, queue.MaxCount))
+		}
+		if queue.StaticCount < 0 {
+			errs.AddField(fmt.Sprintf("compute.queues[%d].static_count", i), fmt.Sprintf("compute.queues[%d].static_count must be >= 0", i))
 		}
+		if queue.StaticCount > queue.MaxCount {
+			errs.AddField(fmt.Sprintf("compute.queues[%d].static_count", i), fmt.Sprintf("compute.queues[%d].static_count (%d) must be <= max_count (%d)", i, queue.StaticCount, queue.MaxCount))
+		}
+
+		validateRoleTags(fmt.Sprintf("compute.queues[%d].tags", i), t.Cluster.Tags, queue.Tags, errs)
+
+		if queue.PlacementGroup {
+			for _, instanceType := range queue.AllInstanceTypes() {
+				if !supportsPlacementGroup(instanceType) {
+					errs.AddField(fmt.Sprintf("compute.queues[%d].placement_group", i), fmt.Sprintf("compute.queues[%d].placement_group is not supported for instance type '%s'", i, instanceType))
+				}
+			}
+		}
+
+		if queue.Image != nil {
+			v.validateQueueImage(t, i, queue, errs)
+		}
+
+		if queue.EFA != nil {
+			validateQueueEFA(i, queue, errs)
+		}
+
+		if queue.CapacityReservation != nil {
+			validateQueueCapacityReservation(t, i, queue, errs)
+		}
+	}
+
+	if t.Compute.ScaledownIdleTime < 0 {
+		errs.AddField("compute.scaledown_idle_time", "compute.scaledown_idle_time must be >= 0")
+	}
+}
+
+// amiIDPattern matches EC2 AMI IDs.
+var amiIDPattern = regexp.MustCompile(`^ami-[0-9a-f]{8}([0-9a-f]{9})?$`)
+
+// validateQueueImage checks that queue i's AMI override can run alongside
+// the head node: ParallelCluster requires the same OS and architecture.
+func (v *Validator) validateQueueImage(t *Template, i int, queue Queue, errs *ValidationError) {
+	image := queue.Image
+
+	if image.CustomAMI == "" {
+		errs.AddField(fmt.Sprintf("compute.queues[%d].image.custom_ami", i), fmt.Sprintf("compute.queues[%d].image.custom_ami is required", i))
+	} else if !amiIDPattern.MatchString(image.CustomAMI) {
+		errs.AddField(fmt.Sprintf("compute.queues[%d].image.custom_ami", i), fmt.Sprintf("compute.queues[%d].image.custom_ami '%s' is not a valid AMI ID (e.g., ami-0123456789abcdef0)", i, image.CustomAMI))
+	}
+
+	if image.OS != "" {
+		if !isSupportedOS(image.OS) {
+			errs.AddField(fmt.Sprintf("compute.queues[%d].image.os", i), fmt.Sprintf("compute.queues[%d].image.os '%s' is not supported (supported: %s)", i, image.OS, strings.Join(SupportedOS, ", ")))
+		} else if image.OS != t.Cluster.GetOS() {
+			errs.AddField(fmt.Sprintf("compute.queues[%d].image.os", i), fmt.Sprintf("compute.queues[%d].image.os '%s' must match cluster.os '%s'; ParallelCluster requires queue AMIs to be built on the cluster OS", i, image.OS, t.Cluster.GetOS()))
+		}
+	}
+
+	if t.Compute.HeadNode == "" {
+		return
+	}
+	headNodeArch := t.Architecture()
+	for _, instanceType := range queue.AllInstanceTypes() {
+		if arch := InstanceArchitecture(instanceType); arch != headNodeArch {
+			errs.AddField(fmt.Sprintf("compute.queues[%d]", i), fmt.Sprintf("compute.queues[%d] instance type '%s' is %s but head node '%s' is %s; a queue image must share the head node's architecture", i, instanceType, arch, t.Compute.HeadNode, headNodeArch))
+		}
+	}
+}
+
+// validateQueueEFA checks that every instance type in queue i supports the
+// EFA features it enables. Instance families pctl does not list are only
+// warned about, by warningIssues.
+func validateQueueEFA(i int, queue Queue, errs *ValidationError) {
+	if queue.EFA.GPUDirectRDMA && !queue.EFA.Enabled {
+		errs.AddField(fmt.Sprintf("compute.queues[%d].efa.gpu_direct_rdma", i), fmt.Sprintf("compute.queues[%d].efa.gpu_direct_rdma requires efa.enabled", i))
+		return
+	}
+	if !queue.EFA.Enabled {
+		return
+	}
+	for _, instanceType := range queue.AllInstanceTypes() {
+		if !efaFamilyListed(instanceType) {
+			continue
+		}
+		if !SupportsEFA(instanceType) {
+			errs.AddField(fmt.Sprintf("compute.queues[%d].efa", i), fmt.Sprintf("compute.queues[%d].efa is not supported for instance type '%s'", i, instanceType))
+		} else if queue.EFA.GPUDirectRDMA && !SupportsGPUDirectRDMA(instanceType) {
+			errs.AddField(fmt.Sprintf("compute.queues[%d].efa.gpu_direct_rdma", i), fmt.Sprintf("compute.queues[%d].efa.gpu_direct_rdma is not supported for instance type '%s' (supported: p4d, p4de, p5, p5e, p5en)", i, instanceType))
+		}
+	}
+}
+
+// capacityReservationGroupPattern matches a resource group ARN, capturing
+// its region.
+var capacityReservationGroupPattern = regexp.MustCompile(`^arn:aws(?:-[a-z]+)*:resource-groups:([a-z0-9-]+):[0-9]{12}:group/[A-Za-z0-9_.-]+$`)
+
+// validateQueueCapacityReservation checks the capacity reservation group
+// targeted by queue i.
+func validateQueueCapacityReservation(t *Template, i int, queue Queue, errs *ValidationError) {
+	prefix := fmt.Sprintf("compute.queues[%d].capacity_reservation.resource_group_arn", i)
+	arn := queue.CapacityReservation.ResourceGroupARN
+	if arn == "" {
+		errs.AddField(prefix, prefix+" is required")
+		return
+	}
+	match := capacityReservationGroupPattern.FindStringSubmatch(arn)
+	if match == nil {
+		errs.AddField(prefix, fmt.Sprintf("%s '%s' must be a resource group ARN (arn:aws:resource-groups:<region>:<account>:group/<name>)", prefix, arn))
+		return
+	}
+	if t.Cluster.Region != "" && match[1] != t.Cluster.Region {
+		errs.AddField(prefix, fmt.Sprintf("%s '%s' is in region %s, but the cluster is in %s", prefix, arn, match[1], t.Cluster.Region))
+	}
+	for _, r := range queue.ComputeResources {
+		if r.GetCapacityType() == CapacityTypeSpot {
+			errs.AddField(fmt.Sprintf("compute.queues[%d].capacity_reservation", i), fmt.Sprintf("compute.queues[%d].capacity_reservation requires on-demand capacity, but the queue uses spot", i))
+			break
+		}
+	}
+}
+
+// computeResourceNamePattern matches compute resource names, which
+// ParallelCluster restricts like queue names.
+var computeResourceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// validateComputeResources validates the compute resources of queue i.
+func (v *Validator) validateComputeResources(i int, queue Queue, errs *ValidationError) {
+	names := make(map[string]bool)
+	capacityType := ""
+	mixedCapacity := false
+
+	for j, r := range queue.ComputeResources {
+		prefix := fmt.Sprintf("compute.queues[%d].compute_resources[%d]", i, j)
+
+		if r.Name == "" {
+			errs.AddField(prefix+".name", prefix+".name is required")
+		} else {
+			if names[r.Name] {
+				errs.AddField(prefix+".name", fmt.Sprintf("%s.name '%s' is duplicate", prefix, r.Name))
+			}
+			names[r.Name] = true
+
+			if !computeResourceNamePattern.MatchString(r.Name) {
+				errs.AddField(prefix+".name", fmt.Sprintf("%s.name '%s' must start with lowercase letter and contain only lowercase letters, numbers, and hyphens", prefix, r.Name))
+			}
+		}
+
+		if len(r.InstanceTypes) == 0 {
+			errs.AddField(prefix+".instance_types", prefix+".instance_types must have at least one instance type")
+		}
+		for k, instanceType := range r.InstanceTypes {
+			if !v.isValidInstanceType(instanceType) {
+				errs.AddField(fmt.Sprintf("%s.instance_types[%d]", prefix, k), fmt.Sprintf("%s.instance_types[%d] '%s' is not a valid instance type format", prefix, k, instanceType))
+			}
+		}
+
+		if r.StaticCount < 0 {
+			errs.AddField(prefix+".static_count", prefix+".static_count must be >= 0")
+		}
+		if r.MaxCount < 0 {
+			errs.AddField(prefix+".max_count", prefix+".max_count must be >= 0")
+		}
+		if r.StaticCount > r.MaxCount {
+			errs.AddField(prefix+".static_count", fmt.Sprintf("%s.static_count (%d) must be <= max_count (%d)", prefix, r.StaticCount, r.MaxCount))
+		}
+
+		switch r.CapacityType {
+		case "", CapacityTypeOnDemand, CapacityTypeSpot:
+		default:
+			errs.AddField(prefix+".capacity_type", fmt.Sprintf("%s.capacity_type '%s' must be one of: %s, %s", prefix, r.CapacityType, CapacityTypeOnDemand, CapacityTypeSpot))
+		}
+		if j == 0 {
+			capacityType = r.GetCapacityType()
+		} else if r.GetCapacityType() != capacityType {
+			mixedCapacity = true
+		}
+	}
+
+	if mixedCapacity {
+		errs.AddField(fmt.Sprintf("compute.queues[%d].compute_resources", i), fmt.Sprintf("compute.queues[%d].compute_resources must all use the same capacity_type (ParallelCluster sets capacity per queue)", i))
+	}
+
+	if queue.MaxNodes() > 1000 {
+		errs.AddField(fmt.Sprintf("compute.queues[%d]", i), fmt.Sprintf("compute.queues[%d] total max_count (%d) exceeds maximum of 1000", i, queue.MaxNodes()))
+	}
+}
+
+// secretARNPattern matches Secrets Manager secret ARNs in any partition.
+var secretARNPattern = regexp.MustCompile(`^arn:aws(-[a-z]+)*:secretsmanager:[a-z0-9-]+:[0-9]{12}:secret:[A-Za-z0-9/_+=.@-]+$`)
+
+// accountingURIPattern matches a database host with an optional port.
+var accountingURIPattern = regexp.MustCompile(`^[a-zA-Z0-9.-]+(:[0-9]{1,5})?$`)
+
+// accountingDatabaseNamePattern matches names slurmdbd accepts for its
+// database.
+var accountingDatabaseNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]{1,64}$`)
+
+func (v *Validator) validateScheduler(t *Template, errs *ValidationError) {
+	accounting := t.Scheduler.Accounting
+	if accounting == nil || !accounting.Enabled {
+		return
+	}
+
+	switch accounting.GetDatabase() {
+	case AccountingDatabaseLocal:
+		// The head node's database is configured entirely by the bootstrap script
+		for _, field := range []struct{ name, value string }{
+			{"uri", accounting.URI},
+			{"user_name", accounting.UserName},
+			{"password_secret_arn", accounting.PasswordSecretARN},
+			{"database_name", accounting.DatabaseName},
+		} {
+			if field.value != "" {
+				errs.AddField("scheduler.accounting."+field.name, fmt.Sprintf("scheduler.accounting.%s is only supported with database: %s", field.name, AccountingDatabaseExternal))
+			}
+		}
+	case AccountingDatabaseExternal:
+		if accounting.URI == "" {
+			errs.AddField("scheduler.accounting.uri", "scheduler.accounting.uri is required for an external database (e.g., slurmdb.abc123.us-east-1.rds.amazonaws.com:3306)")
+		} else if !accountingURIPattern.MatchString(accounting.URI) {
+			errs.AddField("scheduler.accounting.uri", fmt.Sprintf("scheduler.accounting.uri '%s' must be host or host:port, without a scheme", accounting.URI))
+		}
+		if accounting.UserName == "" {
+			errs.AddField("scheduler.accounting.user_name", "scheduler.accounting.user_name is required for an external database")
+		}
+		if accounting.PasswordSecretARN == "" {
+			errs.AddField("scheduler.accounting.password_secret_arn", "scheduler.accounting.password_secret_arn is required for an external database")
+		} else if !secretARNPattern.MatchString(accounting.PasswordSecretARN) {
+			errs.AddField("scheduler.accounting.password_secret_arn", fmt.Sprintf("scheduler.accounting.password_secret_arn '%s' is not a Secrets Manager secret ARN", accounting.PasswordSecretARN))
+		}
+		if accounting.DatabaseName != "" && !accountingDatabaseNamePattern.MatchString(accounting.DatabaseName) {
+			errs.AddField("scheduler.accounting.database_name", fmt.Sprintf("scheduler.accounting.database_name '%s' must be 1-64 letters, numbers, and underscores", accounting.DatabaseName))
+		}
+	default:
+		errs.AddField("scheduler.accounting.database", fmt.Sprintf("scheduler.accounting.database '%s' must be %s or %s", accounting.Database, AccountingDatabaseLocal, AccountingDatabaseExternal))
 	}
 }
 
@@ -176,15 +523,60 @@ func (v *Validator) validateSoftware(t *Template, errs *ValidationError) {
 	if len(t.Software.SpackPackages) > 0 {
 		for i, pkg := range t.Software.SpackPackages {
 			if pkg == "" {
-				errs.Add(fmt.Sprintf("software.spack_packages[%d] cannot be empty", i))
+				errs.AddField(fmt.Sprintf("software.spack_packages[%d]", i), fmt.Sprintf("software.spack_packages[%d] cannot be empty", i))
 			}
-			// Basic validation of package spec format (supports variants, versions, etc.)
-			// Format: name[@version][~variant][+variant][%compiler][...]
-			if !regexp.MustCompile(`^[a-zA-Z0-9_-]+(@[a-zA-Z0-9._-]+)?([~+][a-zA-Z0-9_-]+)*(%[a-zA-Z0-9._@-]+)?$`).MatchString(pkg) {
-				errs.Add(fmt.Sprintf("software.spack_packages[%d] '%s' is not a valid package spec format", i, pkg))
+			if !ValidSpackSpec(pkg) {
+				errs.AddField(fmt.Sprintf("software.spack_packages[%d]", i), fmt.Sprintf("software.spack_packages[%d] '%s' is not a valid package spec format", i, pkg))
 			}
 		}
 	}
+
+	switch t.Software.ModuleSystem {
+	case "", ModuleSystemLmod, ModuleSystemEnvironmentModules:
+	default:
+		errs.AddField("software.module_system", fmt.Sprintf("software.module_system '%s' must be '%s' or '%s'",
+			t.Software.ModuleSystem, ModuleSystemLmod, ModuleSystemEnvironmentModules))
+	}
+
+	if len(t.Software.DefaultModules) > 0 && !t.Software.InstallsModuleSystem() {
+		errs.AddField("software.default_modules", "software.default_modules requires a module system; remove install_lmod: false or the default modules")
+	} else if len(t.Software.DefaultModules) > 0 {
+		// Spack generates one module per package, named after the package
+		available := make(map[string]bool)
+		for _, pkg := range t.Software.SpackPackages {
+			available[SpackPackageName(pkg)] = true
+		}
+
+		for i, mod := range t.Software.DefaultModules {
+			if mod == "" {
+				errs.AddField(fmt.Sprintf("software.default_modules[%d]", i), fmt.Sprintf("software.default_modules[%d] cannot be empty", i))
+				continue
+			}
+			name := strings.SplitN(mod, "/", 2)[0]
+			if !available[name] {
+				errs.AddField(fmt.Sprintf("software.default_modules[%d]", i), fmt.Sprintf("software.default_modules[%d] '%s' does not match any package in software.spack_packages", i, mod))
+			}
+		}
+	}
+}
+
+// spackSpecPattern is a basic check of package spec format (supports variants, versions, etc.)
+// Format: name[@version][~variant][+variant][%compiler][...]
+var spackSpecPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+(@[a-zA-Z0-9._-]+)?([~+][a-zA-Z0-9_-]+)*(%[a-zA-Z0-9._@-]+)?$`)
+
+// ValidSpackSpec reports whether spec is in the Spack package spec format
+// accepted in software.spack_packages.
+func ValidSpackSpec(spec string) bool {
+	return spackSpecPattern.MatchString(spec)
+}
+
+// SpackPackageName returns the package name from a Spack spec
+// (e.g., "openmpi@4.1.4+cuda%gcc@11" -> "openmpi").
+func SpackPackageName(spec string) string {
+	if idx := strings.IndexAny(spec, "@~+% "); idx >= 0 {
+		return spec[:idx]
+	}
+	return spec
 }
 
 func (v *Validator) validateUsers(t *Template, errs *ValidationError) {
@@ -195,41 +587,138 @@ func (v *Validator) validateUsers(t *Template, errs *ValidationError) {
 		for i, user := range t.Users {
 			// Name validation
 			if user.Name == "" {
-				errs.Add(fmt.Sprintf("users[%d].name is required", i))
+				errs.AddField(fmt.Sprintf("users[%d].name", i), fmt.Sprintf("users[%d].name is required", i))
 			} else {
 				if userNames[user.Name] {
-					errs.Add(fmt.Sprintf("users[%d].name '%s' is duplicate", i, user.Name))
+					errs.AddField(fmt.Sprintf("users[%d].name", i), fmt.Sprintf("users[%d].name '%s' is duplicate", i, user.Name))
 				}
 				userNames[user.Name] = true
 
 				if !regexp.MustCompile(`^[a-z_][a-z0-9_-]*$`).MatchString(user.Name) {
-					errs.Add(fmt.Sprintf("users[%d].name '%s' must start with lowercase letter or underscore and contain only lowercase letters, numbers, underscores, and hyphens", i, user.Name))
+					errs.AddField(fmt.Sprintf("users[%d].name", i), fmt.Sprintf("users[%d].name '%s' must start with lowercase letter or underscore and contain only lowercase letters, numbers, underscores, and hyphens", i, user.Name))
 				}
 			}
 
 			// UID validation
 			if user.UID <= 0 {
-				errs.Add(fmt.Sprintf("users[%d].uid must be > 0", i))
+				errs.AddField(fmt.Sprintf("users[%d].uid", i), fmt.Sprintf("users[%d].uid must be > 0", i))
 			} else if user.UID < 1000 {
-				errs.Add(fmt.Sprintf("users[%d].uid %d is in system range (< 1000), recommend using 1000 or higher", i, user.UID))
+				errs.AddField(fmt.Sprintf("users[%d].uid", i), fmt.Sprintf("users[%d].uid %d is in system range (< 1000), recommend using 1000 or higher", i, user.UID))
 			} else if user.UID > 60000 {
-				errs.Add(fmt.Sprintf("users[%d].uid %d exceeds recommended maximum of 60000", i, user.UID))
+				errs.AddField(fmt.Sprintf("users[%d].uid", i), fmt.Sprintf("users[%d].uid %d exceeds recommended maximum of 60000", i, user.UID))
 			}
 			if uids[user.UID] {
-				errs.Add(fmt.Sprintf("users[%d].uid %d is duplicate", i, user.UID))
+				errs.AddField(fmt.Sprintf("users[%d].uid", i), fmt.Sprintf("users[%d].uid %d is duplicate", i, user.UID))
 			}
 			uids[user.UID] = true
 
 			// GID validation
 			if user.GID <= 0 {
-				errs.Add(fmt.Sprintf("users[%d].gid must be > 0", i))
+				errs.AddField(fmt.Sprintf("users[%d].gid", i), fmt.Sprintf("users[%d].gid must be > 0", i))
 			} else if user.GID < 1000 {
-				errs.Add(fmt.Sprintf("users[%d].gid %d is in system range (< 1000), recommend using 1000 or higher", i, user.GID))
+				errs.AddField(fmt.Sprintf("users[%d].gid", i), fmt.Sprintf("users[%d].gid %d is in system range (< 1000), recommend using 1000 or higher", i, user.GID))
 			} else if user.GID > 60000 {
-				errs.Add(fmt.Sprintf("users[%d].gid %d exceeds recommended maximum of 60000", i, user.GID))
+				errs.AddField(fmt.Sprintf("users[%d].gid", i), fmt.Sprintf("users[%d].gid %d exceeds recommended maximum of 60000", i, user.GID))
 			}
+
+			if user.Skeleton != "" {
+				v.validateSkeleton(i, user, errs)
+			}
+		}
+	}
+}
+
+// validateSkeleton checks that a user's skeleton is an S3 URI with a valid
+// bucket or an existing local directory.
+func (v *Validator) validateSkeleton(i int, user User, errs *ValidationError) {
+	if user.SkeletonInS3() {
+		bucket, _, _ := strings.Cut(strings.TrimPrefix(user.Skeleton, skeletonS3Prefix), "/")
+		if !v.isValidS3Bucket(bucket) {
+			errs.AddField(fmt.Sprintf("users[%d].skeleton", i), fmt.Sprintf("users[%d].skeleton '%s' does not name a valid S3 bucket", i, user.Skeleton))
+		}
+		if strings.ContainsAny(user.Skeleton, "'\n") {
+			errs.AddField(fmt.Sprintf("users[%d].skeleton", i), fmt.Sprintf("users[%d].skeleton '%s' must not contain quotes or newlines", i, user.Skeleton))
+		}
+		return
+	}
+
+	info, err := os.Stat(user.Skeleton)
+	if err != nil {
+		errs.AddField(fmt.Sprintf("users[%d].skeleton", i), fmt.Sprintf("users[%d].skeleton '%s' is not an S3 URI or an existing local directory", i, user.Skeleton))
+	} else if !info.IsDir() {
+		errs.AddField(fmt.Sprintf("users[%d].skeleton", i), fmt.Sprintf("users[%d].skeleton '%s' must be a directory", i, user.Skeleton))
+	}
+}
+
+// validateBuild checks that the pre-install script is an S3 URI to a .sh
+// object, an existing local shell script, or an inline shell script, and
+// that the Spack lockfile exists.
+func (v *Validator) validateBuild(t *Template, errs *ValidationError) {
+	script := t.Build.PreInstallScript
+	switch {
+	case script == "":
+	case strings.HasPrefix(script, skeletonS3Prefix):
+		bucket, key, _ := strings.Cut(strings.TrimPrefix(script, skeletonS3Prefix), "/")
+		if !v.isValidS3Bucket(bucket) {
+			errs.AddField("build.pre_install_script", fmt.Sprintf("build.pre_install_script '%s' does not name a valid S3 bucket", script))
+		}
+		if !strings.HasSuffix(key, ".sh") {
+			errs.AddField("build.pre_install_script", fmt.Sprintf("build.pre_install_script '%s' must be a .sh object", script))
+		}
+		if strings.ContainsAny(script, "'\n") {
+			errs.AddField("build.pre_install_script", fmt.Sprintf("build.pre_install_script '%s' must not contain quotes or newlines", script))
+		}
+	case IsInlineScript(script):
+		if err := ValidateShellScript(script); err != nil {
+			errs.AddField("build.pre_install_script", fmt.Sprintf("build.pre_install_script: %v", err))
+		}
+	default:
+		data, err := os.ReadFile(script)
+		if err != nil {
+			errs.AddField("build.pre_install_script", fmt.Sprintf("build.pre_install_script '%s' is not an S3 URI, an inline script, or a readable file", script))
+		} else if err := ValidateShellScript(string(data)); err != nil {
+			errs.AddField("build.pre_install_script", fmt.Sprintf("build.pre_install_script '%s': %v", script, err))
+		}
+	}
+
+	if t.Build.SpackLock != "" {
+		if _, err := os.Stat(t.Build.SpackLock); err != nil {
+			errs.AddField("build.spack_lock", fmt.Sprintf("build.spack_lock '%s' is not a readable file", t.Build.SpackLock))
+		}
+	}
+
+	for i, path := range t.Build.SpackConfig {
+		if _, err := os.Stat(path); err != nil {
+			errs.AddField(fmt.Sprintf("build.spack_config[%d]", i), fmt.Sprintf("build.spack_config[%d] '%s' is not a readable file", i, path))
+		}
+	}
+
+	for i, command := range t.Build.Verify {
+		if strings.TrimSpace(command) == "" {
+			errs.AddField(fmt.Sprintf("build.verify[%d]", i), fmt.Sprintf("build.verify[%d]: command is required", i))
+		} else if strings.Contains(command, "\n") {
+			errs.AddField(fmt.Sprintf("build.verify[%d]", i), fmt.Sprintf("build.verify[%d]: command must be a single line", i))
+		}
+	}
+}
+
+// validateObservability checks the log retention is a period CloudWatch
+// Logs supports.
+func validateObservability(t *Template, errs *ValidationError) {
+	days := t.Observability.LogRetentionDays
+	if days == 0 {
+		return
+	}
+	for _, period := range LogRetentionPeriods {
+		if days == period {
+			return
 		}
 	}
+	periods := make([]string, len(LogRetentionPeriods))
+	for i, period := range LogRetentionPeriods {
+		periods[i] = fmt.Sprint(period)
+	}
+	errs.AddField("observability.log_retention_days", fmt.Sprintf("observability.log_retention_days %d is not supported by CloudWatch Logs (supported: %s)", days, strings.Join(periods, ", ")))
 }
 
 func (v *Validator) validateData(t *Template, errs *ValidationError) {
@@ -239,25 +728,168 @@ func (v *Validator) validateData(t *Template, errs *ValidationError) {
 		for i, mount := range t.Data.S3Mounts {
 			// Bucket validation
 			if mount.Bucket == "" {
-				errs.Add(fmt.Sprintf("data.s3_mounts[%d].bucket is required", i))
+				errs.AddField(fmt.Sprintf("data.s3_mounts[%d].bucket", i), fmt.Sprintf("data.s3_mounts[%d].bucket is required", i))
 			} else if !v.isValidS3Bucket(mount.Bucket) {
-				errs.Add(fmt.Sprintf("data.s3_mounts[%d].bucket '%s' is not a valid S3 bucket name", i, mount.Bucket))
+				errs.AddField(fmt.Sprintf("data.s3_mounts[%d].bucket", i), fmt.Sprintf("data.s3_mounts[%d].bucket '%s' is not a valid S3 bucket name", i, mount.Bucket))
 			}
 
 			// Mount point validation
 			if mount.MountPoint == "" {
-				errs.Add(fmt.Sprintf("data.s3_mounts[%d].mount_point is required", i))
+				errs.AddField(fmt.Sprintf("data.s3_mounts[%d].mount_point", i), fmt.Sprintf("data.s3_mounts[%d].mount_point is required", i))
 			} else {
 				if !filepath.IsAbs(mount.MountPoint) {
-					errs.Add(fmt.Sprintf("data.s3_mounts[%d].mount_point '%s' must be an absolute path", i, mount.MountPoint))
+					errs.AddField(fmt.Sprintf("data.s3_mounts[%d].mount_point", i), fmt.Sprintf("data.s3_mounts[%d].mount_point '%s' must be an absolute path", i, mount.MountPoint))
 				}
 				if mountPoints[mount.MountPoint] {
-					errs.Add(fmt.Sprintf("data.s3_mounts[%d].mount_point '%s' is duplicate", i, mount.MountPoint))
+					errs.AddField(fmt.Sprintf("data.s3_mounts[%d].mount_point", i), fmt.Sprintf("data.s3_mounts[%d].mount_point '%s' is duplicate", i, mount.MountPoint))
 				}
 				mountPoints[mount.MountPoint] = true
 			}
 		}
 	}
+
+	if home := t.Data.PersistentHome; home != nil {
+		v.validatePersistentHome(*home, errs)
+	}
+}
+
+// maxEBSVolumeSize is the largest gp3 volume in GiB.
+const maxEBSVolumeSize = 16384
+
+// persistentHomeNamePattern matches persistent home names, which become
+// part of volume names and tags.
+var persistentHomeNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{0,62}$`)
+
+// ebsVolumeIDPattern matches EBS volume IDs.
+var ebsVolumeIDPattern = regexp.MustCompile(`^vol-[0-9a-f]+$`)
+
+// efsFileSystemIDPattern matches EFS file system IDs.
+var efsFileSystemIDPattern = regexp.MustCompile(`^fs-[0-9a-f]+$`)
+
+func (v *Validator) validatePersistentHome(home PersistentHome, errs *ValidationError) {
+	if home.Name == "" {
+		errs.AddField("data.persistent_home.name", "data.persistent_home.name is required")
+	} else if !persistentHomeNamePattern.MatchString(home.Name) {
+		errs.AddField("data.persistent_home.name", fmt.Sprintf("data.persistent_home.name '%s' must start with a letter and contain only letters, numbers, and hyphens", home.Name))
+	}
+
+	switch home.GetType() {
+	case StorageTypeEBS:
+		if home.ID != "" && !ebsVolumeIDPattern.MatchString(home.ID) {
+			errs.AddField("data.persistent_home.id", fmt.Sprintf("data.persistent_home.id '%s' is not an EBS volume ID (vol-...)", home.ID))
+		}
+		if home.Size < 0 || home.Size > maxEBSVolumeSize {
+			errs.AddField("data.persistent_home.size", fmt.Sprintf("data.persistent_home.size must be between 1 and %d GiB", maxEBSVolumeSize))
+		}
+	case StorageTypeEFS:
+		if home.ID == "" {
+			errs.AddField("data.persistent_home.id", "data.persistent_home.id is required for efs (an existing file system ID)")
+		} else if !efsFileSystemIDPattern.MatchString(home.ID) {
+			errs.AddField("data.persistent_home.id", fmt.Sprintf("data.persistent_home.id '%s' is not an EFS file system ID (fs-...)", home.ID))
+		}
+		if home.Size != 0 {
+			errs.AddField("data.persistent_home.size", "data.persistent_home.size is only supported for ebs")
+		}
+	default:
+		errs.AddField("data.persistent_home.type", fmt.Sprintf("data.persistent_home.type '%s' must be %s or %s", home.Type, StorageTypeEBS, StorageTypeEFS))
+	}
+}
+
+// maxDNSServers is the most DNS servers a VPC DHCP options set accepts.
+const maxDNSServers = 4
+
+// domainNamePattern matches DNS domain names of one or more labels.
+var domainNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+
+func (v *Validator) validateNetwork(t *Template, errs *ValidationError) {
+	if t.Network.DomainName != "" && !domainNamePattern.MatchString(t.Network.DomainName) {
+		errs.AddField("network.domain_name", fmt.Sprintf("network.domain_name '%s' is not a valid domain name", t.Network.DomainName))
+	}
+
+	if len(t.Network.DNSServers) > maxDNSServers {
+		errs.AddField("network.dns_servers", fmt.Sprintf("network.dns_servers can have at most %d servers", maxDNSServers))
+	}
+	for i, server := range t.Network.DNSServers {
+		if server == "AmazonProvidedDNS" {
+			continue
+		}
+		if ip := net.ParseIP(server); ip == nil || ip.To4() == nil {
+			errs.AddField(fmt.Sprintf("network.dns_servers[%d]", i), fmt.Sprintf("network.dns_servers[%d] '%s' must be an IPv4 address or AmazonProvidedDNS", i, server))
+		}
+	}
+
+	if t.Network.Proxy != "" {
+		if u, err := url.Parse(t.Network.Proxy); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.AddField("network.proxy", fmt.Sprintf("network.proxy '%s' must be an http:// or https:// URL (e.g., http://proxy.example.com:3128)", t.Network.Proxy))
+		}
+	} else if len(t.Network.NoProxy) > 0 {
+		errs.AddField("network.no_proxy", "network.no_proxy requires network.proxy")
+	}
+	for i, host := range t.Network.NoProxy {
+		if host == "" || strings.ContainsAny(host, ", ") {
+			errs.AddField(fmt.Sprintf("network.no_proxy[%d]", i), fmt.Sprintf("network.no_proxy[%d] '%s' must be a single host, domain, or CIDR", i, host))
+		}
+	}
+
+	for i, rule := range t.Network.IngressRules {
+		prefix := fmt.Sprintf("network.ingress_rules[%d]", i)
+
+		from, to, err := rule.PortRange()
+		switch {
+		case err != nil:
+			errs.AddField(prefix, fmt.Sprintf("%s: %v", prefix, err))
+		case from < 1 || to > 65535:
+			errs.AddField(prefix, fmt.Sprintf("%s: ports must be between 1 and 65535", prefix))
+		case from > to:
+			errs.AddField(prefix, fmt.Sprintf("%s: port range '%s' starts after it ends", prefix, rule.Port))
+		}
+
+		if protocol := rule.GetProtocol(); protocol != ProtocolTCP && protocol != ProtocolUDP {
+			errs.AddField(prefix, fmt.Sprintf("%s: protocol '%s' must be %s or %s", prefix, rule.Protocol, ProtocolTCP, ProtocolUDP))
+		}
+
+		if ip, _, err := net.ParseCIDR(rule.CIDR); err != nil || ip.To4() == nil {
+			errs.AddField(prefix, fmt.Sprintf("%s: cidr '%s' must be an IPv4 CIDR block (e.g., 10.0.0.0/16)", prefix, rule.CIDR))
+		}
+	}
+}
+
+// iamPolicyARNPattern matches AWS managed and customer managed policy ARNs in
+// any partition.
+var iamPolicyARNPattern = regexp.MustCompile(`^arn:aws(-[a-z]+)*:iam::(aws|[0-9]{12}):policy/[A-Za-z0-9+=,.@_/-]+$`)
+
+func (v *Validator) validateIAM(t *Template, errs *ValidationError) {
+	policies := make(map[string]bool)
+	for i, policy := range t.IAM.AdditionalPolicies {
+		if !iamPolicyARNPattern.MatchString(policy) {
+			errs.AddField(fmt.Sprintf("iam.additional_policies[%d]", i), fmt.Sprintf("iam.additional_policies[%d] '%s' is not an IAM policy ARN (e.g., arn:aws:iam::aws:policy/AmazonDynamoDBReadOnlyAccess)", i, policy))
+		} else if policies[policy] {
+			errs.AddField(fmt.Sprintf("iam.additional_policies[%d]", i), fmt.Sprintf("iam.additional_policies[%d] '%s' is duplicate", i, policy))
+		}
+		policies[policy] = true
+	}
+
+	buckets := make(map[string]bool)
+	for i, access := range t.IAM.S3Access {
+		if access.Bucket == "" {
+			errs.AddField(fmt.Sprintf("iam.s3_access[%d].bucket", i), fmt.Sprintf("iam.s3_access[%d].bucket is required", i))
+		} else if !v.isValidS3Bucket(access.Bucket) {
+			errs.AddField(fmt.Sprintf("iam.s3_access[%d].bucket", i), fmt.Sprintf("iam.s3_access[%d].bucket '%s' is not a valid S3 bucket name", i, access.Bucket))
+		} else if buckets[access.Bucket] {
+			errs.AddField(fmt.Sprintf("iam.s3_access[%d].bucket", i), fmt.Sprintf("iam.s3_access[%d].bucket '%s' is duplicate", i, access.Bucket))
+		}
+		buckets[access.Bucket] = true
+	}
+}
+
+// noPlacementGroupPattern matches the burstable and previous-generation
+// instance families that cannot launch in a cluster placement group.
+var noPlacementGroupPattern = regexp.MustCompile(`^(t[0-9][a-z]*|m1|m2|c1)\.`)
+
+// supportsPlacementGroup reports whether an instance type can be launched in a
+// cluster placement group. Burstable and previous-generation families cannot.
+func supportsPlacementGroup(instanceType string) bool {
+	return !noPlacementGroupPattern.MatchString(instanceType)
 }
 
 func (v *Validator) isValidInstanceType(instanceType string) bool {
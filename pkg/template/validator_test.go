@@ -15,6 +15,9 @@
 package template
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -108,6 +111,50 @@ func TestValidatorClusterValidation(t *testing.T) {
 			},
 			wantErr: []string{"cluster.region 'invalid-region' is not a valid AWS region"},
 		},
+		{
+			name: "supported os",
+			tmpl: Template{
+				Cluster: ClusterConfig{
+					Name:   "test-cluster",
+					Region: "us-east-1",
+					OS:     "ubuntu2204",
+				},
+				Compute: ComputeConfig{
+					HeadNode: "t3.medium",
+					Queues: []Queue{
+						{
+							Name:          "compute",
+							InstanceTypes: []string{"c5.xlarge"},
+							MinCount:      0,
+							MaxCount:      10,
+						},
+					},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "unsupported os",
+			tmpl: Template{
+				Cluster: ClusterConfig{
+					Name:   "test-cluster",
+					Region: "us-east-1",
+					OS:     "centos7",
+				},
+				Compute: ComputeConfig{
+					HeadNode: "t3.medium",
+					Queues: []Queue{
+						{
+							Name:          "compute",
+							InstanceTypes: []string{"c5.xlarge"},
+							MinCount:      0,
+							MaxCount:      10,
+						},
+					},
+				},
+			},
+			wantErr: []string{"cluster.os 'centos7' is not supported"},
+		},
 	}
 
 	validator := NewValidator()
@@ -240,6 +287,12 @@ func TestValidatorComputeValidation(t *testing.T) {
 }
 
 func TestValidatorUsersValidation(t *testing.T) {
+	skelDir := t.TempDir()
+	skelFile := filepath.Join(skelDir, ".bashrc")
+	if err := os.WriteFile(skelFile, []byte("module load gcc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
 	tests := []struct {
 		name    string
 		users   []User
@@ -283,6 +336,35 @@ func TestValidatorUsersValidation(t *testing.T) {
 			},
 			wantErr: []string{"uid 500 is in system range"},
 		},
+		{
+			name: "skeletons from S3 and a local directory",
+			users: []User{
+				{Name: "user1", UID: 5001, GID: 5001, Skeleton: "s3://lab-skel/home"},
+				{Name: "user2", UID: 5002, GID: 5002, Skeleton: skelDir},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "skeleton with invalid bucket",
+			users: []User{
+				{Name: "user1", UID: 5001, GID: 5001, Skeleton: "s3://Lab_Skel/home"},
+			},
+			wantErr: []string{"skeleton 's3://Lab_Skel/home' does not name a valid S3 bucket"},
+		},
+		{
+			name: "missing skeleton directory",
+			users: []User{
+				{Name: "user1", UID: 5001, GID: 5001, Skeleton: filepath.Join(skelDir, "missing")},
+			},
+			wantErr: []string{"is not an S3 URI or an existing local directory"},
+		},
+		{
+			name: "skeleton is a file",
+			users: []User{
+				{Name: "user1", UID: 5001, GID: 5001, Skeleton: skelFile},
+			},
+			wantErr: []string{"must be a directory"},
+		},
 	}
 
 	validator := NewValidator()
@@ -443,3 +525,909 @@ func TestValidationErrorMultiple(t *testing.T) {
 		}
 	}
 }
+
+func TestValidatorSoftwareModules(t *testing.T) {
+	base := func(software SoftwareConfig) Template {
+		return Template{
+			Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+			Compute: ComputeConfig{
+				HeadNode: "t3.medium",
+				Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+			},
+			Software: software,
+		}
+	}
+	skipLmod := false
+
+	tests := []struct {
+		name    string
+		tmpl    Template
+		wantErr []string
+	}{
+		{
+			name:    "environment-modules",
+			tmpl:    base(SoftwareConfig{SpackPackages: []string{"gcc@11.3.0"}, ModuleSystem: "environment-modules"}),
+			wantErr: nil,
+		},
+		{
+			name:    "unknown module system",
+			tmpl:    base(SoftwareConfig{SpackPackages: []string{"gcc@11.3.0"}, ModuleSystem: "spack-env"}),
+			wantErr: []string{"software.module_system 'spack-env' must be 'lmod' or 'environment-modules'"},
+		},
+		{
+			name: "default modules match packages",
+			tmpl: base(SoftwareConfig{
+				SpackPackages:  []string{"gcc@11.3.0", "openmpi@4.1.4+cuda%gcc@11.3.0"},
+				DefaultModules: []string{"gcc", "openmpi/4.1.4"},
+			}),
+			wantErr: nil,
+		},
+		{
+			name: "default module not installed",
+			tmpl: base(SoftwareConfig{
+				SpackPackages:  []string{"gcc@11.3.0"},
+				DefaultModules: []string{"gcc", "python"},
+			}),
+			wantErr: []string{"software.default_modules[1] 'python' does not match any package"},
+		},
+		{
+			name: "empty default module",
+			tmpl: base(SoftwareConfig{
+				SpackPackages:  []string{"gcc@11.3.0"},
+				DefaultModules: []string{""},
+			}),
+			wantErr: []string{"software.default_modules[0] cannot be empty"},
+		},
+		{
+			name: "default modules without Lmod",
+			tmpl: base(SoftwareConfig{
+				SpackPackages:  []string{"gcc@11.3.0"},
+				DefaultModules: []string{"gcc"},
+				InstallLmod:    &skipLmod,
+			}),
+			wantErr: []string{"software.default_modules requires a module system"},
+		},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateTemplate(&tt.tmpl)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("ValidateTemplate() expected error containing %v, got nil", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("ValidateTemplate() error = %v, want error containing %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSpackPackageName(t *testing.T) {
+	tests := map[string]string{
+		"gcc":                           "gcc",
+		"gcc@11.3.0":                    "gcc",
+		"openmpi@4.1.4+cuda%gcc@11.3.0": "openmpi",
+		"hdf5~mpi":                      "hdf5",
+		"py-numpy":                      "py-numpy",
+	}
+	for spec, want := range tests {
+		if got := SpackPackageName(spec); got != want {
+			t.Errorf("SpackPackageName(%q) = %q, want %q", spec, got, want)
+		}
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{"project", "genomics", false},
+		{"cost-center", "", false},
+		{"", "value", true},
+		{strings.Repeat("k", 129), "value", true},
+		{"project", strings.Repeat("v", 257), true},
+		{strings.Repeat("é", 128), strings.Repeat("ü", 256), false},
+		{strings.Repeat("é", 129), "value", true},
+		{"aws:createdBy", "me", true},
+		{"parallelcluster:version", "3", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateTag(tt.key, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateTag(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidatorStaticCountAndScaledown(t *testing.T) {
+	base := func(queue Queue, scaledown int) *Template {
+		return &Template{
+			Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+			Compute: ComputeConfig{
+				HeadNode:          "t3.medium",
+				Queues:            []Queue{queue},
+				ScaledownIdleTime: scaledown,
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    *Template
+		wantErr string
+	}{
+		{"valid static count", base(Queue{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, StaticCount: 2, MaxCount: 10}, 10), ""},
+		{"static equals max", base(Queue{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, StaticCount: 10, MaxCount: 10}, 0), ""},
+		{"static exceeds max", base(Queue{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, StaticCount: 11, MaxCount: 10}, 0), "static_count (11) must be <= max_count (10)"},
+		{"negative static", base(Queue{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, StaticCount: -1, MaxCount: 10}, 0), "static_count must be >= 0"},
+		{"negative scaledown", base(Queue{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}, -5), "scaledown_idle_time must be >= 0"},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateTemplate(tt.tmpl)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorNetwork(t *testing.T) {
+	base := func(network NetworkConfig) *Template {
+		return &Template{
+			Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+			Compute: ComputeConfig{
+				HeadNode: "t3.medium",
+				Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+			},
+			Network: network,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    *Template
+		wantErr string
+	}{
+		{"no network settings", base(NetworkConfig{}), ""},
+		{"valid domain and servers", base(NetworkConfig{DomainName: "hpc.example.com", DNSServers: []string{"10.0.0.2", "AmazonProvidedDNS"}}), ""},
+		{"invalid domain", base(NetworkConfig{DomainName: "bad_domain..com"}), "network.domain_name"},
+		{"invalid server", base(NetworkConfig{DNSServers: []string{"dns.example.com"}}), "network.dns_servers[0]"},
+		{"ipv6 server", base(NetworkConfig{DNSServers: []string{"fd00::2"}}), "must be an IPv4 address"},
+		{"too many servers", base(NetworkConfig{DNSServers: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}}), "at most 4 servers"},
+		{"multiple ingress rules", base(NetworkConfig{IngressRules: []IngressRule{
+			{Port: "8888", CIDR: "10.0.0.0/16", Description: "Jupyter"},
+			{Port: "27000-27009", Protocol: "tcp", CIDR: "192.168.1.0/24"},
+			{Port: "2049", Protocol: "udp", CIDR: "0.0.0.0/0"},
+		}}), ""},
+		{"ingress port not a number", base(NetworkConfig{IngressRules: []IngressRule{{Port: "http", CIDR: "10.0.0.0/16"}}}), "network.ingress_rules[0]: invalid port 'http'"},
+		{"ingress port missing", base(NetworkConfig{IngressRules: []IngressRule{{CIDR: "10.0.0.0/16"}}}), "invalid port ''"},
+		{"ingress port out of range", base(NetworkConfig{IngressRules: []IngressRule{{Port: "70000", CIDR: "10.0.0.0/16"}}}), "between 1 and 65535"},
+		{"ingress port zero", base(NetworkConfig{IngressRules: []IngressRule{{Port: "0-80", CIDR: "10.0.0.0/16"}}}), "between 1 and 65535"},
+		{"ingress range reversed", base(NetworkConfig{IngressRules: []IngressRule{{Port: "9000-8000", CIDR: "10.0.0.0/16"}}}), "starts after it ends"},
+		{"ingress bad range", base(NetworkConfig{IngressRules: []IngressRule{{Port: "8000-", CIDR: "10.0.0.0/16"}}}), "invalid port range '8000-'"},
+		{"ingress bad protocol", base(NetworkConfig{IngressRules: []IngressRule{{Port: "22", Protocol: "icmp", CIDR: "10.0.0.0/16"}}}), "protocol 'icmp' must be tcp or udp"},
+		{"ingress bare ip", base(NetworkConfig{IngressRules: []IngressRule{{Port: "22", CIDR: "10.0.0.1"}}}), "cidr '10.0.0.1' must be an IPv4 CIDR block"},
+		{"ingress ipv6 cidr", base(NetworkConfig{IngressRules: []IngressRule{{Port: "22", CIDR: "fd00::/8"}}}), "must be an IPv4 CIDR block"},
+		{"second ingress rule invalid", base(NetworkConfig{IngressRules: []IngressRule{{Port: "22", CIDR: "10.0.0.0/16"}, {Port: "22", CIDR: ""}}}), "network.ingress_rules[1]"},
+		{"proxy", base(NetworkConfig{Proxy: "http://proxy.example.com:3128", NoProxy: []string{".corp.example.com", "10.10.0.0/16"}}), ""},
+		{"proxy without scheme", base(NetworkConfig{Proxy: "proxy.example.com:3128"}), "network.proxy 'proxy.example.com:3128' must be an http:// or https:// URL"},
+		{"proxy socks", base(NetworkConfig{Proxy: "socks5://proxy.example.com:1080"}), "must be an http:// or https:// URL"},
+		{"no_proxy without proxy", base(NetworkConfig{NoProxy: []string{".corp.example.com"}}), "network.no_proxy requires network.proxy"},
+		{"no_proxy list in one entry", base(NetworkConfig{Proxy: "http://proxy.example.com:3128", NoProxy: []string{"a.example.com,b.example.com"}}), "network.no_proxy[0]"},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateTemplate(tt.tmpl)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorPlacementGroup(t *testing.T) {
+	base := func(instanceTypes ...string) *Template {
+		return &Template{
+			Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+			Compute: ComputeConfig{
+				HeadNode: "t3.medium",
+				Queues:   []Queue{{Name: "mpi", InstanceTypes: instanceTypes, MaxCount: 16, PlacementGroup: true}},
+			},
+		}
+	}
+
+	validator := NewValidator()
+
+	if err := validator.ValidateTemplate(base("c5n.18xlarge", "c6in.32xlarge")); err != nil {
+		t.Errorf("ValidateTemplate() unexpected error = %v", err)
+	}
+
+	err := validator.ValidateTemplate(base("c5n.18xlarge", "t3.large"))
+	if err == nil || !strings.Contains(err.Error(), "placement_group is not supported for instance type 't3.large'") {
+		t.Errorf("ValidateTemplate() error = %v, want placement group error for t3.large", err)
+	}
+}
+
+func TestValidatorComputeResources(t *testing.T) {
+	base := func(queue Queue) *Template {
+		return &Template{
+			Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+			Compute: ComputeConfig{HeadNode: "t3.medium", Queues: []Queue{queue}},
+		}
+	}
+	cpu := ComputeResource{Name: "cpu", InstanceTypes: []string{"c5.2xlarge"}, StaticCount: 1, MaxCount: 8}
+	gpu := ComputeResource{Name: "gpu", InstanceTypes: []string{"g5.xlarge"}, MaxCount: 4}
+
+	tests := []struct {
+		name    string
+		queue   Queue
+		wantErr string
+	}{
+		{"valid", Queue{Name: "mixed", ComputeResources: []ComputeResource{cpu, gpu}}, ""},
+		{"both forms", Queue{Name: "mixed", InstanceTypes: []string{"c5.xlarge"}, ComputeResources: []ComputeResource{cpu}}, "cannot set both instance_types and compute_resources"},
+		{"queue counts", Queue{Name: "mixed", MaxCount: 10, ComputeResources: []ComputeResource{cpu}}, "node counts must be set on each compute resource"},
+		{"duplicate name", Queue{Name: "mixed", ComputeResources: []ComputeResource{cpu, cpu}}, "compute_resources[1].name 'cpu' is duplicate"},
+		{"missing instance types", Queue{Name: "mixed", ComputeResources: []ComputeResource{{Name: "cpu", MaxCount: 4}}}, "compute_resources[0].instance_types must have at least one"},
+		{"static exceeds max", Queue{Name: "mixed", ComputeResources: []ComputeResource{{Name: "cpu", InstanceTypes: []string{"c5.xlarge"}, StaticCount: 5, MaxCount: 4}}}, "static_count (5) must be <= max_count (4)"},
+		{"invalid capacity type", Queue{Name: "mixed", ComputeResources: []ComputeResource{{Name: "cpu", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 4, CapacityType: "reserved"}}}, "capacity_type 'reserved'"},
+		{"mixed capacity types", Queue{Name: "mixed", ComputeResources: []ComputeResource{cpu, {Name: "gpu", InstanceTypes: []string{"g5.xlarge"}, MaxCount: 4, CapacityType: CapacityTypeSpot}}}, "must all use the same capacity_type"},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateTemplate(base(tt.queue))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorQueueImage(t *testing.T) {
+	base := func(image *QueueImage, instanceTypes ...string) *Template {
+		return &Template{
+			Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1", OS: "rocky9"},
+			Compute: ComputeConfig{
+				HeadNode: "t3.medium",
+				Queues:   []Queue{{Name: "gpu", InstanceTypes: instanceTypes, MaxCount: 4, Image: image}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		image         *QueueImage
+		instanceTypes []string
+		wantErr       string
+	}{
+		{"valid", &QueueImage{CustomAMI: "ami-0123456789abcdef0", OS: "rocky9"}, []string{"g5.xlarge"}, ""},
+		{"short AMI ID", &QueueImage{CustomAMI: "ami-12345678"}, []string{"g5.xlarge"}, ""},
+		{"missing AMI", &QueueImage{OS: "rocky9"}, []string{"g5.xlarge"}, "image.custom_ami is required"},
+		{"invalid AMI", &QueueImage{CustomAMI: "rocky-gpu"}, []string{"g5.xlarge"}, "'rocky-gpu' is not a valid AMI ID"},
+		{"unsupported OS", &QueueImage{CustomAMI: "ami-0123456789abcdef0", OS: "windows"}, []string{"g5.xlarge"}, "image.os 'windows' is not supported"},
+		{"OS mismatch", &QueueImage{CustomAMI: "ami-0123456789abcdef0", OS: "ubuntu2204"}, []string{"g5.xlarge"}, "must match cluster.os 'rocky9'"},
+		{"architecture mismatch", &QueueImage{CustomAMI: "ami-0123456789abcdef0"}, []string{"c7g.xlarge"}, "instance type 'c7g.xlarge' is arm64 but head node 't3.medium' is x86_64"},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateTemplate(base(tt.image, tt.instanceTypes...))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorQueueEFA(t *testing.T) {
+	base := func(efa *QueueEFA, instanceTypes ...string) *Template {
+		return &Template{
+			Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+			Compute: ComputeConfig{
+				HeadNode: "t3.medium",
+				Queues:   []Queue{{Name: "hpc", InstanceTypes: instanceTypes, MaxCount: 4, PlacementGroup: true, EFA: efa}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		efa           *QueueEFA
+		instanceTypes []string
+		wantErr       string
+	}{
+		{"EFA", &QueueEFA{Enabled: true}, []string{"c5n.18xlarge", "hpc6a.48xlarge"}, ""},
+		{"GPUDirect RDMA", &QueueEFA{Enabled: true, GPUDirectRDMA: true}, []string{"p4d.24xlarge", "p5.48xlarge"}, ""},
+		{"disabled on any type", &QueueEFA{}, []string{"m5.large"}, ""},
+		{"EFA unsupported size", &QueueEFA{Enabled: true}, []string{"c5n.xlarge"}, "efa is not supported for instance type 'c5n.xlarge'"},
+		{"EFA unlisted family", &QueueEFA{Enabled: true}, []string{"c5n.18xlarge", "c8gn.48xlarge"}, ""},
+		{"GDR on CPU instance", &QueueEFA{Enabled: true, GPUDirectRDMA: true}, []string{"c5n.18xlarge"}, "efa.gpu_direct_rdma is not supported for instance type 'c5n.18xlarge'"},
+		{"GDR on non-GDR GPU", &QueueEFA{Enabled: true, GPUDirectRDMA: true}, []string{"g5.48xlarge"}, "efa.gpu_direct_rdma is not supported for instance type 'g5.48xlarge'"},
+		{"GDR without EFA", &QueueEFA{GPUDirectRDMA: true}, []string{"p5.48xlarge"}, "gpu_direct_rdma requires efa.enabled"},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateTemplate(base(tt.efa, tt.instanceTypes...))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorQueueCapacityReservation(t *testing.T) {
+	base := func(groupARN, capacityType string) *Template {
+		return &Template{
+			Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+			Compute: ComputeConfig{
+				HeadNode: "t3.medium",
+				Queues: []Queue{{
+					Name: "gpu",
+					ComputeResources: []ComputeResource{
+						{Name: "p5", InstanceTypes: []string{"p5.48xlarge"}, MaxCount: 2, CapacityType: capacityType},
+					},
+					CapacityReservation: &QueueCapacityReservation{ResourceGroupARN: groupARN},
+				}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		groupARN     string
+		capacityType string
+		wantErr      string
+	}{
+		{"valid", "arn:aws:resource-groups:us-east-1:123456789012:group/gpu-reservations", "", ""},
+		{"GovCloud partition", "arn:aws-us-gov:resource-groups:us-east-1:123456789012:group/gpu", CapacityTypeOnDemand, ""},
+		{"missing", "", "", "capacity_reservation.resource_group_arn is required"},
+		{"reservation ID", "cr-0123456789abcdef0", "", "must be a resource group ARN"},
+		{"other service", "arn:aws:ec2:us-east-1:123456789012:capacity-reservation/cr-0123456789abcdef0", "", "must be a resource group ARN"},
+		{"short account", "arn:aws:resource-groups:us-east-1:1234:group/gpu", "", "must be a resource group ARN"},
+		{"other region", "arn:aws:resource-groups:us-west-2:123456789012:group/gpu", "", "is in region us-west-2, but the cluster is in us-east-1"},
+		{"spot", "arn:aws:resource-groups:us-east-1:123456789012:group/gpu", CapacityTypeSpot, "capacity_reservation requires on-demand capacity"},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateTemplate(base(tt.groupARN, tt.capacityType))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorObservability(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    int
+		wantErr bool
+	}{
+		{"unset", 0, false},
+		{"shortest", 1, false},
+		{"supported", 30, false},
+		{"longest", 3653, false},
+		{"unsupported", 10, true},
+		{"negative", -7, true},
+		{"too long", 4000, true},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := &Template{
+				Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+				Compute: ComputeConfig{
+					HeadNode: "t3.medium",
+					Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+				},
+				Observability: ObservabilityConfig{LogRetentionDays: tt.days},
+			}
+			err := validator.ValidateTemplate(tmpl)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "observability.log_retention_days") {
+					t.Errorf("ValidateTemplate() error = %v, want log_retention_days error", err)
+				}
+			} else if err != nil {
+				t.Errorf("ValidateTemplate() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidatorRoleTags(t *testing.T) {
+	base := func(clusterTags, headNodeTags, queueTags map[string]string) *Template {
+		return &Template{
+			Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1", Tags: clusterTags},
+			Compute: ComputeConfig{
+				HeadNode:     "t3.medium",
+				HeadNodeTags: headNodeTags,
+				Queues:       []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10, Tags: queueTags}},
+			},
+		}
+	}
+
+	manyTags := func(prefix string, n int) map[string]string {
+		tags := make(map[string]string, n)
+		for i := 0; i < n; i++ {
+			tags[fmt.Sprintf("%s-%d", prefix, i)] = "x"
+		}
+		return tags
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    *Template
+		wantErr string
+	}{
+		{"role tags", base(map[string]string{"project": "bio"}, map[string]string{"role": "head"}, map[string]string{"role": "compute"}), ""},
+		{"overrides share limit", base(manyTags("t", 50), manyTags("t", 10), nil), ""},
+		{"reserved head node key", base(nil, map[string]string{"aws:role": "head"}, nil), "compute.head_node_tags: tag key 'aws:role' uses a reserved prefix"},
+		{"long queue value", base(nil, nil, map[string]string{"role": strings.Repeat("x", 257)}), "compute.queues[0].tags: tag 'role' value must be 256 characters or less"},
+		{"head node over limit", base(manyTags("cluster", 45), manyTags("head", 6), nil), "compute.head_node_tags: 51 tags with cluster.tags exceeds the AWS limit of 50"},
+		{"queue over limit", base(manyTags("cluster", 40), nil, manyTags("queue", 11)), "compute.queues[0].tags: 51 tags"},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateTemplate(tt.tmpl)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorMetadata(t *testing.T) {
+	tmpl := &Template{
+		Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+		Compute: ComputeConfig{
+			HeadNode: "t3.medium",
+			Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+		},
+		Metadata: map[string]string{"owner": "alice", "project": "genomics"},
+	}
+
+	validator := NewValidator()
+	if err := validator.ValidateTemplate(tmpl); err != nil {
+		t.Errorf("ValidateTemplate() unexpected error = %v", err)
+	}
+
+	tmpl.Metadata["aws:owner"] = "alice"
+	err := validator.ValidateTemplate(tmpl)
+	if err == nil || !strings.Contains(err.Error(), "metadata: tag key 'aws:owner' uses a reserved prefix") {
+		t.Errorf("ValidateTemplate() error = %v, want reserved metadata key", err)
+	}
+}
+
+func TestValidatorPersistentHome(t *testing.T) {
+	tests := []struct {
+		name    string
+		home    PersistentHome
+		wantErr string
+	}{
+		{name: "new ebs volume", home: PersistentHome{Name: "lab-home"}},
+		{name: "existing ebs volume", home: PersistentHome{Name: "lab-home", ID: "vol-0123abcd"}},
+		{name: "existing efs", home: PersistentHome{Name: "lab-home", Type: "efs", ID: "fs-0123abcd"}},
+		{name: "missing name", home: PersistentHome{}, wantErr: "data.persistent_home.name is required"},
+		{name: "invalid name", home: PersistentHome{Name: "lab home"}, wantErr: "name 'lab home' must start with a letter"},
+		{name: "invalid type", home: PersistentHome{Name: "lab-home", Type: "fsx"}, wantErr: "type 'fsx' must be ebs or efs"},
+		{name: "efs without id", home: PersistentHome{Name: "lab-home", Type: "efs"}, wantErr: "id is required for efs"},
+		{name: "efs with volume id", home: PersistentHome{Name: "lab-home", Type: "efs", ID: "vol-0123"}, wantErr: "not an EFS file system ID"},
+		{name: "efs with size", home: PersistentHome{Name: "lab-home", Type: "efs", ID: "fs-0123", Size: 10}, wantErr: "size is only supported for ebs"},
+		{name: "ebs with file system id", home: PersistentHome{Name: "lab-home", ID: "fs-0123"}, wantErr: "not an EBS volume ID"},
+		{name: "ebs too large", home: PersistentHome{Name: "lab-home", Size: 20000}, wantErr: "size must be between 1 and 16384 GiB"},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := tt.home
+			tmpl := Template{
+				Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+				Compute: ComputeConfig{
+					HeadNode: "t3.medium",
+					Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+				},
+				Data: DataConfig{PersistentHome: &home},
+			}
+			err := validator.ValidateTemplate(&tmpl)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorIAM(t *testing.T) {
+	tests := []struct {
+		name    string
+		iam     IAMConfig
+		wantErr string
+	}{
+		{
+			name: "valid",
+			iam: IAMConfig{
+				AdditionalPolicies: []string{
+					"arn:aws:iam::aws:policy/AmazonDynamoDBReadOnlyAccess",
+					"arn:aws:iam::123456789012:policy/team/SecretsRead",
+					"arn:aws-us-gov:iam::aws:policy/SecretsManagerReadWrite",
+				},
+				S3Access: []S3Access{{Bucket: "results", EnableWrite: true}, {Bucket: "reference"}},
+			},
+		},
+		{
+			name:    "role ARN",
+			iam:     IAMConfig{AdditionalPolicies: []string{"arn:aws:iam::123456789012:role/MyRole"}},
+			wantErr: "additional_policies[0] 'arn:aws:iam::123456789012:role/MyRole' is not an IAM policy ARN",
+		},
+		{
+			name:    "policy name",
+			iam:     IAMConfig{AdditionalPolicies: []string{"AmazonS3FullAccess"}},
+			wantErr: "is not an IAM policy ARN",
+		},
+		{
+			name: "duplicate policy",
+			iam: IAMConfig{AdditionalPolicies: []string{
+				"arn:aws:iam::aws:policy/AmazonS3FullAccess",
+				"arn:aws:iam::aws:policy/AmazonS3FullAccess",
+			}},
+			wantErr: "additional_policies[1] 'arn:aws:iam::aws:policy/AmazonS3FullAccess' is duplicate",
+		},
+		{
+			name:    "missing bucket",
+			iam:     IAMConfig{S3Access: []S3Access{{EnableWrite: true}}},
+			wantErr: "iam.s3_access[0].bucket is required",
+		},
+		{
+			name:    "invalid bucket",
+			iam:     IAMConfig{S3Access: []S3Access{{Bucket: "My_Bucket"}}},
+			wantErr: "iam.s3_access[0].bucket 'My_Bucket' is not a valid S3 bucket name",
+		},
+		{
+			name:    "duplicate bucket",
+			iam:     IAMConfig{S3Access: []S3Access{{Bucket: "results"}, {Bucket: "results", EnableWrite: true}}},
+			wantErr: "iam.s3_access[1].bucket 'results' is duplicate",
+		},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := Template{
+				Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+				Compute: ComputeConfig{
+					HeadNode: "t3.medium",
+					Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+				},
+				IAM: tt.iam,
+			}
+			err := validator.ValidateTemplate(&tmpl)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorInstanceTypes(t *testing.T) {
+	valid := []string{
+		"t3.medium", "t4g.nano", "c5.xlarge", "c5.metal", "m5.24xlarge", "c7i.48xlarge",
+		"g4dn.xlarge", "g5g.16xlarge", "p4de.24xlarge", "hpc7g.16xlarge", "x2iedn.32xlarge",
+		"m7i-flex.large", "c7i.metal-24xl", "i4i.metal", "is4gen.medium", "trn1n.32xlarge",
+		"inf2.xlarge", "mac2-m2pro.metal", "u-6tb1.metal", "u7i-12tb.224xlarge",
+	}
+	invalid := []string{
+		"foo.bar", "5c.large", "c5.", "c5", ".large", "c5.large.", "C5.large",
+		"c5.1xlarge", "c5.02xlarge", "c5.hugelarge", "c5.metalx", "invalid-type", "c5_large",
+	}
+
+	v := NewValidator()
+	for _, instanceType := range valid {
+		if !v.isValidInstanceType(instanceType) {
+			t.Errorf("isValidInstanceType(%q) = false, want true", instanceType)
+		}
+	}
+	for _, instanceType := range invalid {
+		if v.isValidInstanceType(instanceType) {
+			t.Errorf("isValidInstanceType(%q) = true, want false", instanceType)
+		}
+	}
+}
+
+func TestValidatorBuild(t *testing.T) {
+	dir := t.TempDir()
+	shell := filepath.Join(dir, "setup.sh")
+	if err := os.WriteFile(shell, []byte("#!/bin/bash\ndnf install -y python3.11\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	perl := filepath.Join(dir, "setup.pl")
+	if err := os.WriteFile(perl, []byte("#!/usr/bin/perl\nprint 1;\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		lock    string
+		verify  []string
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "s3", script: "s3://my-bucket/scripts/setup.sh"},
+		{name: "inline", script: "#!/usr/bin/env sh\nsysctl -w vm.swappiness=10\n"},
+		{name: "local", script: shell},
+		{name: "s3 invalid bucket", script: "s3://My_Bucket/setup.sh", wantErr: "does not name a valid S3 bucket"},
+		{name: "s3 not a shell script", script: "s3://my-bucket/setup.py", wantErr: "must be a .sh object"},
+		{name: "inline python", script: "#!/usr/bin/python3\nprint(1)\n", wantErr: "must be a shell script"},
+		{name: "local perl", script: perl, wantErr: "must be a shell script"},
+		{name: "missing file", script: filepath.Join(dir, "missing.sh"), wantErr: "is not an S3 URI, an inline script, or a readable file"},
+		{name: "spack lock", lock: shell},
+		{name: "missing spack lock", lock: filepath.Join(dir, "missing.lock"), wantErr: "build.spack_lock"},
+		{name: "verify", verify: []string{"gmx --version", "python3 -c 'import numpy'"}},
+		{name: "verify empty command", verify: []string{"gmx --version", "  "}, wantErr: "build.verify[1]: command is required"},
+		{name: "verify multiline command", verify: []string{"module load gromacs\ngmx --version"}, wantErr: "build.verify[0]: command must be a single line"},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := Template{
+				Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+				Compute: ComputeConfig{
+					HeadNode: "t3.medium",
+					Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+				},
+				Build: BuildConfig{PreInstallScript: tt.script, SpackLock: tt.lock, Verify: tt.verify},
+			}
+			err := validator.ValidateTemplate(&tmpl)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorAlwaysOnWarnings(t *testing.T) {
+	base := func(queues ...Queue) *Template {
+		return &Template{
+			Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+			Compute: ComputeConfig{HeadNode: "t3.medium", Queues: queues},
+		}
+	}
+	prices := func(region, instanceType string) (float64, error) {
+		switch instanceType {
+		case "c5.xlarge":
+			return 0.17, nil
+		case "g4dn.xlarge":
+			return 0.526, nil
+		}
+		return 0, fmt.Errorf("no price for %s", instanceType)
+	}
+
+	tests := []struct {
+		name  string
+		tmpl  *Template
+		price PriceFunc
+		want  []string
+	}{
+		{
+			name: "scale to zero",
+			tmpl: base(Queue{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}),
+		},
+		{
+			name:  "min count",
+			tmpl:  base(Queue{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MinCount: 2, MaxCount: 10}),
+			price: prices,
+			want:  []string{`queue "compute" keeps 2 node(s) running 24/7 (about $248/month while idle)`},
+		},
+		{
+			name:  "static count",
+			tmpl:  base(Queue{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, StaticCount: 1, MaxCount: 10}),
+			price: prices,
+			want:  []string{`queue "compute" keeps 1 node(s) running 24/7 (about $124/month while idle)`},
+		},
+		{
+			name: "compute resources",
+			tmpl: base(Queue{Name: "mixed", ComputeResources: []ComputeResource{
+				{Name: "cpu", InstanceTypes: []string{"c5.xlarge"}, StaticCount: 1, MaxCount: 4},
+				{Name: "gpu", InstanceTypes: []string{"g4dn.xlarge"}, StaticCount: 1, MaxCount: 2},
+			}}),
+			price: prices,
+			want:  []string{`queue "mixed" keeps 2 node(s) running 24/7 (about $508/month while idle)`},
+		},
+		{
+			name:  "unknown price",
+			tmpl:  base(Queue{Name: "compute", InstanceTypes: []string{"m5.large"}, MinCount: 1, MaxCount: 10}),
+			price: prices,
+			want:  []string{`queue "compute" keeps 1 node(s) running 24/7; set min_count`},
+		},
+		{
+			name: "no price lookup",
+			tmpl: base(
+				Queue{Name: "always", InstanceTypes: []string{"c5.xlarge"}, MinCount: 1, MaxCount: 10},
+				Queue{Name: "burst", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10},
+			),
+			want: []string{`queue "always" keeps 1 node(s) running 24/7; set min_count`},
+		},
+		{
+			name: "EFA without placement group",
+			tmpl: base(
+				Queue{Name: "mpi", InstanceTypes: []string{"c5n.18xlarge"}, MaxCount: 10, EFA: &QueueEFA{Enabled: true}},
+				Queue{Name: "placed", InstanceTypes: []string{"c5n.18xlarge"}, MaxCount: 10, PlacementGroup: true, EFA: &QueueEFA{Enabled: true}},
+			),
+			want: []string{`queue "mpi" uses EFA without placement_group`},
+		},
+		{
+			name: "EFA on unlisted family",
+			tmpl: base(Queue{Name: "new", InstanceTypes: []string{"c8gn.48xlarge"}, MaxCount: 10, PlacementGroup: true, EFA: &QueueEFA{Enabled: true}}),
+			want: []string{`queue "new" enables EFA on instance type 'c8gn.48xlarge', which pctl cannot confirm supports EFA`},
+		},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validator.ValidateTemplate(tt.tmpl); err != nil {
+				t.Fatalf("ValidateTemplate() unexpected error = %v", err)
+			}
+			warnings := validator.Warnings(tt.tmpl, tt.price)
+			if len(warnings) != len(tt.want) {
+				t.Fatalf("Warnings() = %q, want %d warning(s)", warnings, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("Warnings()[%d] = %q, want it to contain %q", i, warnings[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatorSchedulerAccounting(t *testing.T) {
+	secret := "arn:aws:secretsmanager:us-east-1:123456789012:secret:slurmdb-AbCdEf"
+	external := func(modify func(*AccountingConfig)) AccountingConfig {
+		accounting := AccountingConfig{
+			Enabled:           true,
+			Database:          AccountingDatabaseExternal,
+			URI:               "slurmdb.abc123.us-east-1.rds.amazonaws.com:3306",
+			UserName:          "slurm",
+			PasswordSecretARN: secret,
+		}
+		modify(&accounting)
+		return accounting
+	}
+
+	tests := []struct {
+		name       string
+		accounting AccountingConfig
+		wantErr    string
+	}{
+		{name: "local", accounting: AccountingConfig{Enabled: true}},
+		{name: "explicit local", accounting: AccountingConfig{Enabled: true, Database: "local"}},
+		{name: "external", accounting: external(func(a *AccountingConfig) {})},
+		{name: "external with database name", accounting: external(func(a *AccountingConfig) { a.DatabaseName = "lab_accounting" })},
+		{name: "external host without port", accounting: external(func(a *AccountingConfig) { a.URI = "slurmdb.internal" })},
+		{name: "disabled is not checked", accounting: AccountingConfig{Database: "postgres"}},
+		{name: "unknown database", accounting: AccountingConfig{Enabled: true, Database: "postgres"}, wantErr: "database 'postgres' must be local or external"},
+		{name: "local with uri", accounting: AccountingConfig{Enabled: true, URI: "db:3306"}, wantErr: "accounting.uri is only supported with database: external"},
+		{name: "local with secret", accounting: AccountingConfig{Enabled: true, PasswordSecretARN: secret}, wantErr: "password_secret_arn is only supported"},
+		{name: "external without uri", accounting: external(func(a *AccountingConfig) { a.URI = "" }), wantErr: "uri is required for an external database"},
+		{name: "external uri with scheme", accounting: external(func(a *AccountingConfig) { a.URI = "mysql://db:3306" }), wantErr: "must be host or host:port"},
+		{name: "external without user", accounting: external(func(a *AccountingConfig) { a.UserName = "" }), wantErr: "user_name is required"},
+		{name: "external without secret", accounting: external(func(a *AccountingConfig) { a.PasswordSecretARN = "" }), wantErr: "password_secret_arn is required"},
+		{name: "external with ssm parameter", accounting: external(func(a *AccountingConfig) {
+			a.PasswordSecretARN = "arn:aws:ssm:us-east-1:123456789012:parameter/slurmdb"
+		}), wantErr: "is not a Secrets Manager secret ARN"},
+		{name: "external with invalid database name", accounting: external(func(a *AccountingConfig) { a.DatabaseName = "lab-db" }), wantErr: "database_name 'lab-db' must be"},
+	}
+
+	validator := NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounting := tt.accounting
+			tmpl := Template{
+				Cluster: ClusterConfig{Name: "test-cluster", Region: "us-east-1"},
+				Compute: ComputeConfig{
+					HeadNode: "t3.medium",
+					Queues:   []Queue{{Name: "compute", InstanceTypes: []string{"c5.xlarge"}, MaxCount: 10}},
+				},
+				Scheduler: SchedulerConfig{Accounting: &accounting},
+			}
+			err := validator.ValidateTemplate(&tmpl)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTemplate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTemplate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
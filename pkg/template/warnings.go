@@ -0,0 +1,132 @@
+// Copyright 2025 Scott Friedman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import "fmt"
+
+// HoursPerMonth is the average number of hours in a month, used to turn
+// hourly prices into monthly idle cost estimates.
+const HoursPerMonth = 730
+
+// PriceFunc returns the on-demand hourly price of an instance type in a region.
+type PriceFunc func(region, instanceType string) (float64, error)
+
+// Warnings returns non-fatal findings about a template that is otherwise
+// valid. Queues with static nodes keep instances running around the clock,
+// so each one is reported with an idle cost estimate when price is non-nil
+// and the price of its instance types can be found. EFA queues outside a
+// placement group, and EFA on instance families pctl cannot confirm support
+// it, are reported too.
+func (v *Validator) Warnings(t *Template, price PriceFunc) []string {
+	var warnings []string
+	for _, issue := range v.warningIssues(t, price) {
+		warnings = append(warnings, issue.Message)
+	}
+	return warnings
+}
+
+// warningIssues returns the findings behind Warnings with the queue each
+// one concerns.
+func (v *Validator) warningIssues(t *Template, price PriceFunc) []ValidationIssue {
+	var warnings []ValidationIssue
+	warn := func(i int, msg string) {
+		warnings = append(warnings, ValidationIssue{
+			Field:    fmt.Sprintf("compute.queues[%d]", i),
+			Message:  msg,
+			Severity: SeverityWarning,
+		})
+	}
+	for i, queue := range t.Compute.Queues {
+		nodes := queue.StaticNodes()
+		if nodes <= 0 {
+			continue
+		}
+
+		msg := fmt.Sprintf("queue %q keeps %d node(s) running 24/7", queue.Name, nodes)
+		if cost, ok := idleMonthlyCost(t.Cluster.Region, queue, price); ok {
+			msg += fmt.Sprintf(" (about $%.0f/month while idle)", cost)
+		}
+		msg += "; set min_count and static_count to 0 to scale to zero when idle"
+		warn(i, msg)
+	}
+	for i, queue := range t.Compute.Queues {
+		if queue.EFAEnabled() && !queue.PlacementGroup {
+			warn(i, fmt.Sprintf("queue %q uses EFA without placement_group; set placement_group: true so nodes get the low-latency networking EFA is meant for", queue.Name))
+		}
+		if !queue.EFAEnabled() {
+			continue
+		}
+		for _, instanceType := range queue.AllInstanceTypes() {
+			if !efaFamilyListed(instanceType) {
+				warn(i, fmt.Sprintf("queue %q enables EFA on instance type '%s', which pctl cannot confirm supports EFA; cluster creation fails if it does not", queue.Name, instanceType))
+			}
+		}
+	}
+	return warnings
+}
+
+// idleMonthlyCost estimates the monthly cost of a queue's static nodes,
+// priced at each group's first instance type. It reports false if any
+// price is unavailable.
+func idleMonthlyCost(region string, queue Queue, price PriceFunc) (float64, bool) {
+	if price == nil {
+		return 0, false
+	}
+
+	type group struct {
+		instanceType string
+		nodes        int
+	}
+	var groups []group
+	if len(queue.ComputeResources) > 0 {
+		for _, r := range queue.ComputeResources {
+			if r.StaticCount > 0 && len(r.InstanceTypes) > 0 {
+				groups = append(groups, group{r.InstanceTypes[0], r.StaticCount})
+			}
+		}
+	} else if len(queue.InstanceTypes) > 0 {
+		groups = append(groups, group{queue.InstanceTypes[0], queue.StaticNodes()})
+	}
+	if len(groups) == 0 {
+		return 0, false
+	}
+
+	total := 0.0
+	for _, g := range groups {
+		hourly, err := price(region, g.instanceType)
+		if err != nil {
+			return 0, false
+		}
+		total += hourly * float64(g.nodes) * HoursPerMonth
+	}
+	return total, true
+}
+
+// ScaleToZero sets every queue's and compute resource's static node count
+// to zero, so all compute nodes launch on demand. It returns the number of
+// static nodes removed.
+func (t *Template) ScaleToZero() int {
+	removed := 0
+	for i := range t.Compute.Queues {
+		queue := &t.Compute.Queues[i]
+		removed += queue.StaticNodes()
+		queue.MinCount = 0
+		queue.StaticCount = 0
+		for j := range queue.ComputeResources {
+			queue.ComputeResources[j].StaticCount = 0
+		}
+	}
+	return removed
+}